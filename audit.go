@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/titpetric/cli"
+
+	"github.com/titpetric/atkins/colors"
+	"github.com/titpetric/atkins/eventlog"
+	"github.com/titpetric/atkins/runner"
+	"github.com/titpetric/atkins/treeview"
+)
+
+// Audit provides a cli.Command with the security checks of a Go module,
+// and the verification of the audit log of executed commands.
+func Audit() *cli.Command {
+	var (
+		format   string
+		output   string
+		baseline string
+		report   string
+		update   bool
+	)
+
+	return &cli.Command{
+		Name:  "audit",
+		Title: "Generate an SBOM, check for vulnerabilities or verify the audit log",
+		Usage: func() string {
+			return "atkins audit sbom [--format cyclonedx|spdx] [--output file]\n" +
+				"atkins audit vulns [--baseline file] [--report file] [--update-baseline]\n" +
+				"atkins audit verify [audit-log]"
+		},
+		Bind: func(fs *pflag.FlagSet) {
+			fs.StringVar(&format, "format", runner.SBOMCycloneDX, "SBOM format, cyclonedx or spdx")
+			fs.StringVarP(&output, "output", "o", "", "Write the SBOM to a file instead of stdout")
+			fs.StringVar(&baseline, "baseline", runner.VulnBaselinePath, "Report listing the accepted vulnerabilities")
+			fs.StringVar(&report, "report", "", "Write the vulnerability report to a file, defaults to $"+runner.ArtifactsEnv+"/vulns.json")
+			fs.BoolVar(&update, "update-baseline", false, "Accept the current vulnerabilities by writing them to the baseline")
+		},
+		Run: func(ctx context.Context, args []string) error {
+			if len(args) >= 1 && args[0] == "verify" && len(args) <= 2 {
+				return runAuditVerify(args[1:])
+			}
+			if len(args) == 1 {
+				switch args[0] {
+				case "sbom":
+					return runAuditSBOM(ctx, format, output)
+				case "vulns":
+					if report == "" && os.Getenv(runner.ArtifactsEnv) != "" {
+						report = filepath.Join(os.Getenv(runner.ArtifactsEnv), "vulns.json")
+					}
+					return runAuditVulns(ctx, baseline, report, update)
+				}
+			}
+			return fmt.Errorf("%s expected: audit sbom, audit vulns or audit verify", treeview.ErrorHeader())
+		},
+	}
+}
+
+// runAuditVerify checks the hash chain of the audit log of executed commands.
+func runAuditVerify(args []string) error {
+	var path string
+	if len(args) == 1 {
+		path = args[0]
+	} else {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+		}
+		path = filepath.Join(home, eventlog.AuditLogPath)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+	defer f.Close()
+
+	n, err := eventlog.VerifyAuditLog(f)
+	if err != nil {
+		return fmt.Errorf("%s %s: %v (%d entries verified)", treeview.ErrorHeader(), path, err, n)
+	}
+	fmt.Printf("%s %s: %d entries, hash chain intact\n", colors.BrightGreen("✓"), path, n)
+	return nil
+}
+
+func runAuditSBOM(ctx context.Context, format, output string) error {
+	out, err := exec.CommandContext(ctx, "go", "list", "-m", "-json", "all").Output()
+	if err != nil {
+		return fmt.Errorf("%s go list: %v", treeview.ErrorHeader(), err)
+	}
+	modules, err := runner.ParseGoModules(bytes.NewReader(out))
+	if err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+	sbom, err := runner.GenerateSBOM(modules, format, time.Now())
+	if err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+
+	if output == "" {
+		fmt.Println(string(sbom))
+		return nil
+	}
+	if err := writeFile(output, append(sbom, '\n')); err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+	fmt.Printf("%s %s SBOM with %d modules written to %s\n", colors.BrightGreen("✓"), format, len(modules), output)
+	return nil
+}
+
+func runAuditVulns(ctx context.Context, baseline, reportPath string, update bool) error {
+	// govulncheck exits non-zero in text mode only, the JSON stream is parsed here
+	out, err := exec.CommandContext(ctx, "govulncheck", "-format", "json", "./...").Output()
+	if err != nil {
+		return fmt.Errorf("%s govulncheck: %v", treeview.ErrorHeader(), err)
+	}
+	report, err := runner.ParseGovulncheck(bytes.NewReader(out))
+	if err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+	if err := runner.MarkNewVulnerabilities(report, baseline); err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if reportPath != "" {
+		if err := writeFile(reportPath, data); err != nil {
+			return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+		}
+	}
+	if update {
+		if err := writeFile(baseline, data); err != nil {
+			return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+		}
+		fmt.Printf("%s %d vulnerabilities accepted in %s\n", colors.BrightGreen("✓"), len(report.Vulnerabilities), baseline)
+		return nil
+	}
+
+	for _, v := range report.Vulnerabilities {
+		marker := colors.Gray("~")
+		if v.New {
+			marker = colors.BrightRed("!")
+		}
+		fixed := "no fix"
+		if v.FixedVersion != "" {
+			fixed = "fixed in " + v.FixedVersion
+		}
+		fmt.Printf("%s %s %s@%s (%s): %s\n", marker, v.ID, v.Module, v.Version, fixed, v.Summary)
+	}
+
+	if count := report.NewCount(); count > 0 {
+		return fmt.Errorf("%s %d new vulnerabilities, see the report or accept them with --update-baseline", treeview.ErrorHeader(), count)
+	}
+	fmt.Printf("%s no new vulnerabilities (%d known)\n", colors.BrightGreen("✓"), len(report.Vulnerabilities))
+	return nil
+}
+
+// writeFile writes data to path, creating the parent directory.
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/pflag"
+	"github.com/titpetric/cli"
+
+	"github.com/titpetric/atkins/colors"
+	"github.com/titpetric/atkins/model"
+	"github.com/titpetric/atkins/runner"
+	"github.com/titpetric/atkins/treeview"
+)
+
+// Bundle provides a cli.Command packaging the pipeline of a project for
+// air-gapped use, and running the pipeline of a bundle.
+func Bundle() *cli.Command {
+	opts := NewOptions()
+
+	return &cli.Command{
+		Name:  "bundle",
+		Title: "Package the pipeline, skills and pinned tools to run offline",
+		Usage: func() string {
+			return "atkins bundle create <bundle.tar.zst>\n" +
+				"atkins bundle run [flags] <bundle.tar.zst> [jobs...]"
+		},
+		Bind: func(fs *pflag.FlagSet) {
+			opts.Bind(fs)
+		},
+		Run: func(ctx context.Context, args []string) error {
+			if len(args) == 2 && args[0] == "create" {
+				return runBundleCreate(ctx, args[1])
+			}
+			if len(args) >= 2 && args[0] == "run" {
+				return runBundleRun(ctx, opts, args[1], args[2:])
+			}
+			return fmt.Errorf("%s expected: bundle create <file>, or bundle run <file> [jobs...]", treeview.ErrorHeader())
+		},
+	}
+}
+
+// runBundleCreate writes a bundle of the project pipeline, its effective
+// skills and the cached versions of its pinned tools.
+func runBundleCreate(ctx context.Context, output string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+	pipelines, _, configDir, err := discoverPipelines(cwd)
+	if err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+	if len(pipelines) == 0 {
+		return fmt.Errorf("%s no pipeline file found", treeview.ErrorHeader())
+	}
+
+	extendsDirs := []string{filepath.Join(configDir, ".atkins", "skills")}
+	if dir, err := globalSkillsDir(); err == nil {
+		extendsDirs = append(extendsDirs, dir)
+	}
+	bundle, err := runner.NewBundle(pipelines, configDir, extendsDirs)
+	if err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+	bundle.Manifest.Version = Version
+
+	for _, tool := range bundle.Missing {
+		fmt.Fprintf(os.Stderr, "%s %s isn't in %s, the bundle uses the version on PATH\n", treeview.WarningHeader(), tool, runner.ToolCacheDir)
+	}
+	if err := bundle.Write(ctx, output); err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+	fmt.Printf("%s %s (%d files, %d skills, %d tools)\n", colors.BrightGreen("✓"), output, len(bundle.Files)+1, len(bundle.Manifest.Skills), len(bundle.Manifest.Tools))
+	return nil
+}
+
+// runBundleRun extracts a bundle and runs its pipeline in the working
+// directory, without global skills, policy, audit log or job cache from
+// $HOME, and without fetching remote pipelines.
+func runBundleRun(ctx context.Context, opts *Options, file string, jobs []string) error {
+	if opts.File != "" || opts.Project != "" || opts.Recursive {
+		return fmt.Errorf("%s bundle run can't be combined with --file, --project or --recursive", treeview.ErrorHeader())
+	}
+
+	dir, err := os.MkdirTemp("", "atkins-bundle-*")
+	if err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+	defer cleanup()
+	exitHooks = append(exitHooks, cleanup)
+
+	manifest, err := runner.ExtractBundle(ctx, file, dir)
+	if err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+
+	opts.BundleDir = dir
+	if manifest.Config != "" {
+		opts.File = filepath.Join(dir, manifest.Config)
+	}
+	opts.Jail = true
+	runner.ToolCacheDir = filepath.Join(dir, runner.ToolCacheDir)
+
+	return runPipeline(ctx, opts, jobs)
+}
+
+// loadBundlePipelines loads the pipeline and the skills of an extracted
+// bundle. Skills are enabled by the files in cwd, where their steps run.
+func loadBundlePipelines(bundleDir, configPath, cwd string) (pipelines, shadowed []*model.Pipeline, err error) {
+	if configPath != "" {
+		pipelines, err = runner.LoadPipeline(configPath)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	loader := runner.NewSkillsLoader(cwd, cwd)
+	loader.SkillsDirs = []string{filepath.Join(bundleDir, ".atkins", "skills")}
+	loader.ExtendsDirs = []string{filepath.Join(bundleDir, runner.BundleExtendsDir)}
+	loader.Markers, err = runner.LoadMarkers(filepath.Join(bundleDir, ".atkins", runner.MarkersFile))
+	if err != nil {
+		return nil, nil, err
+	}
+	skills, err := loader.Load()
+	if err != nil {
+		return nil, nil, err
+	}
+	return append(pipelines, skills...), loader.Shadowed, nil
+}
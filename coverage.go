@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/spf13/pflag"
+	"github.com/titpetric/cli"
+
+	"github.com/titpetric/atkins/colors"
+	"github.com/titpetric/atkins/eventlog"
+	"github.com/titpetric/atkins/treeview"
+)
+
+// Coverage provides a cli.Command for the coverage recorded in the run index.
+func Coverage() *cli.Command {
+	var (
+		baseline string
+		maxDrop  float64
+	)
+
+	return &cli.Command{
+		Name:  "coverage",
+		Title: "Show the coverage trend of recorded runs",
+		Usage: func() string {
+			return "atkins coverage trend [--baseline branch] [--max-drop percent]"
+		},
+		Bind: func(fs *pflag.FlagSet) {
+			fs.StringVar(&baseline, "baseline", "", "Also compare with the last run on this branch")
+			fs.Float64Var(&maxDrop, "max-drop", 0, "Fail when total coverage drops by more than this many percentage points")
+		},
+		Run: func(ctx context.Context, args []string) error {
+			if len(args) == 1 && args[0] == "trend" {
+				return runCoverageTrend(baseline, maxDrop)
+			}
+			return fmt.Errorf("%s expected: coverage trend", treeview.ErrorHeader())
+		},
+	}
+}
+
+func runCoverageTrend(baseline string, maxDrop float64) error {
+	indexPath, err := runIndexPath()
+	if err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+	entries, err := eventlog.LoadRunIndex(indexPath)
+	if err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+
+	runs := eventlog.CoverageRuns(entries)
+	if len(runs) == 0 {
+		fmt.Println("No coverage recorded. Coverage is recorded for go test -coverprofile when --log is used.")
+		return nil
+	}
+
+	current := runs[len(runs)-1]
+	var previous, base *eventlog.RunIndexEntry
+	if len(runs) > 1 {
+		previous = runs[len(runs)-2]
+	}
+	if baseline != "" {
+		base = eventlog.LastBranchRun(runs[:len(runs)-1], baseline)
+		if base == nil {
+			fmt.Printf("%s no coverage recorded on branch %s\n", colors.BrightYellow("!"), baseline)
+		}
+	}
+
+	fmt.Printf("Run %s on %s\n\n", current.RunID, current.CreatedAt.Format("2006-01-02 15:04:05"))
+	printCoverageLine("total", current.Coverage.Total, previous, base, func(c *eventlog.Coverage) (float64, bool) {
+		return c.Total, true
+	})
+	for _, pkg := range slices.Sorted(maps.Keys(current.Coverage.Packages)) {
+		printCoverageLine(pkg, current.Coverage.Packages[pkg], previous, base, func(c *eventlog.Coverage) (float64, bool) {
+			value, ok := c.Packages[pkg]
+			return value, ok
+		})
+	}
+
+	if maxDrop <= 0 {
+		return nil
+	}
+	for _, other := range []*eventlog.RunIndexEntry{previous, base} {
+		if other == nil {
+			continue
+		}
+		if drop := other.Coverage.Total - current.Coverage.Total; drop > maxDrop {
+			return fmt.Errorf("%s coverage dropped %.1f%% compared to run %s, more than %.1f%%", treeview.ErrorHeader(), drop, other.RunID, maxDrop)
+		}
+	}
+	return nil
+}
+
+// printCoverageLine prints a coverage percentage and its changes
+// compared to the previous and baseline runs.
+func printCoverageLine(name string, value float64, previous, base *eventlog.RunIndexEntry, get func(*eventlog.Coverage) (float64, bool)) {
+	line := fmt.Sprintf("%6.1f%%  %s", value, name)
+	if previous != nil {
+		if before, ok := get(previous.Coverage); ok {
+			line += "  " + coverageDelta(value-before) + " vs previous"
+		}
+	}
+	if base != nil {
+		if before, ok := get(base.Coverage); ok {
+			line += "  " + coverageDelta(value-before) + " vs " + base.Branch
+		}
+	}
+	fmt.Println(line)
+}
+
+func coverageDelta(delta float64) string {
+	text := fmt.Sprintf("%+.1f%%", delta)
+	switch {
+	case delta > 0.05:
+		return colors.BrightGreen(text)
+	case delta < -0.05:
+		return colors.BrightRed(text)
+	}
+	return colors.Gray(text)
+}
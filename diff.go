@@ -0,0 +1,256 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"github.com/titpetric/cli"
+
+	"github.com/titpetric/atkins/colors"
+	"github.com/titpetric/atkins/eventlog"
+	"github.com/titpetric/atkins/runner"
+	"github.com/titpetric/atkins/treeview"
+)
+
+// Diff provides a cli.Command that prints a structural diff of two pipeline files.
+func Diff() *cli.Command {
+	return &cli.Command{
+		Name:  "diff",
+		Title: "Diff two pipeline files",
+		Usage: func() string {
+			return "atkins diff <a.yml> <b.yml>"
+		},
+		Run: func(ctx context.Context, args []string) error {
+			if len(args) != 2 {
+				return fmt.Errorf("%s expected two pipeline files", treeview.ErrorHeader())
+			}
+			return runDiff(args[0], args[1])
+		},
+	}
+}
+
+// Runs provides a cli.Command for inspecting recorded runs.
+func Runs() *cli.Command {
+	var color bool
+
+	return &cli.Command{
+		Name:  "runs",
+		Title: "Inspect recorded runs",
+		Usage: func() string {
+			return "atkins runs show [--color] <run-id>\n" +
+				"atkins runs diff <run-id> <run-id>\n" +
+				"atkins runs gantt <run-id>...\n" +
+				"atkins runs convert <run-id|log file> <output.yml[.gz]>"
+		},
+		Bind: func(fs *pflag.FlagSet) {
+			fs.BoolVar(&color, "color", false, "Show the command output with the colors it was written with")
+		},
+		Run: func(ctx context.Context, args []string) error {
+			if len(args) == 2 && args[0] == "show" {
+				return runRunsShow(args[1], color)
+			}
+			if len(args) == 3 && args[0] == "diff" {
+				return runRunsDiff(args[1], args[2])
+			}
+			if len(args) >= 2 && args[0] == "gantt" {
+				return runRunsGantt(args[1:])
+			}
+			if len(args) == 3 && args[0] == "convert" {
+				return runRunsConvert(args[1], args[2])
+			}
+			return fmt.Errorf("%s expected: runs show <run-id>, runs diff <run-id> <run-id>, runs gantt <run-id>..., or runs convert <run-id> <output>", treeview.ErrorHeader())
+		},
+	}
+}
+
+func runDiff(fileA, fileB string) error {
+	a, err := runner.LoadPipeline(fileA)
+	if err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+	b, err := runner.LoadPipeline(fileB)
+	if err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+
+	changes, err := runner.DiffPipelines(a[0], b[0])
+	if err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+
+	if len(changes) == 0 {
+		fmt.Printf("%s No differences\n", colors.BrightGreen("✓"))
+		return nil
+	}
+
+	for _, change := range changes {
+		switch {
+		case change.Before == "":
+			fmt.Printf("%s %s: %s\n", colors.BrightGreen("+"), change.Path, change.After)
+		case change.After == "":
+			fmt.Printf("%s %s: %s\n", colors.BrightRed("-"), change.Path, change.Before)
+		default:
+			fmt.Printf("%s %s: %s → %s\n", colors.BrightYellow("~"), change.Path, change.Before, change.After)
+		}
+	}
+	return nil
+}
+
+// loadRuns reads the event logs of recorded runs by run ID or prefix.
+func loadRuns(ids ...string) ([]*eventlog.Log, error) {
+	indexPath, err := runIndexPath()
+	if err != nil {
+		return nil, fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+
+	entries, err := eventlog.LoadRunIndex(indexPath)
+	if err != nil {
+		return nil, fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+
+	logs := make([]*eventlog.Log, 0, len(ids))
+	for _, id := range ids {
+		entry := eventlog.FindRun(entries, id)
+		if entry == nil {
+			return nil, fmt.Errorf("%s run %q not found in %s", treeview.ErrorHeader(), id, indexPath)
+		}
+		log, err := eventlog.ReadLog(entry.LogFile)
+		if err != nil {
+			return nil, fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+		}
+		logs = append(logs, log)
+	}
+	return logs, nil
+}
+
+// runRunsShow prints a recorded run and the output of its commands. With
+// color, the output keeps the ANSI colors the commands wrote.
+func runRunsShow(id string, color bool) error {
+	logs, err := loadRuns(id)
+	if err != nil {
+		return err
+	}
+	printRunEvents(os.Stdout, logs[0], color)
+	return nil
+}
+
+// printRunEvents prints the result of a run, followed by the commands of
+// the run with their output.
+func printRunEvents(w io.Writer, log *eventlog.Log, color bool) {
+	fmt.Fprintf(w, "%s %s\n", colors.BrightWhite("Run:"), log.Metadata.RunID)
+	fmt.Fprintf(w, "%s %s\n", colors.BrightWhite("Pipeline:"), log.Metadata.Pipeline)
+	if log.Summary != nil {
+		result := colors.BrightGreen(string(log.Summary.Result))
+		if log.Summary.Result == eventlog.ResultFail {
+			result = colors.BrightRed(string(log.Summary.Result))
+		}
+		fmt.Fprintf(w, "%s %s in %.2fs\n", colors.BrightWhite("Result:"), result, log.Summary.Duration)
+	}
+
+	for _, event := range log.Events {
+		if event.Command == "" || event.Type == eventlog.EventTypeSubstitution {
+			continue
+		}
+		symbol := colors.BrightGreen("✓")
+		if event.ExitCode != 0 || event.Error != "" {
+			symbol = colors.BrightRed("✗")
+		}
+		output := colors.StripANSI(event.Output)
+		if color {
+			output = event.ColorOutput()
+		}
+
+		fmt.Fprintf(w, "\n%s %s %s\n", symbol, event.ID, colors.Gray(fmt.Sprintf("%.2fs", event.Duration)))
+		fmt.Fprintf(w, "  %s %s\n", colors.Dim("$"), event.Command)
+		for _, line := range strings.Split(strings.TrimRight(output, "\n"), "\n") {
+			if line != "" {
+				fmt.Fprintf(w, "  %s\n", line)
+			}
+		}
+		if event.Error != "" {
+			for _, line := range strings.Split(strings.TrimRight(event.Error, "\n"), "\n") {
+				fmt.Fprintf(w, "  %s\n", colors.BrightRed(line))
+			}
+		}
+	}
+}
+
+func runRunsDiff(idA, idB string) error {
+	logs, err := loadRuns(idA, idB)
+	if err != nil {
+		return err
+	}
+
+	for _, d := range eventlog.DiffRuns(logs[0], logs[1]) {
+		result := formatResultDelta(d.BeforeResult, d.AfterResult)
+		delta := fmt.Sprintf("%+.2fs", d.Delta())
+		switch {
+		case d.Delta() > 0:
+			delta = colors.BrightRed(delta)
+		case d.Delta() < 0:
+			delta = colors.BrightGreen(delta)
+		}
+		fmt.Printf("%s %s %.2fs → %.2fs (%s)\n", d.ID, result, d.BeforeDuration, d.AfterDuration, delta)
+	}
+	return nil
+}
+
+// runRunsGantt prints a gantt chart of recorded runs, with several runs
+// side by side for comparison.
+func runRunsGantt(ids []string) error {
+	logs, err := loadRuns(ids...)
+	if err != nil {
+		return err
+	}
+	return eventlog.Gantt(os.Stdout, logs...)
+}
+
+// runRunsConvert rewrites the event log of a run, or a log file, in the
+// format selected by the extension of output, e.g. run.yml.gz.
+func runRunsConvert(source, output string) error {
+	src := source
+	if info, err := os.Stat(source); err != nil || !info.Mode().IsRegular() {
+		indexPath, err := runIndexPath()
+		if err != nil {
+			return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+		}
+		entries, err := eventlog.LoadRunIndex(indexPath)
+		if err != nil {
+			return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+		}
+		entry := eventlog.FindRun(entries, source)
+		if entry == nil {
+			return fmt.Errorf("%s run %q not found in %s", treeview.ErrorHeader(), source, indexPath)
+		}
+		src = entry.LogFile
+	}
+
+	if err := eventlog.ConvertLog(src, output); err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+	fmt.Printf("%s %s → %s\n", colors.BrightGreen("✓"), src, output)
+	return nil
+}
+
+// formatResultDelta formats the result change of a step, using "-" for missing results.
+func formatResultDelta(before, after eventlog.Result) string {
+	format := func(r eventlog.Result) string {
+		switch r {
+		case "":
+			return colors.Dim("-")
+		case eventlog.ResultFail:
+			return colors.BrightRed(string(r))
+		case eventlog.ResultPass:
+			return colors.BrightGreen(string(r))
+		}
+		return colors.Dim(string(r))
+	}
+	if before == after {
+		return format(before)
+	}
+	return format(before) + " → " + format(after)
+}
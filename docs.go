@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/pflag"
+	"github.com/titpetric/cli"
+
+	"github.com/titpetric/atkins/colors"
+	"github.com/titpetric/atkins/runner"
+	"github.com/titpetric/atkins/treeview"
+)
+
+// Docs provides a cli.Command generating Markdown documentation of the
+// effective pipeline.
+func Docs() *cli.Command {
+	var (
+		output string
+		check  bool
+	)
+
+	return &cli.Command{
+		Name:  "docs",
+		Title: "Generate Markdown documentation of the pipeline",
+		Usage: func() string {
+			return "atkins docs [-o file.md] [--check]"
+		},
+		Bind: func(fs *pflag.FlagSet) {
+			fs.StringVarP(&output, "output", "o", "", "Write the documentation to a file instead of stdout")
+			fs.BoolVar(&check, "check", false, "Fail if the file of --output isn't up to date")
+		},
+		Run: func(ctx context.Context, args []string) error {
+			if len(args) != 0 {
+				return fmt.Errorf("%s expected: docs [-o file.md] [--check]", treeview.ErrorHeader())
+			}
+			if check && output == "" {
+				return fmt.Errorf("%s --check needs --output, the file to compare with", treeview.ErrorHeader())
+			}
+			return runDocs(output, check)
+		},
+	}
+}
+
+func runDocs(output string, check bool) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+	pipelines, _, configDir, err := discoverPipelines(cwd)
+	if err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+	if len(pipelines) == 0 {
+		return fmt.Errorf("%s no pipeline file found", treeview.ErrorHeader())
+	}
+
+	docs := []byte(runner.PipelineDocs(pipelines, configDir))
+	if output == "" {
+		_, err := os.Stdout.Write(docs)
+		return err
+	}
+
+	current, err := os.ReadFile(output)
+	if err != nil && (check || !os.IsNotExist(err)) {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+	if bytes.Equal(current, docs) {
+		fmt.Printf("%s %s is up to date\n", colors.BrightGreen("✓"), output)
+		return nil
+	}
+	if check {
+		return fmt.Errorf("%s %s is out of date, run atkins docs -o %s", treeview.ErrorHeader(), output, output)
+	}
+	if err := os.WriteFile(output, docs, 0o644); err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+	fmt.Printf("%s %s\n", colors.BrightGreen("✓"), output)
+	return nil
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"github.com/titpetric/cli"
+	"golang.org/x/term"
+
+	"github.com/titpetric/atkins/colors"
+	"github.com/titpetric/atkins/model"
+	"github.com/titpetric/atkins/runner"
+	"github.com/titpetric/atkins/treeview"
+)
+
+// Edit provides a cli.Command opening a job in $EDITOR, validating the
+// pipeline after each save.
+func Edit() *cli.Command {
+	var dryRun bool
+
+	return &cli.Command{
+		Name:  "edit",
+		Title: "Edit a job in $EDITOR and validate it",
+		Usage: func() string {
+			return "atkins edit [--dry-run] <job>"
+		},
+		Bind: func(fs *pflag.FlagSet) {
+			fs.BoolVar(&dryRun, "dry-run", false, "Print the jobs and commands a run of the job would execute after saving")
+		},
+		Run: func(ctx context.Context, args []string) error {
+			if len(args) != 1 {
+				return fmt.Errorf("%s expected: edit <job>", treeview.ErrorHeader())
+			}
+			return runEdit(ctx, args[0], dryRun)
+		},
+	}
+}
+
+// runEdit opens the lines of a job in the editor and lints the pipeline
+// with the edited job. The file is only written when the pipeline is
+// valid, or when asked to keep the errors.
+func runEdit(ctx context.Context, name string, dryRun bool) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+	pipelines, _, _, err := discoverPipelines(cwd)
+	if err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+	task, err := runner.NewTaskResolver(pipelines).ResolveName(name, false)
+	if err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+	source := task.Pipeline.Source
+	if source == "" || strings.Contains(source, "://") || strings.HasPrefix(source, "embedded:") {
+		return fmt.Errorf("%s job %q isn't defined in a local file", treeview.ErrorHeader(), task.Name)
+	}
+
+	data, err := os.ReadFile(source)
+	if err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+	start, end, err := runner.JobRegion(data, task.Job.Name)
+	if err != nil {
+		return fmt.Errorf("%s %s: %v", treeview.ErrorHeader(), source, err)
+	}
+	region := []byte(strings.Join(strings.Split(string(data), "\n")[start-1:end], "\n") + "\n")
+
+	tmp, err := os.CreateTemp("", "atkins-edit-*.yml")
+	if err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(region); err != nil {
+		tmp.Close()
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+	tmp.Close()
+
+	stdin := bufio.NewReader(os.Stdin)
+	for {
+		if err := openEditor(ctx, tmp.Name()); err != nil {
+			return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+		}
+		edited, err := os.ReadFile(tmp.Name())
+		if err != nil {
+			return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+		}
+		if bytes.Equal(edited, region) {
+			fmt.Printf("%s %s: no changes\n", colors.Gray("-"), task.Name)
+			return nil
+		}
+
+		updated := runner.ReplaceLines(data, start, end, edited)
+		pipeline, problems := lintEdited(updated, task.Pipeline, pipelines)
+		if len(problems) == 0 {
+			if err := writeEdited(source, updated); err != nil {
+				return err
+			}
+			fmt.Printf("%s %s: %s is valid\n", colors.BrightGreen("✓"), source, task.Name)
+			if dryRun {
+				return printDryRun(pipeline, task.Job.Name)
+			}
+			return nil
+		}
+
+		fmt.Printf("%s %s: %s has errors:\n", colors.BrightRed("✗"), source, task.Name)
+		for _, problem := range problems {
+			fmt.Printf("  %s\n", problem)
+		}
+		if !term.IsTerminal(int(os.Stdin.Fd())) {
+			return fmt.Errorf("%s %s wasn't changed", treeview.ErrorHeader(), source)
+		}
+
+		for answered := false; !answered; {
+			fmt.Printf("%s [e]dit again, [w]rite anyway, [d]iscard (default edit): ", colors.BrightYellow("?"))
+			line, err := stdin.ReadString('\n')
+			if err != nil {
+				return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+			}
+			switch strings.ToLower(strings.TrimSpace(line)) {
+			case "", "e", "edit":
+				answered = true
+			case "w", "write":
+				if err := writeEdited(source, updated); err != nil {
+					return err
+				}
+				fmt.Printf("%s %s written with errors\n", colors.BrightYellow("!"), source)
+				return nil
+			case "d", "discard":
+				fmt.Printf("%s %s wasn't changed\n", colors.Gray("-"), source)
+				return nil
+			}
+		}
+	}
+}
+
+// lintEdited parses the edited pipeline file and lints it with the other
+// pipelines. It returns the pipeline and the problems found.
+func lintEdited(data []byte, original *model.Pipeline, pipelines []*model.Pipeline) (*model.Pipeline, []string) {
+	loaded, err := runner.LoadPipelineFromReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, []string{err.Error()}
+	}
+	pipeline := loaded[0]
+	pipeline.ID, pipeline.Source = original.ID, original.Source
+	if pipeline.Name == "" {
+		pipeline.Name = filepath.Base(original.Source)
+	}
+
+	all := slices.Clone(pipelines)
+	if i := slices.Index(all, original); i >= 0 {
+		all[i] = pipeline
+	}
+
+	var problems []string
+	for _, lintErr := range runner.NewLinterWithPipelines(pipeline, all).Lint() {
+		problems = append(problems, fmt.Sprintf("%s: %s", lintErr.Job, lintErr.Detail))
+	}
+	return pipeline, problems
+}
+
+// writeEdited writes the edited pipeline file, keeping its permissions.
+func writeEdited(source string, data []byte) error {
+	info, err := os.Stat(source)
+	if err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+	if err := os.WriteFile(source, data, info.Mode().Perm()); err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+	return nil
+}
+
+// printDryRun prints the jobs and commands a run of the job would execute.
+func printDryRun(pipeline *model.Pipeline, job string) error {
+	plan, err := runner.DryRun(pipeline, job)
+	if err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+	fmt.Printf("\n%s\n\n%s", colors.BrightWhite("Dry run:"), plan)
+	return nil
+}
+
+// openEditor opens a file in $VISUAL or $EDITOR, defaulting to vi. The
+// editor may include arguments, e.g. "code --wait".
+func openEditor(ctx context.Context, file string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	cmd := exec.CommandContext(ctx, "sh", "-c", editor+` "$1"`, "sh", file)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("editor %s: %w", editor, err)
+	}
+	return nil
+}
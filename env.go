@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/pflag"
+	"github.com/titpetric/cli"
+
+	"github.com/titpetric/atkins/runner"
+	"github.com/titpetric/atkins/treeview"
+)
+
+// Env provides a cli.Command printing the environment of a job.
+func Env() *cli.Command {
+	var (
+		all    bool
+		export string
+	)
+
+	return &cli.Command{
+		Name:  "env",
+		Title: "Show the environment a job receives",
+		Usage: func() string {
+			return "atkins env [--all] [--export bash|fish] <job>"
+		},
+		Bind: func(fs *pflag.FlagSet) {
+			fs.BoolVar(&all, "all", false, "Also list the variables inherited from the process environment")
+			fs.StringVar(&export, "export", "", "Print export statements for a shell instead: bash, fish")
+		},
+		Run: func(ctx context.Context, args []string) error {
+			if len(args) != 1 {
+				return fmt.Errorf("%s expected: env <job>", treeview.ErrorHeader())
+			}
+			return runEnv(ctx, args[0], all, export)
+		},
+	}
+}
+
+func runEnv(ctx context.Context, job string, all bool, export string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+	pipelines, _, configDir, err := discoverPipelines(cwd)
+	if err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+	task, err := runner.NewTaskResolver(pipelines).ResolveName(job, false)
+	if err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+
+	// Evaluate the environment from the project root, as a run does
+	if err := os.Chdir(configDir); err != nil {
+		return fmt.Errorf("%s failed to change directory to %s: %v", treeview.ErrorHeader(), configDir, err)
+	}
+	entries, err := runner.JobEnv(ctx, task.Pipeline, task.Job, all)
+	if err != nil {
+		return fmt.Errorf("%s job %q: %v", treeview.ErrorHeader(), task.Name, err)
+	}
+	if export != "" {
+		if err := runner.ExportJobEnv(os.Stdout, entries, export); err != nil {
+			return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+		}
+		return nil
+	}
+	runner.PrintJobEnv(os.Stdout, entries)
+	return nil
+}
@@ -0,0 +1,157 @@
+package eventlog
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// AuditLogPath is the audit log of executed commands, relative to $HOME.
+var AuditLogPath = filepath.Join(".atkins", "audit.log")
+
+// AuditLogOff disables the audit log when given as its path.
+const AuditLogOff = "off"
+
+// AuditEntry is an executed command in the audit log. Each entry holds
+// the hash of the previous one, so removed or edited entries break the
+// chain.
+type AuditEntry struct {
+	Time     time.Time `json:"time"`
+	RunID    string    `json:"run_id,omitempty"`
+	User     string    `json:"user"`
+	Dir      string    `json:"dir"`
+	Command  string    `json:"command"`
+	ExitCode int       `json:"exit_code"`
+	Prev     string    `json:"prev"`
+	Hash     string    `json:"hash"`
+}
+
+// hash returns the hash of the entry, chained to the previous hash.
+func (e AuditEntry) hash() string {
+	e.Hash = ""
+	data, _ := json.Marshal(e)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// AuditLog appends executed commands to an append-only audit file.
+type AuditLog struct {
+	mu   sync.Mutex
+	path string
+	user string
+}
+
+// NewAuditLog creates an audit log writing to path.
+func NewAuditLog(path string) *AuditLog {
+	name := os.Getenv("USER")
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	return &AuditLog{path: path, user: name}
+}
+
+// Append chains the entry to the last one in the file and appends it.
+// The file is locked, so concurrent runs append to the same chain.
+func (a *AuditLog) Append(entry AuditEntry) error {
+	if a == nil {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(a.path), 0o700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(a.path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		return err
+	}
+	defer syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+
+	last, err := lastLine(f)
+	if err != nil {
+		return err
+	}
+	if len(last) > 0 {
+		var prev AuditEntry
+		if err := json.Unmarshal(last, &prev); err != nil {
+			return fmt.Errorf("audit log %s: last entry: %w", a.path, err)
+		}
+		entry.Prev = prev.Hash
+	}
+
+	entry.User = a.user
+	entry.Hash = entry.hash()
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// lastLine returns the last line of the file, without reading all of it.
+func lastLine(f *os.File) ([]byte, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	end := info.Size()
+	var line []byte
+	for end > 0 {
+		size := min(end, 4096)
+		chunk := make([]byte, size)
+		if _, err := f.ReadAt(chunk, end-size); err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		line = append(chunk, line...)
+		end -= size
+
+		trimmed := bytes.TrimRight(line, "\n")
+		if i := bytes.LastIndexByte(trimmed, '\n'); i >= 0 {
+			return trimmed[i+1:], nil
+		}
+	}
+	return bytes.TrimRight(line, "\n"), nil
+}
+
+// VerifyAuditLog checks the hash chain of an audit log, returning the
+// number of entries. The error names the first line that doesn't chain.
+func VerifyAuditLog(r io.Reader) (int, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	var prev string
+	var n int
+	for scanner.Scan() {
+		n++
+		var entry AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return n - 1, fmt.Errorf("line %d: %w", n, err)
+		}
+		if entry.Prev != prev {
+			return n - 1, fmt.Errorf("line %d: previous hash doesn't match, an entry was removed or reordered", n)
+		}
+		if entry.Hash != entry.hash() {
+			return n - 1, fmt.Errorf("line %d: hash doesn't match, the entry was modified", n)
+		}
+		prev = entry.Hash
+	}
+	return n, scanner.Err()
+}
@@ -0,0 +1,55 @@
+package eventlog
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".atkins", "audit.log")
+	log := NewAuditLog(path)
+
+	for i, cmd := range []string{"go test ./...", "echo 'a\nb'", "exit 3"} {
+		require.NoError(t, log.Append(AuditEntry{
+			Time:     time.Date(2026, 1, 1, 0, 0, i, 0, time.UTC),
+			RunID:    "run",
+			Dir:      "/src",
+			Command:  cmd,
+			ExitCode: i,
+		}))
+	}
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 3)
+
+	n, err := VerifyAuditLog(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	t.Run("modified entry", func(t *testing.T) {
+		modified := strings.Replace(string(data), `"exit_code":1`, `"exit_code":0`, 1)
+		n, err := VerifyAuditLog(strings.NewReader(modified))
+		assert.ErrorContains(t, err, "line 2: hash doesn't match")
+		assert.Equal(t, 1, n)
+	})
+
+	t.Run("removed entry", func(t *testing.T) {
+		removed := lines[0] + "\n" + lines[2] + "\n"
+		_, err := VerifyAuditLog(strings.NewReader(removed))
+		assert.ErrorContains(t, err, "line 2: previous hash doesn't match")
+	})
+}
+
+func TestAuditLog_Nil(t *testing.T) {
+	var log *AuditLog
+	assert.NoError(t, log.Append(AuditEntry{Command: "true"}))
+}
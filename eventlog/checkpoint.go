@@ -0,0 +1,76 @@
+package eventlog
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// CheckpointDir holds the checkpoints of running pipelines, relative to the project root.
+var CheckpointDir = filepath.Join(".atkins", "runs", "checkpoints")
+
+// Checkpoint is the state of a running pipeline, written periodically
+// while it runs. A run that didn't finish, e.g. after a crash or a
+// reboot, resumes from its checkpoint with --resume.
+type Checkpoint struct {
+	RunID     string            `yaml:"run_id"`
+	Pipeline  string            `yaml:"pipeline,omitempty"`
+	Jobs      []string          `yaml:"jobs,omitempty"` // Jobs requested for the run
+	UpdatedAt time.Time         `yaml:"updated_at"`
+	Completed []string          `yaml:"completed,omitempty"` // Jobs that completed, by name
+	Steps     map[string]string `yaml:"steps,omitempty"`     // Results of the completed steps, by step ID
+	Hashes    map[string]string `yaml:"hashes,omitempty"`    // Content hashes of the started jobs, by name
+}
+
+var checkpointNameExpr = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// CheckpointPath returns the checkpoint file of a pipeline in dir.
+func CheckpointPath(dir, pipeline string) string {
+	pipeline = strings.TrimSuffix(strings.TrimSuffix(pipeline, ".yml"), ".yaml")
+	name := checkpointNameExpr.ReplaceAllString(pipeline, "-")
+	if name == "" {
+		name = "pipeline"
+	}
+	return filepath.Join(dir, name+".yml")
+}
+
+// WriteCheckpoint writes a checkpoint to path. The checkpoint is written
+// to a temporary file first, so a crash while writing keeps the previous one.
+func WriteCheckpoint(path string, checkpoint *Checkpoint) error {
+	data, err := yaml.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// ReadCheckpoint reads the checkpoint at path.
+// A missing checkpoint returns nil and no error.
+func ReadCheckpoint(path string) (*Checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	checkpoint := &Checkpoint{}
+	if err := yaml.Unmarshal(data, checkpoint); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint %s: %w", path, err)
+	}
+	return checkpoint, nil
+}
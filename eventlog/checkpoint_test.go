@@ -0,0 +1,39 @@
+package eventlog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckpoint(t *testing.T) {
+	dir := t.TempDir()
+	path := CheckpointPath(dir, "my pipeline.yml")
+	assert.Equal(t, filepath.Join(dir, "my-pipeline.yml"), path)
+
+	checkpoint, err := ReadCheckpoint(path)
+	require.NoError(t, err)
+	assert.Nil(t, checkpoint)
+
+	want := &Checkpoint{
+		RunID:     "run-1",
+		Pipeline:  "my pipeline.yml",
+		Jobs:      []string{"default"},
+		UpdatedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Completed: []string{"build"},
+		Steps:     map[string]string{"jobs.build.steps.0": "passed"},
+		Hashes:    map[string]string{"build": "abc"},
+	}
+	require.NoError(t, WriteCheckpoint(path, want))
+	checkpoint, err = ReadCheckpoint(path)
+	require.NoError(t, err)
+	assert.Equal(t, want, checkpoint)
+
+	require.NoError(t, os.WriteFile(path, []byte("steps: ["), 0o644))
+	_, err = ReadCheckpoint(path)
+	assert.ErrorContains(t, err, "failed to parse checkpoint")
+}
@@ -0,0 +1,137 @@
+package eventlog
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Coverage holds the statement coverage of a run, in percent.
+type Coverage struct {
+	Total    float64            `yaml:"total"`
+	Packages map[string]float64 `yaml:"packages,omitempty"`
+}
+
+// coverBlock is a block of statements of a Go coverage profile.
+type coverBlock struct {
+	statements int
+	covered    bool
+}
+
+// CoverProfile merges Go coverage profiles, e.g. of several `go test`
+// runs. A block is covered when any profile covers it.
+type CoverProfile struct {
+	blocks map[string]*coverBlock // keyed by file:range
+}
+
+// NewCoverProfile creates an empty profile.
+func NewCoverProfile() *CoverProfile {
+	return &CoverProfile{blocks: map[string]*coverBlock{}}
+}
+
+// AddFile merges the coverage profile at path.
+func (p *CoverProfile) AddFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return p.Add(f)
+}
+
+// Add merges a coverage profile as written by `go test -coverprofile`.
+func (p *CoverProfile) Add(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "mode:") {
+			continue
+		}
+
+		// example.com/pkg/file.go:10.2,12.16 3 1
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return fmt.Errorf("invalid coverage line %q", line)
+		}
+		statements, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return fmt.Errorf("invalid coverage line %q: %w", line, err)
+		}
+		count, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return fmt.Errorf("invalid coverage line %q: %w", line, err)
+		}
+
+		block, ok := p.blocks[fields[0]]
+		if !ok {
+			block = &coverBlock{statements: statements}
+			p.blocks[fields[0]] = block
+		}
+		block.covered = block.covered || count > 0
+	}
+	return scanner.Err()
+}
+
+// Coverage returns the total and per-package coverage, or nil when
+// no profile was added.
+func (p *CoverProfile) Coverage() *Coverage {
+	if p == nil || len(p.blocks) == 0 {
+		return nil
+	}
+
+	type counts struct{ covered, total int }
+	var total counts
+	packages := map[string]*counts{}
+	for key, block := range p.blocks {
+		file, _, _ := strings.Cut(key, ":")
+		pkg := path.Dir(file)
+		if packages[pkg] == nil {
+			packages[pkg] = &counts{}
+		}
+
+		packages[pkg].total += block.statements
+		total.total += block.statements
+		if block.covered {
+			packages[pkg].covered += block.statements
+			total.covered += block.statements
+		}
+	}
+
+	percent := func(c counts) float64 {
+		if c.total == 0 {
+			return 0
+		}
+		return float64(c.covered) / float64(c.total) * 100
+	}
+
+	coverage := &Coverage{Total: percent(total), Packages: map[string]float64{}}
+	for pkg, c := range packages {
+		coverage.Packages[pkg] = percent(*c)
+	}
+	return coverage
+}
+
+// CoverageRuns returns the entries that recorded coverage, oldest first.
+func CoverageRuns(entries []*RunIndexEntry) []*RunIndexEntry {
+	var runs []*RunIndexEntry
+	for _, entry := range entries {
+		if entry != nil && entry.Coverage != nil {
+			runs = append(runs, entry)
+		}
+	}
+	return runs
+}
+
+// LastBranchRun returns the most recent entry recorded on branch, or nil.
+func LastBranchRun(entries []*RunIndexEntry, branch string) *RunIndexEntry {
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i] != nil && entries[i].Branch == branch {
+			return entries[i]
+		}
+	}
+	return nil
+}
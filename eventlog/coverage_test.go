@@ -0,0 +1,48 @@
+package eventlog
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoverProfile(t *testing.T) {
+	profile := NewCoverProfile()
+	assert.Nil(t, profile.Coverage())
+
+	require.NoError(t, profile.Add(strings.NewReader(`mode: set
+example.com/app/a.go:3.10,5.2 3 1
+example.com/app/a.go:7.10,9.2 1 0
+example.com/app/store/b.go:3.10,5.2 4 0
+`)))
+	// A second run covering the store block
+	require.NoError(t, profile.Add(strings.NewReader(`mode: set
+example.com/app/store/b.go:3.10,5.2 4 2
+example.com/app/store/b.go:7.10,9.2 4 0
+`)))
+
+	coverage := profile.Coverage()
+	require.NotNil(t, coverage)
+	assert.InDelta(t, 7.0/12*100, coverage.Total, 0.001)
+	assert.InDelta(t, 75.0, coverage.Packages["example.com/app"], 0.001)
+	assert.InDelta(t, 50.0, coverage.Packages["example.com/app/store"], 0.001)
+
+	require.Error(t, profile.Add(strings.NewReader("example.com/app/a.go:3.10,5.2 x 1\n")))
+}
+
+func TestCoverageRuns(t *testing.T) {
+	entries := []*RunIndexEntry{
+		{RunID: "1", Branch: "main", Coverage: &Coverage{Total: 70}},
+		{RunID: "2", Branch: "feature"},
+		{RunID: "3", Branch: "feature", Coverage: &Coverage{Total: 72}},
+	}
+
+	runs := CoverageRuns(entries)
+	require.Len(t, runs, 2)
+	assert.Equal(t, "3", runs[1].RunID)
+
+	assert.Equal(t, "1", LastBranchRun(runs, "main").RunID)
+	assert.Nil(t, LastBranchRun(runs, "release"))
+}
@@ -0,0 +1,99 @@
+package eventlog
+
+import (
+	"strings"
+)
+
+// StepDelta compares a single step across two runs.
+type StepDelta struct {
+	ID             string
+	BeforeResult   Result  // Empty if the step did not run before
+	AfterResult    Result  // Empty if the step did not run after
+	BeforeDuration float64 // Seconds
+	AfterDuration  float64 // Seconds
+}
+
+// Delta returns the duration difference in seconds (after - before).
+func (d *StepDelta) Delta() float64 {
+	return d.AfterDuration - d.BeforeDuration
+}
+
+// DiffRuns compares per-step results and durations of two run logs.
+// Steps are matched by ID and returned in execution order of the first run,
+// followed by steps that only ran in the second run.
+func DiffRuns(before, after *Log) []*StepDelta {
+	deltas := make(map[string]*StepDelta)
+	order := make([]string, 0)
+
+	get := func(id string) *StepDelta {
+		if d, ok := deltas[id]; ok {
+			return d
+		}
+		d := &StepDelta{ID: id}
+		deltas[id] = d
+		order = append(order, id)
+		return d
+	}
+
+	for _, event := range stepEvents(before) {
+		d := get(event.ID)
+		d.BeforeResult = event.Result
+		d.BeforeDuration = event.Duration
+	}
+	for _, event := range stepEvents(after) {
+		d := get(event.ID)
+		d.AfterResult = event.Result
+		d.AfterDuration = event.Duration
+	}
+
+	result := make([]*StepDelta, 0, len(order))
+	for _, id := range order {
+		result = append(result, deltas[id])
+	}
+	return result
+}
+
+// stepEvents returns the job and step result events from a log.
+func stepEvents(log *Log) []*Event {
+	if log == nil {
+		return nil
+	}
+
+	result := make([]*Event, 0, len(log.Events))
+	for _, event := range log.Events {
+		if event == nil || event.Type != EventTypeStep || event.Result == "" || event.ID == "" {
+			continue
+		}
+		result = append(result, event)
+	}
+	return result
+}
+
+// FindRun finds a run index entry by run ID or unique run ID prefix.
+// Returns nil if no entry or more than one entry matches.
+func FindRun(entries []*RunIndexEntry, id string) *RunIndexEntry {
+	if id == "" {
+		return nil
+	}
+
+	// An exact match wins over prefixes of longer run IDs
+	for _, entry := range entries {
+		if entry != nil && entry.RunID == id {
+			return entry
+		}
+	}
+
+	var found *RunIndexEntry
+	for _, entry := range entries {
+		if entry == nil {
+			continue
+		}
+		if strings.HasPrefix(entry.RunID, id) {
+			if found != nil && found.RunID != entry.RunID {
+				return nil
+			}
+			found = entry
+		}
+	}
+	return found
+}
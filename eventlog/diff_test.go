@@ -0,0 +1,57 @@
+package eventlog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffRuns(t *testing.T) {
+	before := &Log{
+		Events: []*Event{
+			{ID: "jobs.build.steps.0", Type: EventTypeStep, Result: ResultPass, Duration: 1},
+			{ID: "jobs.build.steps.0", Type: EventTypeStep, Command: "go build", Duration: 1},
+			{ID: "jobs.build.steps.1", Type: EventTypeStep, Result: ResultPass, Duration: 2},
+			{ID: "subst-1", Type: EventTypeSubstitution, Duration: 5},
+		},
+	}
+	after := &Log{
+		Events: []*Event{
+			{ID: "jobs.build.steps.0", Type: EventTypeStep, Result: ResultPass, Duration: 1.5},
+			{ID: "jobs.build.steps.2", Type: EventTypeStep, Result: ResultFail, Duration: 0.5},
+		},
+	}
+
+	deltas := DiffRuns(before, after)
+	require.Len(t, deltas, 3)
+
+	assert.Equal(t, "jobs.build.steps.0", deltas[0].ID)
+	assert.Equal(t, 0.5, deltas[0].Delta())
+
+	assert.Equal(t, "jobs.build.steps.1", deltas[1].ID)
+	assert.Equal(t, ResultPass, deltas[1].BeforeResult)
+	assert.Empty(t, deltas[1].AfterResult)
+
+	assert.Equal(t, "jobs.build.steps.2", deltas[2].ID)
+	assert.Empty(t, deltas[2].BeforeResult)
+	assert.Equal(t, ResultFail, deltas[2].AfterResult)
+}
+
+func TestFindRun(t *testing.T) {
+	entries := []*RunIndexEntry{
+		{RunID: "01ABC"},
+		{RunID: "01ABD"},
+		{RunID: "02XYZ"},
+	}
+
+	assert.Equal(t, "01ABC", FindRun(entries, "01ABC").RunID)
+	assert.Equal(t, "02XYZ", FindRun(entries, "02").RunID)
+	assert.Nil(t, FindRun(entries, "01AB"), "ambiguous prefix")
+	assert.Nil(t, FindRun(entries, "03"))
+	assert.Nil(t, FindRun(entries, ""))
+
+	// Custom run IDs may be prefixes of earlier ones
+	entries = []*RunIndexEntry{{RunID: "build-12"}, {RunID: "build-13"}, {RunID: "build-1"}}
+	assert.Equal(t, "build-1", FindRun(entries, "build-1").RunID)
+}
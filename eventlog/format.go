@@ -0,0 +1,106 @@
+package eventlog
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// CompressedSuffix selects the gzip compressed YAML format of event logs,
+// e.g. --log run.yml.gz. Compressed logs of large runs are a fraction of
+// the size and faster to write to slow disks.
+const CompressedSuffix = ".gz"
+
+// IsCompressed reports whether the event log at path is gzip compressed.
+func IsCompressed(path string) bool {
+	return strings.HasSuffix(path, CompressedSuffix)
+}
+
+// WriteLog writes an event log to path, compressed if the path ends with
+// CompressedSuffix. The log is encoded while writing, without holding
+// the encoded log in memory.
+func WriteLog(path string, log *Log) error {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := EncodeLog(f, log, IsCompressed(path)); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// EncodeLog writes an event log as YAML to w, gzip compressed if compress is set.
+func EncodeLog(w io.Writer, log *Log, compress bool) error {
+	var zw *gzip.Writer
+	if compress {
+		zw = gzip.NewWriter(w)
+		w = zw
+	}
+	enc := yaml.NewEncoder(w)
+	if err := enc.Encode(log); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	if zw != nil {
+		return zw.Close()
+	}
+	return nil
+}
+
+// ReadLog reads an event log written by Logger.Write, decompressing logs
+// ending with CompressedSuffix. The log is decoded while reading the file.
+func ReadLog(path string) (*Log, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	log, err := DecodeLog(f, IsCompressed(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse event log %s: %w", path, err)
+	}
+	return log, nil
+}
+
+// DecodeLog reads a YAML event log from r, gzip compressed if compressed is set.
+func DecodeLog(r io.Reader, compressed bool) (*Log, error) {
+	if compressed {
+		zr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		r = zr
+	}
+	log := &Log{}
+	if err := yaml.NewDecoder(r).Decode(log); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return log, nil
+}
+
+// ConvertLog rewrites the event log at src to dst, in the format selected
+// by the file extension of dst.
+func ConvertLog(src, dst string) error {
+	log, err := ReadLog(src)
+	if err != nil {
+		return err
+	}
+	return WriteLog(dst, log)
+}
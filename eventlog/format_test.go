@@ -0,0 +1,52 @@
+package eventlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteLog_Formats(t *testing.T) {
+	dir := t.TempDir()
+	log := &Log{
+		Metadata: RunMetadata{RunID: "run-1", Pipeline: "build"},
+		Summary:  &RunSummary{Result: ResultPass, Duration: 1.5},
+	}
+	for i := range 200 {
+		log.Events = append(log.Events, &Event{
+			ID:      "jobs.build.steps." + string(rune('a'+i%26)),
+			Command: "go test ./...",
+			Output:  strings.Repeat("ok  \tgithub.com/titpetric/atkins\t0.1s\n", 5),
+			Result:  ResultPass,
+		})
+	}
+
+	plain := filepath.Join(dir, "logs", "run.yml")
+	compressed := filepath.Join(dir, "logs", "run.yml.gz")
+	require.NoError(t, WriteLog(plain, log))
+	require.NoError(t, ConvertLog(plain, compressed))
+
+	for _, path := range []string{plain, compressed} {
+		got, err := ReadLog(path)
+		require.NoError(t, err, path)
+		assert.Equal(t, log, got, path)
+	}
+
+	plainInfo, err := os.Stat(plain)
+	require.NoError(t, err)
+	compressedInfo, err := os.Stat(compressed)
+	require.NoError(t, err)
+	assert.Less(t, compressedInfo.Size()*10, plainInfo.Size(), "compressed log should be a fraction of the size")
+
+	// A compressed log read as YAML, or the other way around, fails
+	require.NoError(t, os.Rename(compressed, filepath.Join(dir, "logs", "other.yml")))
+	_, err = ReadLog(filepath.Join(dir, "logs", "other.yml"))
+	assert.ErrorContains(t, err, "failed to parse event log")
+	require.NoError(t, os.Rename(plain, filepath.Join(dir, "logs", "other.yml.gz")))
+	_, err = ReadLog(filepath.Join(dir, "logs", "other.yml.gz"))
+	assert.ErrorContains(t, err, "failed to parse event log")
+}
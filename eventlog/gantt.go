@@ -0,0 +1,138 @@
+package eventlog
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// ganttLabelWidth limits the width of bar labels.
+const ganttLabelWidth = 60
+
+// ganttSection is a job of a run, with a bar per step.
+type ganttSection struct {
+	name  string
+	start float64
+	bars  []*Event
+}
+
+// Gantt writes a mermaid gantt chart of the runs as a markdown code block.
+// Each job is a section with a bar per step, and the $() substitutions of
+// a step are sub-bars below it. With several runs, e.g. to compare them,
+// the sections are prefixed with the run ID and all runs start at zero.
+func Gantt(w io.Writer, logs ...*Log) error {
+	title := "Run"
+	if len(logs) == 1 && logs[0] != nil {
+		title = strings.TrimSpace(logs[0].Metadata.Pipeline + " " + logs[0].Metadata.RunID)
+	}
+
+	var b strings.Builder
+	b.WriteString("```mermaid\ngantt\n")
+	fmt.Fprintf(&b, "    title %s\n", ganttLabel(title))
+	b.WriteString("    dateFormat x\n")
+	b.WriteString("    axisFormat %M:%S\n")
+
+	for _, log := range logs {
+		if log == nil {
+			continue
+		}
+		prefix := ""
+		if len(logs) > 1 {
+			prefix = log.Metadata.RunID + " "
+		}
+
+		substitutions := make(map[string][]*Event)
+		for _, event := range log.Events {
+			if event != nil && event.Type == EventTypeSubstitution {
+				substitutions[event.ParentID] = append(substitutions[event.ParentID], event)
+			}
+		}
+
+		for _, section := range ganttSections(log) {
+			fmt.Fprintf(&b, "    section %s\n", ganttLabel(prefix+section.name))
+			for _, step := range section.bars {
+				writeGanttBar(&b, strings.TrimPrefix(step.Run, "run: "), ganttTag(step), step)
+				for _, sub := range substitutions[step.ID] {
+					writeGanttBar(&b, "↳ $("+sub.Command+")", "active", sub)
+				}
+			}
+		}
+
+		// Substitutions outside of steps, e.g. in pipeline variables
+		if orphans := substitutions[""]; len(orphans) > 0 {
+			fmt.Fprintf(&b, "    section %s\n", ganttLabel(prefix+"substitutions"))
+			for _, sub := range orphans {
+				writeGanttBar(&b, "$("+sub.Command+")", "active", sub)
+			}
+		}
+	}
+
+	b.WriteString("```\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// ganttSections groups the step results of a log by job, ordered by the
+// start of the first step.
+func ganttSections(log *Log) []*ganttSection {
+	byJob := make(map[string]*ganttSection)
+	var sections []*ganttSection
+	for _, event := range stepEvents(log) {
+		job, _, ok := strings.Cut(event.ID, ".steps.")
+		if !ok || event.Result == ResultSkipped {
+			continue
+		}
+		job = strings.TrimPrefix(job, "jobs.")
+
+		section, ok := byJob[job]
+		if !ok {
+			section = &ganttSection{name: job, start: event.Start}
+			byJob[job] = section
+			sections = append(sections, section)
+		}
+		section.start = min(section.start, event.Start)
+		section.bars = append(section.bars, event)
+	}
+
+	sort.SliceStable(sections, func(i, j int) bool {
+		return sections[i].start < sections[j].start
+	})
+	for _, section := range sections {
+		sort.SliceStable(section.bars, func(i, j int) bool {
+			return section.bars[i].Start < section.bars[j].Start
+		})
+	}
+	return sections
+}
+
+// writeGanttBar writes a bar from the start to the end of an event, in
+// milliseconds since the run started.
+func writeGanttBar(b *strings.Builder, label, tag string, event *Event) {
+	start := int64(event.Start * 1000)
+	end := start + max(int64(event.Duration*1000), 1)
+	if tag != "" {
+		tag += ", "
+	}
+	fmt.Fprintf(b, "    %s :%s%d, %d\n", ganttLabel(label), tag, start, end)
+}
+
+// ganttTag returns the mermaid tag highlighting failed steps.
+func ganttTag(event *Event) string {
+	if event.Result == ResultFail {
+		return "crit"
+	}
+	return ""
+}
+
+// ganttLabel shortens a label to a line without the characters mermaid
+// uses as separators.
+func ganttLabel(s string) string {
+	s = strings.NewReplacer(":", " -", ";", " ", "#", " ").Replace(s)
+	s = strings.Join(strings.Fields(s), " ")
+	runes := []rune(s)
+	if len(runes) > ganttLabelWidth {
+		s = string(runes[:ganttLabelWidth-1]) + "…"
+	}
+	return s
+}
@@ -0,0 +1,54 @@
+package eventlog
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGantt(t *testing.T) {
+	log := &Log{
+		Metadata: RunMetadata{RunID: "r1", Pipeline: "ci"},
+		Events: []*Event{
+			{ID: "subst-1", Type: EventTypeSubstitution, Start: 0.001, Duration: 0.01, Command: "git describe"},
+			{ID: "subst-2", Type: EventTypeSubstitution, Start: 0.5, Duration: 0.2, Command: "go env GOPATH; true", ParentID: "jobs.test.steps.0"},
+			{ID: "jobs.test.steps.0", Type: EventTypeStep, Start: 0.5, Duration: 1.5, Command: "go test ./..."},
+			{ID: "jobs.test.steps.0", Type: EventTypeStep, Start: 0.5, Duration: 1.5, Run: "run: go test ./...", Result: ResultFail},
+			{ID: "jobs.build.steps.0", Type: EventTypeStep, Start: 0.02, Duration: 0.4, Run: "run: go build", Result: ResultPass},
+			{ID: "jobs.build.steps.1", Type: EventTypeStep, Run: "run: upload", Result: ResultSkipped},
+			{ID: "jobs.build", Type: EventTypeStep, Start: 0.02, Duration: 0.4, Run: "build", Result: ResultPass},
+		},
+	}
+
+	var out strings.Builder
+	require.NoError(t, Gantt(&out, log))
+	assert.Equal(t, "```mermaid\n"+`gantt
+    title ci r1
+    dateFormat x
+    axisFormat %M:%S
+    section build
+    go build :20, 420
+    section test
+    go test ./... :crit, 500, 2000
+    ↳ $(go env GOPATH true) :active, 500, 700
+    section substitutions
+    $(git describe) :active, 1, 11
+`+"```\n", out.String())
+
+	t.Run("several runs are prefixed with the run ID", func(t *testing.T) {
+		other := &Log{
+			Metadata: RunMetadata{RunID: "r2"},
+			Events: []*Event{
+				{ID: "jobs.build.steps.0", Type: EventTypeStep, Start: 0.01, Duration: 0.3, Run: "run: go build", Result: ResultPass},
+			},
+		}
+
+		var out strings.Builder
+		require.NoError(t, Gantt(&out, log, other))
+		assert.Contains(t, out.String(), "    title Run\n")
+		assert.Contains(t, out.String(), "    section r1 build\n")
+		assert.Contains(t, out.String(), "    section r2 build\n    go build :10, 310\n")
+	})
+}
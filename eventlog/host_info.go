@@ -0,0 +1,65 @@
+package eventlog
+
+import (
+	"os"
+	"os/user"
+	"runtime"
+)
+
+// CaptureHostInfo captures hostname, platform and the invoking user.
+func CaptureHostInfo() *HostInfo {
+	info := &HostInfo{
+		OS:   runtime.GOOS,
+		Arch: runtime.GOARCH,
+	}
+
+	if hostname, err := os.Hostname(); err == nil {
+		info.Hostname = hostname
+	}
+
+	if u, err := user.Current(); err == nil {
+		info.User = u.Username
+	} else {
+		info.User = os.Getenv("USER")
+	}
+
+	return info
+}
+
+// CaptureCIInfo detects the CI provider from the environment.
+// Returns nil when not running in CI.
+func CaptureCIInfo() *CIInfo {
+	return DetectCI(os.Getenv)
+}
+
+// DetectCI detects the CI provider using the given environment lookup.
+func DetectCI(getenv func(string) string) *CIInfo {
+	switch {
+	case getenv("GITHUB_ACTIONS") == "true":
+		info := &CIInfo{
+			Provider: "github",
+			RunID:    getenv("GITHUB_RUN_ID"),
+		}
+		if server, repo := getenv("GITHUB_SERVER_URL"), getenv("GITHUB_REPOSITORY"); server != "" && repo != "" && info.RunID != "" {
+			info.RunURL = server + "/" + repo + "/actions/runs/" + info.RunID
+		}
+		return info
+	case getenv("GITLAB_CI") == "true":
+		return &CIInfo{
+			Provider: "gitlab",
+			RunID:    getenv("CI_PIPELINE_ID"),
+			RunURL:   getenv("CI_PIPELINE_URL"),
+		}
+	case getenv("JENKINS_URL") != "":
+		return &CIInfo{
+			Provider: "jenkins",
+			RunID:    getenv("BUILD_ID"),
+			RunURL:   getenv("BUILD_URL"),
+		}
+	case getenv("CI") != "" && getenv("CI") != "false":
+		return &CIInfo{
+			Provider: "generic",
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package eventlog
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCaptureHostInfo(t *testing.T) {
+	info := CaptureHostInfo()
+	require.NotNil(t, info)
+	assert.Equal(t, runtime.GOOS, info.OS)
+	assert.Equal(t, runtime.GOARCH, info.Arch)
+}
+
+func TestDetectCI(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		expected *CIInfo
+	}{
+		{
+			name:     "not in CI",
+			env:      map[string]string{},
+			expected: nil,
+		},
+		{
+			name: "github actions",
+			env: map[string]string{
+				"GITHUB_ACTIONS":    "true",
+				"GITHUB_RUN_ID":     "42",
+				"GITHUB_SERVER_URL": "https://github.com",
+				"GITHUB_REPOSITORY": "owner/repo",
+			},
+			expected: &CIInfo{
+				Provider: "github",
+				RunID:    "42",
+				RunURL:   "https://github.com/owner/repo/actions/runs/42",
+			},
+		},
+		{
+			name: "gitlab",
+			env: map[string]string{
+				"GITLAB_CI":       "true",
+				"CI_PIPELINE_ID":  "7",
+				"CI_PIPELINE_URL": "https://gitlab.com/p/-/pipelines/7",
+			},
+			expected: &CIInfo{
+				Provider: "gitlab",
+				RunID:    "7",
+				RunURL:   "https://gitlab.com/p/-/pipelines/7",
+			},
+		},
+		{
+			name: "jenkins",
+			env: map[string]string{
+				"JENKINS_URL": "https://ci.example.com/",
+				"BUILD_ID":    "3",
+			},
+			expected: &CIInfo{
+				Provider: "jenkins",
+				RunID:    "3",
+			},
+		},
+		{
+			name:     "generic CI",
+			env:      map[string]string{"CI": "1"},
+			expected: &CIInfo{Provider: "generic"},
+		},
+		{
+			name:     "CI disabled",
+			env:      map[string]string{"CI": "false"},
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getenv := func(key string) string {
+				return tt.env[key]
+			}
+			assert.Equal(t, tt.expected, DetectCI(getenv))
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package eventlog
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// RunIndexPath is the location of the run index, relative to the project root.
+var RunIndexPath = filepath.Join(".atkins", "runs", "index.yml")
+
+// RunIndexEntry summarizes a single run in the run index.
+type RunIndexEntry struct {
+	RunID     string    `yaml:"run_id"`
+	CreatedAt time.Time `yaml:"created_at"`
+	Pipeline  string    `yaml:"pipeline,omitempty"`
+	Jobs      []string  `yaml:"jobs,omitempty"`
+	Result    Result    `yaml:"result"`
+	Duration  float64   `yaml:"duration"`           // Total duration in seconds
+	LogFile   string    `yaml:"log_file,omitempty"` // Absolute path to the event log
+	Branch    string    `yaml:"branch,omitempty"`
+	Commit    string    `yaml:"commit,omitempty"`
+	Coverage  *Coverage `yaml:"coverage,omitempty"` // Merged coverage of the profiles written in the run
+}
+
+// AppendRunIndex appends an entry to the run index at path.
+// The index is a YAML sequence, so entries are appended without rewriting the file.
+func AppendRunIndex(path string, entry *RunIndexEntry) error {
+	if entry == nil {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal([]*RunIndexEntry{entry})
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// LoadRunIndex reads the run index at path.
+// A missing index returns no entries and no error.
+func LoadRunIndex(path string) ([]*RunIndexEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var entries []*RunIndexEntry
+	if err := yaml.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse run index %s: %w", path, err)
+	}
+	return entries, nil
+}
+
+// LastRun returns the most recent entry, or the most recent failed entry if failed is set.
+// Returns nil if no matching entry exists.
+func LastRun(entries []*RunIndexEntry, failed bool) *RunIndexEntry {
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		if entry == nil {
+			continue
+		}
+		if failed && entry.Result != ResultFail {
+			continue
+		}
+		return entry
+	}
+	return nil
+}
+
+// FindCommand returns the last command event with the given ID, or nil.
+// A step that runs several times, e.g. in a loop, logs an event per run.
+func FindCommand(log *Log, id string) *Event {
+	if log == nil {
+		return nil
+	}
+	for i := len(log.Events) - 1; i >= 0; i-- {
+		if event := log.Events[i]; event != nil && event.ID == id && event.Command != "" {
+			return event
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package eventlog
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunIndex_AppendAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".atkins", "runs", "index.yml")
+
+	entries, err := LoadRunIndex(path)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	require.NoError(t, AppendRunIndex(path, &RunIndexEntry{RunID: "one", CreatedAt: now, Jobs: []string{"build"}, Result: ResultPass, Duration: 1.5}))
+	require.NoError(t, AppendRunIndex(path, &RunIndexEntry{RunID: "two", CreatedAt: now, Jobs: []string{"test"}, Result: ResultFail, LogFile: "/tmp/log.yml"}))
+	require.NoError(t, AppendRunIndex(path, &RunIndexEntry{RunID: "three", CreatedAt: now, Result: ResultPass}))
+	require.NoError(t, AppendRunIndex(path, nil))
+
+	entries, err = LoadRunIndex(path)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, "one", entries[0].RunID)
+	assert.Equal(t, []string{"build"}, entries[0].Jobs)
+	assert.Equal(t, 1.5, entries[0].Duration)
+	assert.Equal(t, "/tmp/log.yml", entries[1].LogFile)
+
+	assert.Equal(t, "three", LastRun(entries, false).RunID)
+	assert.Equal(t, "two", LastRun(entries, true).RunID)
+	assert.Nil(t, LastRun(entries[:1], true))
+	assert.Nil(t, LastRun(nil, false))
+}
+
+func TestLogger_IndexEntry(t *testing.T) {
+	logger := NewLogger("run.yml", "test-pipeline", "test.yml", false)
+	require.NotNil(t, logger)
+
+	entry := logger.IndexEntry([]string{"default"}, &RunSummary{Result: ResultFail, Duration: 2})
+	require.NotNil(t, entry)
+	assert.Equal(t, logger.metadata.RunID, entry.RunID)
+	assert.Equal(t, "test-pipeline", entry.Pipeline)
+	assert.Equal(t, ResultFail, entry.Result)
+	assert.Equal(t, 2.0, entry.Duration)
+	assert.True(t, filepath.IsAbs(entry.LogFile))
+
+	var nilLogger *Logger
+	assert.Nil(t, nilLogger.IndexEntry(nil, nil))
+}
+
+func TestFindCommand(t *testing.T) {
+	log := &Log{Events: []*Event{
+		{ID: "jobs.build", Type: EventTypeStep, Result: ResultPass},
+		{ID: "jobs.build.steps.0", Type: EventTypeStep, Command: "go build", Env: []string{"GOOS=linux"}},
+		nil,
+		{ID: "jobs.build.steps.0", Type: EventTypeStep, Command: "go build", Env: []string{"GOOS=darwin"}},
+	}}
+
+	event := FindCommand(log, "jobs.build.steps.0")
+	require.NotNil(t, event)
+	assert.Equal(t, []string{"GOOS=darwin"}, event.Env)
+
+	assert.Nil(t, FindCommand(log, "jobs.build"))
+	assert.Nil(t, FindCommand(log, "jobs.test.steps.0"))
+	assert.Nil(t, FindCommand(nil, "jobs.build.steps.0"))
+}
@@ -2,14 +2,13 @@ package eventlog
 
 import (
 	"bytes"
-	"os"
+	"path/filepath"
 	"runtime"
 	"strconv"
 	"sync"
 	"time"
 
-	ulid "github.com/oklog/ulid/v2"
-	yaml "gopkg.in/yaml.v3"
+	"github.com/titpetric/atkins/colors"
 )
 
 // Logger collects events during execution and writes the final log.
@@ -20,6 +19,7 @@ type Logger struct {
 	events    []*Event
 	startTime time.Time
 	debug     bool
+	coverage  *CoverProfile
 }
 
 // NewLogger creates a new event logger.
@@ -29,8 +29,16 @@ func NewLogger(filePath, pipelineName, pipelineFile string, debug bool) *Logger
 		return nil
 	}
 
+	l := NewMemoryLogger(pipelineName, pipelineFile, debug)
+	l.filePath = filePath
+	return l
+}
+
+// NewMemoryLogger creates an event logger that collects the events of a
+// run without writing a log file, e.g. to render a gantt chart.
+func NewMemoryLogger(pipelineName, pipelineFile string, debug bool) *Logger {
 	now := time.Now()
-	runID := ulid.Make().String()
+	runID := NewRunID(now)
 
 	metadata := RunMetadata{
 		RunID:     runID,
@@ -45,8 +53,11 @@ func NewLogger(filePath, pipelineName, pipelineFile string, debug bool) *Logger
 	// Capture module path
 	metadata.ModulePath = CaptureModulePath()
 
+	// Capture host and CI environment
+	metadata.Host = CaptureHostInfo()
+	metadata.CI = CaptureCIInfo()
+
 	return &Logger{
-		filePath:  filePath,
 		metadata:  metadata,
 		events:    make([]*Event, 0),
 		startTime: now,
@@ -54,6 +65,28 @@ func NewLogger(filePath, pipelineName, pipelineFile string, debug bool) *Logger
 	}
 }
 
+// SetVersion records the atkins version in the run metadata.
+func (l *Logger) SetVersion(version string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.metadata.Version = version
+}
+
+// SetRunID replaces the generated run ID, e.g. with a CI build number.
+func (l *Logger) SetRunID(id string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.metadata.RunID = id
+}
+
 // LogExec logs a single execution event (one per exec).
 func (l *Logger) LogExec(result Result, id, run string, start float64, durationMs int64, err error) {
 	if l == nil {
@@ -95,12 +128,20 @@ func (l *Logger) LogCommand(entry LogEntry) {
 		Type:     entry.Type,
 		Start:    entry.Start,
 		Duration: float64(entry.DurationMs) / 1000.0,
-		Error:    entry.Error,
+		Error:    colors.StripANSI(entry.Error),
 		Command:  entry.Command,
 		Dir:      entry.Dir,
-		Output:   entry.Output,
+		Output:   colors.StripANSI(entry.Output),
 		ExitCode: entry.ExitCode,
 		ParentID: entry.ParentID,
+		LogFile:  entry.LogFile,
+		Trace:    entry.Trace,
+		Tests:    entry.Tests,
+	}
+	// The output is kept as written too, to replay colored tool output
+	if event.Output != entry.Output {
+		event.Color = true
+		event.RawOutput = entry.Output
 	}
 	if l.debug && len(entry.Env) > 0 {
 		event.Env = entry.Env
@@ -108,32 +149,89 @@ func (l *Logger) LogCommand(entry LogEntry) {
 	l.events = append(l.events, event)
 }
 
+// AddCoverProfile merges a Go coverage profile into the coverage of the run.
+func (l *Logger) AddCoverProfile(path string) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.coverage == nil {
+		l.coverage = NewCoverProfile()
+	}
+	return l.coverage.AddFile(path)
+}
+
 // elapsed returns seconds since the logger started.
 func (l *Logger) elapsed() float64 {
 	return time.Since(l.startTime).Seconds()
 }
 
-// Write writes the final event log to the file.
-func (l *Logger) Write(state *StateNode, summary *RunSummary) error {
+// Log returns the event log of the run with the final state and summary.
+func (l *Logger) Log(state *StateNode, summary *RunSummary) *Log {
 	if l == nil {
 		return nil
 	}
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	log := &Log{
+	return &Log{
 		Metadata: l.metadata,
 		State:    state,
-		Events:   l.events,
+		Events:   append([]*Event(nil), l.events...),
 		Summary:  summary,
 	}
+}
 
-	data, err := yaml.Marshal(log)
-	if err != nil {
-		return err
+// Write writes the final event log to the file.
+func (l *Logger) Write(state *StateNode, summary *RunSummary) error {
+	if l == nil || l.filePath == "" {
+		return nil
+	}
+
+	return WriteLog(ExpandRunID(l.filePath, l.GetRunID()), l.Log(state, summary))
+}
+
+// IndexEntry builds a run index entry for this run.
+func (l *Logger) IndexEntry(jobs []string, summary *RunSummary) *RunIndexEntry {
+	if l == nil || l.filePath == "" {
+		return nil
 	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
 
-	return os.WriteFile(l.filePath, data, 0o644)
+	logFile := ExpandRunID(l.filePath, l.metadata.RunID)
+	if abs, err := filepath.Abs(logFile); err == nil {
+		logFile = abs
+	}
+
+	entry := &RunIndexEntry{
+		RunID:     l.metadata.RunID,
+		CreatedAt: l.metadata.CreatedAt,
+		Pipeline:  l.metadata.Pipeline,
+		Jobs:      jobs,
+		Result:    ResultPass,
+		LogFile:   logFile,
+		Coverage:  l.coverage.Coverage(),
+	}
+	if git := l.metadata.Git; git != nil {
+		entry.Branch = git.Branch
+		entry.Commit = git.Commit
+	}
+	if summary != nil {
+		entry.Result = summary.Result
+		entry.Duration = summary.Duration
+	}
+	return entry
+}
+
+// GetRunID returns the run ID.
+func (l *Logger) GetRunID() string {
+	if l == nil {
+		return ""
+	}
+	return l.metadata.RunID
 }
 
 // GetStartTime returns the start time of the run.
@@ -26,6 +26,10 @@ func TestNewLogger_CreatesLogger(t *testing.T) {
 	assert.NotEmpty(t, logger.metadata.RunID)
 	assert.Equal(t, "test-pipeline", logger.metadata.Pipeline)
 	assert.Equal(t, "test.yml", logger.metadata.File)
+	assert.NotNil(t, logger.metadata.Host)
+
+	logger.SetVersion("v1.2.3")
+	assert.Equal(t, "v1.2.3", logger.metadata.Version)
 }
 
 func TestLogger_LogExec_Pass(t *testing.T) {
@@ -96,6 +100,7 @@ func TestLogger_NilSafe(t *testing.T) {
 
 	// All methods should be safe to call on nil
 	logger.LogExec(ResultPass, "id", "run", 0, 100, nil)
+	logger.SetVersion("dev")
 	assert.Nil(t, logger.GetEvents())
 	assert.Equal(t, float64(0), logger.GetElapsed())
 	assert.Equal(t, time.Time{}, logger.GetStartTime())
@@ -236,6 +241,32 @@ func TestLogger_LogCommand_Step(t *testing.T) {
 	assert.Equal(t, 0.1, cmd.Duration)
 }
 
+func TestLogger_LogCommand_Color(t *testing.T) {
+	logger := NewMemoryLogger("test-pipeline", "test.yml", false)
+
+	logger.LogCommand(LogEntry{
+		Type:    EventTypeStep,
+		ID:      "jobs.test.steps.0",
+		Command: "go test",
+		Output:  "\x1b[32mok\x1b[0m  pkg\n",
+		Error:   "\x1b[31mwarning\x1b[0m",
+	})
+	logger.LogCommand(LogEntry{Type: EventTypeStep, ID: "jobs.test.steps.1", Output: "plain\n"})
+
+	require.Len(t, logger.events, 2)
+	colored := logger.events[0]
+	assert.Equal(t, "ok  pkg\n", colored.Output)
+	assert.Equal(t, "warning", colored.Error)
+	assert.True(t, colored.Color)
+	assert.Equal(t, "\x1b[32mok\x1b[0m  pkg\n", colored.RawOutput)
+	assert.Equal(t, colored.RawOutput, colored.ColorOutput())
+
+	plain := logger.events[1]
+	assert.False(t, plain.Color)
+	assert.Empty(t, plain.RawOutput)
+	assert.Equal(t, "plain\n", plain.ColorOutput())
+}
+
 func TestLogger_LogCommand_Substitution(t *testing.T) {
 	tmpFile := "test_command_subst.yml"
 	t.Cleanup(func() {
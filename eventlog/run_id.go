@@ -0,0 +1,38 @@
+package eventlog
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// RunIDPlaceholder is replaced with the run ID in the event log path,
+// e.g. `--log .atkins/logs/{run_id}.yml`.
+const RunIDPlaceholder = "{run_id}"
+
+// runIDPattern matches run IDs, which are used in file names.
+var runIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,127}$`)
+
+// NewRunID returns a run ID of the start time and a short random hash,
+// e.g. 20240501-120000-3f9a2c. IDs sort by the time the run started.
+func NewRunID(start time.Time) string {
+	var hash [3]byte
+	_, _ = rand.Read(hash[:])
+	return start.UTC().Format("20060102-150405") + "-" + hex.EncodeToString(hash[:])
+}
+
+// ValidateRunID checks a run ID given with --run-id, e.g. a CI build number.
+func ValidateRunID(id string) error {
+	if !runIDPattern.MatchString(id) {
+		return fmt.Errorf("invalid run ID %q, expected up to 128 letters, digits, '.', '_' or '-'", id)
+	}
+	return nil
+}
+
+// ExpandRunID replaces RunIDPlaceholder in path with the run ID.
+func ExpandRunID(path, id string) string {
+	return strings.ReplaceAll(path, RunIDPlaceholder, id)
+}
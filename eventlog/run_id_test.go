@@ -0,0 +1,36 @@
+package eventlog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRunID(t *testing.T) {
+	start := time.Date(2024, 5, 1, 14, 0, 5, 0, time.FixedZone("CEST", 2*60*60))
+
+	id := NewRunID(start)
+	assert.Regexp(t, `^20240501-120005-[0-9a-f]{6}$`, id)
+	assert.NoError(t, ValidateRunID(id))
+	assert.NotEqual(t, id, NewRunID(start))
+}
+
+func TestValidateRunID(t *testing.T) {
+	for _, id := range []string{"1234", "gh-5678.2", "01HX3V5Q6Z"} {
+		assert.NoError(t, ValidateRunID(id), id)
+	}
+	for _, id := range []string{"", "../x", ".hidden", "a/b", "build 1"} {
+		assert.Error(t, ValidateRunID(id), id)
+	}
+}
+
+func TestLogger_RunIDPath(t *testing.T) {
+	dir := t.TempDir()
+	logger := NewLogger(dir+"/logs/"+RunIDPlaceholder+".yml", "test-pipeline", "test.yml", false)
+	logger.SetRunID("ci-42")
+
+	assert.NoError(t, logger.Write(nil, nil))
+	assert.FileExists(t, dir+"/logs/ci-42.yml")
+	assert.Equal(t, dir+"/logs/ci-42.yml", logger.IndexEntry(nil, nil).LogFile)
+}
@@ -37,12 +37,41 @@ type Event struct {
 	GoroutineID uint64 `yaml:"goroutine_id,omitempty"` // Only when debug is enabled
 
 	// Command event fields
-	Command  string   `yaml:"command,omitempty"`   // The actual command executed
-	Dir      string   `yaml:"dir,omitempty"`       // Working directory
-	Output   string   `yaml:"output,omitempty"`    // stdout output
-	ExitCode int      `yaml:"exit_code,omitempty"` // Process exit code
-	ParentID string   `yaml:"parent_id,omitempty"` // Parent step/job ID for $() commands
-	Env      []string `yaml:"env,omitempty"`       // Environment variables (when debug enabled)
+	Command   string   `yaml:"command,omitempty"`    // The actual command executed
+	Dir       string   `yaml:"dir,omitempty"`        // Working directory
+	Output    string   `yaml:"output,omitempty"`     // stdout output, without ANSI escapes
+	Color     bool     `yaml:"color,omitempty"`      // Whether the output contained ANSI escapes
+	RawOutput string   `yaml:"raw_output,omitempty"` // stdout output as written, when it contained ANSI escapes
+	ExitCode  int      `yaml:"exit_code,omitempty"`  // Process exit code
+	ParentID  string   `yaml:"parent_id,omitempty"`  // Parent step/job ID for $() commands
+	Env       []string `yaml:"env,omitempty"`        // Environment variables (when debug enabled)
+	LogFile   string   `yaml:"log_file,omitempty"`   // Full output capture file (with --capture-dir)
+
+	Trace []TraceEntry `yaml:"trace,omitempty"` // Commands executed by the shell (with trace: true)
+	Tests []TestEntry  `yaml:"tests,omitempty"` // Go test results (for `go test -json`)
+}
+
+// ColorOutput returns the output with its ANSI colors, as the command wrote it.
+func (e *Event) ColorOutput() string {
+	if e.RawOutput != "" {
+		return e.RawOutput
+	}
+	return e.Output
+}
+
+// TraceEntry is a command executed by the shell, captured with `set -x`.
+type TraceEntry struct {
+	Line    int    `yaml:"line"`            // Line of the script
+	Depth   int    `yaml:"depth,omitempty"` // Subshell or substitution nesting, 1 at the top level
+	Command string `yaml:"command"`         // Expanded command as printed by the shell
+}
+
+// TestEntry is the result of a Go test or package, parsed from `go test -json`.
+type TestEntry struct {
+	Package  string  `yaml:"package"`
+	Test     string  `yaml:"test,omitempty"` // Empty for the package result
+	Result   Result  `yaml:"result"`
+	Duration float64 `yaml:"duration"` // Seconds
 }
 
 // LogEntry is the input for LogCommand with named fields.
@@ -58,6 +87,9 @@ type LogEntry struct {
 	Start      float64
 	DurationMs int64
 	Env        []string
+	LogFile    string
+	Trace      []TraceEntry
+	Tests      []TestEntry
 }
 
 // StateNode represents a node in the execution state tree for YAML output.
@@ -82,9 +114,27 @@ type RunMetadata struct {
 	Pipeline   string    `yaml:"pipeline,omitempty"`
 	File       string    `yaml:"file,omitempty"`
 	ModulePath string    `yaml:"module_path,omitempty"`
+	Version    string    `yaml:"version,omitempty"` // Atkins version that produced the log
+	Host       *HostInfo `yaml:"host,omitempty"`
+	CI         *CIInfo   `yaml:"ci,omitempty"`
 	Git        *GitInfo  `yaml:"git,omitempty"`
 }
 
+// HostInfo contains information about the machine running the pipeline.
+type HostInfo struct {
+	Hostname string `yaml:"hostname,omitempty"`
+	OS       string `yaml:"os"`
+	Arch     string `yaml:"arch"`
+	User     string `yaml:"user,omitempty"`
+}
+
+// CIInfo contains information about the CI provider running the pipeline.
+type CIInfo struct {
+	Provider string `yaml:"provider"`          // github, gitlab, jenkins, or generic
+	RunID    string `yaml:"run_id,omitempty"`  // Provider build/run identifier
+	RunURL   string `yaml:"run_url,omitempty"` // Link to the build in the provider UI
+}
+
 // GitInfo contains git repository information.
 type GitInfo struct {
 	Commit     string `yaml:"commit,omitempty"`
@@ -103,12 +153,13 @@ type Log struct {
 
 // RunSummary provides aggregate statistics for the run.
 type RunSummary struct {
-	Duration     float64 `yaml:"duration"`               // Total duration in seconds
-	TotalSteps   int     `yaml:"total_steps"`            // Total steps executed
-	PassedSteps  int     `yaml:"passed_steps"`           // Steps that passed
-	FailedSteps  int     `yaml:"failed_steps"`           // Steps that failed
-	SkippedSteps int     `yaml:"skipped_steps"`          // Steps that were skipped
-	Result       Result  `yaml:"result"`                 // Overall result
-	MemoryAlloc  uint64  `yaml:"memory_alloc,omitempty"` // Memory allocated in bytes
-	Goroutines   int     `yaml:"goroutines,omitempty"`   // Number of goroutines running
+	Duration     float64  `yaml:"duration"`               // Total duration in seconds
+	TotalSteps   int      `yaml:"total_steps"`            // Total steps executed
+	PassedSteps  int      `yaml:"passed_steps"`           // Steps that passed
+	FailedSteps  int      `yaml:"failed_steps"`           // Steps that failed
+	SkippedSteps int      `yaml:"skipped_steps"`          // Steps that were skipped
+	Result       Result   `yaml:"result"`                 // Overall result
+	MemoryAlloc  uint64   `yaml:"memory_alloc,omitempty"` // Memory allocated in bytes
+	Goroutines   int      `yaml:"goroutines,omitempty"`   // Number of goroutines running
+	Warnings     []string `yaml:"warnings,omitempty"`     // Warnings of the run, e.g. an unusable cache entry
 }
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/pflag"
+	"github.com/titpetric/cli"
+
+	"github.com/titpetric/atkins/colors"
+	"github.com/titpetric/atkins/runner"
+	"github.com/titpetric/atkins/treeview"
+)
+
+// Format provides a cli.Command that rewrites pipeline files in the canonical style.
+func Format() *cli.Command {
+	var (
+		check bool
+		opts  runner.FormatOptions
+	)
+
+	return &cli.Command{
+		Name:  "fmt",
+		Title: "Format pipeline files",
+		Usage: func() string {
+			return "atkins fmt [--check] [--expand] [file.yml...]"
+		},
+		Bind: func(fs *pflag.FlagSet) {
+			fs.BoolVar(&check, "check", false, "List files that aren't formatted, and fail if there are any")
+			fs.BoolVar(&opts.Expand, "expand", false, "Expand string jobs into jobs with desc: and run:")
+		},
+		Run: func(ctx context.Context, args []string) error {
+			files := args
+			if len(files) == 0 {
+				configPath, _, err := runner.DiscoverConfigFromCwd()
+				if err != nil || configPath == "" {
+					return fmt.Errorf("%s no pipeline file found, pass the files to format", treeview.ErrorHeader())
+				}
+				files = []string{configPath}
+			}
+			return runFormat(files, opts, check)
+		},
+	}
+}
+
+func runFormat(files []string, opts runner.FormatOptions, check bool) error {
+	var unformatted int
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+		}
+
+		formatted, err := runner.FormatPipeline(data, opts)
+		if err != nil {
+			return fmt.Errorf("%s %s: %v", treeview.ErrorHeader(), file, err)
+		}
+		if bytes.Equal(data, formatted) {
+			continue
+		}
+
+		if check {
+			unformatted++
+			fmt.Printf("%s %s\n", colors.BrightYellow("~"), file)
+			continue
+		}
+
+		info, err := os.Stat(file)
+		if err != nil {
+			return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+		}
+		if err := os.WriteFile(file, formatted, info.Mode().Perm()); err != nil {
+			return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+		}
+		fmt.Printf("%s %s\n", colors.BrightGreen("✓"), file)
+	}
+
+	if unformatted > 0 {
+		return fmt.Errorf("%s %d file(s) not formatted, run atkins fmt", treeview.ErrorHeader(), unformatted)
+	}
+	return nil
+}
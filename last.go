@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"github.com/titpetric/cli"
+
+	"github.com/titpetric/atkins/colors"
+	"github.com/titpetric/atkins/eventlog"
+	"github.com/titpetric/atkins/runner"
+	"github.com/titpetric/atkins/treeview"
+)
+
+// Last provides a cli.Command that prints the summary of the last recorded run.
+func Last() *cli.Command {
+	var failed bool
+
+	return &cli.Command{
+		Name:  "last",
+		Title: "Show the last run summary",
+		Bind: func(fs *pflag.FlagSet) {
+			fs.BoolVar(&failed, "failed", false, "Show the last failed run and its error output")
+		},
+		Run: func(ctx context.Context, args []string) error {
+			return runLast(failed)
+		},
+	}
+}
+
+func runLast(failed bool) error {
+	indexPath, err := runIndexPath()
+	if err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+
+	entries, err := eventlog.LoadRunIndex(indexPath)
+	if err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+
+	entry := eventlog.LastRun(entries, failed)
+	if entry == nil {
+		if failed {
+			fmt.Println("No failed runs recorded.")
+		} else {
+			fmt.Println("No runs recorded. Runs are indexed when --log is used.")
+		}
+		return nil
+	}
+
+	printRunEntry(entry)
+
+	if entry.Result == eventlog.ResultFail && entry.LogFile != "" {
+		log, err := eventlog.ReadLog(entry.LogFile)
+		if err != nil {
+			return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+		}
+		printFailedEvents(log)
+	}
+
+	return nil
+}
+
+// runIndexPath returns the run index path for the project containing the working directory.
+func runIndexPath() (string, error) {
+	root, err := projectRoot()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(root, eventlog.RunIndexPath), nil
+}
+
+// projectRoot returns the folder of the project containing the working
+// directory, which runs are started from.
+func projectRoot() (string, error) {
+	_, configDir, err := runner.DiscoverConfigFromCwd()
+	if err != nil {
+		return os.Getwd()
+	}
+	return configDir, nil
+}
+
+func printRunEntry(entry *eventlog.RunIndexEntry) {
+	result := colors.BrightGreen(string(entry.Result))
+	if entry.Result == eventlog.ResultFail {
+		result = colors.BrightRed(string(entry.Result))
+	}
+
+	jobs := strings.Join(entry.Jobs, ", ")
+	if jobs == "" {
+		jobs = "default"
+	}
+
+	fmt.Printf("%s %s\n", colors.BrightWhite("Run:"), entry.RunID)
+	fmt.Printf("%s %s\n", colors.BrightWhite("Started:"), entry.CreatedAt.Format("2006-01-02 15:04:05"))
+	fmt.Printf("%s %s\n", colors.BrightWhite("Pipeline:"), entry.Pipeline)
+	fmt.Printf("%s %s\n", colors.BrightWhite("Jobs:"), jobs)
+	fmt.Printf("%s %s in %.2fs\n", colors.BrightWhite("Result:"), result, entry.Duration)
+	if entry.LogFile != "" {
+		fmt.Printf("%s %s\n", colors.BrightWhite("Log:"), entry.LogFile)
+	}
+}
+
+// printFailedEvents prints the command and error output of failed commands in the log.
+func printFailedEvents(log *eventlog.Log) {
+	for _, event := range log.Events {
+		if event.Command == "" || (event.ExitCode == 0 && event.Error == "") {
+			continue
+		}
+
+		fmt.Println()
+		fmt.Printf("%s %s\n", colors.BrightRed("✗"), event.ID)
+		fmt.Printf("  %s %s\n", colors.Dim("$"), event.Command)
+		if event.Error != "" {
+			fmt.Printf("  %s\n", event.Error)
+		}
+		for _, line := range strings.Split(strings.TrimRight(event.Output, "\n"), "\n") {
+			if line != "" {
+				fmt.Printf("  %s\n", line)
+			}
+		}
+	}
+}
@@ -5,9 +5,17 @@ import (
 	"os"
 
 	"github.com/titpetric/cli"
+
+	"github.com/titpetric/atkins/psexec"
+	"github.com/titpetric/atkins/runner"
+	"github.com/titpetric/atkins/treeview"
+	"github.com/titpetric/atkins/version"
 )
 
 func main() {
+	// Steps without network re-run atkins to set up their namespace
+	psexec.NoNetworkMain(os.Args)
+
 	if err := start(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
@@ -15,8 +23,34 @@ func main() {
 }
 
 func start() error {
+	runner.AtkinsVersion = Version
+
+	// Commands use the labels of $ATKINS_LABELS, run also takes --labels
+	if labels, err := treeview.LoadLabels(os.Getenv("ATKINS_LABELS")); err == nil {
+		treeview.SetLabels(labels)
+	}
+
 	app := cli.NewApp("atkins")
 	app.AddCommand("run", "Run pipeline", Pipeline)
+	app.AddCommand("last", "Show the last run summary", Last)
+	app.AddCommand("diff", "Diff two pipeline files", Diff)
+	app.AddCommand("runs", "Inspect recorded runs", Runs)
+	app.AddCommand("fmt", "Format pipeline files", Format)
+	app.AddCommand("migrate", "Migrate pipeline files to the current schema", Migrate)
+	app.AddCommand("skills", "Inspect skills", Skills)
+	app.AddCommand("audit", "Generate an SBOM, check for vulnerabilities or verify the audit log", Audit)
+	app.AddCommand("coverage", "Show the coverage trend of recorded runs", Coverage)
+	app.AddCommand("replay", "Re-run a recorded step with its environment", Replay)
+	app.AddCommand("env", "Show the environment a job receives", Env)
+	app.AddCommand("docs", "Generate Markdown documentation of the pipeline", Docs)
+	app.AddCommand("edit", "Edit a job in $EDITOR and validate it", Edit)
+	app.AddCommand("bundle", "Package the pipeline, skills and pinned tools to run offline", Bundle)
+	app.AddCommand("x", "Run a command with the environment of a job", X)
+	app.AddCommand("mcp", "Serve pipeline tools to LLM agents over MCP", MCP)
+	app.AddCommand("self-update", "Update atkins to the latest release", SelfUpdate)
+	app.AddCommand("version", version.Name, func() *cli.Command {
+		return version.NewCommand(buildInfo(), runVersionCheck)
+	})
 
 	app.DefaultCommand = "run"
 
@@ -0,0 +1,329 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/titpetric/cli"
+
+	"github.com/titpetric/atkins/colors"
+	"github.com/titpetric/atkins/eventlog"
+	"github.com/titpetric/atkins/mcp"
+	"github.com/titpetric/atkins/model"
+	"github.com/titpetric/atkins/runner"
+	"github.com/titpetric/atkins/treeview"
+)
+
+// mcpOutputLimit is the output kept per failed command in run results.
+const mcpOutputLimit = 4096
+
+// MCP provides a cli.Command serving atkins as Model Context Protocol tools.
+func MCP() *cli.Command {
+	return &cli.Command{
+		Name:  "mcp",
+		Title: "Serve pipeline tools to LLM agents over MCP",
+		Usage: func() string {
+			return "atkins mcp"
+		},
+		Run: func(ctx context.Context, args []string) error {
+			if len(args) != 0 {
+				return fmt.Errorf("%s expected: mcp", treeview.ErrorHeader())
+			}
+			cwd, err := os.Getwd()
+			if err != nil {
+				return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+			}
+			return mcp.NewServer("atkins", Version, mcpTools(cwd)...).Serve(ctx, os.Stdin, os.Stdout)
+		},
+	}
+}
+
+// mcpTools returns the tools served for the project in cwd. Pipelines
+// are loaded on each call, so edits to the config are picked up.
+func mcpTools(cwd string) []*mcp.Tool {
+	noArgs := map[string]any{"type": "object", "properties": map[string]any{}}
+	jobArg := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"job": map[string]any{"type": "string", "description": "Job ID as returned by list_jobs, e.g. test or go:build"},
+		},
+		"required": []string{"job"},
+	}
+
+	return []*mcp.Tool{
+		{
+			Name:        "list_jobs",
+			Description: "List the jobs of the atkins pipeline and skills, with descriptions.",
+			InputSchema: noArgs,
+			Handler: func(ctx context.Context, call *mcp.Call) (*mcp.Result, error) {
+				pipelines, _, _, err := discoverPipelines(cwd)
+				if err != nil {
+					return nil, err
+				}
+				return &mcp.Result{
+					Text:       colors.StripANSI(runner.ListPipelines(pipelines)),
+					Structured: map[string]any{"sections": runner.BuildListOutput(pipelines)},
+				}, nil
+			},
+		},
+		{
+			Name:        "lint",
+			Description: "Lint the atkins pipeline and skills, returning errors and warnings.",
+			InputSchema: noArgs,
+			Handler: func(ctx context.Context, call *mcp.Call) (*mcp.Result, error) {
+				pipelines, _, _, err := discoverPipelines(cwd)
+				if err != nil {
+					return nil, err
+				}
+				return mcpLint(pipelines), nil
+			},
+		},
+		{
+			Name:        "explain_job",
+			Description: "Explain a job: its dependencies in run order and the commands of its steps, without running it.",
+			InputSchema: jobArg,
+			Handler: func(ctx context.Context, call *mcp.Call) (*mcp.Result, error) {
+				pipelines, _, _, err := discoverPipelines(cwd)
+				if err != nil {
+					return nil, err
+				}
+				task, err := mcpResolve(pipelines, call.String("job"))
+				if err != nil {
+					return nil, err
+				}
+				return mcpExplain(task)
+			},
+		},
+		{
+			Name:        "run_job",
+			Description: "Run a job, streaming its progress, and return the result of each failed command.",
+			InputSchema: jobArg,
+			Handler: func(ctx context.Context, call *mcp.Call) (*mcp.Result, error) {
+				pipelines, _, _, err := discoverPipelines(cwd)
+				if err != nil {
+					return nil, err
+				}
+				task, err := mcpResolve(pipelines, call.String("job"))
+				if err != nil {
+					return nil, err
+				}
+				return mcpRun(ctx, call, cwd, task.Name)
+			},
+		},
+	}
+}
+
+// mcpResolve resolves a job ID exactly, without aliases or fuzzy matching,
+// so an agent only runs the job it names.
+func mcpResolve(pipelines []*model.Pipeline, job string) (*model.ResolvedTask, error) {
+	if job == "" {
+		return nil, errors.New("missing job argument")
+	}
+	task, err := runner.NewTaskResolver(pipelines).ResolveName(job, true)
+	if err != nil {
+		return nil, fmt.Errorf("unknown job %q, see list_jobs", job)
+	}
+	return task, nil
+}
+
+type mcpLintIssue struct {
+	Pipeline string `json:"pipeline"`
+	Job      string `json:"job,omitempty"`
+	Detail   string `json:"detail"`
+	Hint     string `json:"hint,omitempty"`
+}
+
+// mcpLint lints each pipeline, as --lint does.
+func mcpLint(pipelines []*model.Pipeline) *mcp.Result {
+	lintErrors, warnings := []mcpLintIssue{}, []mcpLintIssue{}
+	var sb strings.Builder
+	for _, pipeline := range pipelines {
+		linter := runner.NewLinterWithPipelines(pipeline, pipelines)
+		for _, issue := range linter.Lint() {
+			lintErrors = append(lintErrors, mcpLintIssue{pipeline.Name, issue.Job, issue.Detail, issue.Hint})
+			fmt.Fprintf(&sb, "error: %s: %s: %s\n", pipeline.Name, issue.Job, issue.Detail)
+		}
+		for _, issue := range linter.Unused() {
+			warnings = append(warnings, mcpLintIssue{pipeline.Name, issue.Job, issue.Detail, issue.Hint})
+			fmt.Fprintf(&sb, "warning: %s: %s: %s\n", pipeline.Name, issue.Job, issue.Detail)
+		}
+	}
+	if sb.Len() == 0 {
+		sb.WriteString("no issues found\n")
+	}
+	return &mcp.Result{
+		Text: sb.String(),
+		Structured: map[string]any{
+			"valid":    len(lintErrors) == 0,
+			"errors":   lintErrors,
+			"warnings": warnings,
+		},
+		IsError: len(lintErrors) > 0,
+	}
+}
+
+type mcpStep struct {
+	Name     string   `json:"name"`
+	Commands []string `json:"commands,omitempty"`
+	Task     string   `json:"task,omitempty"`
+	If       []string `json:"if,omitempty"`
+	Detach   bool     `json:"detach,omitempty"`
+	Deferred bool     `json:"deferred,omitempty"`
+}
+
+// mcpExplain describes the job with its dependencies and steps.
+func mcpExplain(task *model.ResolvedTask) (*mcp.Result, error) {
+	order, err := runner.ResolveJobDependencies(task.Pipeline.GetJobs(), task.Job.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	steps := []mcpStep{}
+	for _, step := range task.Job.Children() {
+		s := mcpStep{
+			Name:     step.String(),
+			Commands: step.Commands(),
+			Task:     step.Task,
+			Detach:   step.Detach,
+			Deferred: step.Deferred,
+		}
+		for _, cond := range step.If {
+			s.If = append(s.If, string(cond))
+		}
+		steps = append(steps, s)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s", task.Name)
+	if task.Job.Desc != "" {
+		fmt.Fprintf(&sb, ": %s", task.Job.Desc)
+	}
+	fmt.Fprintf(&sb, "\nrun order: %s\n", strings.Join(order, ", "))
+	for _, step := range steps {
+		fmt.Fprintf(&sb, "- %s\n", step.Name)
+		for _, cond := range step.If {
+			fmt.Fprintf(&sb, "    if: %s\n", cond)
+		}
+		for _, cmd := range step.Commands {
+			for _, line := range strings.Split(cmd, "\n") {
+				fmt.Fprintf(&sb, "    $ %s\n", line)
+			}
+		}
+	}
+
+	return &mcp.Result{
+		Text: sb.String(),
+		Structured: map[string]any{
+			"job":       task.Name,
+			"desc":      task.Job.Desc,
+			"run_order": order,
+			"timeout":   task.Job.Timeout,
+			"steps":     steps,
+		},
+	}, nil
+}
+
+type mcpFailure struct {
+	ID       string `json:"id"`
+	Command  string `json:"command"`
+	ExitCode int    `json:"exit_code"`
+	Error    string `json:"error,omitempty"`
+	Output   string `json:"output,omitempty"`
+}
+
+// mcpRun runs the job in a child atkins process, so its output can't
+// interleave with the protocol on stdout. Progress lines are streamed to
+// the client, and the failed commands are read back from the event log.
+func mcpRun(ctx context.Context, call *mcp.Call, cwd, job string) (*mcp.Result, error) {
+	self, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	logFile, err := os.CreateTemp("", "atkins-mcp-*.yml")
+	if err != nil {
+		return nil, err
+	}
+	logFile.Close()
+	defer os.Remove(logFile.Name())
+
+	cmd := exec.CommandContext(ctx, self, "--plain", "--log", logFile.Name(), job)
+	cmd.Dir = cwd
+	output, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	cmd.Stderr = cmd.Stdout
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	var transcript strings.Builder
+	scanner := bufio.NewScanner(output)
+	for scanner.Scan() {
+		line := colors.StripANSI(scanner.Text())
+		transcript.WriteString(line + "\n")
+		call.Progress(line)
+	}
+	runErr := cmd.Wait()
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("job %s cancelled", job)
+	}
+
+	exitCode := 0
+	if exitErr := (*exec.ExitError)(nil); errors.As(runErr, &exitErr) {
+		exitCode = exitErr.ExitCode()
+	} else if runErr != nil {
+		return nil, runErr
+	}
+
+	structured := map[string]any{
+		"job":       job,
+		"passed":    exitCode == 0,
+		"exit_code": exitCode,
+	}
+	failures := []mcpFailure{}
+	if log, err := eventlog.ReadLog(logFile.Name()); err == nil {
+		structured["run_id"] = log.Metadata.RunID
+		if log.Summary != nil {
+			structured["duration"] = log.Summary.Duration
+			structured["steps"] = map[string]int{
+				"total":   log.Summary.TotalSteps,
+				"passed":  log.Summary.PassedSteps,
+				"failed":  log.Summary.FailedSteps,
+				"skipped": log.Summary.SkippedSteps,
+			}
+		}
+		for _, event := range log.Events {
+			if event.Command == "" || (event.ExitCode == 0 && event.Error == "") {
+				continue
+			}
+			failures = append(failures, mcpFailure{
+				ID:       event.ID,
+				Command:  event.Command,
+				ExitCode: event.ExitCode,
+				Error:    event.Error,
+				Output:   tail(event.Output, mcpOutputLimit),
+			})
+		}
+	}
+	structured["failures"] = failures
+
+	return &mcp.Result{
+		Text:       transcript.String(),
+		Structured: structured,
+		IsError:    exitCode != 0,
+	}, nil
+}
+
+// tail returns the last n bytes of s.
+func tail(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return "..." + s[len(s)-n:]
+}
@@ -0,0 +1,277 @@
+// Package mcp implements a Model Context Protocol server over stdio,
+// exposing tools to LLM agents as JSON-RPC 2.0 requests.
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// ProtocolVersion is the MCP revision implemented by the server.
+const ProtocolVersion = "2025-06-18"
+
+// JSON-RPC error codes.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+// Tool is a tool exposed to the client.
+type Tool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	InputSchema map[string]any `json:"inputSchema"`
+
+	// Handler runs the tool. Returned errors are reported to the client
+	// as a failed tool result, not a protocol error.
+	Handler func(ctx context.Context, call *Call) (*Result, error) `json:"-"`
+}
+
+// Call is a tool invocation.
+type Call struct {
+	Arguments map[string]any
+
+	server        *Server
+	progressToken any
+	progress      int
+}
+
+// String returns a string argument, or an empty string.
+func (c *Call) String(name string) string {
+	s, _ := c.Arguments[name].(string)
+	return s
+}
+
+// Progress streams a message to the client while the tool runs. With a
+// progress token from the client, it's sent as a progress notification,
+// otherwise as a log message.
+func (c *Call) Progress(message string) {
+	c.progress++
+	if c.progressToken != nil {
+		c.server.notify("notifications/progress", map[string]any{
+			"progressToken": c.progressToken,
+			"progress":      c.progress,
+			"message":       message,
+		})
+		return
+	}
+	c.server.notify("notifications/message", map[string]any{
+		"level":  "info",
+		"logger": c.server.name,
+		"data":   message,
+	})
+}
+
+// Result is the result of a tool call. Text is shown to the model,
+// Structured holds the same result as JSON.
+type Result struct {
+	Text       string
+	Structured any
+	IsError    bool
+}
+
+// Server serves tools to a single client.
+type Server struct {
+	name    string
+	version string
+	tools   []*Tool
+
+	mu      sync.Mutex // Serializes writes to out
+	out     io.Writer
+	cancels map[string]context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// NewServer creates a server announcing itself with name and version.
+func NewServer(name, version string, tools ...*Tool) *Server {
+	return &Server{
+		name:    name,
+		version: version,
+		tools:   tools,
+		cancels: map[string]context.CancelFunc{},
+	}
+}
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *responseError  `json:"error,omitempty"`
+}
+
+type responseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Serve reads newline delimited requests from r and writes responses to
+// w until r is closed or ctx is done. Tool calls run concurrently and
+// are cancelled when the client cancels them or Serve returns.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer func() {
+		cancel()
+		s.wg.Wait()
+	}()
+	s.out = w
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var req request
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			s.reply(json.RawMessage("null"), nil, &responseError{Code: codeParseError, Message: err.Error()})
+			continue
+		}
+		s.handle(ctx, &req)
+	}
+	return scanner.Err()
+}
+
+func (s *Server) handle(ctx context.Context, req *request) {
+	// Requests without an ID are notifications and get no response
+	if req.ID == nil {
+		if req.Method == "notifications/cancelled" {
+			var params struct {
+				RequestID json.RawMessage `json:"requestId"`
+			}
+			if json.Unmarshal(req.Params, &params) == nil {
+				s.cancel(string(params.RequestID))
+			}
+		}
+		return
+	}
+
+	switch req.Method {
+	case "initialize":
+		var params struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		_ = json.Unmarshal(req.Params, &params)
+		version := ProtocolVersion
+		if params.ProtocolVersion != "" && params.ProtocolVersion < version {
+			version = params.ProtocolVersion
+		}
+		s.reply(req.ID, map[string]any{
+			"protocolVersion": version,
+			"capabilities": map[string]any{
+				"tools":   map[string]any{},
+				"logging": map[string]any{},
+			},
+			"serverInfo": map[string]any{"name": s.name, "version": s.version},
+		}, nil)
+	case "ping", "logging/setLevel":
+		s.reply(req.ID, map[string]any{}, nil)
+	case "tools/list":
+		s.reply(req.ID, map[string]any{"tools": s.tools}, nil)
+	case "tools/call":
+		s.callTool(ctx, req)
+	default:
+		if req.JSONRPC != "2.0" {
+			s.reply(req.ID, nil, &responseError{Code: codeInvalidRequest, Message: "expected jsonrpc 2.0"})
+			return
+		}
+		s.reply(req.ID, nil, &responseError{Code: codeMethodNotFound, Message: fmt.Sprintf("method %q not found", req.Method)})
+	}
+}
+
+func (s *Server) callTool(ctx context.Context, req *request) {
+	var params struct {
+		Name      string         `json:"name"`
+		Arguments map[string]any `json:"arguments"`
+		Meta      struct {
+			ProgressToken any `json:"progressToken"`
+		} `json:"_meta"`
+	}
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		s.reply(req.ID, nil, &responseError{Code: codeInvalidParams, Message: err.Error()})
+		return
+	}
+
+	var tool *Tool
+	for _, t := range s.tools {
+		if t.Name == params.Name {
+			tool = t
+		}
+	}
+	if tool == nil {
+		s.reply(req.ID, nil, &responseError{Code: codeInvalidParams, Message: fmt.Sprintf("unknown tool %q", params.Name)})
+		return
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	s.mu.Lock()
+	s.cancels[string(req.ID)] = cancel
+	s.mu.Unlock()
+
+	call := &Call{
+		Arguments:     params.Arguments,
+		server:        s,
+		progressToken: params.Meta.ProgressToken,
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer s.cancel(string(req.ID))
+
+		result, err := tool.Handler(ctx, call)
+		if err != nil {
+			result = &Result{Text: err.Error(), IsError: true}
+		}
+		if result == nil {
+			result = &Result{}
+		}
+		content := map[string]any{
+			"content": []map[string]any{{"type": "text", "text": result.Text}},
+			"isError": result.IsError,
+		}
+		if result.Structured != nil {
+			content["structuredContent"] = result.Structured
+		}
+		s.reply(req.ID, content, nil)
+	}()
+}
+
+// cancel cancels the tool call with the given request ID.
+func (s *Server) cancel(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cancel, ok := s.cancels[id]; ok {
+		cancel()
+		delete(s.cancels, id)
+	}
+}
+
+func (s *Server) reply(id json.RawMessage, result any, rpcErr *responseError) {
+	s.write(response{JSONRPC: "2.0", ID: id, Result: result, Error: rpcErr})
+}
+
+func (s *Server) notify(method string, params any) {
+	s.write(map[string]any{"jsonrpc": "2.0", "method": method, "params": params})
+}
+
+func (s *Server) write(msg any) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, _ = s.out.Write(append(data, '\n'))
+}
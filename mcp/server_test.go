@@ -0,0 +1,92 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func serve(t *testing.T, tools []*Tool, requests ...string) []map[string]any {
+	t.Helper()
+
+	var out bytes.Buffer
+	server := NewServer("atkins", "dev", tools...)
+	require.NoError(t, server.Serve(context.Background(), strings.NewReader(strings.Join(requests, "\n")), &out))
+
+	var messages []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var msg map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &msg))
+		messages = append(messages, msg)
+	}
+	return messages
+}
+
+func TestServer(t *testing.T) {
+	echo := &Tool{
+		Name:        "echo",
+		InputSchema: map[string]any{"type": "object"},
+		Handler: func(ctx context.Context, call *Call) (*Result, error) {
+			call.Progress("working")
+			if call.String("text") == "" {
+				return nil, errors.New("missing text")
+			}
+			return &Result{Text: call.String("text"), Structured: map[string]any{"ok": true}}, nil
+		},
+	}
+
+	t.Run("initialize", func(t *testing.T) {
+		messages := serve(t, nil, `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26"}}`)
+		require.Len(t, messages, 1)
+		result := messages[0]["result"].(map[string]any)
+		assert.Equal(t, "2025-03-26", result["protocolVersion"])
+		assert.Equal(t, "atkins", result["serverInfo"].(map[string]any)["name"])
+	})
+
+	t.Run("tools/list", func(t *testing.T) {
+		messages := serve(t, []*Tool{echo}, `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`)
+		require.Len(t, messages, 1)
+		tools := messages[0]["result"].(map[string]any)["tools"].([]any)
+		require.Len(t, tools, 1)
+		assert.Equal(t, "echo", tools[0].(map[string]any)["name"])
+	})
+
+	t.Run("tools/call streams progress", func(t *testing.T) {
+		messages := serve(t, []*Tool{echo},
+			`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"echo","arguments":{"text":"hi"},"_meta":{"progressToken":"p"}}}`)
+		require.Len(t, messages, 2)
+
+		assert.Equal(t, "notifications/progress", messages[0]["method"])
+		assert.Equal(t, "working", messages[0]["params"].(map[string]any)["message"])
+
+		result := messages[1]["result"].(map[string]any)
+		assert.Equal(t, false, result["isError"])
+		assert.Equal(t, "hi", result["content"].([]any)[0].(map[string]any)["text"])
+		assert.Equal(t, map[string]any{"ok": true}, result["structuredContent"])
+	})
+
+	t.Run("tool errors are results", func(t *testing.T) {
+		messages := serve(t, []*Tool{echo}, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"echo"}}`)
+		require.Len(t, messages, 2)
+		assert.Equal(t, "notifications/message", messages[0]["method"])
+
+		result := messages[1]["result"].(map[string]any)
+		assert.Equal(t, true, result["isError"])
+		assert.Equal(t, "missing text", result["content"].([]any)[0].(map[string]any)["text"])
+	})
+
+	t.Run("unknown method", func(t *testing.T) {
+		messages := serve(t, nil,
+			`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+			`{"jsonrpc":"2.0","id":"a","method":"resources/list"}`)
+		require.Len(t, messages, 1)
+		assert.Equal(t, "a", messages[0]["id"])
+		assert.Equal(t, float64(codeMethodNotFound), messages[0]["error"].(map[string]any)["code"])
+	})
+}
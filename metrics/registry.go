@@ -0,0 +1,249 @@
+// Package metrics collects run metrics and writes them in the Prometheus text format.
+package metrics
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Type is a Prometheus metric type.
+type Type string
+
+// Metric types.
+const (
+	Counter   Type = "counter"
+	Gauge     Type = "gauge"
+	Histogram Type = "histogram"
+)
+
+// DefaultBuckets are histogram buckets in seconds, sized for pipeline steps.
+var DefaultBuckets = []float64{0.1, 0.5, 1, 5, 10, 30, 60, 300, 600, 1800}
+
+// Labels are metric labels.
+type Labels map[string]string
+
+// family is a named metric with its samples.
+type family struct {
+	name    string
+	help    string
+	typ     Type
+	samples map[string]float64 // Keyed by sample name with rendered labels
+}
+
+// Registry holds metric families and their samples.
+type Registry struct {
+	families map[string]*family
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		families: make(map[string]*family),
+	}
+}
+
+// Register declares a metric family. Registering an existing family updates its help text.
+func (r *Registry) Register(name, help string, typ Type) {
+	if f, ok := r.families[name]; ok {
+		f.help = help
+		f.typ = typ
+		return
+	}
+	r.families[name] = &family{
+		name:    name,
+		help:    help,
+		typ:     typ,
+		samples: make(map[string]float64),
+	}
+}
+
+// Add adds value to a counter sample.
+func (r *Registry) Add(name string, labels Labels, value float64) {
+	r.family(name, Counter).samples[sampleKey(name, labels)] += value
+}
+
+// Set sets a gauge sample.
+func (r *Registry) Set(name string, labels Labels, value float64) {
+	r.family(name, Gauge).samples[sampleKey(name, labels)] = value
+}
+
+// Observe records a histogram observation using DefaultBuckets.
+func (r *Registry) Observe(name string, labels Labels, value float64) {
+	f := r.family(name, Histogram)
+	for _, bucket := range DefaultBuckets {
+		// Every bucket is written, including empty ones
+		key := sampleKey(name+"_bucket", withLabel(labels, "le", formatFloat(bucket)))
+		f.samples[key] += 0
+		if value <= bucket {
+			f.samples[key]++
+		}
+	}
+	f.samples[sampleKey(name+"_bucket", withLabel(labels, "le", "+Inf"))]++
+	f.samples[sampleKey(name+"_sum", labels)] += value
+	f.samples[sampleKey(name+"_count", labels)]++
+}
+
+func (r *Registry) family(name string, typ Type) *family {
+	if _, ok := r.families[name]; !ok {
+		r.Register(name, "", typ)
+	}
+	return r.families[name]
+}
+
+// Load reads samples from a previously written text file, so counters and
+// histograms accumulate across one-shot runs. Gauges are replaced by new values.
+// A missing file is not an error.
+func (r *Registry) Load(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+
+	types := make(map[string]Type)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if fields := strings.Fields(line); strings.HasPrefix(line, "# TYPE ") && len(fields) == 4 {
+			types[fields[2]] = Type(fields[3])
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		idx := strings.LastIndex(line, " ")
+		if idx < 0 {
+			continue
+		}
+		key, raw := line[:idx], line[idx+1:]
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			continue
+		}
+
+		name := key
+		if i := strings.Index(key, "{"); i >= 0 {
+			name = key[:i]
+		}
+		familyName := familyOf(name, types)
+		typ, ok := types[familyName]
+		if !ok || typ == Gauge {
+			continue
+		}
+		r.family(familyName, typ).samples[key] += value
+	}
+	return scanner.Err()
+}
+
+// familyOf returns the family name for a sample name, resolving histogram suffixes.
+func familyOf(name string, types map[string]Type) string {
+	for _, suffix := range []string{"_bucket", "_sum", "_count"} {
+		if base, ok := strings.CutSuffix(name, suffix); ok && types[base] == Histogram {
+			return base
+		}
+	}
+	return name
+}
+
+// Write writes all metrics in the Prometheus text exposition format.
+func (r *Registry) Write(w io.Writer) error {
+	names := make([]string, 0, len(r.families))
+	for name := range r.families {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	bw := bufio.NewWriter(w)
+	for _, name := range names {
+		f := r.families[name]
+		if len(f.samples) == 0 {
+			continue
+		}
+		if f.help != "" {
+			fmt.Fprintf(bw, "# HELP %s %s\n", f.name, f.help)
+		}
+		fmt.Fprintf(bw, "# TYPE %s %s\n", f.name, f.typ)
+
+		keys := make([]string, 0, len(f.samples))
+		for key := range f.samples {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		for _, key := range keys {
+			fmt.Fprintf(bw, "%s %s\n", key, formatFloat(f.samples[key]))
+		}
+	}
+	return bw.Flush()
+}
+
+// WriteTextfile atomically writes all metrics to path, as expected by the
+// node_exporter textfile collector.
+func (r *Registry) WriteTextfile(path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := r.Write(tmp); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// sampleKey renders a sample name with sorted labels.
+func sampleKey(name string, labels Labels) string {
+	if len(labels) == 0 {
+		return name
+	}
+
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		parts = append(parts, key+"="+strconv.Quote(labels[key]))
+	}
+	return name + "{" + strings.Join(parts, ",") + "}"
+}
+
+func withLabel(labels Labels, key, value string) Labels {
+	result := make(Labels, len(labels)+1)
+	for k, v := range labels {
+		result[k] = v
+	}
+	result[key] = value
+	return result
+}
+
+func formatFloat(v float64) string {
+	if v == math.Trunc(v) && math.Abs(v) < 1e15 {
+		return strconv.FormatInt(int64(v), 10)
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_Write(t *testing.T) {
+	reg := NewRegistry()
+	reg.Register("runs_total", "Total runs.", Counter)
+	reg.Register("unused_total", "Not written without samples.", Counter)
+	reg.Add("runs_total", Labels{"result": "pass", "pipeline": "ci"}, 1)
+	reg.Add("runs_total", Labels{"result": "pass", "pipeline": "ci"}, 1)
+	reg.Set("last_run", nil, 1700000000)
+
+	var buf bytes.Buffer
+	require.NoError(t, reg.Write(&buf))
+
+	assert.Equal(t, `# TYPE last_run gauge
+last_run 1700000000
+# HELP runs_total Total runs.
+# TYPE runs_total counter
+runs_total{pipeline="ci",result="pass"} 2
+`, buf.String())
+}
+
+func TestRegistry_Observe(t *testing.T) {
+	reg := NewRegistry()
+	reg.Observe("duration_seconds", Labels{"step": "build"}, 2)
+
+	f := reg.families["duration_seconds"]
+	require.NotNil(t, f)
+	assert.Equal(t, Histogram, f.typ)
+	assert.Equal(t, 0.0, f.samples[`duration_seconds_bucket{le="1",step="build"}`])
+	assert.Equal(t, 1.0, f.samples[`duration_seconds_bucket{le="5",step="build"}`])
+	assert.Equal(t, 1.0, f.samples[`duration_seconds_bucket{le="+Inf",step="build"}`])
+	assert.Equal(t, 2.0, f.samples[`duration_seconds_sum{step="build"}`])
+	assert.Equal(t, 1.0, f.samples[`duration_seconds_count{step="build"}`])
+	assert.Len(t, f.samples, len(DefaultBuckets)+3)
+}
+
+func TestRegistry_TextfileAccumulates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "atkins.prom")
+
+	record := func(timestamp float64) {
+		reg := NewRegistry()
+		reg.Register("runs_total", "Total runs.", Counter)
+		reg.Register("duration_seconds", "Durations.", Histogram)
+		reg.Register("last_run", "Last run.", Gauge)
+		require.NoError(t, reg.Load(path))
+
+		reg.Add("runs_total", nil, 1)
+		reg.Observe("duration_seconds", nil, 0.3)
+		reg.Set("last_run", nil, timestamp)
+		require.NoError(t, reg.WriteTextfile(path))
+	}
+
+	record(100)
+	record(200)
+
+	reg := NewRegistry()
+	require.NoError(t, reg.Load(path))
+	assert.Equal(t, 2.0, reg.families["runs_total"].samples["runs_total"])
+	assert.Equal(t, 2.0, reg.families["duration_seconds"].samples["duration_seconds_count"])
+	assert.Equal(t, 0.6, reg.families["duration_seconds"].samples["duration_seconds_sum"])
+	assert.NotContains(t, reg.families, "last_run", "gauges are not loaded")
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "last_run 200\n")
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "temporary file is removed")
+}
+
+func TestRegistry_LoadMissing(t *testing.T) {
+	reg := NewRegistry()
+	assert.NoError(t, reg.Load(filepath.Join(t.TempDir(), "missing.prom")))
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/pflag"
+	"github.com/titpetric/cli"
+
+	"github.com/titpetric/atkins/colors"
+	"github.com/titpetric/atkins/runner"
+	"github.com/titpetric/atkins/treeview"
+)
+
+// Migrate provides a cli.Command that rewrites legacy pipeline constructs.
+func Migrate() *cli.Command {
+	var dryRun bool
+
+	return &cli.Command{
+		Name:  "migrate",
+		Title: "Migrate pipeline files to the current schema",
+		Usage: func() string {
+			return "atkins migrate [--dry-run] [file.yml...]"
+		},
+		Bind: func(fs *pflag.FlagSet) {
+			fs.BoolVar(&dryRun, "dry-run", false, "Only report the legacy constructs, don't rewrite the files")
+		},
+		Run: func(ctx context.Context, args []string) error {
+			files := args
+			if len(files) == 0 {
+				configPath, _, err := runner.DiscoverConfigFromCwd()
+				if err != nil || configPath == "" {
+					return fmt.Errorf("%s no pipeline file found, pass the files to migrate", treeview.ErrorHeader())
+				}
+				files = []string{configPath}
+			}
+			return runMigrate(files, dryRun)
+		},
+	}
+}
+
+func runMigrate(files []string, dryRun bool) error {
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+		}
+
+		migrated, found, err := runner.MigratePipeline(data)
+		if err != nil {
+			return fmt.Errorf("%s %s: %v", treeview.ErrorHeader(), file, err)
+		}
+		if len(found) == 0 {
+			fmt.Printf("%s %s uses the current schema\n", colors.BrightGreen("✓"), file)
+			continue
+		}
+
+		fmt.Printf("%s %s has %d legacy construct(s):\n", colors.BrightYellow("!"), file, len(found))
+		for _, d := range found {
+			if d.Migrated {
+				fmt.Printf("  %s %s: %s → %s\n", colors.BrightYellow("~"), d.Path, d.Construct, d.Replacement)
+				continue
+			}
+			fmt.Printf("  %s %s: %s, %s\n", colors.BrightRed("!"), d.Path, d.Construct, d.Replacement)
+		}
+
+		if dryRun {
+			continue
+		}
+
+		info, err := os.Stat(file)
+		if err != nil {
+			return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+		}
+		if err := os.WriteFile(file, migrated, info.Mode().Perm()); err != nil {
+			return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BenchmarkOutputEnv names the env var holding the file the benchmark
+// step writes the `go test -bench` output to.
+const BenchmarkOutputEnv = "ATKINS_BENCH_OUTPUT"
+
+// Benchmark configures a benchmark job. The job runs `go test -bench`
+// and fails when a benchmark got slower than Threshold percent,
+// compared to the previous run or the run of the Baseline ref.
+type Benchmark struct {
+	Packages  string  `yaml:"packages,omitempty"`  // Packages to benchmark, defaults to ./...
+	Bench     string  `yaml:"bench,omitempty"`     // Benchmark regex, defaults to .
+	Count     int     `yaml:"count,omitempty"`     // Runs per benchmark, defaults to 5
+	Benchtime string  `yaml:"benchtime,omitempty"` // Passed to -benchtime, e.g. 2s or 100x
+	Baseline  string  `yaml:"baseline,omitempty"`  // Git ref whose results to compare with, defaults to the previous run
+	Threshold float64 `yaml:"threshold,omitempty"` // Allowed slowdown in percent, defaults to 10
+}
+
+// GetThreshold returns the allowed slowdown in percent.
+func (b *Benchmark) GetThreshold() float64 {
+	if b.Threshold > 0 {
+		return b.Threshold
+	}
+	return 10
+}
+
+// Command returns the shell command running the benchmarks. The output
+// is also written to the file named by $ATKINS_BENCH_OUTPUT.
+func (b *Benchmark) Command() string {
+	packages, bench, count := b.Packages, b.Bench, b.Count
+	if packages == "" {
+		packages = "./..."
+	}
+	if bench == "" {
+		bench = "."
+	}
+	if count <= 0 {
+		count = 5
+	}
+
+	args := []string{"go", "test", "-run", "'^$'", "-bench", "'" + bench + "'", "-benchmem", fmt.Sprintf("-count=%d", count)}
+	if b.Benchtime != "" {
+		args = append(args, "-benchtime="+b.Benchtime)
+	}
+	args = append(args, packages)
+	return "set -o pipefail; " + strings.Join(args, " ") + ` | tee "$` + BenchmarkOutputEnv + `"`
+}
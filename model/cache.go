@@ -0,0 +1,9 @@
+package model
+
+// Cache configures the result cache of a job. The outputs are stored keyed
+// by a hash of the job and its inputs, and restored instead of running the
+// job when the hash matches a previous run.
+type Cache struct {
+	Inputs  []string `yaml:"inputs,omitempty"`  // Glob patterns of the files the result depends on, `**` matches across directories
+	Outputs []string `yaml:"outputs,omitempty"` // Files and directories the job produces
+}
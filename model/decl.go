@@ -11,4 +11,8 @@ type Decl struct {
 
 // EnvDecl represents an environment variable declaration that can contain
 // both manually-set variables and includes from external files.
-type EnvDecl Decl
+type EnvDecl struct {
+	Vars    map[string]any    `yaml:"vars,omitempty"`
+	Include *IncludeDecl      `yaml:"include,omitempty"`
+	Doc     map[string]string `yaml:"doc,omitempty"` // Descriptions of the variables a job expects, shown by -l and atkins env
+}
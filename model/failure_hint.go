@@ -0,0 +1,9 @@
+package model
+
+// FailureHint labels failures whose output matches a pattern, with a
+// suggested remedy shown with the failed step.
+type FailureHint struct {
+	Match string `yaml:"match"`           // Regular expression matched against the output, case insensitive
+	Label string `yaml:"label,omitempty"` // Short label of the failure class, e.g. "disk"
+	Hint  string `yaml:"hint"`            // Suggested remedy
+}
@@ -0,0 +1,51 @@
+package model
+
+import (
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Input types of an input schema.
+const (
+	InputString = "string"
+	InputNumber = "number"
+	InputBool   = "bool"
+	InputList   = "list"
+	InputMap    = "map"
+)
+
+// Inputs declares the values a job accepts from `with:` of task steps.
+type Inputs map[string]*Input
+
+// With holds the inputs a task step passes to the task.
+type With map[string]any
+
+// UnmarshalYAML decodes nested maps as map[string]any, like variables.
+func (w *With) UnmarshalYAML(node *yaml.Node) error {
+	var values map[string]any
+	if err := node.Decode(&values); err != nil {
+		return err
+	}
+	*w = values
+	return nil
+}
+
+// Input is the schema of a value passed with `with:`.
+type Input struct {
+	Type       string `yaml:"type,omitempty"`       // string, number, bool, list or map, any type when empty
+	Required   bool   `yaml:"required,omitempty"`   // If true, task steps must pass the input
+	Default    any    `yaml:"default,omitempty"`    // Value used when the input isn't passed
+	Desc       string `yaml:"desc,omitempty"`       // Description of the input
+	Items      *Input `yaml:"items,omitempty"`      // Schema of the items of a list
+	Properties Inputs `yaml:"properties,omitempty"` // Schema of the keys of a map
+}
+
+// UnmarshalYAML supports the input type as a scalar.
+func (i *Input) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		i.Type = node.Value
+		return nil
+	}
+
+	type rawInput Input
+	return node.Decode((*rawInput)(i))
+}
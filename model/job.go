@@ -19,16 +19,28 @@ type Job struct {
 	Run         string       `yaml:"run,omitempty"`
 	Steps       []*Step      `yaml:"steps,omitempty"`
 	Detach      bool         `yaml:"detach,omitempty"`
-	Show        *bool        `yaml:"show,omitempty"` // Show in display (true=show, false=hide, nil=show if root level/ invoked)
+	FailFast    *bool        `yaml:"fail_fast,omitempty"` // Whether a failure cancels the other detached steps (default) or loop iterations
+	Show        *bool        `yaml:"show,omitempty"`      // Show in display (true=show, false=hide, nil=show if root level/ invoked)
 	DependsOn   Dependencies `yaml:"depends_on,omitempty"`
-	Aliases     []string     `yaml:"aliases,omitempty"`  // Alternative names for invoking this job
-	Requires    []string     `yaml:"requires,omitempty"` // Variables required when invoked in a loop
-	Timeout     string       `yaml:"timeout,omitempty"`  // e.g., "10m", "300s"
+	Stage       string       `yaml:"stage,omitempty"`     // Stage the job runs in, jobs of a stage run in parallel
+	Aliases     []string     `yaml:"aliases,omitempty"`   // Alternative names for invoking this job
+	Requires    Requirements `yaml:"requires,omitempty"`  // Variables, env and commands required before the job runs
+	Preflight   *bool        `yaml:"preflight,omitempty"` // Whether to warn about step commands missing from PATH before the job runs (default true)
+	Timeout     string       `yaml:"timeout,omitempty"`   // e.g., "10m", "300s"
 	Summarize   bool         `yaml:"summarize,omitempty"`
 	Quiet       bool         `yaml:"quiet,omitempty"`
 	Passthru    bool         `yaml:"passthru,omitempty"`    // If true, output is printed with tree indentation
-	TTY         bool         `yaml:"tty,omitempty"`         // If true, allocate a PTY for all steps (enables color output)
+	TTY         TTYMode      `yaml:"tty,omitempty"`         // "always" allocates a PTY for all steps (enables color output), "never" for none
 	Interactive bool         `yaml:"interactive,omitempty"` // If true, stream output live and connect stdin for keyboard input
+	Lenient     bool         `yaml:"lenient,omitempty"`     // If true, failed ${{ }} interpolations are left in place instead of failing
+	Workspace   string       `yaml:"workspace,omitempty"`   // "clean" (temporary project copy) or "worktree" (git worktree of HEAD)
+	Services    Dependencies `yaml:"services,omitempty"`    // Pipeline services the job uses, started before its steps
+	Benchmark   *Benchmark   `yaml:"benchmark,omitempty"`   // Run go benchmarks and fail on regressions
+	Cache       *Cache       `yaml:"cache,omitempty"`       // Restore the outputs of a previous run with the same inputs
+	Network     string       `yaml:"network,omitempty"`     // "none" runs the steps without network access, "host" (default) with
+	Lock        *Lock        `yaml:"lock,omitempty"`        // Advisory lock held while the job runs
+	Ports       Ports        `yaml:"ports,omitempty"`       // Named TCP ports, "auto" allocates a free port at job start
+	Inputs      Inputs       `yaml:"inputs,omitempty"`      // Values accepted from `with:` of task steps, validated before the job runs
 
 	Name   string `yaml:"-"`
 	Nested bool   `yaml:"-"`
@@ -114,5 +126,10 @@ func (j *Job) UnmarshalYAML(node *yaml.Node) error {
 		}
 	}
 
+	// A benchmark job without steps runs the benchmarks as its only step
+	if j.Benchmark != nil && j.Steps == nil && j.Cmds == nil {
+		j.Steps = []*Step{{Run: j.Benchmark.Command(), Name: "go test -bench"}}
+	}
+
 	return nil
 }
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Lint runs formatters and linters as a step, and reports their findings
+// by file and line.
+type Lint struct {
+	Tools []string `yaml:"tools"` // gofmt, goimports or golangci-lint
+}
+
+// String returns the tools of the step, e.g. "gofmt, golangci-lint".
+func (l *Lint) String() string {
+	return strings.Join(l.Tools, ", ")
+}
+
+// UnmarshalYAML supports a tool or a list of tools.
+func (l *Lint) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		l.Tools = []string{node.Value}
+		return nil
+	case yaml.SequenceNode:
+		return node.Decode(&l.Tools)
+	}
+
+	type rawLint Lint
+	return node.Decode((*rawLint)(l))
+}
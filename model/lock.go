@@ -0,0 +1,23 @@
+package model
+
+import (
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Lock serializes access to a shared resource, like a local registry or
+// a database port, across parallel jobs and concurrent pipelines.
+type Lock struct {
+	Name    string `yaml:"name"`              // Name of the resource, may use ${{ }} variables
+	Timeout string `yaml:"timeout,omitempty"` // How long to wait for the lock, e.g. "5m", by default until the job times out
+}
+
+// UnmarshalYAML supports a lock name as a scalar.
+func (l *Lock) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		l.Name = node.Value
+		return nil
+	}
+
+	type rawLock Lock
+	return node.Decode((*rawLock)(l))
+}
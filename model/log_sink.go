@@ -0,0 +1,14 @@
+package model
+
+// LogSink streams step output and job results to an external log stack.
+// Type selects the sink, Only and Jobs filter the records it receives.
+type LogSink struct {
+	Type     string            `yaml:"type"`                // syslog, loki or file
+	Address  string            `yaml:"address,omitempty"`   // syslog: udp://, tcp:// or unix:// address, defaults to /dev/log; loki: push URL
+	Path     string            `yaml:"path,omitempty"`      // file: JSON lines file
+	MaxSize  int               `yaml:"max_size,omitempty"`  // file: rotate after this many megabytes, defaults to 10
+	MaxFiles int               `yaml:"max_files,omitempty"` // file: rotated files to keep, defaults to 5
+	Labels   map[string]string `yaml:"labels,omitempty"`    // loki: stream labels, added to app=atkins and job
+	Only     string            `yaml:"only,omitempty"`      // "failures" sends failed steps and jobs only
+	Jobs     []string          `yaml:"jobs,omitempty"`      // Only send records of these jobs
+}
@@ -3,7 +3,7 @@ package model
 import (
 	"maps"
 	"slices"
-	"sort"
+	"strings"
 
 	yaml "gopkg.in/yaml.v3"
 )
@@ -12,14 +12,38 @@ import (
 type Pipeline struct {
 	*Decl
 
-	ID   string `yaml:"-"`
-	Name string `yaml:"name,omitempty"`
-	Dir  string `yaml:"dir,omitempty"`
+	ID     string `yaml:"-"`
+	Source string `yaml:"-"` // File the pipeline was loaded from
+	Name   string `yaml:"name,omitempty"`
+	Dir    string `yaml:"dir,omitempty"`
+
+	Extends string `yaml:"extends,omitempty"` // Skill ID this skill builds on
+	Timeout string `yaml:"timeout,omitempty"` // Limits the whole run, e.g. "30m"
+
+	Requires PipelineRequirements `yaml:"requires,omitempty"` // Checked when the pipeline is loaded, and by `atkins version --check`
+
+	Default []string `yaml:"default,omitempty"` // Jobs run in order when no job is given, instead of a default job
+	Stages  []string `yaml:"stages,omitempty"`  // Order of job stages, by default the order they are first used in
 
 	Jobs  map[string]*Job `yaml:"jobs,omitempty"`
 	Tasks map[string]*Job `yaml:"tasks,omitempty"`
+	Tools Tools           `yaml:"tools,omitempty"`
+
+	Aliases map[string]string `yaml:"aliases,omitempty"` // Global aliases to jobs in any pipeline, e.g. t: go:test
+
+	Services Services `yaml:"services,omitempty"` // Background services shared by jobs
+
+	LogSinks []*LogSink `yaml:"log_sinks,omitempty"` // External destinations for step output and job results
 
-	When *PipelineWhen `yaml:"when,omitempty"`
+	Hints []*FailureHint `yaml:"hints,omitempty"` // Hints for failures matching the output, checked before the built-in ones
+
+	Scrub []*ScrubRule `yaml:"scrub,omitempty"` // Patterns replaced in the captured output and logs
+
+	When    *PipelineWhen `yaml:"when,omitempty"`
+	Detect  []string      `yaml:"detect,omitempty"`  // Files or dirs/ enabling the skill, also marking a project root
+	Lenient bool          `yaml:"lenient,omitempty"` // If true, failed ${{ }} interpolations are left in place instead of failing
+
+	JobOrder []string `yaml:"-"` // Job names in declaration order, captured when unmarshalling
 }
 
 // UnmarshalYAML implements custom unmarshalling for Pipeline to handle Decl.
@@ -34,6 +58,34 @@ func (p *Pipeline) UnmarshalYAML(node *yaml.Node) error {
 		return err
 	}
 
+	p.JobOrder = declarationOrder(node, "jobs")
+	if len(p.Jobs) == 0 {
+		p.JobOrder = declarationOrder(node, "tasks")
+	}
+
+	return nil
+}
+
+// declarationOrder returns the keys of the mapping under key, in the order
+// they are declared in the document.
+func declarationOrder(node *yaml.Node, key string) []string {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value != key {
+			continue
+		}
+		value := node.Content[i+1]
+		if value.Kind != yaml.MappingNode {
+			return nil
+		}
+		result := make([]string, 0, len(value.Content)/2)
+		for j := 0; j+1 < len(value.Content); j += 2 {
+			result = append(result, value.Content[j].Value)
+		}
+		return result
+	}
 	return nil
 }
 
@@ -47,13 +99,14 @@ func (p *Pipeline) GetJobs() map[string]*Job {
 
 // GetKeys will return the available targets in the pipeline. It uses the
 // pipeline ID to optionally prefix job/tasks map keys. The default
-// job is ordered first in the result.
+// job is ordered first in the result, followed by the others in
+// declaration order.
 func (p *Pipeline) GetKeys() []string {
 	var hasDefault bool
-	jobs := p.GetJobs()
-	result := make([]string, 0, len(jobs))
+	names := p.JobNames()
+	result := make([]string, 0, len(names))
 
-	for key := range jobs {
+	for _, key := range names {
 		if key == "default" {
 			hasDefault = true
 			continue
@@ -62,8 +115,6 @@ func (p *Pipeline) GetKeys() []string {
 		result = append(result, key)
 	}
 
-	sort.Strings(result)
-
 	if hasDefault {
 		result = append([]string{"default"}, result...)
 	}
@@ -82,9 +133,53 @@ func (p *Pipeline) HasJobs() bool {
 	return len(p.GetJobs()) > 0
 }
 
-// JobNames returns the names of all jobs (or tasks) in the pipeline.
+// JobNames returns the names of all jobs (or tasks) in the pipeline, in
+// declaration order. Jobs without a captured order, such as jobs added
+// after loading, follow sorted by name.
 func (p *Pipeline) JobNames() []string {
-	return slices.Collect(maps.Keys(p.GetJobs()))
+	jobs := p.GetJobs()
+	result := make([]string, 0, len(jobs))
+	seen := make(map[string]bool, len(jobs))
+	for _, name := range p.JobOrder {
+		if _, ok := jobs[name]; ok && !seen[name] {
+			seen[name] = true
+			result = append(result, name)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(jobs)) {
+		if !seen[name] {
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+// RootJobs returns the names of the root-level jobs in declaration order,
+// without the default job.
+func (p *Pipeline) RootJobs() []string {
+	var result []string
+	for _, name := range p.JobNames() {
+		if name != "default" && !strings.Contains(name, ":") {
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+// StageNames returns the stages of the pipeline in run order: the stages:
+// list if set, otherwise the stages of jobs in declaration order.
+func (p *Pipeline) StageNames() []string {
+	if len(p.Stages) > 0 {
+		return p.Stages
+	}
+	jobs := p.GetJobs()
+	var result []string
+	for _, name := range p.JobNames() {
+		if job := jobs[name]; job != nil && job.Stage != "" && !slices.Contains(result, job.Stage) {
+			result = append(result, job.Stage)
+		}
+	}
+	return result
 }
 
 // GetAliases will give key => value mapping for commands in a pipeline.
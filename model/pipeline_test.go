@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	yaml "gopkg.in/yaml.v3"
 
 	"github.com/titpetric/atkins/model"
@@ -150,6 +151,80 @@ jobs:
 	assert.Equal(t, "1.0.0", pipeline.Vars["version"])
 }
 
+// TestPipelineUnmarshalYAML_JobOrder tests that jobs keep their declaration order.
+func TestPipelineUnmarshalYAML_JobOrder(t *testing.T) {
+	yamlContent := `
+jobs:
+  zeta: echo zeta
+  default: echo default
+  alpha: echo alpha
+  build:test: echo build:test
+  mid: echo mid
+`
+
+	for range 10 {
+		var pipeline model.Pipeline
+		require.NoError(t, yaml.Unmarshal([]byte(yamlContent), &pipeline))
+
+		assert.Equal(t, []string{"zeta", "default", "alpha", "build:test", "mid"}, pipeline.JobNames())
+		assert.Equal(t, []string{"default", "zeta", "alpha", "build:test", "mid"}, pipeline.GetKeys())
+	}
+
+	t.Run("tasks", func(t *testing.T) {
+		var pipeline model.Pipeline
+		require.NoError(t, yaml.Unmarshal([]byte("tasks:\n  b: echo b\n  a: echo a\n"), &pipeline))
+		assert.Equal(t, []string{"b", "a"}, pipeline.JobNames())
+	})
+
+	t.Run("jobs added after loading", func(t *testing.T) {
+		var pipeline model.Pipeline
+		require.NoError(t, yaml.Unmarshal([]byte("jobs:\n  b: echo b\n"), &pipeline))
+		pipeline.Jobs["d"] = &model.Job{}
+		pipeline.Jobs["c"] = &model.Job{}
+		assert.Equal(t, []string{"b", "c", "d"}, pipeline.JobNames())
+	})
+}
+
+// TestPipeline_RootJobs tests that root jobs keep their declaration order.
+func TestPipeline_RootJobs(t *testing.T) {
+	yamlContent := `
+default: [lint, test]
+jobs:
+  test: echo test
+  default: echo default
+  build: echo build
+  build:docker: echo docker
+  lint: echo lint
+`
+
+	var pipeline model.Pipeline
+	require.NoError(t, yaml.Unmarshal([]byte(yamlContent), &pipeline))
+
+	assert.Equal(t, []string{"lint", "test"}, pipeline.Default)
+	assert.Equal(t, []string{"test", "build", "lint"}, pipeline.RootJobs())
+}
+
+// TestPipeline_StageNames tests that stages are ordered by first use, unless declared.
+func TestPipeline_StageNames(t *testing.T) {
+	yamlContent := `
+jobs:
+  e2e:
+    stage: test
+  lint:
+    stage: prepare
+  unit:
+    stage: test
+  default: echo default
+`
+
+	var pipeline model.Pipeline
+	require.NoError(t, yaml.Unmarshal([]byte(yamlContent), &pipeline))
+	assert.Equal(t, []string{"test", "prepare"}, pipeline.StageNames())
+
+	pipeline.Stages = []string{"prepare", "test"}
+	assert.Equal(t, []string{"prepare", "test"}, pipeline.StageNames())
+}
+
 // TestJobUnmarshalYAML_FullDepthDecoding tests full decoding of vars and include in Decl.
 func TestJobUnmarshalYAML_FullDepthDecoding(t *testing.T) {
 	// Create a temporary include file
@@ -2,6 +2,10 @@ package model
 
 // PipelineWhen is a list of files that need to exist somewhere to
 // enable the pipeline, e.g. compose.yml for compose pipeline.
+//
+// Remotes additionally requires a git remote URL containing one of the
+// values, e.g. github.com. When both are set, both must match.
 type PipelineWhen struct {
-	Files []string `yaml:"files"`
+	Files   []string `yaml:"files"`
+	Remotes []string `yaml:"remotes,omitempty"`
 }
@@ -0,0 +1,42 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// PortForward forwards a local port to a remote service for the rest of the job.
+// Exactly one of Kubectl, SSH or Socat selects how the port is forwarded.
+type PortForward struct {
+	Kubectl   string `yaml:"kubectl,omitempty"`    // Resource for `kubectl port-forward`, e.g. svc/api
+	Namespace string `yaml:"namespace,omitempty"`  // Kubernetes namespace for kubectl
+	SSH       string `yaml:"ssh,omitempty"`        // Destination for `ssh -L`, e.g. user@bastion
+	Host      string `yaml:"host,omitempty"`       // Remote host reached through ssh, defaults to localhost
+	Socat     string `yaml:"socat,omitempty"`      // host:port socat forwards to
+	Port      int    `yaml:"port,omitempty"`       // Remote port for kubectl and ssh
+	LocalPort int    `yaml:"local_port,omitempty"` // Local port, a free port is picked when unset
+	As        string `yaml:"as,omitempty"`         // Variable holding the local port, defaults to port_forward
+}
+
+// Variable returns the name of the variable holding the local port.
+func (p *PortForward) Variable() string {
+	if p.As != "" {
+		return p.As
+	}
+	return "port_forward"
+}
+
+// Target returns a readable description of the forwarded service.
+func (p *PortForward) Target() string {
+	switch {
+	case p.Kubectl != "":
+		return p.Kubectl + ":" + strconv.Itoa(p.Port)
+	case p.SSH != "":
+		host := p.Host
+		if host == "" {
+			host = "localhost"
+		}
+		return fmt.Sprintf("%s:%d via %s", host, p.Port, p.SSH)
+	}
+	return p.Socat
+}
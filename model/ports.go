@@ -0,0 +1,5 @@
+package model
+
+// Ports maps port names to a port number, or "auto" for a free port
+// allocated when the job starts.
+type Ports map[string]string
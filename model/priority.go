@@ -0,0 +1,30 @@
+package model
+
+import (
+	"strconv"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Priority lowers the scheduling priority of the commands of a step, so
+// heavy steps don't slow down the rest of the machine.
+type Priority struct {
+	Nice   int    `yaml:"nice,omitempty"`   // Nice level, from -20 (highest) to 19 (lowest)
+	IONice string `yaml:"ionice,omitempty"` // I/O class: realtime, best-effort or idle, with an optional level, e.g. best-effort:7
+	CPUSet string `yaml:"cpuset,omitempty"` // CPUs the commands may run on, e.g. 0-3,6
+}
+
+// UnmarshalYAML supports a nice level as a scalar.
+func (p *Priority) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		nice, err := strconv.Atoi(node.Value)
+		if err != nil {
+			return &yaml.TypeError{Errors: []string{"priority: expected a nice level or a map"}}
+		}
+		p.Nice = nice
+		return nil
+	}
+
+	type rawPriority Priority
+	return node.Decode((*rawPriority)(p))
+}
@@ -0,0 +1,30 @@
+package model
+
+import (
+	"strconv"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Ready describes when a detached step is ready, so the following steps
+// can run while it keeps running in the background.
+type Ready struct {
+	Log     string `yaml:"log,omitempty"`     // Regular expression matched against the step output
+	Port    int    `yaml:"port,omitempty"`    // Local TCP port that accepts connections when ready
+	Timeout string `yaml:"timeout,omitempty"` // How long to wait for readiness, e.g. "30s"
+}
+
+// UnmarshalYAML supports a port number or a log pattern as a scalar.
+func (r *Ready) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		if port, err := strconv.Atoi(node.Value); err == nil {
+			r.Port = port
+			return nil
+		}
+		r.Log = node.Value
+		return nil
+	}
+
+	type rawReady Ready
+	return node.Decode((*rawReady)(r))
+}
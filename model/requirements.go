@@ -0,0 +1,58 @@
+package model
+
+import (
+	"fmt"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Requirements declares what must be available before a job or step runs.
+//
+// The short form is a list of variable names (`requires: [component]`),
+// the long form is a map (`requires: {vars: [...], env: [...], commands: [...]}`).
+type Requirements struct {
+	Vars     []string `yaml:"vars,omitempty"`
+	Env      []string `yaml:"env,omitempty"`
+	Commands []string `yaml:"commands,omitempty"`
+}
+
+// PipelineRequirements declares what a pipeline needs to load, e.g.
+// `requires: {atkins: ">=0.9"}`.
+type PipelineRequirements struct {
+	Atkins string `yaml:"atkins,omitempty"` // Version constraint of atkins, e.g. ">=0.9" or "1.x"
+}
+
+// IsEmpty returns true if nothing is required.
+func (r Requirements) IsEmpty() bool {
+	return len(r.Vars) == 0 && len(r.Env) == 0 && len(r.Commands) == 0
+}
+
+// UnmarshalYAML implements custom unmarshalling for `requires`,
+// taking a string, a list of variable names, or a map.
+func (r *Requirements) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		*r = Requirements{Vars: []string{node.Value}}
+		return nil
+
+	case yaml.SequenceNode:
+		var vars []string
+		if err := node.Decode(&vars); err != nil {
+			return err
+		}
+		*r = Requirements{Vars: vars}
+		return nil
+
+	case yaml.MappingNode:
+		type plain Requirements
+		var req plain
+		if err := node.Decode(&req); err != nil {
+			return err
+		}
+		*r = Requirements(req)
+		return nil
+
+	default:
+		return fmt.Errorf("invalid requires format: expected string, list or map, got %v", node.Kind)
+	}
+}
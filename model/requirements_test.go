@@ -0,0 +1,41 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v3"
+)
+
+func TestRequirements_UnmarshalYAML(t *testing.T) {
+	t.Run("list of vars", func(t *testing.T) {
+		var job Job
+		require.NoError(t, yaml.Unmarshal([]byte("requires: [component, version]"), &job))
+		assert.Equal(t, []string{"component", "version"}, job.Requires.Vars)
+		assert.Empty(t, job.Requires.Env)
+	})
+
+	t.Run("single var", func(t *testing.T) {
+		var job Job
+		require.NoError(t, yaml.Unmarshal([]byte("requires: component"), &job))
+		assert.Equal(t, []string{"component"}, job.Requires.Vars)
+	})
+
+	t.Run("map form", func(t *testing.T) {
+		input := `
+requires:
+  vars: [component]
+  env: [TOKEN]
+  commands: [docker]
+`
+		var step Step
+		require.NoError(t, yaml.Unmarshal([]byte(input), &step))
+		assert.Equal(t, Requirements{
+			Vars:     []string{"component"},
+			Env:      []string{"TOKEN"},
+			Commands: []string{"docker"},
+		}, step.Requires)
+		assert.False(t, step.Requires.IsEmpty())
+	})
+}
@@ -0,0 +1,8 @@
+package model
+
+// ScrubRule replaces the matches of a pattern in the captured output and
+// the logs of a run, e.g. tokens or absolute home paths.
+type ScrubRule struct {
+	Match   string `yaml:"match"`             // Regular expression, the replacement can refer to its groups as ${1}
+	Replace string `yaml:"replace,omitempty"` // Replacement, "***" by default
+}
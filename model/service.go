@@ -0,0 +1,33 @@
+package model
+
+import (
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Service is a background process shared by the jobs of a run. It is
+// started when the first job using it runs, and stopped after the last.
+type Service struct {
+	Run   string `yaml:"run,omitempty"`   // Command running the service
+	Dir   string `yaml:"dir,omitempty"`   // Working directory, relative to the pipeline dir
+	Ready *Ready `yaml:"ready,omitempty"` // When the service is ready for use
+}
+
+// Services maps service names to services.
+type Services map[string]*Service
+
+// UnmarshalYAML supports the service command as a scalar.
+func (s *Service) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		s.Run = strings.TrimSpace(node.Value)
+		return nil
+	}
+
+	type rawService Service
+	if err := node.Decode((*rawService)(s)); err != nil {
+		return err
+	}
+	s.Run = strings.TrimSpace(s.Run)
+	return nil
+}
@@ -11,24 +11,42 @@ import (
 type Step struct {
 	*Decl
 
-	Name        string       `yaml:"name,omitempty"`
-	Desc        string       `yaml:"desc,omitempty"`
-	Dir         string       `yaml:"dir,omitempty"`
-	Run         string       `yaml:"run,omitempty"`
-	Cmd         string       `yaml:"cmd,omitempty"`
-	Cmds        []string     `yaml:"cmds,omitempty"`
-	Task        string       `yaml:"task,omitempty"` // Task/job name to invoke
-	If          Conditionals `yaml:"if,omitempty"`
-	For         Iterators    `yaml:"for,omitempty"`
-	Detach      bool         `yaml:"detach,omitempty"`
-	Deferred    bool         `yaml:"deferred,omitempty"`
-	Verbose     bool         `yaml:"verbose,omitempty"`
-	Summarize   bool         `yaml:"summarize,omitempty"`
-	Quiet       bool         `yaml:"quiet,omitempty"`
-	Passthru    bool         `yaml:"passthru,omitempty"`    // If true, output is printed with tree indentation
-	TTY         bool         `yaml:"tty,omitempty"`         // If true, allocate a PTY for the command (enables color output)
-	Interactive bool         `yaml:"interactive,omitempty"` // If true, stream output live and connect stdin for keyboard input
-	HidePrefix  bool         `yaml:"-"`                     // If true, don't show "run:" prefix in display
+	ID              string       `yaml:"id,omitempty"` // Stable step identifier, used instead of the step index in IDs
+	Name            string       `yaml:"name,omitempty"`
+	Desc            string       `yaml:"desc,omitempty"`
+	Dir             string       `yaml:"dir,omitempty"`
+	Run             string       `yaml:"run,omitempty"`
+	Cmd             string       `yaml:"cmd,omitempty"`
+	Cmds            []string     `yaml:"cmds,omitempty"`
+	Argv            []string     `yaml:"argv,omitempty"`         // Command and arguments, each interpolated as a single shell-quoted word
+	Task            string       `yaml:"task,omitempty"`         // Task/job name to invoke
+	With            With         `yaml:"with,omitempty"`         // Inputs passed to the task, validated against its `inputs:`
+	PortForward     *PortForward `yaml:"port_forward,omitempty"` // Forward a local port until the job ends
+	Lint            *Lint        `yaml:"lint,omitempty"`         // Run formatters and linters, reporting findings by file
+	If              Conditionals `yaml:"if,omitempty"`
+	For             Iterators    `yaml:"for,omitempty"`
+	Detach          bool         `yaml:"detach,omitempty"`
+	Ready           *Ready       `yaml:"ready,omitempty"` // For detached steps, continue once ready and fail the job if the step exits
+	Deferred        bool         `yaml:"deferred,omitempty"`
+	Verbose         bool         `yaml:"verbose,omitempty"`
+	Summarize       bool         `yaml:"summarize,omitempty"`
+	Quiet           bool         `yaml:"quiet,omitempty"`
+	Passthru        bool         `yaml:"passthru,omitempty"`          // If true, output is printed with tree indentation
+	TTY             TTYMode      `yaml:"tty,omitempty"`               // "always" allocates a PTY for the command (enables color output), "never" runs without
+	Interactive     bool         `yaml:"interactive,omitempty"`       // If true, stream output live and connect stdin for keyboard input
+	Requires        Requirements `yaml:"requires,omitempty"`          // Variables, env and commands required, checked before the job runs
+	Trace           bool         `yaml:"trace,omitempty"`             // If true, commands executed by the script are recorded in the event log
+	Lenient         bool         `yaml:"lenient,omitempty"`           // If true, failed ${{ }} interpolations are left in place instead of failing
+	Breakpoint      bool         `yaml:"breakpoint,omitempty"`        // If true, pause before the step and ask to run, skip or abort
+	Priority        *Priority    `yaml:"priority,omitempty"`          // Nice level, I/O class and CPUs of the step commands
+	Network         string       `yaml:"network,omitempty"`           // "none" runs the commands without network access, "host" (default) with
+	Lock            *Lock        `yaml:"lock,omitempty"`              // Advisory lock held while the step runs
+	CheckClean      bool         `yaml:"check_clean,omitempty"`       // If true, fail the step when it changes the git working tree
+	Budget          string       `yaml:"budget,omitempty"`            // Expected duration, e.g. "30s", longer runs are flagged in the summary
+	AcceptExitCodes []int        `yaml:"accept_exit_codes,omitempty"` // Exit codes passing the step besides 0, e.g. [1] for grep without matches
+	SkipExitCodes   []int        `yaml:"skip_exit_codes,omitempty"`   // Exit codes marking the step skipped instead of failed
+	HidePrefix      bool         `yaml:"-"`                           // If true, don't show "run:" prefix in display
+	Attached        bool         `yaml:"-"`                           // If true, connect the command to the standard streams, e.g. for `atkins x`
 }
 
 // String returns a string representation of the step.
@@ -36,6 +54,10 @@ func (s *Step) String() string {
 	switch {
 	case s.Task != "":
 		return "task: " + s.Task
+	case s.PortForward != nil:
+		return "port_forward: " + s.PortForward.Target()
+	case s.Lint != nil:
+		return "lint: " + s.Lint.String()
 	case s.Run != "":
 		// If Run contains newlines, display as <script> instead of full command
 		if strings.Contains(s.Run, "\n") {
@@ -46,6 +68,8 @@ func (s *Step) String() string {
 		return "cmd: " + s.Cmd
 	case len(s.Cmds) > 0:
 		return fmt.Sprintf("cmds: <%d commands>", len(s.Cmds))
+	case len(s.Argv) > 0:
+		return "argv: " + strings.Join(s.Argv, " ")
 	}
 	return s.Name
 }
@@ -59,6 +83,10 @@ func (s *Step) DisplayLabel() string {
 	switch {
 	case s.Task != "":
 		return "task: " + s.Task
+	case s.PortForward != nil:
+		return "port_forward: " + s.PortForward.Target()
+	case s.Lint != nil:
+		return "lint: " + s.Lint.String()
 	case s.Run != "":
 		// If Run contains newlines, display as <script> instead of full command
 		if strings.Contains(s.Run, "\n") {
@@ -69,6 +97,8 @@ func (s *Step) DisplayLabel() string {
 		return "cmd: " + s.Cmd
 	case len(s.Cmds) > 0:
 		return fmt.Sprintf("cmds: <%d commands>", len(s.Cmds))
+	case len(s.Argv) > 0:
+		return "argv: " + strings.Join(s.Argv, " ")
 	}
 	return s.Name
 }
@@ -84,6 +114,18 @@ func (s *Step) Label(showPrefix bool) *Label {
 			Type:       "task",
 			ShowPrefix: showPrefix && !s.HidePrefix,
 		}
+	case s.PortForward != nil:
+		return &Label{
+			Text:       s.PortForward.Target(),
+			Type:       "port_forward",
+			ShowPrefix: showPrefix && !s.HidePrefix,
+		}
+	case s.Lint != nil:
+		return &Label{
+			Text:       s.Lint.String(),
+			Type:       "lint",
+			ShowPrefix: showPrefix && !s.HidePrefix,
+		}
 	case s.Run != "":
 		text := s.Run
 		if strings.Contains(text, "\n") {
@@ -106,6 +148,12 @@ func (s *Step) Label(showPrefix bool) *Label {
 			Type:       "cmds",
 			ShowPrefix: showPrefix && !s.HidePrefix,
 		}
+	case len(s.Argv) > 0:
+		return &Label{
+			Text:       strings.Join(s.Argv, " "),
+			Type:       "argv",
+			ShowPrefix: showPrefix && !s.HidePrefix,
+		}
 	}
 	return &Label{
 		Text:       s.Name,
@@ -117,11 +165,16 @@ func (s *Step) Label(showPrefix bool) *Label {
 // Commands returns all executable commands from this step as a slice.
 // For steps with a single command (Run/Cmd), returns a slice with one element.
 // For steps with multiple commands (Cmds), returns the full slice.
-// Returns an empty slice for Task steps.
+// An Argv step returns its arguments joined with spaces, the runner
+// interpolates and quotes them separately.
+// Returns an empty slice for Task and Lint steps.
 func (s *Step) Commands() []string {
 	if len(s.Cmds) > 0 {
 		return s.Cmds
 	}
+	if len(s.Argv) > 0 {
+		return []string{strings.Join(s.Argv, " ")}
+	}
 	if s.Run != "" {
 		return []string{s.Run}
 	}
@@ -0,0 +1,33 @@
+package model
+
+import yaml "gopkg.in/yaml.v3"
+
+// Tool describes an external tool that a pipeline or skill depends on.
+//
+// The short form pins a version (`go: 1.22.x`), the long form also
+// carries install hints.
+type Tool struct {
+	Version string `yaml:"version,omitempty"` // Version constraint, e.g. "1.22.x", "20", ">=1.21"
+	Hint    string `yaml:"hint,omitempty"`    // Install instructions shown when the tool is missing
+	Install string `yaml:"install,omitempty"` // Job that installs the tool
+}
+
+// UnmarshalYAML implements custom unmarshalling for Tool,
+// taking a version string or a map.
+func (t *Tool) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*t = Tool{Version: node.Value}
+		return nil
+	}
+
+	type plain Tool
+	var tool plain
+	if err := node.Decode(&tool); err != nil {
+		return err
+	}
+	*t = Tool(tool)
+	return nil
+}
+
+// Tools maps tool (executable) names to their declarations.
+type Tools map[string]*Tool
@@ -0,0 +1,27 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v3"
+)
+
+func TestTools_UnmarshalYAML(t *testing.T) {
+	input := `
+tools:
+  go: 1.22.x
+  node: 20
+  golangci-lint:
+    version: ">=1.55"
+    hint: go install github.com/golangci/golangci-lint/cmd/golangci-lint@latest
+`
+	var pipeline Pipeline
+	require.NoError(t, yaml.Unmarshal([]byte(input), &pipeline))
+
+	assert.Equal(t, "1.22.x", pipeline.Tools["go"].Version)
+	assert.Equal(t, "20", pipeline.Tools["node"].Version)
+	assert.Equal(t, ">=1.55", pipeline.Tools["golangci-lint"].Version)
+	assert.NotEmpty(t, pipeline.Tools["golangci-lint"].Hint)
+}
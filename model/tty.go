@@ -0,0 +1,51 @@
+package model
+
+import (
+	"fmt"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// TTYMode controls whether a command runs with a PTY.
+type TTYMode string
+
+const (
+	// TTYAuto allocates a PTY when the job asks for one, the default.
+	TTYAuto TTYMode = ""
+	// TTYAlways allocates a PTY, for tools that only colorize or show
+	// progress on a terminal.
+	TTYAlways TTYMode = "always"
+	// TTYNever runs without a PTY, for tools that emit control sequences
+	// breaking the captured output.
+	TTYNever TTYMode = "never"
+)
+
+// UnmarshalYAML implements custom unmarshalling for `tty`, taking
+// auto, always or never, or a bool where true is always.
+func (m *TTYMode) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!bool" {
+		var enabled bool
+		if err := node.Decode(&enabled); err != nil {
+			return err
+		}
+		*m = TTYAuto
+		if enabled {
+			*m = TTYAlways
+		}
+		return nil
+	}
+
+	var value string
+	if err := node.Decode(&value); err != nil {
+		return err
+	}
+	switch TTYMode(value) {
+	case "auto", TTYAuto:
+		*m = TTYAuto
+	case TTYAlways, TTYNever:
+		*m = TTYMode(value)
+	default:
+		return fmt.Errorf("invalid tty %q, expected auto, always or never", value)
+	}
+	return nil
+}
@@ -1,23 +1,60 @@
 package main
 
-import "github.com/titpetric/cli"
+import (
+	"cmp"
+	"os"
+
+	"github.com/titpetric/cli"
+
+	"github.com/titpetric/atkins/runner"
+)
 
 // Options holds pipeline command-line arguments
 type Options struct {
 	File             string
+	Project          string
 	Jobs             []string
 	List             bool
+	All              bool
 	Lint             bool
 	Debug            bool
 	LogFile          string
+	CaptureDir       string
+	MetricsFile      string
 	FinalOnly        bool
+	Plain            bool
+	Timestamps       bool
+	Theme            string
+	Spinner          string
+	Labels           string
 	WorkingDirectory string
 	Jail             bool
 	JSON             bool
 	YAML             bool
+	Format           string
 	Version          bool
 	Agent            bool
 	Exec             string
+	OnFailure        string
+	Step             bool
+	ProgressFD       int
+	Policy           string
+	AuditLog         string
+	Cache            string
+	CacheMode        string
+	Filter           string
+	RunID            string
+	EnforceBudgets   bool
+	Gantt            string
+	IssueAfter       int
+	Recursive        bool
+	Ref              string
+	Resume           bool
+	InstallTools     bool
+
+	// BundleDir is the extracted bundle of atkins bundle run, the
+	// pipeline and skills are loaded from it.
+	BundleDir string
 
 	FlagSet *cli.FlagSet
 }
@@ -27,16 +64,42 @@ func NewOptions() *Options {
 }
 
 func (o *Options) Bind(fs *cli.FlagSet) {
-	fs.StringVarP(&o.File, "file", "f", "", "Path to pipeline file (auto-discovers .atkins.yml)")
+	fs.StringVarP(&o.File, "file", "f", "", "Path or URL of pipeline file (auto-discovers .atkins.yml)")
+	fs.StringVarP(&o.Project, "project", "p", "", "Run the pipeline of a project from .atkins/projects.yml")
 	fs.BoolVarP(&o.List, "list", "l", false, "List pipeline jobs and dependencies")
 	fs.BoolVar(&o.Lint, "lint", false, "Lint pipeline for errors")
+	fs.BoolVar(&o.All, "all", false, "Run every root-level job in declaration order")
+	fs.BoolVar(&o.Recursive, "recursive", false, "Run the jobs in every directory with a config file or go.mod below the current one")
 	fs.BoolVar(&o.Debug, "debug", false, "Print debug data")
 	fs.StringVar(&o.LogFile, "log", "", "Log file path for command execution")
+	fs.StringVar(&o.Gantt, "gantt", "", "Write a mermaid gantt chart of the run to a markdown file")
+	fs.StringVar(&o.MetricsFile, "metrics-textfile", "", "Write run metrics to a node_exporter textfile")
+	fs.StringVar(&o.RunID, "run-id", "", "Run ID used in logs, capture dirs and the run index, e.g. the CI build number")
+	fs.StringVar(&o.CaptureDir, "capture-dir", "", "Write full step output to <dir>/<run-id>/<step-id>.log")
 	fs.BoolVar(&o.FinalOnly, "final", false, "Only render final output without redrawing (no interactive tree)")
+	fs.BoolVar(&o.Plain, "plain", false, "Print one progress line per state transition instead of the interactive tree (default without a terminal)")
+	fs.IntVar(&o.ProgressFD, "progress-fd", 0, "Write JSON progress records to this file descriptor")
+	fs.BoolVar(&o.InstallTools, "install-tools", false, "Install pinned tools that don't match into .atkins/toolcache with their install job")
+	fs.BoolVar(&o.EnforceBudgets, "enforce-budgets", false, "Fail steps that take longer than their budget")
+	fs.IntVar(&o.IssueAfter, "issue-after", 0, "File a GitHub issue after this many consecutive failed runs (needs --log), closed once they pass")
+	fs.StringVar(&o.Filter, "filter", "", "Only show nodes of the final tree that are failed, skipped or match a regular expression")
+	fs.BoolVar(&o.Timestamps, "timestamps", false, "Prefix plain progress lines with timestamps")
+	fs.StringVar(&o.Theme, "theme", os.Getenv("ATKINS_THEME"), "Tree theme: unicode (default), ascii, or a theme file")
+	fs.StringVar(&o.Spinner, "spinner", os.Getenv("ATKINS_SPINNER"), "Spinner style for running steps: none (default), dots, line, braille")
+	fs.StringVar(&o.Labels, "labels", os.Getenv("ATKINS_LABELS"), "Status and message wording: a locale (de, en, es, fr) or a labels file")
 	fs.StringVarP(&o.WorkingDirectory, "working-directory", "w", "", "Change to this directory before running")
+	fs.StringVar(&o.Ref, "ref", "", "Run in a temporary git worktree of this ref, e.g. a tag or a commit")
+	fs.BoolVar(&o.Resume, "resume", false, "Skip the jobs and steps completed by the last run that didn't pass, from its checkpoint")
+	fs.StringVar(&o.OnFailure, "on-failure", "", "Action when a step fails in a terminal: shell")
+	fs.BoolVar(&o.Step, "step", false, "Pause before each step to run, skip or abort it")
+	fs.StringVar(&o.Policy, "policy", "", "Command policy mode: strict denies commands without an allow rule")
+	fs.StringVar(&o.AuditLog, "audit-log", os.Getenv("ATKINS_AUDIT_LOG"), "Audit log of executed commands, off to disable (default $HOME/.atkins/audit.log, off with --jail)")
+	fs.StringVar(&o.Cache, "cache", os.Getenv("ATKINS_CACHE"), "Remote job cache shared between machines: s3://, gs://, http(s):// or a directory")
+	fs.StringVar(&o.CacheMode, "cache-mode", cmp.Or(os.Getenv("ATKINS_CACHE_MODE"), runner.CacheModeReadWrite), "Remote job cache mode: read or read-write")
 	fs.BoolVar(&o.Jail, "jail", false, "Restrict to project scope, skip global resources from $HOME")
 	fs.BoolVarP(&o.JSON, "json", "j", false, "Output in JSON format")
 	fs.BoolVarP(&o.YAML, "yaml", "y", false, "Output in YAML format")
+	fs.StringVar(&o.Format, "format", "", "Output format for --list: vscode-tasks")
 	fs.BoolVarP(&o.Version, "version", "v", false, "Print version and build information")
 	fs.BoolVar(&o.Agent, "agent", false, "Start interactive agent REPL")
 	fs.StringVarP(&o.Exec, "exec", "x", "", "Run a prompt non-interactively and exit")
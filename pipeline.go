@@ -11,22 +11,172 @@ import (
 
 	"github.com/spf13/pflag"
 	"github.com/titpetric/cli"
+	"golang.org/x/term"
 
 	"github.com/titpetric/atkins/agent"
 	"github.com/titpetric/atkins/colors"
+	"github.com/titpetric/atkins/eventlog"
 	"github.com/titpetric/atkins/model"
 	"github.com/titpetric/atkins/runner"
+	"github.com/titpetric/atkins/treeview"
 	"github.com/titpetric/atkins/version"
 )
 
 // loadSkillPipelines loads skill pipelines from the project-local .atkins/skills/ directory.
 // workspaceDir is the folder containing .atkins/ (used as Dir for skills without when:).
 // startDir is where to start searching for when: files (typically user's cwd).
-func loadSkillPipelines(workspaceDir string, startDir string, opts *Options) ([]*model.Pipeline, error) {
+// Skills skipped because a skill with the same ID has precedence are returned as shadowed.
+func loadSkillPipelines(workspaceDir string, startDir string, opts *Options) (pipelines, shadowed []*model.Pipeline, err error) {
 	loader := runner.NewSkillsLoader(workspaceDir, startDir)
+	loader.Markers = loadMarkers(workspaceDir, opts)
+	if opts == nil || !opts.Jail {
+		if dir, err := globalSkillsDir(); err == nil {
+			loader.ExtendsDirs = []string{dir}
+		}
+	}
+	pipelines, err = loader.Load()
+	return pipelines, loader.Shadowed, err
+}
+
+// loadMarkers loads the markers mapping project files to skills, from
+// .atkins/markers.yml in workspaceDir and $HOME/.atkins/markers.yml.
+func loadMarkers(workspaceDir string, opts *Options) []runner.Marker {
+	paths := []string{filepath.Join(workspaceDir, ".atkins", runner.MarkersFile)}
+	if opts == nil || !opts.Jail {
+		if dir, err := globalSkillsDir(); err == nil {
+			paths = append(paths, filepath.Join(filepath.Dir(dir), runner.MarkersFile))
+		}
+	}
+	markers, err := runner.LoadMarkers(paths...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s %v\n", treeview.WarningHeader(), err)
+	}
+	return markers
+}
+
+// discoveryMarkers returns the markers identifying a project root besides
+// the built-in ones: the global markers and the `detect:` markers of the
+// global and embedded skills.
+func discoveryMarkers(opts *Options) []runner.Marker {
+	loader := runner.NewSkillsLoader("", "")
+	loader.SkillsDirs = nil
+	loader.Markers = loadMarkers("", opts)
+	if !opts.Jail {
+		if dir, err := globalSkillsDir(); err == nil {
+			loader.SkillsDirs = []string{dir}
+		}
+	}
+	markers, err := loader.DetectMarkers()
+	if err != nil {
+		return loader.Markers
+	}
+	return markers
+}
+
+// globalSkillsDir returns the $HOME/.atkins/skills/ directory.
+func globalSkillsDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".atkins", "skills"), nil
+}
+
+// fetchRemotePipeline fetches a remote pipeline into the cache in $HOME
+// and returns the path of the cached file.
+func fetchRemotePipeline(ctx context.Context, ref string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return runner.NewPipelineCache(filepath.Join(home, runner.PipelineCacheDir)).Fetch(ctx, ref)
+}
+
+// loadPolicy loads the command policy of the project and of $HOME. With
+// --policy strict, only the policy in $HOME is used, as the project may
+// come from an untrusted source.
+func loadPolicy(configDir string, opts *Options) (*runner.Policy, error) {
+	strict := opts.Policy == runner.PolicyStrict
+	if configDir == "" {
+		configDir, _ = os.Getwd()
+	}
+	var paths []string
+	if !strict && configDir != "" {
+		paths = append(paths, filepath.Join(configDir, runner.PolicyFile))
+	}
+	if !opts.Jail {
+		if home, err := os.UserHomeDir(); err == nil {
+			paths = append(paths, filepath.Join(home, runner.PolicyFile))
+		}
+	}
+	return runner.LoadPolicy(strict, paths...)
+}
+
+// openAuditLog returns the audit log receiving the executed commands,
+// or nil when it's turned off. The default in $HOME is off with --jail.
+func openAuditLog(opts *Options) (*eventlog.AuditLog, error) {
+	path := opts.AuditLog
+	switch path {
+	case eventlog.AuditLogOff:
+		return nil, nil
+	case "":
+		if opts.Jail {
+			return nil, nil
+		}
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("audit log: %w", err)
+		}
+		path = filepath.Join(home, eventlog.AuditLogPath)
+	}
+	return eventlog.NewAuditLog(path), nil
+}
+
+// openJobCache returns the job cache in $HOME, sharing results through
+// the remote cache when one is configured.
+func openJobCache(opts *Options) (*runner.JobCache, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("job cache: %w", err)
+	}
+	return runner.NewJobCache(filepath.Join(home, runner.JobCacheDir), opts.Cache, opts.CacheMode)
+}
+
+// loadGlobalSkills loads skill pipelines from $HOME/.atkins/skills/.
+// startDir is where to start searching for when: files and markers.
+func loadGlobalSkills(startDir string, markers []runner.Marker) ([]*model.Pipeline, error) {
+	dir, err := globalSkillsDir()
+	if err != nil {
+		return nil, err
+	}
+	loader := runner.NewSkillsLoader(startDir, startDir)
+	loader.SkillsDirs = []string{dir}
+	loader.Markers = markers
+	loader.Adapters = false
 	return loader.Load()
 }
 
+// mergeGlobalSkills merges the skills in $HOME/.atkins/skills/ and the
+// embedded skills into pipelines, unless jailed. Skills already loaded
+// take precedence, the global skills they replace are returned as shadowed.
+func mergeGlobalSkills(pipelines []*model.Pipeline, configDir, cwd string, opts *Options) (merged, shadowed []*model.Pipeline) {
+	if opts.Jail {
+		return pipelines, nil
+	}
+	markers := loadMarkers(configDir, opts)
+	if globalPipelines, globalErr := loadGlobalSkills(cwd, markers); globalErr == nil {
+		pipelines, shadowed = runner.MergeSkills(pipelines, globalPipelines)
+	}
+
+	// Embedded skills are replaced by skill files with the same ID.
+	embeddedLoader := runner.NewSkillsLoader(cwd, cwd)
+	embeddedLoader.Markers = markers
+	if embedded, embeddedErr := embeddedLoader.LoadEmbedded(); embeddedErr == nil {
+		pipelines, _ = runner.MergeSkills(pipelines, embedded)
+	}
+	return pipelines, shadowed
+}
+
 // stdinHasData checks if stdin has data available without blocking.
 // Returns true if stdin is piped/redirected with data available.
 func stdinHasData() bool {
@@ -78,12 +228,7 @@ func Pipeline() *cli.Command {
 func runPipeline(ctx context.Context, opts *Options, args []string) error {
 	// Handle version flag early, before any file discovery
 	if opts.Version {
-		return version.Run(version.Info{
-			Version:    Version,
-			Commit:     Commit,
-			CommitTime: CommitTime,
-			Branch:     Branch,
-		})
+		return version.Run(buildInfo())
 	}
 
 	// Handle agent mode
@@ -98,7 +243,69 @@ func runPipeline(ctx context.Context, opts *Options, args []string) error {
 
 	// Validate mutually exclusive flags
 	if opts.JSON && opts.YAML {
-		return fmt.Errorf("%s --json and --yaml flags cannot be combined", colors.BrightRed("ERROR:"))
+		return fmt.Errorf("%s --json and --yaml flags cannot be combined", treeview.ErrorHeader())
+	}
+
+	if opts.Format != "" && opts.Format != runner.ListFormatVSCodeTasks {
+		return fmt.Errorf("%s unknown --format %q, expected %q", treeview.ErrorHeader(), opts.Format, runner.ListFormatVSCodeTasks)
+	}
+
+	if opts.Policy != "" && opts.Policy != runner.PolicyStrict {
+		return fmt.Errorf("%s unknown --policy %q, expected %q", treeview.ErrorHeader(), opts.Policy, runner.PolicyStrict)
+	}
+
+	if opts.OnFailure != "" && opts.OnFailure != runner.OnFailureShell {
+		return fmt.Errorf("%s unknown --on-failure %q, expected %q", treeview.ErrorHeader(), opts.OnFailure, runner.OnFailureShell)
+	}
+
+	if opts.IssueAfter > 0 && opts.LogFile == "" {
+		return fmt.Errorf("%s --issue-after needs --log, failed runs are counted in the run index", treeview.ErrorHeader())
+	}
+
+	if opts.Resume && (opts.Recursive || opts.Ref != "") {
+		return fmt.Errorf("%s --resume can't be combined with --recursive or --ref, checkpoints are kept in the project", treeview.ErrorHeader())
+	}
+
+	if opts.RunID != "" {
+		if err := eventlog.ValidateRunID(opts.RunID); err != nil {
+			return fmt.Errorf("%s --run-id: %v", treeview.ErrorHeader(), err)
+		}
+	}
+
+	var filter *treeview.Filter
+	if opts.Filter != "" {
+		f, err := treeview.ParseFilter(opts.Filter)
+		if err != nil {
+			return fmt.Errorf("%s --filter: %v", treeview.ErrorHeader(), err)
+		}
+		filter = f
+	}
+
+	var progressFile io.Writer
+	if opts.ProgressFD > 0 {
+		f := os.NewFile(uintptr(opts.ProgressFD), "progress")
+		if _, err := f.Stat(); err != nil {
+			return fmt.Errorf("%s --progress-fd %d is not open: %v", treeview.ErrorHeader(), opts.ProgressFD, err)
+		}
+		progressFile = f
+	}
+
+	theme, themeErr := treeview.NewTheme(opts.Theme, opts.Spinner)
+	if themeErr != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), themeErr)
+	}
+	treeview.SetTheme(theme)
+
+	labels, labelsErr := treeview.LoadLabels(opts.Labels)
+	if labelsErr != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), labelsErr)
+	}
+	treeview.SetLabels(labels)
+
+	// Without a terminal the live tree isn't drawn, so progress is printed
+	// as plain lines, unless --plain or --final is set
+	if plainFlag := opts.FlagSet.Lookup("plain"); (plainFlag == nil || !plainFlag.Changed) && !opts.FinalOnly {
+		opts.Plain = !term.IsTerminal(int(os.Stdout.Fd()))
 	}
 
 	fileFlag := opts.FlagSet.Lookup("file")
@@ -117,19 +324,77 @@ func runPipeline(ctx context.Context, opts *Options, args []string) error {
 		opts.Jobs = append(opts.Jobs, arg)
 	}
 
+	// With --ref, run from a detached worktree of the ref, so the working
+	// copy is left alone. The worktree is removed when the run ends.
+	if opts.Ref != "" {
+		if opts.BundleDir != "" {
+			return fmt.Errorf("%s --ref can't be combined with bundle run", treeview.ErrorHeader())
+		}
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+		}
+		workspace, err := runner.NewRefWorkspace(cwd, opts.Ref)
+		if err != nil {
+			return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+		}
+		cleanup := func() {
+			_ = os.Chdir(cwd)
+			if err := workspace.Remove(); err != nil {
+				fmt.Fprintf(os.Stderr, "%s %v\n", treeview.WarningHeader(), err)
+			}
+		}
+		defer cleanup()
+		exitHooks = append(exitHooks, cleanup)
+		if err := os.Chdir(workspace.Dir); err != nil {
+			return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+		}
+	}
+
+	if opts.Recursive {
+		if fileExplicitlySet || opts.Project != "" || opts.List || opts.Lint || opts.JSON || opts.YAML {
+			return fmt.Errorf("%s --recursive can't be combined with --file, --project, --list, --lint, --json or --yaml", treeview.ErrorHeader())
+		}
+		return runRecursive(ctx, opts)
+	}
+
+	// Find the monorepo project registry, and with -p continue from the
+	// project folder so its own config and skills are used.
+	cwd, _ := os.Getwd()
+	projectsRoot, projects, projectsErr := runner.DiscoverProjects(cwd)
+	if projectsErr != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), projectsErr)
+	}
+	if opts.Project != "" {
+		if fileExplicitlySet && !runner.IsRemotePipeline(opts.File) {
+			opts.File, _ = filepath.Abs(opts.File)
+		}
+		if err := selectProject(projects, opts.Project); err != nil {
+			return err
+		}
+	}
+
 	// Save original working directory for global skill when.files checks,
 	// since cwd may change during config/environment discovery.
 	originalCwd, _ := os.Getwd()
 
 	// Check stdin first (before file discovery)
-	var pipelines []*model.Pipeline
+	var pipelines, shadowed []*model.Pipeline
+	var configDir string
 	var err error
 
-	if stdinHasData() {
+	if opts.BundleDir != "" {
+		// Load the pipeline and skills of a bundle, steps run in the working directory
+		pipelines, shadowed, err = loadBundlePipelines(opts.BundleDir, opts.File, originalCwd)
+		if err != nil {
+			return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+		}
+		configDir = opts.BundleDir
+	} else if stdinHasData() {
 		// Read pipeline from stdin
 		pipelines, err = runner.LoadPipelineFromReader(os.Stdin)
 		if err != nil {
-			return fmt.Errorf("%s %s", colors.BrightRed("ERROR:"), err)
+			return fmt.Errorf("%s %s", treeview.ErrorHeader(), err)
 		}
 		// Set default name if not specified
 		if pipelines[0].Name == "" {
@@ -139,13 +404,23 @@ func runPipeline(ctx context.Context, opts *Options, args []string) error {
 	} else {
 		// Discover or resolve pipeline file before changing directory
 		var absPath string
-		var configDir string
 
-		if fileExplicitlySet {
+		if fileExplicitlySet && runner.IsRemotePipeline(opts.File) {
+			// Fetch a pipeline from a URL or git repository into the cache
+			absPath, err = fetchRemotePipeline(ctx, opts.File)
+			var cached *runner.CachedCopyError
+			if errors.As(err, &cached) {
+				fmt.Fprintf(os.Stderr, "%s %v\n", treeview.WarningHeader(), err)
+				err = nil
+			}
+			if err != nil {
+				return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+			}
+		} else if fileExplicitlySet {
 			// If -f/--file was explicitly provided, use it directly
 			absPath, err = filepath.Abs(opts.File)
 			if err != nil {
-				return fmt.Errorf("%s %v", colors.BrightRed("ERROR:"), err)
+				return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
 			}
 		} else {
 			// Discover config file by traversing parent directories
@@ -154,21 +429,22 @@ func runPipeline(ctx context.Context, opts *Options, args []string) error {
 			configPath, configDir, discoverErr = runner.DiscoverConfigFromCwd()
 			if discoverErr != nil && configPath != "" {
 				// No config file found — try environment autodiscovery
-				env, envErr := runner.DiscoverEnvironmentFromCwd()
+				env, envErr := runner.DiscoverEnvironmentFromCwd(discoveryMarkers(opts)...)
 				if envErr != nil {
 					// Neither config nor environment found
-					return fmt.Errorf("%s %v", colors.BrightRed("ERROR:"), discoverErr)
+					return fmt.Errorf("%s %v", treeview.ErrorHeader(), discoverErr)
 				}
 
 				// Change to the discovered project root
+				configDir = env.Root
 				if err := os.Chdir(env.Root); err != nil {
-					return fmt.Errorf("%s failed to change directory to %s: %v", colors.BrightRed("ERROR:"), env.Root, err)
+					return fmt.Errorf("%s failed to change directory to %s: %v", treeview.ErrorHeader(), env.Root, err)
 				}
 
 				// Load and merge skill pipelines
-				pipelines, err = loadSkillPipelines(env.Root, originalCwd, opts)
+				pipelines, shadowed, err = loadSkillPipelines(env.Root, originalCwd, opts)
 				if err != nil {
-					return fmt.Errorf("%s %v", colors.BrightRed("ERROR:"), err)
+					return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
 				}
 				opts.File = "<autodiscovered>"
 				goto pipelineReady
@@ -180,7 +456,7 @@ func runPipeline(ctx context.Context, opts *Options, args []string) error {
 			// For skills-only mode, stay in user's working directory.
 			if configPath != "" {
 				if err := os.Chdir(configDir); err != nil {
-					return fmt.Errorf("%s failed to change directory to %s: %v", colors.BrightRed("ERROR:"), configDir, err)
+					return fmt.Errorf("%s failed to change directory to %s: %v", treeview.ErrorHeader(), configDir, err)
 				}
 			}
 		}
@@ -190,18 +466,20 @@ func runPipeline(ctx context.Context, opts *Options, args []string) error {
 		if absPath != "" {
 			pipelines, err = runner.LoadPipeline(absPath)
 			if err != nil {
-				return fmt.Errorf("%s %s", colors.BrightRed("ERROR:"), err)
+				return fmt.Errorf("%s %s", treeview.ErrorHeader(), err)
 			}
 
 			// Merge autodiscovered skills into the loaded pipeline
-			if skillPipelines, skillErr := loadSkillPipelines(configDir, originalCwd, opts); skillErr == nil {
+			if skillPipelines, skillShadowed, skillErr := loadSkillPipelines(configDir, originalCwd, opts); skillErr == nil {
 				pipelines = append(pipelines, skillPipelines...)
+				shadowed = skillShadowed
 			}
 		} else {
 			// .atkins/ folder detected without config file - load skills as primary pipelines
 			opts.File = ".atkins/"
-			if skillPipelines, skillErr := loadSkillPipelines(configDir, originalCwd, opts); skillErr == nil {
+			if skillPipelines, skillShadowed, skillErr := loadSkillPipelines(configDir, originalCwd, opts); skillErr == nil {
 				pipelines = skillPipelines
+				shadowed = skillShadowed
 			}
 		}
 	}
@@ -210,30 +488,27 @@ pipelineReady:
 
 	// Always merge global skills from $HOME/.atkins/skills/ (unless jailed).
 	// Local .atkins/skills/ takes precedence: skip globals already loaded by ID.
-	if !opts.Jail {
-		if home, err := os.UserHomeDir(); err == nil {
-			globalLoader := runner.NewSkillsLoader(originalCwd, originalCwd)
-			globalLoader.SkillsDirs = []string{filepath.Join(home, ".atkins", "skills")}
-			if globalPipelines, globalErr := globalLoader.Load(); globalErr == nil {
-				seen := make(map[string]bool)
-				for _, p := range pipelines {
-					if p.ID != "" {
-						seen[p.ID] = true
-					}
-				}
-				for _, gp := range globalPipelines {
-					if !seen[gp.ID] {
-						pipelines = append(pipelines, gp)
-					}
-				}
-			}
+	var globalShadowed []*model.Pipeline
+	pipelines, globalShadowed = mergeGlobalSkills(pipelines, configDir, originalCwd, opts)
+	shadowed = append(shadowed, globalShadowed...)
+
+	// Warn about skill IDs and aliases defined more than once.
+	for _, conflict := range runner.SkillConflicts(pipelines, shadowed) {
+		if conflict.Kind != runner.ConflictJob {
+			fmt.Fprintf(os.Stderr, "%s %s\n", treeview.WarningHeader(), conflict)
 		}
 	}
 
+	// Checkpoints are kept in the project, also when -C runs the steps elsewhere
+	checkpointDir, err := filepath.Abs(eventlog.CheckpointDir)
+	if err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+
 	// Handle working directory override (applies to both stdin and file modes)
 	if opts.WorkingDirectory != "" {
 		if err := os.Chdir(opts.WorkingDirectory); err != nil {
-			return fmt.Errorf("%s failed to change directory to %s: %v", colors.BrightRed("ERROR:"), opts.WorkingDirectory, err)
+			return fmt.Errorf("%s failed to change directory to %s: %v", treeview.ErrorHeader(), opts.WorkingDirectory, err)
 		}
 	}
 
@@ -249,6 +524,21 @@ pipelineReady:
 				}
 				return io.EOF
 			}
+			if opts.Lint {
+				if warnings := linter.Unused(); len(warnings) > 0 {
+					fmt.Printf("%s Pipeline '%s' has warnings:\n", colors.BrightYellow("!"), pipeline.Name)
+					for _, warning := range warnings {
+						if warning.Job != "" {
+							fmt.Printf("  %s: %s\n", warning.Job, warning.Detail)
+						} else {
+							fmt.Printf("  %s\n", warning.Detail)
+						}
+						if warning.Hint != "" {
+							fmt.Printf("    %s %s\n", colors.Gray("hint:"), warning.Hint)
+						}
+					}
+				}
+			}
 		}
 		if opts.Lint {
 			if len(pipelines) > 0 {
@@ -273,6 +563,9 @@ pipelineReady:
 			}
 		}
 
+		if opts.Format == runner.ListFormatVSCodeTasks {
+			return runner.ListPipelinesVSCode(pipelines)
+		}
 		if opts.JSON {
 			return runner.ListPipelinesJSON(pipelines)
 		}
@@ -281,10 +574,37 @@ pipelineReady:
 		}
 
 		fmt.Print(runner.ListPipelines(pipelines))
+		if opts.Project == "" && len(opts.Jobs) == 0 {
+			listProjects(projectsRoot, projects, opts)
+		}
 		return nil
 	}
 
-	// When no jobs specified, run the default job from main pipeline
+	policy, err := loadPolicy(configDir, opts)
+	if err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+	auditLog, err := openAuditLog(opts)
+	if err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+	// Bundles run without the job cache in $HOME
+	var jobCache *runner.JobCache
+	if opts.BundleDir == "" {
+		jobCache, err = openJobCache(opts)
+		if err != nil {
+			return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+		}
+	}
+
+	// When no jobs specified, run every root-level job with --all, the
+	// default: jobs of the main pipeline, or its default job
+	if opts.All && len(opts.Jobs) > 0 {
+		return fmt.Errorf("%s --all can't be combined with job names", treeview.ErrorHeader())
+	}
+	if len(opts.Jobs) == 0 && len(pipelines) > 0 {
+		opts.Jobs = defaultTargets(pipelines[0], opts.All)
+	}
 	if len(opts.Jobs) == 0 {
 		opts.Jobs = []string{"default"}
 	}
@@ -323,11 +643,11 @@ pipelineReady:
 					}
 					fmt.Fprintf(os.Stderr, "  - %s\n", colors.BrightOrange(displayName))
 				}
-				os.Exit(1)
+				exit(1)
 			}
-			fmt.Fprintf(os.Stderr, "%s %v\n", colors.BrightRed("ERROR:"), err)
+			fmt.Fprintf(os.Stderr, "%s %v\n", treeview.ErrorHeader(), err)
 			fmt.Fprintf(os.Stderr, "\nUsage: atkins [flags] [job-names...]\n")
-			os.Exit(1)
+			exit(1)
 		}
 
 		pipeline := target.Pipeline
@@ -347,14 +667,33 @@ pipelineReady:
 	for _, pipeline := range pipelineOrder {
 		pj := pipelineJobsMap[pipeline]
 		err := runner.RunPipeline(ctx, pipeline, runner.PipelineOptions{
-			Jobs:         pj.jobs,
-			LogFile:      opts.LogFile,
-			PipelineFile: opts.File,
-			Debug:        opts.Debug,
-			FinalOnly:    opts.FinalOnly,
-			JSON:         opts.JSON,
-			YAML:         opts.YAML,
-			AllPipelines: allPipelines,
+			Jobs:           pj.jobs,
+			LogFile:        opts.LogFile,
+			PipelineFile:   opts.File,
+			Debug:          opts.Debug,
+			FinalOnly:      opts.FinalOnly,
+			Plain:          opts.Plain,
+			Timestamps:     opts.Timestamps,
+			JSON:           opts.JSON,
+			YAML:           opts.YAML,
+			AllPipelines:   allPipelines,
+			Version:        Version,
+			RunID:          opts.RunID,
+			CaptureDir:     opts.CaptureDir,
+			MetricsFile:    opts.MetricsFile,
+			OnFailure:      opts.OnFailure,
+			Step:           opts.Step,
+			ProgressFile:   progressFile,
+			Policy:         policy,
+			AuditLog:       auditLog,
+			Cache:          jobCache,
+			Filter:         filter,
+			EnforceBudgets: opts.EnforceBudgets,
+			GanttFile:      opts.Gantt,
+			IssueAfter:     opts.IssueAfter,
+			CheckpointDir:  checkpointDir,
+			Resume:         opts.Resume,
+			InstallTools:   opts.InstallTools,
 		})
 		if err != nil {
 			exitCode := 1
@@ -362,7 +701,11 @@ pipelineReady:
 
 			var errorLog runner.ExecError
 			if errors.As(err, &errorLog) {
-				if errorLog.Len() > 0 {
+				// The tree shows the failed command with its output; print it
+				// here only when the tree isn't rendered.
+				if !opts.JSON && !opts.YAML {
+					fmt.Fprintf(os.Stderr, "\nAn error occurred in %q pipeline (exit code %d)\n", failedPipeline, errorLog.LastExitCode)
+				} else if errorLog.Len() > 0 {
 					fmt.Fprintf(os.Stderr, "\nAn error occurred in %q pipeline:\n\n", failedPipeline)
 					fmt.Fprintf(os.Stderr, "  Exit code: %d\n", errorLog.LastExitCode)
 					fmt.Fprintf(os.Stderr, "  Error output:\n")
@@ -379,13 +722,24 @@ pipelineReady:
 			}
 
 			if exitCode != 0 {
-				os.Exit(exitCode)
+				exit(exitCode)
 			}
 		}
 	}
 	return nil
 }
 
+// exitHooks run before a failed run exits the process.
+var exitHooks []func()
+
+// exit runs the exit hooks and exits the process with code.
+func exit(code int) {
+	for _, hook := range exitHooks {
+		hook()
+	}
+	os.Exit(code)
+}
+
 // runAgent starts the interactive agent REPL.
 func runAgent(ctx context.Context, opts *Options) error {
 	cwd, err := os.Getwd()
@@ -427,3 +781,22 @@ func runExec(ctx context.Context, opts *Options) error {
 
 	return a.Exec(ctx, opts.Exec, Version)
 }
+
+// defaultTargets returns the jobs to run when none are given: every
+// root-level job with all, otherwise the default: jobs of the pipeline.
+// Jobs of a skill are prefixed with its ID, so they resolve to it.
+func defaultTargets(pipeline *model.Pipeline, all bool) []string {
+	targets := pipeline.Default
+	if all {
+		targets = pipeline.RootJobs()
+	}
+	jobs := pipeline.GetJobs()
+	result := make([]string, 0, len(targets))
+	for _, name := range targets {
+		if _, ok := jobs[name]; ok && pipeline.ID != "" {
+			name = pipeline.ID + ":" + name
+		}
+		result = append(result, name)
+	}
+	return result
+}
@@ -8,6 +8,8 @@ import (
 	"github.com/spf13/pflag"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/atkins/model"
 )
 
 func TestWorkingDirectory_ChangesDirectory(t *testing.T) {
@@ -134,3 +136,23 @@ func TestMultipleJobsArguments(t *testing.T) {
 		assert.Equal(t, []string{"lint", "test", "build"}, opts.Jobs)
 	})
 }
+
+func TestDefaultTargets(t *testing.T) {
+	pipeline := &model.Pipeline{
+		Default:  []string{"lint", "go:test"},
+		JobOrder: []string{"test", "lint", "lint:fix", "default"},
+		Jobs: map[string]*model.Job{
+			"test":     {},
+			"lint":     {},
+			"lint:fix": {},
+			"default":  {},
+		},
+	}
+
+	assert.Equal(t, []string{"lint", "go:test"}, defaultTargets(pipeline, false))
+	assert.Equal(t, []string{"test", "lint"}, defaultTargets(pipeline, true))
+
+	pipeline.ID = "skill"
+	assert.Equal(t, []string{"skill:lint", "go:test"}, defaultTargets(pipeline, false))
+	assert.Equal(t, []string{"skill:test", "skill:lint"}, defaultTargets(pipeline, true))
+}
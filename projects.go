@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/titpetric/atkins/colors"
+	"github.com/titpetric/atkins/model"
+	"github.com/titpetric/atkins/runner"
+	"github.com/titpetric/atkins/treeview"
+)
+
+// selectProject changes to the folder of the project selected with -p,
+// so its own config and skills are discovered.
+func selectProject(projects []*runner.Project, name string) error {
+	project, err := runner.FindProject(projects, name)
+	if err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+	if err := os.Chdir(project.Dir); err != nil {
+		return fmt.Errorf("%s failed to change directory to %s: %v", treeview.ErrorHeader(), project.Dir, err)
+	}
+	return nil
+}
+
+// loadProjectPipelines loads the pipeline and skills of a project folder.
+// A config file above the project folder isn't used.
+func loadProjectPipelines(dir string, opts *Options) ([]*model.Pipeline, error) {
+	configPath, configDir, err := runner.DiscoverConfig(dir)
+	if err == nil && configPath != "" && configDir == dir {
+		pipelines, err := runner.LoadPipeline(configPath)
+		if err != nil {
+			return nil, err
+		}
+		if skills, _, err := loadSkillPipelines(dir, dir, opts); err == nil {
+			pipelines = append(pipelines, skills...)
+		}
+		return pipelines, nil
+	}
+	pipelines, _, err := loadSkillPipelines(dir, dir, opts)
+	return pipelines, err
+}
+
+// listProjects prints the jobs of each project, grouped under the project name.
+func listProjects(root string, projects []*runner.Project, opts *Options) {
+	for _, project := range projects {
+		rel, err := filepath.Rel(root, project.Dir)
+		if err != nil {
+			rel = project.Dir
+		}
+		header := fmt.Sprintf("%s %s", colors.BrightWhite("Project "+project.Name), colors.Gray("("+rel+")"))
+
+		pipelines, err := loadProjectPipelines(project.Dir, opts)
+		if err != nil {
+			fmt.Printf("\n%s\n\n  %s %v\n", header, treeview.ErrorHeader(), err)
+			continue
+		}
+		list := runner.ListPipelines(pipelines)
+		if list == "" {
+			fmt.Printf("\n%s\n\n  %s\n", header, colors.Gray("no jobs"))
+			continue
+		}
+		fmt.Printf("\n%s\n\n%s", header, indent(list, "  "))
+	}
+}
+
+// indent prefixes each non-empty line of s with prefix.
+func indent(s, prefix string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -1,6 +1,7 @@
 package psexec
 
 import (
+	"fmt"
 	"io"
 	"time"
 )
@@ -24,13 +25,40 @@ type Command struct {
 	// Stderr is an optional writer for stderr.
 	// If nil, output is captured in Result.
 	Stderr io.Writer
+	// CombineOutput redirects stderr into the stdout pipe, like 2>&1, so
+	// the output keeps the order it was written in. Stderr is written to
+	// Stdout, and Result.ErrorOutput is empty. A PTY always combines them.
+	CombineOutput bool
 	// Timeout is the maximum duration for the command.
 	// Zero means no timeout.
 	Timeout time.Duration
 	// UsePTY enables pseudo-terminal allocation for the command.
 	UsePTY bool
 	// Interactive enables full interactive mode with stdin/stdout binding.
+	// The PTY follows the size of the controlling terminal.
 	Interactive bool
+	// Attached connects the command to the standard streams of the
+	// process without a PTY, so its input and output can be piped. The
+	// output isn't captured in Result.
+	Attached bool
+	// Resize receives window sizes for the PTY of the command, e.g. the
+	// terminal size of a websocket client. Used with a PTY only.
+	Resize <-chan WindowSize
+	// Size is the initial size of the PTY, the size of the controlling
+	// terminal if nil. Used with a PTY only.
+	Size *WindowSize
+	// KillGroup runs the command in its own process group, and kills
+	// the whole group when the context is cancelled. This stops
+	// processes started by a shell command together with the shell.
+	KillGroup bool
+	// Priority sets the nice level, I/O class and CPUs of the command
+	// once it starts.
+	Priority *Priority
+	// NoNetwork runs the command in a new network namespace, with only
+	// a loopback interface. Linux only, the command fails to start on
+	// other platforms. The executable has to call NoNetworkMain first
+	// thing in main, see NoNetworkHelper.
+	NoNetwork bool
 }
 
 // NewCommand creates a new Command with the given name and arguments.
@@ -41,6 +69,17 @@ func NewCommand(name string, args ...string) *Command {
 	}
 }
 
+// NewCommandf creates a new Command, formatting each argument with
+// fmt.Sprint. Every argument stays a single argument and no shell parses
+// it, so values holding spaces, quotes or `$(...)` can't inject commands.
+func NewCommandf(name string, args ...any) *Command {
+	argv := make([]string, 0, len(args))
+	for _, arg := range args {
+		argv = append(argv, fmt.Sprint(arg))
+	}
+	return NewCommand(name, argv...)
+}
+
 // NewShellCommand creates a new Command that runs via bash.
 func NewShellCommand(script string) *Command {
 	return &Command{
@@ -18,6 +18,24 @@ func TestNewCommand(t *testing.T) {
 	assert.Equal(t, []string{"hello", "world"}, cmd.Args)
 }
 
+func TestNewCommandf(t *testing.T) {
+	cmd := psexec.NewCommandf("git", "checkout", "main; rm -rf /", 3)
+
+	assert.Equal(t, "git", cmd.Name)
+	assert.Equal(t, []string{"checkout", "main; rm -rf /", "3"}, cmd.Args)
+}
+
+func TestQuote(t *testing.T) {
+	assert.Equal(t, "main", psexec.Quote("main"))
+	assert.Equal(t, "./bin/app-1.0", psexec.Quote("./bin/app-1.0"))
+	assert.Equal(t, "''", psexec.Quote(""))
+	assert.Equal(t, "'a b'", psexec.Quote("a b"))
+	assert.Equal(t, `'$(id)'`, psexec.Quote("$(id)"))
+	assert.Equal(t, `'it'\''s'`, psexec.Quote("it's"))
+
+	assert.Equal(t, `git commit -m 'fix: it'\''s done'`, psexec.QuoteArgs([]string{"git", "commit", "-m", "fix: it's done"}))
+}
+
 func TestNewShellCommand(t *testing.T) {
 	cmd := psexec.NewShellCommand("echo $HOME && ls")
 
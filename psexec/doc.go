@@ -85,6 +85,18 @@
 //	// Or use Pipe for bidirectional copy
 //	err = proc.Pipe(stdoutWriter, stdinReader)
 //
+// Wait for the result with a deadline, or select on it:
+//
+//	result, err := proc.WaitContext(ctx)
+//
+//	select {
+//	case result := <-proc.Result():
+//	case msg := <-messages:
+//	}
+//
+// Wait, WaitContext and Result are safe to use concurrently and after
+// Close, which kills the process.
+//
 // # WebSocket Integration
 //
 // The Process type is designed for websocket transport:
@@ -96,6 +108,21 @@
 //	go io.Copy(proc.PTY(), websocketConn)
 //	io.Copy(websocketConn, proc.PTY())
 //
+// With RunWithIO, send the client terminal size from the control channel
+// to Command.Resize, so full-screen programs redraw at the client size:
+//
+//	resize := make(chan psexec.WindowSize)
+//	cmd.Resize = resize
+//	go func() {
+//		for msg := range controlMessages {
+//			resize <- psexec.WindowSize{Rows: msg.Rows, Cols: msg.Cols}
+//		}
+//	}()
+//	result := exec.RunWithIO(ctx, websocketConn, websocketConn, cmd)
+//
+// In interactive mode, the PTY follows the size of the controlling
+// terminal when it's resized.
+//
 // # Result Interface
 //
 // All execution methods return a Result interface:
@@ -108,4 +135,8 @@
 //			result.ExitCode(), result.Err())
 //		fmt.Println("Stderr:", result.ErrorOutput())
 //	}
+//
+// CombinedOutput returns stdout and stderr interleaved in the order the
+// process wrote them, which shows the error next to the output leading
+// up to it.
 package psexec
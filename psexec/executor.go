@@ -58,6 +58,9 @@ func (e *Executor) Run(ctx context.Context, cmd *Command) Result {
 	if cmd.Interactive {
 		return e.runInteractive(ctx, cmd)
 	}
+	if cmd.Attached {
+		return e.runAttached(ctx, cmd)
+	}
 	if cmd.UsePTY {
 		return e.runWithPTY(ctx, cmd)
 	}
@@ -75,9 +78,42 @@ func (e *Executor) prepareCmd(ctx context.Context, cmd *Command) *exec.Cmd {
 	}
 
 	execCmd.Env = e.buildEnv(cmd.Env)
+
+	if cmd.NoNetwork {
+		// Start fails with the error when the network can't be isolated
+		execCmd.Err = isolateNetwork(execCmd)
+	}
+
+	if cmd.KillGroup {
+		// A PTY starts the command in a new session, which is also a new process group
+		if !cmd.UsePTY && !cmd.Interactive {
+			if execCmd.SysProcAttr == nil {
+				execCmd.SysProcAttr = &syscall.SysProcAttr{}
+			}
+			execCmd.SysProcAttr.Setpgid = true
+		}
+		execCmd.Cancel = func() error {
+			return syscall.Kill(-execCmd.Process.Pid, syscall.SIGKILL)
+		}
+		execCmd.WaitDelay = time.Second
+	}
 	return execCmd
 }
 
+// applyPriority sets the priority of a started command. The command is
+// killed when it can't be set, rather than running at full priority.
+func applyPriority(execCmd *exec.Cmd, priority *Priority) error {
+	if priority == nil {
+		return nil
+	}
+	if err := priority.apply(execCmd.Process.Pid); err != nil {
+		_ = execCmd.Process.Kill()
+		_ = execCmd.Wait()
+		return err
+	}
+	return nil
+}
+
 // applyTimeout applies timeout to context if configured.
 func (e *Executor) applyTimeout(ctx context.Context, cmd *Command) (context.Context, context.CancelFunc) {
 	timeout := cmd.Timeout
@@ -90,13 +126,20 @@ func (e *Executor) applyTimeout(ctx context.Context, cmd *Command) (context.Cont
 	return ctx, func() {}
 }
 
-// startPTY starts a command with PTY and sets terminal size.
-func (e *Executor) startPTY(execCmd *exec.Cmd) (*os.File, error) {
+// startPTY starts a command with PTY and sets the terminal size, the
+// size of the controlling terminal unless size is given.
+func (e *Executor) startPTY(execCmd *exec.Cmd, priority *Priority, size *WindowSize) (*os.File, error) {
 	ptmx, err := pty.Start(execCmd)
 	if err != nil {
 		return nil, fmt.Errorf("failed to start PTY: %w", err)
 	}
-	if size := e.terminalSize(); size != nil {
+	if err := applyPriority(execCmd, priority); err != nil {
+		_ = ptmx.Close()
+		return nil, err
+	}
+	if size != nil {
+		_ = pty.Setsize(ptmx, &pty.Winsize{Rows: size.Rows, Cols: size.Cols})
+	} else if size := e.terminalSize(); size != nil {
 		_ = pty.Setsize(ptmx, size)
 	}
 	return ptmx, nil
@@ -116,18 +159,34 @@ func (e *Executor) runStandard(ctx context.Context, cmd *Command) Result {
 	if cmd.Stdin != nil {
 		execCmd.Stdin = cmd.Stdin
 	}
+	result.combined = &combinedBuffer{}
+	stdout := []io.Writer{result.combined, result.stdout}
 	if cmd.Stdout != nil {
-		execCmd.Stdout = io.MultiWriter(cmd.Stdout, result.stdout)
-	} else {
-		execCmd.Stdout = result.stdout
+		stdout = append(stdout, cmd.Stdout)
 	}
-	if cmd.Stderr != nil {
-		execCmd.Stderr = io.MultiWriter(cmd.Stderr, result.stderr)
+	execCmd.Stdout = io.MultiWriter(stdout...)
+	if cmd.CombineOutput {
+		// The same writer shares a single pipe for stdout and stderr
+		execCmd.Stderr = execCmd.Stdout
 	} else {
-		execCmd.Stderr = result.stderr
+		stderr := []io.Writer{result.combined, result.stderr}
+		if cmd.Stderr != nil {
+			stderr = append(stderr, cmd.Stderr)
+		}
+		execCmd.Stderr = io.MultiWriter(stderr...)
 	}
 
-	if err := execCmd.Run(); err != nil {
+	if err := execCmd.Start(); err != nil {
+		result.err = err
+		result.exitCode = 1
+		return result
+	}
+	if err := applyPriority(execCmd, cmd.Priority); err != nil {
+		result.err = err
+		result.exitCode = 1
+		return result
+	}
+	if err := execCmd.Wait(); err != nil {
 		result.err = err
 		result.exitCode = e.extractExitCode(execCmd, err)
 	}
@@ -146,12 +205,13 @@ func (e *Executor) runWithPTY(ctx context.Context, cmd *Command) Result {
 
 	execCmd := e.prepareCmd(ctx, cmd)
 
-	ptmx, err := e.startPTY(execCmd)
+	ptmx, err := e.startPTY(execCmd, cmd.Priority, cmd.Size)
 	if err != nil {
 		result.err = err
 		result.exitCode = 1
 		return result
 	}
+	stopResize := e.syncSize(ptmx, cmd.Resize, false)
 
 	// Copy stdin to PTY if provided — fire and forget since stdin reads block
 	if cmd.Stdin != nil {
@@ -182,6 +242,7 @@ func (e *Executor) runWithPTY(ctx context.Context, cmd *Command) Result {
 	select {
 	case <-ctx.Done():
 		// Context cancelled - close PTY to unblock io.Copy, then wait for command
+		stopResize()
 		_ = ptmx.Close()
 		<-outputDone
 		_ = execCmd.Wait()
@@ -193,6 +254,7 @@ func (e *Executor) runWithPTY(ctx context.Context, cmd *Command) Result {
 			result.err = err
 			result.exitCode = e.extractExitCode(execCmd, err)
 		}
+		stopResize()
 		_ = ptmx.Close()
 	}
 
@@ -208,7 +270,7 @@ func (e *Executor) runInteractive(ctx context.Context, cmd *Command) Result {
 
 	execCmd := e.prepareCmd(ctx, cmd)
 
-	ptmx, err := e.startPTY(execCmd)
+	ptmx, err := e.startPTY(execCmd, cmd.Priority, cmd.Size)
 	if err != nil {
 		result.err = err
 		result.exitCode = 1
@@ -226,6 +288,9 @@ func (e *Executor) runInteractive(ctx context.Context, cmd *Command) Result {
 	}
 	defer func() { _ = term.Restore(int(os.Stdin.Fd()), oldState) }()
 
+	// Resize the PTY with the terminal, so full-screen programs redraw
+	stopResize := e.syncSize(ptmx, cmd.Resize, true)
+
 	// Copy stdin to PTY — fire and forget since os.Stdin.Read() cannot be
 	// interrupted. The goroutine exits when the next read completes and the
 	// subsequent write to the closed ptmx fails.
@@ -250,13 +315,43 @@ func (e *Executor) runInteractive(ctx context.Context, cmd *Command) Result {
 	}
 
 	// Close PTY to unblock the stdout goroutine, then wait for it to drain.
+	stopResize()
 	_ = ptmx.Close()
 	wg.Wait()
 
 	return result
 }
 
+// runAttached executes a command connected to the standard streams of
+// the process, without a PTY and without capturing its output.
+func (e *Executor) runAttached(ctx context.Context, cmd *Command) Result {
+	result := &processResult{}
+	startTime := time.Now()
+	defer func() { result.duration = time.Since(startTime) }()
+
+	execCmd := e.prepareCmd(ctx, cmd)
+	execCmd.Stdin, execCmd.Stdout, execCmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+
+	if err := execCmd.Start(); err != nil {
+		result.err = err
+		result.exitCode = 1
+		return result
+	}
+	if err := applyPriority(execCmd, cmd.Priority); err != nil {
+		result.err = err
+		result.exitCode = 1
+		return result
+	}
+	if err := execCmd.Wait(); err != nil {
+		result.err = err
+		result.exitCode = e.extractExitCode(execCmd, err)
+	}
+	return result
+}
+
 // RunWithIO executes a command with custom I/O streams, suitable for websocket transport.
+// Window sizes sent on cmd.Resize resize the PTY, e.g. from the control messages
+// of a websocket client.
 func (e *Executor) RunWithIO(ctx context.Context, stdout io.Writer, stdin io.Reader, cmd *Command) Result {
 	result := &processResult{stdout: new(bytes.Buffer), stderr: new(bytes.Buffer)}
 	startTime := time.Now()
@@ -264,13 +359,15 @@ func (e *Executor) RunWithIO(ctx context.Context, stdout io.Writer, stdin io.Rea
 
 	execCmd := e.prepareCmd(ctx, cmd)
 
-	ptmx, err := e.startPTY(execCmd)
+	ptmx, err := e.startPTY(execCmd, cmd.Priority, cmd.Size)
 	if err != nil {
 		result.err = err
 		result.exitCode = 1
 		return result
 	}
 
+	stopResize := e.syncSize(ptmx, cmd.Resize, false)
+
 	var wg sync.WaitGroup
 
 	if stdin != nil {
@@ -297,6 +394,7 @@ func (e *Executor) RunWithIO(ctx context.Context, stdout io.Writer, stdin io.Rea
 
 	err = execCmd.Wait()
 	wg.Wait()
+	stopResize()
 	_ = ptmx.Close()
 
 	if err != nil {
@@ -387,7 +485,7 @@ func (e *Executor) terminalSize() *pty.Winsize {
 func (e *Executor) Start(ctx context.Context, cmd *Command) (*Process, error) {
 	execCmd := e.prepareCmd(ctx, cmd)
 
-	ptmx, err := e.startPTY(execCmd)
+	ptmx, err := e.startPTY(execCmd, cmd.Priority, cmd.Size)
 	if err != nil {
 		return nil, err
 	}
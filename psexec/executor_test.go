@@ -118,6 +118,23 @@ func TestExecutor_Run_WithEnv(t *testing.T) {
 	assert.Contains(t, result.Output(), "test_value")
 }
 
+func TestExecutor_Run_CombineOutput(t *testing.T) {
+	exec := psexec.New()
+	ctx := context.Background()
+
+	var stdout bytes.Buffer
+	cmd := psexec.NewShellCommand("echo one; echo two >&2; echo three")
+	cmd.CombineOutput = true
+	cmd.Stdout = &stdout
+	result := exec.Run(ctx, cmd)
+
+	assert.True(t, result.Success())
+	assert.Equal(t, "one\ntwo\nthree\n", result.Output())
+	assert.Equal(t, "one\ntwo\nthree\n", result.CombinedOutput())
+	assert.Equal(t, "one\ntwo\nthree\n", stdout.String())
+	assert.Empty(t, result.ErrorOutput())
+}
+
 func TestExecutor_Run_WithTimeout(t *testing.T) {
 	exec := psexec.New()
 	ctx := context.Background()
@@ -130,6 +147,23 @@ func TestExecutor_Run_WithTimeout(t *testing.T) {
 	assert.NotNil(t, result.Err())
 }
 
+func TestExecutor_Run_KillGroup(t *testing.T) {
+	exec := psexec.New()
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	// The background sleep keeps stdout open unless the group is killed
+	cmd := psexec.NewShellCommand("sleep 10 & echo started; wait")
+	cmd.KillGroup = true
+
+	start := time.Now()
+	result := exec.Run(ctx, cmd)
+
+	assert.False(t, result.Success())
+	assert.Contains(t, result.Output(), "started")
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
 func TestExecutor_Run_ContextCancellation(t *testing.T) {
 	exec := psexec.New()
 	ctx, cancel := context.WithCancel(context.Background())
@@ -172,6 +206,22 @@ func TestExecutor_Run_WithPTY_CombinesStderr(t *testing.T) {
 	assert.Empty(t, result.ErrorOutput())
 }
 
+func TestExecutor_Run_Attached(t *testing.T) {
+	exec := psexec.New()
+	ctx := context.Background()
+
+	out := t.TempDir() + "/out"
+	cmd := psexec.NewShellCommand("test -t 0 || echo attached > " + out + "; exit 3")
+	cmd.Attached = true
+	result := exec.Run(ctx, cmd)
+
+	assert.Equal(t, 3, result.ExitCode())
+	assert.Empty(t, result.Output())
+	data, err := os.ReadFile(out)
+	assert.NoError(t, err)
+	assert.Equal(t, "attached\n", string(data))
+}
+
 func TestExecutor_Run_WithPTY_Timeout(t *testing.T) {
 	exec := psexec.New()
 	ctx := context.Background()
@@ -313,6 +363,22 @@ func TestExecutor_RunWithIO_WithStdin(t *testing.T) {
 	assert.Contains(t, output.String(), "hello")
 }
 
+func TestExecutor_RunWithIO_Resize(t *testing.T) {
+	exec := psexec.New()
+	ctx := context.Background()
+
+	resize := make(chan psexec.WindowSize, 1)
+	resize <- psexec.WindowSize{Rows: 33, Cols: 101}
+
+	var output bytes.Buffer
+	cmd := psexec.NewShellCommand("sleep 0.3; stty size")
+	cmd.Resize = resize
+	result := exec.RunWithIO(ctx, &output, nil, cmd)
+
+	assert.True(t, result.Success())
+	assert.Contains(t, output.String(), "33 101")
+}
+
 func TestExecutor_Interactive_NoTerminal(t *testing.T) {
 	// When stdin is not a terminal, interactive mode should fail gracefully
 	// with exit code 1 and a descriptive error.
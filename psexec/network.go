@@ -0,0 +1,13 @@
+package psexec
+
+// NoNetworkHelper is the hidden argument an executable running commands
+// with NoNetwork is re-run with, to bring up the loopback interface of
+// the new network namespace before it runs the command. The executable
+// hands it to NoNetworkMain, which only runs the helper when called
+// with it:
+//
+//	func main() {
+//		psexec.NoNetworkMain(os.Args)
+//		...
+//	}
+const NoNetworkHelper = "__psexec-no-network"
@@ -0,0 +1,101 @@
+package psexec
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// NoNetworkMain runs the helper when args, usually os.Args, start the
+// executable as NoNetworkHelper, and exits. Otherwise it returns.
+func NoNetworkMain(args []string) {
+	if len(args) < 3 || args[1] != NoNetworkHelper {
+		return
+	}
+	if err := loopbackUp(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to bring up the loopback interface: %v\n", err)
+		os.Exit(126)
+	}
+	// The command doesn't keep the capability to change the network
+	_ = unix.Prctl(unix.PR_CAP_AMBIENT, unix.PR_CAP_AMBIENT_CLEAR_ALL, 0, 0, 0)
+	err := syscall.Exec(args[2], args[2:], os.Environ())
+	fmt.Fprintf(os.Stderr, "failed to run %s: %v\n", args[2], err)
+	os.Exit(127)
+}
+
+// isolateNetwork runs the command through the NoNetworkHelper of this
+// executable in new network and user namespaces. The user namespace maps the current user to itself,
+// granting the helper the capability to bring up the loopback interface.
+func isolateNetwork(execCmd *exec.Cmd) error {
+	if execCmd.Err != nil {
+		return execCmd.Err
+	}
+	if err := networkNamespaceSupported(); err != nil {
+		return err
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("network isolation: %w", err)
+	}
+
+	execCmd.Args = append([]string{self, NoNetworkHelper, execCmd.Path}, execCmd.Args[1:]...)
+	execCmd.Path = self
+
+	if execCmd.SysProcAttr == nil {
+		execCmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	attr := execCmd.SysProcAttr
+	attr.Cloneflags |= syscall.CLONE_NEWNET
+	if uid, gid := os.Getuid(), os.Getgid(); uid != 0 {
+		attr.Cloneflags |= syscall.CLONE_NEWUSER
+		attr.UidMappings = []syscall.SysProcIDMap{{ContainerID: uid, HostID: uid, Size: 1}}
+		attr.GidMappings = []syscall.SysProcIDMap{{ContainerID: gid, HostID: gid, Size: 1}}
+		attr.GidMappingsEnableSetgroups = false
+		attr.AmbientCaps = []uintptr{unix.CAP_NET_ADMIN}
+	}
+	return nil
+}
+
+// networkNamespaceSupported returns an error when the kernel doesn't
+// allow this user to create a network namespace.
+func networkNamespaceSupported() error {
+	if _, err := os.Stat("/proc/self/ns/net"); err != nil {
+		return errors.New("network isolation is not supported: the kernel has no network namespaces")
+	}
+	if os.Getuid() == 0 {
+		return nil
+	}
+	for file, disabled := range map[string]string{
+		"/proc/sys/user/max_user_namespaces":         "0",
+		"/proc/sys/kernel/unprivileged_userns_clone": "0",
+	} {
+		if data, err := os.ReadFile(file); err == nil && strings.TrimSpace(string(data)) == disabled {
+			return fmt.Errorf("network isolation is not supported: unprivileged user namespaces are disabled (%s)", file)
+		}
+	}
+	return nil
+}
+
+// loopbackUp brings up the loopback interface.
+func loopbackUp() error {
+	fd, err := unix.Socket(unix.AF_INET, unix.SOCK_DGRAM|unix.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return err
+	}
+	defer unix.Close(fd)
+
+	ifr, err := unix.NewIfreq("lo")
+	if err != nil {
+		return err
+	}
+	if err := unix.IoctlIfreq(fd, unix.SIOCGIFFLAGS, ifr); err != nil {
+		return err
+	}
+	ifr.SetUint16(ifr.Uint16() | unix.IFF_UP)
+	return unix.IoctlIfreq(fd, unix.SIOCSIFFLAGS, ifr)
+}
@@ -0,0 +1,17 @@
+//go:build !linux
+
+package psexec
+
+import (
+	"errors"
+	"os/exec"
+	"runtime"
+)
+
+// NoNetworkMain returns, there is no helper without network namespaces.
+func NoNetworkMain([]string) {}
+
+// isolateNetwork fails, network namespaces are Linux only.
+func isolateNetwork(*exec.Cmd) error {
+	return errors.New("network isolation is not supported on " + runtime.GOOS + ", it requires Linux network namespaces")
+}
@@ -0,0 +1,58 @@
+package psexec_test
+
+import (
+	"context"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/atkins/psexec"
+)
+
+func TestMain(m *testing.M) {
+	psexec.NoNetworkMain(os.Args)
+	os.Exit(m.Run())
+}
+
+func TestExecutor_RunNoNetwork(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		cmd := psexec.NewShellCommand("true")
+		cmd.NoNetwork = true
+		result := psexec.New().Run(context.Background(), cmd)
+		assert.ErrorContains(t, result.Err(), "network isolation is not supported")
+		return
+	}
+
+	// The namespace has a loopback interface only, and it's up
+	cmd := psexec.NewShellCommand("cat /proc/net/dev; exec 3<>/dev/tcp/127.0.0.1/9 || echo refused")
+	cmd.NoNetwork = true
+	cmd.Dir = t.TempDir()
+	result := psexec.New().Run(context.Background(), cmd)
+	if err := result.Err(); err != nil && strings.Contains(result.ErrorOutput()+err.Error(), "operation not permitted") {
+		t.Skipf("network namespaces are not permitted: %v", err)
+	}
+	require.NoError(t, result.Err(), result.ErrorOutput())
+
+	var interfaces []string
+	for _, line := range strings.Split(result.Output(), "\n") {
+		if name, _, ok := strings.Cut(line, ":"); ok && !strings.Contains(name, "|") {
+			interfaces = append(interfaces, strings.TrimSpace(name))
+		}
+	}
+	assert.Equal(t, []string{"lo"}, interfaces)
+	assert.Contains(t, result.Output(), "refused")
+	assert.Contains(t, result.ErrorOutput(), "Connection refused")
+
+	// The command runs in its working directory with its environment
+	cmd = psexec.NewShellCommand(`pwd; echo "$FOO"`)
+	cmd.NoNetwork = true
+	cmd.Dir = t.TempDir()
+	cmd.Env = []string{"FOO=bar"}
+	result = psexec.New().Run(context.Background(), cmd)
+	require.NoError(t, result.Err(), result.ErrorOutput())
+	assert.Equal(t, cmd.Dir+"\nbar\n", result.Output())
+}
@@ -0,0 +1,83 @@
+package psexec
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// IOClass is an I/O scheduling class.
+type IOClass int
+
+// I/O scheduling classes, as used by ionice.
+const (
+	IOClassNone IOClass = iota
+	IOClassRealtime
+	IOClassBestEffort
+	IOClassIdle
+)
+
+// Priority sets the scheduling priority of a command once it starts. The
+// processes it starts inherit it. I/O classes and CPUs are only applied
+// on Linux.
+type Priority struct {
+	// Nice is the nice level, from -20 (highest) to 19 (lowest).
+	Nice int
+	// IOClass is the I/O scheduling class, IOClassNone keeps the default.
+	IOClass IOClass
+	// IOLevel is the priority within the realtime and best-effort
+	// classes, from 0 (highest) to 7 (lowest).
+	IOLevel int
+	// CPUs are the CPUs the command may run on, all when empty.
+	CPUs []int
+}
+
+// ParseIOClass parses an ionice class, realtime, best-effort or idle,
+// with an optional level: best-effort:7.
+func ParseIOClass(s string) (IOClass, int, error) {
+	name, levelStr, hasLevel := strings.Cut(s, ":")
+	var class IOClass
+	switch name {
+	case "realtime", "rt":
+		class = IOClassRealtime
+	case "best-effort", "be":
+		class = IOClassBestEffort
+	case "idle":
+		class = IOClassIdle
+	default:
+		return IOClassNone, 0, fmt.Errorf("unknown I/O class %q, expected realtime, best-effort or idle", name)
+	}
+
+	level := 4
+	if hasLevel {
+		var err error
+		level, err = strconv.Atoi(levelStr)
+		if err != nil || level < 0 || level > 7 || class == IOClassIdle {
+			return IOClassNone, 0, fmt.Errorf("invalid I/O level %q, expected 0-7 for realtime and best-effort", levelStr)
+		}
+	}
+	return class, level, nil
+}
+
+// ParseCPUSet parses a list of CPUs and CPU ranges, e.g. 0-3,6.
+func ParseCPUSet(s string) ([]int, error) {
+	var cpus []int
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		first, last, isRange := strings.Cut(part, "-")
+		from, err := strconv.Atoi(first)
+		if err != nil || from < 0 {
+			return nil, fmt.Errorf("invalid cpuset %q", s)
+		}
+		to := from
+		if isRange {
+			if to, err = strconv.Atoi(last); err != nil || to < from {
+				return nil, fmt.Errorf("invalid cpuset %q", s)
+			}
+		}
+		for cpu := from; cpu <= to; cpu++ {
+			cpus = append(cpus, cpu)
+		}
+	}
+	return cpus, nil
+}
@@ -0,0 +1,36 @@
+package psexec
+
+import (
+	"fmt"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// ioprioWhoProcess selects a single process for ioprio_set.
+const ioprioWhoProcess = 1
+
+// apply sets the priority of the started process.
+func (p *Priority) apply(pid int) error {
+	if p.Nice != 0 {
+		if err := syscall.Setpriority(syscall.PRIO_PROCESS, pid, p.Nice); err != nil {
+			return fmt.Errorf("failed to set nice level %d: %w", p.Nice, err)
+		}
+	}
+	if p.IOClass != IOClassNone {
+		prio := int(p.IOClass)<<13 | p.IOLevel
+		if _, _, errno := unix.Syscall(unix.SYS_IOPRIO_SET, ioprioWhoProcess, uintptr(pid), uintptr(prio)); errno != 0 {
+			return fmt.Errorf("failed to set I/O class: %w", errno)
+		}
+	}
+	if len(p.CPUs) > 0 {
+		var set unix.CPUSet
+		for _, cpu := range p.CPUs {
+			set.Set(cpu)
+		}
+		if err := unix.SchedSetaffinity(pid, &set); err != nil {
+			return fmt.Errorf("failed to set cpuset %v: %w", p.CPUs, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+//go:build !linux
+
+package psexec
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// apply sets the nice level of the started process. I/O classes and
+// CPUs are Linux only, and are ignored.
+func (p *Priority) apply(pid int) error {
+	if p.Nice != 0 {
+		if err := syscall.Setpriority(syscall.PRIO_PROCESS, pid, p.Nice); err != nil {
+			return fmt.Errorf("failed to set nice level %d: %w", p.Nice, err)
+		}
+	}
+	return nil
+}
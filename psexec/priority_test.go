@@ -0,0 +1,64 @@
+package psexec_test
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/atkins/psexec"
+)
+
+func TestParseIOClass(t *testing.T) {
+	tests := []struct {
+		in    string
+		class psexec.IOClass
+		level int
+		err   bool
+	}{
+		{in: "idle", class: psexec.IOClassIdle, level: 4},
+		{in: "be", class: psexec.IOClassBestEffort, level: 4},
+		{in: "best-effort:7", class: psexec.IOClassBestEffort, level: 7},
+		{in: "realtime:0", class: psexec.IOClassRealtime, level: 0},
+		{in: "best-effort:8", err: true},
+		{in: "idle:3", err: true},
+		{in: "low", err: true},
+	}
+	for _, tc := range tests {
+		class, level, err := psexec.ParseIOClass(tc.in)
+		if tc.err {
+			assert.Error(t, err, tc.in)
+			continue
+		}
+		require.NoError(t, err, tc.in)
+		assert.Equal(t, tc.class, class, tc.in)
+		assert.Equal(t, tc.level, level, tc.in)
+	}
+}
+
+func TestParseCPUSet(t *testing.T) {
+	cpus, err := psexec.ParseCPUSet("0-3, 6")
+	require.NoError(t, err)
+	assert.Equal(t, []int{0, 1, 2, 3, 6}, cpus)
+
+	for _, in := range []string{"", "a", "3-1", "-1", "0-"} {
+		_, err := psexec.ParseCPUSet(in)
+		assert.Error(t, err, in)
+	}
+}
+
+func TestExecutor_RunPriority(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("nice is not available")
+	}
+	// The priority is set once the command starts
+	cmd := psexec.NewShellCommand("sleep 0.1; nice")
+	cmd.Priority = &psexec.Priority{Nice: 7}
+
+	result := psexec.New().Run(context.Background(), cmd)
+	require.True(t, result.Success(), result.Output())
+	assert.Equal(t, "7", strings.TrimSpace(result.Output()))
+}
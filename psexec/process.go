@@ -1,6 +1,7 @@
 package psexec
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -87,12 +88,39 @@ func (p *Process) Close() error {
 	return nil
 }
 
-// Wait waits for the process to complete and returns the result.
+// Wait waits for the process to complete and returns the result. Wait
+// is safe to call concurrently and more than once, every call returns
+// the same result. After Close, Wait returns once the killed process
+// is reaped.
 func (p *Process) Wait() Result {
 	<-p.done
 	return p.result
 }
 
+// WaitContext waits for the process to complete like Wait, or returns
+// the context error when ctx is done first. The process keeps running
+// in that case, use Close to terminate it.
+func (p *Process) WaitContext(ctx context.Context) (Result, error) {
+	select {
+	case <-p.done:
+		return p.result, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Result returns a channel receiving the result once the process
+// completes, for use in a select. Each call returns a new channel.
+func (p *Process) Result() <-chan Result {
+	ch := make(chan Result, 1)
+	go func() {
+		<-p.done
+		ch <- p.result
+		close(ch)
+	}()
+	return ch
+}
+
 // Done returns a channel that is closed when the process completes.
 func (p *Process) Done() <-chan struct{} {
 	return p.done
@@ -129,6 +129,59 @@ func TestProcess_Wait_Failure(t *testing.T) {
 	assert.Equal(t, 5, result.ExitCode())
 }
 
+func TestProcess_WaitContext(t *testing.T) {
+	exec := psexec.New()
+
+	proc, err := exec.Start(context.Background(), psexec.NewShellCommand("sleep 10"))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	result, err := proc.WaitContext(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Nil(t, result)
+
+	require.NoError(t, proc.Close())
+	result, err = proc.WaitContext(context.Background())
+	require.NoError(t, err)
+	assert.False(t, result.Success())
+}
+
+func TestProcess_Result(t *testing.T) {
+	exec := psexec.New()
+
+	proc, err := exec.Start(context.Background(), psexec.NewShellCommand("exit 3"))
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, proc.Close()) }()
+
+	select {
+	case result := <-proc.Result():
+		assert.Equal(t, 3, result.ExitCode())
+	case <-time.After(2 * time.Second):
+		t.Fatal("process did not complete")
+	}
+}
+
+func TestProcess_Wait_Concurrent(t *testing.T) {
+	exec := psexec.New()
+
+	proc, err := exec.Start(context.Background(), psexec.NewShellCommand("sleep 10"))
+	require.NoError(t, err)
+
+	results := make(chan psexec.Result, 4)
+	for range 3 {
+		go func() { results <- proc.Wait() }()
+	}
+	go func() { results <- <-proc.Result() }()
+
+	require.NoError(t, proc.Close())
+	first := <-results
+	for range 3 {
+		assert.Same(t, first, <-results)
+	}
+	assert.Same(t, first, proc.Wait())
+}
+
 func TestProcess_Done(t *testing.T) {
 	exec := psexec.New()
 	ctx := context.Background()
@@ -0,0 +1,61 @@
+package psexec
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/creack/pty"
+)
+
+// WindowSize is the size of a terminal in characters.
+type WindowSize struct {
+	Rows uint16
+	Cols uint16
+}
+
+// syncSize resizes the PTY to the sizes received from resize, and with
+// winch, to the controlling terminal each time it's resized (SIGWINCH).
+// It stops when the returned function is called, which must happen
+// before the PTY is closed.
+func (e *Executor) syncSize(ptmx *os.File, resize <-chan WindowSize, winch bool) (stop func()) {
+	if resize == nil && !winch {
+		return func() {}
+	}
+
+	var signals chan os.Signal
+	if winch {
+		signals = make(chan os.Signal, 1)
+		signal.Notify(signals, syscall.SIGWINCH)
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case <-done:
+				return
+			case <-signals:
+				if size := e.terminalSize(); size != nil {
+					_ = pty.Setsize(ptmx, size)
+				}
+			case size, ok := <-resize:
+				if !ok {
+					resize = nil
+					continue
+				}
+				_ = pty.Setsize(ptmx, &pty.Winsize{Rows: size.Rows, Cols: size.Cols})
+			}
+		}
+	}()
+
+	return func() {
+		if signals != nil {
+			signal.Stop(signals)
+		}
+		close(done)
+		<-stopped
+	}
+}
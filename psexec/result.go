@@ -5,6 +5,7 @@ package psexec
 
 import (
 	"bytes"
+	"sync"
 	"time"
 )
 
@@ -14,6 +15,9 @@ type Result interface {
 	Output() string
 	// ErrorOutput returns the stderr content.
 	ErrorOutput() string
+	// CombinedOutput returns stdout and stderr interleaved in the order
+	// the process wrote them. With a PTY, it's the same as Output.
+	CombinedOutput() string
 	// ExitCode returns the process exit code.
 	ExitCode() int
 	// Err returns any error that occurred during execution.
@@ -28,6 +32,7 @@ type Result interface {
 type processResult struct {
 	stdout   *bytes.Buffer
 	stderr   *bytes.Buffer
+	combined *combinedBuffer
 	exitCode int
 	err      error
 	duration time.Duration
@@ -49,6 +54,14 @@ func (r *processResult) ErrorOutput() string {
 	return r.stderr.String()
 }
 
+// CombinedOutput returns the captured stdout and stderr in write order.
+func (r *processResult) CombinedOutput() string {
+	if r.combined == nil {
+		return r.Output()
+	}
+	return r.combined.String()
+}
+
 // ExitCode returns the process exit code.
 func (r *processResult) ExitCode() int {
 	return r.exitCode
@@ -69,6 +82,27 @@ func (r *processResult) Duration() time.Duration {
 	return r.duration
 }
 
+// combinedBuffer is a buffer shared by stdout and stderr. Writes are
+// serialized, so the output keeps the order it was written in.
+type combinedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+// Write appends p to the buffer.
+func (b *combinedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+// String returns the buffer contents.
+func (b *combinedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
 // EmptyResult is a Result for empty/no-op commands.
 type EmptyResult struct{}
 
@@ -78,6 +112,9 @@ func (EmptyResult) Output() string { return "" }
 // ErrorOutput returns empty string.
 func (EmptyResult) ErrorOutput() string { return "" }
 
+// CombinedOutput returns empty string.
+func (EmptyResult) CombinedOutput() string { return "" }
+
 // ExitCode returns 0.
 func (EmptyResult) ExitCode() int { return 0 }
 
@@ -30,6 +30,30 @@ func TestResult_Output_Empty(t *testing.T) {
 	assert.Empty(t, result.Output())
 }
 
+func TestResult_CombinedOutput(t *testing.T) {
+	exec := psexec.New()
+	ctx := context.Background()
+
+	cmd := psexec.NewShellCommand("echo one; sleep 0.05; echo two >&2; sleep 0.05; echo three")
+	result := exec.Run(ctx, cmd)
+
+	assert.Equal(t, "one\nthree\n", result.Output())
+	assert.Equal(t, "two\n", result.ErrorOutput())
+	assert.Equal(t, "one\ntwo\nthree\n", result.CombinedOutput())
+}
+
+func TestResult_CombinedOutput_PTY(t *testing.T) {
+	exec := psexec.New()
+	ctx := context.Background()
+
+	cmd := psexec.NewShellCommand("echo one; echo two >&2")
+	cmd.UsePTY = true
+	result := exec.Run(ctx, cmd)
+
+	assert.Equal(t, result.Output(), result.CombinedOutput())
+	assert.Contains(t, result.CombinedOutput(), "two")
+}
+
 func TestResult_ErrorOutput(t *testing.T) {
 	exec := psexec.New()
 	ctx := context.Background()
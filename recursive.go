@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/titpetric/atkins/colors"
+	"github.com/titpetric/atkins/eventlog"
+	"github.com/titpetric/atkins/model"
+	"github.com/titpetric/atkins/runner"
+	"github.com/titpetric/atkins/treeview"
+)
+
+// Results of a directory in a recursive run.
+const (
+	recursivePassed  = "passed"
+	recursiveFailed  = "failed"
+	recursiveSkipped = "skipped"
+)
+
+// recursiveResult is the result of running the jobs in one directory.
+type recursiveResult struct {
+	Dir      string // Relative to the root of the run
+	Result   string
+	Duration time.Duration
+	ExitCode int
+	Detail   string // Why the directory failed or was skipped
+}
+
+// runRecursive runs the jobs in every directory under the current one
+// with a config file or a go.mod, each with its own tree labeled with the
+// directory, and prints a summary of the directories. The run exits with
+// the exit code of the first failed directory.
+func runRecursive(ctx context.Context, opts *Options) error {
+	root, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+	dirs, err := runner.DiscoverRecursive(root)
+	if err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+	if len(dirs) == 0 {
+		return fmt.Errorf("%s no directories with a config file or go.mod found in %s", treeview.ErrorHeader(), root)
+	}
+
+	auditLog, err := openAuditLog(opts)
+	if err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+	jobCache, err := openJobCache(opts)
+	if err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+
+	jobs := opts.Jobs
+	if len(jobs) == 0 {
+		jobs = []string{"default"}
+	}
+
+	// The trees of the runs are separated by an empty line
+	var ran bool
+	separate := func() {
+		if ran {
+			fmt.Println()
+		}
+		ran = true
+	}
+
+	results := make([]recursiveResult, 0, len(dirs))
+	for _, dir := range dirs {
+		rel, err := filepath.Rel(root, dir)
+		if err != nil {
+			rel = dir
+		}
+		if err := os.Chdir(dir); err != nil {
+			return fmt.Errorf("%s failed to change directory to %s: %v", treeview.ErrorHeader(), dir, err)
+		}
+
+		start := time.Now()
+		result := runDirectory(ctx, opts, dir, rel, jobs, separate, auditLog, jobCache)
+		result.Duration = time.Since(start)
+		results = append(results, result)
+	}
+	if err := os.Chdir(root); err != nil {
+		return fmt.Errorf("%s failed to change directory to %s: %v", treeview.ErrorHeader(), root, err)
+	}
+
+	printRecursiveSummary(results)
+	for _, result := range results {
+		if result.Result == recursiveFailed {
+			os.Exit(max(result.ExitCode, 1))
+		}
+	}
+	return nil
+}
+
+// runDirectory runs the jobs with the pipelines of dir. A directory
+// without the jobs is skipped.
+func runDirectory(ctx context.Context, opts *Options, dir, rel string, jobs []string, separate func(), auditLog *eventlog.AuditLog, jobCache *runner.JobCache) recursiveResult {
+	result := recursiveResult{Dir: rel, Result: recursivePassed}
+	fail := func(err error) recursiveResult {
+		result.Result, result.ExitCode, result.Detail = recursiveFailed, 1, err.Error()
+		var execErr runner.ExecError
+		if errors.As(err, &execErr) {
+			result.ExitCode = execErr.LastExitCode
+			result.Detail = fmt.Sprintf("exit code %d", execErr.LastExitCode)
+		}
+		return result
+	}
+
+	pipelines, err := loadProjectPipelines(dir, opts)
+	if err != nil {
+		return fail(err)
+	}
+	pipelines, _ = mergeGlobalSkills(pipelines, dir, dir, opts)
+
+	// Jobs are grouped by pipeline, like a run of the directory would
+	var order []*model.Pipeline
+	pipelineJobs := make(map[*model.Pipeline][]string)
+	resolver := runner.NewTaskResolver(pipelines)
+	for _, name := range jobs {
+		target, err := resolver.Resolve(name)
+		if err != nil {
+			var fuzzyErr *runner.FuzzyMatchError
+			if errors.As(err, &fuzzyErr) {
+				return fail(fmt.Errorf("job %q: %w", name, err))
+			}
+			result.Result, result.Detail = recursiveSkipped, fmt.Sprintf("no job %q", name)
+			return result
+		}
+		if _, ok := pipelineJobs[target.Pipeline]; !ok {
+			order = append(order, target.Pipeline)
+		}
+		pipelineJobs[target.Pipeline] = append(pipelineJobs[target.Pipeline], strings.TrimPrefix(target.Name, target.Pipeline.ID+":"))
+	}
+
+	policy, err := loadPolicy(dir, opts)
+	if err != nil {
+		return fail(err)
+	}
+
+	for _, pipeline := range order {
+		// The tree of the run is labeled with the directory
+		labeled := *pipeline
+		labeled.Name = rel
+		if pipeline.Name != "" && pipeline.Name != rel && !slices.Contains(runner.ConfigNames, pipeline.Name) {
+			labeled.Name = rel + " (" + pipeline.Name + ")"
+		}
+
+		separate()
+		err := runner.RunPipeline(ctx, &labeled, runner.PipelineOptions{
+			Jobs:           pipelineJobs[pipeline],
+			LogFile:        opts.LogFile,
+			Debug:          opts.Debug,
+			FinalOnly:      opts.FinalOnly,
+			Plain:          opts.Plain,
+			Timestamps:     opts.Timestamps,
+			AllPipelines:   pipelines,
+			Version:        Version,
+			RunID:          opts.RunID,
+			CaptureDir:     opts.CaptureDir,
+			MetricsFile:    opts.MetricsFile,
+			Policy:         policy,
+			AuditLog:       auditLog,
+			Cache:          jobCache,
+			EnforceBudgets: opts.EnforceBudgets,
+			GanttFile:      opts.Gantt,
+		})
+		if err != nil {
+			return fail(err)
+		}
+	}
+	return result
+}
+
+// printRecursiveSummary prints the result of each directory, followed by
+// the number of passed, failed and skipped directories.
+func printRecursiveSummary(results []recursiveResult) {
+	width := 0
+	for _, result := range results {
+		width = max(width, len(result.Dir))
+	}
+
+	counts := make(map[string]int)
+	fmt.Printf("\n%s\n\n", colors.BrightWhite(fmt.Sprintf("%d directories:", len(results))))
+	for _, result := range results {
+		counts[result.Result]++
+		symbol, duration := colors.BrightGreen("✓"), result.Duration.Round(time.Millisecond).String()
+		switch result.Result {
+		case recursiveFailed:
+			symbol = colors.BrightRed("✗")
+		case recursiveSkipped:
+			symbol, duration = colors.Gray("-"), ""
+		}
+		cells := []string{fmt.Sprintf("%-*s", width, result.Dir)}
+		if duration != "" {
+			cells = append(cells, duration)
+		}
+		if result.Detail != "" {
+			cells = append(cells, colors.Gray(result.Detail))
+		}
+		fmt.Println(strings.TrimRight("  "+symbol+" "+strings.Join(cells, "  "), " "))
+	}
+
+	labels := treeview.CurrentLabels()
+	summary := fmt.Sprintf("%d %s, %d %s, %d %s", counts[recursivePassed], labels.Passed, counts[recursiveFailed], labels.Failed, counts[recursiveSkipped], labels.Skipped)
+	if counts[recursiveFailed] > 0 {
+		summary = colors.BrightRed(summary)
+	} else {
+		summary = colors.BrightGreen(summary)
+	}
+	fmt.Printf("\n%s\n", summary)
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunDirectory(t *testing.T) {
+	root := t.TempDir()
+	write := func(dir, config string) string {
+		dir = filepath.Join(root, dir)
+		require.NoError(t, os.MkdirAll(dir, 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, ".atkins.yml"), []byte(config), 0o644))
+		return dir
+	}
+	pass := write("api", "jobs:\n  test:\n    steps:\n      - run: \"true\"\n")
+	fail := write("web", "jobs:\n  test:\n    steps:\n      - run: exit 3\n")
+	skip := write("docs", "jobs:\n  build:\n    steps:\n      - run: \"true\"\n")
+
+	opts := &Options{FinalOnly: true}
+	run := func(dir, rel string) recursiveResult {
+		t.Chdir(dir)
+		return runDirectory(t.Context(), opts, dir, rel, []string{"test"}, func() {}, nil, nil)
+	}
+
+	result := run(pass, "api")
+	assert.Equal(t, recursivePassed, result.Result)
+	assert.Equal(t, "api", result.Dir)
+
+	result = run(fail, "web")
+	assert.Equal(t, recursiveFailed, result.Result)
+	assert.Equal(t, 3, result.ExitCode)
+	assert.Equal(t, "exit code 3", result.Detail)
+
+	result = run(skip, "docs")
+	assert.Equal(t, recursiveSkipped, result.Result)
+	assert.Equal(t, `no job "test"`, result.Detail)
+}
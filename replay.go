@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/spf13/pflag"
+	"github.com/titpetric/cli"
+
+	"github.com/titpetric/atkins/colors"
+	"github.com/titpetric/atkins/eventlog"
+	"github.com/titpetric/atkins/treeview"
+)
+
+// Replay provides a cli.Command that re-runs a recorded step command.
+func Replay() *cli.Command {
+	var shell bool
+
+	return &cli.Command{
+		Name:  "replay",
+		Title: "Re-run a recorded step with its environment",
+		Usage: func() string {
+			return "atkins replay [--shell] <run-id> <step-id>"
+		},
+		Bind: func(fs *pflag.FlagSet) {
+			fs.BoolVar(&shell, "shell", false, "Open an interactive shell with the step environment instead")
+		},
+		Run: func(ctx context.Context, args []string) error {
+			if len(args) != 2 {
+				return fmt.Errorf("%s expected: replay <run-id> <step-id>", treeview.ErrorHeader())
+			}
+			return runReplay(ctx, args[0], args[1], shell)
+		},
+	}
+}
+
+func runReplay(ctx context.Context, runID, stepID string, shell bool) error {
+	root, err := projectRoot()
+	if err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+	indexPath := filepath.Join(root, eventlog.RunIndexPath)
+
+	entries, err := eventlog.LoadRunIndex(indexPath)
+	if err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+	entry := eventlog.FindRun(entries, runID)
+	if entry == nil {
+		return fmt.Errorf("%s run %q not found in %s", treeview.ErrorHeader(), runID, indexPath)
+	}
+	log, err := eventlog.ReadLog(entry.LogFile)
+	if err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+
+	event := eventlog.FindCommand(log, stepID)
+	if event == nil {
+		return fmt.Errorf("%s step %q not found in run %s", treeview.ErrorHeader(), stepID, entry.RunID)
+	}
+	if len(event.Env) == 0 {
+		return fmt.Errorf("%s run %s has no recorded environment, record it with --log and --debug", treeview.ErrorHeader(), entry.RunID)
+	}
+
+	cmd := replayCommand(ctx, event, root, shell)
+	if shell {
+		fmt.Printf("%s shell in %s, the step ran:\n  %s %s\n", colors.BrightWhite("Replay:"), cmd.Dir, colors.Dim("$"), event.Command)
+	} else {
+		fmt.Printf("%s %s %s\n", colors.BrightWhite("Replay:"), colors.Dim("$"), event.Command)
+	}
+
+	err = cmd.Run()
+	if exitErr := (*exec.ExitError)(nil); errors.As(err, &exitErr) {
+		if shell {
+			return nil
+		}
+		return fmt.Errorf("%s step exited with code %d (recorded exit code %d)", treeview.ErrorHeader(), exitErr.ExitCode(), event.ExitCode)
+	}
+	if err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+	return nil
+}
+
+// replayCommand returns the command re-running the recorded event with
+// its environment and working directory, relative to the project root.
+// Steps don't read stdin, so the command gets none; the shell gets the
+// terminal.
+func replayCommand(ctx context.Context, event *eventlog.Event, root string, shell bool) *exec.Cmd {
+	var cmd *exec.Cmd
+	if shell {
+		name := os.Getenv("SHELL")
+		if name == "" {
+			name = "bash"
+		}
+		cmd = exec.CommandContext(ctx, name)
+		cmd.Stdin = os.Stdin
+	} else {
+		// Scripts run with pipefail, as in psexec.ShellCommand
+		cmd = exec.CommandContext(ctx, "bash", "-c", "set -o pipefail\n"+event.Command)
+	}
+
+	cmd.Dir = root
+	if event.Dir != "" {
+		cmd.Dir = event.Dir
+		if !filepath.IsAbs(event.Dir) {
+			cmd.Dir = filepath.Join(root, event.Dir)
+		}
+	}
+	cmd.Env = event.Env
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd
+}
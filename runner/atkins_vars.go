@@ -0,0 +1,87 @@
+package runner
+
+import (
+	"slices"
+	"time"
+
+	"github.com/titpetric/atkins/model"
+)
+
+// AtkinsVar is the variable holding the run metadata, e.g. `${{ atkins.job }}`.
+const AtkinsVar = "atkins"
+
+// RunIDEnv names the env var holding the run ID, set for steps.
+const RunIDEnv = "ATKINS_RUN_ID"
+
+// Features are the capabilities of this atkins binary, named by the
+// config keys introducing them. Shared skills check for them with
+// `${{ atkins.supports("services") }}`, to degrade gracefully on older
+// binaries. New features add their key here.
+var Features = []string{
+	"accept_exit_codes",
+	"aliases",
+	"benchmark",
+	"breakpoint",
+	"budget",
+	"cache",
+	"check_clean",
+	"default",
+	"detach",
+	"detect",
+	"extends",
+	"hints",
+	"inputs",
+	"lock",
+	"log_sinks",
+	"network",
+	"port_forward",
+	"ports",
+	"preflight",
+	"priority",
+	"requires",
+	"requires.atkins",
+	"scrub",
+	"services",
+	"skip_exit_codes",
+	"stages",
+	"timeout",
+	"tools",
+	"trace",
+	"tty_mode",
+	"workspace",
+}
+
+// Supports reports whether the binary supports a feature of Features.
+func Supports(feature string) bool {
+	return slices.Contains(Features, feature)
+}
+
+// setRunVars sets the run metadata of the pipeline: `atkins.pipeline`,
+// `atkins.run_id`, `atkins.start_time`, `atkins.version` and the
+// `atkins.supports()` function.
+func setRunVars(ctx *ExecutionContext, runID string, start time.Time, version string) {
+	setAtkinsVars(ctx, map[string]any{
+		"pipeline":   ctx.Pipeline.Name,
+		"run_id":     runID,
+		"start_time": start.UTC().Format(time.RFC3339),
+		"version":    version,
+		"supports":   Supports,
+	})
+}
+
+// setJobVars sets `atkins.job`, and `atkins.pipeline` to the pipeline
+// declaring the job.
+func setJobVars(ctx *ExecutionContext, pipeline *model.Pipeline, job *model.Job) {
+	values := map[string]any{"job": job.Name}
+	if pipeline != nil {
+		values["pipeline"] = pipeline.Name
+	}
+	setAtkinsVars(ctx, values)
+}
+
+// setAtkinsVars adds values to the `atkins` variable. The map is copied,
+// so the contexts the variables were cloned from keep their values.
+func setAtkinsVars(ctx *ExecutionContext, values map[string]any) {
+	current, _ := ctx.Variables.Get(AtkinsVar).(map[string]any)
+	ctx.Variables.Set(AtkinsVar, mergeMaps(current, values))
+}
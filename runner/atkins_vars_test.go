@@ -0,0 +1,111 @@
+package runner
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunPipeline_AtkinsVars(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	pipelines, err := LoadPipelineFromReader(strings.NewReader(`
+name: metadata
+jobs:
+  build:
+    vars:
+      artifact: app-${{ atkins.run_id }}.tar.gz
+    steps:
+      - run: echo "${{ atkins.pipeline }} ${{ atkins.job }} ${{ atkins.step_index }} ${{ atkins.version }}" > first
+      - run: echo "${{ atkins.step_index }} ${{ atkins.start_time }} ${{ artifact }}" > second
+`))
+	require.NoError(t, err)
+	require.NoError(t, RunPipeline(t.Context(), pipelines[0], PipelineOptions{
+		Jobs:    []string{"build"},
+		Silent:  true,
+		Version: "v1.2.3",
+	}))
+
+	first, err := os.ReadFile("first")
+	require.NoError(t, err)
+	assert.Equal(t, "metadata build 0 v1.2.3\n", string(first))
+
+	second, err := os.ReadFile("second")
+	require.NoError(t, err)
+	fields := strings.Fields(string(second))
+	require.Len(t, fields, 3)
+	assert.Equal(t, "1", fields[0])
+	_, err = time.Parse(time.RFC3339, fields[1])
+	assert.NoError(t, err)
+	assert.Regexp(t, `^app-\d{8}-\d{6}-[0-9a-f]{6}\.tar\.gz$`, fields[2])
+}
+
+func TestRunPipeline_RunID(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	pipelines, err := LoadPipelineFromReader(strings.NewReader(`
+jobs:
+  build:
+    steps:
+      - run: mkdir -p $ATKINS_ARTIFACTS && echo "${{ atkins.run_id }} $ATKINS_RUN_ID" > $ATKINS_ARTIFACTS/run
+`))
+	require.NoError(t, err)
+	require.NoError(t, RunPipeline(t.Context(), pipelines[0], PipelineOptions{
+		Jobs:       []string{"build"},
+		Silent:     true,
+		LogFile:    "logs/{run_id}.yml",
+		CaptureDir: "capture",
+		RunID:      "ci-1234",
+	}))
+
+	out, err := os.ReadFile("capture/ci-1234/run")
+	require.NoError(t, err)
+	assert.Equal(t, "ci-1234 ci-1234\n", string(out))
+	assert.FileExists(t, "logs/ci-1234.yml")
+}
+
+func TestRunPipeline_AtkinsSupports(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	pipelines, err := LoadPipelineFromReader(strings.NewReader(`
+jobs:
+  build:
+    steps:
+      - if: atkins.supports("services")
+        run: printf 'services\n' >> features
+      - if: atkins.supports("matrix")
+        run: printf 'matrix\n' >> features
+      - run: 'printf "${{ atkins.supports("lock") ? "lock" : "" }}\n" >> features'
+`))
+	require.NoError(t, err)
+	require.NoError(t, RunPipeline(t.Context(), pipelines[0], PipelineOptions{
+		Jobs:   []string{"build"},
+		Silent: true,
+	}))
+
+	features, err := os.ReadFile("features")
+	require.NoError(t, err)
+	assert.Equal(t, "services\nlock\n", string(features))
+}
+
+func TestCheckAtkinsVersion(t *testing.T) {
+	load := func(version, constraint string) error {
+		t.Helper()
+		current := AtkinsVersion
+		AtkinsVersion = version
+		defer func() { AtkinsVersion = current }()
+
+		_, err := LoadPipelineFromReader(strings.NewReader("requires:\n  atkins: \"" + constraint + "\"\n"))
+		return err
+	}
+
+	assert.NoError(t, load("v0.9.1", ">=0.9"))
+	assert.NoError(t, load("v1.2.0-3-gabc1234", "1.x"))
+	assert.NoError(t, load("dev", ">=0.9"))
+	assert.EqualError(t, load("v0.8.2", ">=0.9"), "pipeline requires atkins >=0.9, this is v0.8.2: upgrade with atkins self-update")
+	assert.ErrorContains(t, load("v0.9.1", "latest"), `invalid requires.atkins "latest"`)
+}
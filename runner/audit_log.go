@@ -0,0 +1,41 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/titpetric/atkins/eventlog"
+	"github.com/titpetric/atkins/treeview"
+)
+
+// auditLog appends the commands of a run to the audit log.
+type auditLog struct {
+	log   *eventlog.AuditLog
+	runID string
+	warn  sync.Once
+}
+
+// record appends an executed command. A failed write is reported once,
+// it doesn't fail the run.
+func (a *auditLog) record(dir, cmd string, start time.Time, exitCode int) {
+	if a == nil {
+		return
+	}
+	if dir == "" {
+		dir, _ = os.Getwd()
+	}
+	err := a.log.Append(eventlog.AuditEntry{
+		Time:     start.UTC(),
+		RunID:    a.runID,
+		Dir:      dir,
+		Command:  cmd,
+		ExitCode: exitCode,
+	})
+	if err != nil {
+		a.warn.Do(func() {
+			fmt.Fprintf(os.Stderr, "%s failed to write audit log: %v\n", treeview.WarningHeader(), err)
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package runner
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/atkins/eventlog"
+)
+
+func TestRunPipeline_AuditLog(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	path := filepath.Join(dir, "audit.log")
+
+	pipelines, err := LoadPipelineFromReader(strings.NewReader(`
+scrub:
+  - match: 'SECRET[0-9]+'
+jobs:
+  default:
+    steps:
+      - for: n in $(printf 1)
+        run: printf '%s SECRET123' "${{ n }}"
+      - run: printf '%s' "$(printf SECRET456)"
+`))
+	require.NoError(t, err)
+
+	err = RunPipeline(t.Context(), pipelines[0], PipelineOptions{
+		Jobs:     []string{"default"},
+		Silent:   true,
+		AuditLog: eventlog.NewAuditLog(path),
+	})
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotRegexp(t, `SECRET[0-9]+`, string(data))
+
+	var commands []string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var entry eventlog.AuditEntry
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		commands = append(commands, entry.Command)
+	}
+	assert.Contains(t, commands, "printf 1", "for: scripts are recorded")
+	assert.Contains(t, commands, "printf '%s "+DefaultScrubReplacement+"' \"1\"")
+	assert.Contains(t, commands, "printf "+DefaultScrubReplacement, "substitutions are recorded")
+}
@@ -0,0 +1,166 @@
+package runner
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// SBOM formats supported by GenerateSBOM.
+const (
+	SBOMCycloneDX = "cyclonedx"
+	SBOMSPDX      = "spdx"
+)
+
+// GoModule is a module as listed by `go list -m -json all`.
+type GoModule struct {
+	Path    string    `json:"Path"`
+	Version string    `json:"Version,omitempty"`
+	Main    bool      `json:"Main,omitempty"`
+	Replace *GoModule `json:"Replace,omitempty"`
+}
+
+// ParseGoModules decodes the JSON stream printed by `go list -m -json all`.
+func ParseGoModules(r io.Reader) ([]GoModule, error) {
+	var modules []GoModule
+	decoder := json.NewDecoder(r)
+	for {
+		var module GoModule
+		err := decoder.Decode(&module)
+		if errors.Is(err, io.EOF) {
+			return modules, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode go list output: %w", err)
+		}
+		if module.Replace != nil && module.Replace.Version != "" {
+			module.Path, module.Version = module.Replace.Path, module.Replace.Version
+		}
+		modules = append(modules, module)
+	}
+}
+
+// modulePURL returns the package URL of a Go module.
+func modulePURL(module GoModule) string {
+	purl := "pkg:golang/" + module.Path
+	if module.Version != "" {
+		purl += "@" + module.Version
+	}
+	return purl
+}
+
+// GenerateSBOM renders the modules as a CycloneDX or SPDX JSON document.
+// The main module describes the document, the others are its dependencies.
+func GenerateSBOM(modules []GoModule, format string, now time.Time) ([]byte, error) {
+	var main GoModule
+	var deps []GoModule
+	for _, module := range modules {
+		if module.Main {
+			main = module
+			continue
+		}
+		deps = append(deps, module)
+	}
+	if main.Path == "" {
+		return nil, errors.New("no main module found")
+	}
+
+	var doc any
+	switch format {
+	case SBOMCycloneDX:
+		doc = cycloneDXDocument(main, deps, now)
+	case SBOMSPDX:
+		doc = spdxDocument(main, deps, now)
+	default:
+		return nil, fmt.Errorf("unknown SBOM format %q, expected %s or %s", format, SBOMCycloneDX, SBOMSPDX)
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+func cycloneDXDocument(main GoModule, deps []GoModule, now time.Time) map[string]any {
+	component := func(module GoModule, kind string) map[string]any {
+		c := map[string]any{
+			"type":    kind,
+			"bom-ref": modulePURL(module),
+			"name":    module.Path,
+			"purl":    modulePURL(module),
+		}
+		if module.Version != "" {
+			c["version"] = module.Version
+		}
+		return c
+	}
+
+	components := make([]map[string]any, 0, len(deps))
+	dependsOn := make([]string, 0, len(deps))
+	for _, dep := range deps {
+		components = append(components, component(dep, "library"))
+		dependsOn = append(dependsOn, modulePURL(dep))
+	}
+
+	return map[string]any{
+		"bomFormat":   "CycloneDX",
+		"specVersion": "1.5",
+		"version":     1,
+		"metadata": map[string]any{
+			"timestamp": now.UTC().Format(time.RFC3339),
+			"tools":     []map[string]any{{"name": "atkins"}},
+			"component": component(main, "application"),
+		},
+		"components": components,
+		"dependencies": []map[string]any{
+			{"ref": modulePURL(main), "dependsOn": dependsOn},
+		},
+	}
+}
+
+func spdxDocument(main GoModule, deps []GoModule, now time.Time) map[string]any {
+	id := func(module GoModule) string {
+		replacer := strings.NewReplacer("/", "-", "@", "-", "+", "-", "_", "-")
+		return "SPDXRef-Package-" + replacer.Replace(module.Path+"-"+module.Version)
+	}
+	pkg := func(module GoModule) map[string]any {
+		p := map[string]any{
+			"name":             module.Path,
+			"SPDXID":           id(module),
+			"downloadLocation": "NOASSERTION",
+			"externalRefs": []map[string]any{{
+				"referenceCategory": "PACKAGE-MANAGER",
+				"referenceType":     "purl",
+				"referenceLocator":  modulePURL(module),
+			}},
+		}
+		if module.Version != "" {
+			p["versionInfo"] = module.Version
+		}
+		return p
+	}
+
+	packages := []map[string]any{pkg(main)}
+	relationships := []map[string]any{
+		{"spdxElementId": "SPDXRef-DOCUMENT", "relationshipType": "DESCRIBES", "relatedSpdxElement": id(main)},
+	}
+	for _, dep := range deps {
+		packages = append(packages, pkg(dep))
+		relationships = append(relationships, map[string]any{
+			"spdxElementId": id(main), "relationshipType": "DEPENDS_ON", "relatedSpdxElement": id(dep),
+		})
+	}
+
+	return map[string]any{
+		"spdxVersion":       "SPDX-2.3",
+		"dataLicense":       "CC0-1.0",
+		"SPDXID":            "SPDXRef-DOCUMENT",
+		"name":              main.Path,
+		"documentNamespace": "https://spdx.org/spdxdocs/" + main.Path + "-" + now.UTC().Format("20060102T150405Z"),
+		"creationInfo": map[string]any{
+			"created":  now.UTC().Format(time.RFC3339),
+			"creators": []string{"Tool: atkins"},
+		},
+		"packages":      packages,
+		"relationships": relationships,
+	}
+}
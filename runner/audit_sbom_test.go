@@ -0,0 +1,93 @@
+package runner
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const goListOutput = `{
+	"Path": "github.com/titpetric/atkins",
+	"Main": true,
+	"Dir": "/src/atkins"
+}
+{
+	"Path": "gopkg.in/yaml.v3",
+	"Version": "v3.0.1"
+}
+{
+	"Path": "github.com/old/dep",
+	"Version": "v1.0.0",
+	"Replace": {
+		"Path": "github.com/new/dep",
+		"Version": "v1.1.0"
+	}
+}
+`
+
+func TestGenerateSBOM(t *testing.T) {
+	modules, err := ParseGoModules(strings.NewReader(goListOutput))
+	require.NoError(t, err)
+	require.Len(t, modules, 3)
+	assert.Equal(t, "github.com/new/dep", modules[2].Path, "replacements are reported")
+
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	t.Run("cyclonedx", func(t *testing.T) {
+		data, err := GenerateSBOM(modules, SBOMCycloneDX, now)
+		require.NoError(t, err)
+
+		var doc struct {
+			BOMFormat string `json:"bomFormat"`
+			Metadata  struct {
+				Timestamp string `json:"timestamp"`
+				Component struct {
+					Name string `json:"name"`
+				} `json:"component"`
+			} `json:"metadata"`
+			Components []struct {
+				Name    string `json:"name"`
+				Version string `json:"version"`
+				PURL    string `json:"purl"`
+			} `json:"components"`
+		}
+		require.NoError(t, json.Unmarshal(data, &doc))
+		assert.Equal(t, "CycloneDX", doc.BOMFormat)
+		assert.Equal(t, "2026-01-02T03:04:05Z", doc.Metadata.Timestamp)
+		assert.Equal(t, "github.com/titpetric/atkins", doc.Metadata.Component.Name)
+		require.Len(t, doc.Components, 2)
+		assert.Equal(t, "pkg:golang/gopkg.in/yaml.v3@v3.0.1", doc.Components[0].PURL)
+		assert.Equal(t, "v1.1.0", doc.Components[1].Version)
+	})
+
+	t.Run("spdx", func(t *testing.T) {
+		data, err := GenerateSBOM(modules, SBOMSPDX, now)
+		require.NoError(t, err)
+
+		var doc struct {
+			SPDXVersion string `json:"spdxVersion"`
+			Packages    []struct {
+				Name   string `json:"name"`
+				SPDXID string `json:"SPDXID"`
+			} `json:"packages"`
+			Relationships []struct {
+				Type string `json:"relationshipType"`
+			} `json:"relationships"`
+		}
+		require.NoError(t, json.Unmarshal(data, &doc))
+		assert.Equal(t, "SPDX-2.3", doc.SPDXVersion)
+		require.Len(t, doc.Packages, 3)
+		assert.Equal(t, "SPDXRef-Package-gopkg.in-yaml.v3-v3.0.1", doc.Packages[1].SPDXID)
+		require.Len(t, doc.Relationships, 3)
+		assert.Equal(t, "DESCRIBES", doc.Relationships[0].Type)
+	})
+
+	_, err = GenerateSBOM(modules, "swid", now)
+	require.Error(t, err)
+	_, err = GenerateSBOM(modules[1:], SBOMCycloneDX, now)
+	require.Error(t, err)
+}
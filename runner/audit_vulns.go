@@ -0,0 +1,143 @@
+package runner
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// VulnBaselinePath is the default file holding the accepted vulnerabilities.
+const VulnBaselinePath = ".atkins/vulns-baseline.json"
+
+// Vulnerability is a vulnerability reported by govulncheck that the
+// code calls into.
+type Vulnerability struct {
+	ID           string   `json:"id"`
+	Aliases      []string `json:"aliases,omitempty"`
+	Summary      string   `json:"summary,omitempty"`
+	Module       string   `json:"module,omitempty"`
+	Version      string   `json:"version,omitempty"`
+	FixedVersion string   `json:"fixed_version,omitempty"`
+	New          bool     `json:"new"` // Not in the baseline
+}
+
+// VulnReport is the structured result of a vulnerability audit.
+type VulnReport struct {
+	Vulnerabilities []Vulnerability `json:"vulnerabilities"`
+}
+
+// NewCount returns the number of vulnerabilities not in the baseline.
+func (r *VulnReport) NewCount() int {
+	count := 0
+	for _, v := range r.Vulnerabilities {
+		if v.New {
+			count++
+		}
+	}
+	return count
+}
+
+// govulncheckMessage is a message of the `govulncheck -format json` stream.
+type govulncheckMessage struct {
+	OSV *struct {
+		ID      string   `json:"id"`
+		Aliases []string `json:"aliases"`
+		Summary string   `json:"summary"`
+	} `json:"osv"`
+	Finding *struct {
+		OSV          string `json:"osv"`
+		FixedVersion string `json:"fixed_version"`
+		Trace        []struct {
+			Module   string `json:"module"`
+			Version  string `json:"version"`
+			Function string `json:"function"`
+		} `json:"trace"`
+	} `json:"finding"`
+}
+
+// ParseGovulncheck reads the `govulncheck -format json` stream and returns
+// the vulnerabilities with a finding in a called function. Vulnerabilities
+// in imported packages or required modules that aren't called are left
+// out, as govulncheck does in its text output.
+func ParseGovulncheck(r io.Reader) (*VulnReport, error) {
+	entries := map[string]*Vulnerability{}
+	called := map[string]bool{}
+
+	decoder := json.NewDecoder(r)
+	for {
+		var msg govulncheckMessage
+		err := decoder.Decode(&msg)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode govulncheck output: %w", err)
+		}
+
+		switch {
+		case msg.OSV != nil:
+			v := vulnEntry(entries, msg.OSV.ID)
+			v.Aliases = msg.OSV.Aliases
+			v.Summary = msg.OSV.Summary
+		case msg.Finding != nil:
+			v := vulnEntry(entries, msg.Finding.OSV)
+			v.FixedVersion = msg.Finding.FixedVersion
+			if len(msg.Finding.Trace) == 0 {
+				continue
+			}
+			v.Module = msg.Finding.Trace[0].Module
+			v.Version = msg.Finding.Trace[0].Version
+			if msg.Finding.Trace[0].Function != "" {
+				called[v.ID] = true
+			}
+		}
+	}
+
+	report := &VulnReport{Vulnerabilities: []Vulnerability{}}
+	for id, v := range entries {
+		if called[id] {
+			report.Vulnerabilities = append(report.Vulnerabilities, *v)
+		}
+	}
+	sort.Slice(report.Vulnerabilities, func(i, j int) bool {
+		return report.Vulnerabilities[i].ID < report.Vulnerabilities[j].ID
+	})
+	return report, nil
+}
+
+func vulnEntry(entries map[string]*Vulnerability, id string) *Vulnerability {
+	if v, ok := entries[id]; ok {
+		return v
+	}
+	v := &Vulnerability{ID: id}
+	entries[id] = v
+	return v
+}
+
+// MarkNewVulnerabilities flags the vulnerabilities of report which aren't
+// in the baseline report. A missing baseline file marks all of them new.
+func MarkNewVulnerabilities(report *VulnReport, baselinePath string) error {
+	known := map[string]bool{}
+	data, err := os.ReadFile(baselinePath)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return err
+	default:
+		var baseline VulnReport
+		if err := json.Unmarshal(data, &baseline); err != nil {
+			return fmt.Errorf("failed to parse baseline %s: %w", baselinePath, err)
+		}
+		for _, v := range baseline.Vulnerabilities {
+			known[v.ID] = true
+		}
+	}
+
+	for i := range report.Vulnerabilities {
+		report.Vulnerabilities[i].New = !known[report.Vulnerabilities[i].ID]
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const govulncheckOutput = `{"config": {"scanner_name": "govulncheck"}}
+{"osv": {"id": "GO-2024-0001", "aliases": ["CVE-2024-0001"], "summary": "Called vulnerability"}}
+{"osv": {"id": "GO-2024-0002", "summary": "Imported, not called"}}
+{"finding": {"osv": "GO-2024-0001", "fixed_version": "v1.2.0", "trace": [{"module": "example.com/dep", "version": "v1.1.0"}]}}
+{"finding": {"osv": "GO-2024-0001", "fixed_version": "v1.2.0", "trace": [{"module": "example.com/dep", "version": "v1.1.0", "package": "example.com/dep", "function": "Parse"}]}}
+{"finding": {"osv": "GO-2024-0002", "trace": [{"module": "example.com/other", "version": "v0.1.0", "package": "example.com/other"}]}}
+`
+
+func TestParseGovulncheck(t *testing.T) {
+	report, err := ParseGovulncheck(strings.NewReader(govulncheckOutput))
+	require.NoError(t, err)
+	assert.Equal(t, []Vulnerability{{
+		ID:           "GO-2024-0001",
+		Aliases:      []string{"CVE-2024-0001"},
+		Summary:      "Called vulnerability",
+		Module:       "example.com/dep",
+		Version:      "v1.1.0",
+		FixedVersion: "v1.2.0",
+	}}, report.Vulnerabilities)
+
+	// Without a baseline, every vulnerability is new
+	baseline := filepath.Join(t.TempDir(), "baseline.json")
+	require.NoError(t, MarkNewVulnerabilities(report, baseline))
+	assert.Equal(t, 1, report.NewCount())
+
+	require.NoError(t, os.WriteFile(baseline, []byte(`{"vulnerabilities": [{"id": "GO-2024-0001"}]}`), 0o644))
+	require.NoError(t, MarkNewVulnerabilities(report, baseline))
+	assert.Equal(t, 0, report.NewCount())
+}
@@ -0,0 +1,211 @@
+package runner
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/titpetric/atkins/model"
+)
+
+// BenchDir is the folder holding the results of benchmark jobs.
+var BenchDir = filepath.Join(".atkins", "bench")
+
+// BenchmarkRegression is a benchmark that got slower than allowed.
+type BenchmarkRegression struct {
+	Name   string
+	Before float64 // ns/op
+	After  float64 // ns/op
+	Delta  float64 // Slowdown in percent
+}
+
+func (r BenchmarkRegression) String() string {
+	return fmt.Sprintf("%s: %.0f ns/op -> %.0f ns/op (+%.1f%%)", r.Name, r.Before, r.After, r.Delta)
+}
+
+// executeBenchmark runs the steps of a benchmark job, stores the results
+// and compares them with the previous results.
+func (e *Executor) executeBenchmark(ctx context.Context, execCtx *ExecutionContext, steps []*model.Step) error {
+	out, err := os.CreateTemp("", "atkins-bench-*.txt")
+	if err != nil {
+		return err
+	}
+	out.Close()
+	defer os.Remove(out.Name())
+
+	execCtx.Env[model.BenchmarkOutputEnv] = out.Name()
+	if err := e.executeSteps(ctx, execCtx, steps); err != nil {
+		return err
+	}
+
+	results, err := os.ReadFile(out.Name())
+	if err != nil {
+		return err
+	}
+	return recordBenchmark(execCtx.Job, results, time.Now())
+}
+
+// recordBenchmark stores the benchmark results of a job in BenchDir, in
+// the benchstat format with a `commit:` line added, and fails when they
+// regressed compared to the baseline results.
+func recordBenchmark(job *model.Job, results []byte, now time.Time) error {
+	dir := filepath.Join(BenchDir, strings.ReplaceAll(job.Name, ":", "-"))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	baseline, err := benchmarkBaseline(dir, job.Benchmark.Baseline)
+	if err != nil {
+		return fmt.Errorf("job %q: %w", job.Name, err)
+	}
+
+	commit, _ := gitOutput(".", "rev-parse", "HEAD")
+	if commit != "" {
+		results = append([]byte("commit: "+commit+"\n"), results...)
+	}
+	name := now.UTC().Format("20060102-150405.000000") + ".txt"
+	if err := os.WriteFile(filepath.Join(dir, name), results, 0o644); err != nil {
+		return err
+	}
+	if baseline == "" {
+		return nil
+	}
+
+	before, err := readBenchmarkFile(baseline)
+	if err != nil {
+		return err
+	}
+	after, err := parseBenchmarks(strings.NewReader(string(results)))
+	if err != nil {
+		return err
+	}
+
+	regressions := compareBenchmarks(before, after, job.Benchmark.GetThreshold())
+	if len(regressions) == 0 {
+		return nil
+	}
+	lines := make([]string, 0, len(regressions))
+	for _, r := range regressions {
+		lines = append(lines, "  "+r.String())
+	}
+	return fmt.Errorf("%d benchmarks regressed more than %.0f%% compared to %s:\n%s",
+		len(regressions), job.Benchmark.GetThreshold(), baseline, strings.Join(lines, "\n"))
+}
+
+// benchmarkBaseline returns the results file to compare with: the latest
+// run of the ref when set, otherwise the latest run. It returns an empty
+// path when there are no previous results.
+func benchmarkBaseline(dir, ref string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".txt") {
+			files = append(files, filepath.Join(dir, entry.Name()))
+		}
+	}
+	slices.Reverse(files)
+
+	if ref == "" {
+		if len(files) == 0 {
+			return "", nil
+		}
+		return files[0], nil
+	}
+
+	commit, err := gitOutput(".", "rev-parse", ref+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve baseline %q: %w", ref, err)
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return "", err
+		}
+		if strings.HasPrefix(string(data), "commit: "+commit+"\n") {
+			return file, nil
+		}
+	}
+	return "", fmt.Errorf("no benchmark results for baseline %q (%s), run the benchmark on it first", ref, commit)
+}
+
+func readBenchmarkFile(path string) (map[string]float64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parseBenchmarks(f)
+}
+
+// parseBenchmarks reads `go test -bench` output and returns the mean
+// ns/op of each benchmark, keyed by package and benchmark name.
+func parseBenchmarks(r io.Reader) (map[string]float64, error) {
+	sums := map[string]float64{}
+	counts := map[string]int{}
+
+	pkg := ""
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if value, ok := strings.CutPrefix(line, "pkg: "); ok {
+			pkg = strings.TrimSpace(value)
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 4 || !strings.HasPrefix(fields[0], "Benchmark") {
+			continue
+		}
+		for i := 2; i+1 < len(fields); i += 2 {
+			if fields[i+1] != "ns/op" {
+				continue
+			}
+			value, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid benchmark line %q: %w", line, err)
+			}
+			name := fields[0]
+			if pkg != "" {
+				name = pkg + "." + name
+			}
+			sums[name] += value
+			counts[name]++
+		}
+	}
+
+	means := make(map[string]float64, len(sums))
+	for name, sum := range sums {
+		means[name] = sum / float64(counts[name])
+	}
+	return means, scanner.Err()
+}
+
+// compareBenchmarks returns the benchmarks that are slower than the
+// threshold percentage. Benchmarks missing in either run are ignored.
+func compareBenchmarks(before, after map[string]float64, threshold float64) []BenchmarkRegression {
+	var regressions []BenchmarkRegression
+	for name, value := range after {
+		previous, ok := before[name]
+		if !ok || previous == 0 {
+			continue
+		}
+		delta := (value - previous) / previous * 100
+		if delta > threshold {
+			regressions = append(regressions, BenchmarkRegression{Name: name, Before: previous, After: value, Delta: delta})
+		}
+	}
+	slices.SortFunc(regressions, func(a, b BenchmarkRegression) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return regressions
+}
@@ -0,0 +1,67 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const benchOutput = `goos: linux
+goarch: amd64
+pkg: github.com/titpetric/atkins/runner
+BenchmarkParse-8     	    1000	      1000 ns/op	     512 B/op	       4 allocs/op
+BenchmarkParse-8     	    1000	      1200 ns/op	     512 B/op	       4 allocs/op
+BenchmarkRender-8    	     500	      3000 ns/op
+PASS
+`
+
+func TestParseBenchmarks(t *testing.T) {
+	results, err := parseBenchmarks(strings.NewReader(benchOutput))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]float64{
+		"github.com/titpetric/atkins/runner.BenchmarkParse-8":  1100,
+		"github.com/titpetric/atkins/runner.BenchmarkRender-8": 3000,
+	}, results)
+}
+
+func TestCompareBenchmarks(t *testing.T) {
+	before := map[string]float64{"A": 100, "B": 100, "C": 100}
+	after := map[string]float64{"A": 105, "B": 150, "D": 500}
+
+	regressions := compareBenchmarks(before, after, 10)
+	require.Len(t, regressions, 1)
+	assert.Equal(t, "B", regressions[0].Name)
+	assert.Equal(t, "B: 100 ns/op -> 150 ns/op (+50.0%)", regressions[0].String())
+}
+
+func TestBenchmarkJob(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	run := func(nsPerOp string) error {
+		pipelines, err := LoadPipelineFromReader(strings.NewReader(`
+jobs:
+  bench:
+    benchmark:
+      threshold: 20
+    steps:
+      - run: echo "BenchmarkParse-8 1000 ` + nsPerOp + ` ns/op" > "$ATKINS_BENCH_OUTPUT"
+`))
+		require.NoError(t, err)
+		return RunPipeline(t.Context(), pipelines[0], PipelineOptions{Jobs: []string{"bench"}, Silent: true, AllPipelines: pipelines})
+	}
+
+	require.NoError(t, run("1000"), "first run has nothing to compare with")
+	require.NoError(t, run("1100"), "within the threshold")
+
+	err := run("2000")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "BenchmarkParse-8: 1100 ns/op -> 2000 ns/op (+81.8%)")
+
+	files, err := os.ReadDir(filepath.Join(BenchDir, "bench"))
+	require.NoError(t, err)
+	assert.Len(t, files, 3, "results of each run are kept")
+}
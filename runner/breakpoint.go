@@ -0,0 +1,108 @@
+package runner
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"golang.org/x/term"
+
+	"github.com/titpetric/atkins/colors"
+	"github.com/titpetric/atkins/model"
+)
+
+// ErrAborted is returned when the run is aborted at a breakpoint.
+var ErrAborted = errors.New("aborted at breakpoint")
+
+// breakpoint pauses before a step and shows the interpolated commands
+// and the variables they use. It returns false when the step should be
+// skipped, and ErrAborted to stop the run. Without a terminal the step
+// runs without pausing.
+func (e *Executor) breakpoint(execCtx *ExecutionContext, step *model.Step) (bool, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return true, nil
+	}
+
+	e.ttyMu.Lock()
+	defer e.ttyMu.Unlock()
+
+	// Answering "continue" stops --step, breakpoints still pause
+	if !step.Breakpoint && e.stepDone {
+		return true, nil
+	}
+	if e.stdin == nil {
+		e.stdin = bufio.NewReader(os.Stdin)
+	}
+
+	run, answerErr := true, error(nil)
+	execCtx.Display.Suspend(func() {
+		fmt.Print(breakpointSummary(execCtx, step))
+		for {
+			fmt.Printf("%s [r]un, [s]kip, [c]ontinue without stepping, [a]bort (default run): ", colors.BrightYellow("?"))
+			line, err := e.stdin.ReadString('\n')
+			if err != nil {
+				answerErr = fmt.Errorf("breakpoint: %w", err)
+				return
+			}
+			switch strings.ToLower(strings.TrimSpace(line)) {
+			case "", "r", "run":
+			case "s", "skip":
+				run = false
+			case "c", "continue":
+				e.stepDone = true
+			case "a", "abort":
+				answerErr = ErrAborted
+			default:
+				continue
+			}
+			fmt.Println()
+			return
+		}
+	})
+	return run, answerErr
+}
+
+// breakpointSummary formats the step, its commands with ${{ }} values
+// filled in, and the variables they reference.
+func breakpointSummary(execCtx *ExecutionContext, step *model.Step) string {
+	var sb strings.Builder
+
+	jobName := ""
+	if execCtx.Job != nil {
+		jobName = execCtx.Job.Name
+	}
+	fmt.Fprintf(&sb, "\n%s %s %s\n", colors.BrightYellow("●"), colors.BrightWhite("breakpoint:"), jobName+" / "+step.String())
+
+	// Substitutions are shown as written, running them here would repeat side effects
+	var names []string
+	for _, cmd := range step.Commands() {
+		if shown, err := interpolateVariablesInString(cmd, execCtx); err == nil {
+			cmd = shown
+		}
+		for _, line := range strings.Split(cmd, "\n") {
+			fmt.Fprintf(&sb, "  %s %s\n", colors.Dim("$"), line)
+		}
+	}
+	for _, match := range interpolationRegex.FindAllStringSubmatch(strings.Join(step.Commands(), "\n"), -1) {
+		names = append(names, extractVarNames(match[1])...)
+	}
+
+	slices.Sort(names)
+	names = slices.Compact(names)
+	var vars []string
+	for _, name := range names {
+		if execCtx.Variables == nil {
+			break
+		}
+		if value := execCtx.Variables.Get(name); value != nil {
+			vars = append(vars, fmt.Sprintf("    %s = %v", name, value))
+		}
+	}
+	if len(vars) > 0 {
+		fmt.Fprintf(&sb, "  %s\n%s\n", colors.Gray("vars:"), strings.Join(vars, "\n"))
+	}
+	return sb.String()
+}
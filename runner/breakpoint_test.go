@@ -0,0 +1,32 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/atkins/colors"
+	"github.com/titpetric/atkins/model"
+)
+
+func TestBreakpointSummary(t *testing.T) {
+	execCtx := &ExecutionContext{
+		Variables: NewContextVariables(map[string]any{"target": "linux", "unused": "x"}),
+		Job:       &model.Job{Name: "build"},
+	}
+	step := &model.Step{Run: "GOOS=${{ target }} go build $(go list -m)", Breakpoint: true}
+
+	summary := colors.StripANSI(breakpointSummary(execCtx, step))
+	assert.Contains(t, summary, "breakpoint: build / run: GOOS=${{ target }} go build $(go list -m)")
+	assert.Contains(t, summary, "$ GOOS=linux go build $(go list -m)")
+	assert.Contains(t, summary, "target = linux")
+	assert.NotContains(t, summary, "unused")
+}
+
+func TestBreakpoint_NoTerminal(t *testing.T) {
+	// Tests don't run in a terminal, breakpoints don't pause
+	run, err := NewExecutor().breakpoint(&ExecutionContext{}, &model.Step{Run: "true", Breakpoint: true})
+	require.NoError(t, err)
+	assert.True(t, run)
+}
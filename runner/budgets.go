@@ -0,0 +1,137 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/titpetric/atkins/colors"
+	"github.com/titpetric/atkins/model"
+	"github.com/titpetric/atkins/treeview"
+)
+
+// ErrOverBudget is returned for steps exceeding their budget with --enforce-budgets.
+var ErrOverBudget = errors.New("step exceeded its budget")
+
+// OverBudgetStep is a step that took longer than its budget, listed in
+// the summary of the run.
+type OverBudgetStep struct {
+	Job      string
+	Step     string
+	Budget   time.Duration
+	Duration time.Duration
+}
+
+// budgetLog collects the steps exceeding their budget, shared across
+// copies of the execution context.
+type budgetLog struct {
+	mu      sync.Mutex
+	enforce bool // Fail the steps exceeding their budget
+	steps   []OverBudgetStep
+}
+
+// add records a step exceeding its budget.
+func (b *budgetLog) add(step OverBudgetStep) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.steps = append(b.steps, step)
+}
+
+// list returns the steps exceeding their budget in the order they finished.
+func (b *budgetLog) list() []OverBudgetStep {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]OverBudgetStep(nil), b.steps...)
+}
+
+// enforced returns true if steps exceeding their budget fail.
+func (b *budgetLog) enforced() bool {
+	return b != nil && b.enforce
+}
+
+// parseBudget parses the budget of a step, e.g. "30s".
+func parseBudget(budget string) (time.Duration, error) {
+	d, err := time.ParseDuration(budget)
+	if err != nil {
+		return 0, fmt.Errorf("invalid budget %q: %w", budget, err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("invalid budget %q, expected a positive duration", budget)
+	}
+	return d, nil
+}
+
+// checkBudget starts timing a step with a budget. The returned function
+// is called with the result of the step; it records the step when it
+// took longer than its budget, and fails it with --enforce-budgets.
+func (e *Executor) checkBudget(stepCtx *ExecutionContext, step *model.Step, stepNode *treeview.Node) (func(error) error, error) {
+	budget, err := parseBudget(step.Budget)
+	if err != nil {
+		stepNode.SetStatus(treeview.StatusFailed)
+		return nil, fmt.Errorf("step %q: %w", step.String(), err)
+	}
+	start := time.Now()
+
+	return func(stepErr error) error {
+		took := time.Since(start)
+		if took <= budget {
+			return stepErr
+		}
+
+		jobName := ""
+		if stepCtx.Job != nil {
+			jobName = stepCtx.Job.Name
+		}
+		stepCtx.budgets.add(OverBudgetStep{
+			Job:      jobName,
+			Step:     step.String(),
+			Budget:   budget,
+			Duration: took,
+		})
+		if stepErr != nil || !stepCtx.budgets.enforced() {
+			return stepErr
+		}
+
+		err := fmt.Errorf("%w: took %s, budget %s", ErrOverBudget, took.Round(time.Millisecond), budget)
+		stepNode.SetStatus(treeview.StatusFailed)
+		if stepNode != nil {
+			stepNode.SetOutput([]string{colors.BrightRed(err.Error())})
+		}
+		stepCtx.Render()
+		stepCtx.failures.add(FailedStep{
+			Job:      jobName,
+			Step:     step.String(),
+			Duration: took,
+			Error:    err.Error(),
+		})
+		return fmt.Errorf("step %q: %w", step.String(), err)
+	}, nil
+}
+
+// printBudgetSummary prints a table of the steps exceeding their budget.
+func printBudgetSummary(w io.Writer, steps []OverBudgetStep) {
+	if len(steps) == 0 {
+		return
+	}
+
+	header := []string{"JOB", "STEP", "DURATION", "BUDGET"}
+	rows := make([][]string, 0, len(steps))
+	for _, step := range steps {
+		rows = append(rows, []string{
+			step.Job,
+			truncate(step.Step, failureSummaryWidth),
+			step.Duration.Round(time.Millisecond).String(),
+			step.Budget.String(),
+		})
+	}
+
+	printTable(w, colors.BrightYellow(fmt.Sprintf("%d step(s) over budget:", len(steps))), header, rows)
+}
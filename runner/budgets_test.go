@@ -0,0 +1,82 @@
+package runner
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/atkins/colors"
+)
+
+func TestRunPipeline_Budget(t *testing.T) {
+	run := func(budget string, enforce bool) error {
+		pipelines, err := LoadPipelineFromReader(strings.NewReader(`
+jobs:
+  default:
+    steps:
+      - budget: ` + budget + `
+        run: sleep 0.1
+`))
+		require.NoError(t, err)
+		return RunPipeline(t.Context(), pipelines[0], PipelineOptions{
+			Jobs:           []string{"default"},
+			Silent:         true,
+			EnforceBudgets: enforce,
+		})
+	}
+
+	// Steps over budget are only flagged by default
+	assert.NoError(t, run("10ms", false))
+	assert.NoError(t, run("1m", true))
+
+	err := run("10ms", true)
+	require.ErrorIs(t, err, ErrOverBudget)
+	assert.Contains(t, err.Error(), "budget 10ms")
+
+	assert.ErrorContains(t, run("soon", false), `invalid budget "soon"`)
+}
+
+func TestPrintBudgetSummary(t *testing.T) {
+	var out bytes.Buffer
+	printBudgetSummary(&out, []OverBudgetStep{
+		{Job: "test", Step: "go test ./...", Budget: 30 * time.Second, Duration: 42500 * time.Millisecond},
+		{Job: "build", Step: "go build", Budget: time.Second, Duration: 1200 * time.Millisecond},
+	})
+
+	assert.Equal(t, `
+2 step(s) over budget:
+
+  JOB    STEP           DURATION  BUDGET
+  test   go test ./...  42.5s     30s
+  build  go build       1.2s      1s
+`, colors.StripANSI(out.String()))
+
+	out.Reset()
+	printBudgetSummary(&out, nil)
+	assert.Empty(t, out.String())
+}
+
+func TestLinter_Budgets(t *testing.T) {
+	pipelines, err := LoadPipelineFromReader(strings.NewReader(`
+jobs:
+  default:
+    steps:
+      - run: "true"
+        budget: 30s
+      - run: "false"
+        budget: -1s
+      - run: "true"
+        budget: half a minute
+`))
+	require.NoError(t, err)
+
+	errs := NewLinter(pipelines[0]).Lint()
+	require.Len(t, errs, 2)
+	for _, e := range errs {
+		assert.Equal(t, "invalid budget", e.Issue)
+	}
+}
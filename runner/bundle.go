@@ -0,0 +1,404 @@
+package runner
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/titpetric/atkins/model"
+)
+
+// BundleManifestFile is the manifest of a bundle, the first file of the archive.
+const BundleManifestFile = "bundle.yml"
+
+// BundleExtendsDir holds the skills named by `extends:` in a bundle,
+// which aren't loaded as skills themselves.
+var BundleExtendsDir = filepath.Join(".atkins", "extends")
+
+// BundleManifest describes the contents of a bundle.
+type BundleManifest struct {
+	Version string   `yaml:"version,omitempty"` // Version of atkins creating the bundle
+	Config  string   `yaml:"config,omitempty"`  // Pipeline file, empty for projects with skills only
+	Skills  []string `yaml:"skills,omitempty"`  // IDs of the bundled skills
+	Tools   []string `yaml:"tools,omitempty"`   // Bundled tool versions, e.g. go@1.22.5
+}
+
+// BundleFile is a file added to a bundle.
+type BundleFile struct {
+	Name string // Path in the bundle
+	Path string // Path on disk
+}
+
+// Bundle holds the pipeline file, the resolved skills and the cached
+// versions of pinned tools of a project, to run the pipeline without
+// network or $HOME access.
+type Bundle struct {
+	Manifest BundleManifest
+	Files    []BundleFile
+
+	// Missing lists the pinned tools without a matching version in the
+	// tool cache, these are looked up on PATH when the bundle runs.
+	Missing []string
+}
+
+// NewBundle collects the files of a bundle from the effective pipelines
+// of the project in root. Skills named by `extends:` are looked up in
+// extendsDirs, embedded skills are part of atkins and aren't bundled.
+func NewBundle(pipelines []*model.Pipeline, root string, extendsDirs []string) (*Bundle, error) {
+	b := &Bundle{}
+	added := make(map[string]bool)
+	add := func(name, path string) {
+		if !added[name] {
+			added[name] = true
+			b.Files = append(b.Files, BundleFile{Name: filepath.ToSlash(name), Path: path})
+		}
+	}
+
+	var extends []string
+	for _, p := range pipelines {
+		// Embedded skills and the virtual skills of Makefiles and
+		// package.json aren't loaded from skill files
+		if !filepath.IsAbs(p.Source) || filepath.Ext(p.Source) != ".yml" {
+			continue
+		}
+		if p.ID == "" {
+			b.Manifest.Config = filepath.Base(p.Source)
+			add(b.Manifest.Config, p.Source)
+		} else {
+			b.Manifest.Skills = append(b.Manifest.Skills, p.ID)
+			add(filepath.Join(".atkins", "skills", filepath.Base(p.Source)), p.Source)
+		}
+		if p.Extends != "" {
+			extends = append(extends, p.Extends)
+		}
+	}
+
+	// Bases may extend other skills in turn
+	for len(extends) > 0 {
+		name := extends[0]
+		extends = extends[1:]
+		for _, dir := range extendsDirs {
+			path := filepath.Join(dir, name+".yml")
+			if _, err := os.Stat(path); err != nil {
+				continue
+			}
+			if bundled := filepath.Join(BundleExtendsDir, name+".yml"); !added[filepath.ToSlash(bundled)] {
+				add(bundled, path)
+				base, err := LoadPipeline(path)
+				if err != nil {
+					return nil, fmt.Errorf("failed to load skill %s: %w", path, err)
+				}
+				if base[0].Extends != "" {
+					extends = append(extends, base[0].Extends)
+				}
+			}
+			break
+		}
+	}
+
+	for _, file := range []string{PolicyFile, filepath.Join(".atkins", MarkersFile)} {
+		path := filepath.Join(root, file)
+		if _, err := os.Stat(path); err == nil {
+			add(file, path)
+		}
+	}
+
+	if err := b.addTools(pipelines, root, add); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// addTools adds the cached versions of the pinned tools of the pipelines.
+func (b *Bundle) addTools(pipelines []*model.Pipeline, root string, add func(name, path string)) error {
+	cacheDir := filepath.Join(root, ToolCacheDir)
+	for _, p := range pipelines {
+		names := make([]string, 0, len(p.Tools))
+		for name, tool := range p.Tools {
+			if tool != nil && tool.Version != "" {
+				names = append(names, name)
+			}
+		}
+		slices.Sort(names)
+
+		for _, name := range names {
+			constraint := p.Tools[name].Version
+			bin := cachedToolBin(cacheDir, name, constraint)
+			if bin == "" {
+				b.Missing = append(b.Missing, name+" "+constraint)
+				continue
+			}
+			versionDir := filepath.Dir(bin)
+			tool := name + "@" + filepath.Base(versionDir)
+			if slices.Contains(b.Manifest.Tools, tool) {
+				continue
+			}
+			b.Manifest.Tools = append(b.Manifest.Tools, tool)
+
+			err := filepath.WalkDir(versionDir, func(path string, d fs.DirEntry, err error) error {
+				if err != nil || !d.Type().IsRegular() {
+					return err
+				}
+				rel, err := filepath.Rel(cacheDir, path)
+				if err != nil {
+					return err
+				}
+				add(filepath.Join(ToolCacheDir, rel), path)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Write writes the bundle to path, compressed by the extension of path:
+// .tar.zst (with the zstd command), .tar.gz or .tar.
+func (b *Bundle) Write(ctx context.Context, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w, err := bundleCompressor(ctx, path, f)
+	if err != nil {
+		os.Remove(path)
+		return err
+	}
+
+	if err := b.writeTar(w); err != nil {
+		w.Close()
+		os.Remove(path)
+		return err
+	}
+	if err := w.Close(); err != nil {
+		os.Remove(path)
+		return err
+	}
+	return f.Close()
+}
+
+// writeTar writes the manifest and the files of the bundle as a tarball.
+func (b *Bundle) writeTar(w io.Writer) error {
+	manifest, err := yaml.Marshal(b.Manifest)
+	if err != nil {
+		return err
+	}
+
+	tw := tar.NewWriter(w)
+	header := &tar.Header{
+		Name:     BundleManifestFile,
+		Mode:     0o644,
+		Size:     int64(len(manifest)),
+		Typeflag: tar.TypeReg,
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	if _, err := tw.Write(manifest); err != nil {
+		return err
+	}
+
+	for _, file := range b.Files {
+		if err := writeTarFile(tw, file); err != nil {
+			return fmt.Errorf("%s: %w", file.Path, err)
+		}
+	}
+	return tw.Close()
+}
+
+func writeTarFile(tw *tar.Writer, file BundleFile) error {
+	f, err := os.Open(file.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	header := &tar.Header{
+		Name:     file.Name,
+		Mode:     int64(info.Mode().Perm()),
+		Size:     info.Size(),
+		Typeflag: tar.TypeReg,
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+// ExtractBundle extracts the bundle at path into dir and returns its manifest.
+func ExtractBundle(ctx context.Context, path, dir string) (*BundleManifest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r, err := bundleDecompressor(ctx, path, f)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var manifest *BundleManifest
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid bundle %s: %w", path, err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if !filepath.IsLocal(header.Name) {
+			return nil, fmt.Errorf("invalid bundle %s: unsafe path %q", path, header.Name)
+		}
+
+		if header.Name == BundleManifestFile {
+			manifest = &BundleManifest{}
+			if err := yaml.NewDecoder(tr).Decode(manifest); err != nil {
+				return nil, fmt.Errorf("invalid bundle %s: %w", path, err)
+			}
+			continue
+		}
+
+		target := filepath.Join(dir, header.Name)
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return nil, err
+		}
+		out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, header.FileInfo().Mode().Perm())
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.Copy(out, tr); err != nil {
+			out.Close()
+			return nil, err
+		}
+		if err := out.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := r.Close(); err != nil {
+		return nil, fmt.Errorf("invalid bundle %s: %w", path, err)
+	}
+	if manifest == nil {
+		return nil, fmt.Errorf("invalid bundle %s: %s not found", path, BundleManifestFile)
+	}
+	return manifest, nil
+}
+
+// bundleCompressor returns a writer compressing to w by the extension of path.
+func bundleCompressor(ctx context.Context, path string, w io.Writer) (io.WriteCloser, error) {
+	switch {
+	case strings.HasSuffix(path, ".tar.zst"), strings.HasSuffix(path, ".tzst"):
+		cmd, err := zstdCommand(ctx, "-q", "-c")
+		if err != nil {
+			return nil, err
+		}
+		cmd.Stdout = w
+		stdin, err := cmd.StdinPipe()
+		if err != nil {
+			return nil, err
+		}
+		if err := cmd.Start(); err != nil {
+			return nil, err
+		}
+		return &commandPipe{Closer: stdin, Writer: stdin, cmd: cmd}, nil
+	case strings.HasSuffix(path, ".tar.gz"), strings.HasSuffix(path, ".tgz"):
+		return gzip.NewWriter(w), nil
+	case strings.HasSuffix(path, ".tar"):
+		return nopWriteCloser{w}, nil
+	}
+	return nil, fmt.Errorf("unknown bundle format %s, expected .tar.zst, .tar.gz or .tar", filepath.Base(path))
+}
+
+// bundleDecompressor returns a reader decompressing r by the extension of path.
+func bundleDecompressor(ctx context.Context, path string, r io.Reader) (io.ReadCloser, error) {
+	switch {
+	case strings.HasSuffix(path, ".tar.zst"), strings.HasSuffix(path, ".tzst"):
+		cmd, err := zstdCommand(ctx, "-q", "-d", "-c")
+		if err != nil {
+			return nil, err
+		}
+		cmd.Stdin = r
+		stdout, err := cmd.StdoutPipe()
+		if err != nil {
+			return nil, err
+		}
+		if err := cmd.Start(); err != nil {
+			return nil, err
+		}
+		return &commandPipe{Closer: stdout, Reader: stdout, cmd: cmd}, nil
+	case strings.HasSuffix(path, ".tar.gz"), strings.HasSuffix(path, ".tgz"):
+		return gzip.NewReader(r)
+	case strings.HasSuffix(path, ".tar"):
+		return io.NopCloser(r), nil
+	}
+	return nil, fmt.Errorf("unknown bundle format %s, expected .tar.zst, .tar.gz or .tar", filepath.Base(path))
+}
+
+// zstdCommand returns the zstd command with args, writing errors to stderr.
+func zstdCommand(ctx context.Context, args ...string) (*exec.Cmd, error) {
+	if _, err := exec.LookPath("zstd"); err != nil {
+		return nil, errors.New("zstd is required for .tar.zst bundles, install zstd or use a .tar.gz bundle")
+	}
+	cmd := exec.CommandContext(ctx, "zstd", args...)
+	cmd.Stderr = os.Stderr
+	return cmd, nil
+}
+
+// commandPipe is the stdin or stdout of a command, closing waits for the
+// command to exit.
+type commandPipe struct {
+	io.Closer
+	io.Reader
+	io.Writer
+
+	cmd    *exec.Cmd
+	closed bool
+}
+
+func (p *commandPipe) Close() error {
+	if p.closed {
+		return nil
+	}
+	p.closed = true
+	if p.Writer != nil {
+		if err := p.Closer.Close(); err != nil {
+			return err
+		}
+		return p.cmd.Wait()
+	}
+	// Drain the output, so the command isn't blocked writing it
+	_, _ = io.Copy(io.Discard, p.Reader)
+	return p.cmd.Wait()
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
@@ -0,0 +1,81 @@
+package runner_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/atkins/model"
+	"github.com/titpetric/atkins/runner"
+)
+
+func TestBundle(t *testing.T) {
+	root := t.TempDir()
+	global := t.TempDir()
+	write := func(path, content string, mode os.FileMode) {
+		t.Helper()
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), mode))
+	}
+	write(filepath.Join(root, "atkins.yml"), "jobs:\n  default: go test ./...\n", 0o644)
+	write(filepath.Join(root, ".atkins", "skills", "lint.yml"), "extends: base\n", 0o644)
+	write(filepath.Join(root, ".atkins", "policy.yml"), "allow: [go]\n", 0o644)
+	write(filepath.Join(global, "base.yml"), "jobs:\n  vet: go vet ./...\n", 0o644)
+	write(filepath.Join(root, ".atkins", "toolcache", "go", "1.22.1", "bin", "go"), "#!/bin/sh\n", 0o755)
+	write(filepath.Join(root, ".atkins", "toolcache", "go", "1.22.5", "bin", "go"), "#!/bin/sh\n", 0o755)
+
+	pipelines := []*model.Pipeline{
+		{Source: filepath.Join(root, "atkins.yml"), Tools: model.Tools{"go": {Version: "1.22.x"}, "node": {Version: "20"}}},
+		{ID: "lint", Source: filepath.Join(root, ".atkins", "skills", "lint.yml"), Extends: "base"},
+		{ID: "go", Source: "embedded:go.yml"},
+		{ID: "make", Source: filepath.Join(root, "Makefile")},
+	}
+	bundle, err := runner.NewBundle(pipelines, root, []string{filepath.Join(root, ".atkins", "skills"), global})
+	require.NoError(t, err)
+	bundle.Manifest.Version = "v1.0.0"
+
+	assert.Equal(t, runner.BundleManifest{
+		Version: "v1.0.0",
+		Config:  "atkins.yml",
+		Skills:  []string{"lint"},
+		Tools:   []string{"go@1.22.5"},
+	}, bundle.Manifest)
+	assert.Equal(t, []string{"node 20"}, bundle.Missing)
+
+	formats := []string{"bundle.tar.gz", "bundle.tar"}
+	if _, err := exec.LookPath("zstd"); err == nil {
+		formats = append(formats, "bundle.tar.zst")
+	}
+	for _, format := range formats {
+		t.Run(format, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), format)
+			require.NoError(t, bundle.Write(context.Background(), path))
+
+			dir := t.TempDir()
+			manifest, err := runner.ExtractBundle(context.Background(), path, dir)
+			require.NoError(t, err)
+			assert.Equal(t, bundle.Manifest, *manifest)
+
+			for _, file := range []string{
+				"atkins.yml",
+				filepath.Join(".atkins", "skills", "lint.yml"),
+				filepath.Join(".atkins", "extends", "base.yml"),
+				filepath.Join(".atkins", "policy.yml"),
+			} {
+				assert.FileExists(t, filepath.Join(dir, file))
+			}
+			info, err := os.Stat(filepath.Join(dir, ".atkins", "toolcache", "go", "1.22.5", "bin", "go"))
+			require.NoError(t, err)
+			assert.Equal(t, os.FileMode(0o755), info.Mode().Perm())
+			assert.NoDirExists(t, filepath.Join(dir, ".atkins", "toolcache", "go", "1.22.1"))
+		})
+	}
+
+	err = bundle.Write(context.Background(), filepath.Join(t.TempDir(), "bundle.zip"))
+	assert.ErrorContains(t, err, "unknown bundle format bundle.zip")
+}
@@ -0,0 +1,233 @@
+package runner
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrCacheMiss is returned by a CacheBackend for a key it doesn't hold.
+var ErrCacheMiss = errors.New("cache miss")
+
+// CacheBackend stores cache objects by key. Keys are slash separated paths.
+type CacheBackend interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Put(ctx context.Context, key string, data []byte) error
+}
+
+// NewCacheBackend creates a cache backend from a URL:
+//
+//   - s3://bucket/prefix, signed with the AWS_* credentials from the environment,
+//   - gs://bucket/prefix, authorized with $GOOGLE_OAUTH_ACCESS_TOKEN,
+//   - http(s)://host/prefix, using GET and PUT, authorized with $ATKINS_CACHE_TOKEN,
+//   - a local directory, or file:///path.
+func NewCacheBackend(rawURL string) (CacheBackend, error) {
+	scheme, rest, ok := strings.Cut(rawURL, "://")
+	if !ok {
+		return &dirCacheBackend{Dir: rawURL}, nil
+	}
+
+	switch scheme {
+	case "file":
+		return &dirCacheBackend{Dir: rest}, nil
+	case "http", "https":
+		return &httpCacheBackend{
+			URL:    strings.TrimSuffix(rawURL, "/"),
+			Client: http.DefaultClient,
+			sign:   bearerToken(os.Getenv("ATKINS_CACHE_TOKEN")),
+		}, nil
+	case "gs":
+		return &httpCacheBackend{
+			URL:    "https://storage.googleapis.com/" + strings.TrimSuffix(rest, "/"),
+			Client: http.DefaultClient,
+			sign:   bearerToken(os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN")),
+		}, nil
+	case "s3":
+		bucket, prefix, _ := strings.Cut(strings.TrimSuffix(rest, "/"), "/")
+		if bucket == "" {
+			return nil, fmt.Errorf("invalid cache URL %q, expected s3://bucket/prefix", rawURL)
+		}
+		region := cmp.Or(os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION"), "us-east-1")
+		base := fmt.Sprintf("https://%s.s3.%s.amazonaws.com", bucket, region)
+		if endpoint := os.Getenv("AWS_ENDPOINT_URL"); endpoint != "" {
+			base = strings.TrimSuffix(endpoint, "/") + "/" + bucket
+		}
+		if prefix != "" {
+			base += "/" + prefix
+		}
+		signer := &s3Signer{
+			AccessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+			Region:       region,
+		}
+		return &httpCacheBackend{URL: base, Client: http.DefaultClient, sign: signer.Sign}, nil
+	}
+	return nil, fmt.Errorf("unsupported cache URL %q, expected s3://, gs://, http(s):// or a directory", rawURL)
+}
+
+// dirCacheBackend stores cache objects as files in a directory.
+type dirCacheBackend struct {
+	Dir string
+}
+
+// Get implements CacheBackend.
+func (d *dirCacheBackend) Get(_ context.Context, key string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(d.Dir, filepath.FromSlash(key)))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrCacheMiss
+	}
+	return data, err
+}
+
+// Put implements CacheBackend, replacing the file once it is complete.
+func (d *dirCacheBackend) Put(_ context.Context, key string, data []byte) error {
+	path := filepath.Join(d.Dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".cache-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// httpCacheBackend stores cache objects with GET and PUT requests below URL.
+type httpCacheBackend struct {
+	URL    string
+	Client *http.Client
+
+	// sign authorizes a request, the body is passed for signatures covering it.
+	sign func(req *http.Request, body []byte)
+}
+
+// Get implements CacheBackend.
+func (h *httpCacheBackend) Get(ctx context.Context, key string) ([]byte, error) {
+	resp, err := h.do(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return io.ReadAll(resp.Body)
+	case http.StatusNotFound:
+		return nil, ErrCacheMiss
+	}
+	return nil, fmt.Errorf("GET %s: unexpected status %s", key, resp.Status)
+}
+
+// Put implements CacheBackend.
+func (h *httpCacheBackend) Put(ctx context.Context, key string, data []byte) error {
+	resp, err := h.do(ctx, http.MethodPut, key, data)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("PUT %s: unexpected status %s", key, resp.Status)
+	}
+	return nil
+}
+
+func (h *httpCacheBackend) do(ctx context.Context, method, key string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, h.URL+"/"+key, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if h.sign != nil {
+		h.sign(req, body)
+	}
+	return h.Client.Do(req)
+}
+
+// bearerToken returns a request signer setting the token, if any.
+func bearerToken(token string) func(*http.Request, []byte) {
+	return func(req *http.Request, _ []byte) {
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+}
+
+// s3Signer signs S3 requests with AWS Signature Version 4.
+type s3Signer struct {
+	AccessKey    string
+	SecretKey    string
+	SessionToken string
+	Region       string
+
+	now func() time.Time
+}
+
+// Sign sets the SigV4 authorization headers of the request.
+func (s *s3Signer) Sign(req *http.Request, body []byte) {
+	now := time.Now
+	if s.now != nil {
+		now = s.now
+	}
+	t := now().UTC()
+	amzDate := t.Format("20060102T150405Z")
+	date := t.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := "host:" + req.URL.Host + "\nx-amz-content-sha256:" + payloadHash + "\nx-amz-date:" + amzDate + "\n"
+	if s.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.SessionToken)
+		signedHeaders += ";x-amz-security-token"
+		canonicalHeaders += "x-amz-security-token:" + s.SessionToken + "\n"
+	}
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := date + "/" + s.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+s.SecretKey), date)
+	for _, part := range []string{s.Region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", s.AccessKey, scope, signedHeaders, signature))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
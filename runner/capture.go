@@ -0,0 +1,44 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ArtifactsEnv names the env var holding the capture dir of the run,
+// set for steps when --capture-dir is used.
+const ArtifactsEnv = "ATKINS_ARTIFACTS"
+
+// captureOutput appends the full output of a command to <dir>/<stepID>.log.
+// The output interleaves stdout and stderr in the order they were written.
+// Commands of a multi-command step share the same file. Returns the file path.
+func captureOutput(dir, stepID, command, output string) (string, error) {
+	if stepID == "" {
+		stepID = "step"
+	}
+	name := strings.ReplaceAll(stepID, string(filepath.Separator), "_") + ".log"
+	path := filepath.Join(dir, name)
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	sb.WriteString("$ " + command + "\n")
+	sb.WriteString(output)
+	if output != "" && !strings.HasSuffix(output, "\n") {
+		sb.WriteString("\n")
+	}
+
+	if _, err := f.WriteString(sb.String()); err != nil {
+		_ = f.Close()
+		return "", err
+	}
+	return path, f.Close()
+}
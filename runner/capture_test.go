@@ -0,0 +1,33 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCaptureOutput(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "run-id")
+
+	path, err := captureOutput(dir, "jobs.build.steps.0", "echo one", "one\n")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "jobs.build.steps.0.log"), path)
+
+	_, err = captureOutput(dir, "jobs.build.steps.0", "echo two >&2", "two")
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "$ echo one\none\n$ echo two >&2\ntwo\n", string(data))
+}
+
+func TestCaptureOutput_EmptyStepID(t *testing.T) {
+	dir := t.TempDir()
+
+	path, err := captureOutput(dir, "", "true", "")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "step.log"), path)
+}
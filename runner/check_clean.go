@@ -0,0 +1,141 @@
+package runner
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/titpetric/atkins/colors"
+	"github.com/titpetric/atkins/model"
+	"github.com/titpetric/atkins/treeview"
+)
+
+// ErrTreeChanged is returned when a `check_clean: true` step changed the
+// git working tree.
+var ErrTreeChanged = errors.New("working tree changed")
+
+// worktreeSnapshot returns the hash of a git tree holding the working tree
+// of the repository containing dir, including untracked files that aren't
+// ignored. The tree is written with a temporary index, the index of the
+// repository isn't changed.
+func worktreeSnapshot(dir string) (string, error) {
+	index, err := gitOutput(dir, "rev-parse", "--path-format=absolute", "--git-path", "index")
+	if err != nil {
+		return "", fmt.Errorf("check_clean requires a git repository: %w", err)
+	}
+
+	tmp, err := os.CreateTemp("", "atkins-index-")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	// Start from the repository index, so unchanged files aren't hashed again
+	if src, err := os.Open(index); err == nil {
+		_, err = io.Copy(tmp, src)
+		src.Close()
+		if err != nil {
+			tmp.Close()
+			return "", err
+		}
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+
+	gitIndex := func(args ...string) (string, error) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_INDEX_FILE="+tmp.Name())
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				return "", fmt.Errorf("git %s: %s", args[0], msg)
+			}
+			return "", err
+		}
+		return strings.TrimSpace(string(out)), nil
+	}
+	if _, err := gitIndex("add", "--all"); err != nil {
+		return "", err
+	}
+	return gitIndex("write-tree")
+}
+
+// checkClean takes a snapshot of the working tree before a `check_clean:
+// true` step. The returned function compares it with the tree after the
+// step, and fails a passing step that changed it, showing the changed
+// files and the diff under the step.
+func (e *Executor) checkClean(stepCtx *ExecutionContext, step *model.Step, stepNode *treeview.Node) (func(error) error, error) {
+	dir := cmp.Or(stepCtx.Dir, ".")
+	before, err := worktreeSnapshot(dir)
+	if err != nil {
+		stepNode.SetStatus(treeview.StatusFailed)
+		return nil, err
+	}
+
+	return func(stepErr error) error {
+		if stepErr != nil {
+			return stepErr
+		}
+		after, err := worktreeSnapshot(dir)
+		if err != nil {
+			stepNode.SetStatus(treeview.StatusFailed)
+			return err
+		}
+		if after == before {
+			return nil
+		}
+
+		status, _ := gitOutput(dir, "diff", "--name-status", before, after)
+		diff, _ := gitOutput(dir, "diff", before, after)
+
+		stepNode.SetStatus(treeview.StatusFailed)
+		if stepNode != nil {
+			stepNode.SetOutput(treeChangedSummary(status, diff))
+		}
+		stepCtx.Render()
+
+		jobName := ""
+		if stepCtx.Job != nil {
+			jobName = stepCtx.Job.Name
+		}
+		stepCtx.failures.add(FailedStep{
+			Job:   jobName,
+			Step:  step.String(),
+			Error: ErrTreeChanged.Error(),
+		})
+		return fmt.Errorf("step %q: %w:\n%s", step.String(), ErrTreeChanged, status)
+	}, nil
+}
+
+// treeChangedSummary renders the changed files and the start of the diff.
+func treeChangedSummary(status, diff string) []string {
+	lines := []string{colors.BrightRed("working tree changed during the step:")}
+	for _, line := range strings.Split(status, "\n") {
+		lines = append(lines, colors.BrightYellow(line))
+	}
+	diffLines := strings.Split(diff, "\n")
+	if len(diffLines) > FailureContextLines {
+		diffLines = diffLines[:FailureContextLines]
+		diffLines = append(diffLines, "...")
+	}
+	for _, line := range diffLines {
+		switch {
+		case strings.HasPrefix(line, "+") && !strings.HasPrefix(line, "+++"):
+			lines = append(lines, colors.BrightGreen(line))
+		case strings.HasPrefix(line, "-") && !strings.HasPrefix(line, "---"):
+			lines = append(lines, colors.BrightRed(line))
+		default:
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,79 @@
+package runner
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunPipeline_CheckClean(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=atkins", "-c", "user.email=atkins@example.com"}, args...)...)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	git("init", "-q")
+	require.NoError(t, os.WriteFile(".gitignore", []byte("build/\n"), 0o644))
+	require.NoError(t, os.WriteFile("generated.go", []byte("package main\n"), 0o644))
+	require.NoError(t, os.WriteFile("notes.txt", []byte("uncommitted\n"), 0o644))
+	git("add", ".gitignore", "generated.go")
+	git("commit", "-q", "-m", "initial")
+
+	run := func(script string) error {
+		pipelines, err := LoadPipelineFromReader(strings.NewReader(`
+jobs:
+  default:
+    steps:
+      - check_clean: true
+        run: ` + script + `
+`))
+		require.NoError(t, err)
+		return RunPipeline(t.Context(), pipelines[0], PipelineOptions{
+			Jobs:   []string{"default"},
+			Silent: true,
+		})
+	}
+
+	// Unchanged files, existing changes and ignored files pass
+	assert.NoError(t, run("mkdir -p build && echo 'package main' > generated.go && touch build/out"))
+
+	err := run("echo '// stale' >> generated.go")
+	require.ErrorIs(t, err, ErrTreeChanged)
+	assert.Contains(t, err.Error(), "M\tgenerated.go")
+
+	err = run("touch new.go")
+	require.ErrorIs(t, err, ErrTreeChanged)
+	assert.Contains(t, err.Error(), "A\tnew.go")
+
+	// The repository index is left alone
+	out, err := exec.Command("git", "status", "--porcelain").Output()
+	require.NoError(t, err)
+	assert.Equal(t, " M generated.go\n?? new.go\n?? notes.txt\n", string(out))
+}
+
+func TestRunPipeline_CheckCleanNoRepository(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	t.Setenv("GIT_CEILING_DIRECTORIES", filepath.Dir(dir))
+
+	pipelines, err := LoadPipelineFromReader(strings.NewReader(`
+jobs:
+  default:
+    steps:
+      - check_clean: true
+        run: "true"
+`))
+	require.NoError(t, err)
+	err = RunPipeline(t.Context(), pipelines[0], PipelineOptions{
+		Jobs:   []string{"default"},
+		Silent: true,
+	})
+	assert.ErrorContains(t, err, "check_clean requires a git repository")
+}
@@ -0,0 +1,258 @@
+package runner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/titpetric/atkins/eventlog"
+	"github.com/titpetric/atkins/model"
+	"github.com/titpetric/atkins/treeview"
+)
+
+// CheckpointInterval is how often the checkpoint of a running pipeline is
+// written, when steps completed since the last write.
+var CheckpointInterval = 5 * time.Second
+
+// checkpoint records the completed jobs and steps of the run to the
+// checkpoint file, shared across copies. When resuming a run, the jobs
+// and steps completed by the resumed run are skipped.
+type checkpoint struct {
+	mu     sync.Mutex
+	path   string
+	state  *eventlog.Checkpoint
+	resume *eventlog.Checkpoint // Checkpoint of the resumed run, nil when not resuming
+	dirs   []string             // Directories removed when empty after the run passed
+	dirty  bool
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// newCheckpoint starts writing the checkpoint of a run to path. The state
+// of the resumed run is carried over, so the checkpoint is complete when
+// the resumed run stops again before reaching the completed steps.
+func newCheckpoint(path string, state, resume *eventlog.Checkpoint) *checkpoint {
+	if resume != nil {
+		state.Completed = slices.Clone(resume.Completed)
+		state.Steps = maps.Clone(resume.Steps)
+		state.Hashes = maps.Clone(resume.Hashes)
+	}
+	c := &checkpoint{
+		path:   path,
+		state:  state,
+		resume: resume,
+		dirs:   checkpointDirs(filepath.Dir(path)),
+		dirty:  true,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go c.run()
+	return c
+}
+
+// checkpointDirs returns dir and the parents of dir that don't exist yet,
+// deepest first. Passing runs remove them when empty, so runs without a
+// resumable checkpoint don't leave empty directories in the project.
+func checkpointDirs(dir string) []string {
+	dirs := []string{dir}
+	for parent := filepath.Dir(dir); parent != dir; dir, parent = parent, filepath.Dir(parent) {
+		if _, err := os.Stat(parent); err == nil {
+			break
+		}
+		dirs = append(dirs, parent)
+	}
+	return dirs
+}
+
+// loadResume reads the checkpoint of the run to resume. A missing
+// checkpoint resumes nothing, a checkpoint of other jobs is an error.
+func loadResume(path string, jobs []string) (*eventlog.Checkpoint, error) {
+	resume, err := eventlog.ReadCheckpoint(path)
+	if err != nil || resume == nil {
+		return nil, err
+	}
+	if !slices.Equal(resume.Jobs, jobs) {
+		return nil, fmt.Errorf("--resume: run %s ran jobs %s, not %s", resume.RunID, strings.Join(resume.Jobs, ", "), strings.Join(jobs, ", "))
+	}
+	return resume, nil
+}
+
+func (c *checkpoint) run() {
+	defer close(c.done)
+	ticker := time.NewTicker(CheckpointInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			_ = c.flush()
+		}
+	}
+}
+
+// flush writes the checkpoint when it changed since the last write.
+func (c *checkpoint) flush() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.dirty {
+		return nil
+	}
+	c.state.UpdatedAt = time.Now()
+	if err := eventlog.WriteCheckpoint(c.path, c.state); err != nil {
+		return err
+	}
+	c.dirty = false
+	return nil
+}
+
+// close stops writing the checkpoint. The checkpoint of a passed run is
+// removed with the empty checkpoint directories, a failed run keeps it
+// to be resumed.
+func (c *checkpoint) close(passed bool) error {
+	if c == nil {
+		return nil
+	}
+	close(c.stop)
+	<-c.done
+	if passed {
+		if err := os.Remove(c.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		// Directories still holding other checkpoints or run logs are kept
+		for _, dir := range c.dirs {
+			if os.Remove(dir) != nil {
+				break
+			}
+		}
+		return nil
+	}
+	return c.flush()
+}
+
+// completeStep records a passed step. Failed and skipped steps run again
+// when resuming, their `if:` may depend on the failure.
+func (c *checkpoint) completeStep(stepID, result string) {
+	if c == nil || result != StepPassed {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.state.Steps == nil {
+		c.state.Steps = make(map[string]string)
+	}
+	c.state.Steps[stepID] = result
+	c.dirty = true
+}
+
+// completeJob records a completed job, and writes the checkpoint.
+func (c *checkpoint) completeJob(jobName string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	if !slices.Contains(c.state.Completed, jobName) {
+		c.state.Completed = append(c.state.Completed, jobName)
+	}
+	c.dirty = true
+	c.mu.Unlock()
+	_ = c.flush()
+}
+
+// resumedStep returns the result of a step completed by the resumed run.
+func (c *checkpoint) resumedStep(stepID string) (string, bool) {
+	if c == nil || c.resume == nil {
+		return "", false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	result, ok := c.resume.Steps[stepID]
+	return result, ok
+}
+
+// resumedJob records the content hash of a starting job, see jobHash,
+// and returns true when the resumed run completed the job. A job with a
+// different hash than in the resumed run changed, and runs again with
+// all of its steps.
+func (c *checkpoint) resumedJob(jobName, hash string) bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.state.Hashes == nil {
+		c.state.Hashes = make(map[string]string)
+	}
+	c.state.Hashes[jobName] = hash
+	c.dirty = true
+	if c.resume == nil {
+		return false
+	}
+	if c.resume.Hashes[jobName] == hash {
+		return slices.Contains(c.resume.Completed, jobName)
+	}
+
+	prefix := "jobs." + jobName + ".steps."
+	for _, steps := range []map[string]string{c.resume.Steps, c.state.Steps} {
+		maps.DeleteFunc(steps, func(id, _ string) bool {
+			return strings.HasPrefix(id, prefix)
+		})
+	}
+	c.state.Completed = slices.DeleteFunc(c.state.Completed, func(name string) bool {
+		return name == jobName
+	})
+	return false
+}
+
+// jobHash returns the content hash of a job for the checkpoint. It's the
+// cache key of a job with `cache:`, which covers its input files, and
+// otherwise hashes the job definition and the state of cacheState.
+func jobHash(execCtx *ExecutionContext, job *model.Job, cacheKey string) (string, error) {
+	if cacheKey != "" {
+		return cacheKey, nil
+	}
+	definition, err := yaml.Marshal(job)
+	if err != nil {
+		return "", err
+	}
+	vars, env := cacheState(execCtx, job)
+	state, err := yaml.Marshal(map[string]any{"vars": vars, "env": env})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(fmt.Appendf(nil, "job %s\n%s\n%s\n", job.Name, definition, state))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// checkpointed returns the checkpoint recording the steps run in execCtx.
+// Steps of tasks, job iterations and benchmarks run more than once with
+// the same step IDs, and aren't checkpointed.
+func (e *ExecutionContext) checkpointed() *checkpoint {
+	if e.Depth != 1 || e.Job == nil || e.Job.Benchmark != nil {
+		return nil
+	}
+	return e.checkpoint
+}
+
+// markResumed marks a node as completed by the resumed run, with its
+// children skipped.
+func markResumed(node *treeview.Node) {
+	if node == nil {
+		return
+	}
+	node.SetResumed(true)
+	for _, child := range node.GetChildren() {
+		child.SetStatus(treeview.StatusSkipped)
+	}
+}
@@ -0,0 +1,129 @@
+package runner
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/atkins/eventlog"
+	"github.com/titpetric/atkins/model"
+)
+
+func TestRunPipeline_Resume(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	pipelines, err := LoadPipelineFromReader(strings.NewReader(`
+jobs:
+  build:
+    steps:
+      - run: printf build >> trace.txt
+  default:
+    depends_on: build
+    steps:
+      - id: first
+        run: printf first >> trace.txt
+      - run: test -f ok
+      - run: printf last >> trace.txt
+        if: steps.first.result == 'passed' && !job.failed
+`))
+	require.NoError(t, err)
+	pipeline := pipelines[0]
+	opts := PipelineOptions{Jobs: []string{"default"}, Silent: true, CheckpointDir: "checkpoints"}
+	path := eventlog.CheckpointPath(opts.CheckpointDir, pipeline.Name)
+
+	// A failed run keeps the checkpoint
+	require.Error(t, RunPipeline(t.Context(), pipeline, opts))
+	checkpoint, err := eventlog.ReadCheckpoint(path)
+	require.NoError(t, err)
+	require.NotNil(t, checkpoint)
+	assert.Equal(t, []string{"default"}, checkpoint.Jobs)
+	assert.Equal(t, []string{"build"}, checkpoint.Completed)
+	assert.Equal(t, map[string]string{
+		"jobs.build.steps.0":       StepPassed,
+		"jobs.default.steps.first": StepPassed,
+	}, checkpoint.Steps)
+
+	// Resuming skips the completed jobs and steps, and restores their results
+	require.NoError(t, os.WriteFile("ok", nil, 0o644))
+	opts.Resume = true
+	require.NoError(t, RunPipeline(t.Context(), pipeline, opts))
+
+	trace, err := os.ReadFile("trace.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "buildfirstlast", string(trace))
+
+	// A passed run removes the checkpoint
+	checkpoint, err = eventlog.ReadCheckpoint(path)
+	require.NoError(t, err)
+	assert.Nil(t, checkpoint)
+
+	// Checkpoints of other jobs can't be resumed
+	require.NoError(t, eventlog.WriteCheckpoint(path, &eventlog.Checkpoint{RunID: "run-1", Jobs: []string{"build"}}))
+	err = RunPipeline(t.Context(), pipeline, opts)
+	assert.ErrorContains(t, err, "--resume: run run-1 ran jobs build, not default")
+}
+
+func TestRunPipeline_CheckpointDirRemoved(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	pipelines, err := LoadPipelineFromReader(strings.NewReader(`
+jobs:
+  default:
+    steps:
+      - run: "true"
+`))
+	require.NoError(t, err)
+	opts := PipelineOptions{Jobs: []string{"default"}, Silent: true, CheckpointDir: eventlog.CheckpointDir}
+
+	// A passed run leaves no empty directories behind
+	require.NoError(t, RunPipeline(t.Context(), pipelines[0], opts))
+	assert.NoDirExists(t, ".atkins")
+
+	// Directories that existed before the run are kept
+	require.NoError(t, os.MkdirAll(".atkins/runs", 0o755))
+	require.NoError(t, RunPipeline(t.Context(), pipelines[0], opts))
+	assert.DirExists(t, ".atkins/runs")
+	assert.NoDirExists(t, eventlog.CheckpointDir)
+}
+
+func TestRunPipeline_ResumeChanged(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	load := func(message string) *model.Pipeline {
+		pipelines, err := LoadPipelineFromReader(strings.NewReader(`
+vars:
+  message: ` + message + `
+jobs:
+  build:
+    steps:
+      - run: printf '${{ message }}' >> trace.txt
+  default:
+    depends_on: build
+    steps:
+      - run: printf first >> trace.txt
+      - run: test -f ok
+`))
+		require.NoError(t, err)
+		return pipelines[0]
+	}
+	opts := PipelineOptions{Jobs: []string{"default"}, Silent: true, CheckpointDir: "checkpoints"}
+
+	pipeline := load("one")
+	require.Error(t, RunPipeline(t.Context(), pipeline, opts))
+	checkpoint, err := eventlog.ReadCheckpoint(eventlog.CheckpointPath(opts.CheckpointDir, pipeline.Name))
+	require.NoError(t, err)
+	require.NotNil(t, checkpoint)
+	assert.Len(t, checkpoint.Hashes, 2)
+
+	// A changed variable changes the hash of both jobs, which run again
+	require.NoError(t, os.WriteFile("ok", nil, 0o644))
+	opts.Resume = true
+	require.NoError(t, RunPipeline(t.Context(), load("two"), opts))
+
+	trace, err := os.ReadFile("trace.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "onefirsttwofirst", string(trace))
+}
@@ -0,0 +1,42 @@
+package runner
+
+import (
+	"github.com/titpetric/atkins/model"
+)
+
+// CommandJob is the name of the job running a command without the
+// environment of a job, see CommandPipeline.
+const CommandJob = "x"
+
+// CommandPipeline returns a pipeline with a single job running command
+// with the env, vars and dir of job, as the job's steps would, e.g. for
+// `atkins x --env-of test -- go test -run TestX ./...`. Without a job,
+// the command gets the env, vars and dir of the pipeline. The command is
+// connected to the standard streams, so its input and output can be piped.
+func CommandPipeline(pipeline *model.Pipeline, job *model.Job, command string) *model.Pipeline {
+	result := &model.Pipeline{
+		Decl:    pipeline.Decl,
+		Source:  pipeline.Source,
+		Name:    pipeline.Name,
+		Dir:     pipeline.Dir,
+		Lenient: pipeline.Lenient,
+	}
+
+	run := &model.Job{Name: CommandJob}
+	if job != nil {
+		run = &model.Job{
+			Decl:    job.Decl,
+			Dir:     job.Dir,
+			Lenient: job.Lenient,
+			Network: job.Network,
+			Ports:   job.Ports,
+			Inputs:  job.Inputs,
+			Name:    job.Name,
+		}
+	}
+	run.Steps = []*model.Step{{Run: command, Attached: true}}
+
+	result.Jobs = map[string]*model.Job{run.Name: run}
+	result.JobOrder = []string{run.Name}
+	return result
+}
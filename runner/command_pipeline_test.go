@@ -0,0 +1,61 @@
+package runner
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/atkins/model"
+)
+
+func TestCommandPipeline(t *testing.T) {
+	t.Chdir(t.TempDir())
+	require.NoError(t, os.Mkdir("sub", 0o755))
+
+	pipelines, err := LoadPipelineFromReader(strings.NewReader(`
+name: demo
+vars:
+  greeting: hello
+env:
+  vars:
+    TOP: top
+jobs:
+  test:
+    dir: sub
+    env:
+      vars:
+        MSG: ${{ greeting }} ${{ atkins.job }}
+    steps:
+      - run: exit 1
+`))
+	require.NoError(t, err)
+	pipeline := pipelines[0]
+
+	run := func(command *model.Pipeline) error {
+		return RunPipeline(t.Context(), command, PipelineOptions{
+			Jobs:   command.JobOrder,
+			Silent: true,
+		})
+	}
+
+	// The command runs with the env and dir of the job, not its steps
+	command := CommandPipeline(pipeline, pipeline.Jobs["test"], `printf '%s\n' "$MSG $TOP" > out`)
+	require.NoError(t, run(command))
+	out, err := os.ReadFile("sub/out")
+	require.NoError(t, err)
+	assert.Equal(t, "hello test top\n", string(out))
+
+	// Without a job, the command gets the pipeline env
+	command = CommandPipeline(pipeline, nil, `printf '%s\n' "${MSG:-unset} $TOP" > out`)
+	require.NoError(t, run(command))
+	out, err = os.ReadFile("out")
+	require.NoError(t, err)
+	assert.Equal(t, "unset top\n", string(out))
+
+	var execErr ExecError
+	require.ErrorAs(t, run(CommandPipeline(pipeline, nil, "exit 3")), &execErr)
+	assert.Equal(t, 3, execErr.LastExitCode)
+}
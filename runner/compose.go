@@ -0,0 +1,209 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/titpetric/atkins/treeview"
+)
+
+// ComposeRefreshInterval is how often service status is polled while a
+// compose command runs.
+var ComposeRefreshInterval = 2 * time.Second
+
+// ComposeRefreshTimeout limits a `docker compose ps` call, so a hanging
+// docker daemon can't block the step from finishing.
+var ComposeRefreshTimeout = 10 * time.Second
+
+// composeWatchCommands are the compose subcommands that get service
+// status rendered in the tree.
+var composeWatchCommands = map[string]bool{
+	"up": true, "start": true, "restart": true, "logs": true,
+}
+
+// composeValueFlags are compose global flags taking a value.
+var composeValueFlags = map[string]bool{
+	"-f": true, "--file": true, "-p": true, "--project-name": true,
+	"--profile": true, "--project-directory": true, "--env-file": true,
+}
+
+// composeService is a service entry from `docker compose ps --format json`.
+type composeService struct {
+	Service    string `json:"Service"`
+	Name       string `json:"Name"`
+	State      string `json:"State"`
+	Health     string `json:"Health"`
+	ExitCode   int    `json:"ExitCode"`
+	Publishers []struct {
+		URL           string `json:"URL"`
+		TargetPort    int    `json:"TargetPort"`
+		PublishedPort int    `json:"PublishedPort"`
+		Protocol      string `json:"Protocol"`
+	} `json:"Publishers"`
+}
+
+// composePsCommand returns the `ps` command for the first compose
+// command in cmd that starts or follows services, keeping global flags
+// like `-f` and `-p`. It returns an empty string for other commands.
+func composePsCommand(cmd string) string {
+	replacer := strings.NewReplacer("&&", "\n", "||", "\n", ";", "\n", "|", "\n")
+	for _, segment := range strings.Split(replacer.Replace(cmd), "\n") {
+		words := strings.Fields(segment)
+		var start int
+		switch {
+		case len(words) > 1 && words[0] == "docker" && words[1] == "compose":
+			start = 2
+		case len(words) > 0 && words[0] == "docker-compose":
+			start = 1
+		default:
+			continue
+		}
+
+		i := start
+		for i < len(words) && strings.HasPrefix(words[i], "-") {
+			if composeValueFlags[words[i]] {
+				i++
+			}
+			i++
+		}
+		if i < len(words) && composeWatchCommands[words[i]] {
+			return strings.Join(append(words[:i:i], "ps", "--all", "--format", "json"), " ")
+		}
+	}
+	return ""
+}
+
+// parseComposeServices parses `ps --format json` output, which is a JSON
+// array in older compose releases and one object per line in newer ones.
+func parseComposeServices(output string) ([]composeService, error) {
+	output = strings.TrimSpace(output)
+	if output == "" {
+		return nil, nil
+	}
+
+	var services []composeService
+	if strings.HasPrefix(output, "[") {
+		if err := json.Unmarshal([]byte(output), &services); err != nil {
+			return nil, err
+		}
+	} else {
+		for _, line := range strings.Split(output, "\n") {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			var service composeService
+			if err := json.Unmarshal([]byte(line), &service); err != nil {
+				return nil, err
+			}
+			services = append(services, service)
+		}
+	}
+
+	sort.Slice(services, func(i, j int) bool {
+		return services[i].Service < services[j].Service
+	})
+	return services, nil
+}
+
+// Label returns the service name with state, health and published ports.
+func (s composeService) Label() string {
+	name := s.Service
+	if name == "" {
+		name = s.Name
+	}
+	parts := []string{name, s.State}
+	if s.Health != "" {
+		parts = append(parts, "("+s.Health+")")
+	}
+	var ports []string
+	for _, p := range s.Publishers {
+		if p.PublishedPort == 0 {
+			continue
+		}
+		ports = appendUnique(ports, fmt.Sprintf("%d->%d/%s", p.PublishedPort, p.TargetPort, p.Protocol))
+	}
+	if len(ports) > 0 {
+		parts = append(parts, strings.Join(ports, ", "))
+	}
+	return strings.Join(parts, " ")
+}
+
+// Status maps the service state to a tree node status.
+func (s composeService) Status() treeview.Status {
+	switch {
+	case s.Health == "unhealthy", s.State == "dead":
+		return treeview.StatusFailed
+	case s.State == "exited" && s.ExitCode != 0:
+		return treeview.StatusFailed
+	case s.State == "exited":
+		return treeview.StatusPassed
+	case s.Health == "starting", s.State == "created", s.State == "restarting":
+		return treeview.StatusRunning
+	case s.State == "running":
+		return treeview.StatusPassed
+	}
+	return treeview.StatusPending
+}
+
+// watchCompose renders the status of compose services as children of
+// node while a compose command runs. Status is refreshed periodically,
+// and once more when the returned stop function is called.
+func watchCompose(ctx context.Context, execCtx *ExecutionContext, node *treeview.Node, cmd string) (stop func()) {
+	psCmd := composePsCommand(cmd)
+	if psCmd == "" || node == nil {
+		return func() {}
+	}
+
+	refresh := func() {
+		exec := execCtx.newExecutor()
+		// The final refresh runs after the step, when ctx may be done
+		psCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), ComposeRefreshTimeout)
+		defer cancel()
+		result, err := execCtx.runProcess(psCtx, exec, exec.ShellCommand(psCmd), psCmd)
+		if err != nil || !result.Success() {
+			return
+		}
+		services, err := parseComposeServices(result.Output())
+		if err != nil || len(services) == 0 {
+			return
+		}
+
+		children := make([]*treeview.Node, 0, len(services))
+		for _, service := range services {
+			child := treeview.NewNode(service.Label())
+			child.SetStatus(service.Status())
+			children = append(children, child)
+		}
+		node.ClearChildren()
+		node.AddChildren(children...)
+		if execCtx.Display != nil && execCtx.Builder != nil {
+			execCtx.Render()
+		}
+	}
+
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		ticker := time.NewTicker(ComposeRefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				refresh()
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-finished
+		refresh()
+	}
+}
@@ -0,0 +1,101 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/atkins/treeview"
+)
+
+func TestComposePsCommand(t *testing.T) {
+	tests := map[string]string{
+		"docker compose up -d":                          "docker compose ps --all --format json",
+		"docker compose -f dev.yml -p app up --wait":    "docker compose -f dev.yml -p app ps --all --format json",
+		"docker-compose logs -f":                        "docker-compose ps --all --format json",
+		"cd app && docker compose --profile db restart": "docker compose --profile db ps --all --format json",
+		"docker compose build":                          "",
+		"docker compose down":                           "",
+		"docker ps":                                     "",
+		"echo docker compose up":                        "",
+	}
+	for cmd, want := range tests {
+		assert.Equal(t, want, composePsCommand(cmd), cmd)
+	}
+}
+
+func TestParseComposeServices(t *testing.T) {
+	t.Run("json lines", func(t *testing.T) {
+		output := `{"Service":"web","State":"running","Health":"healthy","Publishers":[{"URL":"0.0.0.0","TargetPort":80,"PublishedPort":8080,"Protocol":"tcp"},{"URL":"::","TargetPort":80,"PublishedPort":8080,"Protocol":"tcp"}]}
+{"Service":"db","State":"running","Health":"starting"}
+`
+		services, err := parseComposeServices(output)
+		require.NoError(t, err)
+		require.Len(t, services, 2)
+
+		assert.Equal(t, "db running (starting)", services[0].Label())
+		assert.Equal(t, treeview.StatusRunning, services[0].Status())
+		assert.Equal(t, "web running (healthy) 8080->80/tcp", services[1].Label())
+		assert.Equal(t, treeview.StatusPassed, services[1].Status())
+	})
+
+	t.Run("json array", func(t *testing.T) {
+		services, err := parseComposeServices(`[{"Service":"migrate","State":"exited","ExitCode":1}]`)
+		require.NoError(t, err)
+		require.Len(t, services, 1)
+		assert.Equal(t, "migrate exited", services[0].Label())
+		assert.Equal(t, treeview.StatusFailed, services[0].Status())
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		services, err := parseComposeServices("")
+		require.NoError(t, err)
+		assert.Empty(t, services)
+	})
+}
+
+func TestWatchCompose(t *testing.T) {
+	binDir := t.TempDir()
+	script := "#!/bin/sh\necho '{\"Service\":\"web\",\"State\":\"running\"}'\n"
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "docker"), []byte(script), 0o755))
+
+	execCtx := &ExecutionContext{
+		Env: Env{"PATH": binDir + string(os.PathListSeparator) + os.Getenv("PATH")},
+		Dir: t.TempDir(),
+	}
+	node := treeview.NewNode("docker compose up -d")
+
+	stop := watchCompose(t.Context(), execCtx, node, "docker compose up -d")
+	stop()
+
+	children := node.GetChildren()
+	require.Len(t, children, 1)
+	assert.Equal(t, "web running", children[0].GetName())
+	assert.Equal(t, treeview.StatusPassed, children[0].GetStatus())
+}
+
+func TestWatchCompose_Timeout(t *testing.T) {
+	binDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "docker"), []byte("#!/bin/sh\nexec sleep 30\n"), 0o755))
+
+	timeout := ComposeRefreshTimeout
+	ComposeRefreshTimeout = 100 * time.Millisecond
+	t.Cleanup(func() { ComposeRefreshTimeout = timeout })
+
+	execCtx := &ExecutionContext{
+		Env: Env{"PATH": binDir + string(os.PathListSeparator) + os.Getenv("PATH")},
+		Dir: t.TempDir(),
+	}
+	node := treeview.NewNode("docker compose up -d")
+
+	start := time.Now()
+	stop := watchCompose(t.Context(), execCtx, node, "docker compose up -d")
+	stop()
+
+	assert.Less(t, time.Since(start), 5*time.Second)
+	assert.Empty(t, node.GetChildren())
+}
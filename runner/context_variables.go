@@ -40,9 +40,10 @@ func newPendingPromise(raw any) *VarPromise {
 
 // ContextVariables provides thread-safe variable storage with Promise-based lazy evaluation.
 type ContextVariables struct {
-	promises map[string]*VarPromise
-	resolver func(string) (string, error)
-	mu       sync.Mutex
+	promises      map[string]*VarPromise
+	resolver      func(string) (string, error)
+	valueResolver func(any) (any, error)
+	mu            sync.Mutex
 }
 
 // NewContextVariables creates a ContextVariables from a map of evaluated values.
@@ -73,6 +74,16 @@ func NewContextVariablesWithResolver(pending map[string]any, resolver func(strin
 	return cv
 }
 
+// NewContextVariablesWithValueResolver creates a ContextVariables with pending
+// values that are evaluated lazily on first access via Get(). Unlike
+// NewContextVariablesWithResolver, the resolver receives every pending value,
+// including nested maps and slices, and may return non-string values.
+func NewContextVariablesWithValueResolver(pending map[string]any, resolver func(any) (any, error)) *ContextVariables {
+	cv := NewContextVariablesWithResolver(pending, nil)
+	cv.valueResolver = resolver
+	return cv
+}
+
 // SetResolver updates the resolver function. Used when the resolver
 // needs a reference to the ContextVariables itself (circular setup).
 func (v *ContextVariables) SetResolver(resolver func(string) (string, error)) {
@@ -85,7 +96,7 @@ func (v *ContextVariables) SetResolver(resolver func(string) (string, error)) {
 func (v *ContextVariables) Get(key string) any {
 	v.mu.Lock()
 	promise, ok := v.promises[key]
-	resolver := v.resolver
+	resolver := v.resolveFunc()
 	v.mu.Unlock()
 
 	if !ok {
@@ -96,10 +107,28 @@ func (v *ContextVariables) Get(key string) any {
 	return val
 }
 
+// resolveFunc returns the function resolving pending values, or nil.
+// String resolvers only apply to string values. Must be called with v.mu held.
+func (v *ContextVariables) resolveFunc() func(any) (any, error) {
+	if v.valueResolver != nil {
+		return v.valueResolver
+	}
+	resolver := v.resolver
+	if resolver == nil {
+		return nil
+	}
+	return func(raw any) (any, error) {
+		if s, ok := raw.(string); ok {
+			return resolver(s)
+		}
+		return raw, nil
+	}
+}
+
 // resolve evaluates a single promise, detecting cycles via the resolving state.
 // The resolver may call back into Get() for other variables (dependency chain).
 // If re-entry hits the same promise (cycle), it returns an error instead of deadlocking.
-func (v *ContextVariables) resolve(p *VarPromise, resolver func(string) (string, error)) (any, error) {
+func (v *ContextVariables) resolve(p *VarPromise, resolver func(any) (any, error)) (any, error) {
 	p.mu.Lock()
 
 	switch p.state {
@@ -127,10 +156,10 @@ func (v *ContextVariables) resolve(p *VarPromise, resolver func(string) (string,
 		var value any
 		var err error
 
-		if strVal, isStr := raw.(string); isStr && resolver != nil {
-			resolved, resolveErr := resolver(strVal)
+		if resolver != nil {
+			resolved, resolveErr := resolver(raw)
 			if resolveErr != nil {
-				value = strVal // On error, keep the raw string
+				value = raw // On error, keep the raw value
 				err = resolveErr
 			} else {
 				value = resolved
@@ -168,8 +197,9 @@ func (v *ContextVariables) Clone() model.VariableStorage {
 	defer v.mu.Unlock()
 
 	clone := &ContextVariables{
-		promises: make(map[string]*VarPromise, len(v.promises)),
-		resolver: v.resolver,
+		promises:      make(map[string]*VarPromise, len(v.promises)),
+		resolver:      v.resolver,
+		valueResolver: v.valueResolver,
 	}
 	for k, p := range v.promises {
 		p.mu.Lock()
@@ -204,7 +234,7 @@ func (v *ContextVariables) ResolveAll() error {
 	for k := range v.promises {
 		keys = append(keys, k)
 	}
-	resolver := v.resolver
+	resolver := v.resolveFunc()
 	v.mu.Unlock()
 
 	for _, key := range keys {
@@ -0,0 +1,59 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"golang.org/x/term"
+
+	"github.com/titpetric/atkins/colors"
+	"github.com/titpetric/atkins/psexec"
+)
+
+// OnFailureShell opens an interactive shell when a step fails.
+const OnFailureShell = "shell"
+
+// debugShell pauses the run after a failed command and opens an
+// interactive shell in the step dir with the step env, so the failure
+// can be inspected. The run continues handling the failure when the
+// shell exits. Without a terminal, or when the run was cancelled,
+// it does nothing.
+func (e *Executor) debugShell(ctx context.Context, execCtx *ExecutionContext, cmd string, exitCode int) {
+	if ctx != nil && ctx.Err() != nil {
+		return
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return
+	}
+
+	e.ttyMu.Lock()
+	defer e.ttyMu.Unlock()
+
+	shell := os.Getenv("SHELL")
+	if shell == "" {
+		shell = "bash"
+	}
+	dir := execCtx.Dir
+	if dir == "" {
+		dir, _ = os.Getwd()
+	}
+
+	// The shell outlives the job timeout while the user inspects the failure
+	shellCtx := context.Background()
+	if ctx != nil {
+		shellCtx = context.WithoutCancel(ctx)
+	}
+
+	execCtx.Display.Suspend(func() {
+		fmt.Printf("\n%s step failed with exit code %d:\n  %s %s\n", colors.BrightRed("✗"), exitCode, colors.Dim("$"), cmd)
+		fmt.Printf("%s opening %s in %s, exit the shell to continue\n\n", colors.BrightYellow("DEBUG:"), shell, dir)
+
+		executor := psexec.NewWithOptions(&psexec.Options{
+			DefaultDir: dir,
+			DefaultEnv: execCtx.Env.Environ(),
+		})
+		executor.Run(shellCtx, &psexec.Command{Name: shell, Interactive: true})
+		fmt.Println()
+	})
+}
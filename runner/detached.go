@@ -0,0 +1,108 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/titpetric/atkins/model"
+)
+
+// PanicError is returned for a detached step or iteration that panicked.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+// Error returns the panic value.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// detachedGroup runs detached steps and loop iterations concurrently.
+// With fail-fast, the first failure cancels the context of the others,
+// otherwise all of them run to completion. Panics are recovered and
+// returned as a *PanicError. The group can be reused after Wait.
+type detachedGroup struct {
+	parent   context.Context
+	failFast bool
+	limit    int
+
+	group *errgroup.Group
+	ctx   context.Context
+
+	mu  sync.Mutex
+	err error
+}
+
+// newDetachedGroup creates a group running at most limit goroutines at
+// once, without a limit when zero.
+func newDetachedGroup(ctx context.Context, failFast bool, limit int) *detachedGroup {
+	return &detachedGroup{parent: ctx, failFast: failFast, limit: limit}
+}
+
+// Go runs fn in a goroutine with the context of the group.
+func (g *detachedGroup) Go(fn func(ctx context.Context) error) {
+	if g.group == nil {
+		g.group, g.ctx = errgroup.WithContext(g.parent)
+		if g.limit > 0 {
+			g.group.SetLimit(g.limit)
+		}
+	}
+	ctx := g.ctx
+	g.group.Go(func() (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = &PanicError{Value: r, Stack: debug.Stack()}
+			}
+			if err != nil && !g.failFast {
+				// Keep the others running, report the first failure from Wait
+				g.mu.Lock()
+				if g.err == nil {
+					g.err = err
+				}
+				g.mu.Unlock()
+				err = nil
+			}
+		}()
+		return fn(ctx)
+	})
+}
+
+// Err returns the error of the context of the running goroutines, set
+// after a fail-fast failure, when no more goroutines should be started.
+func (g *detachedGroup) Err() error {
+	if g.group == nil {
+		return nil
+	}
+	return g.ctx.Err()
+}
+
+// Wait waits for all started goroutines and returns the first failure.
+func (g *detachedGroup) Wait() error {
+	if g.group == nil {
+		return nil
+	}
+	err := g.group.Wait()
+	g.group, g.ctx = nil, nil
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if err == nil {
+		err = g.err
+	}
+	g.err = nil
+	return err
+}
+
+// failFast returns whether a failure cancels the other detached steps
+// or iterations of the job, def unless the job sets fail_fast.
+func failFast(job *model.Job, def bool) bool {
+	if job == nil || job.FailFast == nil {
+		return def
+	}
+	return *job.FailFast
+}
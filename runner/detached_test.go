@@ -0,0 +1,153 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/atkins/model"
+	"github.com/titpetric/atkins/treeview"
+)
+
+func TestDetachedGroup(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	t.Run("fail-fast cancels the others", func(t *testing.T) {
+		group := newDetachedGroup(context.Background(), true, 0)
+		group.Go(func(ctx context.Context) error { return errFailed })
+		group.Go(func(ctx context.Context) error {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(5 * time.Second):
+				return nil
+			}
+		})
+		assert.ErrorIs(t, group.Wait(), errFailed)
+	})
+
+	t.Run("without fail-fast all complete", func(t *testing.T) {
+		var completed atomic.Int32
+		group := newDetachedGroup(context.Background(), false, 2)
+		group.Go(func(ctx context.Context) error { return errFailed })
+		for range 3 {
+			group.Go(func(ctx context.Context) error {
+				time.Sleep(10 * time.Millisecond)
+				if ctx.Err() == nil {
+					completed.Add(1)
+				}
+				return nil
+			})
+		}
+		assert.ErrorIs(t, group.Wait(), errFailed)
+		assert.Equal(t, int32(3), completed.Load())
+	})
+
+	t.Run("fail-fast stops starting others", func(t *testing.T) {
+		group := newDetachedGroup(context.Background(), true, 0)
+		assert.NoError(t, group.Err())
+		group.Go(func(ctx context.Context) error { return errFailed })
+		require.Eventually(t, func() bool { return group.Err() != nil }, time.Second, time.Millisecond)
+		assert.ErrorIs(t, group.Wait(), errFailed)
+		assert.NoError(t, group.Err(), "a new round gets a new context")
+
+		group = newDetachedGroup(context.Background(), false, 0)
+		group.Go(func(ctx context.Context) error { return errFailed })
+		time.Sleep(10 * time.Millisecond)
+		assert.NoError(t, group.Err())
+		assert.ErrorIs(t, group.Wait(), errFailed)
+	})
+
+	t.Run("panics are recovered", func(t *testing.T) {
+		group := newDetachedGroup(context.Background(), true, 0)
+		group.Go(func(ctx context.Context) error { panic("boom") })
+
+		var panicErr *PanicError
+		require.ErrorAs(t, group.Wait(), &panicErr)
+		assert.Equal(t, "panic: boom", panicErr.Error())
+		assert.NotEmpty(t, panicErr.Stack)
+	})
+
+	t.Run("reusable after Wait", func(t *testing.T) {
+		group := newDetachedGroup(context.Background(), true, 0)
+		assert.NoError(t, group.Wait())
+
+		group.Go(func(ctx context.Context) error { return errFailed })
+		assert.ErrorIs(t, group.Wait(), errFailed)
+
+		group.Go(func(ctx context.Context) error { return ctx.Err() })
+		assert.NoError(t, group.Wait(), "a new round gets a new context")
+	})
+}
+
+func TestFailFast(t *testing.T) {
+	off := false
+	assert.True(t, failFast(nil, true))
+	assert.False(t, failFast(&model.Job{}, false))
+	assert.False(t, failFast(&model.Job{FailFast: &off}, true))
+}
+
+func TestReadyWriter(t *testing.T) {
+	matched := func(w *readyWriter) bool {
+		select {
+		case <-w.matched:
+			return true
+		default:
+			return false
+		}
+	}
+
+	t.Run("matches lines", func(t *testing.T) {
+		w := newReadyWriter(regexp.MustCompile(`^listening on \d+$`))
+		w.Write([]byte("starting\nlisten"))
+		assert.False(t, matched(w))
+		w.Write([]byte("ing on 8080\r\nserving\n"))
+		assert.True(t, matched(w))
+		w.Write([]byte("listening on 8081\n"))
+		assert.Equal(t, "starting\nlistening on 8080\r\nserving\nlistening on 8081\n", w.String())
+	})
+
+	t.Run("matches a prompt without a newline", func(t *testing.T) {
+		w := newReadyWriter(regexp.MustCompile(`ready> $`))
+		w.Write([]byte("ready> "))
+		assert.True(t, matched(w))
+	})
+
+	t.Run("keeps the tail of the output", func(t *testing.T) {
+		w := newReadyWriter(regexp.MustCompile(`never`))
+		line := strings.Repeat("x", 1023) + "\n"
+		for range 2 * readyWriterTail / len(line) {
+			w.Write([]byte(line))
+		}
+		w.Write([]byte("last\n"))
+		assert.False(t, matched(w))
+		assert.Len(t, w.String(), readyWriterTail)
+		assert.True(t, strings.HasSuffix(w.String(), "x\nlast\n"))
+		assert.Empty(t, w.line)
+	})
+}
+
+func TestJobStepNode(t *testing.T) {
+	steps := []*model.Step{{Run: "echo one"}, {Run: "echo two", Detach: true, Ready: &model.Ready{Log: "ready"}}}
+	jobNode := &treeview.TreeNode{Node: treeview.NewNode("job")}
+	buildAndAddStepsToJob(jobNode, steps, nil)
+	execCtx := &ExecutionContext{CurrentJob: jobNode}
+
+	assert.Same(t, jobNode.GetChildren()[1].Node, jobStepNode(execCtx, steps[1], 1))
+
+	// Steps without a node get one on-demand, e.g. for the step of a simple task
+	extra := &model.Step{Run: "echo three"}
+	node := jobStepNode(execCtx, extra, 2)
+	require.NotNil(t, node)
+	assert.Len(t, jobNode.GetChildren(), 3)
+	assert.Same(t, node, jobNode.GetChildren()[2].Node)
+
+	assert.Nil(t, jobStepNode(&ExecutionContext{}, extra, 0))
+}
@@ -0,0 +1,147 @@
+package runner
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/titpetric/atkins/model"
+)
+
+// PipelineChange describes a single structural difference between two pipelines.
+type PipelineChange struct {
+	Path   string // Normalized path, e.g. jobs.build.steps.0.run
+	Before string // Empty when the path was added
+	After  string // Empty when the path was removed
+}
+
+// DiffPipelines compares the jobs, steps and vars of two pipelines after normalization.
+// Shorthand forms (scalar jobs, job-level cmd/run, tasks vs jobs, cmds vs steps) are
+// normalized so that only semantic changes are reported. Steps are addressed by their
+// explicit id when set, otherwise by index. Changes are sorted by path.
+func DiffPipelines(before, after *model.Pipeline) ([]PipelineChange, error) {
+	a, err := flattenPipeline(before)
+	if err != nil {
+		return nil, err
+	}
+	b, err := flattenPipeline(after)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := slices.Collect(maps.Keys(a))
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+
+	var changes []PipelineChange
+	for _, key := range keys {
+		if a[key] != b[key] {
+			changes = append(changes, PipelineChange{
+				Path:   key,
+				Before: a[key],
+				After:  b[key],
+			})
+		}
+	}
+	return changes, nil
+}
+
+// flattenPipeline converts a pipeline into a flat map of normalized paths to values.
+func flattenPipeline(pipeline *model.Pipeline) (map[string]string, error) {
+	result := make(map[string]string)
+	if pipeline == nil {
+		return result, nil
+	}
+
+	if pipeline.Decl != nil {
+		if err := flattenValue(result, "", pipeline.Decl); err != nil {
+			return nil, err
+		}
+	}
+
+	for name, job := range pipeline.GetJobs() {
+		if job == nil {
+			continue
+		}
+		prefix := "jobs." + name
+
+		// Steps are flattened separately below to normalize cmd/cmds/steps.
+		normalized := *job
+		normalized.Cmd, normalized.Run = "", ""
+		normalized.Cmds, normalized.Steps = nil, nil
+		if err := flattenValue(result, prefix, &normalized); err != nil {
+			return nil, err
+		}
+
+		for idx, step := range job.Children() {
+			if step == nil {
+				continue
+			}
+			key := strconv.Itoa(idx)
+			if step.ID != "" {
+				key = step.ID
+			}
+			// String shorthand steps use the command as the name.
+			normalizedStep := *step
+			if normalizedStep.Name == normalizedStep.Run {
+				normalizedStep.Name = ""
+			}
+			if err := flattenValue(result, prefix+".steps."+key, &normalizedStep); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// flattenValue encodes v as yaml and adds each scalar leaf to result under its path.
+func flattenValue(result map[string]string, prefix string, v any) error {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	var node any
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return err
+	}
+
+	flattenNode(result, prefix, node)
+	return nil
+}
+
+func flattenNode(result map[string]string, prefix string, node any) {
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + "." + key
+	}
+
+	switch v := node.(type) {
+	case map[string]any:
+		for key, value := range v {
+			// Embedded declarations (vars, env, include) are hoisted to the parent.
+			if key == "decl" {
+				flattenNode(result, prefix, value)
+				continue
+			}
+			flattenNode(result, join(key), value)
+		}
+	case []any:
+		for idx, value := range v {
+			flattenNode(result, join(strconv.Itoa(idx)), value)
+		}
+	case nil:
+	default:
+		result[prefix] = fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,72 @@
+package runner_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/atkins/runner"
+)
+
+func TestDiffPipelines(t *testing.T) {
+	before, err := runner.LoadPipelineFromReader(strings.NewReader(`
+vars:
+  name: world
+jobs:
+  build:
+    cmd: go build
+  test:
+    desc: Run tests
+    vars:
+      race: false
+    steps:
+      - id: unit
+        run: go test ./...
+      - echo done
+`))
+	require.NoError(t, err)
+
+	after, err := runner.LoadPipelineFromReader(strings.NewReader(`
+vars:
+  name: moon
+tasks:
+  build:
+    run: go build
+  test:
+    desc: Run tests
+    vars:
+      race: true
+    cmds:
+      - echo first
+      - id: unit
+        run: go test -race ./...
+`))
+	require.NoError(t, err)
+
+	changes, err := runner.DiffPipelines(before[0], after[0])
+	require.NoError(t, err)
+
+	assert.Equal(t, []runner.PipelineChange{
+		{Path: "jobs.test.steps.0.run", After: "echo first"},
+		{Path: "jobs.test.steps.1.run", Before: "echo done"},
+		{Path: "jobs.test.steps.unit.run", Before: "go test ./...", After: "go test -race ./..."},
+		{Path: "jobs.test.vars.race", Before: "false", After: "true"},
+		{Path: "vars.name", Before: "world", After: "moon"},
+	}, changes)
+}
+
+func TestDiffPipelines_Identical(t *testing.T) {
+	pipelines, err := runner.LoadPipelineFromReader(strings.NewReader(`
+jobs:
+  default:
+    steps:
+      - run: echo hello
+`))
+	require.NoError(t, err)
+
+	changes, err := runner.DiffPipelines(pipelines[0], pipelines[0])
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+}
@@ -2,8 +2,11 @@ package runner
 
 import (
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 )
 
 // ConfigNames are the default config file names to search for, in order of preference.
@@ -51,3 +54,42 @@ func DiscoverConfigFromCwd() (configPath, configDir string, err error) {
 	}
 	return DiscoverConfig(cwd)
 }
+
+// RecursiveMarkers are the files marking a directory for a recursive run,
+// besides the config files.
+var RecursiveMarkers = []string{"go.mod"}
+
+// recursiveSkipDirs are folders not searched in a recursive run.
+var recursiveSkipDirs = []string{"vendor", "node_modules", "testdata"}
+
+// DiscoverRecursive returns the directories under root, including root,
+// containing a config file or one of the RecursiveMarkers, in walk order.
+// Hidden folders and dependency folders like vendor/ aren't searched.
+func DiscoverRecursive(root string) ([]string, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path: %w", err)
+	}
+
+	markers := append(slices.Clone(ConfigNames), RecursiveMarkers...)
+	var dirs []string
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != root && (strings.HasPrefix(d.Name(), ".") || slices.Contains(recursiveSkipDirs, d.Name())) {
+			return filepath.SkipDir
+		}
+		for _, marker := range markers {
+			if info, err := os.Stat(filepath.Join(path, marker)); err == nil && !info.IsDir() {
+				dirs = append(dirs, path)
+				break
+			}
+		}
+		return nil
+	})
+	return dirs, err
+}
@@ -158,3 +158,30 @@ func TestDiscoverConfig_DeepNesting(t *testing.T) {
 	assert.Equal(t, configPath, foundPath)
 	assert.Equal(t, tmpDir, foundDir)
 }
+
+func TestDiscoverRecursive(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := []string{
+		".atkins.yml",
+		"services/api/.atkins.yml",
+		"services/api/go.mod",
+		"services/web/package.json",
+		"tools/gen/go.mod",
+		"tools/gen/testdata/go.mod",
+		"vendor/example.com/lib/go.mod",
+		".git/modules/go.mod",
+	}
+	for _, file := range files {
+		path := filepath.Join(tmpDir, file)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, nil, 0o644))
+	}
+
+	dirs, err := runner.DiscoverRecursive(tmpDir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		tmpDir,
+		filepath.Join(tmpDir, "services", "api"),
+		filepath.Join(tmpDir, "tools", "gen"),
+	}, dirs)
+}
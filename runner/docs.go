@@ -0,0 +1,241 @@
+package runner
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/titpetric/atkins/model"
+)
+
+// DocsHeader marks generated documentation, so it isn't edited by hand.
+const DocsHeader = "<!-- Generated by atkins docs, don't edit. -->"
+
+// PipelineDocs returns Markdown documentation of the effective pipelines:
+// the jobs of the main pipeline and of each skill with their descriptions,
+// inputs, variables and dependencies, a graph of the dependencies and the
+// file each skill was loaded from. Sources are shown relative to dir.
+func PipelineDocs(pipelines []*model.Pipeline, dir string) string {
+	main, skills := separatePipelines(pipelines)
+
+	var b strings.Builder
+	title := "Pipeline"
+	if main != nil && main.Name != "" {
+		title = main.Name
+	}
+	fmt.Fprintf(&b, "# %s\n\n%s\n", title, DocsHeader)
+
+	if main != nil {
+		if source := docsSource(main.Source, dir); source != "" {
+			fmt.Fprintf(&b, "\nDefined in `%s`. Run a job with `atkins <job>`, list the jobs with `atkins -l`.\n", source)
+		}
+		writeDocsEnv(&b, main.Decl, "##")
+		if main.HasJobs() {
+			b.WriteString("\n## Jobs\n")
+			writeDocsJobs(&b, main)
+		}
+	}
+
+	if graph := docsGraph(append([]*model.Pipeline{main}, skills...)); graph != "" {
+		b.WriteString("\n## Dependencies\n\n" + graph)
+	}
+
+	var listed []*model.Pipeline
+	for _, skill := range skills {
+		if skill.HasJobs() {
+			listed = append(listed, skill)
+		}
+	}
+	if len(listed) == 0 {
+		return b.String()
+	}
+
+	b.WriteString("\n## Skills\n\n| Skill | Source | Jobs |\n|---|---|---|\n")
+	for _, skill := range listed {
+		source := "`" + docsSource(skill.Source, dir) + "`"
+		if skill.Extends != "" {
+			source += ", extends `" + skill.Extends + "`"
+		}
+		fmt.Fprintf(&b, "| `%s` | %s | %d |\n", skill.ID, source, len(skill.GetJobs()))
+	}
+	for _, skill := range listed {
+		fmt.Fprintf(&b, "\n### Skill `%s`\n", skill.ID)
+		if skill.Name != "" && skill.Name != skill.ID {
+			fmt.Fprintf(&b, "\n%s\n", skill.Name)
+		}
+		writeDocsEnv(&b, skill.Decl, "####")
+		writeDocsJobs(&b, skill)
+	}
+	return b.String()
+}
+
+// writeDocsJobs writes a section per job of a pipeline, in listing order.
+func writeDocsJobs(b *strings.Builder, p *model.Pipeline) {
+	jobs := p.GetJobs()
+	level := "###"
+	if p.ID != "" {
+		level = "####"
+	}
+	for _, name := range listedJobNames(p) {
+		job := jobs[name]
+		fmt.Fprintf(b, "\n%s `%s`\n", level, docsJobName(p, name))
+		if job.Desc != "" {
+			fmt.Fprintf(b, "\n%s\n", job.Desc)
+		}
+
+		var facts []string
+		if len(job.Aliases) > 0 {
+			facts = append(facts, "Aliases: "+docsCodeList(job.Aliases))
+		}
+		if job.Stage != "" {
+			facts = append(facts, "Stage: `"+job.Stage+"`")
+		}
+		if deps := docsDependencies(p, job); len(deps) > 0 {
+			facts = append(facts, "Depends on: "+docsCodeList(deps))
+		}
+		if len(job.Services) > 0 {
+			facts = append(facts, "Services: "+docsCodeList(job.Services))
+		}
+		if len(job.Requires.Vars) > 0 {
+			facts = append(facts, "Requires variables: "+docsCodeList(job.Requires.Vars))
+		}
+		if len(job.Requires.Env) > 0 {
+			facts = append(facts, "Requires environment: "+docsCodeList(job.Requires.Env))
+		}
+		if len(job.Requires.Commands) > 0 {
+			facts = append(facts, "Requires commands: "+docsCodeList(job.Requires.Commands))
+		}
+		if job.Timeout != "" {
+			facts = append(facts, "Timeout: `"+job.Timeout+"`")
+		}
+		if len(facts) > 0 {
+			b.WriteString("\n- " + strings.Join(facts, "\n- ") + "\n")
+		}
+
+		if len(job.Inputs) > 0 {
+			b.WriteString("\n| Input | Type | Required | Default | Description |\n|---|---|---|---|---|\n")
+			for _, input := range slices.Sorted(maps.Keys(job.Inputs)) {
+				schema := job.Inputs[input]
+				required := ""
+				if schema.Required {
+					required = "yes"
+				}
+				def := ""
+				if schema.Default != nil {
+					def = "`" + markdownCell(docsValue(schema.Default)) + "`"
+				}
+				fmt.Fprintf(b, "| `%s` | %s | %s | %s | %s |\n", input, cmp.Or(schema.Type, "any"), required, def, markdownCell(schema.Desc))
+			}
+		}
+		if job.Decl != nil && len(job.Vars) > 0 {
+			b.WriteString("\n| Variable | Value |\n|---|---|\n")
+			for _, name := range slices.Sorted(maps.Keys(job.Vars)) {
+				fmt.Fprintf(b, "| `%s` | `%s` |\n", name, markdownCell(docsValue(job.Vars[name])))
+			}
+		}
+		writeDocsEnv(b, job.Decl, "")
+	}
+}
+
+// writeDocsEnv writes the documented environment variables of a
+// declaration as a table, under a heading of level if given.
+func writeDocsEnv(b *strings.Builder, decl *model.Decl, level string) {
+	if decl == nil || decl.Env == nil || len(decl.Env.Doc) == 0 {
+		return
+	}
+	if level != "" {
+		fmt.Fprintf(b, "\n%s Environment\n", level)
+	}
+	b.WriteString("\n| Environment | Description |\n|---|---|\n")
+	for _, name := range slices.Sorted(maps.Keys(decl.Env.Doc)) {
+		fmt.Fprintf(b, "| `$%s` | %s |\n", name, markdownCell(decl.Env.Doc[name]))
+	}
+}
+
+// docsGraph returns a mermaid flowchart of the job dependencies, or an
+// empty string if no job depends on another.
+func docsGraph(pipelines []*model.Pipeline) string {
+	var nodes []string
+	ids := make(map[string]string)
+	node := func(name string) string {
+		if id, ok := ids[name]; ok {
+			return id
+		}
+		id := "j" + strconv.Itoa(len(nodes))
+		ids[name] = id
+		nodes = append(nodes, fmt.Sprintf("  %s[%q]", id, name))
+		return id
+	}
+
+	var edges []string
+	for _, p := range pipelines {
+		if p == nil {
+			continue
+		}
+		jobs := p.GetJobs()
+		for _, name := range listedJobNames(p) {
+			for _, dep := range docsDependencies(p, jobs[name]) {
+				edges = append(edges, fmt.Sprintf("  %s --> %s", node(dep), node(docsJobName(p, name))))
+			}
+		}
+	}
+	if len(edges) == 0 {
+		return ""
+	}
+	return "```mermaid\nflowchart LR\n" + strings.Join(nodes, "\n") + "\n" + strings.Join(edges, "\n") + "\n```\n"
+}
+
+// docsDependencies returns the names the dependencies of a job are
+// invoked with, the dependencies of skill jobs resolve to the jobs of
+// the skill first.
+func docsDependencies(p *model.Pipeline, job *model.Job) []string {
+	jobs := p.GetJobs()
+	deps := GetDependencies(job.DependsOn)
+	result := make([]string, len(deps))
+	for i, dep := range deps {
+		result[i] = dep
+		if _, ok := jobs[dep]; ok {
+			result[i] = docsJobName(p, dep)
+		}
+	}
+	return result
+}
+
+// docsJobName returns the name a job is invoked with.
+func docsJobName(p *model.Pipeline, name string) string {
+	if p.ID == "" {
+		return name
+	}
+	return p.ID + ":" + name
+}
+
+// docsSource returns the source of a pipeline relative to dir.
+func docsSource(source, dir string) string {
+	if rel, err := filepath.Rel(dir, source); err == nil && filepath.IsAbs(source) && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return source
+}
+
+// docsCodeList formats names as a list of inline code.
+func docsCodeList(names []string) string {
+	return "`" + strings.Join(names, "`, `") + "`"
+}
+
+// docsValue formats a variable value on one line, strings as they are and
+// other values as JSON.
+func docsValue(value any) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
@@ -0,0 +1,123 @@
+package runner
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/atkins/model"
+)
+
+func TestPipelineDocs(t *testing.T) {
+	pipelines, err := LoadPipelineFromReader(strings.NewReader(`
+name: shop
+env:
+  doc:
+    DATABASE_URL: Connection string of the database
+jobs:
+  build:
+    desc: Build the binaries
+    aliases: [b]
+  deploy:
+    desc: Deploy a service
+    depends_on: build
+    requires:
+      commands: [kubectl]
+    inputs:
+      service:
+        type: string
+        required: true
+        desc: Service to deploy
+      replicas:
+        type: number
+        default: 2
+    vars:
+      cmd: a | b
+`))
+	require.NoError(t, err)
+	main := pipelines[0]
+	main.Source = "/src/shop/atkins.yml"
+
+	skills, err := LoadPipelineFromReader(strings.NewReader(`
+name: Go tooling
+jobs:
+  default:
+    depends_on: test
+  test:
+    desc: Run tests
+`))
+	require.NoError(t, err)
+	skill := skills[0]
+	skill.ID = "go"
+	skill.Source = "embedded:go.yml"
+
+	docs := PipelineDocs([]*model.Pipeline{main, skill}, "/src/shop")
+	assert.Equal(t, `# shop
+
+`+DocsHeader+`
+
+Defined in `+"`atkins.yml`"+`. Run a job with `+"`atkins <job>`"+`, list the jobs with `+"`atkins -l`"+`.
+
+## Environment
+
+| Environment | Description |
+|---|---|
+| `+"`$DATABASE_URL`"+` | Connection string of the database |
+
+## Jobs
+
+### `+"`build`"+`
+
+Build the binaries
+
+- Aliases: `+"`b`"+`
+
+### `+"`deploy`"+`
+
+Deploy a service
+
+- Depends on: `+"`build`"+`
+- Requires commands: `+"`kubectl`"+`
+
+| Input | Type | Required | Default | Description |
+|---|---|---|---|---|
+| `+"`replicas`"+` | number |  | `+"`2`"+` |  |
+| `+"`service`"+` | string | yes |  | Service to deploy |
+
+| Variable | Value |
+|---|---|
+| `+"`cmd`"+` | `+"`a \\| b`"+` |
+
+## Dependencies
+
+`+"```mermaid"+`
+flowchart LR
+  j0["build"]
+  j1["deploy"]
+  j2["go:test"]
+  j3["go:default"]
+  j0 --> j1
+  j2 --> j3
+`+"```"+`
+
+## Skills
+
+| Skill | Source | Jobs |
+|---|---|---|
+| `+"`go`"+` | `+"`embedded:go.yml`"+` | 2 |
+
+### Skill `+"`go`"+`
+
+Go tooling
+
+#### `+"`go:default`"+`
+
+- Depends on: `+"`go:test`"+`
+
+#### `+"`go:test`"+`
+
+Run tests
+`, docs)
+}
@@ -0,0 +1,86 @@
+package runner
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/titpetric/atkins/model"
+)
+
+// JobRegion returns the 1-based first and last line of a job in the
+// pipeline source, from the job key to the line before the next key.
+// Trailing blank lines are left out of the region.
+func JobRegion(data []byte, name string) (start, end int, err error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return 0, 0, fmt.Errorf("error decoding pipeline: %w", err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return 0, 0, fmt.Errorf("job %q not found", name)
+	}
+	root := doc.Content[0]
+	lines := bytes.Split(data, []byte("\n"))
+
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, jobs := root.Content[i], root.Content[i+1]
+		if (key.Value != "jobs" && key.Value != "tasks") || jobs.Kind != yaml.MappingNode {
+			continue
+		}
+		for j := 0; j+1 < len(jobs.Content); j += 2 {
+			if jobs.Content[j].Value != name {
+				continue
+			}
+			start, end = jobs.Content[j].Line, len(lines)
+			switch {
+			case j+2 < len(jobs.Content):
+				end = jobs.Content[j+2].Line - 1
+			case i+2 < len(root.Content):
+				end = root.Content[i+2].Line - 1
+			}
+			for end > start && len(bytes.TrimSpace(lines[end-1])) == 0 {
+				end--
+			}
+			return start, end, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("job %q not found", name)
+}
+
+// ReplaceLines replaces the 1-based lines start to end of data with region.
+func ReplaceLines(data []byte, start, end int, region []byte) []byte {
+	lines := strings.Split(string(data), "\n")
+	replacement := strings.Split(strings.TrimRight(string(region), "\n"), "\n")
+	lines = slices.Replace(lines, start-1, end, replacement...)
+	return []byte(strings.Join(lines, "\n"))
+}
+
+// DryRun describes the jobs a run of job would execute, in order, with
+// the commands of their steps as written.
+func DryRun(pipeline *model.Pipeline, job string) (string, error) {
+	jobs := pipeline.GetJobs()
+	order, err := ResolveJobDependencies(jobs, job)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	for _, name := range order {
+		fmt.Fprintf(&sb, "%s\n", name)
+		for _, step := range jobs[name].Children() {
+			commands := step.Commands()
+			if len(commands) == 0 {
+				fmt.Fprintf(&sb, "  %s\n", step.String())
+			}
+			for _, cmd := range commands {
+				for _, line := range strings.Split(cmd, "\n") {
+					fmt.Fprintf(&sb, "  $ %s\n", line)
+				}
+			}
+		}
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,74 @@
+package runner
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const editPipeline = `name: demo
+jobs:
+  build:
+    steps:
+      - run: go build ./...
+
+  test:
+    depends_on: build
+    steps:
+      - run: go test ./...
+
+vars:
+  x: 1
+`
+
+func TestJobRegion(t *testing.T) {
+	tests := []struct {
+		job        string
+		start, end int
+	}{
+		{"build", 3, 5},
+		// The last job ends before the next key of the pipeline
+		{"test", 7, 10},
+	}
+	for _, tc := range tests {
+		start, end, err := JobRegion([]byte(editPipeline), tc.job)
+		require.NoError(t, err)
+		assert.Equal(t, tc.start, start, tc.job)
+		assert.Equal(t, tc.end, end, tc.job)
+	}
+
+	start, end, err := JobRegion([]byte("tasks:\n  a: echo a\n  b: echo b"), "b")
+	require.NoError(t, err)
+	assert.Equal(t, []int{3, 3}, []int{start, end})
+
+	_, _, err = JobRegion([]byte(editPipeline), "deploy")
+	assert.ErrorContains(t, err, `job "deploy" not found`)
+}
+
+func TestReplaceLines(t *testing.T) {
+	updated := ReplaceLines([]byte(editPipeline), 3, 5, []byte("  build:\n    run: make\n"))
+	assert.Equal(t, `name: demo
+jobs:
+  build:
+    run: make
+
+  test:
+    depends_on: build
+    steps:
+      - run: go test ./...
+
+vars:
+  x: 1
+`, string(updated))
+}
+
+func TestDryRun(t *testing.T) {
+	pipelines, err := LoadPipelineFromReader(strings.NewReader(editPipeline))
+	require.NoError(t, err)
+
+	plan, err := DryRun(pipelines[0], "test")
+	require.NoError(t, err)
+	assert.Equal(t, "build\n  $ go build ./...\ntest\n  $ go test ./...\n", plan)
+}
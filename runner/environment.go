@@ -1,10 +1,15 @@
 package runner
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/titpetric/atkins/model"
 )
 
 // Environment represents the discovered project environment.
@@ -12,20 +17,77 @@ type Environment struct {
 	Root string // Project root directory
 }
 
+// Marker is a file, or a directory with a trailing slash, that marks a
+// project root. Skills lists the skills the marker enables.
+type Marker struct {
+	Path   string
+	Skills []string
+}
+
+// MarkersFile is the file in .atkins/ mapping markers to skills.
+const MarkersFile = "markers.yml"
+
 // projectMarkers defines files/directories that indicate a project root.
-var projectMarkers = []string{
-	"go.mod",
-	"Dockerfile",
-	"compose.yml",
-	"docker-compose.yml",
-	".github/",
-	"schema/",
+var projectMarkers = []Marker{
+	{Path: "go.mod"},
+	{Path: "Dockerfile"},
+	{Path: "compose.yml"},
+	{Path: "docker-compose.yml"},
+	{Path: ".github/"},
+	{Path: "schema/"},
+}
+
+// LoadMarkers reads markers.yml files, mapping a marker to a skill ID or
+// a list of skill IDs. Missing files are skipped.
+func LoadMarkers(paths ...string) ([]Marker, error) {
+	var markers []Marker
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var node yaml.Node
+		if err := yaml.Unmarshal(data, &node); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		if len(node.Content) == 0 {
+			continue
+		}
+		mapping := node.Content[0]
+		if mapping.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("failed to parse %s: expected a map of markers to skills", path)
+		}
+		for i := 0; i+1 < len(mapping.Content); i += 2 {
+			var skills model.Dependencies
+			if err := mapping.Content[i+1].Decode(&skills); err != nil {
+				return nil, fmt.Errorf("failed to parse %s: marker %q: %w", path, mapping.Content[i].Value, err)
+			}
+			markers = append(markers, Marker{Path: mapping.Content[i].Value, Skills: skills})
+		}
+	}
+	return markers, nil
+}
+
+// SkillMarkers returns the `detect:` markers of the skills.
+func SkillMarkers(pipelines []*model.Pipeline) []Marker {
+	var markers []Marker
+	for _, pipeline := range pipelines {
+		for _, path := range pipeline.Detect {
+			markers = append(markers, Marker{Path: path, Skills: []string{pipeline.ID}})
+		}
+	}
+	return markers
 }
 
 // DiscoverEnvironment scans for marker files starting from startDir,
 // traversing parent directories until the filesystem root is reached.
 // Root is set to the highest directory that contains any markers.
-func DiscoverEnvironment(startDir string) (*Environment, error) {
+// Markers extend the built-in markers, e.g. go.mod or Dockerfile.
+func DiscoverEnvironment(startDir string, markers ...Marker) (*Environment, error) {
 	absStart, err := filepath.Abs(startDir)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get absolute path: %w", err)
@@ -34,7 +96,7 @@ func DiscoverEnvironment(startDir string) (*Environment, error) {
 	root := ""
 	dir := absStart
 	for {
-		if hasProjectMarker(dir) {
+		if hasProjectMarker(dir, projectMarkers) || hasProjectMarker(dir, markers) {
 			root = dir
 		}
 
@@ -53,32 +115,30 @@ func DiscoverEnvironment(startDir string) (*Environment, error) {
 }
 
 // DiscoverEnvironmentFromCwd is a convenience wrapper that starts from the current working directory.
-func DiscoverEnvironmentFromCwd() (*Environment, error) {
+func DiscoverEnvironmentFromCwd(markers ...Marker) (*Environment, error) {
 	cwd, err := os.Getwd()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get current directory: %w", err)
 	}
-	return DiscoverEnvironment(cwd)
+	return DiscoverEnvironment(cwd, markers...)
 }
 
 // hasProjectMarker checks if any marker file/directory exists in dir.
-func hasProjectMarker(dir string) bool {
-	for _, marker := range projectMarkers {
-		path := filepath.Join(dir, marker)
-		info, err := os.Stat(path)
-		if err != nil {
-			continue
-		}
-		// Markers ending with "/" expect a directory, others expect a file
-		if strings.HasSuffix(marker, "/") {
-			if info.IsDir() {
-				return true
-			}
-		} else {
-			if !info.IsDir() {
-				return true
-			}
+func hasProjectMarker(dir string, markers []Marker) bool {
+	for _, marker := range markers {
+		if markerExists(dir, marker.Path) {
+			return true
 		}
 	}
 	return false
 }
+
+// markerExists checks if the marker file/directory exists in dir.
+func markerExists(dir, marker string) bool {
+	info, err := os.Stat(filepath.Join(dir, marker))
+	if err != nil {
+		return false
+	}
+	// Markers ending with "/" expect a directory, others expect a file
+	return info.IsDir() == strings.HasSuffix(marker, "/")
+}
@@ -123,3 +123,32 @@ func TestDiscoverEnvironment_Root(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, tmpDir, env.Root)
 }
+
+func TestDiscoverEnvironment_CustomMarkers(t *testing.T) {
+	tmpDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "Cargo.toml"), []byte("[package]"), 0o644))
+
+	_, err := runner.DiscoverEnvironment(tmpDir)
+	require.Error(t, err)
+
+	env, err := runner.DiscoverEnvironment(tmpDir, runner.Marker{Path: "Cargo.toml", Skills: []string{"cargo"}})
+	require.NoError(t, err)
+	assert.Equal(t, tmpDir, env.Root)
+}
+
+func TestLoadMarkers(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "markers.yml")
+	require.NoError(t, os.WriteFile(path, []byte("Cargo.toml: cargo\npyproject.toml: [python, ruff]\n"), 0o644))
+
+	markers, err := runner.LoadMarkers(path, filepath.Join(tmpDir, "missing.yml"))
+	require.NoError(t, err)
+	assert.Equal(t, []runner.Marker{
+		{Path: "Cargo.toml", Skills: []string{"cargo"}},
+		{Path: "pyproject.toml", Skills: []string{"python", "ruff"}},
+	}, markers)
+
+	require.NoError(t, os.WriteFile(path, []byte("- Cargo.toml\n"), 0o644))
+	_, err = runner.LoadMarkers(path)
+	require.Error(t, err)
+}
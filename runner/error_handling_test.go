@@ -5,6 +5,7 @@ import (
 	"errors"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -260,3 +261,24 @@ func TestErrorHandling(t *testing.T) {
 		})
 	}
 }
+
+func TestPipelineTimeout(t *testing.T) {
+	pipelines, err := runner.LoadPipelineFromReader(strings.NewReader(`
+name: slow
+timeout: 200ms
+jobs:
+  default:
+    steps:
+      - run: sleep 10
+`))
+	require.NoError(t, err)
+
+	start := time.Now()
+	err = runner.RunPipeline(t.Context(), pipelines[0], runner.PipelineOptions{Jobs: []string{"default"}, Silent: true})
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	pipelines[0].Timeout = "soon"
+	err = runner.RunPipeline(t.Context(), pipelines[0], runner.PipelineOptions{Jobs: []string{"default"}, Silent: true})
+	assert.ErrorContains(t, err, `invalid pipeline timeout "soon"`)
+}
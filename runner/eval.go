@@ -63,7 +63,8 @@ func evaluateIfExpression(ifExpr string, ctx *ExecutionContext) (bool, error) {
 	}
 	ifExpr = interpolated
 
-	prog, err := expr.Compile(ifExpr, expr.AllowUndefinedVariables())
+	options := append([]expr.Option{expr.AllowUndefinedVariables()}, conditionFunctions(ctx)...)
+	prog, err := compileExpr(ifExpr, options...)
 	if err != nil {
 		return false, fmt.Errorf("failed to compile if expression %q: %w", ifExpr, err)
 	}
@@ -78,13 +79,16 @@ func evaluateIfExpression(ifExpr string, ctx *ExecutionContext) (bool, error) {
 		})
 	}
 
+	// Add the results of the previous steps of the job
+	ctx.steps.addTo(env)
+
 	// Add environment variables
 	for k, v := range ctx.Env {
 		env[k] = v
 	}
 
 	// Run the compiled program
-	result, err := expr.Run(prog, env)
+	result, err := runExpr(prog, env)
 	if err != nil {
 		return false, fmt.Errorf("failed to evaluate if expression %q: %w", ifExpr, err)
 	}
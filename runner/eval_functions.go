@@ -0,0 +1,211 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/expr-lang/expr"
+
+	"github.com/titpetric/atkins/eventlog"
+)
+
+// ChangedBaseEnv names the env var holding the git ref that changed()
+// compares against, e.g. origin/main in CI. Without it, only uncommitted
+// changes are considered.
+const ChangedBaseEnv = "ATKINS_CHANGED_BASE"
+
+// errNotRepository is returned by listChangedFiles outside of a git
+// repository, where every pattern is considered changed.
+var errNotRepository = errors.New("not a git repository")
+
+// changedFiles caches the changed file list of a run per directory and
+// base ref, so changed() runs git once per directory. It's created for
+// each run, so a later run in the same process sees new changes.
+type changedFiles struct {
+	mu    sync.Mutex
+	files map[string][]string
+}
+
+// conditionFunctions returns the helper functions available in `if:` expressions.
+func conditionFunctions(ctx *ExecutionContext) []expr.Option {
+	return []expr.Option{
+		expr.Function("changed", func(params ...any) (any, error) {
+			return conditionChanged(ctx, stringParams(params))
+		}, new(func(...string) bool)),
+		expr.Function("exists", func(params ...any) (any, error) {
+			return conditionExists(ctx, stringParams(params)), nil
+		}, new(func(...string) bool)),
+		expr.Function("platform", func(params ...any) (any, error) {
+			return runtime.GOOS, nil
+		}, new(func() string)),
+		expr.Function("ci", func(params ...any) (any, error) {
+			return eventlog.DetectCI(func(key string) string { return ctx.Env[key] }) != nil, nil
+		}, new(func() bool)),
+	}
+}
+
+func stringParams(params []any) []string {
+	result := make([]string, 0, len(params))
+	for _, p := range params {
+		result = append(result, fmt.Sprint(p))
+	}
+	return result
+}
+
+// conditionDir returns the directory conditions are evaluated in.
+func conditionDir(ctx *ExecutionContext) string {
+	if ctx.Dir != "" {
+		return ctx.Dir
+	}
+	return "."
+}
+
+// conditionExists reports whether any of the paths or glob patterns exist.
+func conditionExists(ctx *ExecutionContext, patterns []string) bool {
+	dir := conditionDir(ctx)
+	for _, pattern := range patterns {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(dir, pattern)
+		}
+		if strings.ContainsAny(pattern, "*?[") {
+			if matches, _ := filepath.Glob(pattern); len(matches) > 0 {
+				return true
+			}
+			continue
+		}
+		if _, err := os.Stat(pattern); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// conditionChanged reports whether any changed file matches one of the
+// glob patterns. Changed files are the uncommitted changes (including
+// untracked files), plus the commits since ATKINS_CHANGED_BASE when set.
+// Outside of a git repository, everything is considered changed.
+func conditionChanged(ctx *ExecutionContext, patterns []string) (bool, error) {
+	files, err := ctx.changed.list(conditionDir(ctx), ctx.Env[ChangedBaseEnv])
+	if errors.Is(err, errNotRepository) {
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("changed(): %w", err)
+	}
+
+	for _, pattern := range patterns {
+		re, err := globRegexp(pattern)
+		if err != nil {
+			return false, fmt.Errorf("changed(%q): %w", pattern, err)
+		}
+		for _, file := range files {
+			if re.MatchString(file) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// list returns the changed file paths relative to dir, cached unless c
+// is nil.
+func (c *changedFiles) list(dir, base string) ([]string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return listChangedFiles(abs, base)
+	}
+
+	key := abs + "\x00" + base
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if files, ok := c.files[key]; ok {
+		return files, nil
+	}
+	files, err := listChangedFiles(abs, base)
+	if err != nil {
+		return nil, err
+	}
+	if c.files == nil {
+		c.files = map[string][]string{}
+	}
+	c.files[key] = files
+	return files, nil
+}
+
+// listChangedFiles returns changed file paths relative to dir, or
+// errNotRepository when dir isn't in a git repository.
+func listChangedFiles(dir, base string) ([]string, error) {
+	if _, err := gitOutput(dir, "rev-parse", "--git-dir"); err != nil {
+		if strings.Contains(err.Error(), "not a git repository") {
+			return nil, errNotRepository
+		}
+		return nil, err
+	}
+
+	commands := [][]string{
+		{"ls-files", "--others", "--exclude-standard"},
+	}
+	if _, err := gitOutput(dir, "rev-parse", "--verify", "--quiet", "HEAD"); err == nil {
+		commands = append(commands, []string{"diff", "--name-only", "--relative", "HEAD"})
+	} else {
+		// Without commits, everything staged is a change
+		commands = append(commands, []string{"ls-files", "--cached"})
+	}
+	if base != "" {
+		if _, err := gitOutput(dir, "rev-parse", "--verify", "--quiet", base+"^{commit}"); err != nil {
+			return nil, fmt.Errorf("$%s: %q is not a commit", ChangedBaseEnv, base)
+		}
+		commands = append(commands, []string{"diff", "--name-only", "--relative", base + "...HEAD"})
+	}
+
+	seen := map[string]bool{}
+	var files []string
+	for _, args := range commands {
+		out, err := gitOutput(dir, args...)
+		if err != nil {
+			return nil, err
+		}
+		for _, line := range strings.Split(out, "\n") {
+			if line != "" && !seen[line] {
+				seen[line] = true
+				files = append(files, line)
+			}
+		}
+	}
+	return files, nil
+}
+
+// globRegexp converts a glob pattern to a regular expression.
+// `**` matches across directories, `*` and `?` match within a path segment.
+func globRegexp(pattern string) (*regexp.Regexp, error) {
+	var sb strings.Builder
+	sb.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		ch := pattern[i]
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			sb.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			sb.WriteString(".*")
+			i++
+		case ch == '*':
+			sb.WriteString("[^/]*")
+		case ch == '?':
+			sb.WriteString("[^/]")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(ch)))
+		}
+	}
+	sb.WriteString("$")
+	return regexp.Compile(sb.String())
+}
@@ -0,0 +1,111 @@
+package runner
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/atkins/model"
+)
+
+func evalCondition(t *testing.T, ctx *ExecutionContext, cond string) bool {
+	t.Helper()
+	ctx.Step = &model.Step{If: model.Conditionals{model.Condition(cond)}}
+	if ctx.Variables == nil {
+		ctx.Variables = NewContextVariables(nil)
+	}
+	ok, err := EvaluateIf(ctx)
+	require.NoError(t, err, cond)
+	return ok
+}
+
+func TestGlobRegexp(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"**/*.go", "main.go", true},
+		{"**/*.go", "runner/eval.go", true},
+		{"*.go", "runner/eval.go", false},
+		{"docs/**", "docs/content/index.md", true},
+		{"go.???", "go.mod", true},
+		{"go.???", "go.sum", true},
+		{"go.???", "go.work", false},
+	}
+
+	for _, tt := range tests {
+		re, err := globRegexp(tt.pattern)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, re.MatchString(tt.path), "%s ~ %s", tt.pattern, tt.path)
+	}
+}
+
+func TestConditionFunctions(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Dockerfile"), nil, 0o644))
+
+	ctx := &ExecutionContext{Dir: dir, Env: Env{}}
+	assert.True(t, evalCondition(t, ctx, `exists("Dockerfile")`))
+	assert.True(t, evalCondition(t, ctx, `exists("compose.yml", "Docker*")`))
+	assert.False(t, evalCondition(t, ctx, `exists("compose.yml")`))
+	assert.True(t, evalCondition(t, ctx, `platform() == "`+runtime.GOOS+`"`))
+	assert.False(t, evalCondition(t, ctx, `ci()`))
+
+	ctx.Env["GITHUB_ACTIONS"] = "true"
+	assert.True(t, evalCondition(t, ctx, `ci()`))
+}
+
+func TestConditionChanged(t *testing.T) {
+	dir := t.TempDir()
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=atkins", "-c", "user.email=atkins@example.com"}, args...)...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	git("init", "-q")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("readme"), 0o644))
+	git("add", ".")
+	git("commit", "-q", "-m", "initial")
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "cmd"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "cmd", "main.go"), []byte("package main"), 0o644))
+
+	ctx := &ExecutionContext{Dir: dir, Env: Env{}, changed: &changedFiles{}}
+	assert.True(t, evalCondition(t, ctx, `changed("**/*.go")`))
+	assert.False(t, evalCondition(t, ctx, `changed("**/*.md", "docs/**")`))
+
+	// The run caches the changed files, a new run sees new changes
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "CHANGELOG.md"), nil, 0o644))
+	assert.False(t, evalCondition(t, ctx, `changed("*.md")`))
+	ctx.changed = &changedFiles{}
+	assert.True(t, evalCondition(t, ctx, `changed("*.md")`))
+
+	git("add", ".")
+	git("commit", "-q", "-m", "changes")
+	ctx = &ExecutionContext{Dir: dir, Env: Env{ChangedBaseEnv: "HEAD~1"}}
+	assert.True(t, evalCondition(t, ctx, `changed("cmd/*.go")`))
+
+	ctx.Env[ChangedBaseEnv] = "origin/nope"
+	ctx.Step = &model.Step{If: model.Conditionals{`changed("**/*.go")`}}
+	ctx.Variables = NewContextVariables(nil)
+	_, err := EvaluateIf(ctx)
+	assert.ErrorContains(t, err, `$ATKINS_CHANGED_BASE: "origin/nope" is not a commit`)
+}
+
+func TestConditionChanged_NotRepository(t *testing.T) {
+	t.Setenv("GIT_CEILING_DIRECTORIES", os.TempDir())
+	ctx := &ExecutionContext{Dir: t.TempDir(), Env: Env{}}
+	assert.True(t, evalCondition(t, ctx, `changed("**/*.go")`))
+
+	ctx.Dir = filepath.Join(ctx.Dir, "missing")
+	ctx.Step = &model.Step{If: model.Conditionals{`changed("**/*.go")`}}
+	_, err := EvaluateIf(ctx)
+	assert.Error(t, err, "only a dir outside of a repository counts as changed")
+}
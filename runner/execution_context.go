@@ -59,6 +59,8 @@ type ExecutionContext struct {
 	Builder     *treeview.Builder
 	JobNodes    map[string]*treeview.TreeNode // Map of job names to their tree nodes
 	EventLogger *eventlog.Logger
+	CaptureDir  string     // Directory receiving full per-step output (<capture-dir>/<run-id>)
+	Workspace   *Workspace // Isolated job workspace, when the job sets `workspace:`
 
 	// Sequential step counter for this job (incremented for each step execution)
 	StepSequence int
@@ -68,6 +70,44 @@ type ExecutionContext struct {
 	// Shared across copies so the mutex protects the map consistently.
 	jobTracker *jobTracker
 
+	// services starts and stops pipeline services, shared across copies.
+	services *serviceRegistry
+
+	// failures collects the failed steps of the run, shared across copies.
+	failures *failureLog
+
+	// budgets collects the steps exceeding their budget, shared across copies.
+	budgets *budgetLog
+
+	// warnings collects the warnings of the run, shared across copies.
+	warnings *warningLog
+	// changed caches the changed files for changed(), shared across copies.
+	changed *changedFiles
+
+	// hints classifies the output of failed commands, shared across copies.
+	hints *hintClassifier
+
+	// scrub replaces the scrub rule matches in output and logs, shared across copies.
+	scrub *scrubber
+
+	// sinks streams step and job results to the pipeline log sinks, shared across copies.
+	sinks *logSinks
+
+	// policy allows and denies the commands of the run, shared across copies.
+	policy *Policy
+
+	// audit appends the executed commands to the audit log, shared across copies.
+	audit *auditLog
+
+	// steps records the results of the steps of the job, shared across copies.
+	steps *stepResults
+
+	// checkpoint records the completed jobs and steps of the run, shared across copies.
+	checkpoint *checkpoint
+
+	// jobLock is the name of the lock held by the job, see acquireLock.
+	jobLock string
+
 	// Progress receives job lifecycle events (optional).
 	Progress ProgressObserver
 
@@ -91,14 +131,20 @@ func (e *ExecutionContext) Resolve(taskName string) (*model.ResolvedTask, error)
 	return e.SkillResolver().ResolveWithFallback(taskName, e.Resolver())
 }
 
-// Copy copies everything except Context. Variables are cloned.
+// Copy copies the execution context. Variables are cloned, Context is
+// inherited so cancellation reaches work done with the copy.
 // jobTracker is shared (not copied) to maintain consistent dependency tracking.
 func (e *ExecutionContext) Copy() *ExecutionContext {
 	var vars model.VariableStorage
 	if e.Variables != nil {
 		vars = e.Variables.Clone()
 	}
+	// Detached steps copy the context while others take step indices
+	e.stepSeqMu.Lock()
+	stepSequence := e.StepSequence
+	e.stepSeqMu.Unlock()
 	return &ExecutionContext{
+		Context:      e.Context,
 		Variables:    vars,
 		Env:          maps.Clone(e.Env),
 		Results:      e.Results,
@@ -117,8 +163,23 @@ func (e *ExecutionContext) Copy() *ExecutionContext {
 		Builder:      e.Builder,
 		JobNodes:     e.JobNodes,
 		EventLogger:  e.EventLogger,
-		StepSequence: e.StepSequence,
+		CaptureDir:   e.CaptureDir,
+		Workspace:    e.Workspace,
+		StepSequence: stepSequence,
 		jobTracker:   e.jobTracker,
+		services:     e.services,
+		failures:     e.failures,
+		budgets:      e.budgets,
+		warnings:     e.warnings,
+		changed:      e.changed,
+		hints:        e.hints,
+		scrub:        e.scrub,
+		sinks:        e.sinks,
+		policy:       e.policy,
+		audit:        e.audit,
+		steps:        e.steps,
+		checkpoint:   e.checkpoint,
+		jobLock:      e.jobLock,
 		Progress:     e.Progress,
 		Parents:      append([]string(nil), e.Parents...),
 	}
@@ -149,6 +210,9 @@ func (e *ExecutionContext) EmitProgress(ev JobProgressEvent) {
 	if e.Progress != nil {
 		e.Progress.OnJobProgress(ev)
 	}
+	if e.sinks != nil {
+		e.sinks.job(ev)
+	}
 }
 
 // NextStepIndex returns the next sequential step index for this job execution.
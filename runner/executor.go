@@ -1,8 +1,22 @@
 package runner
 
+import (
+	"bufio"
+	"sync"
+)
+
 // Executor runs pipeline jobs and steps.
 type Executor struct {
 	opts *Options
+
+	// ttyMu serializes debug shells and breakpoint and install prompts of parallel jobs.
+	ttyMu          sync.Mutex
+	stdin          *bufio.Reader   // Answers to breakpoint and install prompts
+	stepDone       bool            // Stop pausing with --step, set by answering "continue"
+	installOffered map[string]bool // Install jobs offered to run, see offerInstall
+
+	// preflighted holds the jobs checked for missing executables, see preflight.
+	preflighted sync.Map
 }
 
 // NewExecutor creates a new executor with default options.
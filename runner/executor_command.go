@@ -2,7 +2,9 @@ package runner
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"strings"
 	"time"
 
@@ -25,7 +27,7 @@ func (e *Executor) executeCommands(ctx context.Context, stepCtx *ExecutionContex
 		cmdNodes = stepNode.GetChildren()
 	}
 
-	var lastErr error
+	var lastErr, skipErr error
 	for i, cmd := range commands {
 		var cmdNode *treeview.Node
 		if i < len(cmdNodes) {
@@ -33,21 +35,32 @@ func (e *Executor) executeCommands(ctx context.Context, stepCtx *ExecutionContex
 		} else if stepNode != nil {
 			cmdNode = stepNode // Fallback to parent if no child nodes
 		}
-		if err := e.executeStepIteration(ctx, stepCtx, step, cmdNode, cmd, stepIndex+i); err != nil {
+		err := e.executeStepIteration(ctx, stepCtx, step, cmdNode, cmd, stepIndex+i)
+		switch {
+		case errors.Is(err, ErrSkippedExitCode):
+			skipErr = err
+		case err != nil:
 			lastErr = err
 		}
 	}
 
 	// Update parent node status if we used child nodes
 	if len(cmdNodes) > 0 && stepNode != nil {
-		if lastErr != nil {
+		switch {
+		case lastErr != nil:
 			stepNode.SetStatus(treeview.StatusFailed)
-		} else {
+		case skipErr != nil:
+			stepNode.SetStatus(treeview.StatusSkipped)
+		default:
 			stepNode.SetStatus(treeview.StatusPassed)
 		}
 	}
 
-	return lastErr
+	// Failures take precedence over commands skipped by their exit code
+	if lastErr != nil {
+		return lastErr
+	}
+	return skipErr
 }
 
 // executeStepIteration executes a single step (or iteration of a step) with the given context
@@ -66,7 +79,7 @@ func (e *Executor) executeStepIteration(ctx context.Context, stepCtx *ExecutionC
 	if stepCtx.Job != nil {
 		jobName = stepCtx.Job.Name
 	}
-	stepID := generateStepID(jobName, seqIndex)
+	stepID := resolveStepID(jobName, step, seqIndex)
 
 	// Capture start offset for event log
 	var startOffset float64
@@ -101,19 +114,26 @@ func (e *Executor) executeStepIteration(ctx context.Context, stepCtx *ExecutionC
 
 	// Update tree node status and log result
 	stepNode.SetDuration(duration.Seconds())
-	if err != nil {
+	skipped := errors.Is(err, ErrSkippedExitCode)
+	switch {
+	case skipped:
+		stepNode.SetStatus(treeview.StatusSkipped)
+	case err != nil:
 		stepNode.SetStatus(treeview.StatusFailed)
-	} else {
+	default:
 		stepNode.SetStatus(treeview.StatusPassed)
 	}
 
 	// Log single execution event
 	if stepCtx.EventLogger != nil {
-		result := eventlog.ResultPass
-		if err != nil {
+		result, logErr := eventlog.ResultPass, err
+		switch {
+		case skipped:
+			result, logErr = eventlog.ResultSkipped, nil
+		case err != nil:
 			result = eventlog.ResultFail
 		}
-		stepCtx.EventLogger.LogExec(result, stepID, stepName, startOffset, durationMs, err)
+		stepCtx.EventLogger.LogExec(result, stepID, stepName, startOffset, durationMs, logErr)
 	}
 
 	stepCtx.Render()
@@ -123,11 +143,10 @@ func (e *Executor) executeStepIteration(ctx context.Context, stepCtx *ExecutionC
 // executeCommand runs a single command with interpolation and respects context timeout
 func (e *Executor) executeCommand(ctx context.Context, execCtx *ExecutionContext, step *model.Step, cmd string) error {
 	// Interpolate the command
-	interpolated, err := InterpolateCommand(cmd, execCtx)
+	interpolated, err := interpolateStepCommand(step, cmd, execCtx)
 	if err != nil {
 		return fmt.Errorf("interpolation failed: %w", err)
 	}
-
 	// Check if context is already cancelled
 	if ctx != nil {
 		select {
@@ -145,8 +164,8 @@ func (e *Executor) executeCommand(ctx context.Context, execCtx *ExecutionContext
 	// Check step passthru flag first, then job passthru flag
 	shouldPassthru := step.Passthru || (execCtx.Job != nil && execCtx.Job.Passthru)
 
-	// Determine TTY allocation: Job.TTY is authoritative, otherwise use Step.TTY
-	useTTY := step.TTY || (execCtx.Job != nil && execCtx.Job.TTY)
+	// Determine TTY allocation: Step.TTY overrides Job.TTY
+	useTTY := stepTTY(execCtx.Job, step)
 
 	// Track execution for logging
 	startTime := time.Now()
@@ -156,27 +175,109 @@ func (e *Executor) executeCommand(ctx context.Context, execCtx *ExecutionContext
 	}
 
 	// Execute the command
-	executor := psexec.NewWithOptions(&psexec.Options{
-		DefaultDir: execCtx.Dir,
-		DefaultEnv: execCtx.Env.Environ(),
-	})
+	executor := execCtx.newExecutor()
+	priority, err := stepPriority(step)
+	if err != nil {
+		return err
+	}
+	noNetwork, err := stepNoNetwork(execCtx.Job, step)
+	if err != nil {
+		return err
+	}
 	shellCmd := executor.ShellCommand(interpolated)
 
+	// Record the commands the script executes with `trace: true`
+	var tracePath string
+	if step.Trace && execCtx.EventLogger != nil {
+		if tracePath, err = newTraceFile(); err != nil {
+			return fmt.Errorf("failed to create trace file: %w", err)
+		}
+		shellCmd = executor.ShellCommand(traceScript(interpolated, tracePath))
+	}
+	shellCmd.Priority = priority
+	shellCmd.NoNetwork = noNetwork
+
+	// Captured output is shown in the output box of the step, size it to the box
+	if !isInteractive && !step.Attached {
+		env, size := outputSize(execCtx, useTTY)
+		shellCmd.Env = append(shellCmd.Env, env...)
+		shellCmd.Size = size
+	}
+
+	// Render compose service status under the step while services start
+	if !isInteractive {
+		stopWatch := watchCompose(ctx, execCtx, execCtx.CurrentStep, interpolated)
+		defer stopWatch()
+	}
+
 	var writer *LineCapturingWriter
 	var result psexec.Result
-	if isInteractive {
+	combined := NewCombinedOutputWriter()
+	switch {
+	case isInteractive:
 		shellCmd.Interactive = true
-		result = executor.Run(ctx, shellCmd)
-		execCtx.Display.Invalidate()
-	} else if shouldPassthru && execCtx.CurrentStep != nil {
+	case step.Attached:
+		shellCmd.Attached = true
+	case shouldPassthru && execCtx.CurrentStep != nil:
 		// If passthru is enabled, capture output to the node for display with tree indentation
 		writer = NewLineCapturingWriter()
-		shellCmd.Stdout = writer
-		shellCmd.Stderr = writer
+		shellCmd.Stdout = io.MultiWriter(writer, combined.Stdout())
+		shellCmd.Stderr = io.MultiWriter(writer, combined.Stderr())
 		shellCmd.UsePTY = useTTY
-		result = executor.Run(ctx, shellCmd)
+	default:
+		shellCmd.Stdout = combined.Stdout()
+		shellCmd.Stderr = combined.Stderr()
+		shellCmd.UsePTY = useTTY
+	}
+	if result, err = execCtx.runProcess(ctx, executor, shellCmd, interpolated); err != nil {
+		return err
+	}
+	if isInteractive {
+		execCtx.Display.Invalidate()
+	}
+
+	stepID := ""
+	if execCtx.CurrentStep != nil {
+		stepID = execCtx.CurrentStep.ID
+	}
+
+	// Capture full output to the capture dir, regardless of quiet mode
+	var logFile string
+	if execCtx.CaptureDir != "" && !isInteractive {
+		logFile, _ = captureOutput(execCtx.CaptureDir, stepID, execCtx.scrub.scrub(interpolated), execCtx.scrub.scrub(result.CombinedOutput()))
+	}
+
+	var trace []eventlog.TraceEntry
+	if tracePath != "" {
+		trace = execCtx.scrub.scrubTrace(readTrace(tracePath))
+	}
+
+	// Render the packages and failed tests of `go test -json` under the step
+	var tests *testReport
+	if source := goTestJSONSource(interpolated); source != "" && !isInteractive {
+		stdout := result.Output()
+		if writer != nil {
+			stdout = writer.String()
+		}
+		tests = readTestReport(source, execCtx.scrub.scrub(stdout), execCtx.Dir)
+		if tests != nil && execCtx.CurrentStep != nil {
+			execCtx.CurrentStep.AddChildren(tests.Nodes()...)
+		}
+	}
+
+	// Record the coverage of the run from the profiles written by go test
+	if profile := coverProfilePath(interpolated, execCtx.Dir); profile != "" && execCtx.EventLogger != nil {
+		_ = execCtx.EventLogger.AddCoverProfile(profile)
+	}
+
+	// Exit codes of accept_exit_codes pass the command, skip_exit_codes skip it
+	passed, skipped := exitOutcome(step, result)
+
+	// The output of steps with an id is available as steps.<id>.output
+	if writer != nil {
+		execCtx.steps.recordOutput(step, writer.String())
 	} else {
-		result = executor.Run(ctx, shellCmd)
+		execCtx.steps.recordOutput(step, result.Output())
 	}
 
 	// Log command execution
@@ -184,16 +285,12 @@ func (e *Executor) executeCommand(ctx context.Context, execCtx *ExecutionContext
 	if execCtx.EventLogger != nil {
 		exitCode := result.ExitCode()
 		errMsg := ""
-		if !result.Success() {
+		if !passed && !skipped {
 			errMsg = result.ErrorOutput()
 			if errMsg == "" && result.Err() != nil {
 				errMsg = result.Err().Error()
 			}
 		}
-		stepID := ""
-		if execCtx.CurrentStep != nil {
-			stepID = execCtx.CurrentStep.ID
-		}
 		output := result.Output()
 		if writer != nil {
 			output = writer.String()
@@ -201,17 +298,78 @@ func (e *Executor) executeCommand(ctx context.Context, execCtx *ExecutionContext
 		execCtx.EventLogger.LogCommand(eventlog.LogEntry{
 			Type:       eventlog.EventTypeStep,
 			ID:         stepID,
-			Command:    interpolated,
+			Command:    execCtx.scrub.scrub(interpolated),
 			Dir:        execCtx.Dir,
-			Output:     output,
-			Error:      errMsg,
+			Output:     execCtx.scrub.scrub(output),
+			Error:      execCtx.scrub.scrub(errMsg),
 			ExitCode:   exitCode,
 			Start:      startOffset,
 			DurationMs: durationMs,
+			Env:        execCtx.scrub.scrubLines(execCtx.Env.Environ()),
+			LogFile:    logFile,
+			Trace:      trace,
+			Tests:      tests.Entries(),
 		})
 	}
 
-	if !result.Success() {
+	if execCtx.sinks != nil {
+		rec := LogRecord{
+			Step:     stepID,
+			Command:  execCtx.scrub.scrub(interpolated),
+			Result:   eventlog.ResultPass,
+			ExitCode: result.ExitCode(),
+			Duration: time.Since(startTime).Seconds(),
+			Output:   execCtx.scrub.scrub(StripANSI(result.Output())),
+			Error:    execCtx.scrub.scrub(StripANSI(result.ErrorOutput())),
+		}
+		if writer != nil {
+			rec.Output = execCtx.scrub.scrub(StripANSI(writer.String()))
+		}
+		if execCtx.Job != nil {
+			rec.Job = execCtx.Job.Name
+		}
+		switch {
+		case skipped:
+			rec.Result = eventlog.ResultSkipped
+		case !passed:
+			rec.Result = eventlog.ResultFail
+		}
+		execCtx.sinks.send(rec)
+	}
+
+	if skipped {
+		return fmt.Errorf("%w %d", ErrSkippedExitCode, result.ExitCode())
+	}
+
+	if !passed {
+		jobName := ""
+		if execCtx.Job != nil {
+			jobName = execCtx.Job.Name
+		}
+		label, hint := execCtx.hints.classify(failureLines(combined, result))
+		execCtx.failures.add(FailedStep{
+			Job:      jobName,
+			Step:     execCtx.scrub.scrub(failedStepLabel(step.Name, interpolated)),
+			ExitCode: result.ExitCode(),
+			Duration: time.Since(startTime),
+			Error:    execCtx.scrub.scrub(firstErrorLine(result)),
+			Label:    label,
+			Hint:     hint,
+		})
+
+		// Show the command and the tail of its output under the failed step,
+		// failed tests already show their own output
+		if !isInteractive && execCtx.CurrentStep != nil && tests == nil {
+			lines := failureContext(interpolated, combined)
+			if hint != "" {
+				lines = append(lines, hintLine(label, hint))
+			}
+			execCtx.CurrentStep.SetOutput(execCtx.scrub.scrubLines(lines))
+		}
+
+		if e.opts.OnFailure == OnFailureShell {
+			e.debugShell(ctx, execCtx, interpolated, result.ExitCode())
+		}
 		return NewExecError(result)
 	}
 
@@ -219,18 +377,23 @@ func (e *Executor) executeCommand(ctx context.Context, execCtx *ExecutionContext
 	if execCtx.CurrentStep != nil {
 		// For echo commands, update the step node label with the output
 		if IsEchoCommand(interpolated) {
-			echoOutput, echoErr := evaluateEchoCommand(ctx, execCtx.Env, execCtx.Dir, interpolated)
+			echoOutput, echoErr := evaluateEchoCommand(ctx, execCtx, interpolated)
 			if echoErr == nil && echoOutput != "" {
-				execCtx.CurrentStep.Name = echoOutput
+				execCtx.CurrentStep.Name = execCtx.scrub.scrub(echoOutput)
 			}
-		} else if writer != nil {
+		} else if writer != nil && tests == nil {
 			rawOutput := writer.String()
 			lines, sanitizeErr := Sanitize(rawOutput)
 			if sanitizeErr != nil {
 				return fmt.Errorf("failed to sanitize output: %w", sanitizeErr)
 			}
 			if len(lines) > 0 {
-				execCtx.CurrentStep.SetOutput(lines)
+				execCtx.CurrentStep.SetOutput(execCtx.scrub.scrubLines(lines))
+			}
+		} else if step.Detach && !isInteractive && tests == nil {
+			// Detached steps run while the tree renders, show their spooled output once done
+			if lines := spoolLines(combined, DetachedOutputLines); len(lines) > 0 {
+				execCtx.CurrentStep.SetOutput(execCtx.scrub.scrubLines(lines))
 			}
 		}
 	}
@@ -245,16 +408,9 @@ func IsEchoCommand(cmd string) bool {
 }
 
 // evaluateEchoCommand executes an echo command and returns its output for use as a label
-func evaluateEchoCommand(ctx context.Context, env Env, dir string, cmd string) (string, error) {
-	exec := psexec.NewWithOptions(&psexec.Options{
-		DefaultDir: dir,
-		DefaultEnv: env.Environ(),
-	})
-	result := exec.Run(ctx, exec.ShellCommand(cmd))
-	if !result.Success() {
-		return "", NewExecError(result)
-	}
-	return strings.TrimSpace(result.Output()), nil
+func evaluateEchoCommand(ctx context.Context, execCtx *ExecutionContext, cmd string) (string, error) {
+	output, err := execCtx.runScript(ctx, cmd)
+	return strings.TrimSpace(output), err
 }
 
 // interpolateVariables interpolates all string variables in a map using $(exec) and ${{ var }} syntax.
@@ -273,11 +429,7 @@ func interpolateVariables(ctx *ExecutionContext, vars map[string]any) (map[strin
 	// Build dependency graph
 	deps := make(map[string][]string)
 	for k, v := range vars {
-		if strVal, ok := v.(string); ok {
-			deps[k] = extractVariableDependencies(strVal, vars)
-		} else {
-			deps[k] = nil
-		}
+		deps[k] = extractVariableDependencies(valueText(v), vars)
 	}
 
 	// Topological sort
@@ -291,22 +443,19 @@ func interpolateVariables(ctx *ExecutionContext, vars map[string]any) (map[strin
 		Variables: ctx.Variables.Clone(),
 		Env:       ctx.Env,
 		Dir:       ctx.Dir,
+		Pipeline:  ctx.Pipeline,
+		Job:       ctx.Job,
+		Step:      ctx.Step,
 	}
 
 	result := make(map[string]any)
 	for _, k := range order {
-		v := vars[k]
-		if strVal, ok := v.(string); ok {
-			interpolated, err := InterpolateString(strVal, workCtx)
-			if err != nil {
-				return nil, fmt.Errorf("failed to interpolate variable %q: %w", k, err)
-			}
-			result[k] = interpolated
-			workCtx.Variables.Set(k, interpolated)
-		} else {
-			result[k] = v
-			workCtx.Variables.Set(k, v)
+		interpolated, err := resolveVar(workCtx, vars[k])
+		if err != nil {
+			return nil, fmt.Errorf("failed to interpolate variable %q: %w", k, err)
 		}
+		result[k] = interpolated
+		workCtx.Variables.Set(k, interpolated)
 	}
 	return result, nil
 }
@@ -1,16 +1,33 @@
 package runner
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"regexp"
 	"time"
 
+	yaml "gopkg.in/yaml.v3"
+
 	"github.com/titpetric/atkins/model"
-	"github.com/titpetric/atkins/psexec"
 	"github.com/titpetric/atkins/treeview"
 )
 
+// hasTimeouts returns true if the pipeline or any of its jobs sets a timeout.
+func hasTimeouts(pipeline *model.Pipeline) bool {
+	if pipeline.Timeout != "" {
+		return true
+	}
+	for _, job := range pipeline.GetJobs() {
+		if job.Timeout != "" {
+			return true
+		}
+	}
+	return false
+}
+
 // parseTimeout parses a timeout string into a duration, using default if empty
 func parseTimeout(timeoutStr string, defaultTimeout time.Duration) time.Duration {
 	if timeoutStr == "" {
@@ -35,6 +52,53 @@ func (e *Executor) ExecuteJob(parentCtx context.Context, execCtx *ExecutionConte
 		return fmt.Errorf("job is nil in execution context")
 	}
 
+	if job.Workspace != "" {
+		workspace, err := prepareWorkspace(execCtx, job)
+		if err != nil {
+			return err
+		}
+		err = e.executeJob(parentCtx, execCtx)
+		if err != nil && !errors.Is(err, ErrJobSkipped) {
+			return fmt.Errorf("%w\nworkspace kept at %s", err, workspace.Dir)
+		}
+		if rmErr := workspace.Remove(); rmErr != nil {
+			return fmt.Errorf("failed to remove workspace %s: %w", workspace.Dir, rmErr)
+		}
+		return err
+	}
+
+	return e.executeJob(parentCtx, execCtx)
+}
+
+// prepareWorkspace creates the job workspace and points the job at it.
+func prepareWorkspace(execCtx *ExecutionContext, job *model.Job) (*Workspace, error) {
+	root, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+
+	var workspace *Workspace
+	switch job.Workspace {
+	case WorkspaceClean:
+		workspace, err = newCleanWorkspace(root, job.Name)
+	case WorkspaceWorktree:
+		workspace, err = newWorktreeWorkspace(root, job.Name)
+	default:
+		return nil, fmt.Errorf("job %q: unknown workspace %q, expected %q or %q", job.Name, job.Workspace, WorkspaceClean, WorkspaceWorktree)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("job %q: %w", job.Name, err)
+	}
+
+	execCtx.Dir = workspace.Path(execCtx.Dir)
+	execCtx.Workspace = workspace
+	return workspace, nil
+}
+
+// executeJob runs the job steps in the job's execution context.
+func (e *Executor) executeJob(parentCtx context.Context, execCtx *ExecutionContext) error {
+	job := execCtx.Job
+
 	// Parse job timeout
 	jobTimeout := parseTimeout(job.Timeout, e.opts.DefaultTimeout)
 
@@ -42,9 +106,38 @@ func (e *Executor) ExecuteJob(parentCtx context.Context, execCtx *ExecutionConte
 	ctx, cancel := context.WithTimeout(parentCtx, jobTimeout)
 	defer cancel()
 
+	// Waiting for the lock counts against the job timeout
+	if job.Lock != nil {
+		var jobNode *treeview.Node
+		if execCtx.CurrentJob != nil {
+			jobNode = execCtx.CurrentJob.Node
+		}
+		release, err := acquireLock(ctx, execCtx, job.Lock, lockHolder{Job: job.Name}, jobNode)
+		if err != nil {
+			return fmt.Errorf("job %q: %w", job.Name, err)
+		}
+		defer release()
+	}
+
 	// Store context in execution context for use in steps
 	execCtx.Context = ctx
 
+	// Show the time left in the tree when the job sets a timeout
+	if deadline, ok := ctx.Deadline(); ok && job.Timeout != "" && execCtx.CurrentJob != nil {
+		execCtx.CurrentJob.SetDeadline(deadline, jobTimeout)
+	}
+
+	if err := validateInputs(job.Inputs, "inputs"); err != nil {
+		return fmt.Errorf("job %q: %w", job.Name, err)
+	}
+	setJobVars(execCtx, execCtx.Pipeline, job)
+	setInputs(execCtx, job.Inputs, nil)
+
+	// Allocate ports first, so vars, env and dir can use them
+	if err := allocatePorts(execCtx, job); err != nil {
+		return err
+	}
+
 	// Evaluate job-level working directory and merge variables.
 	// The order depends on whether dir references variables:
 	// - Static dir (e.g., "/path"): evaluate dir first, then vars use that cwd
@@ -68,7 +161,14 @@ func (e *Executor) ExecuteJob(parentCtx context.Context, execCtx *ExecutionConte
 	steps := job.Children()
 
 	if !job.For.IsEmpty() {
-		return e.executeJobWithForLoop(ctx, execCtx, steps)
+		// Checked once before the iterations, which evaluate the job if: each
+		e.preflight(parentCtx, execCtx, job)
+		ctx, release, err := execCtx.services.acquire(ctx, job.Name, job.Services)
+		if err != nil {
+			return err
+		}
+		defer release()
+		return serviceExitCause(ctx, e.executeJobWithForLoop(ctx, execCtx, steps))
 	}
 
 	// Evaluate job-level if condition
@@ -80,7 +180,123 @@ func (e *Executor) ExecuteJob(parentCtx context.Context, execCtx *ExecutionConte
 		return ErrJobSkipped
 	}
 
-	return e.executeSteps(ctx, execCtx, steps)
+	e.preflight(parentCtx, execCtx, job)
+	if err := ValidateJobRequirements(execCtx, job); err != nil {
+		return err
+	}
+
+	var cacheKey string
+	cacheDir := cmp.Or(execCtx.Dir, ".")
+	cache := e.opts.Cache
+	if cache != nil && job.Cache != nil {
+		vars, env := cacheState(execCtx, job)
+		cacheKey, err = cache.Key(job, cacheDir, vars, env)
+		if err != nil {
+			return fmt.Errorf("job %q: failed to compute cache key: %w", job.Name, err)
+		}
+	}
+
+	// Jobs completed by the resumed run are skipped, unless they changed
+	if execCtx.checkpoint != nil {
+		hash, err := jobHash(execCtx, job, cacheKey)
+		if err != nil {
+			return fmt.Errorf("job %q: failed to compute checkpoint hash: %w", job.Name, err)
+		}
+		if execCtx.checkpoint.resumedJob(job.Name, hash) {
+			if execCtx.CurrentJob != nil {
+				markResumed(execCtx.CurrentJob.Node)
+			}
+			execCtx.checkpoint.completeJob(job.Name)
+			return nil
+		}
+	}
+
+	if cacheKey != "" {
+		hit, err := cache.Restore(ctx, cacheKey, cacheDir)
+		if entryErr := (*CacheEntryError)(nil); errors.As(err, &entryErr) {
+			execCtx.warnings.add("job %q: %v", job.Name, err)
+			err = nil
+		}
+		if err != nil {
+			return fmt.Errorf("job %q: %w", job.Name, err)
+		}
+		if hit {
+			markCached(execCtx.CurrentJob)
+			execCtx.checkpoint.completeJob(job.Name)
+			return nil
+		}
+	}
+
+	ctx, release, err := execCtx.services.acquire(ctx, job.Name, job.Services)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	if job.Benchmark != nil {
+		err = e.executeBenchmark(ctx, execCtx, steps)
+	} else {
+		err = e.executeSteps(ctx, execCtx, steps)
+	}
+	if err = serviceExitCause(ctx, err); err != nil {
+		return err
+	}
+	if cacheKey != "" {
+		if err := cache.Save(ctx, cacheKey, cacheDir, job.Cache.Outputs); err != nil {
+			return fmt.Errorf("job %q: %w", job.Name, err)
+		}
+	}
+	execCtx.checkpoint.completeJob(job.Name)
+	return nil
+}
+
+// cacheState returns the state the cache key of the job depends on
+// besides its definition: the resolved variables, and the environment
+// set by the pipeline or referenced as $NAME by the job. The run
+// metadata, `atkins`, $ATKINS_RUN_ID and $ATKINS_ARTIFACTS, and the
+// ports allocated for the run are left out, as they'd change the key on
+// every run.
+func cacheState(execCtx *ExecutionContext, job *model.Job) (map[string]any, map[string]string) {
+	vars := make(map[string]any)
+	if execCtx.Variables != nil {
+		execCtx.Variables.Walk(func(key string, value any) {
+			if key != AtkinsVar && key != "ports" {
+				vars[key] = value
+			}
+		})
+	}
+
+	definition, _ := yaml.Marshal(job)
+	referenced := make(map[string]bool)
+	for _, match := range envReferencePattern.FindAllStringSubmatch(string(definition), -1) {
+		referenced[match[1]] = true
+	}
+	env := make(map[string]string)
+	for key, value := range execCtx.Env {
+		if key == RunIDEnv || key == ArtifactsEnv {
+			continue
+		}
+		if current, ok := os.LookupEnv(key); referenced[key] || !ok || current != value {
+			env[key] = value
+		}
+	}
+	return vars, env
+}
+
+// envReferencePattern matches the environment variables referenced by
+// commands, e.g. $GOFLAGS or ${GOFLAGS}.
+var envReferencePattern = regexp.MustCompile(`\$\{?([A-Za-z_][A-Za-z0-9_]*)`)
+
+// markCached marks the job node as restored from the cache, with its
+// steps skipped.
+func markCached(jobNode *treeview.TreeNode) {
+	if jobNode == nil {
+		return
+	}
+	jobNode.SetCached(true)
+	for _, child := range jobNode.GetChildren() {
+		child.Node.SetStatus(treeview.StatusSkipped)
+	}
 }
 
 // executeJobWithForLoop runs all job steps repeatedly for each iteration of the job-level for loop.
@@ -95,16 +311,8 @@ func (e *Executor) executeJobWithForLoop(ctx context.Context, execCtx *Execution
 	forCtx := execCtx.Copy()
 	forCtx.Step = syntheticStep
 
-	exec := psexec.NewWithOptions(&psexec.Options{
-		DefaultDir: execCtx.Dir,
-		DefaultEnv: execCtx.Env.Environ(),
-	})
 	iterations, err := ExpandFor(forCtx, func(script string) (string, error) {
-		result := exec.Run(ctx, exec.ShellCommand(script))
-		if !result.Success() {
-			return "", NewExecError(result)
-		}
-		return result.Output(), nil
+		return forCtx.runScript(ctx, script)
 	})
 	if err != nil {
 		return fmt.Errorf("failed to expand job-level for loop for job %q: %w", job.Name, err)
@@ -139,12 +347,18 @@ func (e *Executor) executeJobWithForLoop(ctx context.Context, execCtx *Execution
 			}
 		}
 
+		// Validate requirements per iteration — loop variables may satisfy them
+		if err := ValidateJobRequirements(iterCtx, job); err != nil {
+			return err
+		}
+
 		// Re-evaluate job dir per iteration — it may reference loop variables
 		if job.Dir != "" {
 			dir, err := InterpolateString(job.Dir, iterCtx)
 			if err != nil {
 				return fmt.Errorf("failed to interpolate job dir %q for iteration: %w", job.Dir, err)
 			}
+			dir = iterCtx.Workspace.Path(dir)
 			if err := validateDir(dir); err != nil {
 				return fmt.Errorf("job dir %q: %w", dir, err)
 			}
@@ -155,7 +369,7 @@ func (e *Executor) executeJobWithForLoop(ctx context.Context, execCtx *Execution
 		iterLabel := fmt.Sprintf("iteration %d", idx)
 		if job.Desc != "" {
 			if interpolated, err := InterpolateString(job.Desc, iterCtx); err == nil {
-				iterLabel = interpolated
+				iterLabel = iterCtx.scrub.scrub(interpolated)
 			}
 		}
 
@@ -166,7 +380,7 @@ func (e *Executor) executeJobWithForLoop(ctx context.Context, execCtx *Execution
 			job.Summarize,
 		)
 		iterNode.SetStatus(treeview.StatusRunning)
-		buildAndAddStepsToJob(&treeview.TreeNode{Node: iterNode}, steps)
+		buildAndAddStepsToJob(&treeview.TreeNode{Node: iterNode}, steps, iterCtx.scrub)
 		jobNode.AddChild(iterNode)
 
 		// Point the iteration context at this sub-node so executeSteps finds step nodes
@@ -195,8 +409,8 @@ func evaluateDirAndVars(ctx *ExecutionContext, job *model.Job, checkDir bool, la
 	}
 	// Set up lazy evaluation for vars
 	if job.Decl != nil && job.Decl.Vars != nil {
-		lazyVars := NewContextVariablesWithResolver(job.Decl.Vars, func(s string) (string, error) {
-			return InterpolateString(s, ctx)
+		lazyVars := NewContextVariablesWithValueResolver(job.Decl.Vars, func(v any) (any, error) {
+			return resolveVar(ctx, v)
 		})
 		// Copy existing variables into the lazy storage
 		ctx.Variables.Walk(func(k string, v any) {
@@ -211,6 +425,7 @@ func evaluateDirAndVars(ctx *ExecutionContext, job *model.Job, checkDir bool, la
 		if err != nil {
 			return fmt.Errorf("failed to interpolate %s dir %q: %w", prefix, job.Dir, err)
 		}
+		dir = ctx.Workspace.Path(dir)
 		if checkDir {
 			if err := validateDir(dir); err != nil {
 				return fmt.Errorf("%s dir %q: %w", prefix, dir, err)
@@ -241,8 +456,8 @@ func evaluateDirAndVars(ctx *ExecutionContext, job *model.Job, checkDir bool, la
 func evaluateDirAndVarsSkipDir(ctx *ExecutionContext, job *model.Job) error {
 	// Set up lazy evaluation for vars
 	if job.Decl != nil && job.Decl.Vars != nil {
-		lazyVars := NewContextVariablesWithResolver(job.Decl.Vars, func(s string) (string, error) {
-			return InterpolateString(s, ctx)
+		lazyVars := NewContextVariablesWithValueResolver(job.Decl.Vars, func(v any) (any, error) {
+			return resolveVar(ctx, v)
 		})
 		ctx.Variables.Walk(func(k string, v any) {
 			lazyVars.Set(k, v)
@@ -1,40 +1,53 @@
 package runner
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"runtime"
-	"sync"
-
-	"golang.org/x/sync/errgroup"
 
 	"github.com/titpetric/atkins/eventlog"
 	"github.com/titpetric/atkins/model"
-	"github.com/titpetric/atkins/psexec"
 	"github.com/titpetric/atkins/treeview"
 )
 
 // executeSteps runs a sequence of steps (deferred steps are already at the end of the list)
-func (e *Executor) executeSteps(ctx context.Context, execCtx *ExecutionContext, steps []*model.Step) error {
-	eg := new(errgroup.Group)
+func (e *Executor) executeSteps(ctx context.Context, execCtx *ExecutionContext, steps []*model.Step) (err error) {
+	// Supervised detached steps cancel the remaining steps when they exit early.
+	ctx, fail := context.WithCancelCause(ctx)
+	defer fail(nil)
+	defer func() {
+		var exitErr *ExitedEarlyError
+		if cause := context.Cause(ctx); errors.As(cause, &exitErr) {
+			err = cause
+		}
+	}()
+
+	// Detached steps are awaited before the next step that isn't detached,
+	// and with fail-fast a failed one cancels the others. Supervised steps
+	// and port forwards run on, until the deferred steps have run.
+	detached := newDetachedGroup(ctx, failFast(execCtx.Job, true), 0)
+	defer func() {
+		if err != nil {
+			fail(err)
+		}
+		if waitErr := detached.Wait(); err == nil {
+			err = waitErr
+		}
+	}()
+
+	// Steps record their results for the `if:` of the following steps
+	execCtx.steps = newStepResults()
+
+	// After a step fails, only steps checking the step results still run
+	var failed error
 
-	detached := 0
 	deferredSteps := []*model.Step{}
 	deferredIndices := []int{}
 
-	// Wait for all detached steps to complete before running deferred steps.
-	wait := func() error {
-		if detached > 0 {
-			if err := eg.Wait(); err != nil {
-				return err
-			}
-			detached = 0
-		}
-		return nil
-	}
-
 	// First pass: execute non-detached steps and collect deferred steps
 	for idx, step := range steps {
 		if step.IsDeferred() {
@@ -44,36 +57,63 @@ func (e *Executor) executeSteps(ctx context.Context, execCtx *ExecutionContext,
 			continue
 		}
 
+		if failed != nil && (step.Detach || step.PortForward != nil || !checksResults(step)) {
+			continue
+		}
+
+		if step.PortForward != nil {
+			if err := detached.Wait(); err != nil {
+				return err
+			}
+			stop, err := e.startPortForward(ctx, execCtx, step, jobStepNode(execCtx, step, idx))
+			if err != nil {
+				return err
+			}
+			// Torn down after the deferred steps have run
+			defer stop()
+			continue
+		}
+
+		if step.Detach && step.Ready != nil {
+			stop, err := e.startSupervised(ctx, execCtx, step, jobStepNode(execCtx, step, idx), fail)
+			if err != nil {
+				return err
+			}
+			// Stopped after the deferred steps have run
+			defer stop()
+			continue
+		}
+
 		if step.Detach {
-			detached++
-			step := steps[idx]
-			idx := idx
-			eg.Go(func() error {
-				// Each detached task tree gets its own cancellable context
-				// so that if an error occurs, only this tree is cancelled
-				treeCtx, cancel := context.WithCancel(ctx)
-				defer cancel()
-				return e.executeStep(treeCtx, execCtx, step, idx)
+			detached.Go(func(ctx context.Context) error {
+				return e.executeStep(ctx, execCtx, step, idx)
 			})
 			continue
 		}
 
-		if err := wait(); err != nil {
+		if err := detached.Wait(); err != nil {
 			return err
 		}
 
-		if err := e.executeStep(ctx, execCtx, steps[idx], idx); err != nil {
-			return err
+		if err := e.executeStep(ctx, execCtx, step, idx); err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+			failed = cmp.Or(failed, err)
 		}
 	}
 
-	if err := wait(); err != nil {
+	// Wait for all detached steps to complete before running deferred steps.
+	if err := detached.Wait(); err != nil {
 		return err
 	}
 
 	// Second pass: execute deferred steps after all detached steps are done
 	for i, step := range deferredSteps {
 		stepIdx := deferredIndices[i]
+		if failed != nil && !checksResults(step) {
+			continue
+		}
 
 		// Find the deferred step node by looking for deferred nodes in the tree
 		// We need to find it by matching deferred status, not by index (since for loops may have expanded)
@@ -107,16 +147,20 @@ func (e *Executor) executeSteps(ctx context.Context, execCtx *ExecutionContext,
 		}
 	}
 
-	return nil
+	return failed
 }
 
 // executeStepWithNode runs a single step with a provided node
-func (e *Executor) executeStepWithNode(ctx context.Context, execCtx *ExecutionContext, step *model.Step, stepNode *treeview.Node) error {
+func (e *Executor) executeStepWithNode(ctx context.Context, execCtx *ExecutionContext, step *model.Step, stepNode *treeview.Node) (err error) {
+	var skipped bool
+	defer func() { execCtx.steps.record(step, stepResult(skipped, err)) }()
+
 	stepCtx, err := e.prepareStepContext(execCtx, ctx, step)
 	if err != nil {
 		stepNode.SetStatus(treeview.StatusFailed)
 		return err
 	}
+	setAtkinsVars(stepCtx, map[string]any{"step_index": stepCtx.StepSequence})
 
 	// Merge step-level vars with interpolation - but skip if step has a for loop
 	// When !step.For.IsEmpty(), vars may depend on loop variables (e.g., ${{item}})
@@ -141,9 +185,34 @@ func (e *Executor) executeStepWithNode(ctx context.Context, execCtx *ExecutionCo
 	if !shouldRun {
 		seqIndex := execCtx.NextStepIndex()
 		e.logStepSkipped(execCtx, step, stepNode, seqIndex)
+		skipped = true
 		return nil
 	}
 
+	if step.Lock != nil {
+		release, err := e.lockStep(ctx, stepCtx, step, stepNode)
+		if err != nil {
+			return err
+		}
+		defer release()
+	}
+
+	if step.CheckClean {
+		verify, checkErr := e.checkClean(stepCtx, step, stepNode)
+		if checkErr != nil {
+			return checkErr
+		}
+		defer func() { err = verify(err) }()
+	}
+
+	if step.Budget != "" {
+		verify, budgetErr := e.checkBudget(stepCtx, step, stepNode)
+		if budgetErr != nil {
+			return budgetErr
+		}
+		defer func() { err = verify(err) }()
+	}
+
 	// Handle for loop expansion
 	if !step.For.IsEmpty() {
 		return e.executeStepWithForLoop(ctx, stepCtx, step, stepNode, 0)
@@ -153,16 +222,27 @@ func (e *Executor) executeStepWithNode(ctx context.Context, execCtx *ExecutionCo
 			stepNode.SetStatus(treeview.StatusRunning)
 			return e.executeTaskStep(ctx, stepCtx, step, stepNode)
 		}
+		if step.Lint != nil {
+			return e.executeLintStep(ctx, stepCtx, step, stepNode)
+		}
 	}
 
 	// Execute all commands
-	return e.executeCommands(ctx, stepCtx, step, stepNode, step.Commands(), 0)
+	err = e.executeCommands(ctx, stepCtx, step, stepNode, step.Commands(), 0)
+	if errors.Is(err, ErrSkippedExitCode) {
+		skipped = true
+		return nil
+	}
+	return err
 }
 
 // executeStep runs a single step
-func (e *Executor) executeStep(ctx context.Context, execCtx *ExecutionContext, step *model.Step, stepIndex int) error {
+func (e *Executor) executeStep(ctx context.Context, execCtx *ExecutionContext, step *model.Step, stepIndex int) (err error) {
 	defer execCtx.Render()
 
+	var skipped bool
+	defer func() { execCtx.steps.record(step, stepResult(skipped, err)) }()
+
 	// Get the next sequential step index from the PARENT context before copying
 	// This ensures all steps in a job get unique sequential indices
 	seqIndex := execCtx.NextStepIndex()
@@ -172,21 +252,25 @@ func (e *Executor) executeStep(ctx context.Context, execCtx *ExecutionContext, s
 		return err
 	}
 	stepCtx.StepSequence = seqIndex // Set the index for this step
+	setAtkinsVars(stepCtx, map[string]any{"step_index": seqIndex})
 
-	// Get step node from tree, or create one on-demand for dynamically expanded iterations
-	var stepNode *treeview.Node
-	if jobNode := execCtx.CurrentJob; jobNode != nil {
-		children := jobNode.GetChildren()
-		if stepIndex < len(children) {
-			stepNode = children[stepIndex].Node
-		} else {
-			stepNode = treeview.NewPendingStepNode(step.DisplayLabel(), step.IsDeferred(), step.Summarize)
-			stepNode.SetQuiet(step.Quiet)
-			jobNode.AddChild(stepNode)
-		}
+	stepNode := jobStepNode(execCtx, step, stepIndex)
+	if stepNode != nil {
 		stepCtx.CurrentStep = stepNode
 	}
 
+	// Completed steps are checkpointed, and skipped when resuming the run
+	if checkpoint := execCtx.checkpointed(); checkpoint != nil && !step.Detach && !step.IsDeferred() {
+		stepID := resolveStepID(execCtx.Job.Name, step, seqIndex)
+		if result, ok := checkpoint.resumedStep(stepID); ok {
+			markResumed(stepNode)
+			stepNode.SetStatus(treeview.StatusSkipped)
+			checkpoint.completeStep(stepID, result)
+			return nil
+		}
+		defer func() { checkpoint.completeStep(stepID, stepResult(skipped, err)) }()
+	}
+
 	// Merge step-level vars with interpolation - but skip if step has a for loop
 	// When !step.For.IsEmpty(), vars may depend on loop variables (e.g., ${{item}})
 	// and should be merged inside the iteration context instead
@@ -200,7 +284,7 @@ func (e *Executor) executeStep(ctx context.Context, execCtx *ExecutionContext, s
 	// Interpolate step display label with current context
 	if label := step.DisplayLabel(); label != "" {
 		if interpolated, err := InterpolateCommand(label, stepCtx); err == nil {
-			stepNode.SetName(interpolated)
+			stepNode.SetName(stepCtx.scrub.scrub(interpolated))
 		}
 	}
 
@@ -214,15 +298,59 @@ func (e *Executor) executeStep(ctx context.Context, execCtx *ExecutionContext, s
 
 	if !shouldRun {
 		e.logStepSkipped(execCtx, step, stepNode, seqIndex)
+		skipped = true
 		return nil
 	}
 
+	// Pause with --step or `breakpoint: true`
+	if e.opts.Step || step.Breakpoint {
+		run, err := e.breakpoint(stepCtx, step)
+		if err != nil {
+			stepNode.SetStatus(treeview.StatusFailed)
+			return err
+		}
+		if !run {
+			e.logStepSkipped(execCtx, step, stepNode, seqIndex)
+			skipped = true
+			return nil
+		}
+	}
+
+	if step.Lock != nil {
+		release, err := e.lockStep(ctx, stepCtx, step, stepNode)
+		if err != nil {
+			return err
+		}
+		defer release()
+	}
+
+	if step.CheckClean {
+		verify, checkErr := e.checkClean(stepCtx, step, stepNode)
+		if checkErr != nil {
+			return checkErr
+		}
+		defer func() { err = verify(err) }()
+	}
+
+	if step.Budget != "" {
+		verify, budgetErr := e.checkBudget(stepCtx, step, stepNode)
+		if budgetErr != nil {
+			return budgetErr
+		}
+		defer func() { err = verify(err) }()
+	}
+
 	// Handle task invocation
 	if step.Task != "" {
 		stepNode.SetStatus(treeview.StatusRunning)
 		return e.executeTaskStep(ctx, stepCtx, step, stepNode)
 	}
 
+	// Handle formatters and linters
+	if step.Lint != nil {
+		return e.executeLintStep(ctx, stepCtx, step, stepNode)
+	}
+
 	// Handle for loop expansion
 	if !step.For.IsEmpty() {
 		stepNode.SetSummarize(step.Summarize)
@@ -235,7 +363,28 @@ func (e *Executor) executeStep(ctx context.Context, execCtx *ExecutionContext, s
 	}
 
 	// Execute all commands
-	return e.executeCommands(ctx, stepCtx, step, stepNode, step.Commands(), stepIndex)
+	err = e.executeCommands(ctx, stepCtx, step, stepNode, step.Commands(), stepIndex)
+	if errors.Is(err, ErrSkippedExitCode) {
+		skipped = true
+		return nil
+	}
+	return err
+}
+
+// lockStep waits for the lock of the step, with the step shown as running.
+func (e *Executor) lockStep(ctx context.Context, stepCtx *ExecutionContext, step *model.Step, stepNode *treeview.Node) (func(), error) {
+	holder := lockHolder{Step: step.String()}
+	if stepCtx.Job != nil {
+		holder.Job = stepCtx.Job.Name
+	}
+	stepNode.SetStatus(treeview.StatusRunning)
+	stepCtx.Render()
+	release, err := acquireLock(ctx, stepCtx, step.Lock, holder, stepNode)
+	if err != nil {
+		stepNode.SetStatus(treeview.StatusFailed)
+		return nil, err
+	}
+	return release, nil
 }
 
 // recordStepCompletion updates execution counters and status for a completed step
@@ -246,6 +395,22 @@ func (e *Executor) recordStepCompletion(execCtx *ExecutionContext, passed bool)
 	}
 }
 
+// jobStepNode returns the tree node of the step at stepIndex in the
+// current job, or creates one on-demand for dynamically expanded iterations.
+func jobStepNode(execCtx *ExecutionContext, step *model.Step, stepIndex int) *treeview.Node {
+	jobNode := execCtx.CurrentJob
+	if jobNode == nil {
+		return nil
+	}
+	if children := jobNode.GetChildren(); stepIndex < len(children) {
+		return children[stepIndex].Node
+	}
+	stepNode := treeview.NewPendingStepNode(step.DisplayLabel(), step.IsDeferred(), step.Summarize)
+	stepNode.SetQuiet(step.Quiet)
+	jobNode.AddChild(stepNode)
+	return stepNode
+}
+
 // evaluateStepDir evaluates and validates the step's working directory
 func evaluateStepDir(execCtx *ExecutionContext) error {
 	if execCtx.Step == nil || execCtx.Step.Dir == "" {
@@ -255,9 +420,12 @@ func evaluateStepDir(execCtx *ExecutionContext) error {
 	if err != nil {
 		return fmt.Errorf("failed to interpolate step dir %q: %w", execCtx.Step.Dir, err)
 	}
-	// Resolve relative paths against the current execution directory
+	// Resolve relative paths against the current execution directory,
+	// absolute paths within the project against the job workspace
 	if !filepath.IsAbs(dir) && execCtx.Dir != "" {
 		dir = filepath.Join(execCtx.Dir, dir)
+	} else if filepath.IsAbs(dir) {
+		dir = execCtx.Workspace.Path(dir)
 	}
 	if info, statErr := os.Stat(dir); statErr != nil {
 		return fmt.Errorf("step dir %q: %w", dir, statErr)
@@ -346,7 +514,7 @@ func (e *Executor) logStepSkipped(execCtx *ExecutionContext, step *model.Step, s
 	}
 
 	// Log SKIP event
-	stepID := generateStepID(jobName, seqIndex)
+	stepID := resolveStepID(jobName, step, seqIndex)
 	if execCtx.EventLogger != nil {
 		startOffset := execCtx.EventLogger.GetElapsed()
 		execCtx.EventLogger.LogExec(eventlog.ResultSkipped, stepID, stepName, startOffset, 0, nil)
@@ -357,16 +525,8 @@ func (e *Executor) logStepSkipped(execCtx *ExecutionContext, step *model.Step, s
 // Each iteration becomes a separate execution with iteration variables overlaid on context.
 func (e *Executor) executeStepWithForLoop(ctx context.Context, execCtx *ExecutionContext, step *model.Step, stepNode *treeview.Node, stepIndex int) error {
 	// Expand the for loop to get all iterations
-	exec := psexec.NewWithOptions(&psexec.Options{
-		DefaultDir: execCtx.Dir,
-		DefaultEnv: execCtx.Env.Environ(),
-	})
 	iterations, err := ExpandFor(execCtx, func(script string) (string, error) {
-		result := exec.Run(ctx, exec.ShellCommand(script))
-		if !result.Success() {
-			return "", NewExecError(result)
-		}
-		return result.Output(), nil
+		return execCtx.runScript(ctx, script)
 	})
 	if err != nil {
 		stepNode.SetStatus(treeview.StatusFailed)
@@ -429,10 +589,9 @@ func (e *Executor) executeStepWithForLoop(ctx context.Context, execCtx *Executio
 			}
 
 			// Generate unique ID for this iteration
-			iterSeqIndex := execCtx.StepSequence + idx
-			iterID := fmt.Sprintf("jobs.%s.steps.%d", jobName, iterSeqIndex)
+			iterID := resolveIterationID(jobName, step, execCtx.StepSequence, idx)
 
-			iterNode := createIterationNode(iterID, nodeName, step.Summarize)
+			iterNode := createIterationNode(iterID, execCtx.scrub.scrub(nodeName), step.Summarize)
 
 			// If step has multiple commands, create child nodes for each command
 			if len(step.Cmds) > 0 {
@@ -443,7 +602,7 @@ func (e *Executor) executeStepWithForLoop(ctx context.Context, execCtx *Executio
 						stepNode.SetStatus(treeview.StatusFailed)
 						return fmt.Errorf("failed to interpolate command for iteration %d: %w", idx, err)
 					}
-					iterNode.AddChild(treeview.NewCmdNode(interpolatedCmd))
+					iterNode.AddChild(treeview.NewCmdNode(execCtx.scrub.scrub(interpolatedCmd)))
 				}
 			}
 
@@ -456,26 +615,21 @@ func (e *Executor) executeStepWithForLoop(ctx context.Context, execCtx *Executio
 	// Render tree with expanded iterations
 	execCtx.Render()
 
-	// Execute each iteration - use errgroup for detached (parallel) execution
-	var eg *errgroup.Group
-	if step.Detach {
-		eg = new(errgroup.Group)
-		eg.SetLimit(runtime.NumCPU())
-	}
+	// Execute each iteration - detached iterations run in parallel, and
+	// keep running when one fails unless the job sets fail_fast
+	detached := newDetachedGroup(ctx, failFast(execCtx.Job, false), runtime.NumCPU())
 
 	var lastErr error
-	var errMu sync.Mutex
-
 	for idx, iteration := range iterations {
 		// Check if context was cancelled before starting next iteration
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
+		if err := ctx.Err(); err != nil {
+			lastErr = err
+			break
+		}
+		// With fail_fast, a failed iteration stops the others, Wait reports it
+		if detached.Err() != nil {
+			break
 		}
-
-		idx := idx
-		iteration := iteration
 
 		executeIteration := func(iterCtx context.Context) error {
 			// Create iteration context by overlaying iteration variables on parent context
@@ -493,7 +647,7 @@ func (e *Executor) executeStepWithForLoop(ctx context.Context, execCtx *Executio
 			// Update step node label with interpolated display label for this iteration
 			if label := step.DisplayLabel(); label != "" {
 				if interpolated, err := InterpolateCommand(label, stepIterCtx); err == nil {
-					stepNode.SetName(interpolated)
+					stepNode.SetName(stepIterCtx.scrub.scrub(interpolated))
 				}
 			}
 
@@ -511,7 +665,8 @@ func (e *Executor) executeStepWithForLoop(ctx context.Context, execCtx *Executio
 				}
 			} else {
 				// Execute all commands for this iteration
-				if err := e.executeCommands(iterCtx, stepIterCtx, step, iterNode, step.Commands(), stepIndex); err != nil {
+				err := e.executeCommands(iterCtx, stepIterCtx, step, iterNode, step.Commands(), stepIndex)
+				if err != nil && !errors.Is(err, ErrSkippedExitCode) {
 					return err
 				}
 			}
@@ -519,31 +674,20 @@ func (e *Executor) executeStepWithForLoop(ctx context.Context, execCtx *Executio
 		}
 
 		if step.Detach {
-			// Run iterations in parallel - each gets its own cancellable context
-			eg.Go(func() error {
-				iterCtx, cancel := context.WithCancel(ctx)
-				defer cancel()
-				if err := executeIteration(iterCtx); err != nil {
-					errMu.Lock()
-					if lastErr == nil {
-						lastErr = err
-					}
-					errMu.Unlock()
-				}
-				return nil
-			})
-		} else {
-			// Run iterations sequentially - break on error
-			if err := executeIteration(ctx); err != nil {
-				lastErr = err
-				break
-			}
+			detached.Go(executeIteration)
+			continue
+		}
+
+		// Run iterations sequentially - break on error
+		if err := executeIteration(ctx); err != nil {
+			lastErr = err
+			break
 		}
 	}
 
 	// Wait for all parallel iterations to complete
-	if eg != nil {
-		_ = eg.Wait()
+	if err := detached.Wait(); err != nil && lastErr == nil {
+		lastErr = err
 	}
 
 	if lastErr != nil {
@@ -4,14 +4,10 @@ import (
 	"context"
 	"fmt"
 	"runtime"
-	"sync"
 	"time"
 
-	"golang.org/x/sync/errgroup"
-
 	"github.com/titpetric/atkins/eventlog"
 	"github.com/titpetric/atkins/model"
-	"github.com/titpetric/atkins/psexec"
 	"github.com/titpetric/atkins/treeview"
 )
 
@@ -121,6 +117,12 @@ func (e *Executor) executeTaskStep(ctx context.Context, execCtx *ExecutionContex
 	taskCtx.Parents = append(append([]string(nil), execCtx.Parents...), taskName)
 
 	err = func() error {
+		values, err := taskInputs(taskCtx, step, taskJob)
+		if err != nil {
+			return fmt.Errorf("task %q: %w", taskName, err)
+		}
+		setJobVars(taskCtx, targetPipeline, taskJob)
+		setInputs(taskCtx, taskJob.Inputs, values)
 		if err := MergeSkillVariables(taskCtx, targetPipeline.Decl); err != nil {
 			return err
 		}
@@ -133,6 +135,7 @@ func (e *Executor) executeTaskStep(ctx context.Context, execCtx *ExecutionContex
 		if err := MergeVariables(taskCtx, step.Decl); err != nil {
 			return err
 		}
+		e.preflight(ctx, taskCtx, taskJob)
 		if err := ValidateJobRequirements(taskCtx, taskJob); err != nil {
 			return err
 		}
@@ -189,16 +192,8 @@ func (e *Executor) executeTaskStepWithLoop(ctx context.Context, execCtx *Executi
 	defer execCtx.Render()
 
 	// Expand the for loop to get iteration contexts
-	exec := psexec.NewWithOptions(&psexec.Options{
-		DefaultDir: execCtx.Dir,
-		DefaultEnv: execCtx.Env.Environ(),
-	})
 	iterations, err := ExpandFor(execCtx, func(script string) (string, error) {
-		result := exec.Run(ctx, exec.ShellCommand(script))
-		if !result.Success() {
-			return "", NewExecError(result)
-		}
-		return result.Output(), nil
+		return execCtx.runScript(ctx, script)
 	})
 	if err != nil {
 		stepNode.SetStatus(treeview.StatusFailed)
@@ -228,8 +223,7 @@ func (e *Executor) executeTaskStepWithLoop(ctx context.Context, execCtx *Executi
 		}
 
 		// Generate unique ID for this iteration
-		iterSeqIndex := execCtx.StepSequence + idx
-		iterID := fmt.Sprintf("jobs.%s.steps.%d", jobName, iterSeqIndex)
+		iterID := resolveIterationID(jobName, step, execCtx.StepSequence, idx)
 
 		// Create a descriptive name showing the task and key variable values
 		iterName := step.Task
@@ -247,7 +241,7 @@ func (e *Executor) executeTaskStepWithLoop(ctx context.Context, execCtx *Executi
 			}
 		}
 
-		iterNode := createIterationNode(iterID, iterName, step.Summarize)
+		iterNode := createIterationNode(iterID, iterCtx.scrub.scrub(iterName), step.Summarize)
 
 		// Add as child of the step node
 		stepNode.AddChild(iterNode)
@@ -260,26 +254,21 @@ func (e *Executor) executeTaskStepWithLoop(ctx context.Context, execCtx *Executi
 	// Render tree with expanded iterations
 	execCtx.Render()
 
-	// Execute task for each iteration - use errgroup for detached (parallel) execution
-	var eg *errgroup.Group
-	if step.Detach {
-		eg = new(errgroup.Group)
-		eg.SetLimit(runtime.NumCPU())
-	}
+	// Execute task for each iteration - detached iterations run in parallel, and
+	// keep running when one fails unless the job sets fail_fast
+	detached := newDetachedGroup(ctx, failFast(execCtx.Job, false), runtime.NumCPU())
 
 	var lastErr error
-	var errMu sync.Mutex
-
 	for idx, iter := range iterations {
 		// Check if context was cancelled before starting next iteration
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
+		if err := ctx.Err(); err != nil {
+			lastErr = err
+			break
+		}
+		// With fail_fast, a failed iteration stops the others, Wait reports it
+		if detached.Err() != nil {
+			break
 		}
-
-		idx := idx
-		iter := iter
 
 		executeIteration := func(iterRunCtx context.Context) error {
 			iterTreeNode := iterationNodes[idx]
@@ -298,6 +287,14 @@ func (e *Executor) executeTaskStepWithLoop(ctx context.Context, execCtx *Executi
 			iterTreeNode.SetStatus(treeview.StatusRunning)
 			execCtx.Render()
 
+			values, err := taskInputs(iterCtx, step, taskJob)
+			if err != nil {
+				iterTreeNode.SetStatus(treeview.StatusFailed)
+				return fmt.Errorf("task %q: %w", step.Task, err)
+			}
+			setJobVars(iterCtx, targetPipeline, taskJob)
+			setInputs(iterCtx, taskJob.Inputs, values)
+
 			if err := MergeSkillVariables(iterCtx, targetPipeline.Decl); err != nil {
 				iterTreeNode.SetStatus(treeview.StatusFailed)
 				return err
@@ -317,6 +314,7 @@ func (e *Executor) executeTaskStepWithLoop(ctx context.Context, execCtx *Executi
 			}
 
 			// Validate job requirements (loop variables should satisfy requires)
+			e.preflight(ctx, iterCtx, taskJob)
 			if err := ValidateJobRequirements(iterCtx, taskJob); err != nil {
 				iterTreeNode.SetStatus(treeview.StatusFailed)
 				return err
@@ -334,31 +332,20 @@ func (e *Executor) executeTaskStepWithLoop(ctx context.Context, execCtx *Executi
 		}
 
 		if step.Detach {
-			// Run iterations in parallel - each gets its own cancellable context
-			eg.Go(func() error {
-				iterCtx, cancel := context.WithCancel(ctx)
-				defer cancel()
-				if err := executeIteration(iterCtx); err != nil {
-					errMu.Lock()
-					if lastErr == nil {
-						lastErr = err
-					}
-					errMu.Unlock()
-				}
-				return nil
-			})
-		} else {
-			// Run iterations sequentially - break on error
-			if err := executeIteration(ctx); err != nil {
-				lastErr = err
-				break
-			}
+			detached.Go(executeIteration)
+			continue
+		}
+
+		// Run iterations sequentially - break on error
+		if err := executeIteration(ctx); err != nil {
+			lastErr = err
+			break
 		}
 	}
 
 	// Wait for all parallel iterations to complete
-	if eg != nil {
-		_ = eg.Wait()
+	if err := detached.Wait(); err != nil && lastErr == nil {
+		lastErr = err
 	}
 
 	// Update parent node statuses based on results
@@ -4,6 +4,7 @@ import (
 	"context"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -205,7 +206,7 @@ func TestValidateJobRequirements(t *testing.T) {
 			name: "no requirements",
 			job: &model.Job{
 				Name:     "test_job",
-				Requires: []string{},
+				Requires: model.Requirements{},
 			},
 			variables: map[string]any{},
 			expectErr: false,
@@ -214,7 +215,7 @@ func TestValidateJobRequirements(t *testing.T) {
 			name: "requirements satisfied",
 			job: &model.Job{
 				Name:     "build_component",
-				Requires: []string{"component"},
+				Requires: model.Requirements{Vars: []string{"component"}},
 			},
 			variables: map[string]any{
 				"component": "src/main",
@@ -225,30 +226,30 @@ func TestValidateJobRequirements(t *testing.T) {
 			name: "single requirement missing",
 			job: &model.Job{
 				Name:     "build_component",
-				Requires: []string{"component"},
+				Requires: model.Requirements{Vars: []string{"component"}},
 			},
 			variables: map[string]any{},
 			expectErr: true,
-			errMsg:    "requires variables [component] but missing: [component]",
+			errMsg:    "missing variables: component",
 		},
 		{
 			name: "multiple requirements, some missing",
 			job: &model.Job{
 				Name:     "deploy_service",
-				Requires: []string{"service", "version", "env"},
+				Requires: model.Requirements{Vars: []string{"service", "version", "env"}},
 			},
 			variables: map[string]any{
 				"service": "api",
 				"version": "1.0.0",
 			},
 			expectErr: true,
-			errMsg:    "requires variables [service version env] but missing: [env]",
+			errMsg:    "missing variables: env",
 		},
 		{
 			name: "all requirements present",
 			job: &model.Job{
 				Name:     "deploy_service",
-				Requires: []string{"service", "version", "env"},
+				Requires: model.Requirements{Vars: []string{"service", "version", "env"}},
 			},
 			variables: map[string]any{
 				"service": "api",
@@ -383,7 +384,7 @@ func TestTaskInvocationWithForLoop(t *testing.T) {
 		// Create a task that requires the loop variable
 		task := &model.Job{
 			Name:     "build_component",
-			Requires: []string{"component"},
+			Requires: model.Requirements{Vars: []string{"component"}},
 		}
 
 		// Simulate iteration context with loop variable
@@ -402,7 +403,7 @@ func TestTaskInvocationWithForLoop(t *testing.T) {
 		// Create a task that requires a variable
 		task := &model.Job{
 			Name:     "build_component",
-			Requires: []string{"component"},
+			Requires: model.Requirements{Vars: []string{"component"}},
 		}
 
 		// Iteration context without the required variable
@@ -1047,6 +1048,44 @@ func TestExecuteStepWithForLoop_DetachContinuesOnError(t *testing.T) {
 	assert.NoError(t, err3, "third iteration should complete with detach")
 }
 
+// TestExecuteSteps_DetachFailFast verifies that a failed detached step
+// cancels the other detached steps, unless the job disables fail_fast.
+func TestExecuteSteps_DetachFailFast(t *testing.T) {
+	run := func(failFast *bool) (time.Duration, error) {
+		job := &model.Job{
+			Name:     "test_job",
+			FailFast: failFast,
+			Steps: []*model.Step{
+				{Run: "exit 1", Detach: true},
+				{Run: "sleep 1", Detach: true},
+			},
+		}
+
+		builder := treeview.NewBuilder("test")
+		ctx := &runner.ExecutionContext{
+			Variables:  runner.NewContextVariables(nil),
+			Env:        make(map[string]string),
+			Job:        job,
+			CurrentJob: builder.AddJob(job, nil, "test_job"),
+			Display:    treeview.NewSilentDisplay(),
+			Builder:    builder,
+		}
+
+		start := time.Now()
+		err := runner.NewExecutor().ExecuteJob(t.Context(), ctx)
+		return time.Since(start), err
+	}
+
+	elapsed, err := run(nil)
+	assert.Error(t, err)
+	assert.Less(t, elapsed, time.Second, "the sleeping step is cancelled")
+
+	off := false
+	elapsed, err = run(&off)
+	assert.Error(t, err)
+	assert.GreaterOrEqual(t, elapsed, time.Second, "the sleeping step runs to completion")
+}
+
 // TestExecuteStepWithForLoop_ContextCancellation verifies that iterations
 // respect context timeout/cancellation.
 func TestExecuteStepWithForLoop_ContextCancellation(t *testing.T) {
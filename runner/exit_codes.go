@@ -0,0 +1,49 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/titpetric/atkins/model"
+	"github.com/titpetric/atkins/psexec"
+)
+
+// ErrSkippedExitCode is returned for commands exiting with a code listed
+// in the skip_exit_codes of their step. The step is marked skipped.
+var ErrSkippedExitCode = errors.New("skipped by exit code")
+
+// exitOutcome returns whether a command passed, with accept_exit_codes
+// passing it besides 0, or is skipped by skip_exit_codes. Commands that
+// didn't exit, e.g. on a timeout, are never accepted or skipped.
+func exitOutcome(step *model.Step, result psexec.Result) (passed, skipped bool) {
+	if result.Success() {
+		return true, false
+	}
+	code := result.ExitCode()
+	if code <= 0 {
+		return false, false
+	}
+	if slices.Contains(step.AcceptExitCodes, code) {
+		return true, false
+	}
+	return false, slices.Contains(step.SkipExitCodes, code)
+}
+
+// validateExitCodes checks the accept_exit_codes and skip_exit_codes of a step.
+func validateExitCodes(step *model.Step) error {
+	for _, code := range append(slices.Clone(step.AcceptExitCodes), step.SkipExitCodes...) {
+		if code < 0 || code > 255 {
+			return fmt.Errorf("exit code %d out of range 0-255", code)
+		}
+	}
+	for _, code := range step.SkipExitCodes {
+		if code == 0 {
+			return errors.New("skip_exit_codes can't include 0")
+		}
+		if slices.Contains(step.AcceptExitCodes, code) {
+			return fmt.Errorf("exit code %d is both accepted and skipped", code)
+		}
+	}
+	return nil
+}
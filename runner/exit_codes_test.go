@@ -0,0 +1,75 @@
+package runner
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunPipeline_ExitCodes(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	pipelines, err := LoadPipelineFromReader(strings.NewReader(`
+jobs:
+  default:
+    steps:
+      - id: grep
+        run: printf 'a\n' | grep b
+        accept_exit_codes: [1]
+      - id: diff
+        run: exit 2
+        skip_exit_codes: [2]
+      - id: loop
+        for: n in [1, 2]
+        run: exit ${{ n }}
+        skip_exit_codes: [1]
+        accept_exit_codes: [2]
+      - if: steps.grep.result == 'passed' && steps.diff.result == 'skipped' && steps.loop.result == 'passed'
+        run: printf ok >> out
+      - run: exit 3
+        accept_exit_codes: [1]
+        skip_exit_codes: [2]
+`))
+	require.NoError(t, err)
+
+	err = RunPipeline(t.Context(), pipelines[0], PipelineOptions{
+		Jobs:   []string{"default"},
+		Silent: true,
+	})
+	var execErr ExecError
+	require.ErrorAs(t, err, &execErr)
+	assert.Equal(t, 3, execErr.LastExitCode)
+
+	out, err := os.ReadFile("out")
+	require.NoError(t, err)
+	assert.Equal(t, "ok", string(out))
+}
+
+func TestLinter_ExitCodes(t *testing.T) {
+	pipelines, err := LoadPipelineFromReader(strings.NewReader(`
+jobs:
+  default:
+    steps:
+      - run: grep -q x file
+        accept_exit_codes: [1]
+        skip_exit_codes: [2]
+      - run: "true"
+        accept_exit_codes: [256]
+      - run: "true"
+        skip_exit_codes: [0]
+      - run: "true"
+        accept_exit_codes: [1]
+        skip_exit_codes: [1]
+`))
+	require.NoError(t, err)
+
+	errs := NewLinter(pipelines[0]).Lint()
+	require.Len(t, errs, 3)
+	assert.Contains(t, errs[0].Detail+errs[1].Detail+errs[2].Detail, "exit code 1 is both accepted and skipped")
+	for _, e := range errs {
+		assert.Equal(t, "invalid exit codes", e.Issue)
+	}
+}
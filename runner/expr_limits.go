@@ -0,0 +1,78 @@
+package runner
+
+import (
+	"errors"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/ast"
+	"github.com/expr-lang/expr/vm"
+)
+
+// Limits for expressions in `if:`, `for:` and `${{ }}` interpolation,
+// so a pathological expression can't hang the loader or runner.
+var (
+	// ExprMaxNodes is the maximum number of AST nodes in an expression.
+	ExprMaxNodes uint = 1000
+
+	// ExprMemoryBudget is the maximum number of allocations while evaluating
+	// an expression, e.g. the items produced by a range.
+	ExprMemoryBudget uint = 100000
+
+	// ExprOperationBudget is the maximum number of loop iterations while
+	// evaluating an expression, e.g. the items visited by map() or filter().
+	ExprOperationBudget uint = 1000000
+)
+
+// exprOperation is the function counting loop iterations, see exprProgram.
+const exprOperation = "__atkins_operation"
+
+// exprProgram is a compiled expression counting the iterations of its loops.
+type exprProgram struct {
+	*vm.Program
+	ops uint
+}
+
+// compileExpr compiles an expression with the node limit applied, and
+// every loop body counting against the operation budget.
+func compileExpr(input string, ops ...expr.Option) (*exprProgram, error) {
+	p := &exprProgram{}
+	options := []expr.Option{
+		expr.MaxNodes(ExprMaxNodes),
+		expr.Function(exprOperation, func(...any) (any, error) {
+			p.ops++
+			if p.ops > ExprOperationBudget {
+				return nil, errors.New("operation budget exceeded")
+			}
+			return nil, nil
+		}),
+		expr.Patch(operationCounter{}),
+	}
+	program, err := expr.Compile(input, append(options, ops...)...)
+	if err != nil {
+		return nil, err
+	}
+	p.Program = program
+	return p, nil
+}
+
+// runExpr evaluates a compiled expression within the memory and operation
+// budgets. The node limit and the budgets bound the work of an evaluation,
+// so it needs no timeout.
+func runExpr(program *exprProgram, env any) (any, error) {
+	program.ops = 0
+	machine := vm.VM{MemoryBudget: ExprMemoryBudget}
+	return machine.Run(program.Program, env)
+}
+
+// operationCounter prepends a call to exprOperation to the body of every
+// predicate, which runs once per loop iteration.
+type operationCounter struct{}
+
+func (operationCounter) Visit(node *ast.Node) {
+	if predicate, ok := (*node).(*ast.PredicateNode); ok {
+		predicate.Node = &ast.SequenceNode{Nodes: []ast.Node{
+			&ast.CallNode{Callee: &ast.IdentifierNode{Value: exprOperation}},
+			predicate.Node,
+		}}
+	}
+}
@@ -0,0 +1,53 @@
+package runner
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExprLimits(t *testing.T) {
+	ctx := &ExecutionContext{Variables: NewContextVariables(nil), Env: Env{}}
+
+	t.Run("memory budget", func(t *testing.T) {
+		_, err := evaluateExpression("len(1..10000000)", ctx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `"len(1..10000000)"`)
+		assert.Contains(t, err.Error(), "memory budget exceeded")
+	})
+
+	t.Run("node limit", func(t *testing.T) {
+		input := strings.Repeat("1 + ", 2000) + "1"
+		_, err := evaluateExpression(input, ctx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to compile expression")
+	})
+
+	t.Run("nested loops", func(t *testing.T) {
+		// Every evaluation of the inner range counts against the budget
+		_, err := evaluateExpression("len(map(1..1000, len(map(1..1000, # * 2))))", ctx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "memory budget exceeded")
+	})
+
+	t.Run("operation budget", func(t *testing.T) {
+		// Loops over a variable allocate nothing, the iterations count
+		program, err := compileExpr("sum(map(xs, sum(map(xs, sum(map(xs, 1))))))")
+		require.NoError(t, err)
+		_, err = runExpr(program, map[string]any{"xs": make([]any, 1000)})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "operation budget exceeded")
+
+		value, err := evaluateExpression("len(filter(1..1000, # % 2 == 0))", ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 500, value)
+	})
+
+	t.Run("within limits", func(t *testing.T) {
+		value, err := evaluateExpression("len(1..1000)", ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1000, value)
+	})
+}
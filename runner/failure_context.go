@@ -0,0 +1,157 @@
+package runner
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/titpetric/atkins/colors"
+)
+
+// FailureContextLines is the number of trailing output lines shown under a failed step.
+var FailureContextLines = 20
+
+// DetachedOutputLines is the number of trailing output lines shown under a
+// detached step once it finishes.
+var DetachedOutputLines = 20
+
+// outputLine is a line of command output and the stream it was written to.
+type outputLine struct {
+	text   string
+	stderr bool
+}
+
+// CombinedOutputWriter records stdout and stderr lines in the order they
+// were written, so stderr lines can be told apart in the combined output.
+type CombinedOutputWriter struct {
+	mu      sync.Mutex
+	lines   []outputLine
+	pending [2]bytes.Buffer // Incomplete lines of stdout and stderr
+}
+
+// NewCombinedOutputWriter creates a new CombinedOutputWriter.
+func NewCombinedOutputWriter() *CombinedOutputWriter {
+	return &CombinedOutputWriter{}
+}
+
+// Stdout returns the writer for the standard output stream.
+func (w *CombinedOutputWriter) Stdout() io.Writer {
+	return streamWriter{w: w}
+}
+
+// Stderr returns the writer for the standard error stream.
+func (w *CombinedOutputWriter) Stderr() io.Writer {
+	return streamWriter{w: w, stderr: true}
+}
+
+type streamWriter struct {
+	w      *CombinedOutputWriter
+	stderr bool
+}
+
+// Write implements io.Writer.
+func (s streamWriter) Write(p []byte) (int, error) {
+	s.w.write(p, s.stderr)
+	return len(p), nil
+}
+
+func (w *CombinedOutputWriter) write(p []byte, stderr bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	pending := &w.pending[0]
+	if stderr {
+		pending = &w.pending[1]
+	}
+	pending.Write(p)
+	for {
+		line, err := pending.ReadString('\n')
+		if err != nil {
+			// Keep the incomplete line for the next write
+			pending.Reset()
+			pending.WriteString(line)
+			return
+		}
+		w.lines = append(w.lines, outputLine{text: strings.TrimSuffix(line, "\n"), stderr: stderr})
+	}
+}
+
+// tail returns up to n trailing non-empty lines, including incomplete ones.
+func (w *CombinedOutputWriter) tail(n int) []outputLine {
+	w.mu.Lock()
+	lines := append([]outputLine(nil), w.lines...)
+	for i := range w.pending {
+		if w.pending[i].Len() > 0 {
+			lines = append(lines, outputLine{text: w.pending[i].String(), stderr: i == 1})
+		}
+	}
+	w.mu.Unlock()
+
+	result := make([]outputLine, 0, len(lines))
+	for _, line := range lines {
+		text := strings.TrimRight(processCarriageReturns(strings.TrimSuffix(line.text, "\r")), " \t\r")
+		if text != "" {
+			result = append(result, outputLine{text: text, stderr: line.stderr})
+		}
+	}
+	if len(result) > n {
+		result = result[len(result)-n:]
+	}
+	return result
+}
+
+// failureContext returns the lines shown under a failed step: the
+// interpolated command, followed by the last lines of its output with
+// stderr lines highlighted.
+func failureContext(cmd string, output *CombinedOutputWriter) []string {
+	var lines []string
+	for i, line := range strings.Split(strings.TrimSpace(cmd), "\n") {
+		prefix := "$ "
+		if i > 0 {
+			prefix = "> "
+		}
+		lines = append(lines, colors.BrightCyan(prefix+line))
+	}
+	for _, line := range output.tail(FailureContextLines) {
+		if line.stderr {
+			lines = append(lines, colors.BrightRed(line.text))
+			continue
+		}
+		lines = append(lines, line.text)
+	}
+	return lines
+}
+
+// spoolLines returns the last n lines of the output of a detached step,
+// stdout and stderr in the order they were written. Repeated lines, such
+// as progress updates, are collapsed into one with a count.
+func spoolLines(output *CombinedOutputWriter, n int) []string {
+	var lines []string
+	var last string
+	repeats := 0
+	flush := func() {
+		if repeats > 1 {
+			last = fmt.Sprintf("%s %s", last, colors.Gray(fmt.Sprintf("(x%d)", repeats)))
+		}
+		if repeats > 0 {
+			lines = append(lines, last)
+		}
+	}
+	for _, line := range output.tail(math.MaxInt) {
+		if repeats > 0 && line.text == last {
+			repeats++
+			continue
+		}
+		flush()
+		last, repeats = line.text, 1
+	}
+	flush()
+
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines
+}
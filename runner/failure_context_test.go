@@ -0,0 +1,57 @@
+package runner
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/titpetric/atkins/colors"
+)
+
+func TestCombinedOutputWriter(t *testing.T) {
+	w := NewCombinedOutputWriter()
+	fmt.Fprint(w.Stdout(), "building\npart")
+	fmt.Fprint(w.Stderr(), "warning: old\n")
+	fmt.Fprint(w.Stdout(), "ial\n\n")
+	fmt.Fprint(w.Stderr(), "fatal: broken")
+
+	assert.Equal(t, []outputLine{
+		{text: "building"},
+		{text: "warning: old", stderr: true},
+		{text: "partial"},
+		{text: "fatal: broken", stderr: true},
+	}, w.tail(10))
+	assert.Equal(t, []outputLine{{text: "fatal: broken", stderr: true}}, w.tail(1))
+}
+
+func TestFailureContext(t *testing.T) {
+	w := NewCombinedOutputWriter()
+	fmt.Fprintln(w.Stdout(), "building")
+	fmt.Fprintln(w.Stderr(), "fatal: broken")
+
+	lines := failureContext("make build\nexit 2\n", w)
+	assert.Equal(t, []string{
+		colors.BrightCyan("$ make build"),
+		colors.BrightCyan("> exit 2"),
+		"building",
+		colors.BrightRed("fatal: broken"),
+	}, lines)
+}
+
+func TestSpoolLines(t *testing.T) {
+	w := NewCombinedOutputWriter()
+	fmt.Fprintln(w.Stderr(), "Network app_default Creating")
+	fmt.Fprintln(w.Stderr(), "Container app-db-1 Starting")
+	fmt.Fprintln(w.Stderr(), "Container app-db-1 Starting")
+	fmt.Fprintln(w.Stderr(), "Container app-db-1 Starting")
+	fmt.Fprintln(w.Stdout(), "Container app-db-1 Started")
+
+	assert.Equal(t, []string{
+		"Network app_default Creating",
+		"Container app-db-1 Starting " + colors.Gray("(x3)"),
+		"Container app-db-1 Started",
+	}, spoolLines(w, 10))
+	assert.Equal(t, []string{"Container app-db-1 Started"}, spoolLines(w, 1))
+	assert.Empty(t, spoolLines(NewCombinedOutputWriter(), 10))
+}
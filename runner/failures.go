@@ -0,0 +1,149 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/titpetric/atkins/colors"
+	"github.com/titpetric/atkins/psexec"
+	"github.com/titpetric/atkins/treeview"
+)
+
+// FailedStep is a failed command, listed in the summary of a failed run.
+type FailedStep struct {
+	Job      string
+	Step     string
+	ExitCode int
+	Duration time.Duration
+	Error    string // First line of the error output
+	Label    string // Label of the failure hint matching the output
+	Hint     string // Suggested remedy, see DefaultHints
+}
+
+// failureLog collects the failed steps of a run, shared across copies
+// of the execution context.
+type failureLog struct {
+	mu    sync.Mutex
+	steps []FailedStep
+}
+
+// add records a failed step.
+func (f *failureLog) add(step FailedStep) {
+	if f == nil {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.steps = append(f.steps, step)
+}
+
+// list returns the failed steps in the order they failed.
+func (f *failureLog) list() []FailedStep {
+	if f == nil {
+		return nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]FailedStep(nil), f.steps...)
+}
+
+// failedStepLabel returns the step name, or the first line of the command.
+func failedStepLabel(step string, cmd string) string {
+	if step != "" {
+		return step
+	}
+	label, _, _ := strings.Cut(strings.TrimSpace(cmd), "\n")
+	return label
+}
+
+// firstErrorLine returns the first line of stderr, falling back to the
+// last line of stdout and the process error.
+func firstErrorLine(result psexec.Result) string {
+	for _, line := range strings.Split(result.ErrorOutput(), "\n") {
+		if line = strings.TrimSpace(colors.StripANSI(line)); line != "" {
+			return line
+		}
+	}
+	lines := strings.Split(strings.TrimSpace(colors.StripANSI(result.Output())), "\n")
+	if last := strings.TrimSpace(lines[len(lines)-1]); last != "" {
+		return last
+	}
+	if result.Err() != nil {
+		return result.Err().Error()
+	}
+	return ""
+}
+
+// failureSummaryWidth limits the width of the step and error columns.
+const failureSummaryWidth = 60
+
+// printFailureSummary prints a table of the failed steps.
+func printFailureSummary(w io.Writer, steps []FailedStep) {
+	if len(steps) == 0 {
+		return
+	}
+
+	header := []string{"JOB", "STEP", "EXIT", "DURATION", "ERROR"}
+	rows := make([][]string, 0, len(steps))
+	for _, step := range steps {
+		rows = append(rows, []string{
+			step.Job,
+			truncate(step.Step, failureSummaryWidth),
+			strconv.Itoa(step.ExitCode),
+			step.Duration.Round(time.Millisecond).String(),
+			truncate(step.Error, failureSummaryWidth),
+		})
+	}
+
+	printTable(w, colors.BrightRed(fmt.Sprintf("%d %s:", len(steps), treeview.CurrentLabels().FailedSteps)), header, rows)
+
+	// Hints for the failures matching a failure hint
+	var printed bool
+	for _, step := range steps {
+		if step.Hint == "" {
+			continue
+		}
+		if !printed {
+			fmt.Fprintln(w)
+			printed = true
+		}
+		fmt.Fprintf(w, "  %s %s: %s\n", step.Job, truncate(step.Step, failureSummaryWidth), hintLine(step.Label, step.Hint))
+	}
+}
+
+// printTable prints a summary table with a title and aligned columns.
+func printTable(w io.Writer, title string, header []string, rows [][]string) {
+	widths := make([]int, len(header))
+	for _, row := range append([][]string{header}, rows...) {
+		for i, cell := range row {
+			widths[i] = max(widths[i], len([]rune(cell)))
+		}
+	}
+
+	format := func(row []string) string {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = cell + strings.Repeat(" ", widths[i]-len([]rune(cell)))
+		}
+		return strings.TrimRight(strings.Join(cells, "  "), " ")
+	}
+
+	fmt.Fprintf(w, "\n%s\n\n", title)
+	fmt.Fprintf(w, "  %s\n", colors.Gray(format(header)))
+	for _, row := range rows {
+		fmt.Fprintf(w, "  %s\n", format(row))
+	}
+}
+
+// truncate shortens s to n runes, marking the cut with an ellipsis.
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
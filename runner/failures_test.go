@@ -0,0 +1,67 @@
+package runner
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/titpetric/atkins/colors"
+	"github.com/titpetric/atkins/psexec"
+)
+
+func TestFirstErrorLine(t *testing.T) {
+	exec := psexec.New()
+
+	result := exec.Run(t.Context(), exec.ShellCommand("echo out; echo; echo 'first' >&2; echo second >&2; exit 1"))
+	assert.Equal(t, "first", firstErrorLine(result))
+
+	result = exec.Run(t.Context(), exec.ShellCommand("echo one; echo two; exit 1"))
+	assert.Equal(t, "two", firstErrorLine(result))
+
+	result = exec.Run(t.Context(), exec.ShellCommand("exit 3"))
+	assert.Equal(t, "exit status 3", firstErrorLine(result))
+}
+
+func TestFailedStepLabel(t *testing.T) {
+	assert.Equal(t, "build", failedStepLabel("build", "go build ./..."))
+	assert.Equal(t, "go build ./...", failedStepLabel("", "\ngo build ./...\ngo vet ./...\n"))
+}
+
+func TestPrintFailureSummary(t *testing.T) {
+	var buf bytes.Buffer
+	printFailureSummary(&buf, nil)
+	assert.Empty(t, buf.String())
+
+	log := &failureLog{}
+	log.add(FailedStep{Job: "build", Step: "go build ./...", ExitCode: 2, Duration: 1500 * time.Millisecond, Error: "main.go:3:1: syntax error"})
+	log.add(FailedStep{Job: "lint", Step: "golangci-lint run", ExitCode: 1, Duration: 20 * time.Millisecond, Error: "unused variable"})
+
+	printFailureSummary(&buf, log.list())
+	assert.Equal(t, "\n2 failed step(s):\n\n"+
+		"  JOB    STEP               EXIT  DURATION  ERROR\n"+
+		"  build  go build ./...     2     1.5s      main.go:3:1: syntax error\n"+
+		"  lint   golangci-lint run  1     20ms      unused variable\n",
+		colors.StripANSI(buf.String()))
+}
+
+func TestPrintFailureSummary_Hints(t *testing.T) {
+	var buf bytes.Buffer
+	printFailureSummary(&buf, []FailedStep{
+		{Job: "build", Step: "go build ./...", ExitCode: 1, Duration: time.Second, Error: "no space left on device", Label: "disk", Hint: "free up space"},
+		{Job: "lint", Step: "golangci-lint run", ExitCode: 1, Duration: time.Second, Error: "unused variable"},
+	})
+	assert.Equal(t, "\n2 failed step(s):\n\n"+
+		"  JOB    STEP               EXIT  DURATION  ERROR\n"+
+		"  build  go build ./...     1     1s        no space left on device\n"+
+		"  lint   golangci-lint run  1     1s        unused variable\n"+
+		"\n"+
+		"  build go build ./...: [disk] free up space\n",
+		colors.StripANSI(buf.String()))
+}
+
+func TestTruncate(t *testing.T) {
+	assert.Equal(t, "short", truncate("short", 10))
+	assert.Equal(t, "long …", truncate("long text", 6))
+}
@@ -0,0 +1,247 @@
+package runner
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// FormatOptions configures FormatPipeline.
+type FormatOptions struct {
+	// Indent is the number of spaces per indentation level, 2 when unset.
+	Indent int
+	// Expand rewrites string jobs (`build: go build ./...`) into
+	// a job with `desc:` and `run:`.
+	Expand bool
+}
+
+// Canonical key order of pipelines, jobs and steps. Keys not listed are
+// kept in their original order at the position of "*".
+var (
+	pipelineKeyOrder = []string{"name", "desc", "dir", "when", "include", "vars", "env", "*", "tools", "services", "jobs", "tasks"}
+	jobKeyOrder      = []string{"desc", "aliases", "show", "if", "for", "depends_on", "requires", "services", "dir", "workspace", "timeout", "include", "vars", "env", "*", "run", "cmd", "steps", "cmds"}
+	stepKeyOrder     = []string{"id", "name", "desc", "if", "for", "dir", "include", "vars", "env", "*", "run", "cmd", "cmds", "argv", "task", "port_forward", "defer"}
+)
+
+// FormatPipeline rewrites pipeline YAML in the canonical style. Keys are
+// ordered as in pipelineKeyOrder, jobKeyOrder and stepKeyOrder, and the
+// indentation is made consistent. Comments are preserved.
+func FormatPipeline(data []byte, opts FormatOptions) ([]byte, error) {
+	return rewriteDocuments(data, opts.Indent, func(root *yaml.Node) {
+		formatPipelineNode(root, opts)
+	})
+}
+
+// rewriteDocuments decodes each YAML document in data, calls rewrite with
+// its root node and encodes the result. Comments are preserved.
+func rewriteDocuments(data []byte, indent int, rewrite func(root *yaml.Node)) ([]byte, error) {
+	if indent <= 0 {
+		indent = 2
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(indent)
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	documents := 0
+	for {
+		var doc yaml.Node
+		if err := decoder.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("error decoding pipeline: %w", err)
+		}
+		if len(doc.Content) > 0 {
+			root := doc.Content[0]
+			// A comment above the first key is the file header, keep it on top
+			if root.Kind == yaml.MappingNode && len(root.Content) > 0 && root.Content[0].HeadComment != "" {
+				doc.HeadComment = strings.TrimSpace(doc.HeadComment + "\n" + root.Content[0].HeadComment)
+				root.Content[0].HeadComment = ""
+			}
+			rewrite(root)
+		}
+		if err := encoder.Encode(&doc); err != nil {
+			return nil, err
+		}
+		documents++
+	}
+	if documents == 0 {
+		return data, nil
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return separateSections(buf.Bytes(), indent), nil
+}
+
+// separateSections puts a blank line before top-level keys and jobs,
+// together with the comments above them. The YAML encoder drops the blank
+// lines of the original file.
+func separateSections(data []byte, indent int) []byte {
+	lines := strings.Split(string(data), "\n")
+	jobIndent := strings.Repeat(" ", indent)
+
+	isKey := func(line, prefix string) bool {
+		if !strings.HasPrefix(line, prefix) || len(line) == len(prefix) {
+			return false
+		}
+		switch line[len(prefix)] {
+		case ' ', '#', '-':
+			return false
+		}
+		return true
+	}
+
+	result := make([]string, 0, len(lines))
+	inJobs, firstJob, firstKey := false, false, true
+	for _, line := range lines {
+		section := false
+		switch {
+		case isKey(line, ""):
+			section = !firstKey
+			firstKey = false
+			inJobs = strings.HasPrefix(line, "jobs:") || strings.HasPrefix(line, "tasks:")
+			firstJob = true
+		case inJobs && isKey(line, jobIndent):
+			section = !firstJob
+			firstJob = false
+		}
+
+		if section {
+			// Keep comments above the key together with it
+			at := len(result)
+			prefix := line[:len(line)-len(strings.TrimLeft(line, " "))]
+			for at > 0 && strings.HasPrefix(result[at-1], prefix+"#") {
+				at--
+			}
+			if at > 0 && result[at-1] != "" {
+				result = append(result[:at], append([]string{""}, result[at:]...)...)
+			}
+		}
+		result = append(result, line)
+	}
+	return []byte(strings.Join(result, "\n"))
+}
+
+func formatPipelineNode(node *yaml.Node, opts FormatOptions) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	sortKeys(node, pipelineKeyOrder)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		switch node.Content[i].Value {
+		case "jobs", "tasks":
+			jobs := node.Content[i+1]
+			if jobs.Kind != yaml.MappingNode {
+				continue
+			}
+			for j := 0; j+1 < len(jobs.Content); j += 2 {
+				if opts.Expand {
+					expandJob(jobs.Content[j+1])
+				}
+				formatJobNode(jobs.Content[j+1])
+			}
+		}
+	}
+}
+
+func formatJobNode(node *yaml.Node) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	sortKeys(node, jobKeyOrder)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		switch node.Content[i].Value {
+		case "steps", "cmds":
+			formatStepsNode(node.Content[i+1])
+		}
+	}
+}
+
+func formatStepsNode(node *yaml.Node) {
+	if node.Kind != yaml.SequenceNode {
+		return
+	}
+	for _, step := range node.Content {
+		formatStepNode(step)
+	}
+}
+
+func formatStepNode(node *yaml.Node) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	sortKeys(node, stepKeyOrder)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == "defer" {
+			formatStepNode(node.Content[i+1])
+		}
+	}
+}
+
+// expandJob rewrites a string job into a mapping with the same behaviour.
+func expandJob(node *yaml.Node) {
+	if node.Kind != yaml.ScalarNode || node.Tag == "!!null" {
+		return
+	}
+	cmd := strings.TrimSpace(node.Value)
+	style := yaml.Style(0)
+	if strings.Contains(cmd, "\n") {
+		style = yaml.LiteralStyle
+	}
+	value := func(key string) []*yaml.Node {
+		return []*yaml.Node{
+			{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+			{Kind: yaml.ScalarNode, Tag: "!!str", Value: cmd, Style: style},
+		}
+	}
+
+	*node = yaml.Node{
+		Kind:        yaml.MappingNode,
+		Tag:         "!!map",
+		HeadComment: node.HeadComment,
+		LineComment: node.LineComment,
+		FootComment: node.FootComment,
+		Content:     append(value("desc"), value("run")...),
+	}
+}
+
+// sortKeys orders the keys of a mapping node by their position in order.
+func sortKeys(node *yaml.Node, order []string) {
+	rank := make(map[string]int, len(order))
+	for i, key := range order {
+		rank[key] = i
+	}
+	other := rank["*"]
+
+	type pair struct {
+		key, value *yaml.Node
+	}
+	pairs := make([]pair, 0, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		pairs = append(pairs, pair{node.Content[i], node.Content[i+1]})
+	}
+
+	keyRank := func(key string) int {
+		if r, ok := rank[key]; ok {
+			return r
+		}
+		return other
+	}
+	sort.SliceStable(pairs, func(i, j int) bool {
+		return keyRank(pairs[i].key.Value) < keyRank(pairs[j].key.Value)
+	})
+
+	content := make([]*yaml.Node, 0, len(node.Content))
+	for _, p := range pairs {
+		content = append(content, p.key, p.value)
+	}
+	node.Content = content
+}
@@ -0,0 +1,81 @@
+package runner
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatPipeline(t *testing.T) {
+	input := `#!/usr/bin/env atkins
+jobs:
+    # Build the binary
+    build:
+        steps:
+            - run: go build ./...
+              name: build
+              if: ci
+        depends_on: fmt
+        desc: Build
+    fmt: gofmt -w .   # format
+env:
+    vars:
+        CGO_ENABLED: 0
+name: Project
+`
+
+	expected := `#!/usr/bin/env atkins
+
+name: Project
+
+env:
+  vars:
+    CGO_ENABLED: 0
+
+jobs:
+  # Build the binary
+  build:
+    desc: Build
+    depends_on: fmt
+    steps:
+      - name: build
+        if: ci
+        run: go build ./...
+
+  fmt: gofmt -w . # format
+`
+
+	formatted, err := FormatPipeline([]byte(input), FormatOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, expected, string(formatted))
+
+	again, err := FormatPipeline(formatted, FormatOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, expected, string(again), "formatting is idempotent")
+}
+
+func TestFormatPipeline_Expand(t *testing.T) {
+	formatted, err := FormatPipeline([]byte("jobs:\n  fmt: gofmt -w .\n"), FormatOptions{Expand: true})
+	require.NoError(t, err)
+	assert.Equal(t, "jobs:\n  fmt:\n    desc: gofmt -w .\n    run: gofmt -w .\n", string(formatted))
+
+	// The expanded job behaves like the string job
+	short, err := LoadPipelineFromReader(strings.NewReader("jobs:\n  fmt: gofmt -w .\n"))
+	require.NoError(t, err)
+	expanded, err := LoadPipelineFromReader(strings.NewReader(string(formatted)))
+	require.NoError(t, err)
+	assert.Equal(t, short[0].Jobs["fmt"].Desc, expanded[0].Jobs["fmt"].Desc)
+	assert.Equal(t, short[0].Jobs["fmt"].Passthru, expanded[0].Jobs["fmt"].Passthru)
+	assert.Equal(t, short[0].Jobs["fmt"].Steps, expanded[0].Jobs["fmt"].Steps)
+}
+
+func TestFormatPipeline_Invalid(t *testing.T) {
+	_, err := FormatPipeline([]byte("jobs: [\n"), FormatOptions{})
+	require.Error(t, err)
+
+	formatted, err := FormatPipeline(nil, FormatOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, formatted)
+}
@@ -0,0 +1,37 @@
+package runner
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunPipeline_Gantt(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	pipelines, err := LoadPipelineFromReader(strings.NewReader(`
+name: chart
+jobs:
+  build:
+    steps:
+      - run: echo $(printf sub) > out
+`))
+	require.NoError(t, err)
+	require.NoError(t, RunPipeline(t.Context(), pipelines[0], PipelineOptions{
+		Jobs:      []string{"build"},
+		Silent:    true,
+		RunID:     "ci-1",
+		GanttFile: "gantt.md",
+	}))
+
+	// The chart doesn't need an event log file
+	chart, err := os.ReadFile("gantt.md")
+	require.NoError(t, err)
+	assert.Contains(t, string(chart), "    title chart ci-1\n")
+	assert.Contains(t, string(chart), "    section build\n    echo sub > out :")
+	assert.Contains(t, string(chart), "    ↳ $(printf sub) :active, ")
+	assert.NoDirExists(t, ".atkins")
+}
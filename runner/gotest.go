@@ -0,0 +1,249 @@
+package runner
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/titpetric/atkins/eventlog"
+	"github.com/titpetric/atkins/treeview"
+)
+
+// goTestJSONPattern matches commands printing a `go test -json` stream.
+var goTestJSONPattern = regexp.MustCompile(`\bgo\s+test\b[^|;&\n]*\s-json\b`)
+
+// gotestsumPattern matches gotestsum commands writing the stream to a file.
+var gotestsumPattern = regexp.MustCompile(`\bgotestsum\b[^|;&\n]*\s--jsonfile[= ]("[^"]+"|'[^']+'|\S+)`)
+
+// coverProfilePattern matches the coverage profile written by `go test`.
+var coverProfilePattern = regexp.MustCompile(`\s-coverprofile[= ]("[^"]+"|'[^']+'|\S+)`)
+
+// coverProfilePath returns the coverage profile the command writes,
+// relative to dir, or "" if it doesn't write one.
+func coverProfilePath(cmd, dir string) string {
+	match := coverProfilePattern.FindStringSubmatch(cmd)
+	if match == nil {
+		return ""
+	}
+	path := strings.Trim(match[1], `"'`)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	return path
+}
+
+// testEvent is an event of the `go test -json` stream (test2json).
+type testEvent struct {
+	Action  string
+	Package string
+	Test    string
+	Elapsed float64
+	Output  string
+}
+
+// testCase is the result of a single test.
+type testCase struct {
+	Name    string
+	Result  eventlog.Result
+	Elapsed float64
+	Output  []string
+}
+
+// testPackage is the result of a package and its tests.
+type testPackage struct {
+	Name    string
+	Result  eventlog.Result
+	Elapsed float64
+	Tests   []*testCase
+}
+
+// count returns the number of tests with the result.
+func (p *testPackage) count(result eventlog.Result) int {
+	count := 0
+	for _, test := range p.Tests {
+		if test.Result == result {
+			count++
+		}
+	}
+	return count
+}
+
+// testReport holds the results of a `go test -json` run.
+type testReport struct {
+	Packages []*testPackage
+}
+
+// goTestJSONSource returns where the `go test -json` stream of the command
+// is found: "-" for stdout, the gotestsum --jsonfile path, or "" when the
+// command doesn't produce one.
+func goTestJSONSource(cmd string) string {
+	if match := gotestsumPattern.FindStringSubmatch(cmd); match != nil {
+		return strings.Trim(match[1], `"'`)
+	}
+	if goTestJSONPattern.MatchString(cmd) {
+		return "-"
+	}
+	return ""
+}
+
+// readTestReport parses the test stream of a command from its stdout or
+// the json file, relative to dir.
+func readTestReport(source, stdout, dir string) *testReport {
+	if source != "-" {
+		if !filepath.IsAbs(source) {
+			source = filepath.Join(dir, source)
+		}
+		f, err := os.Open(source)
+		if err != nil {
+			return nil
+		}
+		defer f.Close()
+		return parseTestJSON(f)
+	}
+	return parseTestJSON(strings.NewReader(stdout))
+}
+
+// parseTestJSON parses a `go test -json` stream. Lines that aren't JSON,
+// like build errors, are skipped. It returns nil without test events.
+func parseTestJSON(r io.Reader) *testReport {
+	report := &testReport{}
+	packages := map[string]*testPackage{}
+	tests := map[string]*testCase{}
+
+	pkg := func(name string) *testPackage {
+		if p, ok := packages[name]; ok {
+			return p
+		}
+		p := &testPackage{Name: name}
+		packages[name] = p
+		report.Packages = append(report.Packages, p)
+		return p
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var event testEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil || event.Package == "" {
+			continue
+		}
+
+		p := pkg(event.Package)
+		if event.Test == "" {
+			switch event.Action {
+			case "pass", "fail", "skip":
+				p.Result = testResult(event.Action)
+				p.Elapsed = event.Elapsed
+			}
+			continue
+		}
+
+		key := event.Package + "\x00" + event.Test
+		test, ok := tests[key]
+		if !ok {
+			test = &testCase{Name: event.Test}
+			tests[key] = test
+			p.Tests = append(p.Tests, test)
+		}
+		switch event.Action {
+		case "output":
+			test.Output = append(test.Output, strings.TrimRight(event.Output, "\n"))
+		case "pass", "fail", "skip":
+			test.Result = testResult(event.Action)
+			test.Elapsed = event.Elapsed
+		}
+	}
+
+	if len(report.Packages) == 0 {
+		return nil
+	}
+	return report
+}
+
+func testResult(action string) eventlog.Result {
+	switch action {
+	case "pass":
+		return eventlog.ResultPass
+	case "fail":
+		return eventlog.ResultFail
+	}
+	return eventlog.ResultSkipped
+}
+
+func testStatus(result eventlog.Result) treeview.Status {
+	switch result {
+	case eventlog.ResultPass:
+		return treeview.StatusPassed
+	case eventlog.ResultFail:
+		return treeview.StatusFailed
+	case eventlog.ResultSkipped:
+		return treeview.StatusSkipped
+	}
+	return treeview.StatusPending
+}
+
+// Nodes returns a node per package with the pass/fail counts, with
+// the failed tests and their output as children.
+func (r *testReport) Nodes() []*treeview.Node {
+	nodes := make([]*treeview.Node, 0, len(r.Packages))
+	for _, p := range r.Packages {
+		label := p.Name
+		if len(p.Tests) > 0 {
+			counts := []string{fmt.Sprintf("%d passed", p.count(eventlog.ResultPass))}
+			if failed := p.count(eventlog.ResultFail); failed > 0 {
+				counts = append(counts, fmt.Sprintf("%d failed", failed))
+			}
+			if skipped := p.count(eventlog.ResultSkipped); skipped > 0 {
+				counts = append(counts, fmt.Sprintf("%d skipped", skipped))
+			}
+			label += " (" + strings.Join(counts, ", ") + ")"
+		}
+
+		node := treeview.NewNode(label)
+		node.SetStatus(testStatus(p.Result))
+		node.SetDuration(p.Elapsed)
+		for _, test := range p.Tests {
+			// Subtests fail their parent, the leaf holds the output
+			if test.Result != eventlog.ResultFail || r.hasFailedSubtest(p, test) {
+				continue
+			}
+			child := treeview.NewNode(test.Name)
+			child.SetStatus(treeview.StatusFailed)
+			child.SetDuration(test.Elapsed)
+			child.SetOutput(test.Output)
+			node.AddChild(child)
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+func (r *testReport) hasFailedSubtest(p *testPackage, parent *testCase) bool {
+	for _, test := range p.Tests {
+		if test.Result == eventlog.ResultFail && strings.HasPrefix(test.Name, parent.Name+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Entries returns the package and test results for the event log.
+// Nil-safe: returns nil on a nil receiver.
+func (r *testReport) Entries() []eventlog.TestEntry {
+	if r == nil {
+		return nil
+	}
+	var entries []eventlog.TestEntry
+	for _, p := range r.Packages {
+		entries = append(entries, eventlog.TestEntry{Package: p.Name, Result: p.Result, Duration: p.Elapsed})
+		for _, test := range p.Tests {
+			entries = append(entries, eventlog.TestEntry{Package: p.Name, Test: test.Name, Result: test.Result, Duration: test.Elapsed})
+		}
+	}
+	return entries
+}
@@ -0,0 +1,68 @@
+package runner
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/atkins/eventlog"
+	"github.com/titpetric/atkins/treeview"
+)
+
+const goTestStream = `{"Action":"start","Package":"example.com/a"}
+{"Action":"run","Package":"example.com/a","Test":"TestOK"}
+{"Action":"pass","Package":"example.com/a","Test":"TestOK","Elapsed":0.01}
+{"Action":"run","Package":"example.com/a","Test":"TestParse"}
+{"Action":"run","Package":"example.com/a","Test":"TestParse/empty"}
+{"Action":"output","Package":"example.com/a","Test":"TestParse/empty","Output":"    parse_test.go:12: unexpected EOF\n"}
+{"Action":"fail","Package":"example.com/a","Test":"TestParse/empty","Elapsed":0}
+{"Action":"fail","Package":"example.com/a","Test":"TestParse","Elapsed":0.02}
+{"Action":"skip","Package":"example.com/a","Test":"TestSlow","Elapsed":0}
+{"Action":"fail","Package":"example.com/a","Elapsed":0.5}
+# example.com/b
+{"Action":"pass","Package":"example.com/b","Elapsed":0.1}
+`
+
+func TestGoTestJSONSource(t *testing.T) {
+	assert.Equal(t, "-", goTestJSONSource("go test -json ./..."))
+	assert.Equal(t, "-", goTestJSONSource("go test -race -json -count=1 ./runner"))
+	assert.Equal(t, "report.json", goTestJSONSource("gotestsum --jsonfile report.json -- ./..."))
+	assert.Equal(t, "out dir/report.json", goTestJSONSource(`gotestsum --jsonfile="out dir/report.json"`))
+	assert.Equal(t, "", goTestJSONSource("go test ./..."))
+	assert.Equal(t, "", goTestJSONSource("go test ./... | tee out; echo -json"))
+}
+
+func TestParseTestJSON(t *testing.T) {
+	report := parseTestJSON(strings.NewReader(goTestStream))
+	require.NotNil(t, report)
+	require.Len(t, report.Packages, 2)
+
+	nodes := report.Nodes()
+	require.Len(t, nodes, 2)
+	assert.Equal(t, "example.com/a (1 passed, 2 failed, 1 skipped)", nodes[0].Name)
+	assert.Equal(t, treeview.StatusFailed, nodes[0].GetStatus())
+	assert.Equal(t, 0.5, nodes[0].GetDuration())
+
+	// Only the failing leaf test is shown, with its output
+	failed := nodes[0].GetChildren()
+	require.Len(t, failed, 1)
+	assert.Equal(t, "TestParse/empty", failed[0].Name)
+	assert.Contains(t, failed[0].GetOutput(), "    parse_test.go:12: unexpected EOF")
+
+	assert.Equal(t, "example.com/b", nodes[1].Name)
+	assert.Equal(t, treeview.StatusPassed, nodes[1].GetStatus())
+
+	entries := report.Entries()
+	require.Len(t, entries, 6)
+	assert.Equal(t, eventlog.TestEntry{Package: "example.com/a", Test: "TestOK", Result: eventlog.ResultPass, Duration: 0.01}, entries[1])
+
+	assert.Nil(t, parseTestJSON(strings.NewReader("ok  \texample.com/a\t0.1s\n")))
+}
+
+func TestCoverProfilePath(t *testing.T) {
+	assert.Equal(t, "/src/cover.out", coverProfilePath("go test -coverprofile=cover.out ./...", "/src"))
+	assert.Equal(t, "/tmp/c.out", coverProfilePath("go test -coverprofile '/tmp/c.out' ./...", "/src"))
+	assert.Equal(t, "", coverProfilePath("go test -cover ./...", "/src"))
+}
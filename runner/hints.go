@@ -0,0 +1,133 @@
+package runner
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"regexp"
+
+	"github.com/titpetric/atkins/colors"
+	"github.com/titpetric/atkins/model"
+	"github.com/titpetric/atkins/psexec"
+)
+
+// DefaultHintLabel labels hints that don't set a label.
+const DefaultHintLabel = "hint"
+
+// DefaultHints classify common infrastructure failures. Hints of the
+// pipeline are checked first.
+var DefaultHints = []*model.FailureHint{
+	{
+		Label: "disk",
+		Match: `no space left on device|disk quota exceeded`,
+		Hint:  "the disk is full, free up space (e.g. docker system prune) or use a larger disk",
+	},
+	{
+		Label: "dns",
+		Match: `could not resolve host|no such host|temporary failure in name resolution`,
+		Hint:  "a hostname didn't resolve, check the hostname and the DNS configuration",
+	},
+	{
+		Label: "network",
+		Match: `connection refused|connection reset by peer|network is unreachable|no route to host`,
+		Hint:  "a service isn't reachable, check that it's running and listening on the expected address",
+	},
+	{
+		Label: "timeout",
+		Match: `i/o timeout|deadline exceeded|timed out`,
+		Hint:  "an operation timed out, check the network or raise the timeout",
+	},
+	{
+		Label: "dependency",
+		Match: `module not found|cannot find module|no required module provides package|no matching distribution found`,
+		Hint:  "a dependency is missing, install the dependencies (e.g. go mod download, npm ci) or check the module path",
+	},
+	{
+		Label: "command",
+		Match: `command not found|executable file not found`,
+		Hint:  "a command is missing, install it or list it under requires: commands:",
+	},
+	{
+		Label: "permission",
+		Match: `permission denied|operation not permitted`,
+		Hint:  "check the file permissions and the user the step runs as",
+	},
+	{
+		Label: "memory",
+		Match: `out of memory|cannot allocate memory|oom-kill`,
+		Hint:  "the process ran out of memory, lower the parallelism or raise the memory limit",
+	},
+}
+
+// hintRule is a compiled failure hint.
+type hintRule struct {
+	match *regexp.Regexp
+	label string
+	hint  string
+}
+
+// hintClassifier matches the output of failed commands against the
+// failure hints, the first matching hint applies.
+type hintClassifier struct {
+	rules []hintRule
+}
+
+// newHintClassifier compiles the hints of the pipeline, followed by the
+// default hints.
+func newHintClassifier(hints []*model.FailureHint) (*hintClassifier, error) {
+	c := &hintClassifier{}
+	for _, hint := range append(append([]*model.FailureHint(nil), hints...), DefaultHints...) {
+		rule, err := compileHint(hint)
+		if err != nil {
+			return nil, err
+		}
+		c.rules = append(c.rules, rule)
+	}
+	return c, nil
+}
+
+// compileHint compiles the pattern of a hint.
+func compileHint(hint *model.FailureHint) (hintRule, error) {
+	if hint == nil || hint.Match == "" || hint.Hint == "" {
+		return hintRule{}, fmt.Errorf("hint needs a match and a hint")
+	}
+	match, err := regexp.Compile("(?i)" + hint.Match)
+	if err != nil {
+		return hintRule{}, fmt.Errorf("invalid hint match %q: %w", hint.Match, err)
+	}
+	return hintRule{match: match, label: cmp.Or(hint.Label, DefaultHintLabel), hint: hint.Hint}, nil
+}
+
+// classify returns the label and hint of the first hint matching any of
+// the output lines, or empty strings.
+func (c *hintClassifier) classify(lines []string) (label, hint string) {
+	if c == nil {
+		return "", ""
+	}
+	for _, rule := range c.rules {
+		for _, line := range lines {
+			if rule.match.MatchString(line) {
+				return rule.label, rule.hint
+			}
+		}
+	}
+	return "", ""
+}
+
+// failureLines returns the output lines of a failed command, followed by
+// the process error, for the failure hints to match.
+func failureLines(output *CombinedOutputWriter, result psexec.Result) []string {
+	var lines []string
+	for _, line := range output.tail(math.MaxInt) {
+		lines = append(lines, colors.StripANSI(line.text))
+	}
+	if result.Err() != nil {
+		lines = append(lines, result.Err().Error())
+	}
+	return lines
+}
+
+// hintLine formats a failure hint, e.g. "[disk] the disk is full".
+func hintLine(label, hint string) string {
+	return colors.BrightYellow("["+label+"]") + " " + hint
+}
@@ -0,0 +1,108 @@
+package runner
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/atkins/colors"
+	"github.com/titpetric/atkins/model"
+	"github.com/titpetric/atkins/treeview"
+)
+
+func TestHintClassifier(t *testing.T) {
+	c, err := newHintClassifier([]*model.FailureHint{
+		{Match: `ECONNREFUSED .*:5432`, Label: "database", Hint: "start the database"},
+		{Match: `flaky`, Hint: "retry"},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		lines []string
+		label string
+		hint  string
+	}{
+		{[]string{"write /tmp/x: No space left on device"}, "disk", DefaultHints[0].Hint},
+		{[]string{"ok", "go: cannot find module providing package foo"}, "dependency", DefaultHints[4].Hint},
+		// Hints of the pipeline are checked before the defaults
+		{[]string{"connect ECONNREFUSED 127.0.0.1:5432", "connection refused"}, "database", "start the database"},
+		{[]string{"a FLAKY test"}, DefaultHintLabel, "retry"},
+		{[]string{"exit status 1"}, "", ""},
+	}
+	for _, tc := range tests {
+		label, hint := c.classify(tc.lines)
+		assert.Equal(t, tc.label, label, tc.lines)
+		assert.Equal(t, tc.hint, hint, tc.lines)
+	}
+
+	_, err = newHintClassifier([]*model.FailureHint{{Match: "(", Hint: "x"}})
+	assert.ErrorContains(t, err, `invalid hint match "("`)
+	_, err = newHintClassifier([]*model.FailureHint{{Match: "x"}})
+	assert.ErrorContains(t, err, "hint needs a match and a hint")
+}
+
+func TestExecuteJob_FailureHint(t *testing.T) {
+	pipelines, err := LoadPipelineFromReader(strings.NewReader(`
+hints:
+  - match: migration .* locked
+    label: database
+    hint: release the lock with make unlock
+jobs:
+  build:
+    steps:
+      - run: printf 'migration 42 is locked\n' >&2; exit 3
+`))
+	require.NoError(t, err)
+	pipeline := pipelines[0]
+	job := pipeline.Jobs["build"]
+
+	hints, err := newHintClassifier(pipeline.Hints)
+	require.NoError(t, err)
+	builder := treeview.NewBuilder("test")
+	execCtx := &ExecutionContext{
+		Variables:  NewContextVariables(nil),
+		Env:        make(map[string]string),
+		Pipeline:   pipeline,
+		Job:        job,
+		CurrentJob: builder.AddJob(job, nil, "build"),
+		Display:    treeview.NewSilentDisplay(),
+		Builder:    builder,
+		failures:   &failureLog{},
+		hints:      hints,
+	}
+	require.Error(t, NewExecutor().ExecuteJob(t.Context(), execCtx))
+
+	steps := execCtx.failures.list()
+	require.Len(t, steps, 1)
+	assert.Equal(t, "database", steps[0].Label)
+	assert.Equal(t, "release the lock with make unlock", steps[0].Hint)
+
+	// The hint is shown under the failed step
+	output := execCtx.CurrentJob.GetChildren()[0].GetOutput()
+	require.NotEmpty(t, output)
+	assert.Equal(t, "[database] release the lock with make unlock", colors.StripANSI(output[len(output)-1]))
+}
+
+func TestLinter_Hints(t *testing.T) {
+	pipelines, err := LoadPipelineFromReader(strings.NewReader(`
+hints:
+  - match: disk full
+    hint: free up space
+  - match: "("
+    hint: broken
+  - match: no hint
+jobs:
+  default:
+    steps:
+      - run: "true"
+`))
+	require.NoError(t, err)
+
+	errs := NewLinter(pipelines[0]).Lint()
+	require.Len(t, errs, 2)
+	for _, e := range errs {
+		assert.Equal(t, "invalid hint", e.Issue)
+	}
+}
@@ -0,0 +1,220 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/titpetric/atkins/model"
+)
+
+// inputTypes are the types of an input schema.
+var inputTypes = []string{model.InputString, model.InputNumber, model.InputBool, model.InputList, model.InputMap}
+
+// dynamicInput stands in for `with:` values only known at runtime, like a
+// single ${{ expression }}, which match any type when linting.
+type dynamicInput struct{}
+
+// validateInputs checks the input schema of a job, path names the
+// inputs in errors, e.g. inputs.
+func validateInputs(inputs model.Inputs, path string) error {
+	var errs []error
+	for _, name := range slices.Sorted(maps.Keys(inputs)) {
+		if err := validateInput(inputs[name], path+"."+name); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return joinInputErrors(errs)
+}
+
+// validateInput checks the schema of an input.
+func validateInput(in *model.Input, path string) error {
+	if in == nil {
+		return nil
+	}
+	if in.Type != "" && !slices.Contains(inputTypes, in.Type) {
+		return fmt.Errorf("%s: unknown type %q, expected %s", path, in.Type, strings.Join(inputTypes, ", "))
+	}
+	if in.Items != nil {
+		if in.Type != model.InputList {
+			return fmt.Errorf("%s: items requires type %s", path, model.InputList)
+		}
+		if err := validateInput(in.Items, path+".items"); err != nil {
+			return err
+		}
+	}
+	if in.Properties != nil {
+		if in.Type != model.InputMap {
+			return fmt.Errorf("%s: properties requires type %s", path, model.InputMap)
+		}
+		if err := validateInputs(in.Properties, path+".properties"); err != nil {
+			return err
+		}
+	}
+	if in.Default != nil {
+		if err := checkInput(in, in.Default, path+".default"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// joinInputErrors joins errs on a single line, so multiple errors read
+// well after an error header.
+func joinInputErrors(errs []error) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Error()
+	}
+	return errors.New(strings.Join(messages, "; "))
+}
+
+// checkInputs checks values against the schema of their keys, path names
+// the values in errors, e.g. with.
+func checkInputs(inputs model.Inputs, values map[string]any, path string) error {
+	var errs []error
+	for _, name := range slices.Sorted(maps.Keys(inputs)) {
+		in := inputs[name]
+		value, ok := values[name]
+		if !ok {
+			if in != nil && in.Required {
+				errs = append(errs, fmt.Errorf("%s.%s: required input missing", path, name))
+			}
+			continue
+		}
+		if err := checkInput(in, value, path+"."+name); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(values)) {
+		if _, ok := inputs[name]; !ok {
+			errs = append(errs, fmt.Errorf("%s.%s: unknown input, expected one of %s", path, name, strings.Join(slices.Sorted(maps.Keys(inputs)), ", ")))
+		}
+	}
+	return joinInputErrors(errs)
+}
+
+// checkInput checks a value against the schema of an input.
+func checkInput(in *model.Input, value any, path string) error {
+	if in == nil {
+		return nil
+	}
+	got := inputType(value)
+	if in.Type != "" && got != "" && got != in.Type {
+		return fmt.Errorf("%s: expected %s, got %s", path, in.Type, got)
+	}
+	switch v := value.(type) {
+	case []any:
+		if in.Items == nil {
+			return nil
+		}
+		var errs []error
+		for i, item := range v {
+			if err := checkInput(in.Items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return joinInputErrors(errs)
+	case map[string]any:
+		if in.Properties == nil {
+			return nil
+		}
+		return checkInputs(in.Properties, v, path)
+	}
+	return nil
+}
+
+// inputType returns the input type of a value, empty when it's unknown.
+func inputType(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return model.InputString
+	case bool:
+		return model.InputBool
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return model.InputNumber
+	case []any, []string:
+		return model.InputList
+	case map[string]any:
+		return model.InputMap
+	}
+	return ""
+}
+
+// staticInput returns a `with:` value as known before the step runs,
+// with single ${{ expressions }} and decoded values replaced by dynamicInput.
+func staticInput(v any) any {
+	switch val := v.(type) {
+	case string:
+		if _, ok := singleExpression(val); ok {
+			return dynamicInput{}
+		}
+	case []any:
+		result := make([]any, len(val))
+		for i, item := range val {
+			result[i] = staticInput(item)
+		}
+		return result
+	case map[string]any:
+		if len(val) == 1 {
+			for format := range val {
+				if _, ok := varDecoders[format]; ok {
+					return dynamicInput{}
+				}
+			}
+		}
+		result := make(map[string]any, len(val))
+		for k, item := range val {
+			result[k] = staticInput(item)
+		}
+		return result
+	}
+	return v
+}
+
+// taskInputs resolves the `with:` values of a task step and checks them
+// against the inputs of the task job. Jobs without inputs accept any values.
+func taskInputs(ctx *ExecutionContext, step *model.Step, job *model.Job) (map[string]any, error) {
+	if err := validateInputs(job.Inputs, "inputs"); err != nil {
+		return nil, err
+	}
+	values := make(map[string]any, len(step.With))
+	for name, raw := range step.With {
+		value, err := resolveVar(ctx, raw)
+		if err != nil {
+			return nil, fmt.Errorf("with.%s: %w", name, err)
+		}
+		values[name] = value
+	}
+	if len(job.Inputs) == 0 {
+		return values, nil
+	}
+	if err := checkInputs(job.Inputs, values, "with"); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// setInputs sets the input values as variables, and the defaults of the
+// inputs without a value or variable of the same name.
+func setInputs(ctx *ExecutionContext, inputs model.Inputs, values map[string]any) {
+	for name, value := range values {
+		ctx.Variables.Set(name, value)
+	}
+	for name, in := range inputs {
+		if in == nil || in.Default == nil {
+			continue
+		}
+		if _, ok := values[name]; ok || ctx.Variables.Get(name) != nil {
+			continue
+		}
+		ctx.Variables.Set(name, in.Default)
+	}
+}
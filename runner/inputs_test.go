@@ -0,0 +1,202 @@
+package runner
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/atkins/model"
+)
+
+func TestCheckInputs(t *testing.T) {
+	pipelines, err := LoadPipelineFromReader(strings.NewReader(`
+jobs:
+  publish:
+    inputs:
+      image:
+        type: string
+        required: true
+      tags:
+        type: list
+        items: string
+      labels:
+        type: map
+        properties:
+          team: string
+      push: bool
+    run: docker push ${{ image }}
+`))
+	require.NoError(t, err)
+	inputs := pipelines[0].Jobs["publish"].Inputs
+
+	assert.NoError(t, checkInputs(inputs, map[string]any{
+		"image":  "app",
+		"tags":   []any{"latest", "v1"},
+		"labels": map[string]any{"team": "infra"},
+	}, "with"))
+
+	err = checkInputs(inputs, map[string]any{
+		"tags":   map[string]any{"latest": true},
+		"labels": map[string]any{"team": 1, "owner": "x"},
+		"push":   "yes",
+		"extra":  1,
+	}, "with")
+	require.Error(t, err)
+	assert.Equal(t, "with.image: required input missing; "+
+		"with.labels.team: expected string, got number; "+
+		"with.labels.owner: unknown input, expected one of team; "+
+		"with.push: expected bool, got string; "+
+		"with.tags: expected list, got map; "+
+		"with.extra: unknown input, expected one of image, labels, push, tags", err.Error())
+
+	err = checkInputs(inputs, map[string]any{"image": "app", "tags": []any{"v1", 2}}, "with")
+	assert.EqualError(t, err, "with.tags[1]: expected string, got number")
+}
+
+func TestValidateInputs(t *testing.T) {
+	assert.NoError(t, validateInputs(model.Inputs{
+		"count": {Type: model.InputNumber, Default: 3},
+	}, "inputs"))
+
+	assert.EqualError(t, validateInputs(model.Inputs{
+		"count": {Type: "integer"},
+	}, "inputs"), `inputs.count: unknown type "integer", expected string, number, bool, list, map`)
+
+	assert.EqualError(t, validateInputs(model.Inputs{
+		"count": {Type: model.InputNumber, Default: "three"},
+	}, "inputs"), "inputs.count.default: expected number, got string")
+
+	assert.EqualError(t, validateInputs(model.Inputs{
+		"tags": {Type: model.InputString, Items: &model.Input{Type: model.InputString}},
+	}, "inputs"), "inputs.tags: items requires type list")
+}
+
+func TestLinter_Inputs(t *testing.T) {
+	pipelines, err := LoadPipelineFromReader(strings.NewReader(`
+jobs:
+  build:
+    steps:
+      - task: publish
+        with:
+          image: app
+          tags: ${{ tags }}
+      - task: publish
+        with:
+          tags:
+            latest: true
+      - run: echo
+        with:
+          image: app
+  publish:
+    inputs:
+      image:
+        type: string
+        required: true
+      tags:
+        type: list
+    run: docker push ${{ image }}
+`))
+	require.NoError(t, err)
+
+	errors := NewLinter(pipelines[0]).Lint()
+	require.Len(t, errors, 2)
+	issues := map[string]string{}
+	for _, e := range errors {
+		issues[e.Issue] = e.Detail
+	}
+	assert.Equal(t, "step 'task: publish': with.image: required input missing; with.tags: expected list, got map", issues["invalid with"])
+	assert.Contains(t, issues, "with without task")
+}
+
+func TestLinter_UnknownInputType(t *testing.T) {
+	pipelines, err := LoadPipelineFromReader(strings.NewReader(`
+jobs:
+  build:
+    steps:
+      - task: scale
+        with:
+          replicas: "3"
+  scale:
+    inputs:
+      replicas:
+        type: integer
+    run: echo ${{ replicas }}
+`))
+	require.NoError(t, err)
+
+	errors := NewLinter(pipelines[0]).Lint()
+	require.Len(t, errors, 1)
+	assert.Equal(t, "invalid inputs", errors[0].Issue)
+}
+
+func TestRunPipeline_TaskInputs(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	run := func(with string) error {
+		pipelines, err := LoadPipelineFromReader(strings.NewReader(`
+vars:
+  version: v1
+jobs:
+  default:
+    steps:
+      - task: publish
+        with:
+` + with + `
+  publish:
+    inputs:
+      image:
+        type: string
+        required: true
+      tags:
+        type: list
+        default: [latest]
+    steps:
+      - for: tag in tags
+        run: printf '%s\n' "${{ image }}:${{ tag }}" >> published
+`))
+		require.NoError(t, err)
+		return RunPipeline(t.Context(), pipelines[0], PipelineOptions{
+			Jobs:   []string{"default"},
+			Silent: true,
+		})
+	}
+
+	require.NoError(t, run("          image: app"))
+	require.NoError(t, run("          image: app\n          tags: ['${{ version }}', edge]"))
+	out, err := os.ReadFile("published")
+	require.NoError(t, err)
+	assert.Equal(t, "app:latest\napp:v1\napp:edge\n", string(out))
+
+	err = run("          image: app\n          tags: { json: '{\"a\": 1}' }")
+	assert.ErrorContains(t, err, `task "publish": with.tags: expected list, got map`)
+}
+
+func TestRunPipeline_UnknownInputType(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	pipelines, err := LoadPipelineFromReader(strings.NewReader(`
+jobs:
+  default:
+    steps:
+      - task: scale
+        with:
+          replicas: "3"
+  scale:
+    inputs:
+      replicas:
+        type: integer
+    run: echo ${{ replicas }}
+`))
+	require.NoError(t, err)
+
+	err = RunPipeline(t.Context(), pipelines[0], PipelineOptions{Jobs: []string{"default"}, Silent: true})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `inputs.replicas: unknown type "integer"`)
+	assert.NotContains(t, err.Error(), "expected integer")
+
+	err = RunPipeline(t.Context(), pipelines[0], PipelineOptions{Jobs: []string{"scale"}, Silent: true})
+	assert.ErrorContains(t, err, `job "scale": inputs.replicas: unknown type "integer"`)
+}
@@ -10,12 +10,19 @@ import (
 	"github.com/expr-lang/expr"
 
 	"github.com/titpetric/atkins/eventlog"
+	"github.com/titpetric/atkins/model"
 	"github.com/titpetric/atkins/psexec"
 )
 
 // Matches ${{ variable_name }}
 var interpolationRegex = regexp.MustCompile(`\$\{\{\s*([^}]+?)\s*\}\}`)
 
+// quoteFunction quotes a value as a single shell word, for interpolating
+// values into commands: ${{ quote(branch) }}.
+var quoteFunction = expr.Function("quote", func(params ...any) (any, error) {
+	return psexec.Quote(fmt.Sprint(params[0])), nil
+}, new(func(any) string))
+
 // InterpolateString replaces ${{ expression }} with values from context.
 // Supports variable interpolation, dot notation, and expr expressions with ?? and || operators.
 func InterpolateString(s string, ctx *ExecutionContext) (string, error) {
@@ -31,27 +38,7 @@ func InterpolateString(s string, ctx *ExecutionContext) (string, error) {
 	}
 
 	// Handle variable interpolation: ${{ expression }}
-	result = interpolationRegex.ReplaceAllStringFunc(result, func(match string) string {
-		exprStr := interpolationRegex.FindStringSubmatch(match)[1]
-		exprStr = strings.TrimSpace(exprStr)
-
-		// Evaluate expression using expr-lang
-		val, err := evaluateExpression(exprStr, ctx)
-		if err != nil {
-			// If expression evaluation fails, return original match
-			return match
-		}
-
-		// Convert result to string
-		if val != nil {
-			return fmt.Sprintf("%v", val)
-		}
-
-		// Return original if result is nil
-		return match
-	})
-
-	return result, nil
+	return interpolateVariablesInString(result, ctx)
 }
 
 // extractAndProcessCommandSubstitutions handles $(...) by properly matching nested parentheses
@@ -82,10 +69,9 @@ func extractAndProcessCommandSubstitutions(ctx *ExecutionContext, s string, cmdE
 			// First interpolate ${{ }} inside the command before executing it
 			interpolatedCmd, err := interpolateVariablesInString(cmd, ctx)
 			if err != nil {
-				// Log the error but continue with original command
-				interpolatedCmd = cmd
+				*cmdErr = err
+				return s
 			}
-
 			// Execute with context env variables
 			startTime := time.Now()
 			var startOffset float64
@@ -93,11 +79,20 @@ func extractAndProcessCommandSubstitutions(ctx *ExecutionContext, s string, cmdE
 				startOffset = ctx.EventLogger.GetElapsed()
 			}
 
-			exec := psexec.NewWithOptions(&psexec.Options{
-				DefaultDir: ctx.Dir,
-				DefaultEnv: ctx.Env.Environ(),
-			})
-			cmdResult := exec.Run(context.Background(), exec.ShellCommand(interpolatedCmd))
+			exec := ctx.newExecutor()
+			// Run with the job context, so timeouts and cancellation
+			// also stop substitutions and their child processes.
+			runCtx := ctx.Context
+			if runCtx == nil {
+				runCtx = context.Background()
+			}
+			shellCmd := exec.ShellCommand(interpolatedCmd)
+			shellCmd.KillGroup = true
+			cmdResult, err := ctx.runProcess(runCtx, exec, shellCmd, interpolatedCmd)
+			if err != nil {
+				*cmdErr = err
+				return s
+			}
 			durationMs := time.Since(startTime).Milliseconds()
 
 			// Log the command execution
@@ -119,10 +114,11 @@ func extractAndProcessCommandSubstitutions(ctx *ExecutionContext, s string, cmdE
 					Type:       eventlog.EventTypeSubstitution,
 					ID:         fmt.Sprintf("subst-%d", startTime.UnixNano()),
 					ParentID:   parentID,
-					Command:    interpolatedCmd,
+					Command:    ctx.scrub.scrub(interpolatedCmd),
 					Dir:        ctx.Dir,
-					Output:     strings.TrimSpace(cmdResult.Output()),
-					Error:      errMsg,
+					Env:        ctx.scrub.scrubLines(ctx.Env.Environ()),
+					Output:     ctx.scrub.scrub(strings.TrimSpace(cmdResult.Output())),
+					Error:      ctx.scrub.scrub(errMsg),
 					ExitCode:   exitCode,
 					Start:      startOffset,
 					DurationMs: durationMs,
@@ -135,7 +131,7 @@ func extractAndProcessCommandSubstitutions(ctx *ExecutionContext, s string, cmdE
 				if cmdResult.Err() != nil {
 					errMsg = cmdResult.Err().Error()
 				}
-				*cmdErr = fmt.Errorf("command execution failed in $(%s): %s", interpolatedCmd, errMsg)
+				*cmdErr = fmt.Errorf("command execution failed in $(%s): %s", ctx.scrub.scrub(interpolatedCmd), ctx.scrub.scrub(errMsg))
 				return s
 			}
 			result += strings.TrimSpace(cmdResult.Output())
@@ -190,30 +186,40 @@ func findMatchingParen(s string, startIdx int) int {
 	return -1
 }
 
-// interpolateVariablesInString handles ${{ }} substitution within command strings
+// interpolateVariablesInString handles ${{ }} substitution within strings.
+// In strict mode (the default) an expression that fails to evaluate or
+// evaluates to nil is an error. With `lenient: true` the original
+// ${{ }} text is left in place.
 func interpolateVariablesInString(s string, ctx *ExecutionContext) (string, error) {
-	result := s
+	strict := !isLenient(ctx)
+
+	var firstErr error
+	result := interpolationRegex.ReplaceAllStringFunc(s, func(match string) string {
+		if firstErr != nil {
+			return match
+		}
 
-	// Handle variable interpolation: ${{ expression }}
-	result = interpolationRegex.ReplaceAllStringFunc(result, func(match string) string {
 		exprStr := interpolationRegex.FindStringSubmatch(match)[1]
 		exprStr = strings.TrimSpace(exprStr)
 
 		// Evaluate expression using expr-lang
 		val, err := evaluateExpression(exprStr, ctx)
+		if err == nil && val == nil {
+			err = fmt.Errorf("expression %q evaluated to nil", exprStr)
+		}
 		if err != nil {
-			// If expression evaluation fails, log it but return original
+			if strict {
+				firstErr = newInterpolationError(ctx, s, match, err)
+			}
+			// Leave the original text in place
 			return match
 		}
 
-		// Convert result to string
-		if val != nil {
-			return fmt.Sprintf("%v", val)
-		}
-
-		// Return original if result is nil
-		return match
+		return fmt.Sprintf("%v", val)
 	})
+	if firstErr != nil {
+		return "", firstErr
+	}
 
 	return result, nil
 }
@@ -221,30 +227,72 @@ func interpolateVariablesInString(s string, ctx *ExecutionContext) (string, erro
 // InterpolateMap recursively interpolates all string values in a map.
 func InterpolateMap(ctx *ExecutionContext, m map[string]any) error {
 	for k, v := range m {
-		switch val := v.(type) {
-		case string:
-			interpolated, err := InterpolateString(val, ctx)
+		interpolated, err := InterpolateValue(ctx, v)
+		if err != nil {
+			return err
+		}
+		m[k] = interpolated
+	}
+	return nil
+}
+
+// InterpolateValue interpolates strings within v, recursing into maps and
+// slices of any depth. The input is not modified, maps and slices are copied.
+// A string consisting of a single ${{ expression }} keeps the type of the
+// evaluated value, so numbers, booleans, lists and maps are preserved.
+// Other values pass through unchanged.
+func InterpolateValue(ctx *ExecutionContext, v any) (any, error) {
+	switch val := v.(type) {
+	case string:
+		if exprStr, ok := singleExpression(val); ok {
+			result, err := evaluateExpression(exprStr, ctx)
+			if err == nil && result != nil {
+				return result, nil
+			}
+		}
+		return InterpolateString(val, ctx)
+	case map[string]any:
+		result := make(map[string]any, len(val))
+		for k, item := range val {
+			interpolated, err := InterpolateValue(ctx, item)
 			if err != nil {
-				return err
+				return nil, err
 			}
-			m[k] = interpolated
-		case map[string]any:
-			if err := InterpolateMap(ctx, val); err != nil {
-				return err
+			result[k] = interpolated
+		}
+		return result, nil
+	case []any:
+		result := make([]any, len(val))
+		for i, item := range val {
+			interpolated, err := InterpolateValue(ctx, item)
+			if err != nil {
+				return nil, err
 			}
-		case []any:
-			for i, item := range val {
-				if str, ok := item.(string); ok {
-					interpolated, err := InterpolateString(str, ctx)
-					if err != nil {
-						return err
-					}
-					val[i] = interpolated
-				}
+			result[i] = interpolated
+		}
+		return result, nil
+	case []string:
+		result := make([]any, len(val))
+		for i, item := range val {
+			interpolated, err := InterpolateValue(ctx, item)
+			if err != nil {
+				return nil, err
 			}
+			result[i] = interpolated
 		}
+		return result, nil
 	}
-	return nil
+	return v, nil
+}
+
+// singleExpression returns the expression if s is exactly one ${{ expression }}.
+func singleExpression(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	loc := interpolationRegex.FindStringSubmatchIndex(s)
+	if loc == nil || loc[0] != 0 || loc[1] != len(s) {
+		return "", false
+	}
+	return strings.TrimSpace(s[loc[2]:loc[3]]), true
 }
 
 // InterpolateCommand interpolates a command string.
@@ -252,6 +300,46 @@ func InterpolateCommand(cmd string, ctx *ExecutionContext) (string, error) {
 	return InterpolateString(cmd, ctx)
 }
 
+// InterpolateArgv interpolates each argument of an `argv:` step and
+// quotes it as a single shell word, so values can't inject commands.
+// An argument that is a single ${{ }} expression evaluating to a list
+// expands to an argument per item. $(...) is not substituted.
+func InterpolateArgv(argv []string, ctx *ExecutionContext) (string, error) {
+	args := make([]string, 0, len(argv))
+	for _, arg := range argv {
+		if exprStr, ok := singleExpression(arg); ok {
+			if val, err := evaluateExpression(exprStr, ctx); err == nil && val != nil {
+				switch items := val.(type) {
+				case []any:
+					for _, item := range items {
+						args = append(args, fmt.Sprint(item))
+					}
+				case []string:
+					args = append(args, items...)
+				default:
+					args = append(args, fmt.Sprint(val))
+				}
+				continue
+			}
+		}
+		interpolated, err := interpolateVariablesInString(arg, ctx)
+		if err != nil {
+			return "", err
+		}
+		args = append(args, interpolated)
+	}
+	return psexec.QuoteArgs(args), nil
+}
+
+// interpolateStepCommand interpolates a command of the step, quoting the
+// arguments of `argv:` steps.
+func interpolateStepCommand(step *model.Step, cmd string, ctx *ExecutionContext) (string, error) {
+	if len(step.Argv) > 0 {
+		return InterpolateArgv(step.Argv, ctx)
+	}
+	return InterpolateCommand(cmd, ctx)
+}
+
 // evaluateExpression evaluates an expr expression with access to variables and environment.
 // Uses expr-lang/expr for evaluation with support for:
 //   - Simple variable access: varName
@@ -282,20 +370,23 @@ func evaluateExpression(exprStr string, ctx *ExecutionContext) (any, error) {
 		}
 	}
 
+	// Add the results and outputs of the previous steps of the job
+	ctx.steps.addTo(env)
+
 	// Add environment variables
 	for k, v := range ctx.Env {
 		env[k] = v
 	}
 
 	// Compile and evaluate the expression
-	program, err := expr.Compile(exprStr)
+	program, err := compileExpr(exprStr, append(releaseFunctions(ctx), quoteFunction, freePortFunction)...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to compile expression: %w", err)
+		return nil, fmt.Errorf("failed to compile expression %q: %w", exprStr, err)
 	}
 
-	result, err := expr.Run(program, env)
+	result, err := runExpr(program, env)
 	if err != nil {
-		return nil, fmt.Errorf("failed to evaluate expression: %w", err)
+		return nil, fmt.Errorf("failed to evaluate expression %q: %w", exprStr, err)
 	}
 
 	return result, nil
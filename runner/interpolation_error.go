@@ -0,0 +1,79 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+)
+
+// InterpolationError is returned when a ${{ }} expression can't be evaluated.
+type InterpolationError struct {
+	Expression string // The ${{ }} expression, as written
+	Location   string // Where the expression appears (pipeline, job, step)
+	Column     int    // 1-based column of the expression within its line
+	Line       int    // 1-based line of the expression within the source text
+	Err        error
+}
+
+// Error implements error.
+func (e *InterpolationError) Error() string {
+	location := e.Location
+	if location != "" {
+		location += ", "
+	}
+	return fmt.Sprintf("interpolation of %s failed (%sline %d, column %d): %v", e.Expression, location, e.Line, e.Column, e.Err)
+}
+
+// Unwrap returns the underlying evaluation error.
+func (e *InterpolationError) Unwrap() error {
+	return e.Err
+}
+
+// newInterpolationError describes a failed expression match within source.
+func newInterpolationError(ctx *ExecutionContext, source, match string, err error) *InterpolationError {
+	offset := strings.Index(source, match)
+	line, column := 1, offset+1
+	if offset > 0 {
+		before := source[:offset]
+		line += strings.Count(before, "\n")
+		column = offset - strings.LastIndex(before, "\n")
+	}
+	return &InterpolationError{
+		Expression: match,
+		Location:   interpolationLocation(ctx),
+		Line:       line,
+		Column:     column,
+		Err:        err,
+	}
+}
+
+// interpolationLocation names the step, job or pipeline being interpolated.
+func interpolationLocation(ctx *ExecutionContext) string {
+	var parts []string
+	if ctx.Job != nil && ctx.Job.Name != "" {
+		parts = append(parts, "job '"+ctx.Job.Name+"'")
+	}
+	if ctx.Step != nil {
+		switch {
+		case ctx.Step.ID != "":
+			parts = append(parts, "step '"+ctx.Step.ID+"'")
+		case ctx.Step.Name != "":
+			parts = append(parts, "step '"+ctx.Step.Name+"'")
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// isLenient reports whether failed interpolations are left in place
+// instead of failing, set with `lenient: true` on a step, job or pipeline.
+func isLenient(ctx *ExecutionContext) bool {
+	if ctx == nil {
+		return false
+	}
+	if ctx.Step != nil && ctx.Step.Lenient {
+		return true
+	}
+	if ctx.Job != nil && ctx.Job.Lenient {
+		return true
+	}
+	return ctx.Pipeline != nil && ctx.Pipeline.Lenient
+}
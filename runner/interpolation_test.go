@@ -1,11 +1,16 @@
 package runner_test
 
 import (
+	"context"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/expr-lang/expr"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/titpetric/atkins/model"
 	"github.com/titpetric/atkins/runner"
 )
 
@@ -17,6 +22,7 @@ func TestInterpolation(t *testing.T) {
 		env         map[string]string
 		expected    string
 		expectError bool
+		lenient     bool
 	}{
 		// Basic variable interpolation
 		{
@@ -64,13 +70,21 @@ func TestInterpolation(t *testing.T) {
 			expectError: false,
 		},
 
-		// Missing variables (should return original)
+		// Missing variables (error in strict mode, original text when lenient)
 		{
 			name:        "missing variable",
 			cmd:         "echo ${{ missing }}",
 			variables:   map[string]any{},
+			expected:    "",
+			expectError: true,
+		},
+		{
+			name:        "missing variable lenient",
+			cmd:         "echo ${{ missing }}",
+			variables:   map[string]any{},
 			expected:    "echo ${{ missing }}",
 			expectError: false,
+			lenient:     true,
 		},
 
 		// Null coalescing operator ?? (recommended for defaults)
@@ -229,6 +243,7 @@ func TestInterpolation(t *testing.T) {
 			ctx := &runner.ExecutionContext{
 				Variables: runner.NewContextVariables(tt.variables),
 				Env:       tt.env,
+				Pipeline:  &model.Pipeline{Lenient: tt.lenient},
 			}
 
 			result, err := runner.InterpolateCommand(tt.cmd, ctx)
@@ -342,3 +357,130 @@ func evaluateExpr(exprStr string, ctx *runner.ExecutionContext) (any, error) {
 	}
 	return expr.Run(program, env)
 }
+
+func TestInterpolation_StrictErrorLocation(t *testing.T) {
+	ctx := &runner.ExecutionContext{
+		Variables: runner.NewContextVariables(map[string]any{"name": "atkins"}),
+		Job:       &model.Job{Name: "build"},
+		Step:      &model.Step{ID: "greet", Lenient: false},
+	}
+
+	_, err := runner.InterpolateString("echo ${{ name }}\necho ${{ name.missing() }}", ctx)
+	var interpErr *runner.InterpolationError
+	require.ErrorAs(t, err, &interpErr)
+	assert.Equal(t, "${{ name.missing() }}", interpErr.Expression)
+	assert.Equal(t, "job 'build' step 'greet'", interpErr.Location)
+	assert.Equal(t, 2, interpErr.Line)
+	assert.Equal(t, 6, interpErr.Column)
+
+	ctx.Step.Lenient = true
+	result, err := runner.InterpolateString("echo ${{ name.missing() }}", ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "echo ${{ name.missing() }}", result)
+}
+
+func TestInterpolation_SubstitutionTimeout(t *testing.T) {
+	runCtx, cancel := context.WithTimeout(t.Context(), 200*time.Millisecond)
+	defer cancel()
+
+	ctx := &runner.ExecutionContext{
+		Context:   runCtx,
+		Variables: runner.NewContextVariables(nil),
+		Env:       runner.Env{},
+	}
+
+	start := time.Now()
+	_, err := runner.InterpolateString("$(sleep 10 | cat)", ctx.Copy())
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestInterpolateValue(t *testing.T) {
+	ctx := &runner.ExecutionContext{
+		Variables: runner.NewContextVariables(map[string]any{
+			"name":    "api",
+			"port":    8080,
+			"debug":   true,
+			"targets": []any{"linux", "darwin"},
+		}),
+	}
+
+	input := map[string]any{
+		"service": map[string]any{
+			"image": "registry/${{ name }}:latest",
+			"port":  "${{ port + 1 }}",
+			"debug": "${{ debug }}",
+			"ports": []any{"${{ port }}:80", 443},
+			"nested": map[string]any{
+				"targets": "${{ targets }}",
+				"label":   []any{[]any{"${{ name }}"}},
+			},
+		},
+	}
+
+	result, err := runner.InterpolateValue(ctx, input)
+	require.NoError(t, err)
+
+	service := result.(map[string]any)["service"].(map[string]any)
+	assert.Equal(t, "registry/api:latest", service["image"])
+	assert.Equal(t, 8081, service["port"])
+	assert.Equal(t, true, service["debug"])
+	assert.Equal(t, []any{"8080:80", 443}, service["ports"])
+
+	nested := service["nested"].(map[string]any)
+	assert.Equal(t, []any{"linux", "darwin"}, nested["targets"])
+	assert.Equal(t, []any{[]any{"api"}}, nested["label"])
+
+	// The input is left untouched
+	assert.Equal(t, "${{ port + 1 }}", input["service"].(map[string]any)["port"])
+}
+
+func TestInterpolateArgv(t *testing.T) {
+	ctx := &runner.ExecutionContext{
+		Variables: runner.NewContextVariables(map[string]any{
+			"branch":  "main; rm -rf /",
+			"targets": []any{"./a", "./b c"},
+		}),
+	}
+
+	cmd, err := runner.InterpolateArgv([]string{"git", "checkout", "${{ branch }}", "--", "${{ targets }}", "$(id)"}, ctx)
+	require.NoError(t, err)
+	assert.Equal(t, `git checkout 'main; rm -rf /' -- ./a './b c' '$(id)'`, cmd)
+
+	cmd, err = runner.InterpolateCommand("git checkout ${{ quote(branch) }}", ctx)
+	require.NoError(t, err)
+	assert.Equal(t, `git checkout 'main; rm -rf /'`, cmd)
+
+	_, err = runner.InterpolateArgv([]string{"echo", "${{ missing }}"}, ctx)
+	assert.Error(t, err)
+}
+
+func TestLoadPipeline_StructuredVars(t *testing.T) {
+	pipelines, err := runner.LoadPipelineFromReader(strings.NewReader(`
+vars:
+  version: 3
+  release:
+    tag: v${{ version }}
+    next: ${{ version + 1 }}
+    platforms:
+      - os: linux
+        archive: app-${{ version }}-linux.tar.gz
+jobs:
+  default:
+    vars:
+      settings:
+        retries: ${{ release.next }}
+    steps:
+      - test "${{ release.tag }}" = "v3"
+      - test "${{ release.platforms[0].archive }}" = "app-3-linux.tar.gz"
+      - test "${{ settings.retries * 2 }}" = "8"
+`))
+	require.NoError(t, err)
+
+	err = runner.RunPipeline(t.Context(), pipelines[0], runner.PipelineOptions{
+		Jobs:         []string{"default"},
+		Silent:       true,
+		AllPipelines: pipelines,
+	})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,207 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/titpetric/atkins/colors"
+	"github.com/titpetric/atkins/eventlog"
+)
+
+// IssueCommand is the GitHub CLI filing and closing the issues of failing
+// jobs. It uses the token in GH_TOKEN or the login of `gh auth login`.
+var IssueCommand = "gh"
+
+// issueTimeout limits the time spent filing or closing an issue.
+const issueTimeout = time.Minute
+
+// issueErrorLines is the number of trailing error output lines in an issue.
+const issueErrorLines = 30
+
+// issueTitle returns the title of the issue of the failing jobs of a pipeline,
+// used to find the open issue again.
+func issueTitle(entry *eventlog.RunIndexEntry) string {
+	jobs := "default"
+	if len(entry.Jobs) > 0 {
+		jobs = strings.Join(entry.Jobs, ", ")
+	}
+	return fmt.Sprintf("atkins: %s %s is failing", entry.Pipeline, jobs)
+}
+
+// consecutiveFailures returns the number of failed runs of the pipeline and
+// jobs of entry at the end of the run index, stopping at entry's run.
+func consecutiveFailures(entries []*eventlog.RunIndexEntry, entry *eventlog.RunIndexEntry) int {
+	count := 0
+	for i := len(entries) - 1; i >= 0; i-- {
+		other := entries[i]
+		if other == nil || other.Pipeline != entry.Pipeline || !slices.Equal(other.Jobs, entry.Jobs) {
+			continue
+		}
+		if other.Result != eventlog.ResultFail {
+			break
+		}
+		count++
+	}
+	return count
+}
+
+// reportIssue files an issue once the jobs of the run failed after
+// consecutive runs, and closes it once they pass again. The runs are
+// counted in the run index, entry is the run that just ended. The error
+// output in the issue is scrubbed with the scrub rules of the run.
+func reportIssue(ctx context.Context, after int, entry *eventlog.RunIndexEntry, failed []FailedStep, runErr error, scrub *scrubber) error {
+	if entry == nil || after <= 0 {
+		return nil
+	}
+	entries, err := eventlog.LoadRunIndex(eventlog.RunIndexPath)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), issueTimeout)
+	defer cancel()
+
+	title := issueTitle(entry)
+	if entry.Result != eventlog.ResultFail {
+		// The issue was filed if the previous runs failed often enough
+		if i := entryIndex(entries, entry.RunID); i < 0 || consecutiveFailures(entries[:i], entry) < after {
+			return nil
+		}
+		number, err := findIssue(ctx, title)
+		if err != nil || number == 0 {
+			return err
+		}
+		_, err = runIssueCommand(ctx, nil, "issue", "close", strconv.Itoa(number), "--comment", "Passed again in run "+entry.RunID+".")
+		return err
+	}
+
+	failures := consecutiveFailures(entries, entry)
+	if failures < after {
+		return nil
+	}
+	number, err := findIssue(ctx, title)
+	if err != nil || number != 0 {
+		return err
+	}
+	body := issueBody(entry, failures, failed, runErr, eventlog.DetectCI(os.Getenv), scrub)
+	url, err := runIssueCommand(ctx, strings.NewReader(body), "issue", "create", "--title", title, "--body-file", "-")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "%s filed %s\n", colors.BrightYellow("atkins:"), strings.TrimSpace(url))
+	return nil
+}
+
+// entryIndex returns the index of the last entry of a run, or -1.
+func entryIndex(entries []*eventlog.RunIndexEntry, runID string) int {
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i] != nil && entries[i].RunID == runID {
+			return i
+		}
+	}
+	return -1
+}
+
+// findIssue returns the number of the open issue with title, or 0.
+func findIssue(ctx context.Context, title string) (int, error) {
+	out, err := runIssueCommand(ctx, nil, "issue", "list", "--state", "open", "--search", title+" in:title", "--json", "number,title")
+	if err != nil {
+		return 0, err
+	}
+	var issues []struct {
+		Number int    `json:"number"`
+		Title  string `json:"title"`
+	}
+	if err := json.Unmarshal([]byte(out), &issues); err != nil {
+		return 0, fmt.Errorf("failed to parse issues: %w", err)
+	}
+	for _, issue := range issues {
+		if issue.Title == title {
+			return issue.Number, nil
+		}
+	}
+	return 0, nil
+}
+
+// runIssueCommand runs the GitHub CLI and returns its output.
+func runIssueCommand(ctx context.Context, stdin *strings.Reader, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, IssueCommand, args...)
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s %s: %s", IssueCommand, strings.Join(args[:2], " "), msg)
+		}
+		return "", fmt.Errorf("%s %s: %w", IssueCommand, strings.Join(args[:2], " "), err)
+	}
+	return stdout.String(), nil
+}
+
+// issueBody returns the markdown body of the issue of a failing run: the
+// failed steps, the tail of the scrubbed error output and where to find
+// the log.
+func issueBody(entry *eventlog.RunIndexEntry, failures int, failed []FailedStep, runErr error, ci *eventlog.CIInfo, scrub *scrubber) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "The last %d runs of `%s` failed.\n\n", failures, strings.TrimPrefix(issueTitle(entry), "atkins: "))
+
+	fmt.Fprintf(&b, "- Run: `%s`", entry.RunID)
+	if ci != nil && ci.RunURL != "" {
+		fmt.Fprintf(&b, " ([%s](%s))", ci.Provider, ci.RunURL)
+	}
+	b.WriteString("\n")
+	if entry.Branch != "" || entry.Commit != "" {
+		fmt.Fprintf(&b, "- Commit: `%s` on `%s`\n", entry.Commit, entry.Branch)
+	}
+	if entry.LogFile != "" {
+		fmt.Fprintf(&b, "- Log: `%s`, replay with `atkins last --failed`\n", entry.LogFile)
+	}
+
+	if len(failed) > 0 {
+		b.WriteString("\n| Job | Step | Exit | Duration | Error |\n|---|---|---|---|---|\n")
+		for _, step := range failed {
+			errorText := step.Error
+			if step.Hint != "" {
+				errorText += " ([" + step.Label + "] " + step.Hint + ")"
+			}
+			fmt.Fprintf(&b, "| %s | `%s` | %d | %s | %s |\n", step.Job, markdownCell(step.Step), step.ExitCode,
+				step.Duration.Round(time.Millisecond), markdownCell(errorText))
+		}
+	}
+
+	var execErr ExecError
+	output := ""
+	if errors.As(runErr, &execErr) {
+		output = execErr.Output
+	} else if runErr != nil {
+		output = runErr.Error()
+	}
+	if output = strings.TrimSpace(scrub.scrub(output)); output != "" {
+		lines := strings.Split(output, "\n")
+		if len(lines) > issueErrorLines {
+			lines = lines[len(lines)-issueErrorLines:]
+		}
+		fmt.Fprintf(&b, "\n```text\n%s\n```\n", strings.Join(lines, "\n"))
+	}
+
+	b.WriteString("\nThis issue is closed when the jobs pass again.\n")
+	return b.String()
+}
+
+// markdownCell escapes the pipes and newlines of a table cell.
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.Join(strings.Fields(strings.ReplaceAll(s, "\n", " ")), " ")
+}
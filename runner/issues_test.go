@@ -0,0 +1,135 @@
+package runner
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/atkins/eventlog"
+	"github.com/titpetric/atkins/model"
+)
+
+// fakeGH records the calls to the GitHub CLI and keeps the open issue in issues.json.
+const fakeGH = `#!/bin/sh
+echo "$@" >> calls
+case "$1 $2" in
+"issue list") cat issues.json 2>/dev/null || echo '[]' ;;
+"issue create") cat > body.md; printf '[{"number":7,"title":"%s"}]' "$4" > issues.json; echo https://github.com/o/r/issues/7 ;;
+"issue close") rm issues.json ;;
+esac
+`
+
+func TestRunPipeline_IssueAfter(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	require.NoError(t, os.WriteFile("gh", []byte(fakeGH), 0o755))
+	defer func(cmd string) { IssueCommand = cmd }(IssueCommand)
+	IssueCommand = filepath.Join(dir, "gh")
+
+	pipelines, err := LoadPipelineFromReader(strings.NewReader(`
+name: nightly
+jobs:
+  test:
+    steps:
+      - run: test -f fixed || { printf 'connection refused\n' >&2; exit 1; }
+`))
+	require.NoError(t, err)
+	run := func(runID string) error {
+		return RunPipeline(t.Context(), pipelines[0], PipelineOptions{
+			Jobs:       []string{"test"},
+			Silent:     true,
+			LogFile:    "run.yml",
+			RunID:      runID,
+			IssueAfter: 2,
+		})
+	}
+	calls := func() []string {
+		data, _ := os.ReadFile("calls")
+		return strings.Split(strings.TrimSpace(string(data)), "\n")
+	}
+
+	// The first failure doesn't file an issue
+	require.Error(t, run("r1"))
+	assert.NoFileExists(t, "calls")
+
+	require.Error(t, run("r2"))
+	assert.Equal(t, []string{
+		"issue list --state open --search atkins: nightly test is failing in:title --json number,title",
+		"issue create --title atkins: nightly test is failing --body-file -",
+	}, calls())
+	body, err := os.ReadFile("body.md")
+	require.NoError(t, err)
+	assert.Contains(t, string(body), "The last 2 runs of `nightly test is failing` failed.")
+	assert.Contains(t, string(body), "- Run: `r2`")
+	assert.Contains(t, string(body), "[network]")
+	assert.Contains(t, string(body), "```text\nconnection refused\n```")
+
+	// The open issue isn't filed again
+	require.Error(t, run("r3"))
+	assert.Len(t, calls(), 3)
+
+	// The issue is closed once the job passes
+	require.NoError(t, os.WriteFile("fixed", nil, 0o644))
+	require.NoError(t, run("r4"))
+	assert.Equal(t, "issue close 7 --comment Passed again in run r4.", calls()[4])
+	assert.NoFileExists(t, "issues.json")
+
+	// Passing runs don't look for issues
+	require.NoError(t, run("r5"))
+	assert.Len(t, calls(), 5)
+}
+
+func TestConsecutiveFailures(t *testing.T) {
+	entry := &eventlog.RunIndexEntry{Pipeline: "ci", Jobs: []string{"test"}}
+	entries := []*eventlog.RunIndexEntry{
+		{Pipeline: "ci", Jobs: []string{"test"}, Result: eventlog.ResultFail},
+		{Pipeline: "ci", Jobs: []string{"test"}, Result: eventlog.ResultPass},
+		{Pipeline: "ci", Jobs: []string{"test"}, Result: eventlog.ResultFail},
+		{Pipeline: "ci", Jobs: []string{"lint"}, Result: eventlog.ResultPass},
+		{Pipeline: "ci", Jobs: []string{"test"}, Result: eventlog.ResultFail},
+	}
+	assert.Equal(t, 2, consecutiveFailures(entries, entry))
+	assert.Equal(t, 0, consecutiveFailures(entries[:2], entry))
+}
+
+func TestIssueBody(t *testing.T) {
+	entry := &eventlog.RunIndexEntry{
+		RunID:    "42",
+		Pipeline: "ci",
+		Branch:   "main",
+		Commit:   "abc123",
+		LogFile:  "/tmp/run.yml",
+	}
+	body := issueBody(entry, 3, []FailedStep{
+		{Job: "test", Step: "go test | tee", ExitCode: 1, Duration: time.Second, Error: "FAIL"},
+	}, errors.New("job failed"), &eventlog.CIInfo{Provider: "github", RunURL: "https://github.com/o/r/actions/runs/42"}, nil)
+
+	assert.Equal(t, "The last 3 runs of `ci default is failing` failed.\n\n"+
+		"- Run: `42` ([github](https://github.com/o/r/actions/runs/42))\n"+
+		"- Commit: `abc123` on `main`\n"+
+		"- Log: `/tmp/run.yml`, replay with `atkins last --failed`\n"+
+		"\n| Job | Step | Exit | Duration | Error |\n|---|---|---|---|---|\n"+
+		"| test | `go test \\| tee` | 1 | 1s | FAIL |\n"+
+		"\n```text\njob failed\n```\n"+
+		"\nThis issue is closed when the jobs pass again.\n", body)
+}
+
+func TestIssueBody_Scrubbed(t *testing.T) {
+	scrub, err := newScrubber([]*model.ScrubRule{{Match: "SECRET[0-9]+"}})
+	require.NoError(t, err)
+
+	entry := &eventlog.RunIndexEntry{RunID: "42", Pipeline: "ci"}
+	runErr := ExecError{Message: "exit status 1", Output: "token SECRET123 rejected\n"}
+	body := issueBody(entry, 3, nil, runErr, nil, scrub)
+	assert.NotContains(t, body, "SECRET123")
+	assert.Contains(t, body, "token "+DefaultScrubReplacement+" rejected")
+
+	body = issueBody(entry, 3, nil, errors.New("login SECRET456 failed"), nil, scrub)
+	assert.NotContains(t, body, "SECRET456")
+}
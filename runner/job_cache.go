@@ -0,0 +1,283 @@
+package runner
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/titpetric/atkins/model"
+)
+
+// JobCacheDir is the local cache of job results, relative to $HOME.
+var JobCacheDir = filepath.Join(".atkins", "cache", "jobs")
+
+// Remote cache modes.
+const (
+	CacheModeRead      = "read"       // Restore results from the remote cache, only store them locally
+	CacheModeReadWrite = "read-write" // Also upload results to the remote cache
+)
+
+// JobCache stores the outputs of jobs with a `cache:` section, keyed by
+// a hash of the job and its inputs. Results are kept in a local cache,
+// and shared through an optional remote cache.
+//
+// An entry is a content-addressed archive of the outputs:
+//
+//   - entries/<key> holds the digest of the archive,
+//   - blobs/<hex> holds the archive, verified against the digest when restored.
+type JobCache struct {
+	Local  CacheBackend
+	Remote CacheBackend // Optional, shared with other machines
+	Mode   string       // CacheModeRead or CacheModeReadWrite, for the remote cache
+}
+
+// NewJobCache creates a job cache in localDir. The remote cache is
+// configured with a URL, see NewCacheBackend, and is optional.
+func NewJobCache(localDir, remote, mode string) (*JobCache, error) {
+	cache := &JobCache{
+		Local: &dirCacheBackend{Dir: localDir},
+		Mode:  mode,
+	}
+	switch mode {
+	case "":
+		cache.Mode = CacheModeReadWrite
+	case CacheModeRead, CacheModeReadWrite:
+	default:
+		return nil, fmt.Errorf("unknown cache mode %q, expected %q or %q", mode, CacheModeRead, CacheModeReadWrite)
+	}
+	if remote != "" {
+		backend, err := NewCacheBackend(remote)
+		if err != nil {
+			return nil, err
+		}
+		cache.Remote = backend
+	}
+	return cache, nil
+}
+
+// Key returns the cache key of the job run in dir. It hashes the job
+// definition, the platform, the variables and environment the job runs
+// with, see cacheState, and the paths and contents of the input files.
+func (c *JobCache) Key(job *model.Job, dir string, vars map[string]any, env map[string]string) (string, error) {
+	definition, err := yaml.Marshal(job)
+	if err != nil {
+		return "", err
+	}
+	// Maps are marshalled with sorted keys, the same state hashes the same
+	state, err := yaml.Marshal(map[string]any{"vars": vars, "env": env})
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.New()
+	fmt.Fprintf(hash, "job %s\n%s\nplatform %s/%s\n%s\n", job.Name, definition, runtime.GOOS, runtime.GOARCH, state)
+
+	inputs, err := cacheInputs(dir, job.Cache.Inputs)
+	if err != nil {
+		return "", err
+	}
+	for _, input := range inputs {
+		data, err := os.ReadFile(filepath.Join(dir, input))
+		if err != nil {
+			return "", err
+		}
+		sum := sha256.Sum256(data)
+		fmt.Fprintf(hash, "input %s %x\n", input, sum)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// Restore extracts the cached outputs for key into dir. It returns false
+// when there is no entry. An entry failing the integrity check returns a
+// CacheEntryError, which the job treats as a miss. Entries found in the remote cache are also stored locally.
+func (c *JobCache) Restore(ctx context.Context, key, dir string) (bool, error) {
+	archive, err := c.load(ctx, c.Local, key)
+	if errors.Is(err, ErrCacheMiss) && c.Remote != nil {
+		archive, err = c.load(ctx, c.Remote, key)
+		if err == nil {
+			if err := c.store(ctx, c.Local, key, archive); err != nil {
+				return false, err
+			}
+		}
+	}
+	if errors.Is(err, ErrCacheMiss) {
+		return false, nil
+	}
+	if err != nil {
+		return false, &CacheEntryError{Key: key, Err: err}
+	}
+
+	if _, err := extractTarGz(archive, dir); err != nil {
+		return false, fmt.Errorf("failed to restore cache entry %s: %w", key, err)
+	}
+	return true, nil
+}
+
+// CacheEntryError is a cache entry that can't be used, e.g. failing the
+// integrity check.
+type CacheEntryError struct {
+	Key string
+	Err error
+}
+
+func (e *CacheEntryError) Error() string {
+	return fmt.Sprintf("ignoring cache entry %s: %v", e.Key, e.Err)
+}
+
+func (e *CacheEntryError) Unwrap() error {
+	return e.Err
+}
+
+// Save archives the outputs in dir and stores them for key, uploading
+// them to the remote cache in read-write mode.
+func (c *JobCache) Save(ctx context.Context, key, dir string, outputs []string) error {
+	archive, err := archiveOutputs(dir, outputs)
+	if err != nil {
+		return fmt.Errorf("failed to archive cache outputs: %w", err)
+	}
+	if err := c.store(ctx, c.Local, key, archive); err != nil {
+		return err
+	}
+	if c.Remote != nil && c.Mode == CacheModeReadWrite {
+		if err := c.store(ctx, c.Remote, key, archive); err != nil {
+			return fmt.Errorf("failed to upload cache entry %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// load returns the archive of the entry, verified against its digest.
+func (c *JobCache) load(ctx context.Context, backend CacheBackend, key string) ([]byte, error) {
+	digest, err := backend.Get(ctx, "entries/"+key)
+	if err != nil {
+		return nil, err
+	}
+	expected := strings.TrimSpace(string(digest))
+	if !ociDigestPattern.MatchString(expected) {
+		return nil, fmt.Errorf("invalid digest %q", expected)
+	}
+	archive, err := backend.Get(ctx, "blobs/"+strings.TrimPrefix(expected, "sha256:"))
+	if err != nil {
+		return nil, err
+	}
+	if got := sha256Digest(archive); got != expected {
+		return nil, fmt.Errorf("digest mismatch: got %s, expected %s", got, expected)
+	}
+	return archive, nil
+}
+
+// store writes the archive before the entry pointing to it.
+func (c *JobCache) store(ctx context.Context, backend CacheBackend, key string, archive []byte) error {
+	digest := sha256Digest(archive)
+	if err := backend.Put(ctx, "blobs/"+strings.TrimPrefix(digest, "sha256:"), archive); err != nil {
+		return err
+	}
+	return backend.Put(ctx, "entries/"+key, []byte(digest+"\n"))
+}
+
+// cacheInputs returns the sorted files in dir matching the patterns,
+// relative to dir, skipping the .git and .atkins folders.
+func cacheInputs(dir string, patterns []string) ([]string, error) {
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+	matchers := make([]func(string) bool, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := globRegexp(filepath.ToSlash(pattern))
+		if err != nil {
+			return nil, fmt.Errorf("invalid cache input %q: %w", pattern, err)
+		}
+		matchers = append(matchers, re.MatchString)
+	}
+
+	var result []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if name := d.Name(); path != dir && (name == ".git" || name == ".atkins") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if slices.ContainsFunc(matchers, func(match func(string) bool) bool { return match(rel) }) {
+			result = append(result, rel)
+		}
+		return nil
+	})
+	return result, err
+}
+
+// archiveOutputs returns a gzipped tarball of the output files and
+// directories in dir. A missing output fails, the job didn't produce it.
+func archiveOutputs(dir string, outputs []string) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	for _, output := range outputs {
+		if !filepath.IsLocal(output) {
+			return nil, fmt.Errorf("output %q is outside of the job dir", output)
+		}
+		err := filepath.WalkDir(filepath.Join(dir, output), func(path string, d fs.DirEntry, err error) error {
+			if err != nil || !d.Type().IsRegular() {
+				return err
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			header := &tar.Header{
+				Name:     filepath.ToSlash(rel),
+				Mode:     int64(info.Mode().Perm()),
+				Size:     info.Size(),
+				Typeflag: tar.TypeReg,
+			}
+			if err := tw.WriteHeader(header); err != nil {
+				return err
+			}
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			_, err = io.Copy(tw, f)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
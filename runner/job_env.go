@@ -0,0 +1,260 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/titpetric/atkins/colors"
+	"github.com/titpetric/atkins/model"
+	"github.com/titpetric/atkins/psexec"
+)
+
+// Origins of the environment variables of a job.
+const (
+	EnvOriginProcess  = "process"
+	EnvOriginPipeline = "pipeline"
+	EnvOriginJob      = "job"
+	EnvOriginPorts    = "ports"
+)
+
+// Shells of the statements printed by ExportJobEnv.
+const (
+	ExportBash = "bash"
+	ExportFish = "fish"
+)
+
+// secretNamePattern matches names of variables holding secrets, which
+// are masked when the environment is printed.
+var secretNamePattern = regexp.MustCompile(`(?i)(TOKEN|SECRET|PASSWORD|PASSWD|CREDENTIAL|PRIVATE_KEY|API_KEY|ACCESS_KEY)`)
+
+// EnvEntry is an environment variable of a job.
+type EnvEntry struct {
+	Name   string
+	Value  string
+	Origin string // Where the value comes from, empty for unset variables
+	Doc    string // Description from `env: {doc: ...}`
+}
+
+// IsSet reports whether the job receives the variable.
+func (e EnvEntry) IsSet() bool {
+	return e.Origin != ""
+}
+
+// IsSecret reports whether the name suggests the value is a secret.
+func (e EnvEntry) IsSecret() bool {
+	return secretNamePattern.MatchString(e.Name)
+}
+
+// EnvDocs returns the documented variables of the job, the descriptions
+// of the job override the ones of the pipeline.
+func EnvDocs(pipeline *model.Pipeline, job *model.Job) map[string]string {
+	var docs map[string]string
+	if pipeline != nil && pipeline.Decl != nil && pipeline.Env != nil {
+		docs = pipeline.Env.Doc
+	}
+	if job != nil && job.Decl != nil && job.Env != nil {
+		docs = mergeMaps(docs, job.Env.Doc)
+	}
+	return docs
+}
+
+// JobEnv returns the environment the job receives when it runs, sorted
+// by name, with the origin of each variable. Documented variables the job
+// doesn't receive are included unset. Inherited process variables are
+// only included when they're documented, or with all. The variables are
+// evaluated as when the job runs, so `$(...)` commands are executed.
+func JobEnv(ctx context.Context, pipeline *model.Pipeline, job *model.Job, all bool) ([]EnvEntry, error) {
+	execCtx := &ExecutionContext{
+		Variables: NewContextVariables(nil),
+		Env:       make(map[string]string),
+		Results:   make(map[string]any),
+		Pipeline:  pipeline,
+		Context:   ctx,
+		failures:  &failureLog{},
+	}
+	origins := make(map[string]string)
+	for _, env := range os.Environ() {
+		if k, v := parseEnv(env); k != "" {
+			execCtx.Env[k] = v
+			origins[k] = EnvOriginProcess
+		}
+	}
+
+	// track sets the origin of the variables fn adds or changes
+	track := func(origin string, decl *model.EnvDecl, fn func() error) error {
+		before := maps.Clone(execCtx.Env)
+		if err := fn(); err != nil {
+			return err
+		}
+		for k, v := range execCtx.Env {
+			old, ok := before[k]
+			_, declared := envDeclVars(decl)[k]
+			if ok && old == v && !declared {
+				continue
+			}
+			origins[k] = envOrigin(origin, decl, k)
+		}
+		return nil
+	}
+
+	if pipeline.Dir != "" {
+		dir, err := InterpolateString(pipeline.Dir, execCtx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to interpolate pipeline dir %q: %w", pipeline.Dir, err)
+		}
+		execCtx.Dir = dir
+	}
+	var pipelineEnv *model.EnvDecl
+	if pipeline.Decl != nil {
+		pipelineEnv = pipeline.Env
+	}
+	if err := track(EnvOriginPipeline, pipelineEnv, func() error {
+		return MergeVariables(execCtx, pipeline.Decl)
+	}); err != nil {
+		return nil, err
+	}
+
+	execCtx.Job = job
+	setJobVars(execCtx, execCtx.Pipeline, job)
+	if err := track(EnvOriginPorts, nil, func() error {
+		return allocatePorts(execCtx, job)
+	}); err != nil {
+		return nil, err
+	}
+	var jobEnv *model.EnvDecl
+	if job.Decl != nil {
+		jobEnv = job.Env
+	}
+	if err := track(EnvOriginJob, jobEnv, func() error {
+		if job.For.IsEmpty() {
+			return evaluateDirAndVars(execCtx, job, false)
+		}
+		return evaluateDirAndVarsSkipDir(execCtx, job)
+	}); err != nil {
+		return nil, err
+	}
+
+	docs := EnvDocs(pipeline, job)
+	var entries []EnvEntry
+	for _, name := range slices.Sorted(maps.Keys(execCtx.Env)) {
+		if !all && origins[name] == EnvOriginProcess && docs[name] == "" {
+			continue
+		}
+		entries = append(entries, EnvEntry{Name: name, Value: execCtx.Env[name], Origin: origins[name], Doc: docs[name]})
+	}
+	for name, doc := range docs {
+		if _, ok := execCtx.Env[name]; !ok {
+			entries = append(entries, EnvEntry{Name: name, Doc: doc})
+		}
+	}
+	slices.SortFunc(entries, func(a, b EnvEntry) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return entries, nil
+}
+
+// envDeclVars returns the variables set by decl, or nil.
+func envDeclVars(decl *model.EnvDecl) map[string]any {
+	if decl == nil {
+		return nil
+	}
+	return decl.Vars
+}
+
+// envOrigin returns the origin of a variable set by a declaration,
+// naming the include file the value comes from.
+func envOrigin(origin string, decl *model.EnvDecl, name string) string {
+	if decl == nil || decl.Include == nil {
+		return origin
+	}
+	if _, ok := decl.Vars[name]; ok {
+		return origin
+	}
+	for _, file := range slices.Backward(decl.Include.Files) {
+		values := make(map[string]string)
+		if err := loadEnvFile(file, values); err != nil {
+			continue
+		}
+		if _, ok := values[name]; ok {
+			return origin + " (" + file + ")"
+		}
+	}
+	return origin
+}
+
+// envValueWidth limits the width of the value column.
+const envValueWidth = 60
+
+// PrintJobEnv prints the environment of a job as a table, with the values
+// of secrets masked.
+func PrintJobEnv(w io.Writer, entries []EnvEntry) {
+	header := []string{"NAME", "VALUE", "ORIGIN", "DESCRIPTION"}
+	rows := make([][]string, 0, len(entries))
+	for _, entry := range entries {
+		value := truncate(strings.ReplaceAll(entry.Value, "\n", `\n`), envValueWidth)
+		origin := entry.Origin
+		switch {
+		case !entry.IsSet():
+			value, origin = "(unset)", "-"
+		case entry.IsSecret() && entry.Value != "":
+			value = "********"
+		}
+		rows = append(rows, []string{entry.Name, value, origin, entry.Doc})
+	}
+
+	widths := make([]int, len(header))
+	for _, row := range append([][]string{header}, rows...) {
+		for i, cell := range row {
+			widths[i] = max(widths[i], len([]rune(cell)))
+		}
+	}
+	format := func(row []string) string {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = cell + strings.Repeat(" ", widths[i]-len([]rune(cell)))
+		}
+		return strings.TrimRight(strings.Join(cells, "  "), " ")
+	}
+
+	fmt.Fprintln(w, colors.Gray(format(header)))
+	for i, row := range rows {
+		line := format(row)
+		if !entries[i].IsSet() {
+			line = colors.BrightYellow(line)
+		}
+		fmt.Fprintln(w, line)
+	}
+}
+
+// ExportJobEnv prints statements exporting the variables set for the job
+// in the syntax of shell, e.g. for `eval "$(atkins env --export bash job)"`.
+// Inherited process variables and unset variables are left out.
+func ExportJobEnv(w io.Writer, entries []EnvEntry, shell string) error {
+	var format func(name, value string) string
+	switch shell {
+	case ExportBash:
+		format = func(name, value string) string {
+			return "export " + name + "=" + psexec.Quote(value)
+		}
+	case ExportFish:
+		format = func(name, value string) string {
+			return "set -gx " + name + " '" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+		}
+	default:
+		return fmt.Errorf("unknown shell %q, expected %q or %q", shell, ExportBash, ExportFish)
+	}
+
+	for _, entry := range entries {
+		if !entry.IsSet() || entry.Origin == EnvOriginProcess {
+			continue
+		}
+		fmt.Fprintln(w, format(entry.Name, entry.Value))
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package runner
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/atkins/colors"
+)
+
+func TestJobEnv(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("ATKINS_TEST_INHERITED", "1")
+	t.Setenv("ATKINS_TEST_REGION", "eu-west-1")
+	require.NoError(t, os.WriteFile(".env", []byte("DB_PASSWORD=hunter2\nDB_HOST=localhost\n"), 0o644))
+
+	pipelines, err := LoadPipelineFromReader(strings.NewReader(`
+env:
+  vars:
+    GIT_COMMIT: $(echo abc123)
+  doc:
+    ATKINS_TEST_REGION: Region to deploy to
+jobs:
+  deploy:
+    ports:
+      api: 8080
+    env:
+      include: .env
+      vars:
+        DB_HOST: db.internal
+        TARGET: staging
+      doc:
+        TARGET: Target environment
+        SLACK_WEBHOOK: Webhook notified after the deploy
+    run: ./deploy.sh
+`))
+	require.NoError(t, err)
+	pipeline := pipelines[0]
+
+	entries, err := JobEnv(t.Context(), pipeline, pipeline.Jobs["deploy"], false)
+	require.NoError(t, err)
+	assert.Equal(t, []EnvEntry{
+		{Name: "API_PORT", Value: "8080", Origin: EnvOriginPorts},
+		{Name: "ATKINS_TEST_REGION", Value: "eu-west-1", Origin: EnvOriginProcess, Doc: "Region to deploy to"},
+		{Name: "DB_HOST", Value: "db.internal", Origin: EnvOriginJob},
+		{Name: "DB_PASSWORD", Value: "hunter2", Origin: "job (.env)"},
+		{Name: "GIT_COMMIT", Value: "abc123", Origin: EnvOriginPipeline},
+		{Name: "SLACK_WEBHOOK", Doc: "Webhook notified after the deploy"},
+		{Name: "TARGET", Value: "staging", Origin: EnvOriginJob, Doc: "Target environment"},
+	}, entries)
+
+	all, err := JobEnv(t.Context(), pipeline, pipeline.Jobs["deploy"], true)
+	require.NoError(t, err)
+	assert.Contains(t, all, EnvEntry{Name: "ATKINS_TEST_INHERITED", Value: "1", Origin: EnvOriginProcess})
+}
+
+func TestPrintJobEnv(t *testing.T) {
+	var buf bytes.Buffer
+	PrintJobEnv(&buf, []EnvEntry{
+		{Name: "DB_PASSWORD", Value: "hunter2", Origin: EnvOriginJob},
+		{Name: "GITHUB_TOKEN", Origin: EnvOriginProcess},
+		{Name: "SLACK_WEBHOOK", Doc: "Webhook"},
+		{Name: "TARGET", Value: "staging", Origin: EnvOriginJob, Doc: "Target environment"},
+	})
+	assert.Equal(t, `NAME           VALUE     ORIGIN   DESCRIPTION
+DB_PASSWORD    ********  job
+GITHUB_TOKEN             process
+SLACK_WEBHOOK  (unset)   -        Webhook
+TARGET         staging   job      Target environment
+`, colors.StripANSI(buf.String()))
+}
+
+func TestExportJobEnv(t *testing.T) {
+	entries := []EnvEntry{
+		{Name: "DB_PASSWORD", Value: "it's", Origin: "job (.env)"},
+		{Name: "HOME", Value: "/root", Origin: EnvOriginProcess},
+		{Name: "PATTERN", Value: `a\b c`, Origin: EnvOriginPipeline},
+		{Name: "SLACK_WEBHOOK", Doc: "Webhook"},
+		{Name: "TARGET", Value: "staging", Origin: EnvOriginJob},
+	}
+
+	var bash bytes.Buffer
+	require.NoError(t, ExportJobEnv(&bash, entries, ExportBash))
+	assert.Equal(t, `export DB_PASSWORD='it'\''s'
+export PATTERN='a\b c'
+export TARGET=staging
+`, bash.String())
+
+	var fish bytes.Buffer
+	require.NoError(t, ExportJobEnv(&fish, entries, ExportFish))
+	assert.Equal(t, `set -gx DB_PASSWORD 'it\'s'
+set -gx PATTERN 'a\\b c'
+set -gx TARGET 'staging'
+`, fish.String())
+
+	assert.ErrorContains(t, ExportJobEnv(&bash, entries, "tcsh"), `unknown shell "tcsh"`)
+}
+
+func TestListPipelines_EnvDocs(t *testing.T) {
+	pipelines, err := LoadPipelineFromReader(strings.NewReader(`
+env:
+  doc:
+    CI: Set by the CI system
+jobs:
+  deploy:
+    desc: Deploy the app
+    env:
+      doc:
+        DEPLOY_TOKEN: Token for the deploy API
+    run: ./deploy.sh
+`))
+	require.NoError(t, err)
+	pipelines[0].Name = "test"
+
+	assert.Equal(t, `test
+  $CI  Set by the CI system
+
+* deploy:  Deploy the app
+    $DEPLOY_TOKEN  Token for the deploy API
+`, colors.StripANSI(ListPipelines(pipelines)))
+
+	output := BuildListOutput(pipelines)
+	require.Len(t, output, 1)
+	assert.Equal(t, map[string]string{
+		"CI":           "Set by the CI system",
+		"DEPLOY_TOKEN": "Token for the deploy API",
+	}, output[0].Cmds[0].Env)
+}
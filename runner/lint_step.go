@@ -0,0 +1,270 @@
+package runner
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/titpetric/atkins/colors"
+	"github.com/titpetric/atkins/eventlog"
+	"github.com/titpetric/atkins/model"
+	"github.com/titpetric/atkins/psexec"
+	"github.com/titpetric/atkins/treeview"
+)
+
+// LintReportFindings is how many findings a `lint:` step shows under the step.
+var LintReportFindings = 50
+
+// lintFinding is an issue reported by a formatter or linter.
+type lintFinding struct {
+	Tool    string
+	File    string
+	Line    int
+	Column  int
+	Message string
+}
+
+// Position returns the line and column of the finding, e.g. 12:4.
+func (f lintFinding) Position() string {
+	if f.Column > 0 {
+		return fmt.Sprintf("%d:%d", f.Line, f.Column)
+	}
+	return strconv.Itoa(f.Line)
+}
+
+// lintTool is a formatter or linter a `lint:` step runs.
+type lintTool struct {
+	Command string
+	Parse   func(output string) []lintFinding
+}
+
+// lintTools holds the tools supported by `lint:` steps.
+var lintTools = map[string]lintTool{
+	"gofmt":         {Command: "gofmt -d .", Parse: diffFindings("not formatted with gofmt")},
+	"goimports":     {Command: "goimports -d .", Parse: diffFindings("imports not formatted with goimports")},
+	"golangci-lint": {Command: "golangci-lint run", Parse: lineFindings},
+}
+
+// validateLintTools checks the tools of a `lint:` step.
+func validateLintTools(lint *model.Lint) error {
+	if len(lint.Tools) == 0 {
+		return fmt.Errorf("lint: no tools, expected %s", strings.Join(slices.Sorted(maps.Keys(lintTools)), ", "))
+	}
+	for _, name := range lint.Tools {
+		if _, ok := lintTools[name]; !ok {
+			return fmt.Errorf("lint: unknown tool %q, expected %s", name, strings.Join(slices.Sorted(maps.Keys(lintTools)), ", "))
+		}
+	}
+	return nil
+}
+
+// executeLintStep runs the tools of a `lint:` step in the step dir. The
+// findings are shown under the step grouped by file, and emitted as
+// annotations when running in GitHub Actions. The step fails when a tool
+// reports findings or fails.
+func (e *Executor) executeLintStep(ctx context.Context, stepCtx *ExecutionContext, step *model.Step, stepNode *treeview.Node) (err error) {
+	defer stepCtx.Render()
+
+	jobName := ""
+	if stepCtx.Job != nil {
+		jobName = stepCtx.Job.Name
+	}
+	stepID := resolveStepID(jobName, step, stepCtx.StepSequence)
+	var startOffset float64
+	if stepCtx.EventLogger != nil {
+		startOffset = stepCtx.EventLogger.GetElapsed()
+	}
+
+	startTime := time.Now()
+	stepNode.SetID(stepID)
+	stepNode.SetStatus(treeview.StatusRunning)
+	stepCtx.Render()
+	defer func() {
+		stepNode.SetDuration(time.Since(startTime).Seconds())
+		result := eventlog.ResultPass
+		if err != nil {
+			result = eventlog.ResultFail
+			stepNode.SetStatus(treeview.StatusFailed)
+			stepCtx.failures.add(FailedStep{
+				Job:      jobName,
+				Step:     step.String(),
+				Duration: time.Since(startTime),
+				Error:    strings.SplitN(err.Error(), "\n", 2)[0],
+			})
+		} else {
+			stepNode.SetStatus(treeview.StatusPassed)
+		}
+		if stepCtx.EventLogger != nil {
+			stepCtx.EventLogger.LogExec(result, stepID, step.String(), startOffset, time.Since(startTime).Milliseconds(), err)
+		}
+	}()
+
+	if err := validateLintTools(step.Lint); err != nil {
+		return err
+	}
+
+	dir := cmp.Or(stepCtx.Dir, ".")
+	exec := psexec.NewWithOptions(&psexec.Options{
+		DefaultDir: dir,
+		DefaultEnv: stepCtx.Env.Environ(),
+	})
+
+	var findings []lintFinding
+	for _, name := range step.Lint.Tools {
+		tool := lintTools[name]
+		result, err := stepCtx.runProcess(ctx, exec, exec.ShellCommand(tool.Command), tool.Command)
+		if err != nil {
+			return err
+		}
+
+		// Errors like syntax errors are printed as file:line: message to stderr
+		found := append(tool.Parse(result.Output()), lineFindings(result.ErrorOutput())...)
+		for i := range found {
+			found[i].Tool = name
+		}
+		if !result.Success() && len(found) == 0 {
+			if stepNode != nil {
+				combined := NewCombinedOutputWriter()
+				_, _ = io.WriteString(combined.Stdout(), result.Output())
+				_, _ = io.WriteString(combined.Stderr(), result.ErrorOutput())
+				stepNode.SetOutput(failureContext(tool.Command, combined))
+			}
+			return fmt.Errorf("lint: %s failed: %w", name, NewExecError(result))
+		}
+		findings = append(findings, found...)
+	}
+
+	if len(findings) == 0 {
+		return nil
+	}
+	if stepNode != nil {
+		stepNode.SetOutput(lintReport(findings, LintReportFindings))
+	}
+	if os.Getenv("GITHUB_ACTIONS") == "true" {
+		writeAnnotations(os.Stdout, dir, findings)
+	}
+	return fmt.Errorf("lint: %d finding(s)", len(findings))
+}
+
+// lintReport renders up to limit findings grouped by file.
+func lintReport(findings []lintFinding, limit int) []string {
+	sorted := slices.Clone(findings)
+	slices.SortStableFunc(sorted, func(a, b lintFinding) int {
+		return cmp.Or(cmp.Compare(a.File, b.File), cmp.Compare(a.Line, b.Line), cmp.Compare(a.Column, b.Column))
+	})
+
+	var lines []string
+	file := ""
+	for i, f := range sorted {
+		if i == limit {
+			lines = append(lines, colors.Gray(fmt.Sprintf("... and %d more", len(sorted)-limit)))
+			break
+		}
+		if f.File != file {
+			file = f.File
+			lines = append(lines, colors.BrightWhite(file))
+		}
+		lines = append(lines, fmt.Sprintf("  %s %s %s", colors.BrightYellow(f.Position()), f.Message, colors.Gray(f.Tool)))
+	}
+	return lines
+}
+
+// writeAnnotations prints the findings as GitHub Actions error annotations.
+// File paths are made relative to the working directory, the repository
+// root in Actions.
+func writeAnnotations(w io.Writer, dir string, findings []lintFinding) {
+	cwd, _ := os.Getwd()
+	for _, f := range findings {
+		file := f.File
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(dir, file)
+		}
+		if abs, err := filepath.Abs(file); err == nil {
+			if rel, err := filepath.Rel(cwd, abs); err == nil {
+				file = rel
+			}
+		}
+		props := []string{"file=" + escapeAnnotationProperty(filepath.ToSlash(file))}
+		if f.Line > 0 {
+			props = append(props, "line="+strconv.Itoa(f.Line))
+		}
+		if f.Column > 0 {
+			props = append(props, "col="+strconv.Itoa(f.Column))
+		}
+		props = append(props, "title="+escapeAnnotationProperty(f.Tool))
+		fmt.Fprintf(w, "::error %s::%s\n", strings.Join(props, ","), escapeAnnotationData(f.Message))
+	}
+}
+
+// escapeAnnotationData escapes the message of a workflow command.
+func escapeAnnotationData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeAnnotationProperty escapes a property value of a workflow command.
+func escapeAnnotationProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
+
+// lineFindingPattern matches file:line[:column]: message lines, as
+// printed by golangci-lint and go vet.
+var lineFindingPattern = regexp.MustCompile(`^([^\s:][^:]*):(\d+)(?::(\d+))?: (.+)$`)
+
+// lineFindings parses file:line[:column]: message lines from the output.
+func lineFindings(output string) []lintFinding {
+	var findings []lintFinding
+	for _, line := range strings.Split(output, "\n") {
+		match := lineFindingPattern.FindStringSubmatch(strings.TrimRight(line, "\r"))
+		if match == nil {
+			continue
+		}
+		f := lintFinding{File: match[1], Message: strings.TrimSpace(match[4])}
+		f.Line, _ = strconv.Atoi(match[2])
+		f.Column, _ = strconv.Atoi(match[3])
+		findings = append(findings, f)
+	}
+	return findings
+}
+
+// hunkPattern matches the header of a unified diff hunk.
+var hunkPattern = regexp.MustCompile(`^@@ -(\d+)(?:,\d+)? \+\d+(?:,\d+)? @@`)
+
+// diffFindings returns a parser of the `-d` output of formatters, with a
+// finding at the first changed line of each hunk.
+func diffFindings(message string) func(string) []lintFinding {
+	return func(output string) []lintFinding {
+		var findings []lintFinding
+		var file string
+		line := 0 // The line in the original file, 0 once the hunk has a finding
+		for _, text := range strings.Split(output, "\n") {
+			switch {
+			case strings.HasPrefix(text, "+++ "):
+				file, _, _ = strings.Cut(strings.TrimPrefix(text, "+++ "), "\t")
+				line = 0
+			case strings.HasPrefix(text, "@@ "):
+				line = 0
+				if match := hunkPattern.FindStringSubmatch(text); match != nil {
+					line, _ = strconv.Atoi(match[1])
+				}
+			case strings.HasPrefix(text, "diff "), strings.HasPrefix(text, "--- "):
+				line = 0
+			case line == 0 || file == "":
+			case strings.HasPrefix(text, "-"), strings.HasPrefix(text, "+"):
+				findings = append(findings, lintFinding{File: file, Line: line, Message: message})
+				line = 0
+			default:
+				line++
+			}
+		}
+		return findings
+	}
+}
@@ -0,0 +1,149 @@
+package runner
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/titpetric/atkins/colors"
+	"github.com/titpetric/atkins/model"
+)
+
+func TestLint_UnmarshalYAML(t *testing.T) {
+	var steps []*model.Step
+	require.NoError(t, yaml.Unmarshal([]byte(`
+- lint: gofmt
+- lint: [gofmt, golangci-lint]
+- lint:
+    tools: [goimports]
+`), &steps))
+	require.Len(t, steps, 3)
+	assert.Equal(t, []string{"gofmt"}, steps[0].Lint.Tools)
+	assert.Equal(t, []string{"gofmt", "golangci-lint"}, steps[1].Lint.Tools)
+	assert.Equal(t, []string{"goimports"}, steps[2].Lint.Tools)
+	assert.Equal(t, "lint: gofmt, golangci-lint", steps[1].String())
+}
+
+func TestDiffFindings(t *testing.T) {
+	output := `diff pkg/a.go.orig pkg/a.go
+--- pkg/a.go.orig
++++ pkg/a.go
+@@ -1,5 +1,7 @@
+ package main
++
+ import "fmt"
+@@ -20,3 +22,3 @@ func main() {
+ 	x := 1
+-fmt.Println( "x")
++	fmt.Println("x")
+diff -u b.go.orig b.go
+--- b.go.orig	2024-01-01 00:00:00
++++ b.go	2024-01-01 00:00:00
+@@ -3,2 +3,2 @@
+-import "os"
++import "fmt"
+`
+	findings := diffFindings("not formatted")(output)
+	assert.Equal(t, []lintFinding{
+		{File: "pkg/a.go", Line: 2, Message: "not formatted"},
+		{File: "pkg/a.go", Line: 21, Message: "not formatted"},
+		{File: "b.go", Line: 3, Message: "not formatted"},
+	}, findings)
+}
+
+func TestLineFindings(t *testing.T) {
+	output := `level=warning msg="[config_reader] deprecated"
+main.go:10:2: Error return value of ` + "`f.Close`" + ` is not checked (errcheck)
+	f.Close()
+	^
+internal/x.go:4: exported function Foo should have comment (revive)
+2 issues:
+`
+	findings := lineFindings(output)
+	assert.Equal(t, []lintFinding{
+		{File: "main.go", Line: 10, Column: 2, Message: "Error return value of `f.Close` is not checked (errcheck)"},
+		{File: "internal/x.go", Line: 4, Message: "exported function Foo should have comment (revive)"},
+	}, findings)
+}
+
+func TestLintReport(t *testing.T) {
+	findings := []lintFinding{
+		{Tool: "golangci-lint", File: "b.go", Line: 4, Column: 2, Message: "unused"},
+		{Tool: "gofmt", File: "a.go", Line: 7, Message: "not formatted"},
+		{Tool: "golangci-lint", File: "b.go", Line: 1, Message: "shadow"},
+	}
+	var lines []string
+	for _, line := range lintReport(findings, 2) {
+		lines = append(lines, colors.StripANSI(line))
+	}
+	assert.Equal(t, []string{
+		"a.go",
+		"  7 not formatted gofmt",
+		"b.go",
+		"  1 shadow golangci-lint",
+		"... and 1 more",
+	}, lines)
+}
+
+func TestWriteAnnotations(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	var buf bytes.Buffer
+	writeAnnotations(&buf, "pkg", []lintFinding{
+		{Tool: "golangci-lint", File: "a.go", Line: 3, Column: 5, Message: "100% bad\nreally"},
+		{Tool: "gofmt", File: "b,c.go", Line: 1, Message: "not formatted"},
+	})
+	assert.Equal(t, "::error file=pkg/a.go,line=3,col=5,title=golangci-lint::100%25 bad%0Areally\n"+
+		"::error file=pkg/b%2Cc.go,line=1,title=gofmt::not formatted\n", buf.String())
+}
+
+func TestRunPipeline_LintStep(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("GITHUB_ACTIONS", "")
+	require.NoError(t, os.WriteFile("go.mod", []byte("module example.com/lint\n"), 0o644))
+	require.NoError(t, os.WriteFile("ok.go", []byte("package main\n\nfunc main() {}\n"), 0o644))
+
+	run := func() error {
+		pipelines, err := LoadPipelineFromReader(strings.NewReader(`
+jobs:
+  default:
+    steps:
+      - lint: gofmt
+`))
+		require.NoError(t, err)
+		return RunPipeline(t.Context(), pipelines[0], PipelineOptions{
+			Jobs:   []string{"default"},
+			Silent: true,
+		})
+	}
+
+	require.NoError(t, run())
+
+	require.NoError(t, os.MkdirAll("pkg", 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join("pkg", "bad.go"), []byte("package pkg\nfunc  X() {\nreturn\n}\n"), 0o644))
+	assert.ErrorContains(t, run(), "lint: 1 finding(s)")
+
+	require.NoError(t, os.WriteFile(filepath.Join("pkg", "bad.go"), []byte("package pkg\nfunc X( {\n"), 0o644))
+	assert.ErrorContains(t, run(), "lint: 1 finding(s)")
+}
+
+func TestLinter_LintStep(t *testing.T) {
+	pipeline := &model.Pipeline{
+		Name: "test-pipeline",
+		Jobs: map[string]*model.Job{
+			"lint": {Name: "lint", Steps: []*model.Step{{Lint: &model.Lint{Tools: []string{"gofmt", "eslint"}}}}},
+		},
+	}
+
+	errors := NewLinter(pipeline).Lint()
+	require.Len(t, errors, 1)
+	assert.Equal(t, "invalid lint step", errors[0].Issue)
+	assert.Contains(t, errors[0].Detail, `unknown tool "eslint"`)
+}
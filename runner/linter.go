@@ -2,6 +2,10 @@ package runner
 
 import (
 	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/titpetric/atkins/model"
 	runnererrors "github.com/titpetric/atkins/runner/errors"
@@ -12,6 +16,7 @@ type LintError struct {
 	Job    string
 	Issue  string
 	Detail string
+	Hint   string // Suggested fix, if any
 }
 
 // Linter validates a pipeline for correctness.
@@ -42,9 +47,499 @@ func NewLinterWithPipelines(pipeline *model.Pipeline, allPipelines []*model.Pipe
 func (l *Linter) Lint() []LintError {
 	l.validateDependencies()
 	l.validateTaskInvocations()
+	l.validateStepIDs()
+	l.validatePriorities()
+	l.validateNetworks()
+	l.validateLocks()
+	l.validateBudgets()
+	l.validateExitCodes()
+	l.validateHints()
+	l.validateScrub()
+	l.validatePorts()
+	l.validateInputs()
+	l.validateLintSteps()
+	l.validateWorkspaces()
+	l.validateReady()
+	l.validateServices()
+	l.validateAliases()
+	l.validateDefault()
+	l.validateStages()
+	l.validateCache()
 	return l.errors
 }
 
+// validateAliases checks that pipeline aliases target existing jobs and
+// aren't shadowed by a job of the same name.
+func (l *Linter) validateAliases() {
+	if len(l.pipeline.Aliases) == 0 {
+		return
+	}
+
+	pipelines := l.allPipelines
+	if len(pipelines) == 0 {
+		pipelines = []*model.Pipeline{l.pipeline}
+	}
+	resolver := NewTaskResolver(pipelines)
+	jobs := l.pipeline.GetJobs()
+
+	for _, alias := range slices.Sorted(maps.Keys(l.pipeline.Aliases)) {
+		target := l.pipeline.Aliases[alias]
+		if _, ok := jobs[alias]; ok {
+			l.errors = append(l.errors, LintError{
+				Job:    "aliases",
+				Issue:  "shadowed alias",
+				Detail: fmt.Sprintf("alias '%s' has the same name as a job, the job always takes precedence", alias),
+			})
+			continue
+		}
+		if _, found := resolver.resolveExplicitTarget(strings.TrimPrefix(target, ":")); !found {
+			l.errors = append(l.errors, LintError{
+				Job:    "aliases",
+				Issue:  "unknown alias target",
+				Detail: fmt.Sprintf("alias '%s' targets '%s', but no such job exists", alias, target),
+			})
+		}
+	}
+}
+
+// validateDefault checks that the pipeline default: targets existing jobs
+// and doesn't conflict with a default job.
+func (l *Linter) validateDefault() {
+	if len(l.pipeline.Default) == 0 {
+		return
+	}
+
+	if _, found := findDefaultJob(l.pipeline.GetJobs()); found {
+		l.errors = append(l.errors, LintError{
+			Job:    "default",
+			Issue:  "conflicting default",
+			Detail: "both the pipeline default: list and a default job are defined",
+			Hint:   "remove the default job or the pipeline default: list",
+		})
+	}
+
+	pipelines := l.allPipelines
+	if len(pipelines) == 0 {
+		pipelines = []*model.Pipeline{l.pipeline}
+	}
+	resolver := NewTaskResolver(pipelines)
+	jobs := l.pipeline.GetJobs()
+
+	for _, target := range l.pipeline.Default {
+		if _, ok := jobs[target]; ok {
+			continue
+		}
+		if _, found := resolver.resolveExplicitTarget(strings.TrimPrefix(target, ":")); !found {
+			l.errors = append(l.errors, LintError{
+				Job:    "default",
+				Issue:  "unknown default target",
+				Detail: fmt.Sprintf("target '%s' is listed, but no such job exists", target),
+			})
+		}
+	}
+}
+
+// validateStages checks that jobs use declared stages and don't depend on
+// jobs of a later stage, which would never complete before them.
+func (l *Linter) validateStages() {
+	stages := l.pipeline.StageNames()
+	jobs := l.pipeline.GetJobs()
+	for _, jobName := range l.pipeline.JobNames() {
+		job := jobs[jobName]
+		if job == nil || job.Stage == "" {
+			continue
+		}
+		stage := slices.Index(stages, job.Stage)
+		if stage < 0 {
+			l.errors = append(l.errors, LintError{
+				Job:    jobName,
+				Issue:  "unknown stage",
+				Detail: fmt.Sprintf("job '%s' uses stage '%s', which is not listed in stages:", jobName, job.Stage),
+			})
+			continue
+		}
+		for _, dep := range GetDependencies(job.DependsOn) {
+			depJob := jobs[dep]
+			if depJob == nil || slices.Index(stages, depJob.Stage) <= stage {
+				continue
+			}
+			l.errors = append(l.errors, LintError{
+				Job:    jobName,
+				Issue:  "dependency in later stage",
+				Detail: fmt.Sprintf("job '%s' of stage '%s' depends on '%s' of the later stage '%s'", jobName, job.Stage, dep, depJob.Stage),
+			})
+		}
+	}
+}
+
+// validateCache checks that cached jobs don't loop, and that their outputs
+// are within the job dir
+func (l *Linter) validateCache() {
+	for jobName, job := range l.pipeline.GetJobs() {
+		if job == nil || job.Cache == nil {
+			continue
+		}
+		if !job.For.IsEmpty() {
+			l.errors = append(l.errors, LintError{
+				Job:    jobName,
+				Issue:  "cache with for",
+				Detail: fmt.Sprintf("job '%s' sets cache: and a job-level for:, which can't be cached", jobName),
+				Hint:   "move the for: loop to a step",
+			})
+		}
+		for _, output := range job.Cache.Outputs {
+			if filepath.IsLocal(output) {
+				continue
+			}
+			l.errors = append(l.errors, LintError{
+				Job:    jobName,
+				Issue:  "invalid cache output",
+				Detail: fmt.Sprintf("job '%s' caches '%s', which is outside of the job dir", jobName, output),
+			})
+		}
+	}
+}
+
+// validateServices checks that jobs only use services defined in the pipeline
+func (l *Linter) validateServices() {
+	for jobName, job := range l.pipeline.GetJobs() {
+		if job == nil {
+			continue
+		}
+		for _, name := range job.Services {
+			if _, ok := l.pipeline.Services[name]; ok {
+				continue
+			}
+			l.errors = append(l.errors, LintError{
+				Job:    jobName,
+				Issue:  "unknown service",
+				Detail: fmt.Sprintf("job '%s' uses service '%s', which is not defined in services:", jobName, name),
+			})
+		}
+	}
+}
+
+// validateReady checks that steps with ready: are detached
+func (l *Linter) validateReady() {
+	for jobName, job := range l.pipeline.GetJobs() {
+		if job == nil {
+			continue
+		}
+		for idx, step := range job.Children() {
+			if step == nil || step.Ready == nil || step.Detach {
+				continue
+			}
+			l.errors = append(l.errors, LintError{
+				Job:    jobName,
+				Issue:  "ready without detach",
+				Detail: fmt.Sprintf("job '%s' %s sets ready: but is not detached", jobName, stepRequirementLabel(step, idx)),
+			})
+		}
+	}
+}
+
+// validateWorkspaces checks that job workspace modes are known
+func (l *Linter) validateWorkspaces() {
+	for jobName, job := range l.pipeline.GetJobs() {
+		if job == nil {
+			continue
+		}
+		switch job.Workspace {
+		case "", WorkspaceClean, WorkspaceWorktree:
+			continue
+		}
+		l.errors = append(l.errors, LintError{
+			Job:    jobName,
+			Issue:  "unknown workspace",
+			Detail: fmt.Sprintf("job '%s' sets workspace '%s', expected '%s' or '%s'", jobName, job.Workspace, WorkspaceClean, WorkspaceWorktree),
+		})
+	}
+}
+
+// validatePriorities checks the nice level, I/O class and cpuset of steps
+func (l *Linter) validatePriorities() {
+	for jobName, job := range l.pipeline.GetJobs() {
+		if job == nil {
+			continue
+		}
+		for _, step := range job.Children() {
+			if step == nil {
+				continue
+			}
+			if _, err := stepPriority(step); err != nil {
+				l.errors = append(l.errors, LintError{
+					Job:    jobName,
+					Issue:  "invalid priority",
+					Detail: fmt.Sprintf("step '%s': %v", step.String(), err),
+				})
+			}
+		}
+	}
+}
+
+// validateNetworks checks that jobs and steps use known network modes
+func (l *Linter) validateNetworks() {
+	for jobName, job := range l.pipeline.GetJobs() {
+		if job == nil {
+			continue
+		}
+		if _, err := stepNoNetwork(job, &model.Step{}); err != nil {
+			l.errors = append(l.errors, LintError{
+				Job:    jobName,
+				Issue:  "unknown network",
+				Detail: fmt.Sprintf("job '%s': %s", jobName, strings.ReplaceAll(err.Error(), "\n", "; ")),
+			})
+		}
+		for _, step := range job.Children() {
+			if step == nil || step.Network == "" {
+				continue
+			}
+			if _, err := stepNoNetwork(nil, step); err != nil {
+				l.errors = append(l.errors, LintError{
+					Job:    jobName,
+					Issue:  "unknown network",
+					Detail: fmt.Sprintf("step '%s': %v", step.String(), err),
+				})
+			}
+		}
+	}
+}
+
+// validateLocks checks the lock names and timeouts of jobs and steps.
+// Names using variables are checked when the lock is taken.
+func (l *Linter) validateLocks() {
+	check := func(jobName string, lock *model.Lock) {
+		if lock == nil || strings.Contains(lock.Name, "${{") {
+			return
+		}
+		if err := validateLock(lock); err != nil {
+			l.errors = append(l.errors, LintError{
+				Job:    jobName,
+				Issue:  "invalid lock",
+				Detail: fmt.Sprintf("job '%s': %v", jobName, err),
+			})
+		}
+	}
+	for jobName, job := range l.pipeline.GetJobs() {
+		if job == nil {
+			continue
+		}
+		check(jobName, job.Lock)
+		for _, step := range job.Children() {
+			if step == nil {
+				continue
+			}
+			check(jobName, step.Lock)
+			if job.Lock != nil && step.Lock != nil && step.Lock.Name == job.Lock.Name {
+				l.errors = append(l.errors, LintError{
+					Job:    jobName,
+					Issue:  "invalid lock",
+					Detail: fmt.Sprintf("job '%s': step '%s' takes lock %q, which the job holds already", jobName, step.String(), step.Lock.Name),
+					Hint:   "remove the lock: of the step",
+				})
+			}
+		}
+	}
+}
+
+// validateBudgets checks the budgets of steps.
+func (l *Linter) validateBudgets() {
+	for jobName, job := range l.pipeline.GetJobs() {
+		if job == nil {
+			continue
+		}
+		for _, step := range job.Children() {
+			if step == nil || step.Budget == "" {
+				continue
+			}
+			if _, err := parseBudget(step.Budget); err != nil {
+				l.errors = append(l.errors, LintError{
+					Job:    jobName,
+					Issue:  "invalid budget",
+					Detail: fmt.Sprintf("job '%s', step '%s': %v", jobName, step.String(), err),
+					Hint:   `use a duration like "30s" or "5m"`,
+				})
+			}
+		}
+	}
+}
+
+// validateExitCodes checks the accept_exit_codes and skip_exit_codes of steps.
+func (l *Linter) validateExitCodes() {
+	for jobName, job := range l.pipeline.GetJobs() {
+		if job == nil {
+			continue
+		}
+		for _, step := range job.Children() {
+			if step == nil {
+				continue
+			}
+			if err := validateExitCodes(step); err != nil {
+				l.errors = append(l.errors, LintError{
+					Job:    jobName,
+					Issue:  "invalid exit codes",
+					Detail: fmt.Sprintf("job '%s', step '%s': %v", jobName, step.String(), err),
+					Hint:   "use exit codes from 1 to 255, each either accepted or skipped",
+				})
+			}
+		}
+	}
+}
+
+// validateHints checks the failure hints of the pipeline.
+func (l *Linter) validateHints() {
+	for i, hint := range l.pipeline.Hints {
+		if _, err := compileHint(hint); err != nil {
+			l.errors = append(l.errors, LintError{
+				Issue:  "invalid hint",
+				Detail: fmt.Sprintf("hint %d: %v", i, err),
+				Hint:   "set match to a regular expression and hint to the suggested remedy",
+			})
+		}
+	}
+}
+
+// validateScrub checks the scrub rules of the pipeline.
+func (l *Linter) validateScrub() {
+	for i, rule := range l.pipeline.Scrub {
+		if _, err := compileScrubRule(rule); err != nil {
+			l.errors = append(l.errors, LintError{
+				Issue:  "invalid scrub rule",
+				Detail: fmt.Sprintf("scrub rule %d: %v", i, err),
+				Hint:   "set match to a regular expression and replace to the replacement",
+			})
+		}
+	}
+}
+
+// validatePorts checks the port names and values of jobs.
+func (l *Linter) validatePorts() {
+	for jobName, job := range l.pipeline.GetJobs() {
+		if job == nil {
+			continue
+		}
+		if err := validatePorts(job.Ports); err != nil {
+			l.errors = append(l.errors, LintError{
+				Job:    jobName,
+				Issue:  "invalid port",
+				Detail: fmt.Sprintf("job '%s': %v", jobName, err),
+			})
+		}
+	}
+}
+
+// validateInputs checks the input schemas of jobs, and the `with:` values
+// of task steps against the inputs of the task.
+func (l *Linter) validateInputs() {
+	for jobName, job := range l.pipeline.GetJobs() {
+		if job == nil {
+			continue
+		}
+		if err := validateInputs(job.Inputs, "inputs"); err != nil {
+			l.errors = append(l.errors, LintError{
+				Job:    jobName,
+				Issue:  "invalid inputs",
+				Detail: fmt.Sprintf("job '%s': %v", jobName, err),
+			})
+		}
+		for _, step := range job.Children() {
+			if step == nil {
+				continue
+			}
+			if step.Task == "" {
+				if len(step.With) > 0 {
+					l.errors = append(l.errors, LintError{
+						Job:    jobName,
+						Issue:  "with without task",
+						Detail: fmt.Sprintf("step '%s': with: only applies to task steps", step.String()),
+					})
+				}
+				continue
+			}
+			resolved, err := l.resolveTask(step.Task)
+			if err != nil || len(resolved.Job.Inputs) == 0 {
+				continue
+			}
+			// Invalid schemas are reported on the task job
+			if validateInputs(resolved.Job.Inputs, "inputs") != nil {
+				continue
+			}
+			values := staticInput(map[string]any(step.With)).(map[string]any)
+			if err := checkInputs(resolved.Job.Inputs, values, "with"); err != nil {
+				l.errors = append(l.errors, LintError{
+					Job:    jobName,
+					Issue:  "invalid with",
+					Detail: fmt.Sprintf("step '%s': %v", step.String(), err),
+				})
+			}
+		}
+	}
+}
+
+// validateLintSteps checks the tools of `lint:` steps.
+func (l *Linter) validateLintSteps() {
+	for jobName, job := range l.pipeline.GetJobs() {
+		if job == nil {
+			continue
+		}
+		for _, step := range job.Children() {
+			if step == nil || step.Lint == nil {
+				continue
+			}
+			if err := validateLintTools(step.Lint); err != nil {
+				l.errors = append(l.errors, LintError{
+					Job:    jobName,
+					Issue:  "invalid lint step",
+					Detail: fmt.Sprintf("step '%s': %v", step.String(), err),
+				})
+			}
+			if !step.For.IsEmpty() {
+				l.errors = append(l.errors, LintError{
+					Job:    jobName,
+					Issue:  "lint with for",
+					Detail: fmt.Sprintf("step '%s': lint steps don't support for loops, use a job-level for", step.String()),
+				})
+			}
+		}
+	}
+}
+
+// validateStepIDs checks that explicit step ids are valid and unique within a job
+func (l *Linter) validateStepIDs() {
+	jobs := l.pipeline.Jobs
+	if len(jobs) == 0 {
+		jobs = l.pipeline.Tasks
+	}
+
+	for jobName, job := range jobs {
+		if job == nil {
+			continue
+		}
+
+		seen := make(map[string]bool)
+		for _, step := range job.Children() {
+			if step == nil || step.ID == "" {
+				continue
+			}
+			if !stepIDPattern.MatchString(step.ID) {
+				l.errors = append(l.errors, LintError{
+					Job:    jobName,
+					Issue:  "invalid step id",
+					Detail: fmt.Sprintf("job '%s': step id '%s' must start with a letter, followed by letters, digits, '_' or '-'", jobName, step.ID),
+				})
+			}
+			if seen[step.ID] {
+				l.errors = append(l.errors, LintError{
+					Job:    jobName,
+					Issue:  "duplicate step id",
+					Detail: fmt.Sprintf("job '%s' has more than one step with id '%s'", jobName, step.ID),
+				})
+			}
+			seen[step.ID] = true
+		}
+	}
+}
+
 // validateDependencies checks that all depends_on references exist
 func (l *Linter) validateDependencies() {
 	jobs := l.pipeline.Jobs
@@ -107,21 +602,28 @@ func (l *Linter) validateTaskInvocations() {
 }
 
 // validateTaskReference validates a task reference using the shared TaskResolver.
-// It first tries resolving within the current pipeline (skill-local), then falls
-// back to all pipelines for cross-pipeline references.
 func (l *Linter) validateTaskReference(taskName string) error {
+	_, err := l.resolveTask(taskName)
+	return err
+}
+
+// resolveTask resolves a task reference. It first tries resolving within the
+// current pipeline (skill-local), then falls back to all pipelines for
+// cross-pipeline references.
+func (l *Linter) resolveTask(taskName string) (*model.ResolvedTask, error) {
 	// Try skill-local resolution first
 	localResolver := NewSkillResolver(l.pipeline)
-	if _, err := localResolver.Resolve(taskName); err == nil {
-		return nil
+	if resolved, err := localResolver.Resolve(taskName); err == nil {
+		return resolved, nil
 	}
 
 	// Fall back to cross-pipeline resolution
 	resolver := NewTaskResolver(l.allPipelines)
-	if _, err := resolver.Resolve(taskName); err != nil {
-		return fmt.Errorf("step references task '%s', but %s", taskName, err)
+	resolved, err := resolver.Resolve(taskName)
+	if err != nil {
+		return nil, fmt.Errorf("step references task '%s', but %s", taskName, err)
 	}
-	return nil
+	return resolved, nil
 }
 
 // GetDependencies converts depends_on field (string or []string) to a slice of job names.
@@ -159,7 +661,8 @@ func findDefaultJob(jobs map[string]*model.Job) (string, bool) {
 	}
 
 	// Check for a job with "default" as an alias
-	for jobName, job := range jobs {
+	for _, jobName := range slices.Sorted(maps.Keys(jobs)) {
+		job := jobs[jobName]
 		for _, alias := range job.Aliases {
 			if alias == "default" {
 				return jobName, true
@@ -248,24 +751,3 @@ func resolveDependencyChain(jobs map[string]*model.Job, jobName string) ([]strin
 
 	return resolved, nil
 }
-
-// ValidateJobRequirements checks that all required variables are present in the context.
-// Returns an error with a clear message listing missing variables.
-func ValidateJobRequirements(ctx *ExecutionContext, job *model.Job) error {
-	if len(job.Requires) == 0 {
-		return nil // No requirements to validate
-	}
-
-	var missing []string
-	for _, varName := range job.Requires {
-		if ctx.Variables.Get(varName) == nil {
-			missing = append(missing, varName)
-		}
-	}
-
-	if len(missing) > 0 {
-		return fmt.Errorf("job '%s' requires variables %v but missing: %v", job.Name, job.Requires, missing)
-	}
-
-	return nil
-}
@@ -0,0 +1,78 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+)
+
+// unquotedInterpolations reports ${{ }} expressions interpolated into
+// shell commands outside of quotes. Their values are parsed by the shell,
+// so a value holding spaces, `;` or `$(...)` changes the command.
+func (l *Linter) unquotedInterpolations() []LintError {
+	var warnings []LintError
+	for jobName, job := range l.pipeline.GetJobs() {
+		if job == nil {
+			continue
+		}
+		for idx, step := range job.Children() {
+			if step == nil || len(step.Argv) > 0 {
+				continue
+			}
+			var unquoted []string
+			for _, cmd := range step.Commands() {
+				unquoted = append(unquoted, unquotedExpressions(cmd)...)
+			}
+			if len(unquoted) == 0 {
+				continue
+			}
+			warnings = append(warnings, LintError{
+				Job:    jobName,
+				Issue:  "unquoted interpolation",
+				Detail: fmt.Sprintf("%s interpolates %s unquoted", stepRequirementLabel(step, idx), strings.Join(unquoted, ", ")),
+				Hint:   "quote the value with ${{ quote(name) }}, or pass the arguments with argv:",
+			})
+		}
+	}
+	return warnings
+}
+
+// unquotedExpressions returns the ${{ }} expressions of cmd outside of
+// single and double quotes, skipping those wrapped in quote().
+func unquotedExpressions(cmd string) []string {
+	var result []string
+	matches := interpolationRegex.FindAllStringSubmatchIndex(cmd, -1)
+	for _, loc := range matches {
+		exprStr := strings.TrimSpace(cmd[loc[2]:loc[3]])
+		if strings.HasPrefix(exprStr, "quote(") || !identifierPattern.MatchString(exprStr) {
+			continue
+		}
+		if !shellQuotedAt(cmd, loc[0]) {
+			result = append(result, cmd[loc[0]:loc[1]])
+		}
+	}
+	return result
+}
+
+// shellQuotedAt reports whether the position in cmd is within single or
+// double quotes.
+func shellQuotedAt(cmd string, pos int) bool {
+	var quote byte
+	for i := 0; i < pos; i++ {
+		c := cmd[i]
+		switch {
+		case quote == '\'':
+			if c == '\'' {
+				quote = 0
+			}
+		case c == '\\':
+			i++
+		case quote == '"':
+			if c == '"' {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		}
+	}
+	return quote != 0
+}
@@ -0,0 +1,66 @@
+package runner
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/titpetric/atkins/model"
+)
+
+// unscrubbedSecrets reports values of vars, env and commands matching
+// SecretPatterns that no scrub rule replaces. They end up in the output
+// and logs of the run when a command prints them.
+func (l *Linter) unscrubbedSecrets() []LintError {
+	// Invalid scrub rules are reported by validateScrub
+	scrub, _ := newScrubber(l.pipeline.Scrub)
+
+	var warnings []LintError
+	check := func(jobName, where, text string) {
+		for _, name := range slices.Sorted(maps.Keys(SecretPatterns)) {
+			match := SecretPatterns[name].FindString(text)
+			if match == "" || !strings.Contains(scrub.scrub(match), match) {
+				continue
+			}
+			warnings = append(warnings, LintError{
+				Job:    jobName,
+				Issue:  "unscrubbed secret",
+				Detail: fmt.Sprintf("%s holds a likely %s", where, name),
+				Hint:   "pass the secret from the environment, or add a scrub: rule matching it",
+			})
+		}
+	}
+	checkDecl := func(jobName, prefix string, decl *model.Decl) {
+		if decl == nil {
+			return
+		}
+		for name, value := range decl.Vars {
+			check(jobName, prefix+"vars."+name, valueText(value))
+		}
+		if decl.Env != nil {
+			for name, value := range decl.Env.Vars {
+				check(jobName, prefix+"env."+name, valueText(value))
+			}
+		}
+	}
+
+	checkDecl("", "", l.pipeline.Decl)
+	for jobName, job := range l.pipeline.GetJobs() {
+		if job == nil {
+			continue
+		}
+		checkDecl(jobName, fmt.Sprintf("job '%s' ", jobName), job.Decl)
+		for idx, step := range job.Children() {
+			if step == nil {
+				continue
+			}
+			label := fmt.Sprintf("job '%s' %s ", jobName, stepRequirementLabel(step, idx))
+			checkDecl(jobName, label, step.Decl)
+			for _, cmd := range step.Commands() {
+				check(jobName, strings.TrimSpace(label)+" command", cmd)
+			}
+		}
+	}
+	return warnings
+}
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/titpetric/atkins/model"
 	runnererrors "github.com/titpetric/atkins/runner/errors"
@@ -126,6 +127,201 @@ func TestLinter_MissingDependency(t *testing.T) {
 	assert.Contains(t, errors[0].Detail, "nonexistent-dep")
 }
 
+// TestLinter_DuplicateStepID verifies that linter detects duplicate step ids within a job
+func TestLinter_DuplicateStepID(t *testing.T) {
+	pipeline := &model.Pipeline{
+		Name: "test-pipeline",
+		Jobs: map[string]*model.Job{
+			"test-job": {
+				Name: "test-job",
+				Steps: []*model.Step{
+					{ID: "build", Run: "echo one"},
+					{ID: "build", Run: "echo two"},
+					{Run: "echo three"},
+				},
+			},
+		},
+	}
+
+	linter := NewLinter(pipeline)
+	errors := linter.Lint()
+
+	assert.Len(t, errors, 1)
+	assert.Equal(t, errors[0].Job, "test-job")
+	assert.Equal(t, errors[0].Issue, "duplicate step id")
+	assert.Contains(t, errors[0].Detail, "build")
+}
+
+// TestLinter_UnknownWorkspace verifies that linter rejects unknown workspace modes
+func TestLinter_UnknownWorkspace(t *testing.T) {
+	pipeline := &model.Pipeline{
+		Name: "test-pipeline",
+		Jobs: map[string]*model.Job{
+			"clean":    {Name: "clean", Workspace: "clean", Steps: []*model.Step{{Run: "echo ok"}}},
+			"worktree": {Name: "worktree", Workspace: "worktree", Steps: []*model.Step{{Run: "echo ok"}}},
+			"dirty":    {Name: "dirty", Workspace: "dirty", Steps: []*model.Step{{Run: "echo ok"}}},
+		},
+	}
+
+	linter := NewLinter(pipeline)
+	errors := linter.Lint()
+
+	assert.Len(t, errors, 1)
+	assert.Equal(t, errors[0].Job, "dirty")
+	assert.Equal(t, errors[0].Issue, "unknown workspace")
+}
+
+// TestLinter_ReadyWithoutDetach verifies that ready: requires detach: true
+func TestLinter_ReadyWithoutDetach(t *testing.T) {
+	pipeline := &model.Pipeline{
+		Name: "test-pipeline",
+		Jobs: map[string]*model.Job{
+			"dev": {Name: "dev", Steps: []*model.Step{
+				{Run: "npm run dev", Detach: true, Ready: &model.Ready{Port: 3000}},
+				{Run: "npm run dev", Ready: &model.Ready{Port: 3000}},
+			}},
+		},
+	}
+
+	linter := NewLinter(pipeline)
+	errors := linter.Lint()
+
+	assert.Len(t, errors, 1)
+	assert.Equal(t, "ready without detach", errors[0].Issue)
+	assert.Contains(t, errors[0].Detail, "step 1")
+}
+
+// TestLinter_UnknownService verifies that jobs only use defined services
+func TestLinter_UnknownService(t *testing.T) {
+	pipeline := &model.Pipeline{
+		Name:     "test-pipeline",
+		Services: model.Services{"db": {Run: "postgres"}},
+		Jobs: map[string]*model.Job{
+			"test":  {Name: "test", Services: model.Dependencies{"db"}, Steps: []*model.Step{{Run: "go test"}}},
+			"cache": {Name: "cache", Services: model.Dependencies{"redis"}, Steps: []*model.Step{{Run: "go test"}}},
+		},
+	}
+
+	linter := NewLinter(pipeline)
+	errors := linter.Lint()
+
+	assert.Len(t, errors, 1)
+	assert.Equal(t, "cache", errors[0].Job)
+	assert.Equal(t, "unknown service", errors[0].Issue)
+}
+
+// TestLinter_PipelineAliases verifies that pipeline aliases target existing jobs
+func TestLinter_PipelineAliases(t *testing.T) {
+	pipelines := []*model.Pipeline{
+		{
+			Name:    "test-pipeline",
+			Aliases: map[string]string{"t": "go:test", "b": "build", "build": "go:test", "d": "docker:build"},
+			Jobs: map[string]*model.Job{
+				"build": {Name: "build", Steps: []*model.Step{{Run: "go build"}}},
+			},
+		},
+		{
+			ID:   "go",
+			Name: "go",
+			Jobs: map[string]*model.Job{
+				"test": {Name: "test", Steps: []*model.Step{{Run: "go test"}}},
+			},
+		},
+	}
+
+	linter := NewLinterWithPipelines(pipelines[0], pipelines)
+	errors := linter.Lint()
+
+	require.Len(t, errors, 2)
+	assert.Equal(t, "shadowed alias", errors[0].Issue)
+	assert.Contains(t, errors[0].Detail, "'build'")
+	assert.Equal(t, "unknown alias target", errors[1].Issue)
+	assert.Contains(t, errors[1].Detail, "docker:build")
+}
+
+// TestLinter_PipelineDefault verifies that the pipeline default: targets existing jobs
+func TestLinter_PipelineDefault(t *testing.T) {
+	pipelines := []*model.Pipeline{
+		{
+			Name:    "test-pipeline",
+			Default: []string{"build", "go:test", "docker:build"},
+			Jobs: map[string]*model.Job{
+				"build": {Name: "build", Steps: []*model.Step{{Run: "go build"}}},
+			},
+		},
+		{
+			ID:   "go",
+			Name: "go",
+			Jobs: map[string]*model.Job{
+				"test": {Name: "test", Steps: []*model.Step{{Run: "go test"}}},
+			},
+		},
+	}
+
+	errors := NewLinterWithPipelines(pipelines[0], pipelines).Lint()
+	require.Len(t, errors, 1)
+	assert.Equal(t, "unknown default target", errors[0].Issue)
+	assert.Contains(t, errors[0].Detail, "docker:build")
+
+	pipelines[0].Default = []string{"build"}
+	pipelines[0].Jobs["ci"] = &model.Job{Name: "ci", Aliases: []string{"default"}, Steps: []*model.Step{{Task: "build"}}}
+	errors = NewLinterWithPipelines(pipelines[0], pipelines).Lint()
+	require.Len(t, errors, 1)
+	assert.Equal(t, "conflicting default", errors[0].Issue)
+}
+
+// TestLinter_Stages verifies that jobs use declared stages in dependency order
+func TestLinter_Stages(t *testing.T) {
+	pipeline := &model.Pipeline{
+		Name:   "test-pipeline",
+		Stages: []string{"build", "test"},
+		Jobs: map[string]*model.Job{
+			"build":  {Name: "build", Stage: "build", DependsOn: model.Dependencies{"test"}},
+			"test":   {Name: "test", Stage: "test"},
+			"deploy": {Name: "deploy", Stage: "deploy"},
+		},
+	}
+
+	errors := NewLinter(pipeline).Lint()
+	require.Len(t, errors, 2)
+	assert.Equal(t, "dependency in later stage", errors[0].Issue)
+	assert.Equal(t, "build", errors[0].Job)
+	assert.Equal(t, "unknown stage", errors[1].Issue)
+	assert.Equal(t, "deploy", errors[1].Job)
+}
+
+func TestLinter_Cache(t *testing.T) {
+	pipeline := &model.Pipeline{
+		Name: "test-pipeline",
+		Jobs: map[string]*model.Job{
+			"build": {Name: "build", Cache: &model.Cache{Outputs: []string{"bin", "../escape"}}},
+			"each":  {Name: "each", For: model.Iterators{"item in items"}, Cache: &model.Cache{}},
+		},
+	}
+
+	errors := NewLinter(pipeline).Lint()
+	require.Len(t, errors, 2)
+	issues := map[string]string{}
+	for _, e := range errors {
+		issues[e.Job] = e.Issue
+	}
+	assert.Equal(t, map[string]string{"build": "invalid cache output", "each": "cache with for"}, issues)
+}
+
+func TestLinter_Network(t *testing.T) {
+	pipeline := &model.Pipeline{
+		Name: "test-pipeline",
+		Jobs: map[string]*model.Job{
+			"test": {Name: "test", Network: "none", Steps: []*model.Step{{Run: "go test ./...", Network: "off"}}},
+		},
+	}
+
+	errors := NewLinter(pipeline).Lint()
+	require.Len(t, errors, 1)
+	assert.Equal(t, "unknown network", errors[0].Issue)
+	assert.Contains(t, errors[0].Detail, `unknown network "off"`)
+}
+
 // TestJobChildrenConsistency verifies that Job.Children() is used consistently
 func TestJobChildrenConsistency(t *testing.T) {
 	// Test that Children() returns Steps when available
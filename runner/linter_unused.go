@@ -0,0 +1,271 @@
+package runner
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/titpetric/atkins/model"
+)
+
+// Unused reports jobs that are never invoked, vars that are never
+// referenced, steps that can never run and values interpolated into
+// commands unquoted. Unlike Lint, the findings are warnings: the
+// pipeline still runs as written.
+func (l *Linter) Unused() []LintError {
+	var warnings []LintError
+	warnings = append(warnings, l.unusedJobs()...)
+	warnings = append(warnings, l.unusedVars()...)
+	warnings = append(warnings, l.unreachableSteps()...)
+	warnings = append(warnings, l.unquotedInterpolations()...)
+	warnings = append(warnings, l.unscrubbedSecrets()...)
+
+	sort.SliceStable(warnings, func(i, j int) bool {
+		if warnings[i].Job != warnings[j].Job {
+			return warnings[i].Job < warnings[j].Job
+		}
+		return warnings[i].Detail < warnings[j].Detail
+	})
+	return warnings
+}
+
+// unusedJobs reports jobs hidden from the command line that are not
+// referenced by depends_on, task: or aliases of any pipeline.
+func (l *Linter) unusedJobs() []LintError {
+	referenced := l.referencedJobs()
+
+	var warnings []LintError
+	for jobName, job := range l.pipeline.GetJobs() {
+		if job == nil || jobName == "default" || len(job.Aliases) > 0 {
+			continue
+		}
+		// Root-level jobs are invoked from the command line by convention
+		if job.Show == nil && !strings.Contains(jobName, ":") {
+			continue
+		}
+		if job.Show != nil && *job.Show {
+			continue
+		}
+		if referenced[jobName] {
+			continue
+		}
+		warnings = append(warnings, LintError{
+			Job:    jobName,
+			Issue:  "unused job",
+			Detail: fmt.Sprintf("job '%s' is hidden and never invoked", jobName),
+			Hint:   fmt.Sprintf("remove the job, invoke it with `task: %s` or `depends_on`, or set `show: true`", jobName),
+		})
+	}
+	return warnings
+}
+
+// referencedJobs returns the jobs of the pipeline invoked by depends_on
+// or task: steps, including invocations from other pipelines.
+func (l *Linter) referencedJobs() map[string]bool {
+	referenced := make(map[string]bool)
+	add := func(from *model.Pipeline, name string) {
+		name = strings.TrimPrefix(name, ":")
+		if from != l.pipeline {
+			if l.pipeline.ID == "" || !strings.HasPrefix(name, l.pipeline.ID+":") {
+				return
+			}
+		}
+		if l.pipeline.ID != "" {
+			name = strings.TrimPrefix(name, l.pipeline.ID+":")
+		}
+		referenced[name] = true
+	}
+
+	pipelines := l.allPipelines
+	if len(pipelines) == 0 {
+		pipelines = []*model.Pipeline{l.pipeline}
+	}
+	for _, p := range pipelines {
+		if p.ID == "" {
+			for _, target := range p.Aliases {
+				add(p, target)
+			}
+		}
+		for _, job := range p.GetJobs() {
+			if job == nil {
+				continue
+			}
+			for _, dep := range GetDependencies(job.DependsOn) {
+				add(p, dep)
+			}
+			for _, step := range job.Children() {
+				if step != nil && step.Task != "" {
+					add(p, step.Task)
+				}
+			}
+		}
+	}
+	return referenced
+}
+
+// unusedVars reports vars that no expression, condition, loop or
+// requirement of the pipeline refers to.
+func (l *Linter) unusedVars() []LintError {
+	used := referencedIdentifiers(l.pipeline)
+
+	var warnings []LintError
+	report := func(jobName, scope string, decl *model.Decl) {
+		if decl == nil {
+			return
+		}
+		for name := range decl.Vars {
+			if used[name] {
+				continue
+			}
+			warnings = append(warnings, LintError{
+				Job:    jobName,
+				Issue:  "unused var",
+				Detail: fmt.Sprintf("%s var '%s' is never referenced", scope, name),
+				Hint:   fmt.Sprintf("remove '%s' from vars, or use it as ${{ %s }}", name, name),
+			})
+		}
+	}
+
+	report("", "pipeline", l.pipeline.Decl)
+	for jobName, job := range l.pipeline.GetJobs() {
+		if job == nil {
+			continue
+		}
+		report(jobName, fmt.Sprintf("job '%s'", jobName), job.Decl)
+		for idx, step := range job.Children() {
+			// Vars of task: steps are arguments of the invoked job
+			if step == nil || step.Task != "" {
+				continue
+			}
+			report(jobName, fmt.Sprintf("job '%s' %s", jobName, stepRequirementLabel(step, idx)), step.Decl)
+		}
+	}
+	return warnings
+}
+
+var (
+	expressionPattern = regexp.MustCompile(`\$\{\{(.*?)\}\}`)
+	identifierPattern = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*`)
+
+	conditionType    = reflect.TypeOf(model.Condition(""))
+	iteratorType     = reflect.TypeOf(model.Iterator(""))
+	requirementsType = reflect.TypeOf(model.Requirements{})
+)
+
+// referencedIdentifiers returns the identifiers used in ${{ }}
+// expressions, if: conditions, for: loops and required vars.
+func referencedIdentifiers(pipeline *model.Pipeline) map[string]bool {
+	used := make(map[string]bool)
+	addIdentifiers := func(text string) {
+		for _, name := range identifierPattern.FindAllString(text, -1) {
+			used[name] = true
+		}
+	}
+
+	walkStrings(reflect.ValueOf(pipeline), func(value string, typ reflect.Type) {
+		switch typ {
+		case conditionType, iteratorType:
+			addIdentifiers(value)
+			return
+		}
+		for _, match := range expressionPattern.FindAllStringSubmatch(value, -1) {
+			addIdentifiers(match[1])
+		}
+	}, func(req model.Requirements) {
+		for _, name := range req.Vars {
+			used[name] = true
+		}
+	})
+	return used
+}
+
+// walkStrings calls fn for every string reachable from v, and req for
+// every requirements declaration.
+func walkStrings(v reflect.Value, fn func(string, reflect.Type), req func(model.Requirements)) {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			walkStrings(v.Elem(), fn, req)
+		}
+	case reflect.Struct:
+		if v.Type() == requirementsType {
+			req(v.Interface().(model.Requirements))
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				walkStrings(v.Field(i), fn, req)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			walkStrings(v.Index(i), fn, req)
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			walkStrings(iter.Value(), fn, req)
+		}
+	case reflect.String:
+		fn(v.String(), v.Type())
+	}
+}
+
+// unreachableSteps reports jobs and steps with an if: condition that is
+// always false.
+func (l *Linter) unreachableSteps() []LintError {
+	var warnings []LintError
+	for jobName, job := range l.pipeline.GetJobs() {
+		if job == nil {
+			continue
+		}
+		if cond, ok := alwaysFalse(job.If); ok {
+			warnings = append(warnings, LintError{
+				Job:    jobName,
+				Issue:  "unreachable job",
+				Detail: fmt.Sprintf("job '%s' never runs, its condition '%s' is always false", jobName, cond),
+				Hint:   "remove the job, or remove the if: condition",
+			})
+			continue
+		}
+		for idx, step := range job.Children() {
+			if step == nil {
+				continue
+			}
+			if cond, ok := alwaysFalse(step.If); ok {
+				warnings = append(warnings, LintError{
+					Job:    jobName,
+					Issue:  "unreachable step",
+					Detail: fmt.Sprintf("job '%s' %s never runs, its condition '%s' is always false", jobName, stepRequirementLabel(step, idx), cond),
+					Hint:   "remove the step, or remove the if: condition",
+				})
+			}
+		}
+	}
+	return warnings
+}
+
+// alwaysFalse returns the condition that evaluates to false without
+// depending on variables, environment or functions.
+func alwaysFalse(conditions model.Conditionals) (string, bool) {
+	for _, cond := range conditions {
+		text := strings.TrimSpace(string(cond))
+		if strings.Contains(text, "$") {
+			continue
+		}
+		prog, err := compileExpr(text)
+		if err != nil {
+			continue
+		}
+		result, err := runExpr(prog, nil)
+		if err != nil {
+			continue
+		}
+		if value, ok := result.(bool); ok && !value {
+			return text, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,131 @@
+package runner
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/atkins/model"
+)
+
+func lintUnused(t *testing.T, yaml string) []LintError {
+	t.Helper()
+
+	pipelines, err := LoadPipelineFromReader(strings.NewReader(yaml))
+	require.NoError(t, err)
+	return NewLinter(pipelines[0]).Unused()
+}
+
+func issues(warnings []LintError) []string {
+	result := make([]string, 0, len(warnings))
+	for _, w := range warnings {
+		result = append(result, w.Job+": "+w.Issue)
+	}
+	return result
+}
+
+func TestLinter_Unused(t *testing.T) {
+	t.Run("clean pipeline", func(t *testing.T) {
+		warnings := lintUnused(t, `
+vars:
+  name: world
+  items: [a, b]
+  required: yes
+jobs:
+  default:
+    requires: [required]
+    depends_on: build:prepare
+    steps:
+      - run: echo "${{ name }}"
+      - for: item in items
+        run: echo ${{ quote(item) }}
+      - task: build:hidden
+        vars:
+          argument: 1
+  build:prepare:
+    run: echo prepare
+  build:hidden:
+    steps:
+      - echo '${{ argument }}'
+  build:aliased:
+    aliases: [ba]
+    run: echo aliased
+  visible:
+    run: echo visible
+`)
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("unused jobs, vars and unreachable steps", func(t *testing.T) {
+		warnings := lintUnused(t, `
+vars:
+  stale: 1
+  cond: 1
+jobs:
+  default:
+    steps:
+      - run: echo ok
+        if: cond == 1
+      - run: echo never
+        if: "false"
+  build:hidden:
+    vars:
+      unused: 1
+    run: echo hidden
+  off:
+    show: false
+    if: 1 > 2
+    run: echo off
+`)
+		assert.Equal(t, []string{
+			": unused var",
+			"build:hidden: unused job",
+			"build:hidden: unused var",
+			"default: unreachable step",
+			"off: unused job",
+			"off: unreachable job",
+		}, issues(warnings))
+		for _, w := range warnings {
+			assert.NotEmpty(t, w.Hint, w.Detail)
+		}
+	})
+
+	t.Run("unquoted interpolation", func(t *testing.T) {
+		warnings := lintUnused(t, `
+vars:
+  branch: main
+jobs:
+  default:
+    steps:
+      - run: git checkout ${{ branch }}
+      - run: git log "${{ branch }}" -m 'on ${{ branch }}' ${{ quote(branch) }}
+      - run: echo \"${{ branch }}\"
+      - argv: [git, checkout, "${{ branch }}"]
+`)
+		require.Equal(t, []string{"default: unquoted interpolation", "default: unquoted interpolation"}, issues(warnings))
+		assert.Equal(t, "step 0 interpolates ${{ branch }} unquoted", warnings[0].Detail)
+		assert.Equal(t, "step 2 interpolates ${{ branch }} unquoted", warnings[1].Detail)
+	})
+}
+
+func TestLinter_UnusedCrossPipeline(t *testing.T) {
+	mainPipeline := &model.Pipeline{
+		Name: "main",
+		Jobs: map[string]*model.Job{
+			"default": {Name: "default", Steps: []*model.Step{{Task: "go:test:unit"}}},
+		},
+	}
+	goSkill := &model.Pipeline{
+		ID:   "go",
+		Name: "Go Skill",
+		Jobs: map[string]*model.Job{
+			"test:unit": {Name: "test:unit", Steps: []*model.Step{{Run: "go test ./..."}}},
+			"test:race": {Name: "test:race", Steps: []*model.Step{{Run: "go test -race ./..."}}},
+		},
+	}
+
+	warnings := NewLinterWithPipelines(goSkill, []*model.Pipeline{mainPipeline, goSkill}).Unused()
+	assert.Equal(t, []string{"test:race: unused job"}, issues(warnings))
+}
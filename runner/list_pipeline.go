@@ -1,6 +1,7 @@
 package runner
 
 import (
+	"cmp"
 	"fmt"
 	"maps"
 	"slices"
@@ -25,7 +26,7 @@ func ListPipelines(pipelines []*model.Pipeline) string {
 	if s := formatPipelineSection(main); s != "" {
 		sections = append(sections, s)
 	}
-	if s := formatAliasesSection(skills); s != "" {
+	if s := formatAliasesSection(main, skills); s != "" {
 		sections = append(sections, s)
 	}
 	for _, skill := range skills {
@@ -69,17 +70,41 @@ func formatPipelineSection(p *model.Pipeline) string {
 		return ""
 	}
 
-	return fmt.Sprintf("%s\n\n%s", colors.BrightWhite(p.Name), strings.Join(formatJobLines(p.GetJobs(), p.ID), "\n"))
+	header := colors.BrightWhite(p.Name)
+	if p.Decl != nil && p.Env != nil && len(p.Env.Doc) > 0 {
+		header += "\n" + formatEnvDocs(p.Env.Doc, "  ")
+	}
+	return fmt.Sprintf("%s\n\n%s", header, strings.Join(formatJobLines(p, p.ID), "\n"))
+}
+
+// formatEnvDocs formats the documented environment variables, one per line.
+func formatEnvDocs(docs map[string]string, indent string) string {
+	names := slices.Sorted(maps.Keys(docs))
+	maxLen := 0
+	for _, name := range names {
+		maxLen = max(maxLen, len(name))
+	}
+	lines := make([]string, len(names))
+	for i, name := range names {
+		lines[i] = fmt.Sprintf("%s%s%*s%s", indent, colors.BrightCyan("$"+name), maxLen-len(name)+2, "", docs[name])
+	}
+	return strings.Join(lines, "\n")
 }
 
-// formatAliasesSection collects and formats all aliases from skill pipelines.
-func formatAliasesSection(skills []*model.Pipeline) string {
+// formatAliasesSection collects and formats the main pipeline aliases and
+// all aliases from skill pipelines.
+func formatAliasesSection(main *model.Pipeline, skills []*model.Pipeline) string {
 	type aliasEntry struct {
 		alias  string
 		target string
 	}
 
 	var aliases []aliasEntry
+	if main != nil {
+		for alias, target := range main.Aliases {
+			aliases = append(aliases, aliasEntry{alias, target})
+		}
+	}
 	for _, p := range skills {
 		jobs := p.GetJobs()
 		if _, hasDefault := jobs["default"]; hasDefault {
@@ -122,14 +147,9 @@ func formatAliasesSection(skills []*model.Pipeline) string {
 }
 
 // formatJobLines produces a formatted line per job with description, deps, and aliases.
-func formatJobLines(jobs map[string]*model.Job, prefix string) []string {
-	names := treeview.SortJobsByDepth(slices.Collect(maps.Keys(jobs)))
-	for i, name := range names {
-		if name == "default" {
-			names = append([]string{name}, append(names[:i], names[i+1:]...)...)
-			break
-		}
-	}
+func formatJobLines(p *model.Pipeline, prefix string) []string {
+	jobs := p.GetJobs()
+	names := listedJobNames(p)
 
 	isMain := prefix == ""
 	displayNames := make([]string, len(names))
@@ -160,6 +180,9 @@ func formatJobLines(jobs map[string]*model.Job, prefix string) []string {
 		}
 
 		depsStr := formatDependsOn(job)
+		if job.Stage != "" {
+			depsStr += fmt.Sprintf(" (stage: %s)", colors.BrightCyan(job.Stage))
+		}
 		aliasStr := ""
 		if isMain && len(job.Aliases) > 0 {
 			items := make([]string, len(job.Aliases))
@@ -177,10 +200,26 @@ func formatJobLines(jobs map[string]*model.Job, prefix string) []string {
 		default:
 			lines[i] = fmt.Sprintf("* %s", coloredName)
 		}
+		if job.Decl != nil && job.Env != nil && len(job.Env.Doc) > 0 {
+			lines[i] += "\n" + formatEnvDocs(job.Env.Doc, "    ")
+		}
 	}
 	return lines
 }
 
+// listedJobNames returns the job names in listing order: the default job
+// first, followed by the jobs by depth and stage.
+func listedJobNames(p *model.Pipeline) []string {
+	names := sortByStage(p, treeview.SortByDepth(p.JobNames()))
+	for i, name := range names {
+		if name == "default" {
+			names = append([]string{name}, append(names[:i], names[i+1:]...)...)
+			break
+		}
+	}
+	return names
+}
+
 func formatDependsOn(job *model.Job) string {
 	deps := GetDependencies(job.DependsOn)
 	if len(deps) == 0 {
@@ -192,3 +231,22 @@ func formatDependsOn(job *model.Job) string {
 	}
 	return fmt.Sprintf(" (depends_on: %s)", strings.Join(items, ", "))
 }
+
+// sortByStage groups the jobs of the same depth by stage in stage order,
+// keeping the order of jobs within a stage. Jobs without a stage are
+// listed first.
+func sortByStage(p *model.Pipeline, names []string) []string {
+	stages := p.StageNames()
+	if len(stages) == 0 {
+		return names
+	}
+	jobs := p.GetJobs()
+	result := slices.Clone(names)
+	slices.SortStableFunc(result, func(a, b string) int {
+		return cmp.Or(
+			cmp.Compare(strings.Count(a, ":"), strings.Count(b, ":")),
+			cmp.Compare(slices.Index(stages, jobs[a].Stage), slices.Index(stages, jobs[b].Stage)),
+		)
+	})
+	return result
+}
@@ -6,6 +6,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 
 	yaml "gopkg.in/yaml.v3"
@@ -13,6 +14,11 @@ import (
 	"github.com/titpetric/atkins/model"
 )
 
+// AtkinsVersion is the version of the running binary, checked against
+// the `requires: {atkins: ...}` of loaded pipelines. Development builds
+// aren't a release version, and aren't checked.
+var AtkinsVersion = "dev"
+
 // LoadPipeline loads and parses a pipeline from a yaml file.
 // Returns the number of documents loaded, the parsed pipeline, and any error.
 func LoadPipeline(filePath string) ([]*model.Pipeline, error) {
@@ -27,6 +33,8 @@ func LoadPipeline(filePath string) ([]*model.Pipeline, error) {
 		return nil, err
 	}
 
+	pipelines[0].Source = filePath
+
 	// Set default name from filename if not specified
 	if pipelines[0].Name == "" {
 		pipelines[0].Name = filepath.Base(filePath)
@@ -43,7 +51,15 @@ func LoadPipelineFromReader(r io.Reader) ([]*model.Pipeline, error) {
 
 	result := []*model.Pipeline{{}}
 
-	err := decoder.Decode(result[0])
+	var doc yaml.Node
+	err := decoder.Decode(&doc)
+	if err == nil {
+		// Merge keys are merged deeply, instead of the shallow YAML merge
+		err = expandMergeKeys(&doc)
+	}
+	if err == nil {
+		err = doc.Decode(result[0])
+	}
 	if errors.Is(err, io.EOF) {
 		err = nil
 	}
@@ -51,11 +67,18 @@ func LoadPipelineFromReader(r io.Reader) ([]*model.Pipeline, error) {
 		return nil, fmt.Errorf("error decoding pipeline: %w", err)
 	}
 
+	if err := CheckAtkinsVersion(result[0], AtkinsVersion); err != nil {
+		return nil, err
+	}
+
 	for jobName, job := range result[0].Jobs {
 		job.Name = jobName
 		if strings.Contains(jobName, ":") {
 			job.Nested = true
 		}
+		if err := checkStepIDs(job); err != nil {
+			return nil, err
+		}
 	}
 
 	for taskName, task := range result[0].Tasks {
@@ -63,7 +86,52 @@ func LoadPipelineFromReader(r io.Reader) ([]*model.Pipeline, error) {
 		if strings.Contains(taskName, ":") {
 			task.Nested = true
 		}
+		if err := checkStepIDs(task); err != nil {
+			return nil, err
+		}
 	}
 
 	return result, nil
 }
+
+// stepIDPattern matches step ids. They can't contain dots, which separate
+// the parts of step IDs, and start with a letter so they don't clash with
+// the indices of steps without an id.
+var stepIDPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// checkStepIDs returns an error for invalid or duplicate step ids of a job.
+func checkStepIDs(job *model.Job) error {
+	if job == nil {
+		return nil
+	}
+	seen := make(map[string]bool)
+	for _, step := range job.Children() {
+		if step == nil || step.ID == "" {
+			continue
+		}
+		if !stepIDPattern.MatchString(step.ID) {
+			return fmt.Errorf("job '%s': invalid step id '%s', expected a letter followed by letters, digits, '_' or '-'", job.Name, step.ID)
+		}
+		if seen[step.ID] {
+			return fmt.Errorf("job '%s' has more than one step with id '%s'", job.Name, step.ID)
+		}
+		seen[step.ID] = true
+	}
+	return nil
+}
+
+// CheckAtkinsVersion returns an error if the pipeline requires another
+// version of atkins than version. Development builds pass.
+func CheckAtkinsVersion(p *model.Pipeline, version string) error {
+	constraint := p.Requires.Atkins
+	if constraint == "" {
+		return nil
+	}
+	if !versionPattern.MatchString(constraint) {
+		return fmt.Errorf("invalid requires.atkins %q, expected a version constraint like >=0.9", constraint)
+	}
+	if !versionPattern.MatchString(version) || matchVersion(constraint, version) {
+		return nil
+	}
+	return fmt.Errorf("pipeline requires atkins %s, this is %s: upgrade with atkins self-update", constraint, version)
+}
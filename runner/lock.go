@@ -0,0 +1,173 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/titpetric/atkins/model"
+	"github.com/titpetric/atkins/treeview"
+)
+
+// LocksDir is the folder holding the lock files of `lock:`.
+var LocksDir = filepath.Join(".atkins", "locks")
+
+// lockPollInterval is how often a held lock is tried again.
+const lockPollInterval = 100 * time.Millisecond
+
+// lockHolder describes the process holding a lock, written to the lock
+// file so waiting pipelines can tell what they're waiting for.
+type lockHolder struct {
+	PID   int       `json:"pid"`
+	Job   string    `json:"job,omitempty"`
+	Step  string    `json:"step,omitempty"`
+	Since time.Time `json:"since"`
+}
+
+// String describes the holder, e.g. pid 42 (job deploy, step "docker push") for 1m30s.
+func (h *lockHolder) String() string {
+	if h == nil {
+		return "an unknown holder"
+	}
+	var parts []string
+	if h.Job != "" {
+		parts = append(parts, "job "+h.Job)
+	}
+	if h.Step != "" {
+		parts = append(parts, fmt.Sprintf("step %q", h.Step))
+	}
+	result := fmt.Sprintf("pid %d", h.PID)
+	if len(parts) > 0 {
+		result += " (" + strings.Join(parts, ", ") + ")"
+	}
+	return result + " for " + time.Since(h.Since).Round(time.Second).String()
+}
+
+// validateLock checks the lock name and timeout.
+func validateLock(lock *model.Lock) error {
+	if lock.Name == "" || strings.ContainsAny(lock.Name, `/\`) || !filepath.IsLocal(lock.Name) {
+		return fmt.Errorf("invalid lock name %q, expected a name without slashes", lock.Name)
+	}
+	if lock.Timeout != "" {
+		if _, err := time.ParseDuration(lock.Timeout); err != nil {
+			return fmt.Errorf("lock %q: invalid timeout %q", lock.Name, lock.Timeout)
+		}
+	}
+	return nil
+}
+
+// acquireLock interpolates the lock name and waits for the lock, showing
+// the holder under node while waiting. It returns the release function.
+// The lock of a job is recorded in execCtx, as a step taking it again
+// would wait for its own job until the lock times out.
+func acquireLock(ctx context.Context, execCtx *ExecutionContext, lock *model.Lock, holder lockHolder, node *treeview.Node) (func(), error) {
+	name, err := InterpolateString(lock.Name, execCtx)
+	if err != nil {
+		return nil, fmt.Errorf("lock %q: %w", lock.Name, err)
+	}
+	resolved := &model.Lock{Name: name, Timeout: lock.Timeout}
+	if err := validateLock(resolved); err != nil {
+		return nil, err
+	}
+	if name == execCtx.jobLock {
+		return nil, fmt.Errorf("lock %q is held by job %q already", name, holder.Job)
+	}
+
+	waiting := func(current *lockHolder) {
+		if node == nil {
+			return
+		}
+		node.SetOutput([]string{fmt.Sprintf("waiting for lock %q held by %s", name, current)})
+		execCtx.Render()
+	}
+	release, err := lockFile(ctx, resolved, holder, waiting)
+	if node != nil {
+		node.SetOutput(nil)
+	}
+	if err == nil && holder.Step == "" {
+		execCtx.jobLock = name
+	}
+	return release, err
+}
+
+// lockFile takes an exclusive flock on the lock file, trying again until
+// the lock timeout or the context ends. The lock is released by the
+// kernel when the process exits, so a crashed holder doesn't keep it.
+func lockFile(ctx context.Context, lock *model.Lock, holder lockHolder, waiting func(*lockHolder)) (func(), error) {
+	if err := os.MkdirAll(LocksDir, 0o755); err != nil {
+		return nil, err
+	}
+	path := filepath.Join(LocksDir, lock.Name+".lock")
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := parseTimeout(lock.Timeout, 0)
+	var deadline <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	ticker := time.NewTicker(lockPollInterval)
+	defer ticker.Stop()
+	for notified := false; ; {
+		err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, syscall.EWOULDBLOCK) {
+			f.Close()
+			return nil, fmt.Errorf("lock %q: %w", lock.Name, err)
+		}
+
+		current := readLockHolder(path)
+		if !notified && waiting != nil {
+			waiting(current)
+			notified = true
+		}
+		select {
+		case <-ticker.C:
+		case <-deadline:
+			f.Close()
+			return nil, fmt.Errorf("lock %q: timed out after %s, held by %s", lock.Name, timeout, current)
+		case <-ctx.Done():
+			f.Close()
+			return nil, fmt.Errorf("lock %q: %w while held by %s", lock.Name, ctx.Err(), current)
+		}
+	}
+
+	holder.PID = os.Getpid()
+	holder.Since = time.Now()
+	if data, err := json.Marshal(holder); err == nil {
+		_ = f.Truncate(0)
+		_, _ = f.WriteAt(data, 0)
+	}
+
+	return func() {
+		_ = f.Truncate(0)
+		_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, nil
+}
+
+// readLockHolder returns the holder written to the lock file, or nil.
+func readLockHolder(path string) *lockHolder {
+	data, err := os.ReadFile(path)
+	if err != nil || len(data) == 0 {
+		return nil
+	}
+	holder := &lockHolder{}
+	if err := json.Unmarshal(data, holder); err != nil {
+		return nil
+	}
+	return holder
+}
@@ -0,0 +1,165 @@
+package runner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/titpetric/atkins/model"
+)
+
+func TestLock_UnmarshalYAML(t *testing.T) {
+	var step model.Step
+	require.NoError(t, yaml.Unmarshal([]byte("run: make db\nlock: postgres\n"), &step))
+	assert.Equal(t, &model.Lock{Name: "postgres"}, step.Lock)
+
+	var job model.Job
+	require.NoError(t, yaml.Unmarshal([]byte("run: make push\nlock:\n  name: registry\n  timeout: 5m\n"), &job))
+	assert.Equal(t, &model.Lock{Name: "registry", Timeout: "5m"}, job.Lock)
+}
+
+func TestLockFile(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	release, err := lockFile(t.Context(), &model.Lock{Name: "registry"}, lockHolder{Job: "push", Step: "docker push"}, nil)
+	require.NoError(t, err)
+
+	holder := readLockHolder(filepath.Join(LocksDir, "registry.lock"))
+	require.NotNil(t, holder)
+	assert.Equal(t, os.Getpid(), holder.PID)
+	assert.Equal(t, "push", holder.Job)
+	assert.Contains(t, holder.String(), `(job push, step "docker push")`)
+
+	// Another lock of the same name waits for the holder
+	var waitedFor *lockHolder
+	_, err = lockFile(t.Context(), &model.Lock{Name: "registry", Timeout: "200ms"}, lockHolder{Job: "deploy"}, func(h *lockHolder) {
+		waitedFor = h
+	})
+	assert.ErrorContains(t, err, `lock "registry": timed out after 200ms, held by pid`)
+	require.NotNil(t, waitedFor)
+	assert.Equal(t, "push", waitedFor.Job)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 100*time.Millisecond)
+	defer cancel()
+	_, err = lockFile(ctx, &model.Lock{Name: "registry"}, lockHolder{}, nil)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// Other names don't wait
+	other, err := lockFile(t.Context(), &model.Lock{Name: "database"}, lockHolder{}, nil)
+	require.NoError(t, err)
+	other()
+
+	go func() {
+		time.Sleep(150 * time.Millisecond)
+		release()
+	}()
+	next, err := lockFile(t.Context(), &model.Lock{Name: "registry", Timeout: "5s"}, lockHolder{Job: "deploy"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "deploy", readLockHolder(filepath.Join(LocksDir, "registry.lock")).Job)
+	next()
+	assert.Nil(t, readLockHolder(filepath.Join(LocksDir, "registry.lock")))
+}
+
+func TestRunPipeline_Lock(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	// Both jobs run in parallel, the lock keeps them from overlapping
+	pipelines, err := LoadPipelineFromReader(strings.NewReader(`
+vars:
+  resource: db
+jobs:
+  default:
+    depends_on: [a, b]
+  a:
+    detach: true
+    lock: ${{ resource }}
+    run: test ! -f busy; touch busy; sleep 0.3; rm busy
+  b:
+    detach: true
+    steps:
+      - lock: ${{ resource }}
+        run: test ! -f busy; touch busy; sleep 0.3; rm busy
+`))
+	require.NoError(t, err)
+
+	err = RunPipeline(t.Context(), pipelines[0], PipelineOptions{
+		Jobs:   []string{"default"},
+		Silent: true,
+	})
+	require.NoError(t, err)
+	assert.FileExists(t, filepath.Join(LocksDir, "db.lock"))
+}
+
+func TestRunPipeline_LockJobTimeout(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	release, err := lockFile(t.Context(), &model.Lock{Name: "db"}, lockHolder{Job: "other"}, nil)
+	require.NoError(t, err)
+	defer release()
+
+	// Waiting for the lock counts against the job timeout
+	pipelines, err := LoadPipelineFromReader(strings.NewReader(`
+jobs:
+  default:
+    timeout: 200ms
+    lock: db
+    run: "true"
+`))
+	require.NoError(t, err)
+
+	start := time.Now()
+	err = RunPipeline(t.Context(), pipelines[0], PipelineOptions{
+		Jobs:   []string{"default"},
+		Silent: true,
+	})
+	assert.ErrorContains(t, err, `lock "db": context deadline exceeded`)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestRunPipeline_LockHeldByJob(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	pipelines, err := LoadPipelineFromReader(strings.NewReader(`
+vars:
+  resource: db
+jobs:
+  default:
+    lock: db
+    steps:
+      - lock: ${{ resource }}
+        run: "true"
+`))
+	require.NoError(t, err)
+
+	err = RunPipeline(t.Context(), pipelines[0], PipelineOptions{
+		Jobs:   []string{"default"},
+		Silent: true,
+	})
+	assert.ErrorContains(t, err, `lock "db" is held by job "default" already`)
+}
+
+func TestLinter_Locks(t *testing.T) {
+	pipeline := &model.Pipeline{
+		Name: "test-pipeline",
+		Jobs: map[string]*model.Job{
+			"push":    {Name: "push", Lock: &model.Lock{Name: "../registry"}},
+			"migrate": {Name: "migrate", Steps: []*model.Step{{Run: "make migrate", Lock: &model.Lock{Name: "db", Timeout: "soon"}}}},
+			"deploy":  {Name: "deploy", Lock: &model.Lock{Name: "env-${{ env }}"}},
+			"seed":    {Name: "seed", Lock: &model.Lock{Name: "db"}, Steps: []*model.Step{{Run: "make seed", Lock: &model.Lock{Name: "db"}}}},
+		},
+	}
+
+	errors := NewLinter(pipeline).Lint()
+	require.Len(t, errors, 3)
+	details := []string{errors[0].Detail, errors[1].Detail, errors[2].Detail}
+	assert.Contains(t, strings.Join(details, "\n"), `invalid lock name "../registry"`)
+	assert.Contains(t, strings.Join(details, "\n"), `lock "db": invalid timeout "soon"`)
+	assert.Contains(t, strings.Join(details, "\n"), `job 'seed': step 'run: make seed' takes lock "db", which the job holds already`)
+}
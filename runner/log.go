@@ -1,6 +1,10 @@
 package runner
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/titpetric/atkins/model"
+)
 
 // generateStepID creates a step ID from job name and sequential step index
 // Format follows GitHub Actions: jobs.<jobName>.steps.<sequentialIndex>
@@ -11,3 +15,23 @@ func generateStepID(jobName string, stepIndex int) string {
 	// Format: jobs.<jobName>.steps.<sequentialIndex>
 	return "jobs." + jobName + ".steps." + fmt.Sprintf("%d", stepIndex)
 }
+
+// resolveStepID creates a step ID, preferring the explicit step `id:` over the
+// sequential index so reordering steps keeps IDs stable across runs.
+// Format: jobs.<jobName>.steps.<id>
+func resolveStepID(jobName string, step *model.Step, stepIndex int) string {
+	if jobName == "" || step == nil || step.ID == "" {
+		return generateStepID(jobName, stepIndex)
+	}
+	return "jobs." + jobName + ".steps." + step.ID
+}
+
+// resolveIterationID creates a step ID for a for-loop iteration.
+// Steps with an explicit id get the iteration index appended (jobs.<jobName>.steps.<id>.<idx>),
+// other steps keep using the sequential index offset by the iteration.
+func resolveIterationID(jobName string, step *model.Step, stepIndex, idx int) string {
+	if jobName == "" || step == nil || step.ID == "" {
+		return fmt.Sprintf("jobs.%s.steps.%d", jobName, stepIndex+idx)
+	}
+	return fmt.Sprintf("jobs.%s.steps.%s.%d", jobName, step.ID, idx)
+}
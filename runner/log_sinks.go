@@ -0,0 +1,372 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/titpetric/atkins/eventlog"
+	"github.com/titpetric/atkins/model"
+	"github.com/titpetric/atkins/treeview"
+)
+
+// Log sink types.
+const (
+	LogSinkSyslog = "syslog"
+	LogSinkLoki   = "loki"
+	LogSinkFile   = "file"
+)
+
+// LogRecord is a step or job result sent to the log sinks.
+type LogRecord struct {
+	Time     time.Time       `json:"time"`
+	Pipeline string          `json:"pipeline,omitempty"`
+	Job      string          `json:"job"`
+	Step     string          `json:"step,omitempty"` // Step ID, empty for job records
+	Command  string          `json:"command,omitempty"`
+	Result   eventlog.Result `json:"result"`
+	ExitCode int             `json:"exit_code,omitempty"`
+	Duration float64         `json:"duration"` // Seconds
+	Output   string          `json:"output,omitempty"`
+	Error    string          `json:"error,omitempty"`
+}
+
+// logSinkWriter delivers records to a log sink.
+type logSinkWriter interface {
+	write(LogRecord) error
+	Close() error
+}
+
+// logSink is a configured sink with its filter. A sink that fails is
+// reported once and then disabled, so a down log stack doesn't fail runs.
+type logSink struct {
+	config *model.LogSink
+	writer logSinkWriter
+	failed bool
+}
+
+func (s *logSink) accepts(rec LogRecord) bool {
+	if s.config.Only == "failures" && rec.Result != eventlog.ResultFail {
+		return false
+	}
+	return len(s.config.Jobs) == 0 || slices.Contains(s.config.Jobs, rec.Job)
+}
+
+// logSinks fans records out to the sinks of the pipeline, shared across
+// execution context copies.
+type logSinks struct {
+	mu       sync.Mutex
+	pipeline string
+	sinks    []*logSink
+}
+
+// newLogSinks opens the configured sinks. It returns nil without sinks.
+func newLogSinks(pipeline string, configs []*model.LogSink) (*logSinks, error) {
+	if len(configs) == 0 {
+		return nil, nil
+	}
+
+	s := &logSinks{pipeline: pipeline}
+	for i, config := range configs {
+		if config.Only != "" && config.Only != "failures" {
+			s.Close()
+			return nil, fmt.Errorf("log_sinks[%d]: unknown only %q, expected %q", i, config.Only, "failures")
+		}
+		writer, err := newLogSinkWriter(config)
+		if err != nil {
+			s.Close()
+			return nil, fmt.Errorf("log_sinks[%d]: %w", i, err)
+		}
+		s.sinks = append(s.sinks, &logSink{config: config, writer: writer})
+	}
+	return s, nil
+}
+
+func newLogSinkWriter(config *model.LogSink) (logSinkWriter, error) {
+	switch config.Type {
+	case LogSinkSyslog:
+		return newSyslogSink(config.Address)
+	case LogSinkLoki:
+		if config.Address == "" {
+			return nil, fmt.Errorf("loki sink requires an address")
+		}
+		return &lokiSink{url: config.Address, labels: config.Labels, client: &http.Client{Timeout: 10 * time.Second}}, nil
+	case LogSinkFile:
+		if config.Path == "" {
+			return nil, fmt.Errorf("file sink requires a path")
+		}
+		return newFileSink(config.Path, config.MaxSize, config.MaxFiles)
+	}
+	return nil, fmt.Errorf("unknown log sink type %q, expected %s, %s or %s", config.Type, LogSinkSyslog, LogSinkLoki, LogSinkFile)
+}
+
+// send delivers a record to the sinks accepting it. Nil-safe.
+func (s *logSinks) send(rec LogRecord) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if rec.Time.IsZero() {
+		rec.Time = time.Now()
+	}
+	rec.Pipeline = s.pipeline
+	for _, sink := range s.sinks {
+		if sink.failed || !sink.accepts(rec) {
+			continue
+		}
+		if err := sink.writer.write(rec); err != nil {
+			sink.failed = true
+			fmt.Fprintf(os.Stderr, "%s %s log sink disabled: %v\n", treeview.WarningHeader(), sink.config.Type, err)
+		}
+	}
+}
+
+// job sends the result of a finished job.
+func (s *logSinks) job(ev JobProgressEvent) {
+	var result eventlog.Result
+	switch ev.Status {
+	case JobProgressPassed:
+		result = eventlog.ResultPass
+	case JobProgressFailed:
+		result = eventlog.ResultFail
+	case JobProgressSkipped:
+		result = eventlog.ResultSkipped
+	default:
+		return
+	}
+	rec := LogRecord{Job: ev.JobName, Result: result, Duration: ev.Duration.Seconds()}
+	if ev.Err != nil {
+		rec.Error = ev.Err.Error()
+	}
+	s.send(rec)
+}
+
+// Close flushes and closes the sinks. Nil-safe.
+func (s *logSinks) Close() error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var firstErr error
+	for _, sink := range s.sinks {
+		if err := sink.writer.Close(); err != nil && firstErr == nil && !sink.failed {
+			firstErr = fmt.Errorf("%s log sink: %w", sink.config.Type, err)
+		}
+	}
+	return firstErr
+}
+
+// syslogSink writes RFC 5424 messages to a syslog daemon.
+type syslogSink struct {
+	conn     net.Conn
+	stream   bool // tcp needs newline framing
+	hostname string
+}
+
+func newSyslogSink(address string) (*syslogSink, error) {
+	network, addr := "unixgram", "/dev/log"
+	if address != "" {
+		u, err := url.Parse(address)
+		if err != nil {
+			return nil, fmt.Errorf("invalid syslog address %q: %w", address, err)
+		}
+		switch u.Scheme {
+		case "udp", "tcp":
+			network, addr = u.Scheme, u.Host
+		case "unix":
+			network, addr = "unixgram", u.Path
+		default:
+			return nil, fmt.Errorf("invalid syslog address %q, expected udp://, tcp:// or unix://", address)
+		}
+	}
+
+	conn, err := net.DialTimeout(network, addr, 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	hostname, _ := os.Hostname()
+	if hostname == "" {
+		hostname = "-"
+	}
+	return &syslogSink{conn: conn, stream: network == "tcp", hostname: hostname}, nil
+}
+
+func (s *syslogSink) write(rec LogRecord) error {
+	// Facility user (1), severity error (3) for failures, info (6) otherwise
+	priority := 8 + 6
+	if rec.Result == eventlog.ResultFail {
+		priority = 8 + 3
+	}
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	msg := fmt.Sprintf("<%d>1 %s %s atkins %d - - %s", priority, rec.Time.UTC().Format(time.RFC3339Nano), s.hostname, os.Getpid(), data)
+	if s.stream {
+		msg += "\n"
+	}
+	_, err = io.WriteString(s.conn, msg)
+	return err
+}
+
+func (s *syslogSink) Close() error {
+	return s.conn.Close()
+}
+
+// lokiBatchSize is the number of records sent in a single Loki push.
+const lokiBatchSize = 100
+
+// lokiSink pushes records to the Grafana Loki push API in batches,
+// with a stream per job.
+type lokiSink struct {
+	url     string
+	labels  map[string]string
+	client  *http.Client
+	pending []LogRecord
+}
+
+func (s *lokiSink) write(rec LogRecord) error {
+	s.pending = append(s.pending, rec)
+	if len(s.pending) < lokiBatchSize {
+		return nil
+	}
+	return s.flush()
+}
+
+func (s *lokiSink) flush() error {
+	if len(s.pending) == 0 {
+		return nil
+	}
+	type stream struct {
+		Stream map[string]string `json:"stream"`
+		Values [][2]string       `json:"values"`
+	}
+
+	var streams []*stream
+	byJob := map[string]*stream{}
+	for _, rec := range s.pending {
+		st, ok := byJob[rec.Job]
+		if !ok {
+			labels := map[string]string{"app": "atkins", "job": rec.Job}
+			for k, v := range s.labels {
+				labels[k] = v
+			}
+			st = &stream{Stream: labels}
+			byJob[rec.Job] = st
+			streams = append(streams, st)
+		}
+		line, err := json.Marshal(rec)
+		if err != nil {
+			return err
+		}
+		st.Values = append(st.Values, [2]string{strconv.FormatInt(rec.Time.UnixNano(), 10), string(line)})
+	}
+	s.pending = nil
+
+	body, err := json.Marshal(map[string]any{"streams": streams})
+	if err != nil {
+		return err
+	}
+	resp, err := s.client.Post(s.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("loki push: %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+func (s *lokiSink) Close() error {
+	return s.flush()
+}
+
+// fileSink writes records as JSON lines, rotating the file by size.
+type fileSink struct {
+	path     string
+	maxSize  int64
+	maxFiles int
+	file     *os.File
+	size     int64
+}
+
+func newFileSink(path string, maxSizeMB, maxFiles int) (*fileSink, error) {
+	if maxSizeMB <= 0 {
+		maxSizeMB = 10
+	}
+	if maxFiles <= 0 {
+		maxFiles = 5
+	}
+	s := &fileSink{path: path, maxSize: int64(maxSizeMB) << 20, maxFiles: maxFiles}
+	return s, s.open()
+}
+
+func (s *fileSink) open() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	s.file, s.size = f, info.Size()
+	return nil
+}
+
+func (s *fileSink) write(rec LogRecord) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	if s.size > 0 && s.size+int64(len(line)) > s.maxSize {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := s.file.Write(line)
+	s.size += int64(n)
+	return err
+}
+
+// rotate renames path to path.1, path.1 to path.2 and so on, dropping
+// the files beyond maxFiles.
+func (s *fileSink) rotate() error {
+	if err := s.file.Close(); err != nil {
+		return err
+	}
+	_ = os.Remove(s.path + "." + strconv.Itoa(s.maxFiles))
+	for i := s.maxFiles - 1; i >= 1; i-- {
+		_ = os.Rename(s.path+"."+strconv.Itoa(i), s.path+"."+strconv.Itoa(i+1))
+	}
+	if err := os.Rename(s.path, s.path+".1"); err != nil {
+		return err
+	}
+	return s.open()
+}
+
+func (s *fileSink) Close() error {
+	return s.file.Close()
+}
@@ -0,0 +1,151 @@
+package runner
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/atkins/eventlog"
+	"github.com/titpetric/atkins/model"
+)
+
+func readLogRecords(t *testing.T, path string) []LogRecord {
+	t.Helper()
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var records []LogRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var rec LogRecord
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &rec))
+		records = append(records, rec)
+	}
+	return records
+}
+
+func TestLogSinks_File(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	pipelines, err := LoadPipelineFromReader(strings.NewReader(`
+name: sinks
+log_sinks:
+  - type: file
+    path: logs/all.jsonl
+  - type: file
+    path: logs/failures.jsonl
+    only: failures
+  - type: file
+    path: logs/build.jsonl
+    jobs: [build]
+jobs:
+  build:
+    steps:
+      - echo built
+  test:
+    depends_on: build
+    steps:
+      - run: echo failing; exit 3
+`))
+	require.NoError(t, err)
+	err = RunPipeline(t.Context(), pipelines[0], PipelineOptions{Jobs: []string{"test"}, Silent: true, AllPipelines: pipelines})
+	require.Error(t, err)
+
+	all := readLogRecords(t, "logs/all.jsonl")
+	require.Len(t, all, 4, "a step and a job record per job")
+	assert.Equal(t, "sinks", all[0].Pipeline)
+	assert.Equal(t, "build", all[0].Job)
+	assert.Equal(t, "built\n", all[0].Output)
+
+	failures := readLogRecords(t, "logs/failures.jsonl")
+	require.Len(t, failures, 2)
+	assert.Equal(t, "test", failures[0].Job)
+	assert.Equal(t, 3, failures[0].ExitCode)
+	assert.Equal(t, "failing\n", failures[0].Output)
+	assert.Empty(t, failures[1].Step, "job record")
+	assert.Equal(t, eventlog.ResultFail, failures[1].Result)
+
+	for _, rec := range readLogRecords(t, "logs/build.jsonl") {
+		assert.Equal(t, "build", rec.Job)
+	}
+}
+
+func TestLogSinks_FileRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "atkins.jsonl")
+	sink, err := newFileSink(path, 1, 2)
+	require.NoError(t, err)
+	sink.maxSize = 100
+
+	for range 10 {
+		require.NoError(t, sink.write(LogRecord{Job: "build", Result: eventlog.ResultPass}))
+	}
+	require.NoError(t, sink.Close())
+
+	assert.FileExists(t, path+".1")
+	assert.FileExists(t, path+".2")
+	assert.NoFileExists(t, path+".3")
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.LessOrEqual(t, info.Size(), int64(100))
+}
+
+func TestLogSinks_Loki(t *testing.T) {
+	var pushes []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		pushes = append(pushes, body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	sinks, err := newLogSinks("app", []*model.LogSink{{Type: LogSinkLoki, Address: server.URL, Labels: map[string]string{"env": "ci"}}})
+	require.NoError(t, err)
+	sinks.send(LogRecord{Job: "build", Result: eventlog.ResultPass})
+	sinks.send(LogRecord{Job: "test", Result: eventlog.ResultFail})
+	assert.Empty(t, pushes, "records are batched")
+	require.NoError(t, sinks.Close())
+
+	require.Len(t, pushes, 1)
+	streams := pushes[0]["streams"].([]any)
+	require.Len(t, streams, 2)
+	assert.Equal(t, map[string]any{"app": "atkins", "job": "build", "env": "ci"}, streams[0].(map[string]any)["stream"])
+}
+
+func TestLogSinks_Syslog(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	sinks, err := newLogSinks("app", []*model.LogSink{{Type: LogSinkSyslog, Address: "udp://" + conn.LocalAddr().String()}})
+	require.NoError(t, err)
+	sinks.send(LogRecord{Job: "test", Result: eventlog.ResultFail})
+	require.NoError(t, sinks.Close())
+
+	buf := make([]byte, 4096)
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+	msg := string(buf[:n])
+	assert.True(t, strings.HasPrefix(msg, "<11>1 "), msg)
+	assert.Contains(t, msg, ` atkins `)
+	assert.Contains(t, msg, `"job":"test"`)
+}
+
+func TestLogSinks_Invalid(t *testing.T) {
+	_, err := newLogSinks("", []*model.LogSink{{Type: "kafka"}})
+	require.Error(t, err)
+	_, err = newLogSinks("", []*model.LogSink{{Type: LogSinkFile, Path: filepath.Join(t.TempDir(), "x"), Only: "errors"}})
+	require.Error(t, err)
+	_, err = newLogSinks("", []*model.LogSink{{Type: LogSinkLoki}})
+	require.Error(t, err)
+}
@@ -4,6 +4,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/titpetric/atkins/model"
 )
 
 func TestGenerateStepID(t *testing.T) {
@@ -40,3 +42,51 @@ func TestGenerateStepID(t *testing.T) {
 		})
 	}
 }
+
+func TestResolveStepID(t *testing.T) {
+	tests := []struct {
+		name      string
+		jobName   string
+		step      *model.Step
+		stepIndex int
+		expected  string
+	}{
+		{
+			name:      "step without id uses index",
+			jobName:   "build",
+			step:      &model.Step{Run: "go build"},
+			stepIndex: 3,
+			expected:  "jobs.build.steps.3",
+		},
+		{
+			name:      "step with explicit id",
+			jobName:   "build",
+			step:      &model.Step{ID: "compile", Run: "go build"},
+			stepIndex: 3,
+			expected:  "jobs.build.steps.compile",
+		},
+		{
+			name:      "nil step uses index",
+			jobName:   "build",
+			stepIndex: 1,
+			expected:  "jobs.build.steps.1",
+		},
+		{
+			name:      "empty job name",
+			step:      &model.Step{ID: "compile"},
+			stepIndex: 0,
+			expected:  "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, resolveStepID(tt.jobName, tt.step, tt.stepIndex))
+		})
+	}
+}
+
+func TestResolveIterationID(t *testing.T) {
+	assert.Equal(t, "jobs.test.steps.5", resolveIterationID("test", &model.Step{}, 3, 2))
+	assert.Equal(t, "jobs.test.steps.fmt.2", resolveIterationID("test", &model.Step{ID: "fmt"}, 3, 2))
+}
@@ -0,0 +1,182 @@
+package runner
+
+import (
+	"fmt"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Tags of lists combined with the inherited list of a `<<:` merge key,
+// by default a list replaces the inherited one.
+const (
+	TagAppend  = "!append"
+	TagPrepend = "!prepend"
+)
+
+// expandMergeKeys replaces the `<<:` merge keys of the document with the
+// keys of the merged mappings. Unlike the YAML merge, mappings are merged
+// deeply, so a job can extend the `env:` or `vars:` of a template:
+//
+//   - keys of the mapping override merged keys, nested mappings are merged,
+//   - with a list of bases, `<<: [*a, *b]`, earlier bases override later ones,
+//   - lists replace merged lists, tag them with `!append` or `!prepend`
+//     to add to the merged list instead.
+func expandMergeKeys(node *yaml.Node) error {
+	if err := newMergeExpander().expand(node); err != nil {
+		return err
+	}
+	stripListTags(node, make(map[*yaml.Node]bool))
+	return nil
+}
+
+// mergeExpander expands merge keys, expanding each anchored node once.
+type mergeExpander struct {
+	expanded map[*yaml.Node]bool
+}
+
+func newMergeExpander() *mergeExpander {
+	return &mergeExpander{expanded: make(map[*yaml.Node]bool)}
+}
+
+// expand expands the merge keys of node and its children.
+func (m *mergeExpander) expand(node *yaml.Node) error {
+	if node == nil || m.expanded[node] {
+		return nil
+	}
+	m.expanded[node] = true
+
+	switch node.Kind {
+	case yaml.AliasNode:
+		return m.expand(node.Alias)
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, child := range node.Content {
+			if err := m.expand(child); err != nil {
+				return err
+			}
+		}
+		return nil
+	case yaml.MappingNode:
+	default:
+		return nil
+	}
+
+	var bases []*yaml.Node
+	content := make([]*yaml.Node, 0, len(node.Content))
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if err := m.expand(value); err != nil {
+			return err
+		}
+		if !isMergeKey(key) {
+			content = append(content, key, value)
+			continue
+		}
+		merged, err := mergeBases(value)
+		if err != nil {
+			return err
+		}
+		bases = append(bases, merged...)
+	}
+	if len(bases) == 0 {
+		return nil
+	}
+
+	// Earlier bases override later ones, the mapping overrides all of them
+	result := &yaml.Node{Kind: yaml.MappingNode}
+	for i := len(bases) - 1; i >= 0; i-- {
+		result = mergeNodes(result, bases[i])
+	}
+	result = mergeNodes(result, &yaml.Node{Kind: yaml.MappingNode, Content: content})
+	node.Content = result.Content
+	return nil
+}
+
+// mergeBases returns the mappings of a merge key value, a mapping or a
+// list of mappings, usually aliases.
+func mergeBases(value *yaml.Node) ([]*yaml.Node, error) {
+	values := []*yaml.Node{value}
+	if value.Kind == yaml.SequenceNode {
+		values = value.Content
+	}
+	bases := make([]*yaml.Node, 0, len(values))
+	for _, base := range values {
+		base = resolveAlias(base)
+		if base.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("line %d: merge key needs a mapping or a list of mappings", base.Line)
+		}
+		bases = append(bases, base)
+	}
+	return bases, nil
+}
+
+// mergeNodes returns base with the keys of override. Nested mappings are
+// merged, lists tagged with !append or !prepend are added to the list of
+// base, other values of override replace the values of base. The nodes
+// aren't modified, anchored nodes may be merged again.
+func mergeNodes(base, override *yaml.Node) *yaml.Node {
+	base, override = resolveAlias(base), resolveAlias(override)
+
+	switch {
+	case base.Kind == yaml.MappingNode && override.Kind == yaml.MappingNode:
+		result := *base
+		result.Content = append([]*yaml.Node(nil), base.Content...)
+		for i := 0; i+1 < len(override.Content); i += 2 {
+			key, value := override.Content[i], override.Content[i+1]
+			if j := mappingIndex(&result, key.Value); j >= 0 {
+				result.Content[j+1] = mergeNodes(result.Content[j+1], value)
+				continue
+			}
+			result.Content = append(result.Content, key, value)
+		}
+		return &result
+	case base.Kind == yaml.SequenceNode && override.Kind == yaml.SequenceNode:
+		result := *override
+		switch override.Tag {
+		case TagAppend:
+			result.Content = append(append([]*yaml.Node(nil), base.Content...), override.Content...)
+		case TagPrepend:
+			result.Content = append(append([]*yaml.Node(nil), override.Content...), base.Content...)
+		}
+		return &result
+	}
+	return override
+}
+
+// mappingIndex returns the index of the key in a mapping, or -1.
+func mappingIndex(node *yaml.Node, key string) int {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// resolveAlias returns the node an alias refers to.
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	return node
+}
+
+// isMergeKey returns true for an unquoted `<<` key.
+func isMergeKey(key *yaml.Node) bool {
+	return key.Kind == yaml.ScalarNode && key.Value == "<<" && key.Tag == "!!merge"
+}
+
+// stripListTags removes the !append and !prepend tags, which only apply
+// to merges, so the lists decode as plain lists.
+func stripListTags(node *yaml.Node, seen map[*yaml.Node]bool) {
+	if node == nil || seen[node] {
+		return
+	}
+	seen[node] = true
+	if node.Tag == TagAppend || node.Tag == TagPrepend {
+		node.Tag = "!!seq"
+	}
+	stripListTags(node.Alias, seen)
+	for _, child := range node.Content {
+		stripListTags(child, seen)
+	}
+}
@@ -0,0 +1,110 @@
+package runner
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadPipeline_MergeKeys(t *testing.T) {
+	pipelines, err := LoadPipelineFromReader(strings.NewReader(`
+x-go: &go
+  dir: src
+  env:
+    vars:
+      GOFLAGS: -count=1
+      CGO_ENABLED: "0"
+  vars:
+    tags: [unit]
+x-linux: &linux
+  env:
+    vars:
+      GOOS: linux
+      CGO_ENABLED: "1"
+jobs:
+  test:
+    <<: [*go, *linux]
+    env:
+      vars:
+        GOFLAGS: -race
+    vars:
+      tags: !append [integration]
+    steps:
+      - run: go test ./...
+  build:
+    <<: *go
+    vars:
+      tags: !prepend [release]
+    steps:
+      - run: go build ./...
+  lint:
+    <<: *go
+    vars:
+      tags: [lint]
+    steps:
+      - run: golangci-lint run
+`))
+	require.NoError(t, err)
+	jobs := pipelines[0].Jobs
+
+	// Nested maps are merged, earlier bases override later ones
+	test := jobs["test"]
+	assert.Equal(t, "src", test.Dir)
+	assert.Equal(t, map[string]any{
+		"GOFLAGS":     "-race",
+		"CGO_ENABLED": "0",
+		"GOOS":        "linux",
+	}, test.Env.Vars)
+	assert.Equal(t, []any{"unit", "integration"}, test.Vars["tags"])
+	require.Len(t, test.Steps, 1)
+
+	// The anchored mapping isn't modified by the merges
+	build := jobs["build"]
+	assert.Equal(t, map[string]any{
+		"GOFLAGS":     "-count=1",
+		"CGO_ENABLED": "0",
+	}, build.Env.Vars)
+	assert.Equal(t, []any{"release", "unit"}, build.Vars["tags"])
+
+	// Lists replace merged lists by default
+	assert.Equal(t, []any{"lint"}, jobs["lint"].Vars["tags"])
+}
+
+func TestLoadPipeline_MergeKeysStepWith(t *testing.T) {
+	pipelines, err := LoadPipelineFromReader(strings.NewReader(`
+jobs:
+  deploy:
+    steps:
+      - &deploy
+        task: release
+        with:
+          region: eu-west-1
+          flags: [--wait]
+      - <<: *deploy
+        with:
+          flags: !append [--dry-run]
+      - <<: *deploy
+        with:
+          region: us-east-1
+`))
+	require.NoError(t, err)
+	steps := pipelines[0].Jobs["deploy"].Steps
+	require.Len(t, steps, 3)
+
+	assert.Equal(t, "release", steps[1].Task)
+	assert.Equal(t, map[string]any{"region": "eu-west-1", "flags": []any{"--wait", "--dry-run"}}, map[string]any(steps[1].With))
+	assert.Equal(t, map[string]any{"region": "us-east-1", "flags": []any{"--wait"}}, map[string]any(steps[2].With))
+	assert.Equal(t, map[string]any{"region": "eu-west-1", "flags": []any{"--wait"}}, map[string]any(steps[0].With))
+}
+
+func TestLoadPipeline_MergeKeysInvalid(t *testing.T) {
+	_, err := LoadPipelineFromReader(strings.NewReader(`
+x-name: &name build
+jobs:
+  build:
+    <<: *name
+`))
+	assert.ErrorContains(t, err, "merge key needs a mapping or a list of mappings")
+}
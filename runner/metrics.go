@@ -0,0 +1,94 @@
+package runner
+
+import (
+	"strings"
+	"time"
+
+	"github.com/titpetric/atkins/eventlog"
+	"github.com/titpetric/atkins/metrics"
+	"github.com/titpetric/atkins/treeview"
+)
+
+// Metric names written with --metrics-textfile.
+const (
+	metricRunsTotal        = "atkins_runs_total"
+	metricStepsFailedTotal = "atkins_steps_failed_total"
+	metricStepDuration     = "atkins_step_duration_seconds"
+	metricLastRun          = "atkins_last_run_timestamp_seconds"
+)
+
+// newMetricsRegistry creates a registry with the run metric families declared.
+func newMetricsRegistry() *metrics.Registry {
+	reg := metrics.NewRegistry()
+	reg.Register(metricRunsTotal, "Total number of pipeline runs by result.", metrics.Counter)
+	reg.Register(metricStepsFailedTotal, "Total number of failed steps.", metrics.Counter)
+	reg.Register(metricStepDuration, "Step duration in seconds.", metrics.Histogram)
+	reg.Register(metricLastRun, "Unix timestamp of the last pipeline run.", metrics.Gauge)
+	return reg
+}
+
+// recordRunMetrics adds the results of a run, taken from the final tree, to the registry.
+func recordRunMetrics(reg *metrics.Registry, pipelineName string, root *treeview.Node, runErr error) {
+	state := eventlog.NodeToStateNode(root)
+	_, _, failed, _ := eventlog.CountSteps(state)
+
+	result := eventlog.ResultPass
+	if runErr != nil || failed > 0 {
+		result = eventlog.ResultFail
+	}
+
+	reg.Add(metricRunsTotal, metrics.Labels{"pipeline": pipelineName, "result": string(result)}, 1)
+	reg.Set(metricLastRun, metrics.Labels{"pipeline": pipelineName}, float64(time.Now().Unix()))
+
+	for _, job := range state.Children {
+		for _, step := range leafNodes(job) {
+			labels := metrics.Labels{
+				"pipeline": pipelineName,
+				"job":      job.Name,
+				"step":     stepLabel(job.Name, step),
+			}
+			switch step.Result {
+			case eventlog.ResultPass:
+				reg.Observe(metricStepDuration, labels, step.Duration)
+			case eventlog.ResultFail:
+				reg.Observe(metricStepDuration, labels, step.Duration)
+				reg.Add(metricStepsFailedTotal, metrics.Labels{"pipeline": pipelineName, "job": job.Name}, 1)
+			}
+		}
+	}
+}
+
+// writeMetrics records the run into the metrics textfile at path, accumulating
+// counters from previous runs.
+func writeMetrics(path, pipelineName string, root *treeview.Node, runErr error) error {
+	reg := newMetricsRegistry()
+	if err := reg.Load(path); err != nil {
+		return err
+	}
+	recordRunMetrics(reg, pipelineName, root, runErr)
+	return reg.WriteTextfile(path)
+}
+
+// leafNodes returns the leaf nodes (executed steps) below a state node.
+func leafNodes(node *eventlog.StateNode) []*eventlog.StateNode {
+	if len(node.Children) == 0 {
+		return nil
+	}
+	var result []*eventlog.StateNode
+	for _, child := range node.Children {
+		if len(child.Children) == 0 {
+			result = append(result, child)
+			continue
+		}
+		result = append(result, leafNodes(child)...)
+	}
+	return result
+}
+
+// stepLabel returns the step id relative to its job, falling back to the step name.
+func stepLabel(jobName string, step *eventlog.StateNode) string {
+	if step.ID == "" {
+		return step.Name
+	}
+	return strings.TrimPrefix(step.ID, "jobs."+jobName+".steps.")
+}
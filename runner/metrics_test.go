@@ -0,0 +1,43 @@
+package runner
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/atkins/treeview"
+)
+
+func TestRecordRunMetrics(t *testing.T) {
+	root := treeview.NewNode("ci")
+	job := treeview.NewNode("build")
+	job.SetStatus(treeview.StatusFailed)
+
+	compile := treeview.NewNode("run: go build")
+	compile.SetID("jobs.build.steps.compile")
+	compile.SetStatus(treeview.StatusPassed)
+	compile.SetDuration(2)
+
+	vet := treeview.NewNode("run: go vet")
+	vet.SetID("jobs.build.steps.1")
+	vet.SetStatus(treeview.StatusFailed)
+	vet.SetDuration(0.5)
+
+	job.AddChildren(compile, vet)
+	root.AddChild(job)
+
+	reg := newMetricsRegistry()
+	recordRunMetrics(reg, "ci", root, errors.New("failed"))
+
+	var buf bytes.Buffer
+	require.NoError(t, reg.Write(&buf))
+	output := buf.String()
+
+	assert.Contains(t, output, `atkins_runs_total{pipeline="ci",result="fail"} 1`)
+	assert.Contains(t, output, `atkins_steps_failed_total{job="build",pipeline="ci"} 1`)
+	assert.Contains(t, output, `atkins_step_duration_seconds_sum{job="build",pipeline="ci",step="compile"} 2`)
+	assert.Contains(t, output, `atkins_step_duration_seconds_count{job="build",pipeline="ci",step="1"} 1`)
+}
@@ -0,0 +1,233 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Deprecation is a legacy construct found in a pipeline.
+type Deprecation struct {
+	Path        string // Location in the pipeline, e.g. jobs.build.cmds
+	Construct   string // The legacy construct
+	Replacement string // What it's rewritten to
+	Migrated    bool   // False when the construct must be migrated by hand
+}
+
+// MigratePipeline rewrites legacy constructs in pipeline YAML to the
+// current schema, and returns the rewritten YAML with the list of
+// constructs found:
+//
+//   - `tasks:` becomes `jobs:`
+//   - job-level `cmds:` becomes `steps:`
+//   - job-level `run:` and `cmd:` become a single step with `passthru: true`
+//   - step-level `cmd:` becomes `run:`
+//   - `min_atkins_version: 0.9` becomes `requires: {atkins: ">=0.9"}`
+func MigratePipeline(data []byte) ([]byte, []Deprecation, error) {
+	var found []Deprecation
+	migrated, err := rewriteDocuments(data, 0, func(root *yaml.Node) {
+		found = append(found, migratePipelineNode(root)...)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(found) == 0 {
+		return data, nil, nil
+	}
+	return migrated, found, nil
+}
+
+func migratePipelineNode(node *yaml.Node) []Deprecation {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	var found []Deprecation
+	jobsKey := "jobs"
+	if tasks := mappingKey(node, "tasks"); tasks != nil {
+		if mappingKey(node, "jobs") != nil {
+			found = append(found, Deprecation{
+				Path:        "tasks",
+				Construct:   "tasks: alongside jobs:",
+				Replacement: "move the tasks into jobs:, tasks: is ignored when jobs: is set",
+			})
+		} else {
+			tasks.Value = "jobs"
+			jobsKey = "tasks"
+			found = append(found, Deprecation{Path: "tasks", Construct: "tasks:", Replacement: "jobs:", Migrated: true})
+		}
+	}
+
+	if mappingKey(node, "min_atkins_version") != nil {
+		found = append(found, migrateMinAtkinsVersion(node))
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value != "jobs" || node.Content[i+1].Kind != yaml.MappingNode {
+			continue
+		}
+		jobs := node.Content[i+1]
+		for j := 0; j+1 < len(jobs.Content); j += 2 {
+			path := jobsKey + "." + jobs.Content[j].Value
+			found = append(found, migrateJobNode(path, jobs.Content[j+1])...)
+		}
+	}
+	return found
+}
+
+// migrateMinAtkinsVersion moves `min_atkins_version:` into `requires:`.
+func migrateMinAtkinsVersion(node *yaml.Node) Deprecation {
+	var requires *yaml.Node
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == "requires" {
+			requires = node.Content[i+1]
+		}
+	}
+	if requires != nil && (requires.Kind != yaml.MappingNode || mappingKey(requires, "atkins") != nil) {
+		return Deprecation{
+			Path:        "min_atkins_version",
+			Construct:   "min_atkins_version: alongside requires.atkins:",
+			Replacement: "remove it, min_atkins_version: is ignored",
+		}
+	}
+
+	atkins := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "atkins"}
+	if requires == nil {
+		// The key is replaced in place, keeping its position and comments
+		key := mappingKey(node, "min_atkins_version")
+		key.Value = "requires"
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i] == key {
+				requires = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{atkins, node.Content[i+1]}}
+				node.Content[i+1] = requires
+			}
+		}
+	} else {
+		requires.Content = append(requires.Content, atkins, removeMappingKey(node, "min_atkins_version"))
+	}
+	value := requires.Content[len(requires.Content)-1]
+	value.Value = ">=" + strings.TrimPrefix(value.Value, ">=")
+	value.Tag, value.Style = "!!str", yaml.DoubleQuotedStyle
+	return Deprecation{Path: "min_atkins_version", Construct: "min_atkins_version:", Replacement: "requires.atkins:", Migrated: true}
+}
+
+func migrateJobNode(path string, node *yaml.Node) []Deprecation {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	var found []Deprecation
+	steps, stepsKey := mappingKey(node, "steps"), "steps"
+	if cmds := mappingKey(node, "cmds"); cmds != nil {
+		if steps != nil {
+			found = append(found, Deprecation{
+				Path:        path + ".cmds",
+				Construct:   "cmds: alongside steps:",
+				Replacement: "move the cmds into steps:, cmds: is ignored when steps: is set",
+			})
+		} else {
+			cmds.Value = "steps"
+			steps, stepsKey = cmds, "cmds"
+			found = append(found, Deprecation{Path: path + ".cmds", Construct: "job cmds:", Replacement: "steps:", Migrated: true})
+		}
+	}
+
+	// A job-level command is a single step, cmd: takes precedence over run:
+	for _, key := range []string{"cmd", "run"} {
+		keyNode := mappingKey(node, key)
+		if keyNode == nil {
+			continue
+		}
+		if steps != nil {
+			found = append(found, Deprecation{
+				Path:        path + "." + key,
+				Construct:   "job " + key + ": alongside steps:",
+				Replacement: "remove it, " + key + ": is ignored when the job has steps",
+			})
+			continue
+		}
+
+		value := removeMappingKey(node, key)
+		steps = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "steps"}
+		sequence := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Content: []*yaml.Node{value}}
+		node.Content = append(node.Content, steps, sequence)
+		if mappingKey(node, "passthru") == nil {
+			node.Content = append(node.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "passthru"},
+				&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: "true"},
+			)
+		}
+		found = append(found, Deprecation{
+			Path:        path + "." + key,
+			Construct:   "job " + key + ":",
+			Replacement: "steps: with passthru: true",
+			Migrated:    true,
+		})
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i] != steps || node.Content[i+1].Kind != yaml.SequenceNode {
+			continue
+		}
+		for idx, step := range node.Content[i+1].Content {
+			found = append(found, migrateStepNode(fmt.Sprintf("%s.%s.%d", path, stepsKey, idx), step)...)
+		}
+	}
+	return found
+}
+
+func migrateStepNode(path string, node *yaml.Node) []Deprecation {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	var found []Deprecation
+	if cmd := mappingKey(node, "cmd"); cmd != nil {
+		if mappingKey(node, "run") != nil {
+			found = append(found, Deprecation{
+				Path:        path + ".cmd",
+				Construct:   "step cmd: alongside run:",
+				Replacement: "remove one of them",
+			})
+		} else {
+			cmd.Value = "run"
+			found = append(found, Deprecation{Path: path + ".cmd", Construct: "step cmd:", Replacement: "run:", Migrated: true})
+		}
+	}
+	if deferred := mappingKey(node, "defer"); deferred != nil {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i] == deferred {
+				found = append(found, migrateStepNode(path+".defer", node.Content[i+1])...)
+			}
+		}
+	}
+	return found
+}
+
+// mappingKey returns the key node of a mapping, or nil.
+func mappingKey(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i]
+		}
+	}
+	return nil
+}
+
+// removeMappingKey removes a key from a mapping and returns its value.
+// Comments on the key are moved to the value.
+func removeMappingKey(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value != key {
+			continue
+		}
+		keyNode, value := node.Content[i], node.Content[i+1]
+		if value.HeadComment == "" {
+			value.HeadComment = keyNode.HeadComment
+		}
+		node.Content = append(node.Content[:i], node.Content[i+2:]...)
+		return value
+	}
+	return nil
+}
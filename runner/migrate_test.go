@@ -0,0 +1,100 @@
+package runner
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigratePipeline(t *testing.T) {
+	input := `tasks:
+  build:
+    desc: Build
+    # compile
+    cmd: go build ./...
+  test:
+    cmds:
+      - go test ./...
+      - cmd: go vet ./...
+        name: vet
+  both:
+    steps:
+      - echo hi
+    run: echo ignored
+`
+
+	expected := `jobs:
+  build:
+    desc: Build
+    steps:
+      # compile
+      - go build ./...
+    passthru: true
+
+  test:
+    steps:
+      - go test ./...
+      - run: go vet ./...
+        name: vet
+
+  both:
+    steps:
+      - echo hi
+    run: echo ignored
+`
+
+	migrated, found, err := MigratePipeline([]byte(input))
+	require.NoError(t, err)
+	assert.Equal(t, expected, string(migrated))
+	assert.Equal(t, []Deprecation{
+		{Path: "tasks", Construct: "tasks:", Replacement: "jobs:", Migrated: true},
+		{Path: "tasks.build.cmd", Construct: "job cmd:", Replacement: "steps: with passthru: true", Migrated: true},
+		{Path: "tasks.test.cmds", Construct: "job cmds:", Replacement: "steps:", Migrated: true},
+		{Path: "tasks.test.cmds.1.cmd", Construct: "step cmd:", Replacement: "run:", Migrated: true},
+		{Path: "tasks.both.run", Construct: "job run: alongside steps:", Replacement: "remove it, run: is ignored when the job has steps"},
+	}, found)
+
+	// The migrated pipeline runs the same steps
+	before, err := LoadPipelineFromReader(strings.NewReader(input))
+	require.NoError(t, err)
+	after, err := LoadPipelineFromReader(strings.NewReader(string(migrated)))
+	require.NoError(t, err)
+	for name, job := range before[0].GetJobs() {
+		migratedJob := after[0].GetJobs()[name]
+		require.NotNil(t, migratedJob, name)
+		assert.Equal(t, job.Passthru, migratedJob.Passthru, name)
+		require.Len(t, migratedJob.Children(), len(job.Children()), name)
+		for i, step := range job.Children() {
+			assert.Equal(t, step.Commands(), migratedJob.Children()[i].Commands(), name)
+		}
+	}
+}
+
+func TestMigratePipeline_Current(t *testing.T) {
+	input := "jobs:\n    build:\n        steps:\n            - run: go build ./...\n"
+
+	migrated, found, err := MigratePipeline([]byte(input))
+	require.NoError(t, err)
+	assert.Empty(t, found)
+	assert.Equal(t, input, string(migrated), "files without legacy constructs are left as is")
+}
+
+func TestMigratePipeline_MinAtkinsVersion(t *testing.T) {
+	migrated, found, err := MigratePipeline([]byte("min_atkins_version: 0.9\njobs:\n    build:\n        steps:\n            - run: go build ./...\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []Deprecation{
+		{Path: "min_atkins_version", Construct: "min_atkins_version:", Replacement: "requires.atkins:", Migrated: true},
+	}, found)
+	assert.Equal(t, "requires:\n  atkins: \">=0.9\"\n\njobs:\n  build:\n    steps:\n      - run: go build ./...\n", string(migrated))
+
+	pipelines, err := LoadPipelineFromReader(strings.NewReader(string(migrated)))
+	require.NoError(t, err)
+	assert.Equal(t, ">=0.9", pipelines[0].Requires.Atkins)
+
+	_, found, err = MigratePipeline([]byte("min_atkins_version: 0.9\nrequires:\n    atkins: \">=1.0\"\n"))
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.False(t, found[0].Migrated, "requires.atkins is kept")
+}
@@ -0,0 +1,30 @@
+package runner
+
+import (
+	"cmp"
+	"fmt"
+
+	"github.com/titpetric/atkins/model"
+)
+
+// Network modes of steps and jobs.
+const (
+	NetworkHost = "host" // Commands use the network of the host
+	NetworkNone = "none" // Commands run in a network namespace with only a loopback interface
+)
+
+// stepNoNetwork returns true when the step commands run without network
+// access. The step network overrides the network of the job.
+func stepNoNetwork(job *model.Job, step *model.Step) (bool, error) {
+	network := step.Network
+	if job != nil {
+		network = cmp.Or(network, job.Network)
+	}
+	switch network {
+	case "", NetworkHost:
+		return false, nil
+	case NetworkNone:
+		return true, nil
+	}
+	return false, fmt.Errorf("unknown network %q, expected %q or %q", network, NetworkNone, NetworkHost)
+}
@@ -0,0 +1,75 @@
+package runner
+
+import (
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/atkins/model"
+	"github.com/titpetric/atkins/psexec"
+)
+
+func TestMain(m *testing.M) {
+	psexec.NoNetworkMain(os.Args)
+	os.Exit(m.Run())
+}
+
+func TestStepNoNetwork(t *testing.T) {
+	job := &model.Job{Network: NetworkNone}
+
+	noNetwork, err := stepNoNetwork(job, &model.Step{})
+	require.NoError(t, err)
+	assert.True(t, noNetwork)
+
+	noNetwork, err = stepNoNetwork(job, &model.Step{Network: NetworkHost})
+	require.NoError(t, err)
+	assert.False(t, noNetwork)
+
+	noNetwork, err = stepNoNetwork(nil, &model.Step{})
+	require.NoError(t, err)
+	assert.False(t, noNetwork)
+
+	_, err = stepNoNetwork(nil, &model.Step{Network: "bridge"})
+	assert.ErrorContains(t, err, `unknown network "bridge"`)
+}
+
+func TestRunPipeline_NetworkNone(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("network namespaces are Linux only")
+	}
+	t.Chdir(t.TempDir())
+
+	pipelines, err := LoadPipelineFromReader(strings.NewReader(`
+jobs:
+  default:
+    network: none
+    steps:
+      - run: grep -c ':' /proc/net/dev | grep -qx 1; readlink /proc/self/ns/net > isolated
+      - network: host
+        run: readlink /proc/self/ns/net > host
+`))
+	require.NoError(t, err)
+
+	err = RunPipeline(t.Context(), pipelines[0], PipelineOptions{
+		Jobs:   []string{"default"},
+		Silent: true,
+	})
+	if err != nil && strings.Contains(err.Error(), "not permitted") {
+		t.Skipf("network namespaces are not permitted: %v", err)
+	}
+	require.NoError(t, err)
+
+	// Only the step with the network of the job runs in its own namespace
+	hostNS, err := os.Readlink("/proc/self/ns/net")
+	require.NoError(t, err)
+	host, err := os.ReadFile("host")
+	require.NoError(t, err)
+	isolated, err := os.ReadFile("isolated")
+	require.NoError(t, err)
+	assert.Equal(t, hostNS+"\n", string(host))
+	assert.NotEqual(t, hostNS+"\n", string(isolated))
+}
@@ -0,0 +1,355 @@
+package runner
+
+import (
+	"archive/tar"
+	"bytes"
+	"cmp"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// OCICacheDir is the content-addressed cache of pulled OCI blobs, relative to $HOME.
+var OCICacheDir = filepath.Join(".atkins", "cache", "oci")
+
+// OCI media types and annotations of ORAS artifacts.
+const (
+	ociManifestMediaType = "application/vnd.oci.image.manifest.v1+json"
+	ociTitleAnnotation   = "org.opencontainers.image.title"
+	orasUnpackAnnotation = "io.deis.oras.content.unpack"
+)
+
+// ociDigestPattern matches a sha256 content digest.
+var ociDigestPattern = regexp.MustCompile(`^sha256:[0-9a-f]{64}$`)
+
+// OCIReference is an artifact in an OCI registry, e.g.
+// oci://ghcr.io/org/atkins-skills/go:1.2.0, or pinned with @sha256:<hex>.
+type OCIReference struct {
+	Registry   string
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+// ParseOCIReference parses an oci:// reference. The tag defaults to latest.
+func ParseOCIReference(ref string) (*OCIReference, error) {
+	rest, ok := strings.CutPrefix(ref, "oci://")
+	if !ok {
+		return nil, fmt.Errorf("invalid OCI reference %q, expected oci://registry/repository:tag", ref)
+	}
+	result := &OCIReference{}
+	if name, digest, ok := strings.Cut(rest, "@"); ok {
+		if !ociDigestPattern.MatchString(digest) {
+			return nil, fmt.Errorf("invalid OCI reference %q: unsupported digest %q", ref, digest)
+		}
+		rest, result.Digest = name, digest
+	}
+
+	registry, repository, ok := strings.Cut(rest, "/")
+	if !ok || registry == "" || repository == "" {
+		return nil, fmt.Errorf("invalid OCI reference %q, expected oci://registry/repository:tag", ref)
+	}
+	if i := strings.LastIndex(repository, ":"); i >= 0 {
+		repository, result.Tag = repository[:i], repository[i+1:]
+	}
+	if result.Tag == "" && result.Digest == "" {
+		result.Tag = "latest"
+	}
+	result.Registry, result.Repository = registry, repository
+	return result, nil
+}
+
+// String returns the reference in oci:// form.
+func (r *OCIReference) String() string {
+	result := "oci://" + r.Registry + "/" + r.Repository
+	if r.Tag != "" {
+		result += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		result += "@" + r.Digest
+	}
+	return result
+}
+
+// ociManifest is the part of an OCI image manifest needed to pull files.
+type ociManifest struct {
+	MediaType string `json:"mediaType"`
+	Layers    []struct {
+		MediaType   string            `json:"mediaType"`
+		Digest      string            `json:"digest"`
+		Size        int64             `json:"size"`
+		Annotations map[string]string `json:"annotations"`
+	} `json:"layers"`
+}
+
+// OCIClient pulls ORAS artifacts from OCI registries, caching blobs by
+// digest. Anonymous registry tokens are requested as needed, using the
+// username and password when set.
+type OCIClient struct {
+	Client    *http.Client
+	CacheDir  string
+	PlainHTTP bool // Use http:// for all registries, localhost always does
+
+	Username string
+	Password string
+
+	token string
+}
+
+// NewOCIClient creates a client caching blobs in cacheDir.
+func NewOCIClient(cacheDir string) *OCIClient {
+	return &OCIClient{Client: http.DefaultClient, CacheDir: cacheDir}
+}
+
+// Pull resolves the reference to a manifest digest and writes the files
+// of its layers to dir, named by their title annotation. Directories,
+// layers marked for unpacking, are extracted into dir. It returns the
+// manifest digest and the written paths, relative to dir.
+func (c *OCIClient) Pull(ctx context.Context, ref *OCIReference, dir string) (string, []string, error) {
+	digest := ref.Digest
+	if digest == "" {
+		digest = ref.Tag
+	}
+	data, digest, err := c.fetch(ctx, ref, "manifests", digest, ociManifestMediaType)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to pull manifest of %s: %w", ref, err)
+	}
+
+	var manifest ociManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return "", nil, fmt.Errorf("failed to parse manifest of %s: %w", ref, err)
+	}
+
+	var files []string
+	for _, layer := range manifest.Layers {
+		title := layer.Annotations[ociTitleAnnotation]
+		if title == "" {
+			continue
+		}
+		if !filepath.IsLocal(title) {
+			return "", nil, fmt.Errorf("%s: layer %s has an unsafe title %q", ref, layer.Digest, title)
+		}
+		blob, _, err := c.fetch(ctx, ref, "blobs", layer.Digest, "")
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to pull %s of %s: %w", title, ref, err)
+		}
+
+		if layer.Annotations[orasUnpackAnnotation] == "true" {
+			extracted, err := extractTarGz(blob, dir)
+			if err != nil {
+				return "", nil, fmt.Errorf("failed to extract %s of %s: %w", title, ref, err)
+			}
+			files = append(files, extracted...)
+			continue
+		}
+		target := filepath.Join(dir, title)
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return "", nil, err
+		}
+		if err := os.WriteFile(target, blob, 0o644); err != nil {
+			return "", nil, err
+		}
+		files = append(files, filepath.ToSlash(title))
+	}
+	if len(files) == 0 {
+		return "", nil, fmt.Errorf("%s has no files with a %s annotation", ref, ociTitleAnnotation)
+	}
+	return digest, files, nil
+}
+
+// fetch returns a manifest or blob with its digest. Content addressed by
+// digest is served from the cache, fetched content is verified against
+// the requested or returned digest.
+func (c *OCIClient) fetch(ctx context.Context, ref *OCIReference, kind, reference, accept string) ([]byte, string, error) {
+	pinned := ociDigestPattern.MatchString(reference)
+	if pinned {
+		if data, err := os.ReadFile(c.blobPath(reference)); err == nil && sha256Digest(data) == reference {
+			return data, reference, nil
+		}
+	}
+
+	scheme := "https"
+	if c.PlainHTTP || isLocalRegistry(ref.Registry) {
+		scheme = "http"
+	}
+	target := fmt.Sprintf("%s://%s/v2/%s/%s/%s", scheme, ref.Registry, ref.Repository, kind, reference)
+	resp, err := c.get(ctx, target, accept)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+
+	digest := sha256Digest(data)
+	if expected := resp.Header.Get("Docker-Content-Digest"); !pinned && expected != "" {
+		reference, pinned = expected, true
+	}
+	if pinned && digest != reference {
+		return nil, "", fmt.Errorf("digest mismatch: got %s, expected %s", digest, reference)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.blobPath(digest)), 0o755); err != nil {
+		return nil, "", err
+	}
+	if err := os.WriteFile(c.blobPath(digest), data, 0o644); err != nil {
+		return nil, "", err
+	}
+	return data, digest, nil
+}
+
+// get requests target, authenticating with a registry token when challenged.
+func (c *OCIClient) get(ctx context.Context, target, accept string) (*http.Response, error) {
+	do := func() (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+		if err != nil {
+			return nil, err
+		}
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		return c.Client.Do(req)
+	}
+
+	resp, err := do()
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	challenge := resp.Header.Get("WWW-Authenticate")
+	resp.Body.Close()
+	if err := c.authenticate(ctx, challenge); err != nil {
+		return nil, err
+	}
+	return do()
+}
+
+// authenticate requests a token for a Bearer challenge of the registry.
+func (c *OCIClient) authenticate(ctx context.Context, challenge string) error {
+	scheme, params, _ := strings.Cut(challenge, " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return fmt.Errorf("unauthorized, unsupported challenge %q", challenge)
+	}
+	values := url.Values{}
+	var realm string
+	for _, param := range strings.Split(params, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+		value = strings.Trim(value, `"`)
+		if key == "realm" {
+			realm = value
+			continue
+		}
+		values.Set(key, value)
+	}
+	if realm == "" {
+		return errors.New("unauthorized, challenge without realm")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm+"?"+values.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	if c.Username != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to get registry token: %s", resp.Status)
+	}
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return fmt.Errorf("failed to get registry token: %w", err)
+	}
+	c.token = cmp.Or(token.Token, token.AccessToken)
+	return nil
+}
+
+// blobPath returns the cache path of content with the digest.
+func (c *OCIClient) blobPath(digest string) string {
+	algorithm, hash, _ := strings.Cut(digest, ":")
+	return filepath.Join(c.CacheDir, "blobs", algorithm, hash)
+}
+
+// sha256Digest returns the OCI digest of data.
+func sha256Digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// isLocalRegistry returns true for registries served over plain http.
+func isLocalRegistry(registry string) bool {
+	host := registry
+	if h, _, ok := strings.Cut(registry, ":"); ok {
+		host = h
+	}
+	return host == "localhost" || host == "127.0.0.1"
+}
+
+// extractTarGz extracts the regular files of a gzipped tarball into dir,
+// returning their paths relative to dir.
+func extractTarGz(data []byte, dir string) ([]string, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	var files []string
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return files, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if !filepath.IsLocal(header.Name) {
+			return nil, fmt.Errorf("unsafe path %q", header.Name)
+		}
+		target := filepath.Join(dir, header.Name)
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return nil, err
+		}
+		f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, header.FileInfo().Mode().Perm())
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.Copy(f, tr); err != nil {
+			f.Close()
+			return nil, err
+		}
+		if err := f.Close(); err != nil {
+			return nil, err
+		}
+		files = append(files, filepath.ToSlash(header.Name))
+	}
+}
@@ -0,0 +1,216 @@
+package runner_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/atkins/runner"
+)
+
+func TestParseOCIReference(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("a", 64)
+
+	tests := []struct {
+		ref  string
+		want runner.OCIReference
+	}{
+		{"oci://ghcr.io/org/atkins-skills/go:1.2.0", runner.OCIReference{Registry: "ghcr.io", Repository: "org/atkins-skills/go", Tag: "1.2.0"}},
+		{"oci://ghcr.io/org/skills", runner.OCIReference{Registry: "ghcr.io", Repository: "org/skills", Tag: "latest"}},
+		{"oci://localhost:5000/skills:v1", runner.OCIReference{Registry: "localhost:5000", Repository: "skills", Tag: "v1"}},
+		{"oci://ghcr.io/org/skills@" + digest, runner.OCIReference{Registry: "ghcr.io", Repository: "org/skills", Digest: digest}},
+		{"oci://ghcr.io/org/skills:v1@" + digest, runner.OCIReference{Registry: "ghcr.io", Repository: "org/skills", Tag: "v1", Digest: digest}},
+	}
+	for _, tc := range tests {
+		got, err := runner.ParseOCIReference(tc.ref)
+		require.NoError(t, err, tc.ref)
+		assert.Equal(t, tc.want, *got, tc.ref)
+		if tc.want.Tag != "latest" {
+			assert.Equal(t, tc.ref, got.String())
+		}
+	}
+
+	_, err := runner.ParseOCIReference("ghcr.io/org/skills:v1")
+	assert.ErrorContains(t, err, "invalid OCI reference")
+	_, err = runner.ParseOCIReference("oci://ghcr.io")
+	assert.ErrorContains(t, err, "invalid OCI reference")
+	_, err = runner.ParseOCIReference("oci://ghcr.io/org/skills@md5:abc")
+	assert.ErrorContains(t, err, "unsupported digest")
+}
+
+// fakeRegistry serves a skill bundle as an ORAS artifact, requiring a token.
+type fakeRegistry struct {
+	blobs    map[string][]byte
+	manifest []byte
+	requests int
+}
+
+func newFakeRegistry(t *testing.T, layers map[string][]byte, unpack string) *fakeRegistry {
+	t.Helper()
+	r := &fakeRegistry{blobs: map[string][]byte{}}
+
+	type layer struct {
+		MediaType   string            `json:"mediaType"`
+		Digest      string            `json:"digest"`
+		Size        int               `json:"size"`
+		Annotations map[string]string `json:"annotations"`
+	}
+	var manifest struct {
+		SchemaVersion int     `json:"schemaVersion"`
+		MediaType     string  `json:"mediaType"`
+		Layers        []layer `json:"layers"`
+	}
+	manifest.SchemaVersion = 2
+	manifest.MediaType = "application/vnd.oci.image.manifest.v1+json"
+	for _, title := range []string{"go.yml", unpack} {
+		if title == "" {
+			continue
+		}
+		data := layers[title]
+		digest := digestOf(data)
+		r.blobs[digest] = data
+		annotations := map[string]string{"org.opencontainers.image.title": title}
+		if title == unpack {
+			annotations["io.deis.oras.content.unpack"] = "true"
+		}
+		manifest.Layers = append(manifest.Layers, layer{"application/vnd.oci.image.layer.v1.tar", digest, len(data), annotations})
+	}
+	var err error
+	r.manifest, err = json.Marshal(manifest)
+	require.NoError(t, err)
+	return r
+}
+
+func (r *fakeRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.requests++
+	if req.URL.Path == "/token" {
+		json.NewEncoder(w).Encode(map[string]string{"token": "secret"})
+		return
+	}
+	if req.Header.Get("Authorization") != "Bearer secret" {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="http://`+req.Host+`/token",service="test",scope="repository:skills/go:pull"`)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	switch {
+	case strings.HasPrefix(req.URL.Path, "/v2/skills/go/manifests/"):
+		w.Header().Set("Docker-Content-Digest", digestOf(r.manifest))
+		w.Write(r.manifest)
+	case strings.HasPrefix(req.URL.Path, "/v2/skills/go/blobs/"):
+		data, ok := r.blobs[strings.TrimPrefix(req.URL.Path, "/v2/skills/go/blobs/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(data)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func digestOf(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func tarGz(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func TestOCIClient_Pull(t *testing.T) {
+	skill := []byte("jobs:\n  test: go test ./...\n")
+	registry := newFakeRegistry(t, map[string][]byte{
+		"go.yml": skill,
+		"go":     tarGz(t, map[string]string{"go/golangci.yml": "linters: {}\n"}),
+	}, "go")
+	server := httptest.NewServer(registry)
+	defer server.Close()
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	ref, err := runner.ParseOCIReference("oci://" + host + "/skills/go:1.2.0")
+	require.NoError(t, err)
+
+	client := runner.NewOCIClient(t.TempDir())
+	dir := t.TempDir()
+	digest, files, err := client.Pull(t.Context(), ref, dir)
+	require.NoError(t, err)
+	assert.Equal(t, digestOf(registry.manifest), digest)
+	assert.Equal(t, []string{"go.yml", "go/golangci.yml"}, files)
+
+	data, err := os.ReadFile(filepath.Join(dir, "go.yml"))
+	require.NoError(t, err)
+	assert.Equal(t, skill, data)
+	data, err = os.ReadFile(filepath.Join(dir, "go", "golangci.yml"))
+	require.NoError(t, err)
+	assert.Equal(t, "linters: {}\n", string(data))
+
+	// A reference pinned by digest is served from the cache
+	requests := registry.requests
+	ref.Digest = digest
+	_, _, err = client.Pull(t.Context(), ref, t.TempDir())
+	require.NoError(t, err)
+	assert.Equal(t, requests, registry.requests)
+
+	// Content not matching the pinned digest is rejected
+	ref.Digest = digestOf([]byte("other"))
+	_, _, err = client.Pull(t.Context(), ref, t.TempDir())
+	assert.ErrorContains(t, err, "digest mismatch")
+}
+
+func TestOCIClient_PullUnsafeTitle(t *testing.T) {
+	registry := newFakeRegistry(t, map[string][]byte{
+		"go.yml":    []byte("jobs: {}\n"),
+		"../escape": []byte("x"),
+	}, "../escape")
+	server := httptest.NewServer(registry)
+	defer server.Close()
+
+	ref, err := runner.ParseOCIReference("oci://" + strings.TrimPrefix(server.URL, "http://") + "/skills/go:1.2.0")
+	require.NoError(t, err)
+
+	_, _, err = runner.NewOCIClient(t.TempDir()).Pull(t.Context(), ref, t.TempDir())
+	assert.ErrorContains(t, err, "unsafe title")
+}
+
+func TestSkillsLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".atkins", "skills.lock")
+
+	lock, err := runner.LoadSkillsLock(path)
+	require.NoError(t, err)
+	assert.Empty(t, lock.Skills)
+
+	lock.Set(runner.LockedSkill{Ref: "oci://ghcr.io/org/go:1.2.0", Digest: "sha256:1", Files: []string{"go.yml"}})
+	lock.Set(runner.LockedSkill{Ref: "oci://ghcr.io/org/docker:1.0.0", Digest: "sha256:2", Files: []string{"docker.yml"}})
+	lock.Set(runner.LockedSkill{Ref: "oci://ghcr.io/org/go:1.2.0", Digest: "sha256:3", Files: []string{"go.yml"}})
+	require.NoError(t, lock.Save(path))
+
+	loaded, err := runner.LoadSkillsLock(path)
+	require.NoError(t, err)
+	assert.Equal(t, []runner.LockedSkill{
+		{Ref: "oci://ghcr.io/org/go:1.2.0", Digest: "sha256:3", Files: []string{"go.yml"}},
+		{Ref: "oci://ghcr.io/org/docker:1.0.0", Digest: "sha256:2", Files: []string{"docker.yml"}},
+	}, loaded.Skills)
+}
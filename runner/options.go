@@ -7,6 +7,10 @@ import (
 // Options provides configuration for the executor.
 type Options struct {
 	DefaultTimeout time.Duration
+	OnFailure      string // OnFailureShell opens a shell when a step fails
+	Step           bool   // Pause before each step, like a `breakpoint: true` on every step
+
+	Cache *JobCache // Stores the outputs of jobs with a `cache:` section, nil disables caching
 }
 
 // DefaultOptions returns the default executor options.
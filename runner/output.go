@@ -13,9 +13,10 @@ import (
 
 // OutputItem represents a single command in the list output.
 type OutputItem struct {
-	ID   string `json:"id" yaml:"id"`
-	Desc string `json:"desc,omitempty" yaml:"desc,omitempty"`
-	Cmd  string `json:"cmd" yaml:"cmd"`
+	ID   string            `json:"id" yaml:"id"`
+	Desc string            `json:"desc,omitempty" yaml:"desc,omitempty"`
+	Cmd  string            `json:"cmd" yaml:"cmd"`
+	Env  map[string]string `json:"env,omitempty" yaml:"env,omitempty"` // Documented environment variables of the job
 }
 
 // OutputSection represents a pipeline section in the list output.
@@ -26,7 +27,7 @@ type OutputSection struct {
 
 // ListPipelinesJSON outputs pipelines in JSON format.
 func ListPipelinesJSON(pipelines []*model.Pipeline) error {
-	output := buildListOutput(pipelines)
+	output := BuildListOutput(pipelines)
 	data, err := json.MarshalIndent(output, "", "  ")
 	if err != nil {
 		return err
@@ -37,7 +38,7 @@ func ListPipelinesJSON(pipelines []*model.Pipeline) error {
 
 // ListPipelinesYAML outputs pipelines in YAML format.
 func ListPipelinesYAML(pipelines []*model.Pipeline) error {
-	output := buildListOutput(pipelines)
+	output := BuildListOutput(pipelines)
 	data, err := yaml.Marshal(output)
 	if err != nil {
 		return err
@@ -46,8 +47,8 @@ func ListPipelinesYAML(pipelines []*model.Pipeline) error {
 	return nil
 }
 
-// buildListOutput builds the structured list output from pipelines.
-func buildListOutput(pipelines []*model.Pipeline) []OutputSection {
+// BuildListOutput builds the structured list output from pipelines.
+func BuildListOutput(pipelines []*model.Pipeline) []OutputSection {
 	if len(pipelines) == 0 {
 		return nil
 	}
@@ -61,7 +62,7 @@ func buildListOutput(pipelines []*model.Pipeline) []OutputSection {
 	}
 
 	// Aliases section
-	if aliases := buildAliasesSection(skills); len(aliases.Cmds) > 0 {
+	if aliases := buildAliasesSection(main, skills); len(aliases.Cmds) > 0 {
 		sections = append(sections, aliases)
 	}
 
@@ -78,7 +79,7 @@ func buildListOutput(pipelines []*model.Pipeline) []OutputSection {
 // buildPipelineSection builds a section for a pipeline.
 func buildPipelineSection(p *model.Pipeline, prefix string) OutputSection {
 	jobs := p.GetJobs()
-	names := treeview.SortJobsByDepth(p.JobNames())
+	names := treeview.SortByDepth(p.JobNames())
 
 	// Move "default" to front
 	for i, name := range names {
@@ -101,6 +102,7 @@ func buildPipelineSection(p *model.Pipeline, prefix string) OutputSection {
 			ID:   id,
 			Desc: job.Desc,
 			Cmd:  "atkins " + id,
+			Env:  EnvDocs(p, job),
 		})
 	}
 
@@ -111,9 +113,20 @@ func buildPipelineSection(p *model.Pipeline, prefix string) OutputSection {
 }
 
 // buildAliasesSection builds the aliases section.
-func buildAliasesSection(skills []*model.Pipeline) OutputSection {
+func buildAliasesSection(main *model.Pipeline, skills []*model.Pipeline) OutputSection {
 	var cmds []OutputItem
 
+	// Pipeline aliases declared in the main pipeline
+	if main != nil {
+		for alias, target := range main.Aliases {
+			cmds = append(cmds, OutputItem{
+				ID:   alias,
+				Desc: fmt.Sprintf("invokes %s", target),
+				Cmd:  "atkins " + alias,
+			})
+		}
+	}
+
 	for _, p := range skills {
 		jobs := p.GetJobs()
 
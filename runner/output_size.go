@@ -0,0 +1,47 @@
+package runner
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/titpetric/atkins/psexec"
+)
+
+// outputSize returns the environment and the PTY size that size the
+// output of a command to the output box of the current step, so tools
+// like go test and npm wrap to the visible width instead of the full
+// terminal. COLUMNS and LINES set by the pipeline are kept, as is
+// FORCE_COLOR from the environment. It returns nothing when the tree
+// isn't rendered live to a terminal.
+func outputSize(execCtx *ExecutionContext, tty bool) ([]string, *psexec.WindowSize) {
+	if execCtx.Display == nil || execCtx.Builder == nil || execCtx.CurrentStep == nil {
+		return nil, nil
+	}
+	depth := execCtx.Builder.Root().DepthOf(execCtx.CurrentStep)
+	if depth < 0 {
+		return nil, nil
+	}
+	cols, lines, ok := execCtx.Display.OutputSize(depth)
+	if !ok {
+		return nil, nil
+	}
+
+	var env []string
+	set := func(name string, value int) {
+		if v, ok := execCtx.Env[name]; ok && v != os.Getenv(name) {
+			return
+		}
+		env = append(env, name+"="+strconv.Itoa(value))
+	}
+	set("COLUMNS", cols)
+	set("LINES", lines)
+
+	// The tree keeps the colors of TTY output, so tools checking
+	// FORCE_COLOR rather than the terminal color their output too
+	_, forced := execCtx.Env["FORCE_COLOR"]
+	_, disabled := execCtx.Env["NO_COLOR"]
+	if tty && !forced && !disabled {
+		env = append(env, "FORCE_COLOR=1")
+	}
+	return env, &psexec.WindowSize{Rows: uint16(lines), Cols: uint16(cols)}
+}
@@ -35,7 +35,7 @@ func TestBuildListOutput(t *testing.T) {
 	}
 
 	pipelines := []*model.Pipeline{mainPipeline, goSkill}
-	output := buildListOutput(pipelines)
+	output := BuildListOutput(pipelines)
 
 	// Should have 3 sections: main, aliases, go skill
 	if len(output) != 3 {
@@ -67,7 +67,7 @@ func TestBuildListOutput(t *testing.T) {
 }
 
 func TestBuildListOutput_EmptyPipelines(t *testing.T) {
-	output := buildListOutput(nil)
+	output := BuildListOutput(nil)
 	if output != nil {
 		t.Errorf("expected nil for empty pipelines, got %v", output)
 	}
@@ -82,7 +82,7 @@ func TestBuildListOutput_NoMainPipeline(t *testing.T) {
 		},
 	}
 
-	output := buildListOutput([]*model.Pipeline{goSkill})
+	output := BuildListOutput([]*model.Pipeline{goSkill})
 
 	// Should have 1 section (skill only, no aliases since no default)
 	if len(output) != 1 {
@@ -123,6 +123,26 @@ func TestBuildPipelineSection(t *testing.T) {
 	}
 }
 
+func TestBuildPipelineSection_DeclarationOrder(t *testing.T) {
+	pipelines, err := LoadPipelineFromReader(strings.NewReader(`
+jobs:
+  test: echo test
+  build: echo build
+  default: echo default
+  test:unit: echo unit
+  lint: echo lint
+`))
+	require.NoError(t, err)
+
+	for range 10 {
+		var ids []string
+		for _, cmd := range buildPipelineSection(pipelines[0], "").Cmds {
+			ids = append(ids, cmd.ID)
+		}
+		assert.Equal(t, []string{"default", "test", "build", "lint", "test:unit"}, ids)
+	}
+}
+
 func TestBuildAliasesSection(t *testing.T) {
 	skills := []*model.Pipeline{
 		{
@@ -135,7 +155,7 @@ func TestBuildAliasesSection(t *testing.T) {
 		},
 	}
 
-	section := buildAliasesSection(skills)
+	section := buildAliasesSection(nil, skills)
 
 	if section.Desc != "Aliases" {
 		t.Errorf("expected desc 'Aliases', got %s", section.Desc)
@@ -224,3 +244,40 @@ func TestListPipelinesYAML(t *testing.T) {
 	require.Len(t, parsed, 1)
 	assert.Equal(t, "Main", parsed[0].Desc)
 }
+
+func TestBuildVSCodeTasks(t *testing.T) {
+	mainPipeline := &model.Pipeline{
+		ID: "",
+		Jobs: map[string]*model.Job{
+			"build": {Desc: "Build the project", Steps: []*model.Step{{Run: "go build ./..."}}},
+			"lint":  {Steps: []*model.Step{{Run: "echo lint"}}},
+		},
+	}
+	goSkill := &model.Pipeline{
+		ID: "go",
+		Jobs: map[string]*model.Job{
+			"test": {Desc: "Run Go tests", Steps: []*model.Step{{Run: "go test -race ./..."}}},
+		},
+	}
+
+	tasks := buildVSCodeTasks([]*model.Pipeline{mainPipeline, goSkill})
+	assert.Equal(t, "2.0.0", tasks.Version)
+	require.Len(t, tasks.Tasks, 3)
+
+	build := tasks.Tasks[0]
+	assert.Equal(t, "Build the project", build.Label)
+	assert.Equal(t, []string{"build"}, build.Args)
+	assert.Equal(t, &VSCodeGroup{Kind: "build", IsDefault: true}, build.Group)
+	assert.Equal(t, []string{"$go"}, build.ProblemMatcher)
+
+	lint := tasks.Tasks[1]
+	assert.Equal(t, "lint", lint.Label)
+	assert.Nil(t, lint.Group)
+	assert.Empty(t, lint.ProblemMatcher)
+
+	test := tasks.Tasks[2]
+	assert.Equal(t, "Run Go tests", test.Label)
+	assert.Equal(t, []string{"go:test"}, test.Args)
+	assert.Equal(t, &VSCodeGroup{Kind: "test"}, test.Group)
+	assert.Equal(t, []string{"$go"}, test.ProblemMatcher)
+}
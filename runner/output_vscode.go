@@ -0,0 +1,108 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/titpetric/atkins/model"
+)
+
+// ListFormatVSCodeTasks lists the jobs as a .vscode/tasks.json file.
+const ListFormatVSCodeTasks = "vscode-tasks"
+
+// VSCodeTasks is a VS Code tasks.json file.
+type VSCodeTasks struct {
+	Version string        `json:"version"`
+	Tasks   []*VSCodeTask `json:"tasks"`
+}
+
+// VSCodeTask is a task of a VS Code tasks.json file.
+type VSCodeTask struct {
+	Label          string       `json:"label"`
+	Detail         string       `json:"detail,omitempty"`
+	Type           string       `json:"type"`
+	Command        string       `json:"command"`
+	Args           []string     `json:"args"`
+	Group          *VSCodeGroup `json:"group,omitempty"`
+	ProblemMatcher []string     `json:"problemMatcher"`
+}
+
+// VSCodeGroup puts a task in the build or test group of VS Code.
+type VSCodeGroup struct {
+	Kind      string `json:"kind"`
+	IsDefault bool   `json:"isDefault,omitempty"`
+}
+
+// goCommandPattern matches steps running the go toolchain.
+var goCommandPattern = regexp.MustCompile(`(^|[\s;&|(])go\s+(build|test|vet|run|install|generate)\b`)
+
+// ListPipelinesVSCode outputs the jobs as a VS Code tasks.json file, so
+// they show in the VS Code task picker.
+func ListPipelinesVSCode(pipelines []*model.Pipeline) error {
+	data, err := json.MarshalIndent(buildVSCodeTasks(pipelines), "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+// buildVSCodeTasks builds a task per listed job. Tasks are labelled with
+// the job description, and `build` and `test` jobs join the matching
+// group, the ones of the main pipeline as the default task.
+func buildVSCodeTasks(pipelines []*model.Pipeline) *VSCodeTasks {
+	tasks := &VSCodeTasks{Version: "2.0.0", Tasks: []*VSCodeTask{}}
+	if len(pipelines) == 0 {
+		return tasks
+	}
+
+	main, skills := separatePipelines(pipelines)
+	labels := map[string]bool{}
+	for _, p := range append([]*model.Pipeline{main}, skills...) {
+		if p == nil || !p.HasJobs() {
+			continue
+		}
+		jobs := p.GetJobs()
+		for _, item := range buildPipelineSection(p, p.ID).Cmds {
+			name := strings.TrimPrefix(item.ID, p.ID+":")
+			job := jobs[name]
+
+			task := &VSCodeTask{
+				Label:          item.ID,
+				Detail:         item.Cmd,
+				Type:           "shell",
+				Command:        "atkins",
+				Args:           []string{item.ID},
+				ProblemMatcher: []string{},
+			}
+			if item.Desc != "" && !labels[item.Desc] {
+				task.Label = item.Desc
+			}
+			labels[task.Label] = true
+
+			switch name {
+			case "build", "test":
+				task.Group = &VSCodeGroup{Kind: name, IsDefault: p == main}
+			}
+			if job != nil && runsGo(job) {
+				task.ProblemMatcher = []string{"$go"}
+			}
+			tasks.Tasks = append(tasks.Tasks, task)
+		}
+	}
+	return tasks
+}
+
+// runsGo returns true if a step of the job runs the go toolchain.
+func runsGo(job *model.Job) bool {
+	for _, step := range job.Children() {
+		for _, cmd := range step.Commands() {
+			if goCommandPattern.MatchString(cmd) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -1,12 +1,15 @@
 package runner
 
 import (
+	"bytes"
+	"cmp"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"maps"
+	"io"
 	"os"
+	"path/filepath"
 	"slices"
 	"strings"
 	"time"
@@ -23,16 +26,35 @@ import (
 
 // PipelineOptions contains options for running a pipeline.
 type PipelineOptions struct {
-	Jobs         []string // Jobs to run (in order)
-	LogFile      string
-	PipelineFile string
-	Debug        bool
-	FinalOnly    bool
-	Silent       bool
-	JSON         bool
-	YAML         bool
-	AllPipelines []*model.Pipeline // All loaded pipelines for cross-pipeline task references
-	Progress     ProgressObserver  // Optional observer for job progress events
+	Jobs           []string // Jobs to run (in order)
+	LogFile        string
+	PipelineFile   string
+	Debug          bool
+	FinalOnly      bool
+	Plain          bool // Print one line per status transition instead of the live tree
+	Timestamps     bool // Prefix plain progress lines with timestamps
+	Silent         bool
+	JSON           bool
+	YAML           bool
+	AllPipelines   []*model.Pipeline  // All loaded pipelines for cross-pipeline task references
+	Progress       ProgressObserver   // Optional observer for job progress events
+	Version        string             // Atkins version recorded in the event log
+	RunID          string             // Overrides the generated run ID, e.g. with a CI build number
+	CaptureDir     string             // Write full step output to <CaptureDir>/<run-id>/<step-id>.log
+	MetricsFile    string             // Write run metrics to a node_exporter textfile
+	OnFailure      string             // OnFailureShell opens a shell when a step fails
+	Step           bool               // Pause before each step to run, skip or abort it
+	ProgressFile   io.Writer          // Receives a JSON line per status transition, e.g. for IDE plugins
+	Policy         *Policy            // Allows and denies commands before they run
+	AuditLog       *eventlog.AuditLog // Receives every executed command
+	Cache          *JobCache          // Stores the outputs of jobs with a `cache:` section, nil disables caching
+	Filter         *treeview.Filter   // Only shows the matching nodes in the final tree, nil shows all
+	EnforceBudgets bool               // Fails steps taking longer than their `budget:`
+	GanttFile      string             // Write a mermaid gantt chart of the run to this markdown file
+	IssueAfter     int                // File a GitHub issue after this many consecutive failed runs, closed once they pass
+	CheckpointDir  string             // Periodically write the completed jobs and steps to a checkpoint in this directory
+	Resume         bool               // Skip the jobs and steps completed by the run in the checkpoint
+	InstallTools   bool               // Runs the `install:` job of pinned tools that don't match
 }
 
 // Pipeline holds pipeline execution logic.
@@ -50,15 +72,15 @@ func NewPipeline(data *model.Pipeline, opts PipelineOptions) *Pipeline {
 }
 
 // buildAndAddStepsToJob adds step nodes to a job node with command children for multi-command steps
-func buildAndAddStepsToJob(jobNode *treeview.TreeNode, steps []*model.Step) {
+func buildAndAddStepsToJob(jobNode *treeview.TreeNode, steps []*model.Step, scrub *scrubber) {
 	for _, step := range steps {
-		stepNode := treeview.NewPendingStepNode(step.DisplayLabel(), step.IsDeferred(), step.Summarize)
+		stepNode := treeview.NewPendingStepNode(scrub.scrub(step.DisplayLabel()), step.IsDeferred(), step.Summarize)
 		stepNode.SetQuiet(step.Quiet)
 		// Only add command child nodes if step has multiple commands (single command already shown in label)
 		commands := step.Commands()
 		if len(commands) > 1 {
 			for _, cmd := range commands {
-				stepNode.AddChild(treeview.NewCmdNode(cmd))
+				stepNode.AddChild(treeview.NewCmdNode(scrub.scrub(cmd)))
 			}
 		}
 		jobNode.AddChild(stepNode)
@@ -71,6 +93,16 @@ func RunPipeline(ctx context.Context, pipeline *model.Pipeline, opts PipelineOpt
 	if opts.LogFile != "" || opts.PipelineFile != "" {
 		logger = eventlog.NewLogger(opts.LogFile, pipeline.Name, opts.PipelineFile, opts.Debug)
 	}
+	// The gantt chart is rendered from the events, also without a log file
+	if logger == nil && opts.GanttFile != "" {
+		logger = eventlog.NewMemoryLogger(pipeline.Name, opts.PipelineFile, opts.Debug)
+	}
+	if logger != nil {
+		logger.SetVersion(opts.Version)
+		if opts.RunID != "" {
+			logger.SetRunID(opts.RunID)
+		}
+	}
 
 	service := NewPipeline(pipeline, opts)
 
@@ -90,11 +122,36 @@ func (p *Pipeline) runPipeline(ctx context.Context, logger *eventlog.Logger) err
 	tree := treeview.NewBuilder(pipeline.Name)
 	root := tree.Root()
 
+	// Limit the whole run with the pipeline timeout
+	if pipeline.Timeout != "" {
+		timeout, err := time.ParseDuration(pipeline.Timeout)
+		if err != nil {
+			return fmt.Errorf("invalid pipeline timeout %q: %w", pipeline.Timeout, err)
+		}
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+		root.SetDeadline(time.Now().Add(timeout), timeout)
+	}
+
 	var display *treeview.Display
 	if silentOutput {
 		display = treeview.NewSilentDisplay()
+	} else if p.opts.Plain {
+		display = treeview.NewProgressDisplay(p.opts.Timestamps)
 	} else {
 		display = treeview.NewDisplayWithFinal(finalOnly)
+		display.StartSpinner(root)
+		if hasTimeouts(pipeline) {
+			display.StartRefresh(root)
+		}
+	}
+	defer display.Cleanup()
+	if p.opts.ProgressFile != nil {
+		display.SetProgressWriter(p.opts.ProgressFile)
+	}
+	if p.opts.Filter != nil {
+		display.SetFilter(p.opts.Filter)
 	}
 
 	pipelineCtx := &ExecutionContext{
@@ -110,9 +167,54 @@ func (p *Pipeline) runPipeline(ctx context.Context, logger *eventlog.Logger) err
 		JobNodes:     make(map[string]*treeview.TreeNode),
 		EventLogger:  logger,
 		jobTracker:   newJobTracker(),
+		failures:     &failureLog{},
+		budgets:      &budgetLog{enforce: p.opts.EnforceBudgets},
+		warnings:     &warningLog{},
+		changed:      &changedFiles{},
+		policy:       p.opts.Policy,
 		Progress:     p.opts.Progress,
 	}
 
+	hints, err := newHintClassifier(pipeline.Hints)
+	if err != nil {
+		return err
+	}
+	pipelineCtx.hints = hints
+
+	scrub, err := newScrubber(pipeline.Scrub)
+	if err != nil {
+		return err
+	}
+	pipelineCtx.scrub = scrub
+
+	sinks, err := newLogSinks(pipeline.Name, pipeline.LogSinks)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := sinks.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "%s %v\n", treeview.WarningHeader(), err)
+		}
+	}()
+	pipelineCtx.sinks = sinks
+
+	runID := cmp.Or(p.opts.RunID, logger.GetRunID())
+	if runID == "" {
+		runID = eventlog.NewRunID(time.Now())
+	}
+	setRunVars(pipelineCtx, runID, time.Now(), p.opts.Version)
+	if p.opts.AuditLog != nil {
+		pipelineCtx.audit = &auditLog{log: p.opts.AuditLog, runID: runID}
+	}
+
+	if p.opts.CaptureDir != "" {
+		captureDir, err := filepath.Abs(filepath.Join(p.opts.CaptureDir, runID))
+		if err != nil {
+			return fmt.Errorf("failed to resolve capture dir: %w", err)
+		}
+		pipelineCtx.CaptureDir = captureDir
+	}
+
 	// Copy environment variables from OS
 	for _, env := range os.Environ() {
 		k, v := parseEnv(env)
@@ -121,6 +223,14 @@ func (p *Pipeline) runPipeline(ctx context.Context, logger *eventlog.Logger) err
 		}
 	}
 
+	// Steps name reports and artifacts after the run
+	pipelineCtx.Env[RunIDEnv] = runID
+
+	// Steps attach files to the run by writing them to the capture dir
+	if pipelineCtx.CaptureDir != "" {
+		pipelineCtx.Env[ArtifactsEnv] = pipelineCtx.CaptureDir
+	}
+
 	// Evaluate pipeline-level working directory BEFORE merging variables,
 	// so that $(command) interpolation in vars runs from the correct directory.
 	if pipeline.Dir != "" {
@@ -136,6 +246,14 @@ func (p *Pipeline) runPipeline(ctx context.Context, logger *eventlog.Logger) err
 		pipelineCtx.Dir = dir
 	}
 
+	// Verify pinned tool versions before anything runs
+	if err := verifyTools(ctx, pipelineCtx, pipeline.Tools, p.opts.InstallTools); err != nil {
+		if !silentOutput {
+			fmt.Printf("%s %s\n", treeview.ErrorHeader(), err)
+		}
+		return err
+	}
+
 	if err := MergeVariables(pipelineCtx, pipeline.Decl); err != nil {
 		return err
 	}
@@ -153,13 +271,13 @@ func (p *Pipeline) runPipeline(ctx context.Context, logger *eventlog.Logger) err
 			if errors.As(err, &noDefaultErr) {
 				if !silentOutput {
 					fmt.Fprintf(os.Stderr, "%s Available jobs for this project:\n", colors.BrightYellow("atkins:"))
-					printAvailableJobs(noDefaultErr.Jobs, pipeline.ID)
+					printAvailableJobs(pipeline)
 					fmt.Fprintf(os.Stderr, "%s Job %q does not exist\n", colors.BrightRed("atkins:"), "default")
 				}
 				return err
 			}
 			if !silentOutput {
-				fmt.Printf("%s %s\n", colors.BrightRed("ERROR:"), err)
+				fmt.Printf("%s %s\n", treeview.ErrorHeader(), err)
 			}
 			return err
 		}
@@ -172,6 +290,21 @@ func (p *Pipeline) runPipeline(ctx context.Context, logger *eventlog.Logger) err
 		}
 	}
 
+	// Group jobs by stage, and run and display them in stage order
+	stages, err := stageGroups(pipeline, jobOrder)
+	if err != nil {
+		if !silentOutput {
+			fmt.Printf("%s %s\n", treeview.ErrorHeader(), err)
+		}
+		return err
+	}
+	if stages != nil {
+		jobOrder = jobOrder[:0]
+		for _, stage := range stages {
+			jobOrder = append(jobOrder, stage.Jobs...)
+		}
+	}
+
 	// Build ancestor map: for each dependency job, track which parent chain caused it.
 	// e.g. if "default" depends_on "fmt", depAncestors["fmt"] = ["default"].
 	depAncestors := buildDepAncestors(allJobs, jobs)
@@ -249,15 +382,27 @@ func (p *Pipeline) runPipeline(ctx context.Context, logger *eventlog.Logger) err
 	for _, jobName := range jobOrder {
 		if err := findInvokedJobs(jobName, ""); err != nil {
 			if !silentOutput {
-				fmt.Printf("%s %s\n", colors.BrightRed("ERROR:"), err)
+				fmt.Printf("%s %s\n", treeview.ErrorHeader(), err)
 			}
 			return err
 		}
 	}
 
+	// Services start with the first job using them and stop after the last
+	// scheduled one. Jobs invoked as tasks don't hold them.
+	if len(pipeline.Services) > 0 {
+		pipelineCtx.services = newServiceRegistry(pipelineCtx.Copy(), pipeline.Services)
+		for _, jobName := range jobOrder {
+			if job := allJobs[jobName]; job != nil {
+				pipelineCtx.services.expect(job.Services)
+			}
+		}
+		defer pipelineCtx.services.stopAll()
+	}
+
 	// Create job nodes for all jobs that might be invoked
 	// Only add root-level jobs to the tree display; nested jobs are added when invoked as tasks
-	jobsToCreateSorted := treeview.SortByOrder(jobsToCreate, jobOrder)
+	jobsToCreateSorted := treeview.SortByOrder(jobsToCreate, append(slices.Clone(jobOrder), pipeline.JobNames()...))
 	for _, jobName := range jobsToCreateSorted {
 		// Look up job from current pipeline or cross-pipeline jobs
 		job := allJobs[jobName]
@@ -279,9 +424,9 @@ func (p *Pipeline) runPipeline(ctx context.Context, logger *eventlog.Logger) err
 		if job.Desc != "" {
 			descInterpolated, err := InterpolateString(job.Desc, pipelineCtx)
 			if err == nil {
-				jobLabel = displayName + " - " + descInterpolated
+				jobLabel = displayName + " - " + pipelineCtx.scrub.scrub(descInterpolated)
 			} else {
-				jobLabel = displayName + " - " + job.Desc
+				jobLabel = displayName + " - " + pipelineCtx.scrub.scrub(job.Desc)
 			}
 		}
 
@@ -304,9 +449,10 @@ func (p *Pipeline) runPipeline(ctx context.Context, logger *eventlog.Logger) err
 		if isRootJob {
 			jobNode := tree.AddJobWithoutSteps(deps, jobLabel, job.Nested)
 			jobNode.SetSummarize(job.Summarize)
+			jobNode.SetStage(job.Stage)
 
 			if !isSimpleTask {
-				buildAndAddStepsToJob(jobNode, steps)
+				buildAndAddStepsToJob(jobNode, steps, pipelineCtx.scrub)
 			}
 
 			jobNodes[jobName] = jobNode
@@ -316,7 +462,7 @@ func (p *Pipeline) runPipeline(ctx context.Context, logger *eventlog.Logger) err
 			jobNode.SetSummarize(job.Summarize)
 
 			if !isSimpleTask {
-				buildAndAddStepsToJob(&treeview.TreeNode{Node: jobNode}, steps)
+				buildAndAddStepsToJob(&treeview.TreeNode{Node: jobNode}, steps, pipelineCtx.scrub)
 			}
 
 			jobNodes[jobName] = &treeview.TreeNode{Node: jobNode}
@@ -378,7 +524,42 @@ func (p *Pipeline) runPipeline(ctx context.Context, logger *eventlog.Logger) err
 	pipelineCtx.JobNodes = jobNodes
 	display.Render(root)
 
+	// Completed jobs and steps are checkpointed, so a run that didn't
+	// finish resumes from the checkpoint with --resume
+	var passed bool
+	if p.opts.CheckpointDir != "" {
+		path := eventlog.CheckpointPath(p.opts.CheckpointDir, cmp.Or(pipeline.ID, pipeline.Name))
+		var resume *eventlog.Checkpoint
+		if p.opts.Resume {
+			resume, err = loadResume(path, jobs)
+			if err != nil {
+				if !silentOutput {
+					fmt.Printf("%s %s\n", treeview.ErrorHeader(), err)
+				}
+				return err
+			}
+			if resume == nil && !silentOutput {
+				fmt.Fprintf(os.Stderr, "%s --resume: no checkpoint of pipeline %q, running all steps\n", treeview.WarningHeader(), pipeline.Name)
+			}
+		}
+		pipelineCtx.checkpoint = newCheckpoint(path, &eventlog.Checkpoint{
+			RunID:    runID,
+			Pipeline: pipeline.Name,
+			Jobs:     jobs,
+		}, resume)
+		defer func() {
+			if err := pipelineCtx.checkpoint.close(passed); err != nil {
+				fmt.Fprintf(os.Stderr, "%s failed to write checkpoint: %v\n", treeview.WarningHeader(), err)
+			}
+		}()
+	}
+
 	executor := NewExecutor()
+	executor.opts.Cache = p.opts.Cache
+	if !silentOutput {
+		executor.opts.OnFailure = p.opts.OnFailure
+		executor.opts.Step = p.opts.Step
+	}
 
 	// Helper to execute a job (with dependency checking)
 	executeJobWithDeps := func(jobName string, job *model.Job) error {
@@ -422,6 +603,7 @@ func (p *Pipeline) runPipeline(ctx context.Context, logger *eventlog.Logger) err
 		display.Render(root)
 
 		execErr := executor.ExecuteJob(ctx, jobCtx)
+		pipelineCtx.services.done(job.Services)
 
 		// Calculate job duration
 		jobDuration := time.Since(jobStartTime)
@@ -498,10 +680,53 @@ func (p *Pipeline) runPipeline(ctx context.Context, logger *eventlog.Logger) err
 		return nil
 	}
 
+	// Helper to end the run on a failed job
+	failRun := func(err error) error {
+		root.SetStatus(treeview.StatusFailed)
+
+		// Clear the live tree and print final scrollable output
+		if !silentOutput {
+			display.RenderFinal(root)
+			printBudgetSummary(os.Stdout, pipelineCtx.budgets.list())
+			printFailureSummary(os.Stdout, pipelineCtx.failures.list())
+		}
+		printWarnings(os.Stderr, pipelineCtx.warnings.list())
+
+		// Write event log and metrics on failure
+		entry := writeEventLog(logger, root, err, p.opts.Jobs, p.opts.GanttFile, pipelineCtx.warnings.list())
+		if p.opts.MetricsFile != "" {
+			_ = writeMetrics(p.opts.MetricsFile, pipeline.Name, root, err)
+		}
+		if err := reportIssue(ctx, p.opts.IssueAfter, entry, pipelineCtx.failures.list(), err, pipelineCtx.scrub); err != nil {
+			fmt.Fprintf(os.Stderr, "%s %v\n", treeview.WarningHeader(), err)
+		}
+
+		return err
+	}
+
+	// Jobs of a stage run in parallel, the next stage starts when all
+	// of them completed. Without stages, jobs run in order.
+	sequential := jobOrder
+	if stages != nil {
+		sequential = nil
+	}
+	for _, stage := range stages {
+		stageGroup := new(errgroup.Group)
+		for _, name := range stage.Jobs {
+			job := allJobs[name]
+			stageGroup.Go(func() error {
+				return executeJobWithDeps(name, job)
+			})
+		}
+		if err := stageGroup.Wait(); err != nil {
+			return failRun(err)
+		}
+	}
+
 	eg := new(errgroup.Group)
 	detached := 0
 
-	for _, name := range jobOrder {
+	for _, name := range sequential {
 		job := allJobs[name]
 
 		if job == nil {
@@ -520,17 +745,7 @@ func (p *Pipeline) runPipeline(ctx context.Context, logger *eventlog.Logger) err
 		}
 
 		if err := executeJobWithDeps(name, job); err != nil {
-			root.SetStatus(treeview.StatusFailed)
-
-			// Clear the live tree and print final scrollable output
-			if !silentOutput {
-				display.RenderFinal(root)
-			}
-
-			// Write event log on failure
-			writeEventLog(logger, root, err)
-
-			return err
+			return failRun(err)
 		}
 	}
 
@@ -547,15 +762,27 @@ func (p *Pipeline) runPipeline(ctx context.Context, logger *eventlog.Logger) err
 	if runErr == nil {
 		// Mark pipeline as passed and render final tree
 		root.SetStatus(treeview.StatusPassed)
+		passed = true
 	}
 
 	// Clear the live tree and print final scrollable output
 	if !silentOutput {
 		display.RenderFinal(root)
+		printBudgetSummary(os.Stdout, pipelineCtx.budgets.list())
+		if runErr != nil {
+			printFailureSummary(os.Stdout, pipelineCtx.failures.list())
+		}
 	}
+	printWarnings(os.Stderr, pipelineCtx.warnings.list())
 
-	// Write event log
-	writeEventLog(logger, root, runErr)
+	// Write event log and metrics
+	entry := writeEventLog(logger, root, runErr, p.opts.Jobs, p.opts.GanttFile, pipelineCtx.warnings.list())
+	if p.opts.MetricsFile != "" {
+		_ = writeMetrics(p.opts.MetricsFile, pipeline.Name, root, runErr)
+	}
+	if err := reportIssue(ctx, p.opts.IssueAfter, entry, pipelineCtx.failures.list(), runErr, pipelineCtx.scrub); err != nil {
+		fmt.Fprintf(os.Stderr, "%s %v\n", treeview.WarningHeader(), err)
+	}
 
 	// Output JSON/YAML if requested
 	if silentOutput {
@@ -572,10 +799,11 @@ func (p *Pipeline) runPipeline(ctx context.Context, logger *eventlog.Logger) err
 	return runErr
 }
 
-// writeEventLog writes the final event log to the file.
-func writeEventLog(logger *eventlog.Logger, root *treeview.Node, runErr error) {
+// writeEventLog writes the final event log to the file and records the run in the run index.
+// It returns the run index entry, nil without a log file.
+func writeEventLog(logger *eventlog.Logger, root *treeview.Node, runErr error, jobs []string, ganttFile string, warnings []string) *eventlog.RunIndexEntry {
 	if logger == nil {
-		return
+		return nil
 	}
 
 	// Set root duration
@@ -602,9 +830,25 @@ func writeEventLog(logger *eventlog.Logger, root *treeview.Node, runErr error) {
 		Result:       result,
 		MemoryAlloc:  stats.MemoryAlloc,
 		Goroutines:   stats.Goroutines,
+		Warnings:     warnings,
 	}
 
 	_ = logger.Write(state, summary)
+	entry := logger.IndexEntry(jobs, summary)
+	_ = eventlog.AppendRunIndex(eventlog.RunIndexPath, entry)
+	if ganttFile != "" {
+		_ = writeGantt(ganttFile, logger.Log(state, summary))
+	}
+	return entry
+}
+
+// writeGantt writes a mermaid gantt chart of the run to a markdown file.
+func writeGantt(path string, log *eventlog.Log) error {
+	var buf bytes.Buffer
+	if err := eventlog.Gantt(&buf, log); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
 }
 
 // buildDepAncestors walks the depends_on graph for each requested job
@@ -645,8 +889,9 @@ func parseEnv(env string) (string, string) {
 }
 
 // printAvailableJobs prints available jobs in a format similar to task.
-func printAvailableJobs(jobs map[string]*model.Job, pipelineID string) {
-	names := treeview.SortJobsByDepth(slices.Collect(maps.Keys(jobs)))
+func printAvailableJobs(pipeline *model.Pipeline) {
+	jobs, pipelineID := pipeline.GetJobs(), pipeline.ID
+	names := treeview.SortByDepth(pipeline.JobNames())
 	maxLen := 0
 	for _, name := range names {
 		displayName := name
@@ -0,0 +1,278 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// PolicyFile is the command policy, relative to the project root and $HOME.
+var PolicyFile = filepath.Join(".atkins", "policy.yml")
+
+// PolicyStrict denies commands without an allow rule and capabilities
+// not granted to the skill.
+const PolicyStrict = "strict"
+
+// Policy capabilities, granted per skill.
+const (
+	CapabilityNetwork        = "network"
+	CapabilityPackageInstall = "package-install"
+)
+
+// commandStart matches the start of a command within a line, including
+// command substitutions and sudo.
+const commandStart = "(^|[\\s;&|(`])(sudo\\s+)?"
+
+// capabilityPatterns match the commands needing a capability.
+var capabilityPatterns = map[string]*regexp.Regexp{
+	CapabilityNetwork: regexp.MustCompile(commandStart +
+		`(curl|wget|ssh|scp|rsync|nc|ftp|git\s+(clone|fetch|pull|push|ls-remote)|go\s+(get|mod\s+download)|docker\s+(pull|push|login))\b`),
+	CapabilityPackageInstall: regexp.MustCompile(commandStart +
+		`(apt(-get)?\s+install|apk\s+add|(yum|dnf)\s+install|brew\s+install|(npm|pnpm|yarn)\s+(install|i|add|ci)|pip3?\s+install|gem\s+install|cargo\s+install|go\s+install)\b`),
+}
+
+// Policy allows and denies the interpolated commands of a run. Rules are
+// globs where `*` matches anything, or regular expressions with a `re:`
+// prefix. Scripts are parsed as shell: deny rules match each statement,
+// e.g. a pipeline, and each simple command within it, allow rules and
+// capabilities match the simple commands. Simple commands include those
+// in pipes, background jobs, subshells and `$(...)` substitutions.
+type Policy struct {
+	Allow        []string            `yaml:"allow,omitempty"`
+	Deny         []string            `yaml:"deny,omitempty"`
+	Capabilities map[string][]string `yaml:"capabilities,omitempty"` // Capabilities granted per skill ID, `main` for the project pipeline
+
+	Strict bool `yaml:"-"`
+
+	allow []*regexp.Regexp
+	deny  []*regexp.Regexp
+}
+
+// PolicyError is returned for a command denied by the policy.
+type PolicyError struct {
+	Command string
+	Reason  string
+}
+
+// Error returns the denied command with the reason.
+func (e *PolicyError) Error() string {
+	return fmt.Sprintf("policy: command %q %s", e.Command, e.Reason)
+}
+
+// LoadPolicy merges the rules of the policy files that exist. It returns
+// nil without any, unless strict, as there is nothing to enforce.
+func LoadPolicy(strict bool, paths ...string) (*Policy, error) {
+	policy := &Policy{Strict: strict, Capabilities: map[string][]string{}}
+	var found bool
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		found = true
+
+		var file Policy
+		if err := yaml.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		policy.Allow = append(policy.Allow, file.Allow...)
+		policy.Deny = append(policy.Deny, file.Deny...)
+		for skill, caps := range file.Capabilities {
+			for _, capability := range caps {
+				if _, ok := capabilityPatterns[capability]; !ok {
+					return nil, fmt.Errorf("failed to parse %s: skill %q: unknown capability %q", path, skill, capability)
+				}
+			}
+			policy.Capabilities[skill] = append(policy.Capabilities[skill], caps...)
+		}
+	}
+	if !found && !strict {
+		return nil, nil
+	}
+
+	var err error
+	if policy.allow, err = compileRules(policy.Allow); err != nil {
+		return nil, err
+	}
+	if policy.deny, err = compileRules(policy.Deny); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// compileRules compiles globs and `re:` prefixed regular expressions.
+func compileRules(rules []string) ([]*regexp.Regexp, error) {
+	result := make([]*regexp.Regexp, 0, len(rules))
+	for _, rule := range rules {
+		if expr, ok := strings.CutPrefix(rule, "re:"); ok {
+			re, err := regexp.Compile(expr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid policy rule %q: %w", rule, err)
+			}
+			result = append(result, re)
+			continue
+		}
+		glob := regexp.QuoteMeta(rule)
+		glob = strings.ReplaceAll(glob, `\*`, `.*`)
+		glob = strings.ReplaceAll(glob, `\?`, `.`)
+		result = append(result, regexp.MustCompile(`^`+glob+`$`))
+	}
+	return result, nil
+}
+
+// Check returns a *PolicyError if the command isn't allowed for the
+// skill, the main pipeline has an empty skill ID. A deny rule wins over
+// an allow rule. Skills without granted capabilities have them all,
+// unless the policy is strict.
+func (p *Policy) Check(skill, cmd string) error {
+	if p == nil {
+		return nil
+	}
+	if skill == "" {
+		skill = "main"
+	}
+	granted, listed := p.Capabilities[skill]
+	allowed := p.Strict || len(p.allow) > 0
+
+	commands, err := parsePolicyCommands(cmd)
+	if err != nil {
+		return &PolicyError{Command: cmd, Reason: fmt.Sprintf("can't be parsed: %v", err)}
+	}
+	for _, stmt := range commands.stmts {
+		if rule := matchRule(p.Deny, p.deny, stmt); rule != "" {
+			return &PolicyError{Command: stmt, Reason: fmt.Sprintf("is denied by rule %q", rule)}
+		}
+	}
+	for _, call := range commands.calls {
+		if rule := matchRule(p.Deny, p.deny, call.text); rule != "" {
+			return &PolicyError{Command: call.text, Reason: fmt.Sprintf("is denied by rule %q", rule)}
+		}
+		if call.dynamic && (allowed || listed) {
+			return &PolicyError{Command: call.text, Reason: "runs a command only known when it runs"}
+		}
+		if allowed && matchRule(p.Allow, p.allow, call.text) == "" {
+			return &PolicyError{Command: call.text, Reason: "doesn't match an allow rule"}
+		}
+		if !listed && !p.Strict {
+			continue
+		}
+		for _, capability := range []string{CapabilityNetwork, CapabilityPackageInstall} {
+			if capabilityPatterns[capability].MatchString(call.text) && !slices.Contains(granted, capability) {
+				return &PolicyError{Command: call.text, Reason: fmt.Sprintf("needs capability %q, not granted to skill %q", capability, skill)}
+			}
+		}
+	}
+	return nil
+}
+
+// matchRule returns the first rule matching cmd.
+func matchRule(rules []string, compiled []*regexp.Regexp, cmd string) string {
+	for i, re := range compiled {
+		if re.MatchString(cmd) {
+			return rules[i]
+		}
+	}
+	return ""
+}
+
+// policyCommands are the statements and simple commands of a script.
+type policyCommands struct {
+	stmts []string
+	calls []policyCall
+}
+
+// policyCall is a simple command, without its assignments and redirects.
+type policyCall struct {
+	text    string
+	dynamic bool // The command name is an expansion, e.g. `$tool run`
+}
+
+// parsePolicyCommands parses a script with a shell parser and returns its
+// statements and the simple commands within them, in any nesting.
+func parsePolicyCommands(script string) (*policyCommands, error) {
+	file, err := syntax.NewParser().Parse(strings.NewReader(script), "")
+	if err != nil {
+		return nil, err
+	}
+	source := func(from, to syntax.Pos) string {
+		return strings.TrimSpace(script[from.Offset():to.Offset()])
+	}
+
+	result := &policyCommands{}
+	syntax.Walk(file, func(node syntax.Node) bool {
+		switch n := node.(type) {
+		case *syntax.Stmt:
+			stmt := strings.TrimRight(source(n.Pos(), n.End()), " \t;&")
+			result.stmts = append(result.stmts, stmt)
+		case *syntax.CallExpr:
+			if len(n.Args) == 0 {
+				return true
+			}
+			// Quotes are removed from the name, so `"go" test` matches `go *`
+			name, literal := literalWord(n.Args[0])
+			if !literal {
+				name = source(n.Args[0].Pos(), n.Args[0].End())
+			}
+			text := name + script[n.Args[0].End().Offset():n.Args[len(n.Args)-1].End().Offset()]
+			result.calls = append(result.calls, policyCall{text: strings.TrimSpace(text), dynamic: !literal})
+		}
+		return true
+	})
+	return result, nil
+}
+
+// literalWord returns the value of a word without expansions, quoted or
+// not, and false for words with expansions.
+func literalWord(word *syntax.Word) (string, bool) {
+	var sb strings.Builder
+	for _, part := range word.Parts {
+		switch p := part.(type) {
+		case *syntax.Lit:
+			sb.WriteString(p.Value)
+		case *syntax.SglQuoted:
+			sb.WriteString(p.Value)
+		case *syntax.DblQuoted:
+			for _, inner := range p.Parts {
+				lit, ok := inner.(*syntax.Lit)
+				if !ok {
+					return "", false
+				}
+				sb.WriteString(lit.Value)
+			}
+		default:
+			return "", false
+		}
+	}
+	return sb.String(), true
+}
+
+// checkPolicy checks an interpolated command against the policy of the
+// run, for the skill defining the running job.
+func (e *ExecutionContext) checkPolicy(cmd string) error {
+	if e.policy == nil {
+		return nil
+	}
+	skill := ""
+	if e.Pipeline != nil {
+		skill = e.Pipeline.ID
+	}
+	if e.Job != nil {
+		for _, pipeline := range e.AllPipelines {
+			if job, ok := pipeline.GetJobs()[e.Job.Name]; ok && job == e.Job {
+				skill = pipeline.ID
+				break
+			}
+		}
+	}
+	return e.policy.Check(skill, cmd)
+}
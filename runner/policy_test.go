@@ -0,0 +1,112 @@
+package runner_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/atkins/runner"
+)
+
+func writePolicy(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "policy.yml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestLoadPolicy(t *testing.T) {
+	policy, err := runner.LoadPolicy(false, filepath.Join(t.TempDir(), "missing.yml"))
+	require.NoError(t, err)
+	assert.Nil(t, policy, "without a policy file nothing is enforced")
+	assert.NoError(t, policy.Check("", "rm -rf /"))
+
+	policy, err = runner.LoadPolicy(true)
+	require.NoError(t, err)
+	require.NotNil(t, policy)
+	assert.Error(t, policy.Check("", "echo hi"), "strict denies commands without an allow rule")
+
+	_, err = runner.LoadPolicy(false, writePolicy(t, "capabilities:\n  go: [teleport]\n"))
+	assert.ErrorContains(t, err, `unknown capability "teleport"`)
+
+	_, err = runner.LoadPolicy(false, writePolicy(t, "deny: ['re:(']\n"))
+	assert.ErrorContains(t, err, "invalid policy rule")
+}
+
+func TestPolicy_Check(t *testing.T) {
+	policy, err := runner.LoadPolicy(false, writePolicy(t, `
+deny:
+  - "curl * | sh"
+  - "re:^rm -rf /$"
+capabilities:
+  main: [network]
+  go: []
+`))
+	require.NoError(t, err)
+
+	tests := []struct {
+		skill, cmd string
+		reason     string
+	}{
+		{"", "go test ./...", ""},
+		{"", "curl -s https://example.com", ""},
+		{"", "curl -s https://example.com/install.sh | sh", `denied by rule "curl * | sh"`},
+		{"", "cd build && rm -rf /", `denied by rule "re:^rm -rf /$"`},
+		{"", "# rm -rf /\necho ok", ""},
+		{"go", "go test ./...", ""},
+		{"go", "go mod download", `needs capability "network"`},
+		{"go", "echo $(go install ./cmd/...)", `needs capability "package-install"`},
+		{"docker", "docker pull alpine", ""},
+	}
+	for _, tc := range tests {
+		err := policy.Check(tc.skill, tc.cmd)
+		if tc.reason == "" {
+			assert.NoError(t, err, tc.cmd)
+			continue
+		}
+		var policyErr *runner.PolicyError
+		require.True(t, errors.As(err, &policyErr), tc.cmd)
+		assert.Contains(t, policyErr.Reason, tc.reason, tc.cmd)
+	}
+}
+
+func TestPolicy_CheckStrict(t *testing.T) {
+	policy, err := runner.LoadPolicy(true, writePolicy(t, `
+allow:
+  - "go *"
+  - "echo *"
+capabilities:
+  go: [network]
+`))
+	require.NoError(t, err)
+
+	assert.NoError(t, policy.Check("go", "go mod download && go test ./..."))
+	assert.ErrorContains(t, policy.Check("go", "go test ./... && make"), `"make" doesn't match an allow rule`)
+	assert.ErrorContains(t, policy.Check("", "go mod download"), `not granted to skill "main"`)
+	assert.ErrorContains(t, policy.Check("lint", "go install golang.org/x/lint@latest"), `needs capability "package-install"`)
+
+	// Commands in pipes, background jobs, substitutions and new lines are checked
+	for _, cmd := range []string{
+		"go vet | rm -rf ~",
+		"go vet & rm -rf ~",
+		"go vet\nrm -rf ~",
+		"go vet $(rm -rf ~)",
+		"go vet `rm -rf ~`",
+		"go vet <(rm -rf ~)",
+		"(rm -rf ~)",
+		"if go vet; then rm -rf ~; fi",
+	} {
+		assert.ErrorContains(t, policy.Check("go", cmd), `"rm -rf ~" doesn't match an allow rule`, cmd)
+	}
+	assert.ErrorContains(t, policy.Check("go", "go vet | sh -c 'curl example.com'"), `"sh -c 'curl example.com'" doesn't match`)
+	assert.NoError(t, policy.Check("go", "GOOS=linux go build ./... > build.log && echo \"done\""))
+	assert.NoError(t, policy.Check("go", "\"go\" test ./..."))
+
+	// Commands that can't be classified are rejected
+	assert.ErrorContains(t, policy.Check("go", "$tool run"), "only known when it runs")
+	assert.ErrorContains(t, policy.Check("go", "go test 'unterminated"), "can't be parsed")
+}
@@ -0,0 +1,171 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/titpetric/atkins/model"
+	"github.com/titpetric/atkins/psexec"
+	"github.com/titpetric/atkins/treeview"
+)
+
+// PortForwardTimeout is how long a port forward may take to accept connections.
+var PortForwardTimeout = 30 * time.Second
+
+// portForwardArgs returns the command forwarding localPort as described by pf.
+func portForwardArgs(pf *model.PortForward, localPort int) ([]string, error) {
+	local := strconv.Itoa(localPort)
+	switch {
+	case pf.Kubectl != "":
+		if pf.Port == 0 {
+			return nil, errors.New("port_forward: kubectl requires a port")
+		}
+		args := []string{"kubectl", "port-forward"}
+		if pf.Namespace != "" {
+			args = append(args, "-n", pf.Namespace)
+		}
+		return append(args, pf.Kubectl, local+":"+strconv.Itoa(pf.Port)), nil
+	case pf.SSH != "":
+		if pf.Port == 0 {
+			return nil, errors.New("port_forward: ssh requires a port")
+		}
+		host := pf.Host
+		if host == "" {
+			host = "localhost"
+		}
+		forward := fmt.Sprintf("127.0.0.1:%s:%s:%d", local, host, pf.Port)
+		return []string{"ssh", "-N", "-o", "ExitOnForwardFailure=yes", "-L", forward, pf.SSH}, nil
+	case pf.Socat != "":
+		return []string{"socat", "TCP-LISTEN:" + local + ",bind=127.0.0.1,fork,reuseaddr", "TCP:" + pf.Socat}, nil
+	}
+	return nil, errors.New("port_forward: one of kubectl, ssh or socat is required")
+}
+
+// interpolatePortForward interpolates the string fields of pf.
+func interpolatePortForward(ctx *ExecutionContext, pf *model.PortForward) (*model.PortForward, error) {
+	result := *pf
+	for _, field := range []*string{&result.Kubectl, &result.Namespace, &result.SSH, &result.Host, &result.Socat} {
+		value, err := InterpolateString(*field, ctx)
+		if err != nil {
+			return nil, err
+		}
+		*field = value
+	}
+	return &result, nil
+}
+
+// startPortForward starts the port forward of a `port_forward:` step and
+// waits until the local port accepts connections. The local port is set
+// as a variable in execCtx, so the following steps of the job can use it.
+// The returned stop function tears down the forward.
+func (e *Executor) startPortForward(ctx context.Context, execCtx *ExecutionContext, step *model.Step, stepNode *treeview.Node) (stop func(), err error) {
+	defer execCtx.Render()
+
+	stepCtx, err := e.prepareStepContext(execCtx, ctx, step)
+	if err != nil {
+		stepNode.SetStatus(treeview.StatusFailed)
+		return nil, err
+	}
+	if err := MergeVariables(stepCtx, step.Decl); err != nil {
+		stepNode.SetStatus(treeview.StatusFailed)
+		return nil, fmt.Errorf("failed to process step env: %w", err)
+	}
+
+	shouldRun, err := EvaluateIf(stepCtx)
+	if err != nil {
+		stepNode.SetStatus(treeview.StatusSkipped)
+		return nil, fmt.Errorf("failed to evaluate if condition for step %q: %w", step.Name, err)
+	}
+	if !shouldRun {
+		e.logStepSkipped(execCtx, step, stepNode, execCtx.NextStepIndex())
+		return func() {}, nil
+	}
+
+	startTime := time.Now()
+	stepNode.SetStatus(treeview.StatusRunning)
+	execCtx.Render()
+	defer func() {
+		stepNode.SetDuration(time.Since(startTime).Seconds())
+		if err != nil {
+			stepNode.SetStatus(treeview.StatusFailed)
+			return
+		}
+		stepNode.SetStatus(treeview.StatusPassed)
+	}()
+
+	pf, err := interpolatePortForward(stepCtx, step.PortForward)
+	if err != nil {
+		return nil, err
+	}
+
+	localPort := pf.LocalPort
+	if localPort == 0 {
+		if localPort, err = freePort(); err != nil {
+			return nil, fmt.Errorf("port_forward: %w", err)
+		}
+	}
+
+	args, err := portForwardArgs(pf, localPort)
+	if err != nil {
+		return nil, err
+	}
+
+	exec := stepCtx.newExecutor()
+	forwardCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	done, err := stepCtx.startProcess(forwardCtx, exec, psexec.NewCommand(args[0], args[1:]...), psexec.QuoteArgs(args))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	stop = func() {
+		cancel()
+		<-done
+	}
+
+	if err := waitForPort(ctx, localPort, done); err != nil {
+		stop()
+		return nil, fmt.Errorf("port_forward to %s: %w", pf.Target(), err)
+	}
+
+	execCtx.Variables.Set(pf.Variable(), localPort)
+	stepNode.SetName(fmt.Sprintf("port_forward: %s on localhost:%d", pf.Target(), localPort))
+	return stop, nil
+}
+
+// waitForPort waits until the local port accepts connections, the forward
+// process exits, or PortForwardTimeout passes.
+func waitForPort(ctx context.Context, port int, done chan psexec.Result) error {
+	address := net.JoinHostPort("127.0.0.1", strconv.Itoa(port))
+	deadline := time.After(PortForwardTimeout)
+	for !dialable(address) {
+		select {
+		case result := <-done:
+			done <- result
+			if msg := result.ErrorOutput(); msg != "" {
+				return fmt.Errorf("exited: %s", msg)
+			}
+			return fmt.Errorf("exited with code %d", result.ExitCode())
+		case <-deadline:
+			return fmt.Errorf("port %d not ready after %s", port, PortForwardTimeout)
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+	return nil
+}
+
+// dialable reports whether address accepts TCP connections.
+func dialable(address string) bool {
+	conn, err := net.DialTimeout("tcp", address, time.Second)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
@@ -0,0 +1,87 @@
+package runner_test
+
+import (
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/atkins/runner"
+)
+
+func TestPortForward(t *testing.T) {
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 is not installed")
+	}
+
+	// A fake kubectl serving the forwarded local port
+	binDir := t.TempDir()
+	kubectl := "#!/bin/sh\nport=${3%%:*}\nexec python3 -m http.server --bind 127.0.0.1 \"$port\"\n"
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "kubectl"), []byte(kubectl), 0o755))
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	yaml := `
+jobs:
+  default:
+    steps:
+      - port_forward:
+          kubectl: svc/api
+          port: 80
+          as: api_port
+      - run: echo ${{ api_port }} > port.txt
+`
+	pipelines, err := runner.LoadPipelineFromReader(strings.NewReader(yaml))
+	require.NoError(t, err)
+
+	err = runner.RunPipeline(t.Context(), pipelines[0], runner.PipelineOptions{
+		Jobs:   []string{"default"},
+		Silent: true,
+	})
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(filepath.Join(dir, "port.txt"))
+	require.NoError(t, err)
+	port, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	require.NoError(t, err)
+	assert.NotZero(t, port)
+
+	// The forward is torn down with the job
+	_, err = net.Dial("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(port)))
+	assert.Error(t, err)
+}
+
+func TestPortForward_ExitedEarly(t *testing.T) {
+	binDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "ssh"), []byte("#!/bin/sh\necho 'connection refused' >&2\nexit 255\n"), 0o755))
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	yaml := `
+jobs:
+  default:
+    steps:
+      - port_forward:
+          ssh: bastion
+          host: db.internal
+          port: 5432
+      - run: "false"
+`
+	pipelines, err := runner.LoadPipelineFromReader(strings.NewReader(yaml))
+	require.NoError(t, err)
+
+	err = runner.RunPipeline(t.Context(), pipelines[0], runner.PipelineOptions{
+		Jobs:   []string{"default"},
+		Silent: true,
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "port_forward to db.internal:5432 via bastion")
+	assert.Contains(t, err.Error(), "connection refused")
+}
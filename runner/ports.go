@@ -0,0 +1,106 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/expr-lang/expr"
+
+	"github.com/titpetric/atkins/model"
+)
+
+// PortAuto allocates a free port for a `ports:` entry.
+const PortAuto = "auto"
+
+// portNamePattern matches port names usable as ${{ ports.<name> }}.
+var portNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// reservedPorts holds the ports handed out by this process, so jobs
+// running in parallel never get the same port.
+var reservedPorts = struct {
+	sync.Mutex
+	ports map[int]bool
+}{ports: map[int]bool{}}
+
+// freePortFunction returns a free TCP port, for ${{ free_port() }}.
+var freePortFunction = expr.Function("free_port", func(...any) (any, error) {
+	return freePort()
+}, new(func() int))
+
+// freePort asks the kernel for a free TCP port on the loopback interface.
+// The port is reserved for the lifetime of the process and won't be
+// returned again.
+func freePort() (int, error) {
+	reservedPorts.Lock()
+	defer reservedPorts.Unlock()
+
+	for range 100 {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			return 0, fmt.Errorf("failed to find a free port: %w", err)
+		}
+		port := listener.Addr().(*net.TCPAddr).Port
+		listener.Close()
+		if !reservedPorts.ports[port] {
+			reservedPorts.ports[port] = true
+			return port, nil
+		}
+	}
+	return 0, errors.New("failed to find a free port")
+}
+
+// validatePorts checks the names and values of a `ports:` block.
+func validatePorts(ports map[string]string) error {
+	for _, name := range slices.Sorted(maps.Keys(ports)) {
+		if !portNamePattern.MatchString(name) {
+			return fmt.Errorf("invalid port name %q, expected letters, digits and underscores", name)
+		}
+		value := ports[name]
+		if value == PortAuto {
+			continue
+		}
+		if port, err := strconv.Atoi(value); err != nil || port < 1 || port > 65535 {
+			return fmt.Errorf("port %q: invalid value %q, expected %q or a port number", name, value, PortAuto)
+		}
+	}
+	return nil
+}
+
+// allocatePorts resolves the `ports:` of the job, allocating free ports
+// for "auto". The ports are set as the ports variable, e.g. ${{ ports.db }},
+// and as the <NAME>_PORT environment variable, e.g. $DB_PORT.
+func allocatePorts(ctx *ExecutionContext, job *model.Job) error {
+	if len(job.Ports) == 0 {
+		return nil
+	}
+	if err := validatePorts(job.Ports); err != nil {
+		return fmt.Errorf("job %q: %w", job.Name, err)
+	}
+
+	ports := make(map[string]any, len(job.Ports))
+	for _, name := range slices.Sorted(maps.Keys(job.Ports)) {
+		port, err := strconv.Atoi(job.Ports[name])
+		if job.Ports[name] == PortAuto {
+			port, err = freePort()
+		}
+		if err != nil {
+			return fmt.Errorf("job %q: port %q: %w", job.Name, name, err)
+		}
+		ports[name] = port
+		ctx.Env[portEnvName(name)] = strconv.Itoa(port)
+	}
+	ctx.Variables.Set("ports", ports)
+	return nil
+}
+
+// portEnvName returns the environment variable of a port, e.g. DB_PORT.
+func portEnvName(name string) string {
+	return strings.ToUpper(name) + "_PORT"
+}
@@ -0,0 +1,83 @@
+package runner
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/atkins/model"
+)
+
+func TestFreePort(t *testing.T) {
+	seen := map[int]bool{}
+	for range 20 {
+		port, err := freePort()
+		require.NoError(t, err)
+		assert.Greater(t, port, 0)
+		assert.False(t, seen[port], "port %d returned twice", port)
+		seen[port] = true
+	}
+}
+
+func TestValidatePorts(t *testing.T) {
+	assert.NoError(t, validatePorts(map[string]string{"db": "auto", "http_api": "8080"}))
+	assert.ErrorContains(t, validatePorts(map[string]string{"db-main": "auto"}), `invalid port name "db-main"`)
+	assert.ErrorContains(t, validatePorts(map[string]string{"db": "random"}), `port "db": invalid value "random"`)
+	assert.ErrorContains(t, validatePorts(map[string]string{"db": "70000"}), `invalid value "70000"`)
+}
+
+func TestRunPipeline_Ports(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	pipelines, err := LoadPipelineFromReader(strings.NewReader(`
+jobs:
+  default:
+    ports:
+      db: auto
+      web: 8080
+    vars:
+      dsn: postgres://localhost:${{ ports.db }}/test
+    steps:
+      - run: echo "${{ dsn }} $DB_PORT ${{ ports.web }} $WEB_PORT" > ports
+      - run: echo ${{ free_port() }} > free
+`))
+	require.NoError(t, err)
+
+	err = RunPipeline(t.Context(), pipelines[0], PipelineOptions{
+		Jobs:   []string{"default"},
+		Silent: true,
+	})
+	require.NoError(t, err)
+
+	out, err := os.ReadFile("ports")
+	require.NoError(t, err)
+	fields := strings.Fields(string(out))
+	require.Len(t, fields, 4)
+	db := strings.TrimSuffix(strings.TrimPrefix(fields[0], "postgres://localhost:"), "/test")
+	assert.Equal(t, db, fields[1])
+	assert.Equal(t, []string{"8080", "8080"}, fields[2:])
+
+	free, err := os.ReadFile("free")
+	require.NoError(t, err)
+	port, err := strconv.Atoi(strings.TrimSpace(string(free)))
+	require.NoError(t, err)
+	assert.NotEqual(t, db, strconv.Itoa(port))
+}
+
+func TestLinter_Ports(t *testing.T) {
+	pipeline := &model.Pipeline{
+		Name: "test-pipeline",
+		Jobs: map[string]*model.Job{
+			"test": {Name: "test", Ports: map[string]string{"db": "any"}, Steps: []*model.Step{{Run: "go test ./..."}}},
+		},
+	}
+
+	errors := NewLinter(pipeline).Lint()
+	require.Len(t, errors, 1)
+	assert.Equal(t, "invalid port", errors[0].Issue)
+	assert.Contains(t, errors[0].Detail, `invalid value "any"`)
+}
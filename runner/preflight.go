@@ -0,0 +1,302 @@
+package runner
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/term"
+
+	"github.com/titpetric/atkins/colors"
+	"github.com/titpetric/atkins/model"
+	"github.com/titpetric/atkins/psexec"
+)
+
+// shellBuiltins are leading words that aren't looked up on PATH.
+var shellBuiltins = map[string]bool{
+	"!": true, ".": true, ":": true, "[": true, "[[": true,
+	"alias": true, "break": true, "case": true, "cd": true, "command": true,
+	"continue": true, "do": true, "done": true, "echo": true, "elif": true,
+	"else": true, "esac": true, "eval": true, "exec": true, "exit": true,
+	"export": true, "false": true, "fi": true, "for": true, "function": true,
+	"if": true, "local": true, "printf": true, "pwd": true, "read": true,
+	"return": true, "set": true, "shift": true, "source": true, "test": true,
+	"then": true, "time": true, "trap": true, "true": true, "type": true,
+	"ulimit": true, "umask": true, "unset": true, "until": true, "wait": true,
+	"while": true,
+}
+
+// leadingExecutables returns the executables each command segment of cmd
+// starts with. Multi-line scripts, segments starting with a `NAME=value`
+// assignment, which may change PATH, and words that can't be resolved
+// statically (interpolations, substitutions, quoted or relative paths)
+// are skipped.
+func leadingExecutables(cmd string) []string {
+	cmd = strings.TrimSpace(cmd)
+	if cmd == "" || strings.Contains(cmd, "\n") {
+		return nil
+	}
+
+	replacer := strings.NewReplacer("&&", "\n", "||", "\n", ";", "\n", "|", "\n")
+	var result []string
+	for _, segment := range strings.Split(replacer.Replace(cmd), "\n") {
+		fields := strings.Fields(segment)
+		if len(fields) == 0 || isEnvAssignment(fields[0]) {
+			continue
+		}
+		if word := fields[0]; !shellBuiltins[word] && !strings.ContainsAny(word, "$`'\"(){}<>/=\\*?") {
+			result = append(result, word)
+		}
+	}
+	return result
+}
+
+// isEnvAssignment returns true for `NAME=value` prefixes.
+func isEnvAssignment(word string) bool {
+	name, _, ok := strings.Cut(word, "=")
+	if !ok || name == "" {
+		return false
+	}
+	for _, ch := range name {
+		if ch != '_' && (ch < 'A' || ch > 'Z') && (ch < 'a' || ch > 'z') && (ch < '0' || ch > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// stepExecutables returns the executables a step runs, without duplicates.
+func stepExecutables(step *model.Step) []string {
+	commands := step.Cmds
+	if step.Run != "" {
+		commands = append([]string{step.Run}, commands...)
+	}
+	if step.Cmd != "" {
+		commands = append([]string{step.Cmd}, commands...)
+	}
+
+	var result []string
+	for _, cmd := range commands {
+		for _, name := range leadingExecutables(cmd) {
+			result = appendUnique(result, name)
+		}
+	}
+	if step.PortForward != nil {
+		if args, err := portForwardArgs(step.PortForward, 0); err == nil {
+			result = appendUnique(result, args[0])
+		}
+	}
+	if step.Lint != nil {
+		for _, name := range step.Lint.Tools {
+			if tool, ok := lintTools[name]; ok {
+				result = appendUnique(result, strings.Fields(tool.Command)[0])
+			}
+		}
+	}
+	return result
+}
+
+// checkJobExecutables returns an error listing the leading executables of
+// the job steps that aren't on PATH, with install hints from `tools:`.
+// Steps with an `if:` are skipped as they may not run, as are jobs that
+// set `preflight: false`. Earlier steps may also install the executable,
+// so the result is a warning, not a failure.
+func checkJobExecutables(ctx *ExecutionContext, job *model.Job) error {
+	if job.Preflight != nil && !*job.Preflight {
+		return nil
+	}
+
+	missing := &missingRequirements{}
+	for idx, step := range job.Children() {
+		if step == nil || !step.If.IsEmpty() {
+			continue
+		}
+		missing.addCommands(ctx, stepExecutables(step), " ("+stepRequirementLabel(step, idx)+")")
+	}
+	if missing.empty() {
+		return nil
+	}
+
+	err := fmt.Sprintf("job '%s' runs commands not found on PATH: %s", job.Name, strings.Join(missing.commands, ", "))
+	for _, hint := range toolHints(ctx, missing.names) {
+		err += "\n  " + hint
+	}
+	return &MissingExecutablesError{Message: err, Commands: missing.names}
+}
+
+// MissingExecutablesError lists the commands of a job not found on PATH.
+type MissingExecutablesError struct {
+	Message  string
+	Commands []string
+}
+
+func (e *MissingExecutablesError) Error() string {
+	return e.Message
+}
+
+// preflight warns about the step executables of the job missing from
+// PATH, once per job before its iterations run, after offering to run
+// the `install:` jobs of their `tools:`. The warning is printed after
+// the tree.
+func (e *Executor) preflight(ctx context.Context, execCtx *ExecutionContext, job *model.Job) {
+	if _, checked := e.preflighted.LoadOrStore(job, true); checked {
+		return
+	}
+	var missing *MissingExecutablesError
+	if !errors.As(checkJobExecutables(execCtx, job), &missing) {
+		return
+	}
+	if e.offerInstall(ctx, execCtx, installJobs(execCtx, missing.Commands)) {
+		if !errors.As(checkJobExecutables(execCtx, job), &missing) {
+			return
+		}
+	}
+	execCtx.warnings.add("%s", missing.Message)
+}
+
+// offerInstall asks to run each of the install jobs as `atkins <job>`,
+// once per run, and returns true if one ran. Without a terminal it
+// doesn't ask.
+func (e *Executor) offerInstall(ctx context.Context, execCtx *ExecutionContext, jobs []string) bool {
+	if len(jobs) == 0 || !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return false
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return false
+	}
+
+	e.ttyMu.Lock()
+	defer e.ttyMu.Unlock()
+	if e.stdin == nil {
+		e.stdin = bufio.NewReader(os.Stdin)
+	}
+	if e.installOffered == nil {
+		e.installOffered = make(map[string]bool)
+	}
+
+	var ran bool
+	execCtx.Display.Suspend(func() {
+		for _, job := range jobs {
+			if e.installOffered[job] {
+				continue
+			}
+			e.installOffered[job] = true
+
+			args := append(mainPipelineArgs(execCtx), job)
+			fmt.Printf("%s missing commands, run `atkins %s` to install them? [y/N]: ", colors.BrightYellow("?"), strings.Join(args, " "))
+			line, err := e.stdin.ReadString('\n')
+			if err != nil {
+				return
+			}
+			if answer := strings.ToLower(strings.TrimSpace(line)); answer != "y" && answer != "yes" {
+				continue
+			}
+
+			// Run from the working directory of atkins, which finds the same skills
+			executor := execCtx.newExecutor()
+			process := &psexec.Command{Name: self, Args: args, Interactive: true}
+			process.Dir, _ = os.Getwd()
+			result, err := execCtx.runProcess(ctx, executor, process, psexec.QuoteArgs(append([]string{"atkins"}, args...)))
+			if err == nil {
+				err = result.Err()
+			}
+			if err != nil {
+				execCtx.warnings.add("atkins %s: %v", job, err)
+			}
+			ran = true
+			fmt.Println()
+		}
+	})
+	return ran
+}
+
+// mainPipelineArgs returns the --file argument of the main pipeline,
+// unless it's embedded.
+func mainPipelineArgs(ctx *ExecutionContext) []string {
+	for _, pipeline := range append([]*model.Pipeline{ctx.Pipeline}, ctx.AllPipelines...) {
+		if pipeline != nil && pipeline.ID == "" && pipeline.Source != "" && !strings.HasPrefix(pipeline.Source, "embedded:") {
+			return []string{"--file", pipeline.Source}
+		}
+	}
+	return nil
+}
+
+// lookPath reports whether name resolves to an executable on the PATH
+// from env, falling back to the process PATH.
+func lookPath(name string, env Env) bool {
+	path, ok := env["PATH"]
+	if !ok {
+		_, err := exec.LookPath(name)
+		return err == nil
+	}
+	for _, dir := range filepath.SplitList(path) {
+		if dir == "" {
+			dir = "."
+		}
+		info, err := os.Stat(filepath.Join(dir, name))
+		if err == nil && !info.IsDir() && info.Mode()&0o111 != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// toolHints returns install hints for missing commands, taken from the
+// `tools:` declared in the current pipeline and loaded skills.
+func toolHints(ctx *ExecutionContext, commands []string) []string {
+	pipelines := append([]*model.Pipeline{ctx.Pipeline}, ctx.AllPipelines...)
+
+	var hints []string
+	for _, name := range commands {
+		for _, pipeline := range pipelines {
+			if pipeline == nil || pipeline.Tools[name] == nil {
+				continue
+			}
+			tool := pipeline.Tools[name]
+			switch {
+			case tool.Install != "":
+				hints = append(hints, name+": run `atkins "+installJob(pipeline, tool)+"` to install")
+			case tool.Hint != "":
+				hints = append(hints, name+": "+tool.Hint)
+			default:
+				continue
+			}
+			break
+		}
+	}
+	sort.Strings(hints)
+	return hints
+}
+
+// installJobs returns the `install:` jobs of the `tools:` declaring the
+// commands, without duplicates.
+func installJobs(ctx *ExecutionContext, commands []string) []string {
+	pipelines := append([]*model.Pipeline{ctx.Pipeline}, ctx.AllPipelines...)
+
+	var jobs []string
+	for _, name := range commands {
+		for _, pipeline := range pipelines {
+			if pipeline == nil || pipeline.Tools[name] == nil || pipeline.Tools[name].Install == "" {
+				continue
+			}
+			jobs = appendUnique(jobs, installJob(pipeline, pipeline.Tools[name]))
+			break
+		}
+	}
+	return jobs
+}
+
+// installJob returns the name of the job installing the tool.
+func installJob(pipeline *model.Pipeline, tool *model.Tool) string {
+	if pipeline.ID != "" {
+		return pipeline.ID + ":" + tool.Install
+	}
+	return tool.Install
+}
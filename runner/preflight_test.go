@@ -0,0 +1,150 @@
+package runner
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/titpetric/atkins/eventlog"
+	"github.com/titpetric/atkins/model"
+)
+
+func TestLeadingExecutables(t *testing.T) {
+	tests := []struct {
+		cmd  string
+		want []string
+	}{
+		{"go test ./...", []string{"go"}},
+		{"cd web && npm ci && npm run build", []string{"npm", "npm"}},
+		{"CGO_ENABLED=0 go build ./cmd/app", nil},
+		{"PATH=$PWD/bin:$PATH mytool && go vet", []string{"go"}},
+		{"cat go.mod | grep module", []string{"cat", "grep"}},
+		{"echo hello; true", nil},
+		{"./bin/app --help", nil},
+		{"${{ tool }} run", nil},
+		{"$(which go) version", nil},
+		{"if true; then\n  make\nfi", nil},
+		{"", nil},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, leadingExecutables(tt.cmd), tt.cmd)
+	}
+}
+
+func TestCheckJobExecutables(t *testing.T) {
+	pipeline := &model.Pipeline{
+		ID: "lint",
+		Tools: model.Tools{
+			"atkins-missing-linter": {Hint: "go install example.com/linter@latest"},
+			"atkins-missing-fmt":    {Install: "install"},
+		},
+	}
+	job := &model.Job{
+		Name: "check",
+		Steps: []*model.Step{
+			{Run: "atkins-missing-linter run ./..."},
+			{Cmds: []string{"sh -c true", "atkins-missing-fmt -w ."}},
+		},
+	}
+	ctx := &ExecutionContext{
+		Variables:    NewContextVariables(nil),
+		Env:          Env{"PATH": "/usr/bin:/bin"},
+		AllPipelines: []*model.Pipeline{pipeline},
+	}
+
+	err := checkJobExecutables(ctx, job)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not found on PATH: atkins-missing-linter (step 0), atkins-missing-fmt (step 1)")
+	assert.Contains(t, err.Error(), "atkins-missing-fmt: run `atkins lint:install` to install")
+	assert.Contains(t, err.Error(), "atkins-missing-linter: go install example.com/linter@latest")
+}
+
+func TestCheckJobExecutables_Skipped(t *testing.T) {
+	ctx := &ExecutionContext{
+		Variables: NewContextVariables(nil),
+		Env:       Env{"PATH": "/usr/bin:/bin"},
+	}
+
+	t.Run("conditional step", func(t *testing.T) {
+		job := &model.Job{
+			Name: "setup",
+			Steps: []*model.Step{
+				{If: model.Conditionals{"false"}, Run: "brew-not-here install foo"},
+			},
+		}
+		assert.NoError(t, checkJobExecutables(ctx, job))
+		assert.NoError(t, ValidateJobRequirements(ctx, job))
+	})
+
+	t.Run("preflight disabled", func(t *testing.T) {
+		disabled := false
+		job := &model.Job{
+			Name:      "setup",
+			Preflight: &disabled,
+			Steps:     []*model.Step{{Run: "brew-not-here install foo"}},
+		}
+		assert.NoError(t, checkJobExecutables(ctx, job))
+	})
+
+	t.Run("explicit requires", func(t *testing.T) {
+		job := &model.Job{
+			Name:     "setup",
+			Requires: model.Requirements{Commands: []string{"brew-not-here"}},
+		}
+		err := ValidateJobRequirements(ctx, job)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "commands: brew-not-here")
+	})
+}
+
+func TestRunPipeline_InstalledByEarlierStep(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	pipelines, err := LoadPipelineFromReader(strings.NewReader(`
+jobs:
+  default:
+    steps:
+      - mkdir -p bin && printf '#!/bin/sh\nprintf installed\n' > bin/mytool && chmod +x bin/mytool
+      - PATH=$PWD/bin:$PATH mytool
+      - atkins-not-installed-yet --version || true
+`))
+	require.NoError(t, err)
+
+	err = RunPipeline(t.Context(), pipelines[0], PipelineOptions{Jobs: []string{"default"}, Silent: true})
+	assert.NoError(t, err)
+}
+
+func TestRunPipeline_MissingExecutablesWarnedOnce(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	pipelines, err := LoadPipelineFromReader(strings.NewReader(`
+jobs:
+  default:
+    for: n in [1, 2, 3]
+    steps:
+      - atkins-not-installed-yet --version || true
+      - for: m in [1, 2]
+        task: check
+  check:
+    steps:
+      - atkins-not-installed-either --version || true
+`))
+	require.NoError(t, err)
+
+	err = RunPipeline(t.Context(), pipelines[0], PipelineOptions{Jobs: []string{"default"}, Silent: true, LogFile: "log.yml"})
+	require.NoError(t, err)
+
+	data, err := os.ReadFile("log.yml")
+	require.NoError(t, err)
+	var log eventlog.Log
+	require.NoError(t, yaml.Unmarshal(data, &log))
+	require.NotNil(t, log.Summary)
+	require.Len(t, log.Summary.Warnings, 2, "one warning per job, not per iteration")
+	assert.Contains(t, log.Summary.Warnings[0], "job 'default' runs commands not found on PATH: atkins-not-installed-yet")
+	assert.Contains(t, log.Summary.Warnings[1], "job 'check' runs commands not found on PATH: atkins-not-installed-either")
+}
@@ -0,0 +1,38 @@
+package runner
+
+import (
+	"fmt"
+
+	"github.com/titpetric/atkins/model"
+	"github.com/titpetric/atkins/psexec"
+)
+
+// stepPriority returns the scheduling priority of the step commands, or
+// nil when the step doesn't set `priority:`.
+func stepPriority(step *model.Step) (*psexec.Priority, error) {
+	if step.Priority == nil {
+		return nil, nil
+	}
+
+	p := step.Priority
+	if p.Nice < -20 || p.Nice > 19 {
+		return nil, fmt.Errorf("priority: nice level %d out of range -20 to 19", p.Nice)
+	}
+	priority := &psexec.Priority{Nice: p.Nice}
+
+	if p.IONice != "" {
+		class, level, err := psexec.ParseIOClass(p.IONice)
+		if err != nil {
+			return nil, fmt.Errorf("priority: %w", err)
+		}
+		priority.IOClass, priority.IOLevel = class, level
+	}
+	if p.CPUSet != "" {
+		cpus, err := psexec.ParseCPUSet(p.CPUSet)
+		if err != nil {
+			return nil, fmt.Errorf("priority: %w", err)
+		}
+		priority.CPUs = cpus
+	}
+	return priority, nil
+}
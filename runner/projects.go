@@ -0,0 +1,97 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// ProjectsFile is the monorepo project registry, relative to the repository root.
+var ProjectsFile = filepath.Join(".atkins", "projects.yml")
+
+// Project is a named subdirectory of a monorepo with its own pipeline.
+type Project struct {
+	Name string
+	Dir  string // Absolute path of the project folder
+}
+
+// LoadProjects reads a project registry mapping project names to folders
+// relative to root. Projects are returned in the order of the file.
+func LoadProjects(root string) ([]*Project, error) {
+	path := filepath.Join(root, ProjectsFile)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if len(node.Content) == 0 {
+		return nil, nil
+	}
+	mapping := node.Content[0]
+	if mapping.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("failed to parse %s: expected a map of project names to folders", path)
+	}
+
+	projects := make([]*Project, 0, len(mapping.Content)/2)
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		name, value := mapping.Content[i].Value, mapping.Content[i+1]
+		if value.Kind != yaml.ScalarNode || value.Value == "" {
+			return nil, fmt.Errorf("failed to parse %s: project %q: expected a folder", path, name)
+		}
+		dir := value.Value
+		if filepath.IsAbs(dir) {
+			return nil, fmt.Errorf("failed to parse %s: project %q: folder must be relative", path, name)
+		}
+		projects = append(projects, &Project{Name: name, Dir: filepath.Join(root, dir)})
+	}
+	return projects, nil
+}
+
+// DiscoverProjects searches for the project registry starting from the
+// given directory and traversing parent directories. It returns the
+// repository root and its projects, or an empty root without a registry.
+func DiscoverProjects(startDir string) (root string, projects []*Project, err error) {
+	dir, err := filepath.Abs(startDir)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get absolute path: %w", err)
+	}
+
+	for {
+		projects, err := LoadProjects(dir)
+		if err == nil {
+			return dir, projects, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return "", nil, err
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil, nil
+		}
+		dir = parent
+	}
+}
+
+// FindProject returns the project with the given name.
+func FindProject(projects []*Project, name string) (*Project, error) {
+	names := make([]string, 0, len(projects))
+	for _, project := range projects {
+		if project.Name == name {
+			return project, nil
+		}
+		names = append(names, project.Name)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("unknown project %q, no %s found", name, ProjectsFile)
+	}
+	return nil, fmt.Errorf("unknown project %q, expected one of: %s", name, strings.Join(names, ", "))
+}
@@ -0,0 +1,62 @@
+package runner_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/atkins/runner"
+)
+
+func TestDiscoverProjects(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, ".atkins"), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "services", "api"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, runner.ProjectsFile), []byte("web: web\napi: services/api\n"), 0o644))
+
+	found, projects, err := runner.DiscoverProjects(filepath.Join(root, "services", "api"))
+	require.NoError(t, err)
+	assert.Equal(t, root, found)
+	require.Len(t, projects, 2)
+	assert.Equal(t, "web", projects[0].Name)
+	assert.Equal(t, filepath.Join(root, "web"), projects[0].Dir)
+	assert.Equal(t, "api", projects[1].Name)
+	assert.Equal(t, filepath.Join(root, "services", "api"), projects[1].Dir)
+
+	project, err := runner.FindProject(projects, "api")
+	require.NoError(t, err)
+	assert.Equal(t, projects[1], project)
+
+	_, err = runner.FindProject(projects, "db")
+	assert.ErrorContains(t, err, "expected one of: web, api")
+}
+
+func TestDiscoverProjects_NoRegistry(t *testing.T) {
+	root, projects, err := runner.DiscoverProjects(t.TempDir())
+	require.NoError(t, err)
+	assert.Empty(t, root)
+	assert.Empty(t, projects)
+
+	_, err = runner.FindProject(projects, "api")
+	assert.ErrorContains(t, err, "no .atkins/projects.yml found")
+}
+
+func TestLoadProjects_Invalid(t *testing.T) {
+	for name, data := range map[string]string{
+		"list":     "- api\n",
+		"absolute": "api: /srv/api\n",
+		"map":      "api: {dir: api}\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			root := t.TempDir()
+			require.NoError(t, os.MkdirAll(filepath.Join(root, ".atkins"), 0o755))
+			require.NoError(t, os.WriteFile(filepath.Join(root, runner.ProjectsFile), []byte(data), 0o644))
+
+			_, err := runner.LoadProjects(root)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,246 @@
+package runner
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/expr-lang/expr"
+)
+
+// ReleaseBumpEnv names the env var forcing the version bump of
+// release_version() to major, minor or patch. Without it, the bump
+// is derived from the conventional commits since the latest tag.
+const ReleaseBumpEnv = "RELEASE_BUMP"
+
+// semverTagPattern matches release tags like v1.2.3 or 1.2.3.
+var semverTagPattern = regexp.MustCompile(`^(v?)(\d+)\.(\d+)\.(\d+)$`)
+
+// conventionalCommitPattern matches a conventional commit subject,
+// e.g. `feat(runner)!: drop cmds`.
+var conventionalCommitPattern = regexp.MustCompile(`^(\w+)(\([^)]*\))?(!)?: (.+)$`)
+
+// releaseCommit is a commit since the previous release.
+type releaseCommit struct {
+	Subject string
+	Body    string
+}
+
+// releaseFunctions returns the release helpers available in ${{ }} expressions.
+func releaseFunctions(ctx *ExecutionContext) []expr.Option {
+	return []expr.Option{
+		expr.Function("release_previous", func(params ...any) (any, error) {
+			previous, _, err := releaseTags(conditionDir(ctx))
+			return previous, err
+		}, new(func() string)),
+		expr.Function("release_version", func(params ...any) (any, error) {
+			return releaseVersion(conditionDir(ctx), ctx.Env[ReleaseBumpEnv])
+		}, new(func() string)),
+		expr.Function("release_changelog", func(params ...any) (any, error) {
+			return releaseChangelog(conditionDir(ctx))
+		}, new(func() string)),
+	}
+}
+
+// releaseTags returns the latest release tag before HEAD, and the
+// release tag of HEAD, if HEAD is tagged.
+func releaseTags(dir string) (previous, head string, err error) {
+	out, err := gitOutput(dir, "tag", "--merged", "HEAD")
+	if err != nil {
+		return "", "", err
+	}
+	headOut, err := gitOutput(dir, "tag", "--points-at", "HEAD")
+	if err != nil {
+		return "", "", err
+	}
+	atHead := strings.Fields(headOut)
+
+	var tags []string
+	for _, tag := range strings.Fields(out) {
+		if semverTagPattern.MatchString(tag) {
+			tags = append(tags, tag)
+		}
+	}
+	slices.SortFunc(tags, compareSemver)
+
+	for i := len(tags) - 1; i >= 0; i-- {
+		if slices.Contains(atHead, tags[i]) {
+			if head == "" {
+				head = tags[i]
+			}
+			continue
+		}
+		return tags[i], head, nil
+	}
+	return "", head, nil
+}
+
+// releaseVersion returns the release tag of HEAD if it's tagged,
+// otherwise the previous release bumped according to the commits since.
+func releaseVersion(dir, bump string) (string, error) {
+	previous, head, err := releaseTags(dir)
+	if err != nil {
+		return "", err
+	}
+	if head != "" {
+		return head, nil
+	}
+
+	if bump == "" {
+		commits, err := releaseCommits(dir, previous)
+		if err != nil {
+			return "", err
+		}
+		bump = commitBump(commits)
+	}
+	if previous == "" {
+		previous = "v0.0.0"
+	}
+	return bumpVersion(previous, bump)
+}
+
+// bumpVersion increments the major, minor or patch part of a semver tag.
+func bumpVersion(version, bump string) (string, error) {
+	match := semverTagPattern.FindStringSubmatch(version)
+	if match == nil {
+		return "", fmt.Errorf("%q is not a semver version", version)
+	}
+	major, _ := strconv.Atoi(match[2])
+	minor, _ := strconv.Atoi(match[3])
+	patch, _ := strconv.Atoi(match[4])
+
+	switch bump {
+	case "major":
+		major, minor, patch = major+1, 0, 0
+	case "minor":
+		minor, patch = minor+1, 0
+	case "patch":
+		patch++
+	default:
+		return "", fmt.Errorf("unknown version bump %q, expected major, minor or patch", bump)
+	}
+	return fmt.Sprintf("%s%d.%d.%d", match[1], major, minor, patch), nil
+}
+
+// compareSemver orders semver tags by version.
+func compareSemver(a, b string) int {
+	ma, mb := semverTagPattern.FindStringSubmatch(a), semverTagPattern.FindStringSubmatch(b)
+	for i := 2; i <= 4; i++ {
+		na, _ := strconv.Atoi(ma[i])
+		nb, _ := strconv.Atoi(mb[i])
+		if na != nb {
+			return na - nb
+		}
+	}
+	return strings.Compare(a, b)
+}
+
+// releaseCommits returns the commits since the previous release tag,
+// or all commits when there's no previous release.
+func releaseCommits(dir, previous string) ([]releaseCommit, error) {
+	args := []string{"log", "--format=%s%x1f%b%x1e"}
+	if previous != "" {
+		args = append(args, previous+"..HEAD")
+	}
+	out, err := gitOutput(dir, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []releaseCommit
+	for _, record := range strings.Split(out, "\x1e") {
+		subject, body, _ := strings.Cut(strings.TrimSpace(record), "\x1f")
+		if subject == "" {
+			continue
+		}
+		commits = append(commits, releaseCommit{Subject: subject, Body: strings.TrimSpace(body)})
+	}
+	return commits, nil
+}
+
+// commitBump returns major for breaking changes, minor for features
+// and patch otherwise.
+func commitBump(commits []releaseCommit) string {
+	bump := "patch"
+	for _, commit := range commits {
+		switch commitKind(commit) {
+		case "breaking":
+			return "major"
+		case "feat":
+			bump = "minor"
+		}
+	}
+	return bump
+}
+
+// commitKind classifies a commit as breaking, feat, fix or other.
+func commitKind(commit releaseCommit) string {
+	if strings.Contains(commit.Body, "BREAKING CHANGE") {
+		return "breaking"
+	}
+	match := conventionalCommitPattern.FindStringSubmatch(commit.Subject)
+	switch {
+	case match == nil:
+		return "other"
+	case match[3] == "!":
+		return "breaking"
+	case match[1] == "feat" || match[1] == "fix":
+		return match[1]
+	}
+	return "other"
+}
+
+// releaseChangelog renders the commits of the release as markdown,
+// grouped by conventional commit type.
+func releaseChangelog(dir string) (string, error) {
+	previous, _, err := releaseTags(dir)
+	if err != nil {
+		return "", err
+	}
+	commits, err := releaseCommits(dir, previous)
+	if err != nil {
+		return "", err
+	}
+	return formatChangelog(commits), nil
+}
+
+// formatChangelog renders commits as markdown sections.
+func formatChangelog(commits []releaseCommit) string {
+	sections := []struct {
+		kind, title string
+	}{
+		{"breaking", "Breaking Changes"},
+		{"feat", "Features"},
+		{"fix", "Fixes"},
+		{"other", "Other Changes"},
+	}
+
+	var sb strings.Builder
+	for _, section := range sections {
+		var items []string
+		for _, commit := range commits {
+			if commitKind(commit) != section.kind {
+				continue
+			}
+			subject := commit.Subject
+			if match := conventionalCommitPattern.FindStringSubmatch(subject); match != nil {
+				subject = match[4]
+				if scope := strings.Trim(match[2], "()"); scope != "" {
+					subject = "**" + scope + ":** " + subject
+				}
+			}
+			items = append(items, "- "+subject)
+		}
+		if len(items) == 0 {
+			continue
+		}
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString("### " + section.title + "\n\n")
+		sb.WriteString(strings.Join(items, "\n") + "\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,128 @@
+package runner
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBumpVersion(t *testing.T) {
+	for _, tc := range []struct{ version, bump, want string }{
+		{"v1.2.3", "patch", "v1.2.4"},
+		{"v1.2.3", "minor", "v1.3.0"},
+		{"1.2.3", "major", "2.0.0"},
+	} {
+		got, err := bumpVersion(tc.version, tc.bump)
+		require.NoError(t, err)
+		assert.Equal(t, tc.want, got)
+	}
+
+	_, err := bumpVersion("v1.2", "patch")
+	require.Error(t, err)
+	_, err = bumpVersion("v1.2.3", "huge")
+	require.Error(t, err)
+}
+
+func TestCommitBump(t *testing.T) {
+	assert.Equal(t, "patch", commitBump([]releaseCommit{{Subject: "fix: typo"}, {Subject: "update readme"}}))
+	assert.Equal(t, "minor", commitBump([]releaseCommit{{Subject: "fix: typo"}, {Subject: "feat(cli): add flag"}}))
+	assert.Equal(t, "major", commitBump([]releaseCommit{{Subject: "feat!: drop cmds"}}))
+	assert.Equal(t, "major", commitBump([]releaseCommit{{Subject: "refactor: loader", Body: "BREAKING CHANGE: skills move"}}))
+}
+
+func TestFormatChangelog(t *testing.T) {
+	changelog := formatChangelog([]releaseCommit{
+		{Subject: "feat(cli): add --dry-run"},
+		{Subject: "fix: handle empty files"},
+		{Subject: "update readme"},
+		{Subject: "feat!: drop tasks"},
+	})
+
+	assert.Equal(t, `### Breaking Changes
+
+- drop tasks
+
+### Features
+
+- **cli:** add --dry-run
+
+### Fixes
+
+- handle empty files
+
+### Other Changes
+
+- update readme
+`, changelog)
+}
+
+func TestReleaseVersion(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	dir := t.TempDir()
+	t.Chdir(dir)
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=atkins", "-c", "user.email=atkins@example.com"}, args...)...)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	commit := func(message string) {
+		git("commit", "-q", "--allow-empty", "-m", message)
+	}
+
+	git("init", "-q")
+	commit("initial")
+	version, err := releaseVersion(dir, "")
+	require.NoError(t, err)
+	assert.Equal(t, "v0.0.1", version, "first release without tags")
+
+	git("tag", "v1.2.3")
+	commit("fix: handle empty files")
+	commit("feat(cli): add --dry-run")
+
+	version, err = releaseVersion(dir, "")
+	require.NoError(t, err)
+	assert.Equal(t, "v1.3.0", version)
+
+	version, err = releaseVersion(dir, "patch")
+	require.NoError(t, err)
+	assert.Equal(t, "v1.2.4", version)
+
+	// Once HEAD is tagged, the tag is the release version
+	git("tag", "v1.3.0")
+	previous, head, err := releaseTags(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "v1.2.3", previous)
+	assert.Equal(t, "v1.3.0", head)
+
+	pipelines, err := LoadPipelineFromReader(strings.NewReader(`
+vars:
+  release:
+    version: ${{ release_version() }}
+    changelog: ${{ release_changelog() }}
+jobs:
+  default:
+    steps:
+      - run: echo "${{ release.version }}" > version.txt
+      - run: |
+          cat > notes.md <<'EOF'
+          ${{ release.changelog }}
+          EOF
+`))
+	require.NoError(t, err)
+	require.NoError(t, RunPipeline(t.Context(), pipelines[0], PipelineOptions{Jobs: []string{"default"}, Silent: true, AllPipelines: pipelines}))
+
+	out, err := os.ReadFile("version.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "v1.3.0\n", string(out))
+
+	notes, err := os.ReadFile("notes.md")
+	require.NoError(t, err)
+	assert.Contains(t, string(notes), "### Features\n\n- **cli:** add --dry-run")
+}
@@ -0,0 +1,254 @@
+package runner
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// PipelineCacheDir is the cache of remote pipelines, relative to $HOME.
+var PipelineCacheDir = filepath.Join(".atkins", "cache", "pipelines")
+
+// commitPattern matches a full git commit hash, a ref that can't move.
+var commitPattern = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// RemotePipeline is a pipeline file fetched from a URL or a git repository:
+//
+//   - https://example.com/pipeline.yml
+//   - git::github.com/org/repo//ci/atkins.yml@v1
+//
+// A `?checksum=sha256:<hex>` suffix pins the content of the file.
+type RemotePipeline struct {
+	URL      string // URL of the file, or of the git repository
+	Path     string // Path of the file within the git repository
+	Ref      string // Git branch, tag or commit, the default branch if empty
+	Checksum string // Expected sha256 of the file, hex encoded
+	Git      bool
+}
+
+// IsRemotePipeline returns true if ref points to a remote pipeline.
+func IsRemotePipeline(ref string) bool {
+	return strings.HasPrefix(ref, "http://") || strings.HasPrefix(ref, "https://") || strings.HasPrefix(ref, "git::")
+}
+
+// ParseRemotePipeline parses a remote pipeline reference.
+func ParseRemotePipeline(ref string) (*RemotePipeline, error) {
+	result := &RemotePipeline{}
+	if base, query, ok := strings.Cut(ref, "?checksum="); ok {
+		sum, ok := strings.CutPrefix(query, "sha256:")
+		if _, err := hex.DecodeString(sum); !ok || err != nil || len(sum) != sha256.Size*2 {
+			return nil, fmt.Errorf("invalid checksum %q, expected sha256:<hex>", query)
+		}
+		ref, result.Checksum = base, strings.ToLower(sum)
+	}
+
+	rest, ok := strings.CutPrefix(ref, "git::")
+	if !ok {
+		if !IsRemotePipeline(ref) {
+			return nil, fmt.Errorf("unsupported pipeline reference %q", ref)
+		}
+		result.URL = ref
+		return result, nil
+	}
+	result.Git = true
+
+	scheme := "https://"
+	if i := strings.Index(rest, "://"); i >= 0 {
+		scheme, rest = rest[:i+3], rest[i+3:]
+	} else if strings.HasPrefix(rest, "git@") {
+		scheme = ""
+	}
+	repo, path, ok := strings.Cut(rest, "//")
+	if !ok || repo == "" || path == "" {
+		return nil, fmt.Errorf("invalid git pipeline reference %q, expected git::host/org/repo//path/to/file.yml[@ref]", ref)
+	}
+	if i := strings.LastIndex(path, "@"); i >= 0 {
+		path, result.Ref = path[:i], path[i+1:]
+	}
+	if !filepath.IsLocal(filepath.FromSlash(path)) {
+		return nil, fmt.Errorf("invalid git pipeline reference %q, the path must stay within the repository", ref)
+	}
+	result.URL = scheme + repo
+	result.Path = path
+	return result, nil
+}
+
+// String returns the reference without the checksum.
+func (r *RemotePipeline) String() string {
+	if !r.Git {
+		return r.URL
+	}
+	result := "git::" + r.URL + "//" + r.Path
+	if r.Ref != "" {
+		result += "@" + r.Ref
+	}
+	return result
+}
+
+// fileName returns the name of the file in the URL, so the pipeline name
+// defaults to it as it would for a local file.
+func (r *RemotePipeline) fileName() string {
+	if u, err := url.Parse(r.URL); err == nil {
+		if name := path.Base(u.Path); name != "." && name != "/" {
+			return name
+		}
+	}
+	return "pipeline.yml"
+}
+
+// pinned returns true if the fetched content can't change, so the cache
+// can be used without fetching again.
+func (r *RemotePipeline) pinned() bool {
+	return r.Checksum != "" || (r.Git && commitPattern.MatchString(r.Ref))
+}
+
+// PipelineCache fetches remote pipelines into a cache directory.
+type PipelineCache struct {
+	Dir    string
+	Client *http.Client
+}
+
+// NewPipelineCache creates a pipeline cache in dir.
+func NewPipelineCache(dir string) *PipelineCache {
+	return &PipelineCache{Dir: dir, Client: http.DefaultClient}
+}
+
+// Fetch returns the path of the cached pipeline file for ref. Pinned
+// references are fetched once, others on each call, falling back to the
+// cached copy when fetching fails. The fallback returns the path along
+// with a *CachedCopyError for the caller to warn about. The checksum is
+// verified either way.
+func (c *PipelineCache) Fetch(ctx context.Context, ref string) (string, error) {
+	remote, err := ParseRemotePipeline(ref)
+	if err != nil {
+		return "", err
+	}
+
+	key := sha256.Sum256([]byte(remote.String()))
+	dir := filepath.Join(c.Dir, hex.EncodeToString(key[:8]))
+	file := filepath.Join(dir, remote.fileName())
+	if remote.Git {
+		file = filepath.Join(dir, "repo", filepath.FromSlash(remote.Path))
+	}
+
+	if _, err := os.Stat(file); err == nil && remote.pinned() {
+		if err := remote.verify(file); err == nil {
+			return file, nil
+		}
+	}
+
+	if remote.Git {
+		err = c.fetchGit(ctx, remote, filepath.Join(dir, "repo"))
+	} else {
+		err = c.fetchURL(ctx, remote, file)
+	}
+	var fetchErr error
+	if err != nil {
+		if _, statErr := os.Stat(file); statErr != nil {
+			return "", fmt.Errorf("failed to fetch %s: %w", remote, err)
+		}
+		fetchErr = &CachedCopyError{Ref: remote.String(), Err: err}
+	}
+
+	if err := remote.verify(file); err != nil {
+		return "", err
+	}
+	return file, fetchErr
+}
+
+// CachedCopyError is returned by Fetch along with the path of the cached
+// copy, when fetching the pipeline failed.
+type CachedCopyError struct {
+	Ref string
+	Err error
+}
+
+func (e *CachedCopyError) Error() string {
+	return fmt.Sprintf("failed to fetch %s, using the cached copy: %v", e.Ref, e.Err)
+}
+
+func (e *CachedCopyError) Unwrap() error {
+	return e.Err
+}
+
+// verify checks the file against the pinned checksum.
+func (r *RemotePipeline) verify(path string) error {
+	if r.Checksum == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(data)
+	if got := hex.EncodeToString(sum[:]); got != r.Checksum {
+		return fmt.Errorf("checksum mismatch for %s: got sha256:%s, expected sha256:%s", r, got, r.Checksum)
+	}
+	return nil
+}
+
+// fetchURL downloads the file, replacing the cached copy once complete.
+func (c *PipelineCache) fetchURL(ctx context.Context, remote *RemotePipeline, path string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, remote.URL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".pipeline-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, resp.Body); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// fetchGit checks out the ref of the repository into dir, with a shallow
+// fetch reusing the existing checkout.
+func (c *PipelineCache) fetchGit(ctx context.Context, remote *RemotePipeline, dir string) error {
+	if _, err := os.Stat(filepath.Join(dir, ".git")); errors.Is(err, os.ErrNotExist) {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+		if _, err := gitOutputContext(ctx, dir, "init", "--quiet"); err != nil {
+			return err
+		}
+	}
+
+	ref := remote.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	if _, err := gitOutputContext(ctx, dir, "fetch", "--quiet", "--depth", "1", remote.URL, ref); err != nil {
+		return err
+	}
+	_, err := gitOutputContext(ctx, dir, "checkout", "--quiet", "--force", "FETCH_HEAD")
+	return err
+}
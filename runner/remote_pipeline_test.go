@@ -0,0 +1,140 @@
+package runner_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/atkins/runner"
+)
+
+func TestParseRemotePipeline(t *testing.T) {
+	sum := sha256.Sum256([]byte("jobs: {}"))
+	checksum := hex.EncodeToString(sum[:])
+
+	tests := []struct {
+		ref  string
+		want runner.RemotePipeline
+	}{
+		{"https://example.com/ci/pipeline.yml", runner.RemotePipeline{URL: "https://example.com/ci/pipeline.yml"}},
+		{"https://example.com/pipeline.yml?checksum=sha256:" + checksum, runner.RemotePipeline{URL: "https://example.com/pipeline.yml", Checksum: checksum}},
+		{"git::github.com/org/repo//ci/atkins.yml@v1", runner.RemotePipeline{URL: "https://github.com/org/repo", Path: "ci/atkins.yml", Ref: "v1", Git: true}},
+		{"git::github.com/org/repo//atkins.yml", runner.RemotePipeline{URL: "https://github.com/org/repo", Path: "atkins.yml", Git: true}},
+		{"git::file:///srv/repo//atkins.yml@main", runner.RemotePipeline{URL: "file:///srv/repo", Path: "atkins.yml", Ref: "main", Git: true}},
+		{"git::git@github.com:org/repo//atkins.yml@v2", runner.RemotePipeline{URL: "git@github.com:org/repo", Path: "atkins.yml", Ref: "v2", Git: true}},
+	}
+	for _, tc := range tests {
+		got, err := runner.ParseRemotePipeline(tc.ref)
+		require.NoError(t, err, tc.ref)
+		assert.Equal(t, tc.want, *got, tc.ref)
+	}
+
+	_, err := runner.ParseRemotePipeline("git::github.com/org/repo")
+	assert.ErrorContains(t, err, "invalid git pipeline reference")
+	for _, ref := range []string{
+		"git::github.com/org/repo//../../outside.yml",
+		"git::github.com/org/repo///etc/passwd",
+		"git::github.com/org/repo//ci/../../outside.yml@v1",
+	} {
+		_, err = runner.ParseRemotePipeline(ref)
+		assert.ErrorContains(t, err, "must stay within the repository", ref)
+	}
+	_, err = runner.ParseRemotePipeline("https://example.com/pipeline.yml?checksum=md5:abc")
+	assert.ErrorContains(t, err, "invalid checksum")
+}
+
+func TestPipelineCache_FetchURL(t *testing.T) {
+	content := "name: shared\njobs:\n  default: echo hi\n"
+	served := content
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if served == "" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(served))
+	}))
+	defer server.Close()
+
+	cache := runner.NewPipelineCache(t.TempDir())
+	ref := server.URL + "/ci/shared.yml"
+
+	file, err := cache.Fetch(t.Context(), ref)
+	require.NoError(t, err)
+	assert.Equal(t, "shared.yml", filepath.Base(file))
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, content, string(data))
+
+	// Unpinned references are fetched again, falling back to the cache
+	served = ""
+	cached, err := cache.Fetch(t.Context(), ref)
+	var cachedErr *runner.CachedCopyError
+	require.ErrorAs(t, err, &cachedErr)
+	assert.Equal(t, file, cached)
+	assert.Equal(t, 2, requests)
+
+	// Pinned references are served from the cache
+	sum := sha256.Sum256([]byte(content))
+	pinned := ref + "?checksum=sha256:" + hex.EncodeToString(sum[:])
+	_, err = cache.Fetch(t.Context(), pinned)
+	require.NoError(t, err)
+	assert.Equal(t, 2, requests)
+
+	served = "jobs: {}\n"
+	_, err = cache.Fetch(t.Context(), server.URL+"/other.yml?checksum=sha256:"+hex.EncodeToString(sum[:]))
+	assert.ErrorContains(t, err, "checksum mismatch")
+}
+
+func TestPipelineCache_FetchGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	repo := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = repo
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	git("init", "--quiet")
+	require.NoError(t, os.MkdirAll(filepath.Join(repo, "ci"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "ci", "atkins.yml"), []byte("jobs:\n  default: echo v1\n"), 0o644))
+	git("add", ".")
+	git("commit", "--quiet", "-m", "v1")
+	git("tag", "v1")
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "ci", "atkins.yml"), []byte("jobs:\n  default: echo v2\n"), 0o644))
+	git("commit", "--quiet", "-am", "v2")
+
+	cache := runner.NewPipelineCache(t.TempDir())
+
+	file, err := cache.Fetch(t.Context(), "git::file://"+repo+"//ci/atkins.yml@v1")
+	require.NoError(t, err)
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "echo v1")
+
+	file, err = cache.Fetch(t.Context(), "git::file://"+repo+"//ci/atkins.yml")
+	require.NoError(t, err)
+	data, err = os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "echo v2")
+
+	// A cancelled fetch falls back to the cached copy
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	_, err = cache.Fetch(ctx, "git::file://"+repo+"//ci/atkins.yml")
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,144 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/titpetric/atkins/model"
+)
+
+// missingRequirements collects unmet requirements for a job, grouped by kind.
+type missingRequirements struct {
+	vars     []string
+	env      []string
+	commands []string
+	names    []string // missing command names, for install hints
+}
+
+func (m *missingRequirements) empty() bool {
+	return len(m.vars) == 0 && len(m.env) == 0 && len(m.commands) == 0
+}
+
+func (m *missingRequirements) String() string {
+	var parts []string
+	if len(m.vars) > 0 {
+		parts = append(parts, fmt.Sprintf("variables: %s", strings.Join(m.vars, ", ")))
+	}
+	if len(m.env) > 0 {
+		parts = append(parts, fmt.Sprintf("env: %s", strings.Join(m.env, ", ")))
+	}
+	if len(m.commands) > 0 {
+		parts = append(parts, fmt.Sprintf("commands: %s", strings.Join(m.commands, ", ")))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// add records the requirements from req that are not satisfied by ctx.
+// Variable and env names present in declared are treated as satisfied,
+// which covers vars and env set on the step itself.
+func (m *missingRequirements) add(ctx *ExecutionContext, req model.Requirements, declared map[string]bool, label string) {
+	suffix := ""
+	if label != "" {
+		suffix = " (" + label + ")"
+	}
+	for _, name := range req.Vars {
+		if declared[name] || ctx.Variables.Get(name) != nil {
+			continue
+		}
+		m.vars = appendUnique(m.vars, name+suffix)
+	}
+	for _, name := range req.Env {
+		if declared[name] {
+			continue
+		}
+		if _, ok := ctx.Env[name]; ok {
+			continue
+		}
+		m.env = appendUnique(m.env, name+suffix)
+	}
+	m.addCommands(ctx, req.Commands, suffix)
+}
+
+// addCommands records the commands that can't be found on PATH.
+func (m *missingRequirements) addCommands(ctx *ExecutionContext, commands []string, suffix string) {
+	for _, name := range commands {
+		if lookPath(name, ctx.Env) {
+			continue
+		}
+		m.commands = appendUnique(m.commands, name+suffix)
+		m.names = appendUnique(m.names, name)
+	}
+}
+
+// ValidateJobRequirements checks the job's `requires:` and the `requires:`
+// of every step in the job before any step runs. All missing variables,
+// env vars and commands are reported in a single error, together with
+// install hints from `tools:` declarations. Step executables missing
+// from PATH are only reported as a warning, see Executor.preflight.
+func ValidateJobRequirements(ctx *ExecutionContext, job *model.Job) error {
+	missing := &missingRequirements{}
+	missing.add(ctx, job.Requires, nil, "")
+
+	for idx, step := range job.Children() {
+		if step == nil {
+			continue
+		}
+		label := stepRequirementLabel(step, idx)
+		if step.Requires.IsEmpty() {
+			continue
+		}
+		req := step.Requires
+		if !step.For.IsEmpty() {
+			// Loop variables are only known per iteration.
+			req.Vars = nil
+		}
+		missing.add(ctx, req, stepDeclaredNames(step), label)
+	}
+
+	if missing.empty() {
+		return nil
+	}
+	err := fmt.Sprintf("job '%s' has unmet requirements, missing %s", job.Name, missing)
+	for _, hint := range toolHints(ctx, missing.names) {
+		err += "\n  " + hint
+	}
+	return errors.New(err)
+}
+
+// stepDeclaredNames returns the variable and env names declared on the step.
+func stepDeclaredNames(step *model.Step) map[string]bool {
+	names := map[string]bool{}
+	if step.Decl == nil {
+		return names
+	}
+	for k := range step.Vars {
+		names[k] = true
+	}
+	if step.Env != nil {
+		for k := range step.Env.Vars {
+			names[k] = true
+		}
+	}
+	return names
+}
+
+// stepRequirementLabel names the step in requirement errors.
+func stepRequirementLabel(step *model.Step, idx int) string {
+	switch {
+	case step.ID != "":
+		return "step " + step.ID
+	case step.Name != "":
+		return "step " + step.Name
+	}
+	return fmt.Sprintf("step %d", idx)
+}
+
+func appendUnique(list []string, value string) []string {
+	for _, v := range list {
+		if v == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
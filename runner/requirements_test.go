@@ -0,0 +1,65 @@
+package runner_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/atkins/model"
+	"github.com/titpetric/atkins/runner"
+)
+
+func TestValidateJobRequirements_Aggregated(t *testing.T) {
+	job := &model.Job{
+		Name: "deploy",
+		Requires: model.Requirements{
+			Vars: []string{"service"},
+			Env:  []string{"DEPLOY_TOKEN"},
+		},
+		Steps: []*model.Step{
+			{ID: "push", Run: "true", Requires: model.Requirements{
+				Commands: []string{"atkins-missing-command"},
+			}},
+			{Run: "true", Requires: model.Requirements{
+				Env: []string{"STEP_TOKEN"},
+			}},
+		},
+	}
+	ctx := &runner.ExecutionContext{
+		Variables: runner.NewContextVariables(nil),
+		Env:       runner.Env{},
+	}
+
+	err := runner.ValidateJobRequirements(ctx, job)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "variables: service")
+	assert.Contains(t, err.Error(), "env: DEPLOY_TOKEN, STEP_TOKEN (step 1)")
+	assert.Contains(t, err.Error(), "commands: atkins-missing-command (step push)")
+}
+
+func TestValidateJobRequirements_StepDeclarations(t *testing.T) {
+	job := &model.Job{
+		Name: "build",
+		Steps: []*model.Step{
+			{
+				Decl: &model.Decl{
+					Vars: map[string]any{"target": "linux"},
+					Env:  &model.EnvDecl{Vars: map[string]any{"GOOS": "linux"}},
+				},
+				Run: "true",
+				Requires: model.Requirements{
+					Vars:     []string{"target"},
+					Env:      []string{"GOOS", "HOME"},
+					Commands: []string{"sh"},
+				},
+			},
+		},
+	}
+	ctx := &runner.ExecutionContext{
+		Variables: runner.NewContextVariables(nil),
+		Env:       runner.Env{"HOME": "/root"},
+	}
+
+	assert.NoError(t, runner.ValidateJobRequirements(ctx, job))
+}
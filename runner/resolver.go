@@ -83,18 +83,10 @@ func (r *Resolver) seedContext(ctx *ExecutionContext) {
 func (r *Resolver) buildOrder() ([]string, error) {
 	deps := make(map[string][]string)
 	for k, v := range r.vars {
-		if strVal, ok := v.(string); ok {
-			deps[nodePrefixVar+k] = extractUnifiedDependencies(strVal, r.vars, r.envVars)
-		} else {
-			deps[nodePrefixVar+k] = nil
-		}
+		deps[nodePrefixVar+k] = extractUnifiedDependencies(valueText(v), r.vars, r.envVars)
 	}
 	for k, v := range r.envVars {
-		if strVal, ok := v.(string); ok {
-			deps[nodePrefixEnv+k] = extractUnifiedDependencies(strVal, r.vars, r.envVars)
-		} else {
-			deps[nodePrefixEnv+k] = nil
-		}
+		deps[nodePrefixEnv+k] = extractUnifiedDependencies(valueText(v), r.vars, r.envVars)
 	}
 	return topologicalSort(deps)
 }
@@ -109,7 +101,7 @@ func (r *Resolver) resolve(order []string) (map[string]any, map[string]string, e
 		switch {
 		case strings.HasPrefix(nodeID, nodePrefixVar):
 			k := strings.TrimPrefix(nodeID, nodePrefixVar)
-			v, err := r.resolveValue(r.vars[k])
+			v, err := resolveVar(r.workCtx, r.vars[k])
 			if err != nil {
 				return nil, nil, fmt.Errorf("error processing variables: failed to interpolate variable %q: %w", k, err)
 			}
@@ -131,13 +123,9 @@ func (r *Resolver) resolve(order []string) (map[string]any, map[string]string, e
 	return resolvedVars, resolvedEnv, nil
 }
 
-// resolveValue interpolates a single value if it's a string, otherwise passes it through.
+// resolveValue interpolates a single value, recursing into maps and slices.
 func (r *Resolver) resolveValue(v any) (any, error) {
-	strVal, ok := v.(string)
-	if !ok {
-		return v, nil
-	}
-	return InterpolateString(strVal, r.workCtx)
+	return InterpolateValue(r.workCtx, v)
 }
 
 // mergeInto runs the full resolution and writes results into ctx.
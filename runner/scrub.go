@@ -0,0 +1,95 @@
+package runner
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+
+	"github.com/titpetric/atkins/eventlog"
+	"github.com/titpetric/atkins/model"
+)
+
+// DefaultScrubReplacement replaces the matches of scrub rules that don't
+// set a replacement.
+const DefaultScrubReplacement = "***"
+
+// SecretPatterns match well known token formats. The linter warns about
+// matching values in the pipeline that no scrub rule replaces.
+var SecretPatterns = map[string]*regexp.Regexp{
+	"AWS access key": regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`),
+	"GitHub token":   regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`),
+	"JWT":            regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`),
+	"Slack token":    regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`),
+	"private key":    regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`),
+}
+
+// scrubRule is a compiled scrub rule.
+type scrubRule struct {
+	match   *regexp.Regexp
+	replace string
+}
+
+// scrubber replaces the matches of the scrub rules in output and logs,
+// in the order of the rules.
+type scrubber struct {
+	rules []scrubRule
+}
+
+// newScrubber compiles the scrub rules of the pipeline.
+func newScrubber(rules []*model.ScrubRule) (*scrubber, error) {
+	s := &scrubber{}
+	for _, rule := range rules {
+		compiled, err := compileScrubRule(rule)
+		if err != nil {
+			return nil, err
+		}
+		s.rules = append(s.rules, compiled)
+	}
+	return s, nil
+}
+
+// compileScrubRule compiles the pattern of a scrub rule.
+func compileScrubRule(rule *model.ScrubRule) (scrubRule, error) {
+	if rule == nil || rule.Match == "" {
+		return scrubRule{}, fmt.Errorf("scrub rule needs a match")
+	}
+	match, err := regexp.Compile(rule.Match)
+	if err != nil {
+		return scrubRule{}, fmt.Errorf("invalid scrub match %q: %w", rule.Match, err)
+	}
+	return scrubRule{match: match, replace: cmp.Or(rule.Replace, DefaultScrubReplacement)}, nil
+}
+
+// scrub returns text with the matches of the rules replaced.
+func (s *scrubber) scrub(text string) string {
+	if s == nil {
+		return text
+	}
+	for _, rule := range s.rules {
+		text = rule.match.ReplaceAllString(text, rule.replace)
+	}
+	return text
+}
+
+// scrubTrace scrubs the commands of the trace entries.
+func (s *scrubber) scrubTrace(entries []eventlog.TraceEntry) []eventlog.TraceEntry {
+	if s == nil || len(s.rules) == 0 {
+		return entries
+	}
+	for i := range entries {
+		entries[i].Command = s.scrub(entries[i].Command)
+	}
+	return entries
+}
+
+// scrubLines scrubs each of the lines.
+func (s *scrubber) scrubLines(lines []string) []string {
+	if s == nil || len(s.rules) == 0 {
+		return lines
+	}
+	result := make([]string, len(lines))
+	for i, line := range lines {
+		result[i] = s.scrub(line)
+	}
+	return result
+}
@@ -0,0 +1,251 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/titpetric/atkins/model"
+)
+
+// serviceRegistry starts pipeline services when the first job using them
+// runs, and stops each service once the last job using it has finished,
+// or was skipped.
+type serviceRegistry struct {
+	ctx      *ExecutionContext // Pipeline context services are started in
+	services model.Services
+
+	mu      sync.Mutex
+	users   map[string]int // Jobs scheduled in the run that haven't finished yet
+	running map[string]*runningService
+}
+
+// runningService is a started service and the jobs currently using it.
+type runningService struct {
+	start   sync.Once
+	err     error
+	stop    func()
+	cancels map[*jobServices]context.CancelCauseFunc
+}
+
+// jobServices identifies the services acquired by a single job.
+type jobServices struct{}
+
+func newServiceRegistry(ctx *ExecutionContext, services model.Services) *serviceRegistry {
+	return &serviceRegistry{
+		ctx:      ctx,
+		services: services,
+		users:    map[string]int{},
+		running:  map[string]*runningService{},
+	}
+}
+
+// expect records that a job scheduled in the run uses the services, so
+// they keep running between the jobs. Done must be called when the job
+// has finished, was skipped or failed.
+func (r *serviceRegistry) expect(names []string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, name := range names {
+		r.users[name]++
+	}
+}
+
+// done marks a scheduled job as finished with the services, stopping the
+// services no other job uses.
+func (r *serviceRegistry) done(names []string) {
+	if r == nil {
+		return
+	}
+	for _, name := range names {
+		r.mu.Lock()
+		r.users[name]--
+		r.mu.Unlock()
+		r.stopUnused(name)
+	}
+}
+
+// acquire starts the services the job uses, if not already running. The
+// returned context is cancelled with an *ExitedEarlyError when one of the
+// services exits while the job runs. Release must be called when the job
+// has finished.
+func (r *serviceRegistry) acquire(ctx context.Context, jobName string, names []string) (context.Context, func(), error) {
+	if len(names) == 0 {
+		return ctx, func() {}, nil
+	}
+	if r == nil {
+		return nil, nil, fmt.Errorf("job '%s' uses service '%s', but no services are defined", jobName, names[0])
+	}
+
+	jobCtx, cancel := context.WithCancelCause(ctx)
+	owner := &jobServices{}
+	var acquired []string
+	release := func() {
+		cancel(nil)
+		for _, name := range acquired {
+			r.release(name, owner)
+		}
+	}
+
+	for _, name := range names {
+		service, ok := r.services[name]
+		if !ok || service == nil {
+			release()
+			return nil, nil, fmt.Errorf("job '%s' uses unknown service '%s'", jobName, name)
+		}
+
+		running := r.get(name)
+		running.start.Do(func() {
+			running.stop, running.err = r.start(ctx, name, service)
+		})
+		if running.err != nil {
+			release()
+			return nil, nil, running.err
+		}
+
+		r.mu.Lock()
+		running.cancels[owner] = cancel
+		r.mu.Unlock()
+		acquired = append(acquired, name)
+	}
+
+	return jobCtx, release, nil
+}
+
+// get returns the running service entry, creating it on first use.
+func (r *serviceRegistry) get(name string) *runningService {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	running, ok := r.running[name]
+	if !ok {
+		running = &runningService{cancels: map[*jobServices]context.CancelCauseFunc{}}
+		r.running[name] = running
+	}
+	return running
+}
+
+// start runs the service command and waits until it's ready.
+func (r *serviceRegistry) start(ctx context.Context, name string, service *model.Service) (func(), error) {
+	if service.Run == "" {
+		return nil, fmt.Errorf("service '%s' has no run command", name)
+	}
+
+	svcCtx := r.ctx.Copy()
+	if service.Dir != "" {
+		dir, err := InterpolateString(service.Dir, svcCtx)
+		if err != nil {
+			return nil, fmt.Errorf("service '%s': failed to interpolate dir: %w", name, err)
+		}
+		if !filepath.IsAbs(dir) && svcCtx.Dir != "" {
+			dir = filepath.Join(svcCtx.Dir, dir)
+		}
+		svcCtx.Dir = dir
+	}
+
+	cmd, err := InterpolateCommand(service.Run, svcCtx)
+	if err != nil {
+		return nil, fmt.Errorf("service '%s': interpolation failed: %w", name, err)
+	}
+
+	ready := service.Ready
+	if ready == nil {
+		ready = &model.Ready{}
+	}
+
+	stop, err := startBackground(ctx, svcCtx, cmd, ready, func(exitErr *ExitedEarlyError) {
+		exitErr.Service = name
+		r.exited(name, exitErr)
+	})
+	if err != nil {
+		var exitErr *ExitedEarlyError
+		if errors.As(err, &exitErr) {
+			exitErr.Service = name
+			return nil, exitErr
+		}
+		return nil, fmt.Errorf("service '%s': %w", name, err)
+	}
+	return stop, nil
+}
+
+// exited fails the jobs using a service that exited. The service is
+// started again by the next job using it.
+func (r *serviceRegistry) exited(name string, exitErr *ExitedEarlyError) {
+	r.mu.Lock()
+	running, ok := r.running[name]
+	delete(r.running, name)
+	r.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	for _, cancel := range running.cancels {
+		cancel(exitErr)
+	}
+}
+
+// release marks the job as done with the service, and stops the service
+// when no other job of the run will use it.
+func (r *serviceRegistry) release(name string, owner *jobServices) {
+	r.mu.Lock()
+	if running, ok := r.running[name]; ok {
+		delete(running.cancels, owner)
+	}
+	r.mu.Unlock()
+	r.stopUnused(name)
+}
+
+// stopUnused stops the service when no job is using it, and no other
+// scheduled job will.
+func (r *serviceRegistry) stopUnused(name string) {
+	r.mu.Lock()
+	running, ok := r.running[name]
+	if !ok || r.users[name] > 0 || len(running.cancels) > 0 {
+		r.mu.Unlock()
+		return
+	}
+	delete(r.running, name)
+	r.mu.Unlock()
+
+	if running.stop != nil {
+		running.stop()
+	}
+}
+
+// stopAll stops the services still running at the end of the run.
+func (r *serviceRegistry) stopAll() {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	names := make([]string, 0, len(r.running))
+	for name := range r.running {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	running := r.running
+	r.running = map[string]*runningService{}
+	r.mu.Unlock()
+
+	for _, name := range names {
+		if stop := running[name].stop; stop != nil {
+			stop()
+		}
+	}
+}
+
+// serviceExitCause returns the service exit error when a job failed
+// because a service it uses exited.
+func serviceExitCause(ctx context.Context, err error) error {
+	var exitErr *ExitedEarlyError
+	if err != nil && errors.As(context.Cause(ctx), &exitErr) {
+		return exitErr
+	}
+	return err
+}
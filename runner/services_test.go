@@ -0,0 +1,127 @@
+package runner_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/atkins/runner"
+)
+
+func TestServices(t *testing.T) {
+	t.Run("starts once and is shared by jobs", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Chdir(dir)
+
+		pipelines, err := runner.LoadPipelineFromReader(strings.NewReader(`
+services:
+  db:
+    run: echo started >> starts.log; echo ready; sleep 30
+    ready: ready
+
+jobs:
+  default:
+    depends_on: [test-a, test-b]
+    steps:
+      - run: "true"
+  test-a:
+    services: [db]
+    steps:
+      - run: test -f starts.log
+  test-b:
+    services: db
+    steps:
+      - run: test -f starts.log
+`))
+		require.NoError(t, err)
+
+		err = runner.RunPipeline(t.Context(), pipelines[0], runner.PipelineOptions{
+			Jobs:   []string{"default"},
+			Silent: true,
+		})
+		require.NoError(t, err)
+
+		data, err := os.ReadFile(filepath.Join(dir, "starts.log"))
+		require.NoError(t, err)
+		assert.Equal(t, "started\n", string(data))
+	})
+
+	t.Run("stops after the last job using it was skipped", func(t *testing.T) {
+		t.Chdir(t.TempDir())
+
+		pipelines, err := runner.LoadPipelineFromReader(strings.NewReader(`
+services:
+  db:
+    run: echo $$ > db.pid; echo ready; exec sleep 30
+    ready: ready
+
+jobs:
+  test-a:
+    services: [db]
+    steps:
+      - run: test -f db.pid
+  test-b:
+    if: "false"
+    services: [db]
+    steps:
+      - run: "true"
+  check:
+    steps:
+      - run: "! kill -0 $(cat db.pid) 2>/dev/null"
+`))
+		require.NoError(t, err)
+
+		err = runner.RunPipeline(t.Context(), pipelines[0], runner.PipelineOptions{
+			Jobs:   []string{"test-a", "test-b", "check"},
+			Silent: true,
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("fails the job when the service exits", func(t *testing.T) {
+		pipelines, err := runner.LoadPipelineFromReader(strings.NewReader(`
+services:
+  db: sleep 0.2; echo out of memory; exit 137
+
+jobs:
+  default:
+    services: [db]
+    steps:
+      - run: sleep 30
+`))
+		require.NoError(t, err)
+
+		err = runner.RunPipeline(t.Context(), pipelines[0], runner.PipelineOptions{
+			Jobs:   []string{"default"},
+			Silent: true,
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `service "db" exited early with code 137`)
+		assert.Contains(t, err.Error(), "out of memory")
+	})
+
+	t.Run("unknown service", func(t *testing.T) {
+		pipelines, err := runner.LoadPipelineFromReader(strings.NewReader(`
+services:
+  db: sleep 30
+
+jobs:
+  default:
+    services: [cache]
+    steps:
+      - run: "true"
+`))
+		require.NoError(t, err)
+
+		err = runner.RunPipeline(t.Context(), pipelines[0], runner.PipelineOptions{
+			Jobs:   []string{"default"},
+			Silent: true,
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "job 'default' uses unknown service 'cache'")
+	})
+}
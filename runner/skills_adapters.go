@@ -0,0 +1,161 @@
+package runner
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/titpetric/atkins/model"
+	"github.com/titpetric/atkins/treeview"
+)
+
+// skillAdapter surfaces targets of an existing build tool as a virtual skill.
+type skillAdapter struct {
+	ID    string                                                // Skill ID, e.g. "make"
+	Name  string                                                // Pipeline name shown in listings
+	Files []string                                              // Files activating the adapter
+	Load  func(dir, file string) (map[string]*model.Job, error) // Builds jobs from the matched file
+}
+
+// skillAdapters are the build tools surfaced as virtual skills.
+var skillAdapters = []skillAdapter{
+	{ID: "make", Name: "Makefile targets", Files: []string{"Makefile", "makefile", "GNUmakefile"}, Load: makeJobs},
+	{ID: "npm", Name: "package.json scripts", Files: []string{"package.json"}, Load: npmJobs},
+}
+
+// makeTargetPattern matches explicit rule targets, with an optional
+// `## description` comment after the prerequisites.
+var makeTargetPattern = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9_./-]*)\s*:([^=].*)?$`)
+
+// loadAdapterSkills returns virtual skills for build tool files found
+// between StartDir and WorkspaceDir. Adapters with an ID in seen are
+// skipped, so skill files take precedence. An adapter failing to read
+// its file is skipped with a warning, so the other skills still load.
+func (l *SkillsLoader) loadAdapterSkills(seen map[string]bool) []*model.Pipeline {
+	var pipelines []*model.Pipeline
+	for _, adapter := range skillAdapters {
+		if seen[adapter.ID] {
+			continue
+		}
+		dir, file, found := l.findAdapterFile(adapter.Files)
+		if !found {
+			continue
+		}
+		jobs, err := adapter.Load(dir, file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s skipping %s skill: %v\n", treeview.WarningHeader(), adapter.ID, err)
+			continue
+		}
+		if len(jobs) == 0 {
+			continue
+		}
+		seen[adapter.ID] = true
+		pipelines = append(pipelines, &model.Pipeline{
+			ID:     adapter.ID,
+			Source: filepath.Join(dir, file),
+			Name:   adapter.Name,
+			Dir:    dir,
+			Jobs:   jobs,
+		})
+	}
+	return pipelines
+}
+
+// findAdapterFile searches for one of files from StartDir up to the
+// WorkspaceDir. When StartDir is outside of the workspace, only
+// StartDir is searched.
+func (l *SkillsLoader) findAdapterFile(files []string) (dir, file string, found bool) {
+	current := l.StartDir
+	for {
+		for _, name := range files {
+			if info, err := os.Stat(filepath.Join(current, name)); err == nil && !info.IsDir() {
+				return current, name, true
+			}
+		}
+
+		parent := filepath.Dir(current)
+		if current == l.WorkspaceDir || parent == current || !strings.HasPrefix(current, l.WorkspaceDir) {
+			return "", "", false
+		}
+		current = parent
+	}
+}
+
+// makeJobs returns a job for every explicit target in the Makefile.
+// Special targets (.PHONY), pattern rules and variable assignments are skipped.
+func makeJobs(dir, file string) (map[string]*model.Job, error) {
+	f, err := os.Open(filepath.Join(dir, file))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	jobs := map[string]*model.Job{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		match := makeTargetPattern.FindStringSubmatch(scanner.Text())
+		if match == nil {
+			continue
+		}
+		target := match[1]
+		if _, ok := jobs[target]; ok {
+			continue
+		}
+		desc := ""
+		if _, comment, ok := strings.Cut(match[2], "##"); ok {
+			desc = strings.TrimSpace(comment)
+		}
+		jobs[target] = adapterJob(target, "make "+target, desc)
+	}
+	return jobs, scanner.Err()
+}
+
+// npmJobs returns a job for every script in package.json. Scripts are run
+// with the package manager matching the lockfile, defaulting to npm.
+func npmJobs(dir, file string) (map[string]*model.Job, error) {
+	data, err := os.ReadFile(filepath.Join(dir, file))
+	if err != nil {
+		return nil, err
+	}
+	var pkg struct {
+		Scripts map[string]string `json:"scripts"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filepath.Join(dir, file), err)
+	}
+
+	manager := "npm"
+	for _, lock := range []struct{ file, manager string }{
+		{"pnpm-lock.yaml", "pnpm"},
+		{"yarn.lock", "yarn"},
+		{"bun.lockb", "bun"},
+	} {
+		if _, err := os.Stat(filepath.Join(dir, lock.file)); err == nil {
+			manager = lock.manager
+			break
+		}
+	}
+
+	jobs := map[string]*model.Job{}
+	for name, script := range pkg.Scripts {
+		jobs[name] = adapterJob(name, manager+" run "+name, script)
+	}
+	return jobs, nil
+}
+
+// adapterJob builds a job running cmd, like a string job in a pipeline.
+func adapterJob(name, cmd, desc string) *model.Job {
+	if desc == "" {
+		desc = cmd
+	}
+	return &model.Job{
+		Name:     name,
+		Desc:     desc,
+		Steps:    []*model.Step{{Run: cmd, Name: cmd, HidePrefix: true}},
+		Passthru: true,
+	}
+}
@@ -0,0 +1,134 @@
+package runner_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/atkins/runner"
+)
+
+func TestSkillsLoader_Adapters(t *testing.T) {
+	t.Run("surfaces Makefile targets", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		makefile := `VERSION := 1.0
+CC ?= gcc
+
+.PHONY: build test
+
+build: ## Build the binary
+	go build ./...
+
+test: build
+	go test ./...
+
+%.o: %.c
+	$(CC) -c $<
+`
+		require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "Makefile"), []byte(makefile), 0o644))
+
+		pipelines, err := runner.NewSkillsLoader(tmpDir, tmpDir).Load()
+		require.NoError(t, err)
+		require.Len(t, pipelines, 1)
+
+		p := pipelines[0]
+		assert.Equal(t, "make", p.ID)
+		assert.Equal(t, tmpDir, p.Dir)
+		require.Len(t, p.Jobs, 2)
+		assert.Equal(t, "Build the binary", p.Jobs["build"].Desc)
+		assert.Equal(t, "make test", p.Jobs["test"].Desc)
+		assert.Equal(t, "make test", p.Jobs["test"].Steps[0].Run)
+	})
+
+	t.Run("surfaces package.json scripts", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		subDir := filepath.Join(tmpDir, "web")
+		require.NoError(t, os.MkdirAll(subDir, 0o755))
+		pkg := `{"name": "web", "scripts": {"test": "vitest", "build": "vite build"}}`
+		require.NoError(t, os.WriteFile(filepath.Join(subDir, "package.json"), []byte(pkg), 0o644))
+		require.NoError(t, os.WriteFile(filepath.Join(subDir, "pnpm-lock.yaml"), nil, 0o644))
+
+		pipelines, err := runner.NewSkillsLoader(tmpDir, subDir).Load()
+		require.NoError(t, err)
+		require.Len(t, pipelines, 1)
+
+		p := pipelines[0]
+		assert.Equal(t, "npm", p.ID)
+		assert.Equal(t, subDir, p.Dir)
+		require.Len(t, p.Jobs, 2)
+		assert.Equal(t, "vitest", p.Jobs["test"].Desc)
+		assert.Equal(t, "pnpm run test", p.Jobs["test"].Steps[0].Run)
+	})
+
+	t.Run("skips invalid package.json", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "package.json"), []byte(`{"scripts": {`), 0o644))
+		require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "Makefile"), []byte("build:\n\tgo build\n"), 0o644))
+
+		pipelines, err := runner.NewSkillsLoader(tmpDir, tmpDir).Load()
+		require.NoError(t, err)
+		require.Len(t, pipelines, 1)
+		assert.Equal(t, "make", pipelines[0].ID)
+	})
+
+	t.Run("skill files take precedence", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		skillsDir := filepath.Join(tmpDir, ".atkins", "skills")
+		require.NoError(t, os.MkdirAll(skillsDir, 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(skillsDir, "make.yml"), []byte("jobs:\n  all: make all\n"), 0o644))
+		require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "Makefile"), []byte("build:\n\tgo build\n"), 0o644))
+
+		pipelines, err := runner.NewSkillsLoader(tmpDir, tmpDir).Load()
+		require.NoError(t, err)
+		require.Len(t, pipelines, 1)
+		assert.Contains(t, pipelines[0].Jobs, "all")
+		assert.NotContains(t, pipelines[0].Jobs, "build")
+	})
+
+	t.Run("ignores files outside the workspace", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		workspace := filepath.Join(tmpDir, "project")
+		require.NoError(t, os.MkdirAll(workspace, 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "Makefile"), []byte("build:\n"), 0o644))
+
+		pipelines, err := runner.NewSkillsLoader(workspace, workspace).Load()
+		require.NoError(t, err)
+		assert.Empty(t, pipelines)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "Makefile"), []byte("build:\n"), 0o644))
+
+		loader := runner.NewSkillsLoader(tmpDir, tmpDir)
+		loader.Adapters = false
+		pipelines, err := loader.Load()
+		require.NoError(t, err)
+		assert.Empty(t, pipelines)
+	})
+}
+
+func TestSkillsLoader_AdapterJobRuns(t *testing.T) {
+	if _, err := exec.LookPath("make"); err != nil {
+		t.Skip("make is not installed")
+	}
+
+	tmpDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "Makefile"), []byte("hello:\n\ttouch hello.txt\n"), 0o644))
+
+	skills, err := runner.NewSkillsLoader(tmpDir, tmpDir).Load()
+	require.NoError(t, err)
+	require.Len(t, skills, 1)
+
+	err = runner.RunPipeline(t.Context(), skills[0], runner.PipelineOptions{
+		Jobs:         []string{"hello"},
+		Silent:       true,
+		AllPipelines: skills,
+	})
+	require.NoError(t, err)
+	assert.FileExists(t, filepath.Join(tmpDir, "hello.txt"))
+}
@@ -0,0 +1,167 @@
+package runner
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/titpetric/atkins/model"
+)
+
+// Skill conflict kinds.
+const (
+	ConflictSkill = "skill" // Two skills share an ID
+	ConflictAlias = "alias" // An alias points to different jobs
+	ConflictJob   = "job"   // A job name shadows an alias
+)
+
+// SkillConflict describes a name defined more than once in the effective
+// skill set, and which definition is used.
+type SkillConflict struct {
+	Kind     string   // ConflictSkill, ConflictAlias or ConflictJob
+	Name     string   // Skill ID, alias or job name
+	Winner   string   // The definition that is used
+	Shadowed []string // The definitions that are ignored
+}
+
+// String returns a one line description of the conflict.
+func (c SkillConflict) String() string {
+	return fmt.Sprintf("%s %q: using %s, ignoring %s", c.Kind, c.Name, c.Winner, strings.Join(c.Shadowed, ", "))
+}
+
+// MergeSkills appends skills to pipelines, skipping skills with an ID
+// that's already loaded. The skipped skills are returned as shadowed.
+func MergeSkills(pipelines, skills []*model.Pipeline) (merged, shadowed []*model.Pipeline) {
+	seen := make(map[string]bool)
+	for _, p := range pipelines {
+		if p.ID != "" {
+			seen[p.ID] = true
+		}
+	}
+
+	merged = pipelines
+	for _, skill := range skills {
+		if seen[skill.ID] {
+			shadowed = append(shadowed, skill)
+			continue
+		}
+		seen[skill.ID] = true
+		merged = append(merged, skill)
+	}
+	return merged, shadowed
+}
+
+// SkillConflicts audits the effective pipelines for skills shadowed by a
+// skill with the same ID, aliases pointing to different jobs, and
+// aliases shadowed by a job with the same name. Winners are reported
+// following the task resolution order.
+func SkillConflicts(pipelines, shadowed []*model.Pipeline) []SkillConflict {
+	var conflicts []SkillConflict
+
+	for _, skill := range shadowed {
+		for _, p := range pipelines {
+			if p.ID != skill.ID {
+				continue
+			}
+			// A skill extending its own ID builds on the shadowed skill
+			if p.Extends != skill.ID {
+				conflicts = append(conflicts, SkillConflict{
+					Kind:     ConflictSkill,
+					Name:     skill.ID,
+					Winner:   pipelineSource(p),
+					Shadowed: []string{pipelineSource(skill)},
+				})
+			}
+			break
+		}
+	}
+
+	// Collect alias definitions across pipelines, main pipeline aliases first
+	type definition struct {
+		target string
+		source string
+	}
+	definitions := make(map[string][]definition)
+	add := func(alias, target string, p *model.Pipeline) {
+		for _, d := range definitions[alias] {
+			if d.target == target {
+				return
+			}
+		}
+		definitions[alias] = append(definitions[alias], definition{target, pipelineSource(p)})
+	}
+	for _, p := range pipelines {
+		if p.ID == "" {
+			for _, alias := range slices.Sorted(maps.Keys(p.Aliases)) {
+				add(alias, strings.TrimPrefix(p.Aliases[alias], ":"), p)
+			}
+		}
+	}
+	for _, p := range pipelines {
+		aliases := p.GetAliases()
+		for _, alias := range slices.Sorted(maps.Keys(aliases)) {
+			add(alias, aliases[alias], p)
+		}
+	}
+
+	resolver := NewTaskResolver(pipelines)
+	for _, alias := range slices.Sorted(maps.Keys(definitions)) {
+		defs := definitions[alias]
+		describe := func(d definition) string {
+			return fmt.Sprintf("%s (%s)", d.target, d.source)
+		}
+
+		if job, found := resolver.resolveExplicitTarget(alias); found {
+			conflict := SkillConflict{
+				Kind:   ConflictJob,
+				Name:   alias,
+				Winner: fmt.Sprintf("%s (%s)", job.Name, pipelineSource(job.Pipeline)),
+			}
+			for _, d := range defs {
+				conflict.Shadowed = append(conflict.Shadowed, "alias to "+describe(d))
+			}
+			conflicts = append(conflicts, conflict)
+			continue
+		}
+
+		if len(defs) < 2 {
+			continue
+		}
+		winner := defs[0]
+		if resolved, found := resolver.resolveAlias(alias); found {
+			for _, d := range defs {
+				if d.target == resolved.Name {
+					winner = d
+					break
+				}
+			}
+		}
+		conflict := SkillConflict{Kind: ConflictAlias, Name: alias, Winner: describe(winner)}
+		for _, d := range defs {
+			if d != winner {
+				conflict.Shadowed = append(conflict.Shadowed, describe(d))
+			}
+		}
+		conflicts = append(conflicts, conflict)
+	}
+
+	sort.SliceStable(conflicts, func(i, j int) bool {
+		return conflictOrder(conflicts[i].Kind) < conflictOrder(conflicts[j].Kind)
+	})
+	return conflicts
+}
+
+// conflictOrder sorts skill conflicts first, then aliases, then jobs.
+func conflictOrder(kind string) int {
+	return slices.Index([]string{ConflictSkill, ConflictAlias, ConflictJob}, kind)
+}
+
+// pipelineSource returns the file a pipeline was loaded from, or its name.
+func pipelineSource(p *model.Pipeline) string {
+	if p.Source != "" {
+		return p.Source
+	}
+	return p.Name
+}
@@ -0,0 +1,74 @@
+package runner_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/atkins/model"
+	"github.com/titpetric/atkins/runner"
+)
+
+func TestSkillsLoader_Shadowed(t *testing.T) {
+	tmpDir := t.TempDir()
+	local := filepath.Join(tmpDir, "local")
+	global := filepath.Join(tmpDir, "global")
+	require.NoError(t, os.MkdirAll(local, 0o755))
+	require.NoError(t, os.MkdirAll(global, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(local, "go.yml"), []byte("jobs:\n  test: go test ./...\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(global, "go.yml"), []byte("jobs:\n  test: go test -race ./...\n"), 0o644))
+
+	loader := runner.NewSkillsLoader(tmpDir, tmpDir)
+	loader.SkillsDirs = []string{local, global}
+	loader.Adapters = false
+
+	pipelines, err := loader.Load()
+	require.NoError(t, err)
+	require.Len(t, pipelines, 1)
+	require.Len(t, loader.Shadowed, 1)
+	assert.Equal(t, filepath.Join(local, "go.yml"), pipelines[0].Source)
+	assert.Equal(t, filepath.Join(global, "go.yml"), loader.Shadowed[0].Source)
+}
+
+func TestSkillConflicts(t *testing.T) {
+	main := &model.Pipeline{
+		Name:    "main",
+		Source:  "atkins.yml",
+		Aliases: map[string]string{"t": "go:test"},
+		Jobs: map[string]*model.Job{
+			"build": {Name: "build"},
+		},
+	}
+	goSkill := &model.Pipeline{
+		ID:     "go",
+		Source: "go.yml",
+		Jobs: map[string]*model.Job{
+			"build": {Name: "build", Aliases: []string{"build"}},
+			"test":  {Name: "test", Aliases: []string{"t", "ut"}},
+		},
+	}
+	jsSkill := &model.Pipeline{
+		ID:     "js",
+		Source: "js.yml",
+		Jobs: map[string]*model.Job{
+			"test": {Name: "test", Aliases: []string{"t", "ut"}},
+		},
+	}
+	globalGo := &model.Pipeline{ID: "go", Source: "global/go.yml"}
+
+	pipelines, shadowed := runner.MergeSkills([]*model.Pipeline{main, goSkill, jsSkill}, []*model.Pipeline{globalGo})
+	require.Len(t, pipelines, 3)
+	require.Equal(t, []*model.Pipeline{globalGo}, shadowed)
+
+	conflicts := runner.SkillConflicts(pipelines, shadowed)
+	assert.Equal(t, []runner.SkillConflict{
+		{Kind: runner.ConflictSkill, Name: "go", Winner: "go.yml", Shadowed: []string{"global/go.yml"}},
+		{Kind: runner.ConflictAlias, Name: "t", Winner: "go:test (atkins.yml)", Shadowed: []string{"js:test (js.yml)"}},
+		{Kind: runner.ConflictAlias, Name: "ut", Winner: "go:test (go.yml)", Shadowed: []string{"js:test (js.yml)"}},
+		{Kind: runner.ConflictJob, Name: "build", Winner: "build (atkins.yml)", Shadowed: []string{"alias to go:build (go.yml)"}},
+	}, conflicts)
+	assert.Equal(t, `skill "go": using go.yml, ignoring global/go.yml`, conflicts[0].String())
+}
@@ -0,0 +1,64 @@
+package runner
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/titpetric/atkins/model"
+)
+
+// embeddedSkillsFS holds the skills shipped with atkins.
+//
+//go:embed skills/*.yml
+var embeddedSkillsFS embed.FS
+
+// embeddedSkills parses the skills shipped with atkins.
+func embeddedSkills() ([]*model.Pipeline, error) {
+	files, err := fs.Glob(embeddedSkillsFS, "skills/*.yml")
+	if err != nil {
+		return nil, err
+	}
+
+	pipelines := make([]*model.Pipeline, 0, len(files))
+	for _, file := range files {
+		data, err := embeddedSkillsFS.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		loaded, err := LoadPipelineFromReader(strings.NewReader(string(data)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load embedded skill %s: %w", file, err)
+		}
+
+		pipeline := loaded[0]
+		pipeline.ID = strings.TrimSuffix(path.Base(file), path.Ext(file))
+		pipeline.Source = "embedded:" + path.Base(file)
+		pipelines = append(pipelines, pipeline)
+	}
+	return pipelines, nil
+}
+
+// LoadEmbedded returns the enabled skills shipped with atkins. They have
+// the lowest precedence, skill files with the same ID replace them.
+func (l *SkillsLoader) LoadEmbedded() ([]*model.Pipeline, error) {
+	skills, err := embeddedSkills()
+	if err != nil {
+		return nil, err
+	}
+
+	var pipelines []*model.Pipeline
+	for _, pipeline := range skills {
+		workDir, enabled := l.evaluateWhen(pipeline)
+		if !enabled {
+			continue
+		}
+		if pipeline.Dir == "" {
+			pipeline.Dir = workDir
+		}
+		pipelines = append(pipelines, pipeline)
+	}
+	return pipelines, nil
+}
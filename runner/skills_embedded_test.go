@@ -0,0 +1,77 @@
+package runner
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmbeddedSkills(t *testing.T) {
+	skills, err := embeddedSkills()
+	require.NoError(t, err)
+	require.NotEmpty(t, skills)
+
+	for _, skill := range skills {
+		assert.NotEmpty(t, skill.Name, skill.ID)
+		assert.Empty(t, NewLinter(skill).Lint(), skill.ID)
+	}
+}
+
+func TestSkillsLoader_LoadEmbedded_GitHub(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	repo := t.TempDir()
+	sub := filepath.Join(repo, "sub")
+	_, err := gitOutput(repo, "init", "-q")
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(sub, 0o755))
+
+	loader := NewSkillsLoader(repo, sub)
+	skills, err := loader.LoadEmbedded()
+	require.NoError(t, err)
+	for _, skill := range skills {
+		assert.NotEqual(t, "github", skill.ID, "no github remote")
+	}
+
+	_, err = gitOutput(repo, "remote", "add", "origin", "git@github.com:titpetric/atkins.git")
+	require.NoError(t, err)
+
+	skills, err = loader.LoadEmbedded()
+	require.NoError(t, err)
+	var found bool
+	for _, skill := range skills {
+		if skill.ID == "github" {
+			found = true
+			resolved, err := filepath.EvalSymlinks(repo)
+			require.NoError(t, err)
+			assert.Equal(t, resolved, skill.Dir)
+			assert.Equal(t, "embedded:github.yml", skill.Source)
+		}
+	}
+	assert.True(t, found)
+}
+
+func TestSkillsLoader_LoadEmbedded_Markers(t *testing.T) {
+	root := t.TempDir()
+	web := filepath.Join(root, "web")
+	require.NoError(t, os.MkdirAll(web, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "Cargo.toml"), []byte("[package]"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(web, "package.json"), []byte("{}"), 0o644))
+
+	skills, err := NewSkillsLoader(root, web).LoadEmbedded()
+	require.NoError(t, err)
+
+	dirs := map[string]string{}
+	for _, skill := range skills {
+		dirs[skill.ID] = skill.Dir
+	}
+	assert.Equal(t, web, dirs["node"])
+	assert.Equal(t, root, dirs["cargo"])
+	assert.NotContains(t, dirs, "python")
+}
@@ -0,0 +1,151 @@
+package runner
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/titpetric/atkins/model"
+)
+
+// resolveExtends returns candidates[i] layered on top of the skill it
+// extends. A skill extending its own ID builds on the next skill with
+// that ID in a lower-priority directory; any other ID is looked up in
+// priority order. Chains are resolved recursively.
+func resolveExtends(candidates []*model.Pipeline, i int, visiting map[int]bool) (*model.Pipeline, error) {
+	skill := candidates[i]
+	if skill.Extends == "" {
+		return skill, nil
+	}
+	if visiting[i] {
+		return nil, fmt.Errorf("skill %s: extends cycle through %q", skill.Source, skill.Extends)
+	}
+
+	base := -1
+	for j, candidate := range candidates {
+		if candidate.ID != skill.Extends || j == i {
+			continue
+		}
+		if skill.Extends == skill.ID && j < i {
+			continue
+		}
+		base = j
+		break
+	}
+	if base < 0 {
+		return nil, fmt.Errorf("skill %s extends %q, which was not found", skill.Source, skill.Extends)
+	}
+
+	visiting[i] = true
+	parent, err := resolveExtends(candidates, base, visiting)
+	if err != nil {
+		return nil, err
+	}
+	return ExtendPipeline(parent, skill), nil
+}
+
+// ExtendPipeline returns a new pipeline with skill layered on top of base:
+//
+//   - name, dir, when: and default: are taken from skill when set, otherwise from base
+//   - vars, env vars, tools, services and aliases are merged, skill keys win
+//   - include files of base are read first, then the ones of skill
+//   - jobs of skill replace jobs of base with the same name, other base jobs are kept
+//
+// The ID and Source of skill are kept.
+func ExtendPipeline(base, skill *model.Pipeline) *model.Pipeline {
+	result := *skill
+	result.Tasks = nil
+
+	if result.Name == "" || result.Name == skill.ID+".yml" {
+		result.Name = base.Name
+	}
+	if result.Dir == "" {
+		result.Dir = base.Dir
+	}
+	if result.When == nil {
+		result.When = base.When
+	}
+	if len(result.Default) == 0 {
+		result.Default = base.Default
+	}
+	result.Lenient = base.Lenient || skill.Lenient
+
+	result.Decl = extendDecl(base.Decl, skill.Decl)
+	result.Tools = mergeMaps(base.Tools, skill.Tools)
+	result.Services = mergeMaps(base.Services, skill.Services)
+	result.Aliases = mergeMaps(base.Aliases, skill.Aliases)
+
+	result.Jobs = make(map[string]*model.Job)
+	result.JobOrder = nil
+	for _, pipeline := range []*model.Pipeline{base, skill} {
+		jobs := pipeline.GetJobs()
+		for _, name := range pipeline.JobNames() {
+			if _, ok := result.Jobs[name]; !ok {
+				result.JobOrder = append(result.JobOrder, name)
+			}
+			if jobs[name] == nil {
+				result.Jobs[name] = nil
+				continue
+			}
+			job := *jobs[name]
+			result.Jobs[name] = &job
+		}
+	}
+	return &result
+}
+
+// extendDecl merges the vars, env and include declarations of skill on top of base.
+func extendDecl(base, skill *model.Decl) *model.Decl {
+	if base == nil {
+		return skill
+	}
+	if skill == nil {
+		return base
+	}
+
+	result := &model.Decl{
+		Vars:    mergeMaps(base.Vars, skill.Vars),
+		Include: extendInclude(base.Include, skill.Include),
+	}
+	result.Env = extendEnv(base.Env, skill.Env)
+	return result
+}
+
+// extendEnv merges the env declaration of skill on top of base.
+func extendEnv(base, skill *model.EnvDecl) *model.EnvDecl {
+	if base == nil {
+		return skill
+	}
+	if skill == nil {
+		return base
+	}
+	return &model.EnvDecl{
+		Vars:    mergeMaps(base.Vars, skill.Vars),
+		Include: extendInclude(base.Include, skill.Include),
+		Doc:     mergeMaps(base.Doc, skill.Doc),
+	}
+}
+
+// extendInclude lists the include files of base, then the ones of skill.
+func extendInclude(base, skill *model.IncludeDecl) *model.IncludeDecl {
+	if base == nil {
+		return skill
+	}
+	if skill == nil {
+		return base
+	}
+	return &model.IncludeDecl{Files: append(slices.Clone(base.Files), skill.Files...)}
+}
+
+// mergeMaps returns a new map with the values of override set over base.
+func mergeMaps[M ~map[string]V, V any](base, override M) M {
+	if len(base) == 0 {
+		return override
+	}
+	if len(override) == 0 {
+		return base
+	}
+	result := maps.Clone(base)
+	maps.Copy(result, override)
+	return result
+}
@@ -0,0 +1,109 @@
+package runner_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/atkins/runner"
+)
+
+func writeSkill(t *testing.T, dir, name, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+}
+
+func TestSkillsLoader_Extends(t *testing.T) {
+	tmpDir := t.TempDir()
+	local := filepath.Join(tmpDir, "local")
+	global := filepath.Join(tmpDir, "global")
+
+	writeSkill(t, global, "go.yml", `name: Go
+vars:
+  race: false
+  tags: none
+env:
+  vars:
+    CGO_ENABLED: 0
+jobs:
+  build:
+    desc: Build
+    run: go build ./...
+  test:
+    desc: Test
+    run: go test ./...
+`)
+	writeSkill(t, local, "go.yml", `extends: go
+vars:
+  race: true
+env:
+  vars:
+    GOFLAGS: -mod=vendor
+jobs:
+  test:
+    desc: Test with race
+    run: go test -race ./...
+  lint:
+    run: golangci-lint run
+`)
+	writeSkill(t, local, "company.yml", `extends: go
+name: Company Go
+jobs:
+  release:
+    run: goreleaser
+`)
+
+	loader := runner.NewSkillsLoader(tmpDir, tmpDir)
+	loader.SkillsDirs = []string{local}
+	loader.ExtendsDirs = []string{global}
+	loader.Adapters = false
+
+	pipelines, err := loader.Load()
+	require.NoError(t, err)
+	require.Len(t, pipelines, 2)
+	assert.Empty(t, loader.Shadowed)
+
+	company, goSkill := pipelines[0], pipelines[1]
+
+	assert.Equal(t, "go", goSkill.ID)
+	assert.Equal(t, "Go", goSkill.Name)
+	assert.Equal(t, filepath.Join(local, "go.yml"), goSkill.Source)
+	assert.Equal(t, map[string]any{"race": true, "tags": "none"}, goSkill.Vars)
+	assert.Equal(t, map[string]any{"CGO_ENABLED": 0, "GOFLAGS": "-mod=vendor"}, goSkill.Env.Vars)
+	assert.Equal(t, []string{"build", "test", "lint"}, goSkill.JobNames(), "base jobs first, in declaration order")
+	assert.Equal(t, "Test with race", goSkill.Jobs["test"].Desc)
+	assert.Equal(t, "Build", goSkill.Jobs["build"].Desc)
+
+	// Extending another ID builds on the effective (extended) skill
+	assert.Equal(t, "company", company.ID)
+	assert.Equal(t, "Company Go", company.Name)
+	assert.Equal(t, []string{"build", "test", "lint", "release"}, company.JobNames())
+	assert.Equal(t, "Test with race", company.Jobs["test"].Desc)
+}
+
+func TestSkillsLoader_ExtendsErrors(t *testing.T) {
+	t.Run("missing base", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		writeSkill(t, tmpDir, "go.yml", "extends: golang\njobs:\n  test: go test ./...\n")
+
+		loader := runner.NewSkillsLoader(tmpDir, tmpDir)
+		loader.SkillsDirs = []string{tmpDir}
+		_, err := loader.Load()
+		require.ErrorContains(t, err, `extends "golang", which was not found`)
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		writeSkill(t, tmpDir, "a.yml", "extends: b\n")
+		writeSkill(t, tmpDir, "b.yml", "extends: a\n")
+
+		loader := runner.NewSkillsLoader(tmpDir, tmpDir)
+		loader.SkillsDirs = []string{tmpDir}
+		_, err := loader.Load()
+		require.ErrorContains(t, err, "extends cycle")
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/titpetric/atkins/model"
@@ -23,6 +24,23 @@ type SkillsLoader struct {
 
 	// WorkspaceDir is the folder containing .atkins/ (used for skills without when:).
 	WorkspaceDir string
+
+	// Adapters enables virtual skills for Makefile targets (make:*) and
+	// package.json scripts (npm:*). Skill files with the same ID win.
+	Adapters bool
+
+	// ExtendsDirs are searched for skills named by `extends:` after
+	// SkillsDirs, followed by the embedded skills. Skills in these
+	// directories aren't loaded themselves.
+	ExtendsDirs []string
+
+	// Markers map project files to the skills they enable, in addition
+	// to the `detect:` markers of the skills themselves.
+	Markers []Marker
+
+	// Shadowed holds the enabled skills skipped by Load, because a skill
+	// with the same ID was loaded from a higher-priority directory.
+	Shadowed []*model.Pipeline
 }
 
 // NewSkillsLoader creates a loader for the given workspace.
@@ -33,6 +51,7 @@ func NewSkillsLoader(workspaceDir, startDir string) *SkillsLoader {
 		SkillsDirs:   []string{filepath.Join(workspaceDir, ".atkins", "skills")},
 		StartDir:     startDir,
 		WorkspaceDir: workspaceDir,
+		Adapters:     true,
 	}
 }
 
@@ -44,10 +63,62 @@ func (l *SkillsLoader) AddSkillsDir(dir string) {
 
 // Load discovers and returns all enabled skill pipelines.
 func (l *SkillsLoader) Load() ([]*model.Pipeline, error) {
+	candidates, err := l.loadSkillFiles(l.SkillsDirs)
+	if err != nil {
+		return nil, err
+	}
+	bases, err := l.loadSkillFiles(l.ExtendsDirs)
+	if err != nil {
+		return nil, err
+	}
+	embedded, err := embeddedSkills()
+	if err != nil {
+		return nil, err
+	}
+	bases = append(bases, embedded...)
+	candidates = append(candidates, bases...)
+
 	var pipelines []*model.Pipeline
 	seen := make(map[string]bool) // Track skill IDs for deduplication
 
-	for _, skillsDir := range l.SkillsDirs {
+	for i := range len(candidates) - len(bases) {
+		pipeline, err := resolveExtends(candidates, i, map[int]bool{})
+		if err != nil {
+			return nil, err
+		}
+
+		// Evaluate when: condition and determine working directory
+		workDir, enabled := l.evaluateWhen(pipeline)
+		if !enabled {
+			continue
+		}
+
+		// Skip if already loaded from higher-priority directory
+		if seen[pipeline.ID] {
+			l.Shadowed = append(l.Shadowed, pipeline)
+			continue
+		}
+
+		// Set Dir only if not already explicitly set in the skill file
+		if pipeline.Dir == "" {
+			pipeline.Dir = workDir
+		}
+
+		seen[pipeline.ID] = true
+		pipelines = append(pipelines, pipeline)
+	}
+
+	if l.Adapters {
+		pipelines = append(pipelines, l.loadAdapterSkills(seen)...)
+	}
+
+	return pipelines, nil
+}
+
+// loadSkillFiles loads the skill files of dirs, in directory order.
+func (l *SkillsLoader) loadSkillFiles(dirs []string) ([]*model.Pipeline, error) {
+	var pipelines []*model.Pipeline
+	for _, skillsDir := range dirs {
 		entries, err := os.ReadDir(skillsDir)
 		if err != nil {
 			if os.IsNotExist(err) {
@@ -67,28 +138,9 @@ func (l *SkillsLoader) Load() ([]*model.Pipeline, error) {
 			if err != nil {
 				return nil, fmt.Errorf("failed to load skill %s: %w", skillPath, err)
 			}
-
-			// Skip if already loaded from higher-priority directory
-			if seen[pipeline.ID] {
-				continue
-			}
-
-			// Evaluate when: condition and determine working directory
-			workDir, enabled := l.evaluateWhen(pipeline)
-			if !enabled {
-				continue
-			}
-
-			// Set Dir only if not already explicitly set in the skill file
-			if pipeline.Dir == "" {
-				pipeline.Dir = workDir
-			}
-
-			seen[pipeline.ID] = true
 			pipelines = append(pipelines, pipeline)
 		}
 	}
-
 	return pipelines, nil
 }
 
@@ -111,10 +163,27 @@ func (l *SkillsLoader) loadSkillFile(path string) (*model.Pipeline, error) {
 }
 
 // evaluateWhen checks if a skill's when: condition is satisfied.
+// A detected marker enables the skill regardless of when:.
 func (l *SkillsLoader) evaluateWhen(pipeline *model.Pipeline) (workDir string, enabled bool) {
-	// No when: condition means always enabled, use workspace dir
-	if pipeline.When == nil || len(pipeline.When.Files) == 0 {
-		return l.WorkspaceDir, true
+	markers := l.skillMarkers(pipeline)
+	if dir, found := findMarker(markers, l.StartDir); found {
+		return dir, true
+	}
+
+	// No when: condition means always enabled, use workspace dir,
+	// unless the skill is only enabled by its markers
+	if pipeline.When == nil || (len(pipeline.When.Files) == 0 && len(pipeline.When.Remotes) == 0) {
+		return l.WorkspaceDir, len(pipeline.Detect) == 0
+	}
+
+	if len(pipeline.When.Remotes) > 0 {
+		root, found := l.FindRemote(pipeline.When.Remotes, l.StartDir)
+		if !found {
+			return "", false
+		}
+		if len(pipeline.When.Files) == 0 {
+			return root, true
+		}
 	}
 
 	// Find the first matching file from any pattern
@@ -126,6 +195,74 @@ func (l *SkillsLoader) evaluateWhen(pipeline *model.Pipeline) (workDir string, e
 	return matchDir, true
 }
 
+// DetectMarkers returns the loader markers and the `detect:` markers of
+// the skill files in SkillsDirs, ExtendsDirs and the embedded skills,
+// for project root discovery.
+func (l *SkillsLoader) DetectMarkers() ([]Marker, error) {
+	skills, err := l.loadSkillFiles(append(slices.Clone(l.SkillsDirs), l.ExtendsDirs...))
+	if err != nil {
+		return nil, err
+	}
+	embedded, err := embeddedSkills()
+	if err != nil {
+		return nil, err
+	}
+	return append(slices.Clone(l.Markers), SkillMarkers(append(skills, embedded...))...), nil
+}
+
+// skillMarkers returns the markers enabling the skill.
+func (l *SkillsLoader) skillMarkers(pipeline *model.Pipeline) []string {
+	markers := slices.Clone(pipeline.Detect)
+	for _, marker := range l.Markers {
+		if slices.Contains(marker.Skills, pipeline.ID) {
+			markers = append(markers, marker.Path)
+		}
+	}
+	return markers
+}
+
+// findMarker searches for any of the markers starting from startDir and
+// traversing parent directories. Returns the directory of the closest match.
+func findMarker(markers []string, startDir string) (matchDir string, found bool) {
+	if len(markers) == 0 {
+		return "", false
+	}
+	current := startDir
+	for {
+		for _, marker := range markers {
+			if markerExists(current, marker) {
+				return current, true
+			}
+		}
+
+		parent := filepath.Dir(current)
+		if parent == current {
+			return "", false
+		}
+		current = parent
+	}
+}
+
+// FindRemote checks if the git repository containing startDir has a
+// remote URL containing any of the patterns. Returns the repository
+// root when a remote matches.
+func (l *SkillsLoader) FindRemote(patterns []string, startDir string) (root string, found bool) {
+	urls, err := gitOutput(startDir, "config", "--get-regexp", `^remote\..*\.url$`)
+	if err != nil {
+		return "", false
+	}
+	for _, line := range strings.Split(urls, "\n") {
+		_, url, _ := strings.Cut(line, " ")
+		for _, pattern := range patterns {
+			if strings.Contains(url, pattern) {
+				root, err := gitOutput(startDir, "rev-parse", "--show-toplevel")
+				return root, err == nil
+			}
+		}
+	}
+	return "", false
+}
+
 // FindFolder searches for a directory with the given name starting from startDir
 // and traversing parent directories. Returns (found, containingDir) where
 // containingDir is the parent directory that contains the named folder.
@@ -0,0 +1,64 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// SkillsLockFile pins the digests of pulled skills, relative to the
+// project root or $HOME for global skills.
+var SkillsLockFile = filepath.Join(".atkins", "skills.lock")
+
+// SkillsLock is the lockfile of skills pulled from OCI registries.
+type SkillsLock struct {
+	Skills []LockedSkill `yaml:"skills"`
+}
+
+// LockedSkill is a pulled skill bundle, pinned to its manifest digest.
+type LockedSkill struct {
+	Ref    string   `yaml:"ref"`    // Reference as pulled, e.g. oci://ghcr.io/org/atkins-skills/go:1.2.0
+	Digest string   `yaml:"digest"` // Manifest digest the reference resolved to
+	Files  []string `yaml:"files"`  // Files written to the skills directory
+}
+
+// LoadSkillsLock reads the lockfile, returning an empty lock if it doesn't exist.
+func LoadSkillsLock(path string) (*SkillsLock, error) {
+	lock := &SkillsLock{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return lock, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, lock); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return lock, nil
+}
+
+// Set adds or replaces the entry with the same reference.
+func (l *SkillsLock) Set(skill LockedSkill) {
+	if i := slices.IndexFunc(l.Skills, func(s LockedSkill) bool { return s.Ref == skill.Ref }); i >= 0 {
+		l.Skills[i] = skill
+		return
+	}
+	l.Skills = append(l.Skills, skill)
+}
+
+// Save writes the lockfile.
+func (l *SkillsLock) Save(path string) error {
+	data, err := yaml.Marshal(l)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
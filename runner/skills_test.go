@@ -417,3 +417,44 @@ jobs:
 		assert.Equal(t, "/custom/path", pipelines[0].Dir)
 	})
 }
+
+func TestSkillsLoaderMarkers(t *testing.T) {
+	tmpDir := t.TempDir()
+	skillsDir := filepath.Join(tmpDir, ".atkins", "skills")
+	require.NoError(t, os.MkdirAll(skillsDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(skillsDir, "cargo.yml"), []byte("detect: [Cargo.toml]\njobs:\n  build: cargo build\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(skillsDir, "python.yml"), []byte("when:\n  files: [setup.py]\njobs:\n  test: pytest\n"), 0o644))
+
+	app := filepath.Join(tmpDir, "app")
+	require.NoError(t, os.MkdirAll(app, 0o755))
+
+	ids := func(loader *runner.SkillsLoader) []string {
+		pipelines, err := loader.Load()
+		require.NoError(t, err)
+		var ids []string
+		for _, p := range pipelines {
+			ids = append(ids, p.ID)
+		}
+		return ids
+	}
+
+	loader := runner.NewSkillsLoader(tmpDir, app)
+	loader.Adapters = false
+	assert.Empty(t, ids(loader), "detect: gates the skill")
+
+	require.NoError(t, os.WriteFile(filepath.Join(app, "Cargo.toml"), []byte("[package]"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(app, "pyproject.toml"), []byte("[project]"), 0o644))
+	loader = runner.NewSkillsLoader(tmpDir, app)
+	loader.Adapters = false
+	loader.Markers = []runner.Marker{{Path: "pyproject.toml", Skills: []string{"python"}}}
+	pipelines, err := loader.Load()
+	require.NoError(t, err)
+	require.Len(t, pipelines, 2)
+	for _, p := range pipelines {
+		assert.Equal(t, app, p.Dir, "skill %s runs where its marker is", p.ID)
+	}
+
+	markers, err := loader.DetectMarkers()
+	require.NoError(t, err)
+	assert.Contains(t, markers, runner.Marker{Path: "Cargo.toml", Skills: []string{"cargo"}})
+}
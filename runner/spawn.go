@@ -0,0 +1,62 @@
+package runner
+
+import (
+	"cmp"
+	"context"
+	"time"
+
+	"github.com/titpetric/atkins/psexec"
+)
+
+// startProcess starts a process of the pipeline and returns a channel
+// receiving its result. Every process spawned for the pipeline goes
+// through here, so none bypasses the policy or the audit log. cmd is the
+// command as interpolated from the pipeline, which process may wrap, e.g.
+// to trace it.
+func (e *ExecutionContext) startProcess(ctx context.Context, executor *psexec.Executor, process *psexec.Command, cmd string) (chan psexec.Result, error) {
+	if err := e.checkPolicy(cmd); err != nil {
+		return nil, err
+	}
+	dir := cmp.Or(process.Dir, e.Dir)
+	cmd = e.scrub.scrub(cmd)
+	done := make(chan psexec.Result, 1)
+	go func() {
+		start := time.Now()
+		result := executor.Run(ctx, process)
+		e.audit.record(dir, cmd, start, result.ExitCode())
+		done <- result
+	}()
+	return done, nil
+}
+
+// runProcess runs a process of the pipeline, see startProcess.
+func (e *ExecutionContext) runProcess(ctx context.Context, executor *psexec.Executor, process *psexec.Command, cmd string) (psexec.Result, error) {
+	done, err := e.startProcess(ctx, executor, process, cmd)
+	if err != nil {
+		return nil, err
+	}
+	return <-done, nil
+}
+
+// newExecutor returns an executor running processes in the dir and env
+// of the context.
+func (e *ExecutionContext) newExecutor() *psexec.Executor {
+	return psexec.NewWithOptions(&psexec.Options{
+		DefaultDir: e.Dir,
+		DefaultEnv: e.Env.Environ(),
+	})
+}
+
+// runScript runs a shell script of the pipeline, like the script of a
+// `for:` loop, and returns its output.
+func (e *ExecutionContext) runScript(ctx context.Context, script string) (string, error) {
+	executor := e.newExecutor()
+	result, err := e.runProcess(ctx, executor, executor.ShellCommand(script), script)
+	if err != nil {
+		return "", err
+	}
+	if !result.Success() {
+		return "", NewExecError(result)
+	}
+	return result.Output(), nil
+}
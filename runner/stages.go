@@ -0,0 +1,71 @@
+package runner
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/titpetric/atkins/model"
+)
+
+// jobStage is a group of jobs running in parallel, after the jobs of the
+// previous stage completed.
+type jobStage struct {
+	Name string
+	Jobs []string
+}
+
+// stageGroups partitions the jobs to run into stages, in stage order,
+// keeping the order of jobOrder within a stage. A job without a stage
+// runs in the stage of its latest dependency, or the first stage. It
+// returns nil when none of the jobs sets a stage.
+func stageGroups(pipeline *model.Pipeline, jobOrder []string) ([]jobStage, error) {
+	jobs := pipeline.GetJobs()
+	staged := slices.ContainsFunc(jobOrder, func(name string) bool {
+		return jobs[name] != nil && jobs[name].Stage != ""
+	})
+	if !staged {
+		return nil, nil
+	}
+
+	names := pipeline.StageNames()
+	index := make(map[string]int, len(jobOrder))
+	for _, name := range jobOrder {
+		job := jobs[name]
+		if job == nil {
+			continue
+		}
+
+		latest, latestDep := 0, ""
+		for _, dep := range GetDependencies(job.DependsOn) {
+			if i, ok := index[dep]; ok && i >= latest {
+				latest, latestDep = i, dep
+			}
+		}
+
+		if job.Stage == "" {
+			index[name] = latest
+			continue
+		}
+		i := slices.Index(names, job.Stage)
+		if i < 0 {
+			return nil, fmt.Errorf("job %q: unknown stage %q, expected one of %v", name, job.Stage, names)
+		}
+		if latest > i {
+			return nil, fmt.Errorf("job %q of stage %q depends on %q of the later stage %q", name, job.Stage, latestDep, names[latest])
+		}
+		index[name] = i
+	}
+
+	result := make([]jobStage, len(names))
+	for i, stage := range names {
+		result[i].Name = stage
+	}
+	for _, name := range jobOrder {
+		if i, ok := index[name]; ok {
+			result[i].Jobs = append(result[i].Jobs, name)
+		}
+	}
+	return slices.DeleteFunc(result, func(s jobStage) bool {
+		return len(s.Jobs) == 0
+	}), nil
+}
@@ -0,0 +1,86 @@
+package runner
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStageGroups(t *testing.T) {
+	pipelines, err := LoadPipelineFromReader(strings.NewReader(`
+jobs:
+  default:
+    depends_on: [unit, e2e]
+  deps:
+    stage: prepare
+  lint:
+    stage: prepare
+  bin:
+    stage: build
+    depends_on: deps
+  unit:
+    stage: test
+  e2e:
+    stage: test
+    depends_on: bin
+`))
+	require.NoError(t, err)
+	pipeline := pipelines[0]
+
+	stages, err := stageGroups(pipeline, []string{"unit", "deps", "bin", "e2e", "default"})
+	require.NoError(t, err)
+	assert.Equal(t, []jobStage{
+		{Name: "prepare", Jobs: []string{"deps"}},
+		{Name: "build", Jobs: []string{"bin"}},
+		{Name: "test", Jobs: []string{"unit", "e2e", "default"}},
+	}, stages)
+
+	t.Run("without stages", func(t *testing.T) {
+		stages, err := stageGroups(pipeline, []string{"default"})
+		require.NoError(t, err)
+		assert.Nil(t, stages)
+	})
+
+	t.Run("declared stage order", func(t *testing.T) {
+		pipeline.Stages = []string{"test", "build", "prepare"}
+		defer func() { pipeline.Stages = nil }()
+
+		_, err := stageGroups(pipeline, []string{"deps", "bin"})
+		assert.ErrorContains(t, err, `job "bin" of stage "build" depends on "deps" of the later stage "prepare"`)
+
+		pipeline.Stages = []string{"prepare", "build"}
+		_, err = stageGroups(pipeline, []string{"unit"})
+		assert.ErrorContains(t, err, `unknown stage "test"`)
+	})
+}
+
+func TestRunPipeline_Stages(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	// Jobs of a stage wait for each other, so they only pass in parallel,
+	// and the test stage only passes after the build stage completed.
+	pipelines, err := LoadPipelineFromReader(strings.NewReader(`
+stages: [build, test]
+jobs:
+  default:
+    depends_on: [check, a, b]
+  a:
+    stage: build
+    run: touch a; for i in 1 2 3 4 5 6 7 8 9 10 11 12 13 14 15 16 17 18 19 20; do test -f b && break; sleep 0.1; done; test -f b
+  b:
+    stage: build
+    run: touch b; for i in 1 2 3 4 5 6 7 8 9 10 11 12 13 14 15 16 17 18 19 20; do test -f a && break; sleep 0.1; done; sleep 0.2; touch built
+  check:
+    stage: test
+    run: test -f built
+`))
+	require.NoError(t, err)
+
+	err = RunPipeline(t.Context(), pipelines[0], PipelineOptions{
+		Jobs:   []string{"default"},
+		Silent: true,
+	})
+	require.NoError(t, err)
+}
@@ -0,0 +1,116 @@
+package runner
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/titpetric/atkins/model"
+)
+
+// Step results, available in `if:` conditions and ${{ }} expressions as
+// `steps.<id>.result`, next to the output as `steps.<id>.output`.
+const (
+	StepPassed  = "passed"
+	StepFailed  = "failed"
+	StepSkipped = "skipped"
+)
+
+// resultsExpr matches conditions checking the results of previous steps.
+var resultsExpr = regexp.MustCompile(`\b(steps|job)\.`)
+
+// stepResults records the results of the steps of a job as they complete,
+// shared across copies so detached steps record into the same job.
+type stepResults struct {
+	mu      sync.Mutex
+	steps   map[string]string
+	outputs map[string]string
+	failed  bool
+}
+
+func newStepResults() *stepResults {
+	return &stepResults{steps: make(map[string]string), outputs: make(map[string]string)}
+}
+
+// record records the result of a step, by step id. Steps without an id
+// only mark the job as failed.
+func (r *stepResults) record(step *model.Step, result string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if result == StepFailed {
+		r.failed = true
+	}
+	if step.ID != "" {
+		r.steps[step.ID] = result
+	}
+}
+
+// recordOutput appends the standard output of a command to the output
+// of the step, for steps with an id.
+func (r *stepResults) recordOutput(step *model.Step, output string) {
+	if r == nil || step.ID == "" {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.outputs[step.ID] += output
+}
+
+// hasFailed returns true once a step of the job has failed.
+func (r *stepResults) hasFailed() bool {
+	if r == nil {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.failed
+}
+
+// addTo adds `steps` and `job` to the environment of an expression,
+// unless variables with those names are set.
+func (r *stepResults) addTo(env map[string]any) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := env["steps"]; !ok {
+		steps := make(map[string]any, len(r.steps))
+		for id, result := range r.steps {
+			steps[id] = map[string]any{
+				"result": result,
+				"output": strings.TrimRight(StripANSI(r.outputs[id]), "\r\n"),
+			}
+		}
+		env["steps"] = steps
+	}
+	if _, ok := env["job"]; !ok {
+		env["job"] = map[string]any{"failed": r.failed}
+	}
+}
+
+// stepResult returns the result of a completed step.
+func stepResult(skipped bool, err error) string {
+	switch {
+	case err != nil:
+		return StepFailed
+	case skipped:
+		return StepSkipped
+	}
+	return StepPassed
+}
+
+// checksResults returns true when the `if:` of a step checks the results
+// of the previous steps, such steps still run after a step has failed.
+func checksResults(step *model.Step) bool {
+	for _, cond := range step.If {
+		if resultsExpr.MatchString(string(cond)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+package runner
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunPipeline_StepResults(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	pipelines, err := LoadPipelineFromReader(strings.NewReader(`
+jobs:
+  build:
+    steps:
+      - id: lint
+        if: "false"
+        run: printf lint >> out
+      - id: build
+        run: exit 1
+      - run: printf next >> out
+      - if: steps.lint.result == 'skipped' && steps.build.result == 'failed'
+        run: printf rollback >> out
+      - if: "!job.failed"
+        run: printf passed >> out
+      - defer:
+          if: job.failed
+          run: printf notify >> out
+`))
+	require.NoError(t, err)
+
+	err = RunPipeline(t.Context(), pipelines[0], PipelineOptions{
+		Jobs:   []string{"build"},
+		Silent: true,
+	})
+	var execErr ExecError
+	require.ErrorAs(t, err, &execErr)
+	assert.Equal(t, 1, execErr.LastExitCode)
+
+	// Only the steps checking the step results run after the failure
+	out, err := os.ReadFile("out")
+	require.NoError(t, err)
+	assert.Equal(t, "rollbacknotify", string(out))
+}
+
+func TestRunPipeline_StepResultsPassed(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	pipelines, err := LoadPipelineFromReader(strings.NewReader(`
+jobs:
+  build:
+    steps:
+      - id: build
+        run: printf build >> out
+      - if: steps.build.result == 'passed' && !job.failed
+        run: printf passed >> out
+      - if: job.failed
+        run: printf failed >> out
+`))
+	require.NoError(t, err)
+	require.NoError(t, RunPipeline(t.Context(), pipelines[0], PipelineOptions{
+		Jobs:   []string{"build"},
+		Silent: true,
+	}))
+
+	out, err := os.ReadFile("out")
+	require.NoError(t, err)
+	assert.Equal(t, "buildpassed", string(out))
+}
+
+func TestRunPipeline_StepOutputs(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	pipelines, err := LoadPipelineFromReader(strings.NewReader(`
+jobs:
+  build:
+    steps:
+      - id: version
+        run: echo v1.2.3
+      - if: steps.version.output == 'v1.2.3'
+        run: printf '${{ steps.version.output }}' >> out
+`))
+	require.NoError(t, err)
+	require.NoError(t, RunPipeline(t.Context(), pipelines[0], PipelineOptions{
+		Jobs:   []string{"build"},
+		Silent: true,
+	}))
+
+	out, err := os.ReadFile("out")
+	require.NoError(t, err)
+	assert.Equal(t, "v1.2.3", string(out))
+}
+
+func TestLoadPipelineFromReader_StepIDs(t *testing.T) {
+	for _, id := range []string{"0", "build.linux", "-build"} {
+		_, err := LoadPipelineFromReader(strings.NewReader("jobs:\n  build:\n    steps:\n      - id: '" + id + "'\n        run: echo\n"))
+		assert.ErrorContains(t, err, "invalid step id", id)
+	}
+
+	_, err := LoadPipelineFromReader(strings.NewReader(`
+jobs:
+  build:
+    steps:
+      - id: build
+        run: echo one
+      - id: build
+        run: echo two
+`))
+	assert.ErrorContains(t, err, "more than one step with id 'build'")
+
+	_, err = LoadPipelineFromReader(strings.NewReader("jobs:\n  build:\n    steps:\n      - id: build_linux-amd64\n        run: echo\n"))
+	assert.NoError(t, err)
+}
@@ -0,0 +1,294 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/titpetric/atkins/model"
+	"github.com/titpetric/atkins/psexec"
+	"github.com/titpetric/atkins/treeview"
+)
+
+// DefaultReadyTimeout is how long a detached step may take to become ready.
+var DefaultReadyTimeout = 30 * time.Second
+
+// ExitedEarlyError is returned when a supervised detached step or
+// service exits while it is still in use.
+type ExitedEarlyError struct {
+	Service string // Service name, empty for detached steps
+	Step    string // Step command
+	Result  psexec.Result
+	Output  string // Captured output of the step
+}
+
+// exitedEarlyOutputLines is the number of trailing output lines in the error.
+const exitedEarlyOutputLines = 20
+
+// Error implements error.
+func (e *ExitedEarlyError) Error() string {
+	msg := fmt.Sprintf("detached step %q exited early with code %d", e.Step, e.Result.ExitCode())
+	if e.Service != "" {
+		msg = fmt.Sprintf("service %q exited early with code %d", e.Service, e.Result.ExitCode())
+	}
+	if output := strings.TrimSpace(e.Output); output != "" {
+		lines := strings.Split(output, "\n")
+		if len(lines) > exitedEarlyOutputLines {
+			lines = lines[len(lines)-exitedEarlyOutputLines:]
+		}
+		msg += ":\n" + strings.Join(lines, "\n")
+	}
+	return msg
+}
+
+// readyWriterTail is the number of trailing output bytes a readyWriter
+// keeps for the ExitedEarlyError.
+const readyWriterTail = 64 << 10
+
+// readyWriter matches output line by line and signals when a line
+// matches the pattern. It keeps only the tail of the output, since
+// the process may run for as long as the pipeline does.
+type readyWriter struct {
+	mu      sync.Mutex
+	tail    []byte
+	line    []byte
+	pattern *regexp.Regexp
+	matched chan struct{}
+}
+
+func newReadyWriter(pattern *regexp.Regexp) *readyWriter {
+	return &readyWriter{pattern: pattern, matched: make(chan struct{})}
+}
+
+// Write implements io.Writer.
+func (w *readyWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.tail = append(w.tail, p...)
+	if len(w.tail) > readyWriterTail {
+		w.tail = append(w.tail[:0], w.tail[len(w.tail)-readyWriterTail:]...)
+	}
+
+	if w.pattern == nil {
+		return len(p), nil
+	}
+	w.line = append(w.line, p...)
+	for {
+		i := bytes.IndexByte(w.line, '\n')
+		if i < 0 {
+			break
+		}
+		if w.match(w.line[:i]) {
+			return len(p), nil
+		}
+		w.line = w.line[i+1:]
+	}
+	// A prompt may not end with a newline; an overlong line is matched
+	// by its tail.
+	if len(w.line) > readyWriterTail {
+		w.line = w.line[len(w.line)-readyWriterTail:]
+	}
+	w.match(w.line)
+	return len(p), nil
+}
+
+// match signals a match of the line and stops matching further output.
+func (w *readyWriter) match(line []byte) bool {
+	if !w.pattern.Match(bytes.TrimSuffix(line, []byte("\r"))) {
+		return false
+	}
+	w.pattern = nil
+	w.line = nil
+	close(w.matched)
+	return true
+}
+
+func (w *readyWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return string(w.tail)
+}
+
+// startSupervised starts a detached step with `ready:` and waits until it
+// is ready. If the step exits before stop is called, fail is called with an
+// *ExitedEarlyError holding its output. The returned stop function
+// terminates the step.
+func (e *Executor) startSupervised(ctx context.Context, execCtx *ExecutionContext, step *model.Step, stepNode *treeview.Node, fail func(error)) (stop func(), err error) {
+	defer execCtx.Render()
+
+	stepCtx, err := e.prepareStepContext(execCtx, ctx, step)
+	if err != nil {
+		stepNode.SetStatus(treeview.StatusFailed)
+		return nil, err
+	}
+	if err := MergeVariables(stepCtx, step.Decl); err != nil {
+		stepNode.SetStatus(treeview.StatusFailed)
+		return nil, fmt.Errorf("failed to process step env: %w", err)
+	}
+
+	shouldRun, err := EvaluateIf(stepCtx)
+	if err != nil {
+		stepNode.SetStatus(treeview.StatusSkipped)
+		return nil, fmt.Errorf("failed to evaluate if condition for step %q: %w", step.Name, err)
+	}
+	if !shouldRun {
+		e.logStepSkipped(execCtx, step, stepNode, execCtx.NextStepIndex())
+		return func() {}, nil
+	}
+
+	stepNode.SetStatus(treeview.StatusRunning)
+	execCtx.Render()
+	defer func() {
+		if err != nil {
+			stepNode.SetStatus(treeview.StatusFailed)
+		}
+	}()
+
+	commands := step.Commands()
+	if len(commands) != 1 || !step.For.IsEmpty() {
+		return nil, errors.New("ready: requires a detached step with a single command")
+	}
+	if step.Ready.Log == "" && step.Ready.Port == 0 {
+		return nil, errors.New("ready: requires a log pattern or a port")
+	}
+	if noNetwork, _ := stepNoNetwork(execCtx.Job, step); noNetwork {
+		return nil, errors.New("ready: can't be combined with network: none, the readiness check runs on the host network")
+	}
+	cmd, err := interpolateStepCommand(step, commands[0], stepCtx)
+	if err != nil {
+		return nil, fmt.Errorf("interpolation failed: %w", err)
+	}
+
+	stopProcess, err := startBackground(ctx, stepCtx, cmd, step.Ready, func(exitErr *ExitedEarlyError) {
+		stepNode.SetStatus(treeview.StatusFailed)
+		setExitedOutput(stepNode, exitErr)
+		fail(exitErr)
+	})
+	if err != nil {
+		var exitErr *ExitedEarlyError
+		if errors.As(err, &exitErr) {
+			setExitedOutput(stepNode, exitErr)
+			return nil, err
+		}
+		return nil, fmt.Errorf("detached step %q: %w", cmd, err)
+	}
+
+	return func() {
+		stopProcess()
+		if stepNode.GetStatus() == treeview.StatusRunning {
+			stepNode.SetStatus(treeview.StatusPassed)
+		}
+	}, nil
+}
+
+// setExitedOutput shows the output of an exited background process on the node.
+func setExitedOutput(node *treeview.Node, exitErr *ExitedEarlyError) {
+	if lines, err := Sanitize(exitErr.Output); err == nil && len(lines) > 0 {
+		node.SetOutput(lines)
+	}
+}
+
+// startBackground starts cmd in the background and waits until it's ready.
+// Without a log pattern or port, the process is ready once started.
+// If the process exits after it was ready and before stop is called,
+// onExit is called with an *ExitedEarlyError holding its output. The
+// returned stop function terminates the process and its children.
+func startBackground(ctx context.Context, execCtx *ExecutionContext, cmd string, ready *model.Ready, onExit func(*ExitedEarlyError)) (stop func(), err error) {
+	var pattern *regexp.Regexp
+	if ready.Log != "" {
+		if pattern, err = regexp.Compile(ready.Log); err != nil {
+			return nil, fmt.Errorf("ready: invalid log pattern: %w", err)
+		}
+	}
+
+	writer := newReadyWriter(pattern)
+	exec := execCtx.newExecutor()
+	shellCmd := exec.ShellCommand(cmd)
+	shellCmd.Stdout = writer
+	shellCmd.Stderr = writer
+	shellCmd.KillGroup = true
+
+	procCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	done, err := execCtx.startProcess(procCtx, exec, shellCmd, cmd)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	exited := func(result psexec.Result) *ExitedEarlyError {
+		return &ExitedEarlyError{Step: cmd, Result: result, Output: writer.String()}
+	}
+
+	if err := waitReady(ctx, ready, writer, done); err != nil {
+		cancel()
+		result := <-done
+		if errors.Is(err, errExited) {
+			return nil, exited(result)
+		}
+		return nil, err
+	}
+
+	stopped := make(chan struct{})
+	supervised := make(chan struct{})
+	go func() {
+		defer close(supervised)
+		select {
+		case result := <-done:
+			onExit(exited(result))
+		case <-stopped:
+			<-done
+		}
+	}()
+
+	return func() {
+		close(stopped)
+		cancel()
+		<-supervised
+	}, nil
+}
+
+var errExited = errors.New("exited before it was ready")
+
+// waitReady waits for the log pattern to match and the port to accept
+// connections. A result received on done means the step exited; it's
+// put back on the channel for the caller.
+func waitReady(ctx context.Context, ready *model.Ready, writer *readyWriter, done chan psexec.Result) error {
+	timeout := parseTimeout(ready.Timeout, DefaultReadyTimeout)
+	deadline := time.After(timeout)
+
+	if ready.Log != "" {
+		select {
+		case <-writer.matched:
+		case result := <-done:
+			done <- result
+			return errExited
+		case <-deadline:
+			return fmt.Errorf("output didn't match %q after %s", ready.Log, timeout)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	if ready.Port != 0 {
+		address := fmt.Sprintf("127.0.0.1:%d", ready.Port)
+		for !dialable(address) {
+			select {
+			case result := <-done:
+				done <- result
+				return errExited
+			case <-deadline:
+				return fmt.Errorf("port %d not ready after %s", ready.Port, timeout)
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(100 * time.Millisecond):
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package runner_test
+
+import (
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/atkins/runner"
+)
+
+func runSupervisedPipeline(t *testing.T, yaml string) error {
+	t.Helper()
+
+	pipelines, err := runner.LoadPipelineFromReader(strings.NewReader(yaml))
+	require.NoError(t, err)
+
+	return runner.RunPipeline(t.Context(), pipelines[0], runner.PipelineOptions{
+		Jobs:   []string{"default"},
+		Silent: true,
+	})
+}
+
+func TestDetachedReady(t *testing.T) {
+	t.Run("continues once ready and stops with the job", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Chdir(dir)
+
+		start := time.Now()
+		err := runSupervisedPipeline(t, `
+jobs:
+  default:
+    steps:
+      - run: echo starting; sleep 0.1; echo listening; sleep 30
+        detach: true
+        ready: listening
+      - run: echo done > done.txt
+`)
+		require.NoError(t, err)
+		assert.FileExists(t, filepath.Join(dir, "done.txt"))
+		assert.Less(t, time.Since(start), 10*time.Second)
+	})
+
+	t.Run("fails the job when the step exits early", func(t *testing.T) {
+		start := time.Now()
+		err := runSupervisedPipeline(t, `
+jobs:
+  default:
+    steps:
+      - run: echo listening; sleep 0.2; echo crashed; exit 3
+        detach: true
+        ready:
+          log: listen
+      - run: sleep 30
+`)
+		require.Error(t, err)
+
+		var exitErr *runner.ExitedEarlyError
+		require.True(t, errors.As(err, &exitErr), err.Error())
+		assert.Equal(t, 3, exitErr.Result.ExitCode())
+		assert.Contains(t, err.Error(), "crashed")
+		assert.Less(t, time.Since(start), 10*time.Second)
+	})
+
+	t.Run("fails when the step exits before it is ready", func(t *testing.T) {
+		err := runSupervisedPipeline(t, `
+jobs:
+  default:
+    steps:
+      - run: echo "address already in use"; exit 1
+        detach: true
+        ready:
+          port: 1
+      - run: "true"
+`)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "exited early with code 1")
+		assert.Contains(t, err.Error(), "address already in use")
+	})
+
+	t.Run("fails when not ready in time", func(t *testing.T) {
+		err := runSupervisedPipeline(t, `
+jobs:
+  default:
+    steps:
+      - run: sleep 30
+        detach: true
+        ready:
+          log: never
+          timeout: 200ms
+`)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `output didn't match "never" after 200ms`)
+	})
+}
+
+func TestDetachedReady_Port(t *testing.T) {
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 is not installed")
+	}
+
+	err := runSupervisedPipeline(t, `
+jobs:
+  default:
+    steps:
+      - run: python3 -m http.server --bind 127.0.0.1 38471
+        detach: true
+        ready: 38471
+      - run: python3 -c "import urllib.request; urllib.request.urlopen('http://127.0.0.1:38471')"
+`)
+	require.NoError(t, err)
+}
@@ -94,8 +94,12 @@ func lookupJob(pipeline *model.Pipeline, name string) *model.Job {
 	return jobs[name]
 }
 
-// resolveAlias checks if alias matches any job alias.
+// resolveAlias checks if alias matches a pipeline alias or any job alias.
+// Aliases declared at the main pipeline level take precedence over job aliases.
 func (r *TaskResolver) resolveAlias(alias string) (*model.ResolvedTask, bool) {
+	if target, found := r.resolvePipelineAlias(alias); found {
+		return target, true
+	}
 	for _, pipeline := range r.pipelines {
 		aliases := pipeline.GetAliases()
 		if target, ok := aliases[alias]; ok {
@@ -105,6 +109,20 @@ func (r *TaskResolver) resolveAlias(alias string) (*model.ResolvedTask, bool) {
 	return nil, false
 }
 
+// resolvePipelineAlias resolves an alias from the main pipeline `aliases:`
+// map. The alias target must name a job exactly, e.g. `build` or `go:test`.
+func (r *TaskResolver) resolvePipelineAlias(alias string) (*model.ResolvedTask, bool) {
+	for _, pipeline := range r.pipelines {
+		if pipeline.ID != "" {
+			continue
+		}
+		if target, ok := pipeline.Aliases[alias]; ok {
+			return r.resolveExplicitTarget(strings.TrimPrefix(target, ":"))
+		}
+	}
+	return nil, false
+}
+
 // resolveFuzzyTarget handles fuzzy/substring matching across all pipelines.
 func (r *TaskResolver) resolveFuzzy(name string) (*model.ResolvedTask, error) {
 	matches := findFuzzyMatches(r.pipelines, name)
@@ -0,0 +1,47 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/atkins/model"
+)
+
+func TestTaskResolver_PipelineAliases(t *testing.T) {
+	pipelines := []*model.Pipeline{
+		{
+			Name:    "main",
+			Aliases: map[string]string{"t": "go:test", "b": ":build", "lint": "go:test", "x": "go:missing"},
+			Jobs: map[string]*model.Job{
+				"build": {Name: "build"},
+				"lint":  {Name: "lint"},
+			},
+		},
+		{
+			ID:   "go",
+			Name: "go",
+			Jobs: map[string]*model.Job{
+				"test": {Name: "test", Aliases: []string{"t", "gt"}},
+				"vet":  {Name: "vet", Aliases: []string{"x"}},
+			},
+		},
+	}
+	resolver := NewTaskResolver(pipelines)
+
+	for name, want := range map[string]string{
+		"t":    "go:test", // pipeline alias before job alias
+		"b":    "build",   // leading : in the target is allowed
+		"lint": "lint",    // exact job name before pipeline alias
+		"gt":   "go:test", // job alias
+		"x":    "go:vet",  // unresolvable pipeline alias falls back to job aliases
+	} {
+		resolved, err := resolver.Resolve(name)
+		require.NoError(t, err, name)
+		assert.Equal(t, want, resolved.Name, name)
+	}
+
+	_, err := resolver.Resolve(":t")
+	assert.Error(t, err, "strict names bypass aliases")
+}
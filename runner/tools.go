@@ -0,0 +1,229 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/titpetric/atkins/model"
+	"github.com/titpetric/atkins/psexec"
+)
+
+// ToolCacheDir holds locally installed tool versions, relative to the project root.
+// Layout is <dir>/<tool>/<version>/bin.
+var ToolCacheDir = filepath.Join(".atkins", "toolcache")
+
+// toolVersionCommands are the commands printing a tool's version,
+// for tools that don't support `--version`.
+var toolVersionCommands = map[string]string{
+	"go":    "go env GOVERSION",
+	"java":  "java -version",
+	"rustc": "rustc --version",
+}
+
+var versionPattern = regexp.MustCompile(`\d+(\.\d+)*`)
+
+// Environment of the install job run by --install-tools.
+const (
+	ToolDirEnv     = "ATKINS_TOOL_DIR"     // Install the tool into $ATKINS_TOOL_DIR/<version>/bin
+	ToolVersionEnv = "ATKINS_TOOL_VERSION" // Version constraint of the tool
+	toolInstallEnv = "ATKINS_INSTALL_TOOL" // Tool being installed, not verified by the install run
+)
+
+// atkinsExecutable returns the binary running install jobs.
+var atkinsExecutable = os.Executable
+
+// verifyTools checks the pinned tool versions before any job runs.
+// Matching versions found in the tool cache are prepended to PATH. With
+// install set, tools that don't match and have an `install:` job are
+// installed into the tool cache and checked again.
+func verifyTools(ctx context.Context, execCtx *ExecutionContext, tools model.Tools, install bool) error {
+	names := make([]string, 0, len(tools))
+	for name, tool := range tools {
+		if tool != nil && tool.Version != "" && name != execCtx.Env[toolInstallEnv] {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if bin := cachedToolBin(ToolCacheDir, name, tools[name].Version); bin != "" {
+			execCtx.Env["PATH"] = bin + string(os.PathListSeparator) + execCtx.Env["PATH"]
+		}
+	}
+
+	var problems []string
+	for _, name := range names {
+		tool := tools[name]
+		problem, err := checkTool(ctx, execCtx, name, tool)
+		if err != nil {
+			return err
+		}
+		if problem != "" && install && tool.Install != "" {
+			if err := installTool(ctx, execCtx, name, tool); err != nil {
+				return err
+			}
+			if problem, err = checkTool(ctx, execCtx, name, tool); err != nil {
+				return err
+			}
+		}
+		if problem != "" {
+			problems = append(problems, problem+toolHint(tool))
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.New("tool versions don't match:\n  " + strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+// checkTool runs the version command of the tool, returning why the
+// version doesn't match, or an empty string.
+func checkTool(ctx context.Context, execCtx *ExecutionContext, name string, tool *model.Tool) (string, error) {
+	script, ok := toolVersionCommands[name]
+	if !ok {
+		script = name + " --version"
+	}
+	exec := execCtx.newExecutor()
+	result, err := execCtx.runProcess(ctx, exec, exec.ShellCommand(script), script)
+	if err != nil {
+		return "", err
+	}
+	if !result.Success() {
+		return fmt.Sprintf("%s %s is required, but %s was not found", name, tool.Version, name), nil
+	}
+	version := versionPattern.FindString(result.Output() + result.ErrorOutput())
+	if !matchVersion(tool.Version, version) {
+		return fmt.Sprintf("%s %s is required, found %s", name, tool.Version, version), nil
+	}
+	return "", nil
+}
+
+// installTool runs `atkins <install job>` with $ATKINS_TOOL_DIR pointing
+// into the tool cache, and prepends the installed version to PATH.
+func installTool(ctx context.Context, execCtx *ExecutionContext, name string, tool *model.Tool) error {
+	self, err := atkinsExecutable()
+	if err != nil {
+		return err
+	}
+	dir, err := filepath.Abs(filepath.Join(ToolCacheDir, name))
+	if err != nil {
+		return err
+	}
+
+	// Run from the working directory of atkins, which finds the same skills
+	args := append(mainPipelineArgs(execCtx), tool.Install)
+	process := &psexec.Command{Name: self, Args: args, Env: execCtx.Env.Environ()}
+	process.Dir, _ = os.Getwd()
+	process.Env = append(process.Env, ToolDirEnv+"="+dir, ToolVersionEnv+"="+tool.Version, toolInstallEnv+"="+name)
+
+	executor := execCtx.newExecutor()
+	result, err := execCtx.runProcess(ctx, executor, process, psexec.QuoteArgs(append([]string{"atkins"}, args...)))
+	if err == nil {
+		err = result.Err()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to install %s with `atkins %s`: %w", name, tool.Install, err)
+	}
+
+	if bin := cachedToolBin(ToolCacheDir, name, tool.Version); bin != "" {
+		execCtx.Env["PATH"] = bin + string(os.PathListSeparator) + execCtx.Env["PATH"]
+	}
+	return nil
+}
+
+func toolHint(tool *model.Tool) string {
+	switch {
+	case tool.Install != "":
+		return fmt.Sprintf(" (run `atkins %s` to install)", tool.Install)
+	case tool.Hint != "":
+		return " (" + tool.Hint + ")"
+	}
+	return ""
+}
+
+// cachedToolBin returns the bin directory of the highest cached version
+// of the tool matching the constraint, or an empty string.
+func cachedToolBin(cacheDir, name, constraint string) string {
+	entries, err := os.ReadDir(filepath.Join(cacheDir, name))
+	if err != nil {
+		return ""
+	}
+
+	var best string
+	for _, entry := range entries {
+		version := entry.Name()
+		if !entry.IsDir() || !matchVersion(constraint, version) {
+			continue
+		}
+		if best == "" || compareVersions(version, best) > 0 {
+			best = version
+		}
+	}
+	if best == "" {
+		return ""
+	}
+
+	bin, err := filepath.Abs(filepath.Join(cacheDir, name, best, "bin"))
+	if err != nil {
+		return ""
+	}
+	return bin
+}
+
+// matchVersion reports whether version satisfies constraint.
+// A constraint is a version prefix where "x" or "*" match any
+// component ("1.22.x", "20"), optionally prefixed with ">=".
+func matchVersion(constraint, version string) bool {
+	version = versionPattern.FindString(version)
+	if version == "" {
+		return false
+	}
+
+	if minimum, ok := strings.CutPrefix(constraint, ">="); ok {
+		return compareVersions(version, strings.TrimSpace(minimum)) >= 0
+	}
+
+	want := strings.Split(strings.TrimPrefix(strings.TrimSpace(constraint), "v"), ".")
+	have := strings.Split(version, ".")
+	for i, part := range want {
+		if part == "x" || part == "*" {
+			continue
+		}
+		if i >= len(have) || part != have[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// compareVersions compares dotted numeric versions, returning -1, 0 or 1.
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,112 @@
+package runner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/atkins/model"
+)
+
+func TestMatchVersion(t *testing.T) {
+	tests := []struct {
+		constraint string
+		version    string
+		want       bool
+	}{
+		{"1.22.x", "go1.22.3", true},
+		{"1.22.x", "1.23.0", false},
+		{"20", "v20.11.0", true},
+		{"20", "v18.19.0", false},
+		{"1.22", "1.22.0", true},
+		{"v1.22.*", "1.22.9", true},
+		{">=1.21", "1.22.0", true},
+		{">=1.21", "1.20.14", false},
+		{"1.22.x", "", false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, matchVersion(tt.constraint, tt.version), "%s ~ %s", tt.constraint, tt.version)
+	}
+}
+
+func TestCachedToolBin(t *testing.T) {
+	dir := t.TempDir()
+	for _, version := range []string{"1.21.5", "1.22.1", "1.22.10"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "go", version, "bin"), 0o755))
+	}
+
+	assert.Equal(t, filepath.Join(dir, "go", "1.22.10", "bin"), cachedToolBin(dir, "go", "1.22.x"))
+	assert.Equal(t, filepath.Join(dir, "go", "1.21.5", "bin"), cachedToolBin(dir, "go", "1.21"))
+	assert.Empty(t, cachedToolBin(dir, "go", "1.23.x"))
+	assert.Empty(t, cachedToolBin(dir, "node", "20"))
+}
+
+func TestVerifyTools(t *testing.T) {
+	newCtx := func() *ExecutionContext {
+		return &ExecutionContext{Env: Env{"PATH": os.Getenv("PATH"), "HOME": os.Getenv("HOME")}}
+	}
+
+	t.Run("matching version", func(t *testing.T) {
+		err := verifyTools(context.Background(), newCtx(), model.Tools{"go": {Version: ">=1.0"}}, false)
+		assert.NoError(t, err)
+	})
+
+	t.Run("mismatched and missing tools", func(t *testing.T) {
+		err := verifyTools(context.Background(), newCtx(), model.Tools{
+			"go":                 {Version: "0.1.x"},
+			"atkins-missing-cli": {Version: "1", Hint: "brew install atkins-missing-cli"},
+		}, false)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "atkins-missing-cli 1 is required, but atkins-missing-cli was not found (brew install atkins-missing-cli)")
+		assert.Contains(t, err.Error(), "go 0.1.x is required, found ")
+	})
+
+	t.Run("denied by the policy", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "policy.yml")
+		require.NoError(t, os.WriteFile(path, []byte("deny: ['go *']\n"), 0o644))
+		policy, err := LoadPolicy(false, path)
+		require.NoError(t, err)
+
+		execCtx := newCtx()
+		execCtx.policy = policy
+		err = verifyTools(context.Background(), execCtx, model.Tools{"go": {Version: ">=1.0"}}, false)
+		var policyErr *PolicyError
+		require.ErrorAs(t, err, &policyErr)
+		assert.Equal(t, "go env GOVERSION", policyErr.Command)
+	})
+	t.Run("installed into the tool cache", func(t *testing.T) {
+		t.Chdir(t.TempDir())
+		installer := filepath.Join(t.TempDir(), "atkins")
+		script := `#!/bin/sh
+test "$1" = install-fake || exit 1
+mkdir -p "$ATKINS_TOOL_DIR/1.2.3/bin"
+printf '#!/bin/sh\necho atkins-fake-tool 1.2.3\n' > "$ATKINS_TOOL_DIR/1.2.3/bin/atkins-fake-tool"
+chmod +x "$ATKINS_TOOL_DIR/1.2.3/bin/atkins-fake-tool"
+`
+		require.NoError(t, os.WriteFile(installer, []byte(script), 0o755))
+		executable := atkinsExecutable
+		atkinsExecutable = func() (string, error) { return installer, nil }
+		t.Cleanup(func() { atkinsExecutable = executable })
+
+		tools := model.Tools{"atkins-fake-tool": {Version: "1.2.x", Install: "install-fake"}}
+		err := verifyTools(context.Background(), newCtx(), tools, false)
+		assert.ErrorContains(t, err, "run `atkins install-fake` to install")
+
+		execCtx := newCtx()
+		require.NoError(t, verifyTools(context.Background(), execCtx, tools, true))
+		assert.DirExists(t, filepath.Join(ToolCacheDir, "atkins-fake-tool", "1.2.3", "bin"))
+		assert.Contains(t, execCtx.Env["PATH"], filepath.Join(ToolCacheDir, "atkins-fake-tool", "1.2.3", "bin"))
+	})
+
+	t.Run("skipped by its install run", func(t *testing.T) {
+		execCtx := newCtx()
+		execCtx.Env[toolInstallEnv] = "atkins-missing-cli"
+		err := verifyTools(context.Background(), execCtx, model.Tools{"atkins-missing-cli": {Version: "1"}}, false)
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,72 @@
+package runner
+
+import (
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/titpetric/atkins/eventlog"
+)
+
+// traceFD is the file descriptor the shell writes the xtrace stream to,
+// keeping it apart from the command output.
+const traceFD = 19
+
+// traceLinePattern matches the PS4 prefix set by traceScript.
+var traceLinePattern = regexp.MustCompile(`^(\++)(\d+)\t`)
+
+// traceScript wraps script so the shell writes the commands it executes
+// to path. PS4 prefixes each traced command with its line number.
+func traceScript(script, path string) string {
+	fd := strconv.Itoa(traceFD)
+	prefix := "exec " + fd + ">>'" + strings.ReplaceAll(path, "'", `'\''`) + "'; " +
+		"BASH_XTRACEFD=" + fd + "; PS4='+${LINENO}\t'; set -x\n"
+	return prefix + script
+}
+
+// traceLineOffset is the number of lines before the script in the shell
+// command: `set -o pipefail` and the traceScript prefix.
+const traceLineOffset = 2
+
+// newTraceFile creates the file receiving the xtrace stream.
+func newTraceFile() (string, error) {
+	f, err := os.CreateTemp("", "atkins-trace-*")
+	if err != nil {
+		return "", err
+	}
+	return f.Name(), f.Close()
+}
+
+// readTrace reads and removes the trace file.
+func readTrace(path string) []eventlog.TraceEntry {
+	defer os.Remove(path)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	return parseTrace(string(data))
+}
+
+// parseTrace parses the xtrace stream into entries. Lines without the
+// PS4 prefix continue the previous command, e.g. multi-line strings.
+func parseTrace(trace string) []eventlog.TraceEntry {
+	var entries []eventlog.TraceEntry
+	for _, line := range strings.Split(strings.TrimSuffix(trace, "\n"), "\n") {
+		match := traceLinePattern.FindStringSubmatch(line)
+		if match == nil {
+			if n := len(entries); n > 0 {
+				entries[n-1].Command += "\n" + line
+			}
+			continue
+		}
+
+		lineNo, _ := strconv.Atoi(match[2])
+		entries = append(entries, eventlog.TraceEntry{
+			Line:    max(lineNo-traceLineOffset, 1),
+			Depth:   len(match[1]),
+			Command: line[len(match[0]):],
+		})
+	}
+	return entries
+}
@@ -0,0 +1,62 @@
+package runner
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/atkins/eventlog"
+)
+
+func TestParseTrace(t *testing.T) {
+	entries := parseTrace("+3\techo a\n++4\techo 'b\nc'\n+4\tx='b\nc'\n+5\tfalse\n")
+	assert.Equal(t, []eventlog.TraceEntry{
+		{Line: 1, Depth: 1, Command: "echo a"},
+		{Line: 2, Depth: 2, Command: "echo 'b\nc'"},
+		{Line: 2, Depth: 1, Command: "x='b\nc'"},
+		{Line: 3, Depth: 1, Command: "false"},
+	}, entries)
+}
+
+func TestStepTrace(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	logFile := filepath.Join(dir, "events.yml")
+
+	pipelines, err := LoadPipelineFromReader(strings.NewReader(`
+jobs:
+  default:
+    steps:
+      - trace: true
+        run: |
+          name=world
+          echo "hello $name"
+          test "$name" = nobody
+`))
+	require.NoError(t, err)
+
+	err = RunPipeline(t.Context(), pipelines[0], PipelineOptions{
+		Jobs:    []string{"default"},
+		Silent:  true,
+		LogFile: logFile,
+	})
+	require.Error(t, err)
+
+	log, err := eventlog.ReadLog(logFile)
+	require.NoError(t, err)
+
+	var trace []eventlog.TraceEntry
+	for _, event := range log.Events {
+		if len(event.Trace) > 0 {
+			trace = event.Trace
+		}
+	}
+	assert.Equal(t, []eventlog.TraceEntry{
+		{Line: 1, Depth: 1, Command: "name=world"},
+		{Line: 2, Depth: 1, Command: "echo 'hello world'"},
+		{Line: 3, Depth: 1, Command: "test world = nobody"},
+	}, trace)
+}
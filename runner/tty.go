@@ -0,0 +1,17 @@
+package runner
+
+import (
+	"cmp"
+
+	"github.com/titpetric/atkins/model"
+)
+
+// stepTTY returns true when the step commands run with a PTY. The step
+// tty overrides the tty of the job, and neither set runs without.
+func stepTTY(job *model.Job, step *model.Step) bool {
+	mode := step.TTY
+	if job != nil {
+		mode = cmp.Or(mode, job.TTY)
+	}
+	return mode == model.TTYAlways
+}
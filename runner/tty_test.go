@@ -0,0 +1,75 @@
+package runner
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStepTTY(t *testing.T) {
+	pipelines, err := LoadPipelineFromReader(strings.NewReader(`
+jobs:
+  default:
+    tty: true
+    steps:
+      - run: 'true'
+      - run: 'true'
+        tty: never
+      - run: 'true'
+        tty: auto
+  plain:
+    steps:
+      - run: 'true'
+      - run: 'true'
+        tty: always
+`))
+	require.NoError(t, err)
+
+	jobs := pipelines[0].GetJobs()
+	steps := jobs["default"].Children()
+	assert.True(t, stepTTY(jobs["default"], steps[0]))
+	assert.False(t, stepTTY(jobs["default"], steps[1]))
+	assert.True(t, stepTTY(jobs["default"], steps[2]))
+
+	steps = jobs["plain"].Children()
+	assert.False(t, stepTTY(jobs["plain"], steps[0]))
+	assert.True(t, stepTTY(jobs["plain"], steps[1]))
+	assert.True(t, stepTTY(nil, steps[1]))
+
+	_, err = LoadPipelineFromReader(strings.NewReader(`
+jobs:
+  default:
+    steps:
+      - run: 'true'
+        tty: sometimes
+`))
+	assert.ErrorContains(t, err, `invalid tty "sometimes"`)
+}
+
+func TestRunPipeline_TTY(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	pipelines, err := LoadPipelineFromReader(strings.NewReader(`
+log_sinks:
+  - type: file
+    path: logs.jsonl
+jobs:
+  default:
+    tty: always
+    steps:
+      - run: 'if [ -t 1 ]; then printf tty; else printf pipe; fi'
+      - run: 'if [ -t 1 ]; then printf tty; else printf pipe; fi'
+        tty: never
+`))
+	require.NoError(t, err)
+
+	err = RunPipeline(t.Context(), pipelines[0], PipelineOptions{Jobs: []string{"default"}, Silent: true})
+	require.NoError(t, err)
+
+	records := readLogRecords(t, "logs.jsonl")
+	require.Len(t, records, 3)
+	assert.Equal(t, "tty", records[0].Output)
+	assert.Equal(t, "pipe", records[1].Output)
+}
@@ -189,3 +189,25 @@ func topologicalSort(deps map[string][]string) ([]string, error) {
 	}
 	return order, nil
 }
+
+// valueText joins all strings nested in v, so dependencies of structured
+// values can be extracted like those of plain strings.
+func valueText(v any) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case map[string]any:
+		parts := make([]string, 0, len(val))
+		for _, item := range val {
+			parts = append(parts, valueText(item))
+		}
+		return strings.Join(parts, "\n")
+	case []any:
+		parts := make([]string, 0, len(val))
+		for _, item := range val {
+			parts = append(parts, valueText(item))
+		}
+		return strings.Join(parts, "\n")
+	}
+	return ""
+}
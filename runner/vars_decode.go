@@ -0,0 +1,80 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// varDecoders parse command output into structured values, used with
+// `vars: {pods: {json: "$(kubectl get pods -o json)"}}`.
+var varDecoders = map[string]func(string) (any, error){
+	"json": func(s string) (any, error) {
+		var v any
+		err := json.Unmarshal([]byte(s), &v)
+		return v, err
+	},
+	"yaml": func(s string) (any, error) {
+		var v any
+		err := yaml.Unmarshal([]byte(s), &v)
+		return v, err
+	},
+	"lines": func(s string) (any, error) {
+		lines := []any{}
+		for _, line := range strings.Split(s, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				lines = append(lines, line)
+			}
+		}
+		return lines, nil
+	},
+}
+
+// resolveVar interpolates a variable value and decodes structured values.
+func resolveVar(ctx *ExecutionContext, v any) (any, error) {
+	interpolated, err := InterpolateValue(ctx, v)
+	if err != nil {
+		return nil, err
+	}
+	if format, ok := decodeFormat(v); ok {
+		return decodeVar(format, interpolated)
+	}
+	return interpolated, nil
+}
+
+// decodeFormat returns the format of a `{json: $(...)}`, `{yaml: $(...)}`
+// or `{lines: $(...)}` value. Only command substitutions are decoded, so
+// literal maps with a `json` key are kept as they are.
+func decodeFormat(v any) (string, bool) {
+	m, ok := v.(map[string]any)
+	if !ok || len(m) != 1 {
+		return "", false
+	}
+	for format, raw := range m {
+		s, ok := raw.(string)
+		if !ok || varDecoders[format] == nil {
+			return "", false
+		}
+		s = strings.TrimSpace(s)
+		return format, strings.HasPrefix(s, "$(") && strings.HasSuffix(s, ")")
+	}
+	return "", false
+}
+
+// decodeVar decodes the interpolated value of a decodeFormat value.
+func decodeVar(format string, v any) (any, error) {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return v, nil
+	}
+	if s, ok := m[format].(string); ok {
+		decoded, err := varDecoders[format](s)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s value: %w", format, err)
+		}
+		return decoded, nil
+	}
+	return v, nil
+}
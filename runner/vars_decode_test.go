@@ -0,0 +1,86 @@
+package runner_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/atkins/runner"
+)
+
+func TestStructuredVars(t *testing.T) {
+	pipelines, err := runner.LoadPipelineFromReader(strings.NewReader(`
+vars:
+  pods:
+    json: $(echo '{"items":[{"name":"api"},{"name":"web"}]}')
+  config:
+    yaml: "$(printf 'replicas: 3\\nimage: app\\n')"
+jobs:
+  default:
+    vars:
+      files:
+        lines: $(printf 'a.go\nb.go\n\n')
+    steps:
+      - test "${{ len(pods.items) }}" = "2"
+      - test "${{ config.replicas }}" = "3"
+      - test "${{ files[1] }}" = "b.go"
+      - for: pod in pods.items
+        run: test -n "${{ pod.name }}"
+      - for: file in files
+        run: test "${{ file }}" != ""
+`))
+	require.NoError(t, err)
+
+	err = runner.RunPipeline(t.Context(), pipelines[0], runner.PipelineOptions{
+		Jobs:         []string{"default"},
+		Silent:       true,
+		AllPipelines: pipelines,
+	})
+	assert.NoError(t, err)
+}
+
+func TestStructuredVars_InvalidJSON(t *testing.T) {
+	pipelines, err := runner.LoadPipelineFromReader(strings.NewReader(`
+vars:
+  pods:
+    json: $(echo 'not json')
+jobs:
+  default:
+    steps:
+      - echo ok
+`))
+	require.NoError(t, err)
+
+	err = runner.RunPipeline(t.Context(), pipelines[0], runner.PipelineOptions{
+		Jobs:         []string{"default"},
+		Silent:       true,
+		AllPipelines: pipelines,
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to decode json value")
+}
+
+func TestStructuredVars_LiteralMap(t *testing.T) {
+	pipelines, err := runner.LoadPipelineFromReader(strings.NewReader(`
+vars:
+  formats:
+    json: application/json
+  payload:
+    yaml: "key: value"
+jobs:
+  default:
+    steps:
+      - test "${{ formats.json }}" = "application/json"
+      - test "${{ payload.yaml }}" = "key: value"
+`))
+	require.NoError(t, err)
+
+	err = runner.RunPipeline(t.Context(), pipelines[0], runner.PipelineOptions{
+		Jobs:         []string{"default"},
+		Silent:       true,
+		AllPipelines: pipelines,
+	})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,48 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+
+	"github.com/titpetric/atkins/treeview"
+)
+
+// warningLog collects the warnings of a run, shared across copies of
+// the execution context. Printing them while the tree renders would
+// tear it, so they're printed after it, and written to the event log.
+type warningLog struct {
+	mu       sync.Mutex
+	warnings []string
+}
+
+// add records a warning, once.
+func (w *warningLog) add(format string, args ...any) {
+	if w == nil {
+		return
+	}
+	warning := fmt.Sprintf(format, args...)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !slices.Contains(w.warnings, warning) {
+		w.warnings = append(w.warnings, warning)
+	}
+}
+
+// list returns the warnings in the order they were added.
+func (w *warningLog) list() []string {
+	if w == nil {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]string(nil), w.warnings...)
+}
+
+// printWarnings prints the warnings of the run.
+func printWarnings(w io.Writer, warnings []string) {
+	for _, warning := range warnings {
+		fmt.Fprintf(w, "%s %s\n", treeview.WarningHeader(), warning)
+	}
+}
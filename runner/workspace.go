@@ -0,0 +1,269 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Workspace modes for `workspace:` on jobs.
+const (
+	WorkspaceClean    = "clean"    // Run steps in a temporary copy of the project
+	WorkspaceWorktree = "worktree" // Run steps in a git worktree of HEAD
+)
+
+// Workspace is an isolated directory a job runs in.
+type Workspace struct {
+	Root string // Project root the workspace was created from
+	Dir  string // Workspace directory
+
+	remove func() error
+}
+
+// worktreeMu serializes git worktree changes, git locks its
+// administrative files and fails when jobs add worktrees concurrently.
+var worktreeMu sync.Mutex
+
+// newCleanWorkspace copies the project root into a temporary directory.
+// The .atkins state directory is not copied.
+func newCleanWorkspace(root, jobName string) (*Workspace, error) {
+	dir, err := os.MkdirTemp("", "atkins-"+workspaceName(jobName)+"-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create workspace: %w", err)
+	}
+
+	remove := func() error { return removeTree(dir) }
+	if err := copyTree(root, dir, map[string]bool{".atkins": true}); err != nil {
+		remove()
+		return nil, fmt.Errorf("failed to copy project into workspace: %w", err)
+	}
+
+	return &Workspace{Root: root, Dir: dir, remove: remove}, nil
+}
+
+// newWorktreeWorkspace checks out HEAD into a detached git worktree in a
+// temporary directory. When the project root is a subdirectory of the
+// repository, the workspace points at the same subdirectory.
+func newWorktreeWorkspace(root, jobName string) (*Workspace, error) {
+	workspace, err := newGitWorktree(root, workspaceName(jobName), "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("workspace %q %w", WorkspaceWorktree, err)
+	}
+	return workspace, nil
+}
+
+// NewRefWorkspace checks out a git ref, e.g. a tag or a commit, into a
+// detached worktree in a temporary directory, for `atkins --ref`. Like
+// the worktree workspace, it points at the subdirectory of root.
+func NewRefWorkspace(root, ref string) (*Workspace, error) {
+	workspace, err := newGitWorktree(root, "ref-"+workspaceName(ref), ref)
+	if err != nil {
+		return nil, fmt.Errorf("--ref %s: %w", ref, err)
+	}
+	return workspace, nil
+}
+
+// newGitWorktree adds a detached worktree of ref in a temporary directory,
+// removed with the workspace.
+func newGitWorktree(root, name, ref string) (*Workspace, error) {
+	toplevel, err := gitOutput(root, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, fmt.Errorf("requires a git repository: %w", err)
+	}
+	if _, err := gitOutput(root, "rev-parse", "--verify", "--quiet", ref+"^{commit}"); err != nil {
+		return nil, fmt.Errorf("unknown git ref %q", ref)
+	}
+	resolved, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		resolved = root
+	}
+	rel, err := filepath.Rel(toplevel, resolved)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		rel = "."
+	}
+
+	dir, err := os.MkdirTemp("", "atkins-"+name+"-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create workspace: %w", err)
+	}
+
+	worktreeMu.Lock()
+	_, err = gitOutput(root, "worktree", "add", "--detach", dir, ref)
+	worktreeMu.Unlock()
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("failed to create git worktree: %w", err)
+	}
+
+	return &Workspace{
+		Root: root,
+		Dir:  filepath.Join(dir, rel),
+		remove: func() error {
+			worktreeMu.Lock()
+			defer worktreeMu.Unlock()
+			if _, err := gitOutput(root, "worktree", "remove", "--force", dir); err != nil {
+				return err
+			}
+			_, err := gitOutput(root, "worktree", "prune")
+			return err
+		},
+	}, nil
+}
+
+// gitOutput runs git in dir and returns its trimmed output.
+func gitOutput(dir string, args ...string) (string, error) {
+	return gitOutputContext(context.Background(), dir, args...)
+}
+
+// gitOutputContext runs git like gitOutput, killing it when ctx is done.
+func gitOutputContext(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.WaitDelay = time.Second
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// Path maps a directory from the project root into the workspace.
+// Relative paths are resolved against the workspace, absolute paths
+// outside of the project root are returned unchanged.
+func (w *Workspace) Path(dir string) string {
+	if w == nil {
+		return dir
+	}
+	if dir == "" {
+		return w.Dir
+	}
+	if !filepath.IsAbs(dir) {
+		return filepath.Join(w.Dir, dir)
+	}
+	rel, err := filepath.Rel(w.Root, dir)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return dir
+	}
+	return filepath.Join(w.Dir, rel)
+}
+
+// Remove deletes the workspace directory.
+func (w *Workspace) Remove() error {
+	if w.remove != nil {
+		return w.remove()
+	}
+	return os.RemoveAll(w.Dir)
+}
+
+// workspaceName returns a job name usable in a directory name.
+func workspaceName(jobName string) string {
+	return strings.NewReplacer("/", "-", ":", "-", string(filepath.Separator), "-").Replace(jobName)
+}
+
+// copyTree copies the contents of src into dst, preserving file modes
+// and symlinks. Top-level entries named in skip are not copied.
+// Directories are writable until their contents are copied, their
+// modes are set last, so read-only directories can be copied.
+func copyTree(src, dst string, skip map[string]bool) error {
+	type dirMode struct {
+		path string
+		perm fs.FileMode
+	}
+	var dirs []dirMode
+
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		if skip[rel] {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		target := filepath.Join(dst, rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case d.IsDir():
+			dirs = append(dirs, dirMode{target, info.Mode().Perm()})
+			return os.MkdirAll(target, 0o700)
+		case info.Mode()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case info.Mode().IsRegular():
+			return copyFile(path, target, info.Mode().Perm())
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if err := os.Chmod(dirs[i].path, dirs[i].perm); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeTree removes dir like os.RemoveAll, making the directories
+// writable first, as copyTree keeps read-only directories read-only.
+func removeTree(dir string) error {
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && d.IsDir() {
+			os.Chmod(path, 0o700)
+		}
+		return nil
+	})
+	return os.RemoveAll(dir)
+}
+
+func copyFile(src, dst string, perm fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
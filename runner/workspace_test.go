@@ -0,0 +1,171 @@
+package runner_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/atkins/runner"
+)
+
+func TestWorkspaceClean(t *testing.T) {
+	project := t.TempDir()
+	t.Chdir(project)
+	require.NoError(t, os.WriteFile("input.txt", []byte("data"), 0o644))
+	require.NoError(t, os.MkdirAll(filepath.Join(".atkins", "runs"), 0o755))
+
+	run := func(script string) error {
+		pipelines, err := runner.LoadPipelineFromReader(strings.NewReader(`
+jobs:
+  default:
+    workspace: clean
+    steps:
+      - run: ` + script + `
+`))
+		require.NoError(t, err)
+		return runner.RunPipeline(t.Context(), pipelines[0], runner.PipelineOptions{
+			Jobs:         []string{"default"},
+			JSON:         true,
+			AllPipelines: pipelines,
+		})
+	}
+
+	t.Run("passing job leaves checkout untouched", func(t *testing.T) {
+		err := run("test -f input.txt && test ! -d .atkins && touch created.txt")
+		require.NoError(t, err)
+		assert.NoFileExists(t, filepath.Join(project, "created.txt"))
+	})
+
+	t.Run("failing job keeps workspace", func(t *testing.T) {
+		err := run("touch created.txt && false")
+		require.Error(t, err)
+		assert.NoFileExists(t, filepath.Join(project, "created.txt"))
+
+		match := regexp.MustCompile(`workspace kept at (\S+)`).FindStringSubmatch(err.Error())
+		require.Len(t, match, 2, err.Error())
+		t.Cleanup(func() { os.RemoveAll(match[1]) })
+		assert.FileExists(t, filepath.Join(match[1], "created.txt"))
+	})
+
+	t.Run("read-only directories are copied", func(t *testing.T) {
+		require.NoError(t, os.MkdirAll(filepath.Join("readonly", "nested"), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join("readonly", "nested", "file.txt"), []byte("data"), 0o644))
+		require.NoError(t, os.Chmod(filepath.Join("readonly", "nested"), 0o555))
+		require.NoError(t, os.Chmod("readonly", 0o555))
+		t.Cleanup(func() {
+			os.Chmod("readonly", 0o755)
+			os.Chmod(filepath.Join("readonly", "nested"), 0o755)
+		})
+
+		err := run(`test -f readonly/nested/file.txt && test -n "$(find readonly/nested -prune -perm 555)"`)
+		require.NoError(t, err)
+	})
+
+	t.Run("absolute step dir is mapped into the workspace", func(t *testing.T) {
+		require.NoError(t, os.MkdirAll("sub", 0o755))
+		pipelines, err := runner.LoadPipelineFromReader(strings.NewReader(`
+jobs:
+  default:
+    workspace: clean
+    steps:
+      - run: touch created.txt
+        dir: ` + filepath.Join(project, "sub") + `
+`))
+		require.NoError(t, err)
+		err = runner.RunPipeline(t.Context(), pipelines[0], runner.PipelineOptions{
+			Jobs:         []string{"default"},
+			JSON:         true,
+			AllPipelines: pipelines,
+		})
+		require.NoError(t, err)
+		assert.NoFileExists(t, filepath.Join(project, "sub", "created.txt"))
+	})
+}
+
+func TestWorkspaceWorktree(t *testing.T) {
+	project := t.TempDir()
+	t.Chdir(project)
+
+	git := func(args ...string) string {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=atkins", "-c", "user.email=atkins@example.com"}, args...)...)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+		return string(out)
+	}
+	git("init", "-q")
+	require.NoError(t, os.WriteFile("input.txt", []byte("data"), 0o644))
+	git("add", "input.txt")
+	git("commit", "-q", "-m", "initial")
+
+	pipelines, err := runner.LoadPipelineFromReader(strings.NewReader(`
+jobs:
+  default:
+    depends_on: [build-a, build-b]
+    steps:
+      - run: test ! -f out.txt
+  build-a:
+    detach: true
+    workspace: worktree
+    steps:
+      - run: test -f input.txt && echo a > out.txt && sleep 0.1 && grep -q a out.txt
+  build-b:
+    detach: true
+    workspace: worktree
+    steps:
+      - run: test -f input.txt && echo b > out.txt && sleep 0.1 && grep -q b out.txt
+`))
+	require.NoError(t, err)
+
+	err = runner.RunPipeline(t.Context(), pipelines[0], runner.PipelineOptions{
+		Jobs:         []string{"default"},
+		JSON:         true,
+		AllPipelines: pipelines,
+	})
+	require.NoError(t, err)
+
+	assert.NoFileExists(t, filepath.Join(project, "out.txt"))
+	assert.Len(t, strings.Split(strings.TrimSpace(git("worktree", "list")), "\n"), 1, "worktrees should be pruned")
+}
+
+func TestNewRefWorkspace(t *testing.T) {
+	project := t.TempDir()
+	t.Chdir(project)
+
+	git := func(args ...string) string {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=atkins", "-c", "user.email=atkins@example.com"}, args...)...)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+		return string(out)
+	}
+	git("init", "-q")
+	require.NoError(t, os.MkdirAll("sub", 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join("sub", "version.txt"), []byte("v1"), 0o644))
+	git("add", ".")
+	git("commit", "-q", "-m", "initial")
+	git("tag", "v1")
+	require.NoError(t, os.WriteFile(filepath.Join("sub", "version.txt"), []byte("v2"), 0o644))
+
+	// The workspace points at the same subdirectory, checked out at the ref
+	workspace, err := runner.NewRefWorkspace(filepath.Join(project, "sub"), "v1")
+	require.NoError(t, err)
+	data, err := os.ReadFile(filepath.Join(workspace.Dir, "version.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "v1", string(data))
+
+	require.NoError(t, workspace.Remove())
+	assert.NoDirExists(t, workspace.Dir)
+	assert.Len(t, strings.Split(strings.TrimSpace(git("worktree", "list")), "\n"), 1, "worktrees should be pruned")
+	assert.Equal(t, " M sub/version.txt\n", git("status", "--porcelain"))
+
+	_, err = runner.NewRefWorkspace(project, "v9")
+	assert.ErrorContains(t, err, `--ref v9: unknown git ref "v9"`)
+
+	_, err = runner.NewRefWorkspace(t.TempDir(), "v1")
+	assert.ErrorContains(t, err, "--ref v1: requires a git repository")
+}
@@ -0,0 +1,91 @@
+//go:build ignore
+
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/titpetric/atkins/version"
+)
+
+// sign creates the release key and signs the checksums of a release,
+// in the format `atkins self-update` verifies.
+//
+//	go run ./scripts/sign.go keygen
+//	go run ./scripts/sign.go check
+//	go run ./scripts/sign.go sign dist/checksums.txt
+//
+// The private key is read from $ATKINS_RELEASE_SIGNING_KEY, and the
+// signature is verified with the public key in $ATKINS_RELEASE_KEY, the
+// one built into the binaries, before it's written to <file>.sig. check
+// verifies the keys are set and match before the release is built.
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "sign: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	switch {
+	case len(args) == 1 && args[0] == "keygen":
+		public, private, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("ATKINS_RELEASE_KEY=%s\n", base64.StdEncoding.EncodeToString(public))
+		fmt.Printf("ATKINS_RELEASE_SIGNING_KEY=%s\n", base64.StdEncoding.EncodeToString(private))
+		return nil
+	case len(args) == 1 && args[0] == "check":
+		_, err := signature([]byte("atkins"))
+		return err
+	case len(args) == 2 && args[0] == "sign":
+		data, err := os.ReadFile(args[1])
+		if err != nil {
+			return err
+		}
+		sig, err := signature(data)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(args[1]+".sig", sig, 0o644)
+	}
+	return errors.New("usage: sign keygen | sign check | sign sign <file>")
+}
+
+// signature returns the base64 encoded signature of data, verified with
+// the public key.
+func signature(data []byte) ([]byte, error) {
+	private, err := decodeKey("ATKINS_RELEASE_SIGNING_KEY", ed25519.PrivateKeySize)
+	if err != nil {
+		return nil, err
+	}
+	public, err := decodeKey("ATKINS_RELEASE_KEY", ed25519.PublicKeySize)
+	if err != nil {
+		return nil, err
+	}
+
+	sig := []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(ed25519.PrivateKey(private), data)) + "\n")
+	if err := version.VerifySignature(ed25519.PublicKey(public), data, sig); err != nil {
+		return nil, fmt.Errorf("$ATKINS_RELEASE_SIGNING_KEY doesn't match $ATKINS_RELEASE_KEY: %w", err)
+	}
+	return sig, nil
+}
+
+// decodeKey returns the base64 encoded key in the environment variable.
+func decodeKey(name string, size int) ([]byte, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return nil, fmt.Errorf("$%s is not set, create the keys with `go run ./scripts/sign.go keygen`", name)
+	}
+	key, err := base64.StdEncoding.DecodeString(value)
+	if err != nil || len(key) != size {
+		return nil, fmt.Errorf("$%s is not a base64 encoded ed25519 key", name)
+	}
+	return key, nil
+}
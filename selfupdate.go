@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/pflag"
+	"github.com/titpetric/cli"
+
+	"github.com/titpetric/atkins/colors"
+	"github.com/titpetric/atkins/runner"
+	"github.com/titpetric/atkins/treeview"
+	"github.com/titpetric/atkins/version"
+)
+
+// SelfUpdate provides a cli.Command replacing the running binary with
+// the latest release of a channel.
+func SelfUpdate() *cli.Command {
+	var (
+		channel string
+		force   bool
+	)
+
+	return &cli.Command{
+		Name:  "self-update",
+		Title: "Update atkins to the latest release",
+		Usage: func() string {
+			return "atkins self-update [--channel stable|edge] [--force]"
+		},
+		Bind: func(fs *pflag.FlagSet) {
+			fs.StringVar(&channel, "channel", version.ChannelStable, "Release channel: stable, or edge to include pre-releases")
+			fs.BoolVar(&force, "force", false, "Install the release even if it isn't newer")
+		},
+		Run: func(ctx context.Context, args []string) error {
+			if len(args) != 0 {
+				return fmt.Errorf("%s expected: self-update [--channel stable|edge]", treeview.ErrorHeader())
+			}
+			return runSelfUpdate(ctx, channel, force)
+		},
+	}
+}
+
+func runSelfUpdate(ctx context.Context, channel string, force bool) error {
+	updater, err := version.NewUpdater(ReleaseKey)
+	if err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+	updater.Token = os.Getenv("GITHUB_TOKEN")
+
+	release, err := updater.Latest(ctx, channel)
+	if err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+	if cmp, ok := version.Compare(Version, release.Tag); ok && cmp >= 0 && !force {
+		fmt.Printf("%s atkins %s is up to date (%s)\n", colors.BrightGreen("✓"), Version, channel)
+		return nil
+	}
+
+	binary, err := updater.Download(ctx, release)
+	if err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+	if err := version.Replace(exe, binary); err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+	fmt.Printf("%s atkins %s → %s (%s): %s\n", colors.BrightGreen("✓"), Version, release.Tag, channel, exe)
+	return nil
+}
+
+// runVersionCheck warns if a pipeline of the working directory requires
+// another atkins than the running binary, by its `requires: {atkins:}`.
+func runVersionCheck() error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+	// Pipelines requiring another atkins fail to load, load them as a
+	// development build to report all of them
+	runner.AtkinsVersion = "dev"
+	pipelines, _, _, err := discoverPipelines(cwd)
+	runner.AtkinsVersion = Version
+	if err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+
+	fmt.Println()
+	var checked bool
+	for _, p := range pipelines {
+		constraint := p.Requires.Atkins
+		if constraint == "" {
+			continue
+		}
+		checked = true
+		name := p.Source
+		if p.ID != "" {
+			name = "skill " + p.ID
+		}
+		_, release := version.Compare(Version, Version)
+		switch err := runner.CheckAtkinsVersion(p, Version); {
+		case err != nil:
+			fmt.Printf("%s %s: %v\n", treeview.WarningHeader(), name, err)
+		case !release:
+			fmt.Printf("%s %s requires atkins %s, %s isn't a release to compare with\n", treeview.WarningHeader(), name, constraint, Version)
+		default:
+			fmt.Printf("%s %s requires atkins %s\n", colors.BrightGreen("✓"), name, constraint)
+		}
+	}
+	if !checked {
+		fmt.Printf("%s no pipeline requires an atkins version\n", colors.BrightGreen("✓"))
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/pflag"
+	"github.com/titpetric/cli"
+
+	"github.com/titpetric/atkins/colors"
+	"github.com/titpetric/atkins/model"
+	"github.com/titpetric/atkins/runner"
+	"github.com/titpetric/atkins/treeview"
+)
+
+// Skills provides a cli.Command to inspect the skills available to a
+// project, and to pull skills from OCI registries.
+func Skills() *cli.Command {
+	var global bool
+
+	return &cli.Command{
+		Name:  "skills",
+		Title: "Inspect and pull skills",
+		Usage: func() string {
+			return "atkins skills doctor\n" +
+				"atkins skills pull [--global] [oci://registry/repository:tag]"
+		},
+		Bind: func(fs *pflag.FlagSet) {
+			fs.BoolVar(&global, "global", false, "Pull into $HOME/.atkins/skills instead of the project")
+		},
+		Run: func(ctx context.Context, args []string) error {
+			if len(args) == 1 && args[0] == "doctor" {
+				return runSkillsDoctor()
+			}
+			if len(args) >= 1 && args[0] == "pull" && len(args) <= 2 {
+				return runSkillsPull(ctx, args[1:], global)
+			}
+			return fmt.Errorf("%s expected: skills doctor, or skills pull [oci://...]", treeview.ErrorHeader())
+		},
+	}
+}
+
+// runSkillsPull pulls a skill bundle into the skills directory and pins
+// its digest in the lockfile. Without a reference, it pulls all the skills
+// of the lockfile at their pinned digests.
+func runSkillsPull(ctx context.Context, args []string, global bool) error {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+
+	root := home
+	if !global {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+		}
+		root = cwd
+		if _, configDir, err := runner.DiscoverConfig(cwd); err == nil {
+			root = configDir
+		}
+	}
+	skillsDir := filepath.Join(root, ".atkins", "skills")
+	lockPath := filepath.Join(root, runner.SkillsLockFile)
+
+	lock, err := runner.LoadSkillsLock(lockPath)
+	if err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+
+	client := runner.NewOCIClient(filepath.Join(home, runner.OCICacheDir))
+	client.Username = os.Getenv("ATKINS_REGISTRY_USERNAME")
+	client.Password = os.Getenv("ATKINS_REGISTRY_PASSWORD")
+
+	pull := func(ref, pinned string) error {
+		parsed, err := runner.ParseOCIReference(ref)
+		if err != nil {
+			return err
+		}
+		if pinned != "" {
+			parsed.Digest = pinned
+		}
+		digest, files, err := client.Pull(ctx, parsed, skillsDir)
+		if err != nil {
+			return err
+		}
+		lock.Set(runner.LockedSkill{Ref: ref, Digest: digest, Files: files})
+		fmt.Printf("%s %s@%s (%d files)\n", colors.BrightGreen("✓"), ref, digest, len(files))
+		return nil
+	}
+
+	if len(args) == 1 {
+		err = pull(args[0], "")
+	} else {
+		if len(lock.Skills) == 0 {
+			return fmt.Errorf("%s no skills in %s, expected: skills pull oci://...", treeview.ErrorHeader(), lockPath)
+		}
+		for _, skill := range lock.Skills {
+			if err = pull(skill.Ref, skill.Digest); err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+
+	if err := lock.Save(lockPath); err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+	return nil
+}
+
+// runSkillsDoctor lists the effective skill set of the working directory
+// and audits it for skill ID and alias conflicts, and shadowed aliases.
+func runSkillsDoctor() error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+
+	pipelines, shadowed, _, err := discoverPipelines(cwd)
+	if err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+
+	fmt.Println(colors.BrightWhite("Skills"))
+	fmt.Println()
+	for _, p := range pipelines {
+		if p.ID == "" {
+			continue
+		}
+		fmt.Printf("* %s: %s\n", colors.BrightOrange(p.ID), p.Source)
+	}
+	fmt.Println()
+
+	conflicts := runner.SkillConflicts(pipelines, shadowed)
+	if len(conflicts) == 0 {
+		fmt.Printf("%s No conflicts found\n", colors.BrightGreen("✓"))
+		return nil
+	}
+
+	var failed int
+	for _, conflict := range conflicts {
+		if conflict.Kind == runner.ConflictJob {
+			fmt.Printf("%s %s\n", colors.BrightYellow("~"), conflict)
+			continue
+		}
+		failed++
+		fmt.Printf("%s %s\n", colors.BrightRed("!"), conflict)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%s %d conflict(s) found", treeview.ErrorHeader(), failed)
+	}
+	return nil
+}
+
+// discoverPipelines loads the pipeline and the effective skill set of the
+// given directory, with the skills shadowed by another skill of the same
+// ID. It returns the folder of the config file, or cwd without one.
+func discoverPipelines(cwd string) (pipelines, shadowed []*model.Pipeline, configDir string, err error) {
+	configPath, configDir, discoverErr := runner.DiscoverConfig(cwd)
+	if discoverErr != nil {
+		configDir = cwd
+	}
+	if configPath != "" && discoverErr == nil {
+		pipelines, err = runner.LoadPipeline(configPath)
+		if err != nil {
+			return nil, nil, "", err
+		}
+	}
+
+	local, localShadowed, err := loadSkillPipelines(configDir, cwd, nil)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	pipelines = append(pipelines, local...)
+	shadowed = append(shadowed, localShadowed...)
+
+	markers := loadMarkers(configDir, nil)
+	if global, err := loadGlobalSkills(cwd, markers); err == nil {
+		var globalShadowed []*model.Pipeline
+		pipelines, globalShadowed = runner.MergeSkills(pipelines, global)
+		shadowed = append(shadowed, globalShadowed...)
+	}
+	embeddedLoader := runner.NewSkillsLoader(cwd, cwd)
+	embeddedLoader.Markers = markers
+	if embedded, err := embeddedLoader.LoadEmbedded(); err == nil {
+		pipelines, _ = runner.MergeSkills(pipelines, embedded)
+	}
+	return pipelines, shadowed, configDir, nil
+}
@@ -126,12 +126,8 @@ func BuildFromPipeline(pipeline *model.Pipeline, resolveDeps func(map[string]*mo
 	}
 
 	// Build tree structure for static display - include all jobs
-	// Sort job names by depth, then alphabetically for consistent display
-	var jobNames []string
-	for jobName := range jobs {
-		jobNames = append(jobNames, jobName)
-	}
-	jobNames = SortJobsByDepth(jobNames)
+	// Sort job names by depth, then in declaration order for consistent display
+	jobNames := SortByDepth(pipeline.JobNames())
 
 	for _, jobName := range jobNames {
 		job := jobs[jobName]
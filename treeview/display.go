@@ -2,20 +2,29 @@ package treeview
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"strings"
 	"sync"
+	"time"
 
 	"golang.org/x/term"
+
+	"github.com/titpetric/atkins/colors"
 )
 
 // Display manages in-place tree rendering with ANSI cursor control.
 type Display struct {
 	lastLineCount int
+	lastLines     []string // Previously printed frame, used to only rewrite changed lines
 	mu            sync.Mutex
 	isTerminal    bool
 	renderer      *Renderer
 	finalOnly     bool
+	stopSpinner   chan struct{} // Closed by RenderFinal to stop spinner animation
+	progress      *ProgressTracker
+	recorder      *ProgressRecorder // Machine-readable progress, written alongside the tree
+	filter        *Filter           // Selects the nodes of the final tree, nil shows all
 }
 
 // NewDisplay creates a new display manager.
@@ -51,6 +60,34 @@ func NewSilentDisplay() *Display {
 	}
 }
 
+// NewProgressDisplay creates a display that prints one plain line per status
+// transition instead of redrawing the tree, and the full tree on completion.
+func NewProgressDisplay(timestamps bool) *Display {
+	return &Display{
+		lastLineCount: 0,
+		isTerminal:    false,
+		renderer:      NewRenderer(),
+		finalOnly:     true,
+		progress:      NewProgressTracker(timestamps),
+	}
+}
+
+// OutputSize returns the columns and lines of the output box of a node
+// at depth below the root, so commands can wrap their output to the
+// visible width. It returns false when the tree isn't rendered live to
+// a terminal.
+func (d *Display) OutputSize(depth int) (cols, lines int, ok bool) {
+	if d == nil || !d.isTerminal {
+		return 0, 0, false
+	}
+	width, height, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil || width <= 0 || height <= 0 {
+		return 0, 0, false
+	}
+	// The tree uses one line less than the terminal, the box borders two
+	return OutputWidth(width, depth), max(height-3, 1), true
+}
+
 // IsTerminal returns whether stdout is a TTY.
 func (d *Display) IsTerminal() bool {
 	return d.isTerminal
@@ -63,13 +100,60 @@ func (d *Display) Invalidate() {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 	d.lastLineCount = 0
+	d.lastLines = nil
+}
+
+// Suspend stops rendering while fn runs, e.g. an interactive shell, and
+// renders the next frame below its output. Nil-safe: runs fn on a nil receiver.
+func (d *Display) Suspend(fn func()) {
+	if d == nil {
+		fn()
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	fn()
+	d.lastLineCount = 0
+	d.lastLines = nil
 }
 
 // Render outputs the tree, updating in-place if previously rendered.
+// Only lines that changed since the previous frame are rewritten, and
+// nothing is written if the frame is unchanged.
 func (d *Display) Render(root *Node) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
+	d.render(root)
+}
+
+// SetProgressWriter writes a JSON line per node status transition to w,
+// in addition to the rendered tree.
+func (d *Display) SetProgressWriter(w io.Writer) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.recorder = NewProgressRecorder(w)
+}
+
+// SetFilter only shows the nodes matching the filter in the final tree,
+// with their ancestors.
+func (d *Display) SetFilter(filter *Filter) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.filter = filter
+}
+
+// render outputs the tree. The caller must hold d.mu.
+func (d *Display) render(root *Node) {
+	if d.recorder != nil {
+		_ = d.recorder.Write(root)
+	}
+
+	if d.progress != nil {
+		d.renderProgress(root)
+		return
+	}
+
 	if !d.isTerminal {
 		return
 	}
@@ -91,16 +175,72 @@ func (d *Display) Render(root *Node) {
 		lines = lines[len(lines)-maxLines:]
 	}
 
-	// Roll back exactly what we printed last time (never more)
-	if d.lastLineCount > 0 {
-		fmt.Printf("\033[%dA\033[J", d.lastLineCount)
+	// Rewrite changed lines of what we printed last time (never more)
+	if output := diffFrame(d.lastLines, lines); output != "" {
+		fmt.Print(output)
 	}
 
-	for _, line := range lines {
-		fmt.Print(line + "\n")
+	d.lastLines = lines
+	d.lastLineCount = len(lines)
+}
+
+// renderProgress prints status transitions since the last render. The caller must hold d.mu.
+func (d *Display) renderProgress(root *Node) {
+	for _, line := range d.progress.Lines(root) {
+		fmt.Println(line)
 	}
+}
 
-	d.lastLineCount = len(lines)
+// StartSpinner re-renders the tree periodically so spinner frames of running
+// nodes animate. It is a no-op if the current theme has no spinner or the
+// display is not a terminal. The animation stops on RenderFinal or Cleanup.
+func (d *Display) StartSpinner(root *Node) {
+	if len(CurrentTheme().Spinner) == 0 {
+		return
+	}
+	d.startTicker(root, spinnerInterval)
+}
+
+// StartRefresh re-renders the tree every second, so the remaining time of
+// nodes with a deadline counts down. It is a no-op if the display is not a
+// terminal or the spinner already re-renders the tree.
+func (d *Display) StartRefresh(root *Node) {
+	d.startTicker(root, time.Second)
+}
+
+// startTicker re-renders the tree at the interval until RenderFinal or Cleanup.
+func (d *Display) startTicker(root *Node, interval time.Duration) {
+	if !d.isTerminal {
+		return
+	}
+
+	d.mu.Lock()
+	if d.stopSpinner != nil {
+		d.mu.Unlock()
+		return
+	}
+	stop := make(chan struct{})
+	d.stopSpinner = stop
+	d.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				d.mu.Lock()
+				if d.stopSpinner != stop {
+					d.mu.Unlock()
+					return
+				}
+				d.render(root)
+				d.mu.Unlock()
+			}
+		}
+	}()
 }
 
 // RenderStatic displays a static tree view (for list).
@@ -118,19 +258,51 @@ func (d *Display) RenderFinal(root *Node) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
+	d.stopSpinnerLocked()
+
+	// Report transitions that happened after the last render
+	if d.recorder != nil {
+		_ = d.recorder.Write(root)
+	}
+	if d.progress != nil {
+		d.renderProgress(root)
+		fmt.Println()
+	}
+
 	// Roll back what we printed
 	if d.isTerminal && d.lastLineCount > 0 {
 		fmt.Printf("\033[%dA\033[J", d.lastLineCount)
 		d.lastLineCount = 0
 	}
+	d.lastLines = nil
 
-	// Print the full tree
-	output := d.renderer.Render(root)
-	fmt.Print(output)
+	// Print the full tree, or the nodes matching the filter
+	if d.filter == nil {
+		fmt.Print(d.renderer.Render(root))
+		return
+	}
+	filtered, hidden := d.filter.Apply(root)
+	fmt.Print(d.renderer.Render(filtered))
+	if hidden > 0 {
+		fmt.Println(colors.Gray(fmt.Sprintf("(%d nodes not matching %q hidden)", hidden, d.filter.String())))
+	}
 }
 
-// Cleanup is a no-op kept for API compatibility.
-func (d *Display) Cleanup() {}
+// Cleanup stops the spinner animation, if running.
+func (d *Display) Cleanup() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.stopSpinnerLocked()
+}
+
+// stopSpinnerLocked stops the spinner animation. The caller must hold d.mu.
+func (d *Display) stopSpinnerLocked() {
+	if d.stopSpinner != nil {
+		close(d.stopSpinner)
+		d.stopSpinner = nil
+	}
+}
 
 // countOutputLines counts the number of newlines in output
 func countOutputLines(output string) int {
@@ -46,6 +46,21 @@ func TestDisplayInvalidate(t *testing.T) {
 	})
 }
 
+func TestDisplaySuspend(t *testing.T) {
+	t.Run("Suspend runs fn", func(t *testing.T) {
+		calls := 0
+		treeview.NewSilentDisplay().Suspend(func() { calls++ })
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("Suspend is nil-safe", func(t *testing.T) {
+		var display *treeview.Display
+		calls := 0
+		display.Suspend(func() { calls++ })
+		assert.Equal(t, 1, calls)
+	})
+}
+
 func TestDisplayCleanup(t *testing.T) {
 	t.Run("Cleanup is safe to call multiple times", func(t *testing.T) {
 		display := treeview.NewSilentDisplay()
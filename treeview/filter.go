@@ -0,0 +1,111 @@
+package treeview
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/titpetric/atkins/colors"
+)
+
+// Filter values matching nodes by status.
+const (
+	FilterFailed  = "failed"
+	FilterSkipped = "skipped"
+)
+
+// Filter selects the nodes of the final tree, so large runs only show the
+// interesting nodes. The ancestors of matching nodes are kept for context.
+type Filter struct {
+	value   string
+	status  Status
+	pattern *regexp.Regexp
+}
+
+// ParseFilter parses a filter: failed, skipped, or a regular expression
+// matched against node names and IDs.
+func ParseFilter(value string) (*Filter, error) {
+	switch value {
+	case FilterFailed:
+		return &Filter{value: value, status: StatusFailed}, nil
+	case FilterSkipped:
+		return &Filter{value: value, status: StatusSkipped}, nil
+	}
+	pattern, err := regexp.Compile(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter %q: %w", value, err)
+	}
+	return &Filter{value: value, pattern: pattern}, nil
+}
+
+// String returns the filter as given.
+func (f *Filter) String() string {
+	return f.value
+}
+
+// Match reports whether the node matches the filter.
+func (f *Filter) Match(n *Node) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if f.pattern == nil {
+		return n.Status == f.status
+	}
+	return f.pattern.MatchString(colors.StripANSI(n.Name)) || (n.ID != "" && f.pattern.MatchString(n.ID))
+}
+
+// Apply returns a copy of the tree with the matching nodes and their
+// ancestors, and the number of nodes left out. The root is always kept.
+func (f *Filter) Apply(root *Node) (*Node, int) {
+	var filter func(n *Node) *Node
+	filter = func(n *Node) *Node {
+		var children []*Node
+		for _, child := range n.GetChildren() {
+			if kept := filter(child); kept != nil {
+				children = append(children, kept)
+			}
+		}
+		if len(children) == 0 && n != root && !f.Match(n) {
+			return nil
+		}
+		return n.copyWithChildren(children)
+	}
+	filtered := filter(root)
+	return filtered, countNodes(root) - countNodes(filtered)
+}
+
+// copyWithChildren returns a copy of the node with the given children.
+func (n *Node) copyWithChildren(children []*Node) *Node {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return &Node{
+		Name:         n.Name,
+		ID:           n.ID,
+		Status:       n.Status,
+		CreatedAt:    n.CreatedAt,
+		UpdatedAt:    n.UpdatedAt,
+		StartOffset:  n.StartOffset,
+		Duration:     n.Duration,
+		If:           n.If,
+		Children:     append([]*Node{}, children...),
+		Dependencies: n.Dependencies,
+		Stage:        n.Stage,
+		Cached:       n.Cached,
+		Resumed:      n.Resumed,
+		Deferred:     n.Deferred,
+		Summarize:    n.Summarize,
+		Quiet:        n.Quiet,
+		Output:       n.Output,
+		Deadline:     n.Deadline,
+		Timeout:      n.Timeout,
+	}
+}
+
+// countNodes returns the number of nodes below n.
+func countNodes(n *Node) int {
+	count := 0
+	for _, child := range n.GetChildren() {
+		count += 1 + countNodes(child)
+	}
+	return count
+}
@@ -0,0 +1,93 @@
+package treeview
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/atkins/colors"
+)
+
+func TestFilter_Apply(t *testing.T) {
+	newTree := func() *Node {
+		root := NewNode("pipeline")
+
+		build := NewNode("build")
+		build.SetStatus(StatusPassed)
+		compile := NewNode("run: go build")
+		compile.SetStatus(StatusPassed)
+		compile.SetID("jobs.build.steps.0")
+		build.AddChild(compile)
+
+		test := NewNode("test")
+		test.SetStatus(StatusFailed)
+		unit := NewNode("run: go test ./...")
+		unit.SetStatus(StatusFailed)
+		unit.SetOutput([]string{"--- FAIL: TestX"})
+		e2e := NewNode("run: ./e2e.sh")
+		e2e.SetStatus(StatusSkipped)
+		test.AddChildren(unit, e2e)
+
+		root.AddChildren(build, test)
+		return root
+	}
+
+	render := func(root *Node) string {
+		renderer := NewRenderer()
+		renderer.trimmer = nil
+		return colors.StripANSI(renderer.Render(root))
+	}
+
+	t.Run("failed keeps failed nodes with their ancestors", func(t *testing.T) {
+		filter, err := ParseFilter(FilterFailed)
+		require.NoError(t, err)
+
+		filtered, hidden := filter.Apply(newTree())
+		assert.Equal(t, 3, hidden)
+		assert.Equal(t, `pipeline
+└─ test ✗
+   └─ run: go test ./... ✗
+      --- FAIL: TestX
+`, render(filtered))
+	})
+
+	t.Run("skipped keeps the ancestors of skipped nodes", func(t *testing.T) {
+		filter, err := ParseFilter(FilterSkipped)
+		require.NoError(t, err)
+
+		filtered, hidden := filter.Apply(newTree())
+		assert.Equal(t, 3, hidden)
+		assert.Equal(t, "pipeline\n└─ test ✗\n   └─ run: ./e2e.sh ⊘\n", render(filtered))
+	})
+
+	t.Run("regular expressions match names and IDs", func(t *testing.T) {
+		filter, err := ParseFilter(`^jobs\.build\.|e2e`)
+		require.NoError(t, err)
+
+		filtered, hidden := filter.Apply(newTree())
+		assert.Equal(t, 1, hidden)
+		assert.Equal(t, `pipeline
+├─ build ✓
+│  └─ run: go build ✓
+└─ test ✗
+   └─ run: ./e2e.sh ⊘
+`, render(filtered))
+	})
+
+	t.Run("the tree is not modified", func(t *testing.T) {
+		filter, err := ParseFilter("nothing matches")
+		require.NoError(t, err)
+
+		root := newTree()
+		filtered, hidden := filter.Apply(root)
+		assert.Equal(t, 5, hidden)
+		assert.Empty(t, filtered.GetChildren())
+		assert.Len(t, root.GetChildren(), 2)
+	})
+
+	t.Run("invalid regular expression", func(t *testing.T) {
+		_, err := ParseFilter("(")
+		assert.ErrorContains(t, err, `invalid filter "("`)
+	})
+}
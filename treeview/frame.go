@@ -0,0 +1,44 @@
+package treeview
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// diffFrame returns the terminal output needed to turn the previously printed
+// frame into next. The cursor is expected below the previous frame, and is left
+// below the new frame. Unchanged lines are skipped with a cursor move, changed
+// lines are cleared and rewritten, and leftover lines from a longer previous
+// frame are cleared. Returns an empty string if the frames are equal.
+func diffFrame(prev, next []string) string {
+	if slices.Equal(prev, next) {
+		return ""
+	}
+
+	var sb strings.Builder
+
+	// Move to the first line of the previous frame
+	if len(prev) > 0 {
+		fmt.Fprintf(&sb, "\033[%dA", len(prev))
+	}
+
+	for i, line := range next {
+		if i < len(prev) {
+			if prev[i] == line {
+				sb.WriteString("\033[1B")
+				continue
+			}
+			// Clear the stale line before rewriting it
+			sb.WriteString("\r\033[2K")
+		}
+		sb.WriteString(line + "\n")
+	}
+
+	// Clear lines left over from a longer previous frame
+	if len(next) < len(prev) {
+		sb.WriteString("\033[J")
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,53 @@
+package treeview
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffFrame(t *testing.T) {
+	tests := []struct {
+		name     string
+		prev     []string
+		next     []string
+		expected string
+	}{
+		{
+			name:     "unchanged frame writes nothing",
+			prev:     []string{"a", "b"},
+			next:     []string{"a", "b"},
+			expected: "",
+		},
+		{
+			name:     "first frame is printed",
+			prev:     nil,
+			next:     []string{"a", "b"},
+			expected: "a\nb\n",
+		},
+		{
+			name:     "only changed lines are rewritten",
+			prev:     []string{"a", "b", "c"},
+			next:     []string{"a", "B", "c"},
+			expected: "\033[3A\033[1B\r\033[2KB\n\033[1B",
+		},
+		{
+			name:     "growing frame appends lines",
+			prev:     []string{"a"},
+			next:     []string{"a", "b"},
+			expected: "\033[1A\033[1Bb\n",
+		},
+		{
+			name:     "shrinking frame clears leftover lines",
+			prev:     []string{"a", "b", "c"},
+			next:     []string{"a"},
+			expected: "\033[3A\033[1B\033[J",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, diffFrame(tt.prev, tt.next))
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package treeview
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/titpetric/atkins/colors"
+)
+
+// Labels are the words used for statuses and message headers in the
+// output. Logs, JSON and progress records keep the English status names.
+type Labels struct {
+	Running     string `yaml:"running,omitempty"`
+	Passed      string `yaml:"passed,omitempty"`
+	Failed      string `yaml:"failed,omitempty"`
+	Skipped     string `yaml:"skipped,omitempty"`
+	Error       string `yaml:"error,omitempty"`        // Header of error messages
+	Warning     string `yaml:"warning,omitempty"`      // Header of warnings
+	FailedSteps string `yaml:"failed_steps,omitempty"` // Title of the failure summary, after the count
+}
+
+// EnglishLabels are the default labels.
+var EnglishLabels = Labels{
+	Running:     "running",
+	Passed:      "passed",
+	Failed:      "failed",
+	Skipped:     "skipped",
+	Error:       "ERROR:",
+	Warning:     "WARN:",
+	FailedSteps: "failed step(s)",
+}
+
+// Locales maps locale names to their labels.
+var Locales = map[string]Labels{
+	"en": EnglishLabels,
+	"de": {
+		Running:     "läuft",
+		Passed:      "bestanden",
+		Failed:      "fehlgeschlagen",
+		Skipped:     "übersprungen",
+		Error:       "FEHLER:",
+		Warning:     "WARNUNG:",
+		FailedSteps: "fehlgeschlagene Schritte",
+	},
+	"es": {
+		Running:     "en curso",
+		Passed:      "correcto",
+		Failed:      "fallido",
+		Skipped:     "omitido",
+		Error:       "ERROR:",
+		Warning:     "AVISO:",
+		FailedSteps: "pasos fallidos",
+	},
+	"fr": {
+		Running:     "en cours",
+		Passed:      "réussi",
+		Failed:      "échoué",
+		Skipped:     "ignoré",
+		Error:       "ERREUR:",
+		Warning:     "ATTENTION:",
+		FailedSteps: "étapes échouées",
+	},
+}
+
+var currentLabels atomic.Pointer[Labels]
+
+func init() {
+	labels := EnglishLabels
+	currentLabels.Store(&labels)
+}
+
+// labelsFile is a labels file, overriding the labels of a locale.
+type labelsFile struct {
+	Locale string `yaml:"locale,omitempty"`
+	Labels `yaml:",inline"`
+}
+
+// LoadLabels returns the labels of a locale name, e.g. "de", or of a YAML
+// labels file. A labels file overrides the labels of its `locale:`,
+// English by default. An empty value selects the English labels.
+func LoadLabels(value string) (Labels, error) {
+	if value == "" {
+		return EnglishLabels, nil
+	}
+	if labels, ok := Locales[value]; ok {
+		return labels, nil
+	}
+
+	data, err := os.ReadFile(value)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Labels{}, fmt.Errorf("unknown labels %q, expected a locale (%s) or a labels file", value, strings.Join(sortedKeys(Locales), ", "))
+		}
+		return Labels{}, err
+	}
+	var file labelsFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return Labels{}, fmt.Errorf("invalid labels file %s: %w", value, err)
+	}
+	base, ok := Locales[cmp.Or(file.Locale, "en")]
+	if !ok {
+		return Labels{}, fmt.Errorf("invalid labels file %s: unknown locale %q, expected one of: %s", value, file.Locale, strings.Join(sortedKeys(Locales), ", "))
+	}
+	return base.merge(file.Labels), nil
+}
+
+// merge returns the labels with the labels set in override.
+func (l Labels) merge(override Labels) Labels {
+	return Labels{
+		Running:     cmp.Or(override.Running, l.Running),
+		Passed:      cmp.Or(override.Passed, l.Passed),
+		Failed:      cmp.Or(override.Failed, l.Failed),
+		Skipped:     cmp.Or(override.Skipped, l.Skipped),
+		Error:       cmp.Or(override.Error, l.Error),
+		Warning:     cmp.Or(override.Warning, l.Warning),
+		FailedSteps: cmp.Or(override.FailedSteps, l.FailedSteps),
+	}
+}
+
+// SetLabels sets the labels used in the output.
+func SetLabels(labels Labels) {
+	currentLabels.Store(&labels)
+}
+
+// CurrentLabels returns the labels used in the output.
+func CurrentLabels() Labels {
+	return *currentLabels.Load()
+}
+
+// Text returns the label of the Status for display, in the current labels.
+func (s Status) Text() string {
+	labels := CurrentLabels()
+	switch s {
+	case StatusRunning:
+		return labels.Running
+	case StatusPassed:
+		return labels.Passed
+	case StatusFailed:
+		return labels.Failed
+	case StatusSkipped:
+		return labels.Skipped
+	}
+	return s.Label()
+}
+
+// ErrorHeader returns the colored header of error messages, e.g. "ERROR:".
+func ErrorHeader() string {
+	return colors.BrightRed(CurrentLabels().Error)
+}
+
+// WarningHeader returns the colored header of warnings, e.g. "WARN:".
+func WarningHeader() string {
+	return colors.BrightYellow(CurrentLabels().Warning)
+}
@@ -0,0 +1,58 @@
+package treeview
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/atkins/colors"
+)
+
+func TestLoadLabels(t *testing.T) {
+	labels, err := LoadLabels("")
+	require.NoError(t, err)
+	assert.Equal(t, EnglishLabels, labels)
+
+	labels, err = LoadLabels("de")
+	require.NoError(t, err)
+	assert.Equal(t, "bestanden", labels.Passed)
+
+	// A labels file overrides the labels of its locale
+	file := filepath.Join(t.TempDir(), "labels.yml")
+	require.NoError(t, os.WriteFile(file, []byte("locale: fr\npassed: OK\nerror: \"E:\"\n"), 0o644))
+	labels, err = LoadLabels(file)
+	require.NoError(t, err)
+	assert.Equal(t, "OK", labels.Passed)
+	assert.Equal(t, "E:", labels.Error)
+	assert.Equal(t, "échoué", labels.Failed)
+
+	_, err = LoadLabels("xx")
+	assert.ErrorContains(t, err, `unknown labels "xx", expected a locale (de, en, es, fr) or a labels file`)
+
+	require.NoError(t, os.WriteFile(file, []byte("locale: xx\n"), 0o644))
+	_, err = LoadLabels(file)
+	assert.ErrorContains(t, err, `unknown locale "xx"`)
+}
+
+func TestLabels_Output(t *testing.T) {
+	labels, err := LoadLabels("de")
+	require.NoError(t, err)
+	SetLabels(labels)
+	t.Cleanup(func() {
+		SetLabels(EnglishLabels)
+	})
+
+	root := NewNode("pipeline")
+	job := NewNode("build")
+	root.AddChild(job)
+	job.SetDuration(1.5)
+	job.SetStatus(StatusPassed)
+	assert.Equal(t, []string{"[job build] bestanden in 1.5s"}, NewProgressTracker(false).Lines(root))
+
+	assert.Equal(t, "FEHLER:", colors.StripANSI(ErrorHeader()))
+	assert.Equal(t, "WARNUNG:", colors.StripANSI(WarningHeader()))
+	assert.Equal(t, "passed", StatusPassed.Label(), "serialized statuses stay English")
+}
@@ -21,10 +21,16 @@ type Node struct {
 	If           string  // Condition that was evaluated (for conditional steps)
 	Children     []*Node
 	Dependencies []string
+	Stage        string // Stage the job runs in
+	Cached       bool   // Job outputs were restored from the cache
+	Resumed      bool   // Completed by the resumed run, see --resume
 	Deferred     bool
 	Summarize    bool
 	Quiet        bool
 	Output       []string // Multi-line output from command execution
+
+	Deadline time.Time     // When the node times out, zero without a timeout
+	Timeout  time.Duration // Timeout the deadline was set from
 }
 
 // NewNode creates a new tree node.
@@ -83,11 +89,11 @@ func (n *Node) StatusColor() string {
 
 	status := n.Status.String()
 	if status == "" && (haveChildren || haveDeps) {
-		return colors.Green("●")
+		return colors.Green(CurrentTheme().Pending)
 	}
 	// For leaf nodes (no children, no deps), show a status indicator if in pending state
 	if status == "" && !haveChildren && !haveDeps {
-		return colors.Green("●")
+		return colors.Green(CurrentTheme().Pending)
 	}
 	return status
 }
@@ -167,6 +173,24 @@ func (n *Node) SetDuration(duration float64) {
 	n.UpdatedAt = time.Now()
 }
 
+// SetDeadline sets when the node times out. Nil-safe: no-op on nil receiver.
+func (n *Node) SetDeadline(deadline time.Time, timeout time.Duration) {
+	if n == nil {
+		return
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.Deadline = deadline
+	n.Timeout = timeout
+}
+
+// GetDeadline returns when the node times out and the timeout it was set from.
+func (n *Node) GetDeadline() (time.Time, time.Duration) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.Deadline, n.Timeout
+}
+
 // SetIf sets the condition string that was evaluated. Nil-safe: no-op on nil receiver.
 func (n *Node) SetIf(condition string) {
 	if n == nil {
@@ -177,6 +201,63 @@ func (n *Node) SetIf(condition string) {
 	n.If = condition
 }
 
+// SetStage sets the stage of a job node. Nil-safe: no-op on nil receiver.
+func (n *Node) SetStage(stage string) {
+	if n == nil {
+		return
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.Stage = stage
+}
+
+// GetStage returns the stage of a job node (thread-safe).
+func (n *Node) GetStage() string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.Stage
+}
+
+// SetCached marks a job node as restored from the cache. Nil-safe: no-op on nil receiver.
+func (n *Node) SetCached(cached bool) {
+	if n == nil {
+		return
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.Cached = cached
+}
+
+// IsCached returns true if the job outputs were restored from the cache (thread-safe). Nil-safe: false on nil receiver.
+func (n *Node) IsCached() bool {
+	if n == nil {
+		return false
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.Cached
+}
+
+// SetResumed marks a node as completed by the resumed run. Nil-safe: no-op on nil receiver.
+func (n *Node) SetResumed(resumed bool) {
+	if n == nil {
+		return
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.Resumed = resumed
+}
+
+// IsResumed returns true if the node was completed by the resumed run (thread-safe). Nil-safe: false on nil receiver.
+func (n *Node) IsResumed() bool {
+	if n == nil {
+		return false
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.Resumed
+}
+
 // SetSummarize sets the summarize flag. Nil-safe: no-op on nil receiver.
 func (n *Node) SetSummarize(summarize bool) {
 	if n == nil {
@@ -223,6 +304,13 @@ func (n *Node) GetStatus() Status {
 	return n.Status
 }
 
+// GetDuration returns the node duration in seconds (thread-safe).
+func (n *Node) GetDuration() float64 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.Duration
+}
+
 // GetIf returns the condition string (thread-safe).
 func (n *Node) GetIf() string {
 	n.mu.Lock()
@@ -307,3 +395,17 @@ func (n *Node) GetChildren() []*Node {
 	copy(children, n.Children)
 	return children
 }
+
+// DepthOf returns the depth of target below n, 0 for the children of n,
+// or -1 if target isn't in the tree of n.
+func (n *Node) DepthOf(target *Node) int {
+	for _, child := range n.GetChildren() {
+		if child == target {
+			return 0
+		}
+		if depth := child.DepthOf(target); depth >= 0 {
+			return depth + 1
+		}
+	}
+	return -1
+}
@@ -343,3 +343,16 @@ func TestNodeNestedStructure(t *testing.T) {
 		}
 	})
 }
+
+func TestNode_DepthOf(t *testing.T) {
+	root := NewNode("pipeline")
+	job := NewNode("job")
+	step := NewNode("step")
+	job.AddChild(step)
+	root.AddChild(job)
+
+	assert.Equal(t, 0, root.DepthOf(job))
+	assert.Equal(t, 1, root.DepthOf(step))
+	assert.Equal(t, -1, root.DepthOf(NewNode("other")))
+	assert.Equal(t, -1, root.DepthOf(root))
+}
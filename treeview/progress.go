@@ -0,0 +1,165 @@
+package treeview
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/titpetric/atkins/colors"
+)
+
+// ProgressTracker produces plain progress lines for node status transitions.
+// It is used when the tree can't be redrawn in place, e.g. in CI logs.
+type ProgressTracker struct {
+	seen       map[*Node]Status
+	timestamps bool
+	now        func() time.Time
+}
+
+// NewProgressTracker creates a progress tracker, optionally prefixing lines with timestamps.
+func NewProgressTracker(timestamps bool) *ProgressTracker {
+	return &ProgressTracker{
+		seen:       make(map[*Node]Status),
+		timestamps: timestamps,
+		now:        time.Now,
+	}
+}
+
+// Lines returns one line per node whose status changed since the previous call.
+// Pending and conditional states are not reported.
+func (p *ProgressTracker) Lines(root *Node) []string {
+	var lines []string
+	for _, job := range root.GetChildren() {
+		lines = p.collect(lines, job, job.GetName(), true)
+	}
+	return lines
+}
+
+func (p *ProgressTracker) collect(lines []string, node *Node, jobName string, isJob bool) []string {
+	status := node.GetStatus()
+	if last, ok := p.seen[node]; !ok || last != status {
+		p.seen[node] = status
+		if line := p.format(node, status, jobName, isJob); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	for _, child := range node.GetChildren() {
+		lines = p.collect(lines, child, jobName, false)
+	}
+	return lines
+}
+
+func (p *ProgressTracker) format(node *Node, status Status, jobName string, isJob bool) string {
+	switch status {
+	case StatusRunning, StatusPassed, StatusFailed, StatusSkipped:
+	default:
+		return ""
+	}
+
+	var sb strings.Builder
+	if p.timestamps {
+		sb.WriteString(p.now().Format(time.RFC3339) + " ")
+	}
+	sb.WriteString("[job " + jobName + "]")
+	if !isJob {
+		fmt.Fprintf(&sb, " step %q", colors.StripANSI(node.GetName()))
+	}
+	sb.WriteString(" " + status.Text())
+	if status == StatusPassed || status == StatusFailed {
+		fmt.Fprintf(&sb, " in %.1fs", node.GetDuration())
+	}
+	return sb.String()
+}
+
+// ProgressRecord is a machine-readable status transition of a node.
+type ProgressRecord struct {
+	ID       string  `json:"id"`
+	Job      string  `json:"job"`
+	Name     string  `json:"name"`
+	State    string  `json:"state"`
+	Percent  int     `json:"percent"`            // Share of finished steps in the run
+	Duration float64 `json:"duration,omitempty"` // Seconds, for passed and failed nodes
+}
+
+// ProgressRecorder writes a JSON line per node status transition, for
+// wrappers rendering their own progress UI.
+type ProgressRecorder struct {
+	w    io.Writer
+	seen map[*Node]Status
+}
+
+// NewProgressRecorder creates a progress recorder writing to w.
+func NewProgressRecorder(w io.Writer) *ProgressRecorder {
+	return &ProgressRecorder{w: w, seen: make(map[*Node]Status)}
+}
+
+// Records returns a record per node whose status changed since the
+// previous call. Pending and conditional states are not reported.
+func (p *ProgressRecorder) Records(root *Node) []ProgressRecord {
+	done, total := countSteps(root)
+	percent := 100
+	if total > 0 {
+		percent = done * 100 / total
+	}
+
+	var records []ProgressRecord
+	var collect func(node *Node, job, id string)
+	collect = func(node *Node, job, id string) {
+		if node.ID != "" {
+			id = node.ID
+		}
+		status := node.GetStatus()
+		if last, ok := p.seen[node]; !ok || last != status {
+			p.seen[node] = status
+			switch status {
+			case StatusRunning, StatusPassed, StatusFailed, StatusSkipped:
+				record := ProgressRecord{ID: id, Job: job, Name: colors.StripANSI(node.GetName()), State: status.Label(), Percent: percent}
+				if status == StatusPassed || status == StatusFailed {
+					record.Duration = node.GetDuration()
+				}
+				records = append(records, record)
+			}
+		}
+		for i, child := range node.GetChildren() {
+			collect(child, job, fmt.Sprintf("%s.%d", id, i))
+		}
+	}
+	for _, job := range root.GetChildren() {
+		collect(job, job.GetName(), "jobs."+job.GetName())
+	}
+	return records
+}
+
+// Write writes the records of the status transitions since the previous call.
+func (p *ProgressRecorder) Write(root *Node) error {
+	for _, record := range p.Records(root) {
+		line, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		if _, err := p.w.Write(append(line, '\n')); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// countSteps returns the number of finished and all nodes without children below root.
+func countSteps(root *Node) (done, total int) {
+	for _, child := range root.GetChildren() {
+		if !child.HasChildren() {
+			total++
+			switch child.GetStatus() {
+			case StatusPassed, StatusFailed, StatusSkipped:
+				done++
+			}
+			continue
+		}
+		d, t := countSteps(child)
+		done, total = done+d, total+t
+	}
+	return done, total
+}
@@ -0,0 +1,80 @@
+package treeview
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProgressTracker_Lines(t *testing.T) {
+	root := NewNode("pipeline")
+	job := NewNode("build")
+	step := NewNode("go build")
+	job.AddChild(step)
+	root.AddChild(job)
+
+	tracker := NewProgressTracker(false)
+	assert.Empty(t, tracker.Lines(root), "pending nodes are not reported")
+
+	job.SetStatus(StatusRunning)
+	step.SetStatus(StatusRunning)
+	assert.Equal(t, []string{
+		"[job build] running",
+		`[job build] step "go build" running`,
+	}, tracker.Lines(root))
+
+	assert.Empty(t, tracker.Lines(root), "unchanged nodes are not reported again")
+
+	step.SetDuration(1.23)
+	step.SetStatus(StatusPassed)
+	job.SetDuration(1.5)
+	job.SetStatus(StatusFailed)
+	assert.Equal(t, []string{
+		"[job build] failed in 1.5s",
+		`[job build] step "go build" passed in 1.2s`,
+	}, tracker.Lines(root))
+}
+
+func TestProgressTracker_Timestamps(t *testing.T) {
+	root := NewNode("pipeline")
+	job := NewNode("test")
+	job.SetStatus(StatusSkipped)
+	root.AddChild(job)
+
+	tracker := NewProgressTracker(true)
+	tracker.now = func() time.Time {
+		return time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	}
+
+	assert.Equal(t, []string{"2025-01-02T03:04:05Z [job test] skipped"}, tracker.Lines(root))
+}
+
+func TestProgressRecorder(t *testing.T) {
+	root := NewNode("pipeline")
+	job := NewNode("build")
+	step := NewNode("go build")
+	step.SetID("jobs.build.steps.0")
+	vet := NewNode("go vet")
+	job.AddChildren(step, vet)
+	root.AddChild(job)
+
+	var out strings.Builder
+	recorder := NewProgressRecorder(&out)
+	assert.Empty(t, recorder.Records(root), "pending nodes are not reported")
+
+	job.SetStatus(StatusRunning)
+	step.SetDuration(0.5)
+	step.SetStatus(StatusPassed)
+	require.NoError(t, recorder.Write(root))
+	assert.Equal(t, `{"id":"jobs.build","job":"build","name":"build","state":"running","percent":50}
+{"id":"jobs.build.steps.0","job":"build","name":"go build","state":"passed","percent":50,"duration":0.5}
+`, out.String())
+
+	vet.SetStatus(StatusSkipped)
+	assert.Equal(t, []ProgressRecord{
+		{ID: "jobs.build.1", Job: "build", Name: "go vet", State: "skipped", Percent: 100},
+	}, recorder.Records(root))
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/titpetric/atkins/colors"
 )
@@ -44,7 +45,11 @@ func (r *Renderer) Render(root *Node) string {
 		r.trimmer.RefreshViewport()
 	}
 
-	output := colors.BrightWhite(root.GetName()) + "\n"
+	output := colors.BrightWhite(root.GetName())
+	if status := root.GetStatus(); status == StatusPending || status == StatusRunning {
+		output += remainingLabel(root, time.Now())
+	}
+	output += "\n"
 
 	if root.IsSummarize() {
 		output += r.renderNodeSummary(root, "", true)
@@ -81,13 +86,27 @@ func (r *Renderer) RenderStatic(root *Node) string {
 	return output
 }
 
+// remainingLabel returns the time left until the node deadline, e.g.
+// " (1m32s remaining)", in red when less than 10% of the timeout is left.
+// It returns "" for nodes without a deadline.
+func remainingLabel(node *Node, now time.Time) string {
+	deadline, timeout := node.GetDeadline()
+	if deadline.IsZero() {
+		return ""
+	}
+	remaining := max(deadline.Sub(now), 0).Round(time.Second)
+	label := fmt.Sprintf("(%s remaining)", remaining)
+	if timeout > 0 && remaining*10 < timeout {
+		return " " + colors.BrightRed(label)
+	}
+	return " " + colors.Gray(label)
+}
+
 // renderNodeSummary will give a one-liner with status (pending, running, passed...)
 func (r *Renderer) renderNodeSummary(node *Node, prefix string, isLast bool) string {
 	// Determine branch character
-	branch := "├─ "
-	if isLast {
-		branch = "└─ "
-	}
+	theme := CurrentTheme()
+	branch := theme.BranchFor(isLast)
 
 	var pending, running, passing, failed int
 	for _, child := range node.GetChildren() {
@@ -134,10 +153,8 @@ func (r *Renderer) renderNodeForExecution(node *Node, prefix string, isLast bool
 	output := ""
 
 	// Determine branch character
-	branch := "├─ "
-	if isLast {
-		branch = "└─ "
-	}
+	theme := CurrentTheme()
+	branch := theme.BranchFor(isLast)
 
 	if node.IsSummarize() {
 		return r.renderNodeSummary(node, prefix, isLast)
@@ -163,6 +180,15 @@ func (r *Renderer) renderNodeForExecution(node *Node, prefix string, isLast bool
 		depsStr := strings.Join(depItems, ", ")
 		suffix += fmt.Sprintf(" (depends_on: %s)", depsStr)
 	}
+	if stage := node.GetStage(); stage != "" {
+		suffix += fmt.Sprintf(" (stage: %s)", colors.BrightCyan(stage))
+	}
+	if node.IsCached() {
+		suffix += " " + colors.BrightCyan("(cached)")
+	}
+	if node.IsResumed() {
+		suffix += " " + colors.BrightCyan("(resumed)")
+	}
 
 	// Add if condition for skipped nodes
 	if node.GetStatus() == StatusSkipped {
@@ -171,13 +197,17 @@ func (r *Renderer) renderNodeForExecution(node *Node, prefix string, isLast bool
 		}
 	}
 
-	// Add status indicator - show all status during execution
-	if status != "" && !strings.HasSuffix(strings.TrimSpace(label), "●") &&
-		!strings.HasSuffix(strings.TrimSpace(label), "✓") &&
-		!strings.HasSuffix(strings.TrimSpace(label), "✗") {
+	// Add status indicator - show all status during execution. The status
+	// comes from the node, names never carry it.
+	if status != "" {
 		suffix += " " + status
 	}
 
+	// Add the time left until a running node times out
+	if node.GetStatus() == StatusRunning {
+		suffix += remainingLabel(node, time.Now())
+	}
+
 	// Get children once for consistent progress counter and rendering
 	children := node.GetChildren()
 
@@ -212,10 +242,7 @@ func (r *Renderer) renderNodeForExecution(node *Node, prefix string, isLast bool
 	nodeOutput := node.GetOutput()
 	if len(nodeOutput) > 0 {
 		// Determine continuation character for output indentation
-		continuation := "│  "
-		if isLast {
-			continuation = "   "
-		}
+		continuation := theme.ContinuationFor(isLast)
 
 		// Trim output lines and calculate max width for border (visual width, excluding ANSI)
 		outputPrefixLen := colors.VisualLength(prefix + continuation)
@@ -238,7 +265,7 @@ func (r *Renderer) renderNodeForExecution(node *Node, prefix string, isLast bool
 
 		// Add top border if 2+ elements (account for spaces around content)
 		if hasBorder {
-			topBorder := prefix + continuation + colors.Gray("┌"+strings.Repeat("─", maxWidth+2)+"┐") + "\n"
+			topBorder := prefix + continuation + colors.Gray(theme.BoxTopLeft+strings.Repeat(theme.BoxHorizontal, maxWidth+2)+theme.BoxTopRight) + "\n"
 			output += topBorder
 		}
 
@@ -249,7 +276,7 @@ func (r *Renderer) renderNodeForExecution(node *Node, prefix string, isLast bool
 			padding := strings.Repeat(" ", maxWidth-currentWidth)
 			paddedLine := " " + trimmedLine + padding + " "
 			if hasBorder {
-				output += prefix + continuation + colors.Gray("│") + colors.White(paddedLine) + colors.Gray("│") + "\n"
+				output += prefix + continuation + colors.Gray(theme.BoxVertical) + colors.White(paddedLine) + colors.Gray(theme.BoxVertical) + "\n"
 			} else {
 				output += prefix + continuation + colors.White(trimmedLine) + "\n"
 			}
@@ -257,7 +284,7 @@ func (r *Renderer) renderNodeForExecution(node *Node, prefix string, isLast bool
 
 		// Add bottom border if 2+ elements (account for spaces around content)
 		if hasBorder {
-			bottomBorder := prefix + continuation + colors.Gray("└"+strings.Repeat("─", maxWidth+2)+"┘") + "\n"
+			bottomBorder := prefix + continuation + colors.Gray(theme.BoxBottomLeft+strings.Repeat(theme.BoxHorizontal, maxWidth+2)+theme.BoxBottomRight) + "\n"
 			output += bottomBorder
 		}
 	}
@@ -265,10 +292,7 @@ func (r *Renderer) renderNodeForExecution(node *Node, prefix string, isLast bool
 	// Render children
 	if len(children) > 0 {
 		// Determine continuation character
-		continuation := "│  "
-		if isLast {
-			continuation = "   "
-		}
+		continuation := theme.ContinuationFor(isLast)
 
 		for j, child := range children {
 			childIsLast := j == len(children)-1
@@ -284,10 +308,8 @@ func (r *Renderer) renderStaticNode(node *Node, prefix string, isLast bool) stri
 	output := ""
 
 	// Determine branch character
-	branch := "├─ "
-	if isLast {
-		branch = "└─ "
-	}
+	theme := CurrentTheme()
+	branch := theme.BranchFor(isLast)
 
 	if node.IsSummarize() {
 		return r.renderNodeSummary(node, prefix, isLast)
@@ -313,6 +335,15 @@ func (r *Renderer) renderStaticNode(node *Node, prefix string, isLast bool) stri
 		depsStr := strings.Join(depItems, ", ")
 		suffix += fmt.Sprintf(" (depends_on: %s)", depsStr)
 	}
+	if stage := node.GetStage(); stage != "" {
+		suffix += fmt.Sprintf(" (stage: %s)", colors.BrightCyan(stage))
+	}
+	if node.IsCached() {
+		suffix += " " + colors.BrightCyan("(cached)")
+	}
+	if node.IsResumed() {
+		suffix += " " + colors.BrightCyan("(resumed)")
+	}
 
 	// Add if condition for skipped nodes
 	if node.GetStatus() == StatusSkipped {
@@ -344,10 +375,7 @@ func (r *Renderer) renderStaticNode(node *Node, prefix string, isLast bool) stri
 	nodeOutput := node.GetOutput()
 	if len(nodeOutput) > 0 {
 		// Determine continuation character for output indentation
-		continuation := "│  "
-		if isLast {
-			continuation = "   "
-		}
+		continuation := theme.ContinuationFor(isLast)
 
 		// Trim output lines and calculate max width for border (visual width, excluding ANSI)
 		outputPrefixLen := colors.VisualLength(prefix + continuation)
@@ -370,7 +398,7 @@ func (r *Renderer) renderStaticNode(node *Node, prefix string, isLast bool) stri
 
 		// Add top border if 2+ elements (account for spaces around content)
 		if hasBorder {
-			topBorder := prefix + continuation + colors.Gray("┌"+strings.Repeat("─", maxWidth+2)+"┐") + "\n"
+			topBorder := prefix + continuation + colors.Gray(theme.BoxTopLeft+strings.Repeat(theme.BoxHorizontal, maxWidth+2)+theme.BoxTopRight) + "\n"
 			output += topBorder
 		}
 
@@ -381,7 +409,7 @@ func (r *Renderer) renderStaticNode(node *Node, prefix string, isLast bool) stri
 			padding := strings.Repeat(" ", maxWidth-currentWidth)
 			paddedLine := " " + trimmedLine + padding + " "
 			if hasBorder {
-				output += prefix + continuation + colors.Gray("│") + colors.White(paddedLine) + colors.Gray("│") + "\n"
+				output += prefix + continuation + colors.Gray(theme.BoxVertical) + colors.White(paddedLine) + colors.Gray(theme.BoxVertical) + "\n"
 			} else {
 				output += prefix + continuation + colors.White(trimmedLine) + "\n"
 			}
@@ -389,7 +417,7 @@ func (r *Renderer) renderStaticNode(node *Node, prefix string, isLast bool) stri
 
 		// Add bottom border if 2+ elements (account for spaces around content)
 		if hasBorder {
-			bottomBorder := prefix + continuation + colors.Gray("└"+strings.Repeat("─", maxWidth+2)+"┘") + "\n"
+			bottomBorder := prefix + continuation + colors.Gray(theme.BoxBottomLeft+strings.Repeat(theme.BoxHorizontal, maxWidth+2)+theme.BoxBottomRight) + "\n"
 			output += bottomBorder
 		}
 	}
@@ -398,10 +426,7 @@ func (r *Renderer) renderStaticNode(node *Node, prefix string, isLast bool) stri
 	children := node.GetChildren()
 	if len(children) > 0 {
 		// Determine continuation character
-		continuation := "│  "
-		if isLast {
-			continuation = "   "
-		}
+		continuation := theme.ContinuationFor(isLast)
 
 		for j, child := range children {
 			childIsLast := j == len(children)-1
@@ -1,7 +1,9 @@
 package treeview
 
 import (
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -459,3 +461,34 @@ func TestRenderProgressCounter(t *testing.T) {
 		assert.NotContains(t, stripped, "1/1", "single child should not show counter")
 	})
 }
+
+func TestRenderRemaining(t *testing.T) {
+	now := time.Now()
+
+	node := NewNode("build")
+	assert.Empty(t, remainingLabel(node, now))
+
+	node.SetDeadline(now.Add(92*time.Second), 10*time.Minute)
+	assert.Equal(t, " "+colors.Gray("(1m32s remaining)"), remainingLabel(node, now))
+
+	node.SetDeadline(now.Add(30*time.Second), 10*time.Minute)
+	assert.Equal(t, " "+colors.BrightRed("(30s remaining)"), remainingLabel(node, now))
+
+	node.SetDeadline(now.Add(-time.Second), 10*time.Minute)
+	assert.Equal(t, " "+colors.BrightRed("(0s remaining)"), remainingLabel(node, now))
+
+	renderer := NewRenderer()
+	root := NewNode("pipeline")
+	root.SetDeadline(now.Add(time.Hour), time.Hour)
+	job := NewNode("build")
+	job.SetStatus(StatusRunning)
+	job.SetDeadline(now.Add(time.Hour), time.Hour)
+	root.AddChild(job)
+
+	output := renderer.Render(root)
+	assert.Equal(t, 2, strings.Count(output, "remaining)"))
+
+	job.SetStatus(StatusPassed)
+	root.SetStatus(StatusPassed)
+	assert.NotContains(t, renderer.Render(root), "remaining")
+}
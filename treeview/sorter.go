@@ -1,6 +1,9 @@
 package treeview
 
-import "slices"
+import (
+	"cmp"
+	"slices"
+)
 
 // SortJobsByDepth sorts job names by ':' depth, then alphabetically.
 // Depth is determined by the count of ':' separators in the job name.
@@ -12,6 +15,16 @@ func SortJobsByDepth(jobNames []string) []string {
 	return result
 }
 
+// SortByDepth sorts job names by ':' depth, keeping the given order of
+// jobs with the same depth, e.g. the declaration order of a pipeline.
+func SortByDepth(jobNames []string) []string {
+	result := slices.Clone(jobNames)
+	slices.SortStableFunc(result, func(a, b string) int {
+		return cmp.Compare(countDepth(a), countDepth(b))
+	})
+	return result
+}
+
 // compareByDepthThenName returns the comparison result for two job names.
 // Returns -1 if a < b, 0 if a == b, 1 if a > b.
 //
@@ -56,30 +69,27 @@ func countDepth(name string) int {
 }
 
 // SortByOrder returns the job names from the set in the order specified by orderList.
-// Jobs in the set that are not in orderList are appended at the end.
+// Jobs in the set that are not in orderList are appended at the end, sorted by name.
 func SortByOrder(jobSet map[string]bool, orderList []string) []string {
 	result := make([]string, 0, len(jobSet))
+	seen := make(map[string]bool, len(jobSet))
 
 	// Add jobs in order from orderList
 	for _, jobName := range orderList {
-		if jobSet[jobName] {
+		if jobSet[jobName] && !seen[jobName] {
+			seen[jobName] = true
 			result = append(result, jobName)
 		}
 	}
 
 	// Add any remaining jobs from the set not in orderList
+	var remaining []string
 	for jobName := range jobSet {
-		found := false
-		for _, ordered := range result {
-			if ordered == jobName {
-				found = true
-				break
-			}
-		}
-		if !found {
-			result = append(result, jobName)
+		if !seen[jobName] {
+			remaining = append(remaining, jobName)
 		}
 	}
+	slices.Sort(remaining)
 
-	return result
+	return append(result, remaining...)
 }
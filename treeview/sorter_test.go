@@ -188,6 +188,21 @@ func TestSortJobsByDepth_DoesNotMutate(t *testing.T) {
 	})
 }
 
+// TestSortByDepth tests that jobs of the same depth keep their order
+func TestSortByDepth(t *testing.T) {
+	input := []string{"zeta", "test:run", "alpha", "docker:run", "build"}
+	assert.Equal(t, []string{"zeta", "alpha", "build", "test:run", "docker:run"}, SortByDepth(input))
+	assert.Equal(t, []string{"zeta", "test:run", "alpha", "docker:run", "build"}, input, "input should not be modified")
+}
+
+// TestSortByOrder tests that jobs outside the order are appended by name
+func TestSortByOrder(t *testing.T) {
+	set := map[string]bool{"c": true, "a": true, "b": true, "z": true, "y": true}
+	for range 10 {
+		assert.Equal(t, []string{"z", "b", "a", "c", "y"}, SortByOrder(set, []string{"z", "b", "z", "missing"}))
+	}
+}
+
 // TestCountDepth tests the depth counting logic
 func TestCountDepth(t *testing.T) {
 	tests := []struct {
@@ -15,21 +15,23 @@ const (
 	StatusConditional
 )
 
-// String returns a colored string representation of the Status for display.
+// String returns a colored string representation of the Status for display,
+// using the glyphs of the current theme.
 func (s Status) String() string {
+	theme := CurrentTheme()
 	switch s {
 	case StatusPending:
-		return colors.Gray("●")
+		return colors.Gray(theme.Pending)
 	case StatusRunning:
-		return colors.BrightOrange("●")
+		return colors.BrightOrange(theme.RunningGlyph())
 	case StatusPassed:
-		return colors.BrightGreen("✓")
+		return colors.BrightGreen(theme.Passed)
 	case StatusFailed:
-		return colors.BrightRed("✗")
+		return colors.BrightRed(theme.Failed)
 	case StatusSkipped:
-		return colors.BrightYellow("⊘")
+		return colors.BrightYellow(theme.Skipped)
 	case StatusConditional:
-		return colors.Gray("●")
+		return colors.Gray(theme.Pending)
 	default:
 	}
 	return ""
@@ -0,0 +1,222 @@
+package treeview
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Theme defines the glyphs used for status indicators, tree branches and output borders.
+type Theme struct {
+	Pending string
+	Running string
+	Passed  string
+	Failed  string
+	Skipped string
+
+	// Spinner frames replace the Running glyph when set.
+	Spinner []string
+
+	Branch     string // Branch to a child node, e.g. "├─ "
+	LastBranch string // Branch to the last child node, e.g. "└─ "
+	Vertical   string // Continuation below a non-last child, e.g. "│  "
+
+	BoxTopLeft     string
+	BoxTopRight    string
+	BoxBottomLeft  string
+	BoxBottomRight string
+	BoxHorizontal  string
+	BoxVertical    string
+}
+
+// UnicodeTheme is the default theme using Unicode symbols and box drawing.
+var UnicodeTheme = Theme{
+	Pending:        "●",
+	Running:        "●",
+	Passed:         "✓",
+	Failed:         "✗",
+	Skipped:        "⊘",
+	Branch:         "├─ ",
+	LastBranch:     "└─ ",
+	Vertical:       "│  ",
+	BoxTopLeft:     "┌",
+	BoxTopRight:    "┐",
+	BoxBottomLeft:  "└",
+	BoxBottomRight: "┘",
+	BoxHorizontal:  "─",
+	BoxVertical:    "│",
+}
+
+// ASCIITheme uses only ASCII characters, for terminals or fonts without Unicode support.
+var ASCIITheme = Theme{
+	Pending:        "o",
+	Running:        "*",
+	Passed:         "+",
+	Failed:         "x",
+	Skipped:        "-",
+	Branch:         "|- ",
+	LastBranch:     "`- ",
+	Vertical:       "|  ",
+	BoxTopLeft:     "+",
+	BoxTopRight:    "+",
+	BoxBottomLeft:  "+",
+	BoxBottomRight: "+",
+	BoxHorizontal:  "-",
+	BoxVertical:    "|",
+}
+
+// Themes maps theme names to themes.
+var Themes = map[string]Theme{
+	"unicode": UnicodeTheme,
+	"ascii":   ASCIITheme,
+}
+
+// Spinners maps spinner style names to their frames.
+var Spinners = map[string][]string{
+	"none":    nil,
+	"dots":    {"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"},
+	"braille": {"⣾", "⣽", "⣻", "⢿", "⡿", "⣟", "⣯", "⣷"},
+	"line":    {"-", "\\", "|", "/"},
+}
+
+// spinnerInterval is the duration each spinner frame is shown.
+const spinnerInterval = 100 * time.Millisecond
+
+var currentTheme atomic.Pointer[Theme]
+
+func init() {
+	theme := UnicodeTheme
+	currentTheme.Store(&theme)
+}
+
+// themeFile is a theme file, overriding the status glyphs of its base theme.
+type themeFile struct {
+	Theme   string    `yaml:"theme,omitempty"`   // Base theme, unicode by default
+	Spinner yaml.Node `yaml:"spinner,omitempty"` // Spinner style name, or a list of frames
+	Pending string    `yaml:"pending,omitempty"`
+	Running string    `yaml:"running,omitempty"`
+	Passed  string    `yaml:"passed,omitempty"`
+	Failed  string    `yaml:"failed,omitempty"`
+	Skipped string    `yaml:"skipped,omitempty"`
+}
+
+// NewTheme returns the named theme with the named spinner style. The
+// theme is a theme name or a YAML theme file overriding the status glyphs
+// and spinner of a theme. Empty names select the unicode theme, and the
+// spinner of a theme file or no spinner.
+func NewTheme(name, spinner string) (Theme, error) {
+	if name == "" {
+		name = "unicode"
+	}
+	theme, ok := Themes[name]
+	if !ok {
+		var err error
+		theme, err = loadTheme(name)
+		if err != nil {
+			return Theme{}, err
+		}
+	}
+
+	if spinner == "" && theme.Spinner != nil {
+		return theme, nil
+	}
+	if spinner == "" {
+		spinner = "none"
+	}
+	frames, ok := Spinners[spinner]
+	if !ok {
+		return Theme{}, fmt.Errorf("unknown spinner %q, expected one of: %s", spinner, strings.Join(sortedKeys(Spinners), ", "))
+	}
+	theme.Spinner = frames
+
+	return theme, nil
+}
+
+// loadTheme reads a theme file.
+func loadTheme(path string) (Theme, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Theme{}, fmt.Errorf("unknown theme %q, expected one of: %s, or a theme file", path, strings.Join(sortedKeys(Themes), ", "))
+		}
+		return Theme{}, err
+	}
+	var file themeFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return Theme{}, fmt.Errorf("invalid theme file %s: %w", path, err)
+	}
+	theme, ok := Themes[cmp.Or(file.Theme, "unicode")]
+	if !ok {
+		return Theme{}, fmt.Errorf("invalid theme file %s: unknown theme %q, expected one of: %s", path, file.Theme, strings.Join(sortedKeys(Themes), ", "))
+	}
+	theme.Pending = cmp.Or(file.Pending, theme.Pending)
+	theme.Running = cmp.Or(file.Running, theme.Running)
+	theme.Passed = cmp.Or(file.Passed, theme.Passed)
+	theme.Failed = cmp.Or(file.Failed, theme.Failed)
+	theme.Skipped = cmp.Or(file.Skipped, theme.Skipped)
+
+	switch file.Spinner.Kind {
+	case 0:
+	case yaml.ScalarNode:
+		frames, ok := Spinners[file.Spinner.Value]
+		if !ok {
+			return Theme{}, fmt.Errorf("invalid theme file %s: unknown spinner %q, expected one of: %s", path, file.Spinner.Value, strings.Join(sortedKeys(Spinners), ", "))
+		}
+		theme.Spinner = frames
+	default:
+		if err := file.Spinner.Decode(&theme.Spinner); err != nil {
+			return Theme{}, fmt.Errorf("invalid theme file %s: %w", path, err)
+		}
+	}
+	return theme, nil
+}
+
+// SetTheme sets the theme used by the renderer and status indicators.
+func SetTheme(theme Theme) {
+	currentTheme.Store(&theme)
+}
+
+// CurrentTheme returns the theme used by the renderer and status indicators.
+func CurrentTheme() Theme {
+	return *currentTheme.Load()
+}
+
+// RunningGlyph returns the running indicator, animating the spinner if one is set.
+func (t Theme) RunningGlyph() string {
+	if len(t.Spinner) == 0 {
+		return t.Running
+	}
+	frame := int(time.Now().UnixNano()/int64(spinnerInterval)) % len(t.Spinner)
+	return t.Spinner[frame]
+}
+
+// BranchFor returns the branch prefix for a child node.
+func (t Theme) BranchFor(isLast bool) string {
+	if isLast {
+		return t.LastBranch
+	}
+	return t.Branch
+}
+
+// ContinuationFor returns the indentation below a child node.
+func (t Theme) ContinuationFor(isLast bool) string {
+	if isLast {
+		return strings.Repeat(" ", len([]rune(t.Vertical)))
+	}
+	return t.Vertical
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,97 @@
+package treeview
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/atkins/colors"
+)
+
+func TestNewTheme(t *testing.T) {
+	theme, err := NewTheme("", "")
+	require.NoError(t, err)
+	assert.Equal(t, UnicodeTheme, theme)
+
+	theme, err = NewTheme("ascii", "line")
+	require.NoError(t, err)
+	assert.Equal(t, "+", theme.Passed)
+	assert.Equal(t, Spinners["line"], theme.Spinner)
+
+	_, err = NewTheme("fancy", "")
+	assert.ErrorContains(t, err, "unknown theme")
+
+	_, err = NewTheme("unicode", "wobble")
+	assert.ErrorContains(t, err, "unknown spinner")
+}
+
+func TestNewTheme_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "theme.yml")
+	require.NoError(t, os.WriteFile(path, []byte("theme: ascii\npassed: OK\nspinner: [a, b]\n"), 0o644))
+
+	theme, err := NewTheme(path, "")
+	require.NoError(t, err)
+	assert.Equal(t, "OK", theme.Passed)
+	assert.Equal(t, ASCIITheme.Failed, theme.Failed)
+	assert.Equal(t, []string{"a", "b"}, theme.Spinner)
+
+	// The spinner flag overrides the spinner of the theme file
+	theme, err = NewTheme(path, "none")
+	require.NoError(t, err)
+	assert.Nil(t, theme.Spinner)
+
+	require.NoError(t, os.WriteFile(path, []byte("spinner: wobble\n"), 0o644))
+	_, err = NewTheme(path, "")
+	assert.ErrorContains(t, err, `unknown spinner "wobble"`)
+}
+
+func TestTheme_RunningGlyph(t *testing.T) {
+	assert.Equal(t, "*", ASCIITheme.RunningGlyph())
+
+	theme := ASCIITheme
+	theme.Spinner = Spinners["braille"]
+	assert.Contains(t, theme.Spinner, theme.RunningGlyph())
+}
+
+func TestTheme_ContinuationFor(t *testing.T) {
+	assert.Equal(t, "│  ", UnicodeTheme.ContinuationFor(false))
+	assert.Equal(t, "   ", UnicodeTheme.ContinuationFor(true))
+	assert.Equal(t, "`- ", ASCIITheme.BranchFor(true))
+}
+
+func TestRenderer_ASCIITheme(t *testing.T) {
+	SetTheme(ASCIITheme)
+	t.Cleanup(func() {
+		SetTheme(UnicodeTheme)
+	})
+
+	root := NewNode("pipeline")
+	job := NewNode("build")
+	job.SetStatus(StatusPassed)
+	step := NewNode("run: make")
+	step.SetStatus(StatusFailed)
+	step.SetOutput([]string{"one", "two"})
+	job.AddChild(step)
+	// Names ending in a glyph of the theme still get their status
+	for _, name := range []string{"run: make fix", "run: echo +", "run: echo o"} {
+		child := NewNode(name)
+		child.SetStatus(StatusPassed)
+		job.AddChild(child)
+	}
+	root.AddChild(job)
+
+	output := colors.StripANSI(NewRenderer().Render(root))
+	for _, r := range output {
+		assert.Less(t, r, rune(128), "unexpected non-ASCII rune %q in output", r)
+	}
+	assert.True(t, strings.Contains(output, "`- build +"))
+	assert.True(t, strings.Contains(output, "|- run: make x"))
+	assert.True(t, strings.Contains(output, "|- run: make fix +"))
+	assert.True(t, strings.Contains(output, "|- run: echo + +"))
+	assert.True(t, strings.Contains(output, "`- run: echo o +"))
+	assert.True(t, strings.Contains(output, "+-----+"))
+}
@@ -57,6 +57,17 @@ func (t *Trimmer) SetViewportWidth(width int) {
 	t.viewportWidth = width
 }
 
+// MinOutputWidth is the narrowest output width passed to commands.
+const MinOutputWidth = 20
+
+// OutputWidth returns the width of the output lines of a node at depth
+// below the root, within a viewport of width columns: the indentation of
+// the tree and the borders of the output box are taken off.
+func OutputWidth(width, depth int) int {
+	indent := (depth + 1) * len([]rune(CurrentTheme().Vertical))
+	return max(width-indent-4, MinOutputWidth)
+}
+
 // argPattern matches flag=value, handling both quoted and unquoted values.
 // Matches: -flag=value, --flag="value", -flag=123, etc.
 var argPattern = regexp.MustCompile(`(-+[\w-]+=)("[^"]*"|[^\s]+)`)
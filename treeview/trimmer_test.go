@@ -5,6 +5,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/titpetric/atkins/colors"
 )
 
 func TestCompactArgs(t *testing.T) {
@@ -214,3 +216,24 @@ func stripResetCode(s string) string {
 	result = strings.ReplaceAll(result, resetCode, "")
 	return result
 }
+
+func TestOutputWidth(t *testing.T) {
+	assert.Equal(t, 70, OutputWidth(80, 1))
+	assert.Equal(t, MinOutputWidth, OutputWidth(30, 4))
+
+	// Output of the width fits the output box of a step without trimming
+	line := strings.Repeat("x", OutputWidth(80, 1))
+	step := NewNode("step")
+	step.SetOutput([]string{line, line})
+	job := NewNode("job")
+	job.AddChild(step)
+	root := NewNode("pipeline")
+	root.AddChild(job)
+
+	// Without a terminal, the viewport is 80 columns wide
+	output := NewRenderer().Render(root)
+	assert.Contains(t, output, line)
+	for _, l := range strings.Split(strings.TrimSpace(output), "\n") {
+		assert.LessOrEqual(t, colors.VisualLength(l), 80, l)
+	}
+}
@@ -1,9 +1,25 @@
 package main
 
+import "github.com/titpetric/atkins/version"
+
 // Version information injected at build time via ldflags
 var (
 	Version    = "dev"
 	Commit     = "unknown"
 	CommitTime = "unknown"
 	Branch     = "unknown"
+
+	// ReleaseKey is the base64 ed25519 public key verifying the
+	// downloads of self-update.
+	ReleaseKey = ""
 )
+
+// buildInfo returns the build information of the binary.
+func buildInfo() version.Info {
+	return version.Info{
+		Version:    Version,
+		Commit:     Commit,
+		CommitTime: CommitTime,
+		Branch:     Branch,
+	}
+}
@@ -0,0 +1,264 @@
+package version
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Release channels of atkins self-update.
+const (
+	ChannelStable = "stable" // The latest release
+	ChannelEdge   = "edge"   // The latest release or pre-release
+)
+
+// ReleasesURL is the GitHub API listing the atkins releases.
+var ReleasesURL = "https://api.github.com/repos/titpetric/atkins/releases"
+
+// Release assets besides the binaries, named atkins-<os>-<arch>.
+const (
+	ChecksumsAsset = "checksums.txt"     // sha256sum of the binaries
+	SignatureAsset = "checksums.txt.sig" // Base64 ed25519 signature of the checksums
+)
+
+var versionPattern = regexp.MustCompile(`^v?(\d+(\.\d+)*)`)
+
+// Release is a GitHub release of atkins.
+type Release struct {
+	Tag        string  `json:"tag_name"`
+	Prerelease bool    `json:"prerelease"`
+	Draft      bool    `json:"draft"`
+	Assets     []Asset `json:"assets"`
+}
+
+// Asset is a file attached to a release.
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+// Updater downloads atkins releases, verifying the signed checksums.
+type Updater struct {
+	Client    *http.Client
+	URL       string            // Releases API, ReleasesURL by default
+	PublicKey ed25519.PublicKey // Verifies the signature of the checksums
+	Token     string            // GitHub token, raises the API rate limit
+}
+
+// NewUpdater creates an Updater verifying releases with a base64 encoded
+// ed25519 public key.
+func NewUpdater(publicKey string) (*Updater, error) {
+	if publicKey == "" {
+		return nil, errors.New("this build has no release key to verify downloads with, build it with -ldflags \"-X main.ReleaseKey=<key>\"")
+	}
+	key, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, errors.New("invalid release key, expected a base64 encoded ed25519 public key")
+	}
+	return &Updater{
+		Client:    http.DefaultClient,
+		URL:       ReleasesURL,
+		PublicKey: ed25519.PublicKey(key),
+	}, nil
+}
+
+// Latest returns the latest release of the channel.
+func (u *Updater) Latest(ctx context.Context, channel string) (*Release, error) {
+	switch channel {
+	case ChannelStable:
+		var release Release
+		if err := u.getJSON(ctx, u.URL+"/latest", &release); err != nil {
+			return nil, err
+		}
+		return &release, nil
+	case ChannelEdge:
+		var releases []Release
+		if err := u.getJSON(ctx, u.URL+"?per_page=20", &releases); err != nil {
+			return nil, err
+		}
+		for _, release := range releases {
+			if !release.Draft {
+				return &release, nil
+			}
+		}
+		return nil, errors.New("no releases found")
+	}
+	return nil, fmt.Errorf("unknown channel %q, expected %s or %s", channel, ChannelStable, ChannelEdge)
+}
+
+// Download returns the binary of the release for the running platform,
+// after verifying the signature of the checksums and the checksum of
+// the binary.
+func (u *Updater) Download(ctx context.Context, release *Release) ([]byte, error) {
+	name := BinaryName(runtime.GOOS, runtime.GOARCH)
+	checksums, err := u.download(ctx, release, ChecksumsAsset)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := u.download(ctx, release, SignatureAsset)
+	if err != nil {
+		return nil, err
+	}
+	if err := VerifySignature(u.PublicKey, checksums, signature); err != nil {
+		return nil, fmt.Errorf("%s %s: %w", release.Tag, ChecksumsAsset, err)
+	}
+
+	want, err := checksum(checksums, name)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", release.Tag, ChecksumsAsset, err)
+	}
+	binary, err := u.download(ctx, release, name)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(binary)
+	if got := hex.EncodeToString(sum[:]); got != want {
+		return nil, fmt.Errorf("%s %s: checksum mismatch, expected sha256:%s, got sha256:%s", release.Tag, name, want, got)
+	}
+	return binary, nil
+}
+
+// BinaryName returns the name of the release binary for a platform.
+func BinaryName(goos, goarch string) string {
+	name := "atkins-" + goos + "-" + goarch
+	if goos == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// VerifySignature verifies a base64 encoded ed25519 signature of data.
+func VerifySignature(key ed25519.PublicKey, data, signature []byte) error {
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil || !ed25519.Verify(key, data, sig) {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
+
+// checksum returns the sha256 of name in a sha256sum file.
+func checksum(checksums []byte, name string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", fmt.Errorf("no checksum for %s", name)
+}
+
+// Replace replaces the executable at path with binary, by renaming a new
+// file over it so a failed write leaves the executable in place.
+func Replace(path string, binary []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".new-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(binary); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Compare compares the numeric components of two versions, e.g. v0.9.1
+// and 0.10, returning -1, 0 or 1. Suffixes like -rc1 are ignored. It
+// returns false if either isn't a version, e.g. a development build.
+func Compare(a, b string) (int, bool) {
+	ma, mb := versionPattern.FindStringSubmatch(a), versionPattern.FindStringSubmatch(b)
+	if ma == nil || mb == nil {
+		return 0, false
+	}
+	as, bs := strings.Split(ma[1], "."), strings.Split(mb[1], ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		switch {
+		case x < y:
+			return -1, true
+		case x > y:
+			return 1, true
+		}
+	}
+	return 0, true
+}
+
+func (u *Updater) download(ctx context.Context, release *Release, name string) ([]byte, error) {
+	for _, asset := range release.Assets {
+		if asset.Name != name {
+			continue
+		}
+		resp, err := u.get(ctx, asset.URL, "application/octet-stream")
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		return io.ReadAll(resp.Body)
+	}
+	return nil, fmt.Errorf("release %s has no %s", release.Tag, name)
+}
+
+func (u *Updater) getJSON(ctx context.Context, url string, v any) error {
+	resp, err := u.get(ctx, url, "application/vnd.github+json")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("invalid response from %s: %w", url, err)
+	}
+	return nil
+}
+
+func (u *Updater) get(ctx context.Context, url, accept string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", accept)
+	if u.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+u.Token)
+	}
+	resp, err := u.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return resp, nil
+}
@@ -0,0 +1,154 @@
+package version_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/atkins/version"
+)
+
+func TestUpdater(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	name := version.BinaryName(runtime.GOOS, runtime.GOARCH)
+	binary := []byte("new atkins")
+	sum := sha256.Sum256(binary)
+	checksums := []byte(hex.EncodeToString(sum[:]) + "  " + name + "\n")
+	sign := func(data []byte) []byte {
+		return []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(private, data)))
+	}
+	files := map[string][]byte{
+		"/download/" + name:                            binary,
+		"/download/" + version.ChecksumsAsset:          checksums,
+		"/download/" + version.SignatureAsset:          sign(checksums),
+		"/download/tampered/" + name:                   binary,
+		"/download/tampered/" + version.ChecksumsAsset: checksums,
+		"/download/tampered/" + version.SignatureAsset: sign([]byte("other")),
+		"/download/mismatch/" + name:                   []byte("tampered"),
+		"/download/mismatch/" + version.ChecksumsAsset: checksums,
+		"/download/mismatch/" + version.SignatureAsset: sign(checksums),
+	}
+
+	var server *httptest.Server
+	asset := func(dir, file string) version.Asset {
+		return version.Asset{Name: file, URL: server.URL + "/download/" + dir + file}
+	}
+	release := func(tag, dir string, prerelease bool) version.Release {
+		return version.Release{Tag: tag, Prerelease: prerelease, Assets: []version.Asset{
+			asset(dir, name), asset(dir, version.ChecksumsAsset), asset(dir, version.SignatureAsset),
+		}}
+	}
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/releases/latest":
+			_ = json.NewEncoder(w).Encode(release("v1.2.0", "", false))
+		case "/releases":
+			_ = json.NewEncoder(w).Encode([]version.Release{{Tag: "v1.4.0", Draft: true}, release("v1.3.0-rc1", "", true), release("v1.2.0", "", false)})
+		default:
+			data, ok := files[r.URL.Path]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			_, _ = w.Write(data)
+		}
+	}))
+	defer server.Close()
+
+	updater, err := version.NewUpdater(base64.StdEncoding.EncodeToString(public))
+	require.NoError(t, err)
+	updater.URL = server.URL + "/releases"
+	ctx := context.Background()
+
+	t.Run("channels", func(t *testing.T) {
+		stable, err := updater.Latest(ctx, version.ChannelStable)
+		require.NoError(t, err)
+		assert.Equal(t, "v1.2.0", stable.Tag)
+
+		edge, err := updater.Latest(ctx, version.ChannelEdge)
+		require.NoError(t, err)
+		assert.Equal(t, "v1.3.0-rc1", edge.Tag)
+
+		_, err = updater.Latest(ctx, "nightly")
+		assert.ErrorContains(t, err, `unknown channel "nightly"`)
+	})
+
+	t.Run("download", func(t *testing.T) {
+		stable, err := updater.Latest(ctx, version.ChannelStable)
+		require.NoError(t, err)
+		got, err := updater.Download(ctx, stable)
+		require.NoError(t, err)
+		assert.Equal(t, binary, got)
+
+		tampered := release("v1.2.0", "tampered/", false)
+		_, err = updater.Download(ctx, &tampered)
+		assert.ErrorContains(t, err, "invalid signature")
+
+		mismatch := release("v1.2.0", "mismatch/", false)
+		_, err = updater.Download(ctx, &mismatch)
+		assert.ErrorContains(t, err, "checksum mismatch")
+
+		missing := version.Release{Tag: "v1.2.0"}
+		_, err = updater.Download(ctx, &missing)
+		assert.ErrorContains(t, err, "release v1.2.0 has no checksums.txt")
+	})
+
+	t.Run("keys", func(t *testing.T) {
+		_, err := version.NewUpdater("")
+		assert.ErrorContains(t, err, "no release key")
+		_, err = version.NewUpdater("not a key")
+		assert.ErrorContains(t, err, "invalid release key")
+	})
+}
+
+func TestReplace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "atkins")
+	require.NoError(t, os.WriteFile(path, []byte("old"), 0o755))
+
+	require.NoError(t, version.Replace(path, []byte("new")))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "new", string(data))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o755), info.Mode().Perm())
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+		ok   bool
+	}{
+		{"v0.9.1", "0.9", 1, true},
+		{"v0.9.0", "0.9", 0, true},
+		{"v0.9.1", "0.10", -1, true},
+		{"v1.0.0-rc1", "1.0.0", 0, true},
+		{"v0.9.1-3-gabc1234-dirty", "v0.9.1", 0, true},
+		{"dev", "0.9", 0, false},
+	}
+	for _, tc := range tests {
+		got, ok := version.Compare(tc.a, tc.b)
+		assert.Equal(t, tc.want, got, tc.a+" "+tc.b)
+		assert.Equal(t, tc.ok, ok, tc.a+" "+tc.b)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/spf13/pflag"
 	"github.com/titpetric/cli"
 )
 
@@ -21,12 +22,24 @@ type Info struct {
 const Name = "Show version/build information"
 
 // NewCommand creates a new version command with build information.
-func NewCommand(info Info) *cli.Command {
+// With --check, check runs after the build information is printed.
+func NewCommand(info Info, check func() error) *cli.Command {
+	var doCheck bool
+
 	return &cli.Command{
 		Name:  "version",
 		Title: Name,
+		Bind: func(fs *pflag.FlagSet) {
+			fs.BoolVar(&doCheck, "check", false, "Warn if the pipeline requires another atkins, by its requires.atkins")
+		},
 		Run: func(ctx context.Context, args []string) error {
-			return Run(info)
+			if err := Run(info); err != nil {
+				return err
+			}
+			if doCheck && check != nil {
+				return check()
+			}
+			return nil
 		},
 	}
 }
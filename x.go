@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/pflag"
+	"github.com/titpetric/cli"
+
+	"github.com/titpetric/atkins/model"
+	"github.com/titpetric/atkins/psexec"
+	"github.com/titpetric/atkins/runner"
+	"github.com/titpetric/atkins/treeview"
+)
+
+// X provides a cli.Command running a command with the environment of a job.
+func X() *cli.Command {
+	var envOf string
+
+	return &cli.Command{
+		Name:  "x",
+		Title: "Run a command with the environment of a job",
+		Usage: func() string {
+			return "atkins x [--env-of job] -- <command...>"
+		},
+		Bind: func(fs *pflag.FlagSet) {
+			fs.StringVar(&envOf, "env-of", "", "Job whose env, vars and dir the command runs with")
+		},
+		Run: func(ctx context.Context, args []string) error {
+			if len(args) == 0 {
+				return fmt.Errorf("%s expected: x [--env-of job] -- <command...>", treeview.ErrorHeader())
+			}
+			return runX(ctx, envOf, args)
+		},
+	}
+}
+
+func runX(ctx context.Context, envOf string, args []string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+	pipelines, _, configDir, err := discoverPipelines(cwd)
+	if err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+	if len(pipelines) == 0 {
+		return fmt.Errorf("%s no pipeline found in %s", treeview.ErrorHeader(), cwd)
+	}
+
+	pipeline, job := pipelines[0], (*model.Job)(nil)
+	if envOf != "" {
+		task, err := runner.NewTaskResolver(pipelines).ResolveName(envOf, false)
+		if err != nil {
+			return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+		}
+		pipeline, job = task.Pipeline, task.Job
+	}
+
+	opts := NewOptions()
+	policy, err := loadPolicy(configDir, opts)
+	if err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+	auditLog, err := openAuditLog(opts)
+	if err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+
+	// Run the command from the project root, as the job steps do
+	if err := os.Chdir(configDir); err != nil {
+		return fmt.Errorf("%s failed to change directory to %s: %v", treeview.ErrorHeader(), configDir, err)
+	}
+
+	command := runner.CommandPipeline(pipeline, job, psexec.QuoteArgs(args))
+	err = runner.RunPipeline(ctx, command, runner.PipelineOptions{
+		Jobs:         command.JobOrder,
+		AllPipelines: pipelines,
+		Version:      Version,
+		Silent:       true,
+		Policy:       policy,
+		AuditLog:     auditLog,
+	})
+
+	// Exit with the exit code of the command, so atkins x can be used in scripts
+	var execErr runner.ExecError
+	if errors.As(err, &execErr) {
+		os.Exit(execErr.LastExitCode)
+	}
+	if err != nil {
+		return fmt.Errorf("%s %v", treeview.ErrorHeader(), err)
+	}
+	return nil
+}